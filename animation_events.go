@@ -0,0 +1,82 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+// AnimationEvent is a named point in time on an animation, such as a
+// footstep or the moment a character lets go of a thrown object, so that
+// gameplay and audio can be synchronized with the animation.
+type AnimationEvent struct {
+	// Name is the name callbacks are registered for.
+	Name string
+
+	// Animation is the name of the animation the event is on.
+	Animation string
+
+	// Time is the number of seconds into the animation the event happens.
+	Time float32
+}
+
+// AnimationEventCallback is called by an Animator when an animation plays
+// past an event.
+type AnimationEventCallback func(a *Animator, event *AnimationEvent)
+
+// AddEvent adds an event to an animation at the time in seconds.
+func (ac *AnimatorController) AddEvent(animation string, name string, time float32) {
+	ac.Events = append(ac.Events, AnimationEvent{Name: name, Animation: animation, Time: time})
+}
+
+// OnEvent registers a callback for the events with the name. Callbacks
+// registered for an empty name get all of the events.
+func (a *Animator) OnEvent(name string, callback AnimationEventCallback) {
+	a.eventCallbacks[name] = append(a.eventCallbacks[name], callback)
+}
+
+// ClearEventCallbacks removes all of the registered event callbacks.
+func (a *Animator) ClearEventCallbacks() {
+	a.eventCallbacks = make(map[string][]AnimationEventCallback)
+}
+
+// fireEvents sends the events of the current animation that happen after
+// lastTime up to time to their callbacks. Only forward playback sends events.
+func (a *Animator) fireEvents(lastTime, time float32) {
+	if len(a.eventCallbacks) == 0 {
+		return
+	}
+
+	p := &a.current
+	looped := p.state.Loop && time < lastTime
+	atEnd := !p.state.Loop && time >= p.length && lastTime < time
+	if !looped && time <= lastTime {
+		return
+	}
+
+	for i := range a.Controller.Events {
+		event := &a.Controller.Events[i]
+		if event.Animation != p.state.Animation {
+			continue
+		}
+
+		var passed bool
+		switch {
+		case looped:
+			passed = event.Time >= lastTime || event.Time < time
+		case atEnd:
+			passed = event.Time >= lastTime && event.Time <= time
+		default:
+			passed = event.Time >= lastTime && event.Time < time
+		}
+		if !passed {
+			continue
+		}
+
+		for _, callback := range a.eventCallbacks[event.Name] {
+			callback(a, event)
+		}
+		if event.Name != "" {
+			for _, callback := range a.eventCallbacks[""] {
+				callback(a, event)
+			}
+		}
+	}
+}
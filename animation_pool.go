@@ -0,0 +1,98 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/tbogdala/gombz"
+)
+
+// animationJob is a skeleton or animator queued to be animated by an
+// AnimationPool.
+type animationJob struct {
+	skeleton   *Skeleton
+	animation  *gombz.Animation
+	time       float32
+	animator   *Animator
+	frameDelta float64
+}
+
+// AnimationPool animates many skeletons across goroutines so that the
+// animation work for a frame can be done before the render pass. Jobs are
+// queued each frame and run with Run. A skeleton or animator should only be
+// queued once per Run since each one is animated on a single goroutine.
+type AnimationPool struct {
+	workers int
+	jobs    []animationJob
+}
+
+// NewAnimationPool creates a new pool that animates on the number of
+// worker goroutines. If workers is 0 or less, the number of CPUs is used.
+func NewAnimationPool(workers int) *AnimationPool {
+	pool := new(AnimationPool)
+	pool.workers = workers
+	if pool.workers <= 0 {
+		pool.workers = runtime.NumCPU()
+	}
+	return pool
+}
+
+// QueueAnimate queues the skeleton to be animated at the time as if
+// Skeleton.Animate was called.
+func (pool *AnimationPool) QueueAnimate(skel *Skeleton, animation *gombz.Animation, time float32) {
+	pool.jobs = append(pool.jobs, animationJob{skeleton: skel, animation: animation, time: time})
+}
+
+// QueueAnimator queues the animator to be updated by the time since the
+// last update in seconds as if Animator.Update was called. Animation event
+// callbacks for the animator get called on one of the worker goroutines.
+func (pool *AnimationPool) QueueAnimator(a *Animator, frameDelta float64) {
+	pool.jobs = append(pool.jobs, animationJob{animator: a, frameDelta: frameDelta})
+}
+
+// Run animates all of the queued jobs across the worker goroutines and
+// waits for them to finish. The queue is empty afterwards.
+func (pool *AnimationPool) Run() {
+	jobCount := len(pool.jobs)
+	if jobCount == 0 {
+		return
+	}
+
+	workers := pool.workers
+	if workers > jobCount {
+		workers = jobCount
+	}
+
+	// each worker takes an even share of the jobs
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		start := w * jobCount / workers
+		end := (w + 1) * jobCount / workers
+		go func(jobs []animationJob) {
+			defer wg.Done()
+			for i := range jobs {
+				jobs[i].run()
+			}
+		}(pool.jobs[start:end])
+	}
+	wg.Wait()
+
+	// clear the references so the pool doesn't hold on to them
+	for i := range pool.jobs {
+		pool.jobs[i] = animationJob{}
+	}
+	pool.jobs = pool.jobs[:0]
+}
+
+// run animates the skeleton or updates the animator of the job.
+func (job *animationJob) run() {
+	if job.animator != nil {
+		job.animator.Update(job.frameDelta)
+	} else if job.skeleton != nil && job.animation != nil {
+		job.skeleton.Animate(job.animation, job.time)
+	}
+}
@@ -0,0 +1,424 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/gombz"
+)
+
+// The comparisons an AnimatorCondition can make with a parameter.
+const (
+	// ConditionTrue passes when a bool parameter is true.
+	ConditionTrue = "true"
+
+	// ConditionFalse passes when a bool parameter is false.
+	ConditionFalse = "false"
+
+	// ConditionGreater passes when a float parameter is greater than the value.
+	ConditionGreater = "greater"
+
+	// ConditionLess passes when a float parameter is less than the value.
+	ConditionLess = "less"
+)
+
+// AnimatorCondition is a test of an Animator parameter that has to pass
+// for a transition to happen.
+type AnimatorCondition struct {
+	// Parameter is the name of the bool or float parameter to test.
+	Parameter string
+
+	// Mode is one of ConditionTrue, ConditionFalse, ConditionGreater
+	// or ConditionLess.
+	Mode string
+
+	// Value is what float parameters are compared to.
+	Value float32
+}
+
+// AnimatorTransition moves the Animator from one state to another when all
+// of its conditions pass.
+type AnimatorTransition struct {
+	// To is the name of the state to change to.
+	To string
+
+	// Conditions all have to pass for the transition to happen. A
+	// transition without any conditions needs HasExitTime.
+	Conditions []AnimatorCondition
+
+	// HasExitTime makes the transition wait until the animation has played
+	// up to ExitTime, which is a fraction of its length. For looping states
+	// the completed loops count as well, so an ExitTime of 2.5 waits for two
+	// and a half loops.
+	HasExitTime bool
+	ExitTime    float32
+
+	// BlendDuration is the number of seconds spent blending from the old
+	// state's animation into the new one.
+	BlendDuration float32
+}
+
+// AnimatorState is a state of an Animator that plays an animation.
+type AnimatorState struct {
+	// Name identifies the state for transitions.
+	Name string
+
+	// Animation is the name of the animation in the skeleton to play.
+	Animation string
+
+	// Speed multiplies the playback speed of the animation. A Speed of 0
+	// is treated as 1 so that it can be left out of data files.
+	Speed float32
+
+	// Loop makes the animation start over when it ends instead of holding
+	// the last frame.
+	Loop bool
+
+	// Transitions are checked in order each update and the first one that
+	// passes is taken.
+	Transitions []AnimatorTransition
+}
+
+// AnimatorController is the serializable definition of the states of an
+// Animator so that animation logic can be kept in data files and shared
+// between many Animator objects.
+type AnimatorController struct {
+	// DefaultState is the name of the state an Animator starts in.
+	DefaultState string
+
+	// States are the states the Animator can be in.
+	States []AnimatorState
+
+	// Events are the time-tagged events on the animations that get sent to
+	// the callbacks registered with Animator.OnEvent.
+	Events []AnimationEvent
+}
+
+// LoadAnimatorController reads an AnimatorController from a JSON file.
+func LoadAnimatorController(filepath string) (*AnimatorController, error) {
+	jsonBytes, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the animator controller file %s. %v", filepath, err)
+	}
+
+	controller := new(AnimatorController)
+	err = json.Unmarshal(jsonBytes, controller)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the animator controller file %s. %v", filepath, err)
+	}
+	return controller, nil
+}
+
+// Save writes the AnimatorController to a JSON file.
+func (ac *AnimatorController) Save(filepath string) error {
+	jsonBytes, err := json.MarshalIndent(ac, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode the animator controller. %v", err)
+	}
+
+	err = ioutil.WriteFile(filepath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the animator controller file %s. %v", filepath, err)
+	}
+	return nil
+}
+
+// GetState returns the state with the name or nil if there isn't one.
+func (ac *AnimatorController) GetState(name string) *AnimatorState {
+	for i := range ac.States {
+		if ac.States[i].Name == name {
+			return &ac.States[i]
+		}
+	}
+	return nil
+}
+
+// animatorPlayback is a state being played by an Animator.
+type animatorPlayback struct {
+	state     *AnimatorState
+	animation *gombz.Animation
+	length    float32
+	time      float32
+
+	// loops is the number of times a looping state has wrapped around.
+	loops int
+}
+
+// advance moves the playback time forward, looping or holding at the end.
+func (p *animatorPlayback) advance(frameDelta float32) {
+	speed := p.state.Speed
+	if speed == 0.0 {
+		speed = 1.0
+	}
+	p.time += frameDelta * speed
+	if p.state.Loop && p.length > 0.0 {
+		wraps := math.Floor(float64(p.time) / float64(p.length))
+		p.loops += int(math.Abs(wraps))
+		p.time = float32(float64(p.time) - wraps*float64(p.length))
+	} else if p.time > p.length {
+		p.time = p.length
+	} else if p.time < 0.0 {
+		p.time = 0.0
+	}
+}
+
+// normalizedTime returns how far through the animation the playback is
+// in the range of [0, 1].
+func (p *animatorPlayback) normalizedTime() float32 {
+	if p.length <= 0.0 {
+		return 1.0
+	}
+	return p.time / p.length
+}
+
+// exitTime returns how far through the animation the playback is like
+// normalizedTime, but adds the loops completed by a looping state so it
+// can be compared to an ExitTime of 1 or more.
+func (p *animatorPlayback) exitTime() float32 {
+	return float32(p.loops) + p.normalizedTime()
+}
+
+// ticks returns the playback time in the animation's ticks.
+func (p *animatorPlayback) ticks() float32 {
+	return p.ticksAt(p.time)
+}
+
+// ticksAt converts a time in seconds to the animation's ticks.
+func (p *animatorPlayback) ticksAt(time float32) float32 {
+	if p.animation.TicksPerSecond > 0.0 {
+		return time * p.animation.TicksPerSecond
+	}
+	return time
+}
+
+// Animator plays the animations of a Skeleton by moving between the states
+// of an AnimatorController as its parameters change, blending between the
+// animations when it changes state.
+type Animator struct {
+	// Controller is the definition of the states.
+	Controller *AnimatorController
+
+	// Skeleton is the skeleton that gets posed by Update.
+	Skeleton *Skeleton
+
+	// IKChains are solved in order by Update after the animations are
+	// applied, such as to have hands reach for a target.
+	IKChains []*TwoBoneIK
+
+	// RootMotion takes the movement of the RootMotionBone out of the
+	// animations so that it can be applied to the entity instead, which
+	// keeps the feet from sliding. An empty RootMotionBone uses the first
+	// bone without a parent. Only movement along the RootMotionAxes, in
+	// the space of the mesh, is taken out. With RootMotionRotation the
+	// turning of the bone is taken out too.
+	RootMotion         bool
+	RootMotionBone     string
+	RootMotionAxes     mgl.Vec3
+	RootMotionRotation bool
+
+	// rootMotionMove and rootMotionTurn are the root motion of the last update.
+	rootMotionMove mgl.Vec3
+	rootMotionTurn mgl.Quat
+
+	bools  map[string]bool
+	floats map[string]float32
+
+	// eventCallbacks are the callbacks for animation events by event name.
+	eventCallbacks map[string][]AnimationEventCallback
+
+	current       animatorPlayback
+	previous      animatorPlayback
+	blending      bool
+	blendTime     float32
+	blendDuration float32
+
+	pose     []BonePose
+	blendOut []BonePose
+}
+
+// NewAnimator creates an Animator for the skeleton that starts in the
+// controller's default state. An error is returned if a state refers to
+// an animation or state that doesn't exist.
+func NewAnimator(controller *AnimatorController, skeleton *Skeleton) (*Animator, error) {
+	for _, state := range controller.States {
+		if skeleton.GetAnimation(state.Animation) == nil {
+			return nil, fmt.Errorf("Failed to create the animator because the state %s uses the animation %s which the skeleton doesn't have.", state.Name, state.Animation)
+		}
+		for _, t := range state.Transitions {
+			if controller.GetState(t.To) == nil {
+				return nil, fmt.Errorf("Failed to create the animator because the state %s has a transition to the unknown state %s.", state.Name, t.To)
+			}
+		}
+	}
+
+	a := new(Animator)
+	a.Controller = controller
+	a.Skeleton = skeleton
+	a.bools = make(map[string]bool)
+	a.floats = make(map[string]float32)
+	a.eventCallbacks = make(map[string][]AnimationEventCallback)
+	a.pose = make([]BonePose, len(skeleton.Bones))
+	a.blendOut = make([]BonePose, len(skeleton.Bones))
+	a.RootMotionAxes = mgl.Vec3{1, 0, 1}
+	a.rootMotionTurn = mgl.QuatIdent()
+
+	if !a.Play(controller.DefaultState, 0.0) {
+		return nil, fmt.Errorf("Failed to create the animator because the default state %s doesn't exist.", controller.DefaultState)
+	}
+	return a, nil
+}
+
+// SetBool sets a bool parameter used by the transition conditions.
+func (a *Animator) SetBool(name string, value bool) {
+	a.bools[name] = value
+}
+
+// GetBool returns a bool parameter, which is false if it hasn't been set.
+func (a *Animator) GetBool(name string) bool {
+	return a.bools[name]
+}
+
+// SetFloat sets a float parameter used by the transition conditions.
+func (a *Animator) SetFloat(name string, value float32) {
+	a.floats[name] = value
+}
+
+// GetFloat returns a float parameter, which is 0 if it hasn't been set.
+func (a *Animator) GetFloat(name string) float32 {
+	return a.floats[name]
+}
+
+// GetCurrentState returns the name of the state being played.
+func (a *Animator) GetCurrentState() string {
+	return a.current.state.Name
+}
+
+// GetCurrentTime returns the playback time in seconds of the current state.
+func (a *Animator) GetCurrentTime() float32 {
+	return a.current.time
+}
+
+// IsBlending returns true if the animator is blending into the current
+// state from the previous one.
+func (a *Animator) IsBlending() bool {
+	return a.blending
+}
+
+// Play changes to the named state regardless of the transitions, blending
+// into it over blendDuration seconds. False is returned if the state
+// doesn't exist.
+func (a *Animator) Play(stateName string, blendDuration float32) bool {
+	state := a.Controller.GetState(stateName)
+	if state == nil {
+		return false
+	}
+
+	if a.current.state != nil && blendDuration > 0.0 {
+		a.previous = a.current
+		a.blending = true
+		a.blendTime = 0.0
+		a.blendDuration = blendDuration
+	} else {
+		a.blending = false
+	}
+
+	a.current.state = state
+	a.current.animation = a.Skeleton.GetAnimation(state.Animation)
+	a.current.length = GetAnimationLength(a.current.animation)
+	a.current.time = 0.0
+	a.current.loops = 0
+	return true
+}
+
+// Update advances the animations by frameDelta seconds, takes the first
+// transition of the current state that passes and then poses the skeleton.
+// Events of the current state's animation that were passed are sent to
+// their callbacks before the transitions are checked and the IKChains are
+// solved last. With RootMotion enabled the root bone's movement gets taken
+// out of the pose and can be read with GetRootMotion.
+func (a *Animator) Update(frameDelta float64) {
+	dt := float32(frameDelta)
+	lastTime := a.current.time
+	lastPreviousTime := a.previous.time
+	a.current.advance(dt)
+	a.fireEvents(lastTime, a.current.time)
+	if a.blending {
+		a.previous.advance(dt)
+		a.blendTime += dt
+		if a.blendTime >= a.blendDuration {
+			a.blending = false
+		}
+	}
+	if a.RootMotion {
+		a.updateRootMotion(lastTime, lastPreviousTime)
+	}
+
+	for _, t := range a.current.state.Transitions {
+		if a.canTransition(&t) {
+			a.Play(t.To, t.BlendDuration)
+			break
+		}
+	}
+
+	a.Skeleton.SamplePose(a.current.animation, a.current.ticks(), a.pose)
+	if a.RootMotion {
+		a.removeRootMotion(&a.current, a.pose)
+	}
+	if a.blending {
+		a.Skeleton.SamplePose(a.previous.animation, a.previous.ticks(), a.blendOut)
+		if a.RootMotion {
+			a.removeRootMotion(&a.previous, a.blendOut)
+		}
+		BlendPoses(a.blendOut, a.pose, a.blendTime/a.blendDuration, a.pose)
+	}
+	a.Skeleton.ApplyPose(a.pose, a.current.animation.Transform)
+
+	for _, ik := range a.IKChains {
+		a.Skeleton.SolveTwoBoneIK(ik)
+	}
+}
+
+// canTransition returns true if the exit time and all of the conditions of
+// the transition pass.
+func (a *Animator) canTransition(t *AnimatorTransition) bool {
+	if t.To == a.current.state.Name {
+		return false
+	}
+	if !t.HasExitTime && len(t.Conditions) == 0 {
+		return false
+	}
+	if t.HasExitTime && a.current.exitTime() < t.ExitTime {
+		return false
+	}
+
+	for _, c := range t.Conditions {
+		switch c.Mode {
+		case ConditionTrue:
+			if !a.bools[c.Parameter] {
+				return false
+			}
+		case ConditionFalse:
+			if a.bools[c.Parameter] {
+				return false
+			}
+		case ConditionGreater:
+			if a.floats[c.Parameter] <= c.Value {
+				return false
+			}
+		case ConditionLess:
+			if a.floats[c.Parameter] >= c.Value {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
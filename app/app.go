@@ -0,0 +1,224 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package app takes care of the setup that every fizzle application repeats:
+creating a GLFW window with an OpenGL 3.3 core context, initializing the
+graphics provider, creating a forward renderer that follows the size of the
+window and running the main loop until the window closes.
+
+Only one window is supported at a time. A minimal application looks like:
+
+	window, gfx, renderer, err := app.NewWindow(app.DefaultOptions())
+	if err != nil {
+		...
+	}
+	app.Run(func(dt float32) {
+		gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+		...
+	})
+
+*/
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	glfw "github.com/go-gl/glfw/v3.1/glfw"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	opengl "github.com/tbogdala/fizzle/graphicsprovider/opengl"
+	forward "github.com/tbogdala/fizzle/renderer/forward"
+)
+
+// GLFW event handling must run on the main OS thread, so lock the OS thread
+// for the main goroutine while the packages get initialized.
+func init() {
+	runtime.LockOSThread()
+}
+
+// Options are the settings used by NewWindow to create the window.
+type Options struct {
+	// Title is the title of the window.
+	Title string
+
+	// Width and Height are the size of the window in screen coordinates.
+	Width  int
+	Height int
+
+	// Samples is the number of samples per pixel for multisampling; 0
+	// disables it.
+	Samples int
+
+	// VSync limits the frame rate to the refresh rate of the monitor.
+	VSync bool
+
+	// Resizable allows the user to change the size of the window.
+	Resizable bool
+
+	// Fullscreen creates the window on the primary monitor in full screen
+	// mode instead of a normal window.
+	Fullscreen bool
+
+	// OnResize, if set, is called by the size callback of the window after
+	// the renderer has been resized.
+	OnResize func(width, height int)
+}
+
+// DefaultOptions returns the Options for a resizable 1280x720 window with
+// 4x multisampling and v-sync disabled.
+func DefaultOptions() Options {
+	return Options{
+		Title:     "fizzle",
+		Width:     1280,
+		Height:    720,
+		Samples:   4,
+		Resizable: true,
+	}
+}
+
+var (
+	// window is the window created by NewWindow.
+	window *glfw.Window
+
+	// renderer is the renderer created by NewWindow.
+	renderer *forward.ForwardRenderer
+
+	// onResize is the resize callback from the Options.
+	onResize func(width, height int)
+)
+
+// NewWindow initializes GLFW, creates a window with an OpenGL 3.3 core
+// context made current and initializes OpenGL, which also gets set as the
+// fizzle graphics provider. A forward renderer is created at the size of
+// the framebuffer and gets resized along with the window.
+func NewWindow(opts Options) (*glfw.Window, graphics.GraphicsProvider, *forward.ForwardRenderer, error) {
+	if window != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to create the window because only one window is supported.")
+	}
+
+	// GLFW must be initialized before it's called
+	err := glfw.Init()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to initialize GLFW.\n%v", err)
+	}
+
+	// request a OpenGL 3.3 core context
+	glfw.WindowHint(glfw.Samples, opts.Samples)
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	if opts.Resizable {
+		glfw.WindowHint(glfw.Resizable, glfw.True)
+	} else {
+		glfw.WindowHint(glfw.Resizable, glfw.False)
+	}
+
+	var monitor *glfw.Monitor
+	if opts.Fullscreen {
+		monitor = glfw.GetPrimaryMonitor()
+	}
+
+	// do the actual window creation
+	w, err := glfw.CreateWindow(opts.Width, opts.Height, opts.Title, monitor, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, nil, nil, fmt.Errorf("Failed to create the window.\n%v", err)
+	}
+	w.MakeContextCurrent()
+
+	if opts.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
+	// initialize OpenGL
+	gfx, err := opengl.InitOpenGL()
+	if err != nil {
+		w.Destroy()
+		glfw.Terminate()
+		return nil, nil, nil, fmt.Errorf("Failed to initialize OpenGL.\n%v", err)
+	}
+	fizzle.SetGraphics(gfx)
+
+	// create the renderer at the size of the framebuffer, which can differ
+	// from the window size on high DPI displays
+	fbWidth, fbHeight := w.GetFramebufferSize()
+	r := forward.NewForwardRenderer(gfx)
+	r.ChangeResolution(int32(fbWidth), int32(fbHeight))
+	w.SetFramebufferSizeCallback(onFramebufferResize)
+
+	window = w
+	renderer = r
+	onResize = opts.OnResize
+	return w, gfx, r, nil
+}
+
+// Run calls loop once per frame with the number of seconds since the last
+// frame until the window is told to close, ending the renderer's frame,
+// swapping the buffers and polling for events after each call. When the loop ends, the renderer and window
+// get destroyed and GLFW is terminated.
+func Run(loop func(dt float32)) {
+	if window == nil {
+		fizzle.Logf(fizzle.LogError, "app", "Run was called without creating a window with NewWindow.")
+		return
+	}
+	defer Shutdown()
+
+	lastFrame := time.Now()
+	for !window.ShouldClose() {
+		// calculate the difference in time since the last frame
+		thisFrame := time.Now()
+		frameDelta := float32(thisFrame.Sub(lastFrame).Seconds())
+		lastFrame = thisFrame
+
+		loop(frameDelta)
+		renderer.EndRenderFrame()
+
+		// draw the screen
+		window.SwapBuffers()
+
+		// advise GLFW to poll for input. without this the window appears to hang.
+		glfw.PollEvents()
+	}
+}
+
+// Close tells the window to close so that Run returns after the current
+// frame. It can be bound to a key to quit the application.
+func Close() {
+	if window != nil {
+		window.SetShouldClose(true)
+	}
+}
+
+// Shutdown destroys the renderer and window and terminates GLFW. Run calls
+// it when the loop ends, so it only needs to be called by applications
+// that run their own loop.
+func Shutdown() {
+	if window == nil {
+		return
+	}
+
+	renderer.Destroy()
+	window.Destroy()
+	glfw.Terminate()
+
+	window = nil
+	renderer = nil
+	onResize = nil
+}
+
+// onFramebufferResize is called by GLFW when the framebuffer of the window
+// changes size.
+func onFramebufferResize(w *glfw.Window, width int, height int) {
+	renderer.ChangeResolution(int32(width), int32(height))
+	if onResize != nil {
+		onResize(width, height)
+	}
+}
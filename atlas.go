@@ -0,0 +1,199 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// AtlasRegion is the area of one image packed into a TextureAtlas.
+type AtlasRegion struct {
+	// Name is the name the image was added to the atlas with.
+	Name string
+
+	// UV is the area of the atlas texture as {u0, v0, u1, v1} with u0, v0
+	// at the bottom-left of the image.
+	UV mgl.Vec4
+
+	// Width and Height are the size of the image in pixels.
+	Width  int32
+	Height int32
+}
+
+// TextureAtlas is a single texture holding many smaller images so that
+// they can be drawn without binding a different texture for each one.
+type TextureAtlas struct {
+	// Texture is the OpenGL texture object with all of the images.
+	Texture graphics.Texture
+
+	// Size is the width and height of the atlas texture.
+	Size int32
+
+	// Regions maps the names of the images to where they are in the atlas.
+	Regions map[string]*AtlasRegion
+}
+
+// GetRegion returns the region of the atlas for a named image and a bool
+// indicating if the image is in the atlas.
+func (atlas *TextureAtlas) GetRegion(name string) (*AtlasRegion, bool) {
+	region, okay := atlas.Regions[name]
+	return region, okay
+}
+
+// Destroy deletes the atlas texture from OpenGL.
+func (atlas *TextureAtlas) Destroy() {
+	gfx.DeleteTexture(atlas.Texture)
+}
+
+// TextureAtlasBuilder collects images and packs them into a TextureAtlas.
+type TextureAtlasBuilder struct {
+	// MaxSize is the largest the atlas texture is allowed to get. The atlas
+	// is made with the smallest power of two size that fits all of the images.
+	MaxSize int32
+
+	// Padding is the number of pixels around each image that get filled
+	// with its edge pixels to keep neighboring images from bleeding in
+	// when filtering and mipmapping.
+	Padding int32
+
+	images []atlasImage
+}
+
+// atlasImage is an image waiting to be packed into an atlas.
+type atlasImage struct {
+	name string
+	img  *image.NRGBA
+	x, y int32
+}
+
+// NewTextureAtlasBuilder creates a new atlas builder that will make atlas
+// textures no bigger than maxSize.
+func NewTextureAtlasBuilder(maxSize int32) *TextureAtlasBuilder {
+	ab := new(TextureAtlasBuilder)
+	ab.MaxSize = maxSize
+	ab.Padding = 2
+	return ab
+}
+
+// AddFile loads a PNG file and adds it to the atlas under a name.
+func (ab *TextureAtlasBuilder) AddFile(name string, filePath string) error {
+	img, err := loadFile(filePath)
+	if err != nil {
+		return err
+	}
+	ab.images = append(ab.images, atlasImage{name: name, img: img})
+	return nil
+}
+
+// AddImage adds an image to the atlas under a name.
+func (ab *TextureAtlasBuilder) AddImage(name string, img image.Image) error {
+	flipped, err := loadDecodedPNG(img)
+	if err != nil {
+		return err
+	}
+	ab.images = append(ab.images, atlasImage{name: name, img: flipped})
+	return nil
+}
+
+// atlasImagesByHeight sorts the images from tallest to shortest.
+type atlasImagesByHeight []atlasImage
+
+func (a atlasImagesByHeight) Len() int {
+	return len(a)
+}
+
+func (a atlasImagesByHeight) Less(i, j int) bool {
+	return a[i].img.Bounds().Dy() > a[j].img.Bounds().Dy()
+}
+
+func (a atlasImagesByHeight) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// pack places the images in rows on an atlas of the given size and returns
+// false if they don't all fit.
+func (ab *TextureAtlasBuilder) pack(size int32) bool {
+	var x, y, rowHeight int32
+	for i := range ab.images {
+		w := int32(ab.images[i].img.Bounds().Dx()) + ab.Padding*2
+		h := int32(ab.images[i].img.Bounds().Dy()) + ab.Padding*2
+
+		// start a new row if this image goes off the edge
+		if x+w > size {
+			x = 0
+			y += rowHeight
+			rowHeight = 0
+		}
+		if x+w > size || y+h > size {
+			return false
+		}
+
+		ab.images[i].x = x + ab.Padding
+		ab.images[i].y = y + ab.Padding
+		x += w
+		if h > rowHeight {
+			rowHeight = h
+		}
+	}
+	return true
+}
+
+// Build packs all of the images added so far into a new atlas texture with
+// mipmaps generated.
+func (ab *TextureAtlasBuilder) Build() (*TextureAtlas, error) {
+	if len(ab.images) == 0 {
+		return nil, fmt.Errorf("Failed to build the texture atlas because no images were added.")
+	}
+
+	// stable so images of the same height stay in the order they were added
+	sort.Stable(atlasImagesByHeight(ab.images))
+
+	size := int32(1)
+	for !ab.pack(size) {
+		size *= 2
+		if size > ab.MaxSize {
+			return nil, fmt.Errorf("Failed to build the texture atlas because the images don't fit in %dx%d.", ab.MaxSize, ab.MaxSize)
+		}
+	}
+
+	// copy the images in and extend their edges out into the padding; the
+	// images are already flipped so row 0 is the bottom of the texture
+	atlasImg := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	atlas := new(TextureAtlas)
+	atlas.Size = size
+	atlas.Regions = make(map[string]*AtlasRegion)
+	for _, ai := range ab.images {
+		w := int32(ai.img.Bounds().Dx())
+		h := int32(ai.img.Bounds().Dy())
+		for dy := -ab.Padding; dy < h+ab.Padding; dy++ {
+			sy := clampInt(int(dy), 0, int(h)-1)
+			for dx := -ab.Padding; dx < w+ab.Padding; dx++ {
+				sx := clampInt(int(dx), 0, int(w)-1)
+				soffset := sy*ai.img.Stride + sx*4
+				doffset := int(ai.y+dy)*atlasImg.Stride + int(ai.x+dx)*4
+				copy(atlasImg.Pix[doffset:doffset+4], ai.img.Pix[soffset:soffset+4])
+			}
+		}
+
+		fsize := float32(size)
+		region := new(AtlasRegion)
+		region.Name = ai.name
+		region.Width = w
+		region.Height = h
+		region.UV = mgl.Vec4{
+			float32(ai.x) / fsize, float32(ai.y) / fsize,
+			float32(ai.x+w) / fsize, float32(ai.y+h) / fsize,
+		}
+		atlas.Regions[ai.name] = region
+	}
+
+	atlas.Texture = LoadRGBAToTextureExt(atlasImg.Pix, size, graphics.LINEAR, graphics.LINEAR_MIPMAP_LINEAR, graphics.CLAMP_TO_EDGE, graphics.CLAMP_TO_EDGE)
+	GenerateMipmaps(atlas.Texture)
+	return atlas, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package audio plays 3D positional sounds using fizzle's math types.
+
+A Manager drives a Backend that does the actual playback, such as the one
+in the audio/openal package, so that this package doesn't need cgo. The
+Listener follows a fizzle Camera and Emitters follow Renderables, both
+getting their positions and velocities updated by Manager.Update. Sound
+emitters can also be declared in component files with SoundRef entries.
+
+A simple example:
+
+	backend, err := openal.NewBackend()
+	...
+	am := audio.NewManager(backend)
+	am.Listener.Camera = camera
+	emitters, err := am.CreateEmittersForComponent(comp, renderable)
+
+	// every frame
+	am.Update(frameDelta)
+
+Positional sounds need to be mono since backends play stereo sounds
+without any 3D effect.
+
+*/
+package audio
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Backend is the interface for the audio library that plays the sounds.
+type Backend interface {
+	// CreateBuffer uploads the samples of a sound to a new buffer.
+	CreateBuffer(s *Sound) (Buffer, error)
+
+	// CreateSource creates a new source that can play buffers.
+	CreateSource() (Source, error)
+
+	// SetListener places the listener that hears the sources.
+	SetListener(position, velocity, forward, up mgl.Vec3)
+
+	// SetListenerGain sets the master volume.
+	SetListenerGain(gain float32)
+
+	// Destroy releases the audio device.
+	Destroy()
+}
+
+// Buffer is a sound uploaded to the backend.
+type Buffer interface {
+	// Destroy releases the buffer.
+	Destroy()
+}
+
+// Source is a point in the world that plays a buffer.
+type Source interface {
+	SetBuffer(b Buffer)
+	SetPosition(position mgl.Vec3)
+	SetVelocity(velocity mgl.Vec3)
+	SetGain(gain float32)
+	SetPitch(pitch float32)
+	SetLooping(looping bool)
+
+	// SetDistances sets the distance that the source is heard at full
+	// volume and the distance after which it doesn't get any quieter.
+	SetDistances(reference, max float32)
+
+	Play()
+	Pause()
+	Stop()
+	IsPlaying() bool
+
+	// Destroy stops the source and releases it.
+	Destroy()
+}
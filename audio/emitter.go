@@ -0,0 +1,53 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package audio
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// Emitter is a source of sound in the world that can follow a Renderable.
+type Emitter struct {
+	// Name is the user identifier for the emitter.
+	Name string
+
+	// Source is the backend source playing the sound. Use it to play, stop
+	// and change the volume of the sound.
+	Source Source
+
+	// Renderable is what the emitter follows. If it is nil then Offset is
+	// the position of the emitter in world space.
+	Renderable *fizzle.Renderable
+
+	// Offset is the position of the emitter relative to the Renderable.
+	Offset mgl.Vec3
+
+	lastPosition mgl.Vec3
+	updated      bool
+}
+
+// newEmitter creates a new emitter for the source.
+func newEmitter(source Source) *Emitter {
+	e := new(Emitter)
+	e.Source = source
+	return e
+}
+
+// GetPosition returns the position of the emitter in world space.
+func (e *Emitter) GetPosition() mgl.Vec3 {
+	if e.Renderable == nil {
+		return e.Offset
+	}
+	return mgl.TransformCoordinate(e.Offset, e.Renderable.GetTransformMat4())
+}
+
+// update moves the source to the emitter's position.
+func (e *Emitter) update(frameDelta float32) {
+	position := e.GetPosition()
+	e.Source.SetPosition(position)
+	e.Source.SetVelocity(getVelocity(e.lastPosition, position, frameDelta, !e.updated))
+	e.lastPosition = position
+	e.updated = true
+}
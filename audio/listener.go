@@ -0,0 +1,48 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package audio
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// Listener hears the sounds from the position and direction of a camera.
+type Listener struct {
+	// Camera is the camera the listener follows. The listener stays put if
+	// it is nil.
+	Camera fizzle.Camera
+
+	// Gain is the master volume.
+	Gain float32
+
+	lastPosition mgl.Vec3
+	updated      bool
+}
+
+// NewListener creates a new listener at full volume.
+func NewListener() *Listener {
+	l := new(Listener)
+	l.Gain = 1.0
+	return l
+}
+
+// update places the backend's listener at the camera.
+func (l *Listener) update(backend Backend, frameDelta float32) {
+	backend.SetListenerGain(l.Gain)
+	if l.Camera == nil {
+		return
+	}
+
+	// the rows of the view matrix are the camera's axes in world space
+	view := l.Camera.GetViewMatrix()
+	up := view.Row(1).Vec3()
+	forward := view.Row(2).Vec3().Mul(-1.0)
+
+	position := l.Camera.GetPosition()
+	velocity := getVelocity(l.lastPosition, position, frameDelta, !l.updated)
+	backend.SetListener(position, velocity, forward, up)
+	l.lastPosition = position
+	l.updated = true
+}
@@ -0,0 +1,154 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package audio
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/component"
+)
+
+// Manager loads sounds and keeps the listener and emitters updated.
+type Manager struct {
+	// Backend plays the sounds.
+	Backend Backend
+
+	// Listener is where the sounds are heard from.
+	Listener *Listener
+
+	// Emitters are the emitters created by the manager.
+	Emitters []*Emitter
+
+	buffers map[string]Buffer
+}
+
+// NewManager creates a new audio manager for the backend.
+func NewManager(backend Backend) *Manager {
+	am := new(Manager)
+	am.Backend = backend
+	am.Listener = NewListener()
+	am.buffers = make(map[string]Buffer)
+	return am
+}
+
+// Destroy releases all of the emitters, the loaded sounds and the backend.
+func (am *Manager) Destroy() {
+	for _, e := range am.Emitters {
+		e.Source.Destroy()
+	}
+	am.Emitters = nil
+	for _, b := range am.buffers {
+		b.Destroy()
+	}
+	am.buffers = make(map[string]Buffer)
+	am.Backend.Destroy()
+}
+
+// LoadSound loads a WAV file into a buffer of the backend, returning the
+// buffer loaded already if the file was loaded before.
+func (am *Manager) LoadSound(filePath string) (Buffer, error) {
+	if b, okay := am.buffers[filePath]; okay {
+		return b, nil
+	}
+
+	s, err := LoadWAV(filePath)
+	if err != nil {
+		return nil, err
+	}
+	b, err := am.Backend.CreateBuffer(s)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the buffer for %s: %v", filePath, err)
+	}
+	am.buffers[filePath] = b
+	return b, nil
+}
+
+// CreateEmitter creates an emitter for the sound file that gets updated by
+// the manager. It doesn't start playing.
+func (am *Manager) CreateEmitter(filePath string) (*Emitter, error) {
+	b, err := am.LoadSound(filePath)
+	if err != nil {
+		return nil, err
+	}
+	source, err := am.Backend.CreateSource()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the source for %s: %v", filePath, err)
+	}
+	source.SetBuffer(b)
+
+	e := newEmitter(source)
+	am.Emitters = append(am.Emitters, e)
+	return e, nil
+}
+
+// CreateEmittersForComponent creates an emitter for each of the component's
+// sounds attached to the Renderable, which should be an instance of the
+// component, and starts the ones set to autoplay.
+func (am *Manager) CreateEmittersForComponent(comp *component.Component, r *fizzle.Renderable) ([]*Emitter, error) {
+	var emitters []*Emitter
+	for i, ref := range comp.Sounds {
+		e, err := am.CreateEmitter(comp.GetFullSoundPath(i))
+		if err != nil {
+			for _, created := range emitters {
+				am.DestroyEmitter(created)
+			}
+			return nil, err
+		}
+
+		e.Name = ref.Name
+		e.Renderable = r
+		e.Offset = ref.Offset
+		gain, pitch := ref.Gain, ref.Pitch
+		if gain == 0.0 {
+			gain = 1.0
+		}
+		if pitch == 0.0 {
+			pitch = 1.0
+		}
+		e.Source.SetGain(gain)
+		e.Source.SetPitch(pitch)
+		e.Source.SetLooping(ref.Looping)
+		if ref.ReferenceDistance > 0.0 || ref.MaxDistance > 0.0 {
+			e.Source.SetDistances(ref.ReferenceDistance, ref.MaxDistance)
+		}
+		e.update(0.0)
+		if ref.Autoplay {
+			e.Source.Play()
+		}
+		emitters = append(emitters, e)
+	}
+	return emitters, nil
+}
+
+// DestroyEmitter stops the emitter and removes it from the manager.
+func (am *Manager) DestroyEmitter(e *Emitter) {
+	for i, other := range am.Emitters {
+		if other == e {
+			am.Emitters = append(am.Emitters[:i], am.Emitters[i+1:]...)
+			break
+		}
+	}
+	e.Source.Destroy()
+}
+
+// Update moves the listener to the camera and the emitters to their
+// Renderables. frameDelta is the time in seconds since the last update and
+// is used to calculate the velocities for the doppler effect.
+func (am *Manager) Update(frameDelta float32) {
+	am.Listener.update(am.Backend, frameDelta)
+	for _, e := range am.Emitters {
+		e.update(frameDelta)
+	}
+}
+
+// getVelocity returns the velocity between two positions frameDelta
+// seconds apart, or zero for the first update.
+func getVelocity(last, current mgl.Vec3, frameDelta float32, first bool) mgl.Vec3 {
+	if first || frameDelta <= 0.0 {
+		return mgl.Vec3{}
+	}
+	return current.Sub(last).Mul(1.0 / frameDelta)
+}
@@ -0,0 +1,182 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package openal is an audio.Backend that plays sounds with OpenAL through
+golang.org/x/mobile/exp/audio/al, which needs cgo and the OpenAL library.
+
+*/
+package openal
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle/audio"
+	"golang.org/x/mobile/exp/audio/al"
+)
+
+// source parameters that the al package doesn't wrap
+const (
+	alPitch             = 0x1003
+	alLooping           = 0x1007
+	alBuffer            = 0x1009
+	alReferenceDistance = 0x1020
+	alMaxDistance       = 0x1023
+)
+
+// Backend plays sounds with OpenAL.
+type Backend struct{}
+
+// NewBackend opens the default OpenAL device.
+func NewBackend() (*Backend, error) {
+	if err := al.OpenDevice(); err != nil {
+		return nil, fmt.Errorf("Failed to open the OpenAL device: %v", err)
+	}
+	return new(Backend), nil
+}
+
+// Destroy closes the OpenAL device.
+func (b *Backend) Destroy() {
+	al.CloseDevice()
+}
+
+// CreateBuffer uploads the samples of a sound to a new OpenAL buffer.
+func (b *Backend) CreateBuffer(s *audio.Sound) (audio.Buffer, error) {
+	var format uint32
+	switch {
+	case s.Channels == 1 && s.BitsPerSample == 8:
+		format = al.FormatMono8
+	case s.Channels == 1 && s.BitsPerSample == 16:
+		format = al.FormatMono16
+	case s.Channels == 2 && s.BitsPerSample == 8:
+		format = al.FormatStereo8
+	case s.Channels == 2 && s.BitsPerSample == 16:
+		format = al.FormatStereo16
+	default:
+		return nil, fmt.Errorf("Failed to create the buffer. %d channels of %d bit samples is not supported.", s.Channels, s.BitsPerSample)
+	}
+
+	buffers := al.GenBuffers(1)
+	buffers[0].BufferData(format, s.Data, int32(s.SampleRate))
+	if code := al.Error(); code != 0 {
+		al.DeleteBuffers(buffers...)
+		return nil, fmt.Errorf("Failed to upload the buffer data. OpenAL error 0x%x.", code)
+	}
+	return &Buffer{buffers[0]}, nil
+}
+
+// CreateSource creates a new OpenAL source.
+func (b *Backend) CreateSource() (audio.Source, error) {
+	sources := al.GenSources(1)
+	if code := al.Error(); code != 0 {
+		return nil, fmt.Errorf("Failed to generate the source. OpenAL error 0x%x.", code)
+	}
+	return &Source{sources[0]}, nil
+}
+
+// SetListener places the OpenAL listener.
+func (b *Backend) SetListener(position, velocity, forward, up mgl.Vec3) {
+	al.SetListenerPosition(al.Vector(position))
+	al.SetListenerVelocity(al.Vector(velocity))
+	al.SetListenerOrientation(al.Orientation{
+		Forward: al.Vector(forward),
+		Up:      al.Vector(up),
+	})
+}
+
+// SetListenerGain sets the gain of the OpenAL listener.
+func (b *Backend) SetListenerGain(gain float32) {
+	al.SetListenerGain(gain)
+}
+
+// Buffer is an OpenAL buffer.
+type Buffer struct {
+	buffer al.Buffer
+}
+
+// Destroy deletes the OpenAL buffer.
+func (b *Buffer) Destroy() {
+	al.DeleteBuffers(b.buffer)
+}
+
+// Source is an OpenAL source.
+type Source struct {
+	source al.Source
+}
+
+// SetBuffer sets the buffer the source plays.
+func (s *Source) SetBuffer(b audio.Buffer) {
+	alBuf, okay := b.(*Buffer)
+	if !okay {
+		return
+	}
+	s.source.Seti(alBuffer, int32(alBuf.buffer))
+}
+
+// SetPosition sets the position of the source.
+func (s *Source) SetPosition(position mgl.Vec3) {
+	s.source.SetPosition(al.Vector(position))
+}
+
+// SetVelocity sets the velocity of the source.
+func (s *Source) SetVelocity(velocity mgl.Vec3) {
+	s.source.SetVelocity(al.Vector(velocity))
+}
+
+// SetGain sets the volume of the source.
+func (s *Source) SetGain(gain float32) {
+	s.source.SetGain(gain)
+}
+
+// SetPitch sets the pitch multiplier of the source.
+func (s *Source) SetPitch(pitch float32) {
+	s.source.Setf(alPitch, pitch)
+}
+
+// SetLooping sets whether or not the source repeats its buffer.
+func (s *Source) SetLooping(looping bool) {
+	var value int32
+	if looping {
+		value = 1
+	}
+	s.source.Seti(alLooping, value)
+}
+
+// SetDistances sets the reference and max distances of the source. Values
+// that are zero are left unchanged.
+func (s *Source) SetDistances(reference, max float32) {
+	if reference > 0.0 {
+		s.source.Setf(alReferenceDistance, reference)
+	}
+	if max > 0.0 {
+		s.source.Setf(alMaxDistance, max)
+	}
+}
+
+// Play starts playing the source.
+func (s *Source) Play() {
+	al.PlaySources(s.source)
+}
+
+// Pause pauses the source.
+func (s *Source) Pause() {
+	al.PauseSources(s.source)
+}
+
+// Stop stops the source.
+func (s *Source) Stop() {
+	al.StopSources(s.source)
+}
+
+// IsPlaying returns true if the source is playing.
+func (s *Source) IsPlaying() bool {
+	return s.source.State() == al.Playing
+}
+
+// Destroy stops the source and deletes it.
+func (s *Source) Destroy() {
+	al.StopSources(s.source)
+	al.DeleteSources(s.source)
+}
@@ -0,0 +1,111 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Sound is uncompressed PCM audio.
+type Sound struct {
+	// Channels is 1 for mono or 2 for stereo.
+	Channels int
+
+	// BitsPerSample is 8 or 16.
+	BitsPerSample int
+
+	// SampleRate is the number of samples per second.
+	SampleRate int
+
+	// Data is the interleaved samples, unsigned for 8 bit sounds and
+	// signed little-endian for 16 bit sounds.
+	Data []byte
+}
+
+// GetDuration returns the length of the sound in seconds.
+func (s *Sound) GetDuration() float32 {
+	frameSize := s.Channels * s.BitsPerSample / 8
+	if frameSize == 0 || s.SampleRate == 0 {
+		return 0.0
+	}
+	return float32(len(s.Data)/frameSize) / float32(s.SampleRate)
+}
+
+// LoadWAV loads a PCM WAV file.
+func LoadWAV(filePath string) (*Sound, error) {
+	wavBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the sound file %s: %v", filePath, err)
+	}
+	return DecodeWAV(bytes.NewReader(wavBytes))
+}
+
+// DecodeWAV reads a PCM WAV file with 8 or 16 bit samples.
+func DecodeWAV(r io.Reader) (*Sound, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("Failed to read the WAV header: %v", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("Failed to decode the WAV file. It is not a RIFF WAVE file.")
+	}
+
+	s := new(Sound)
+	gotFormat := false
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			return nil, fmt.Errorf("Failed to find the data of the WAV file: %v", err)
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, fmt.Errorf("Failed to decode the WAV file. The format chunk is too short.")
+			}
+			format := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return nil, fmt.Errorf("Failed to read the WAV format: %v", err)
+			}
+			if audioFormat := binary.LittleEndian.Uint16(format[0:2]); audioFormat != 1 {
+				return nil, fmt.Errorf("Failed to decode the WAV file. Only PCM is supported, not format %d.", audioFormat)
+			}
+			s.Channels = int(binary.LittleEndian.Uint16(format[2:4]))
+			s.SampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+			s.BitsPerSample = int(binary.LittleEndian.Uint16(format[14:16]))
+			if s.Channels < 1 || s.Channels > 2 || (s.BitsPerSample != 8 && s.BitsPerSample != 16) {
+				return nil, fmt.Errorf("Failed to decode the WAV file. %d channels of %d bit samples is not supported.", s.Channels, s.BitsPerSample)
+			}
+			gotFormat = true
+
+		case "data":
+			if !gotFormat {
+				return nil, fmt.Errorf("Failed to decode the WAV file. The data comes before the format.")
+			}
+			s.Data = make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, s.Data); err != nil {
+				return nil, fmt.Errorf("Failed to read the WAV data: %v", err)
+			}
+			return s, nil
+
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, chunkSize); err != nil {
+				return nil, fmt.Errorf("Failed to skip the WAV chunk %s: %v", chunkID, err)
+			}
+		}
+
+		// chunks are padded to an even size
+		if chunkSize%2 == 1 {
+			if _, err := io.CopyN(ioutil.Discard, r, 1); err != nil {
+				return nil, fmt.Errorf("Failed to read the WAV file: %v", err)
+			}
+		}
+	}
+}
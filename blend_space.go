@@ -0,0 +1,411 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/gombz"
+)
+
+// blendSpaceEpsilon is how far outside of a triangle a point can be and
+// still be treated as inside of it.
+const blendSpaceEpsilon = 0.00001
+
+// BlendSpaceSample is an animation placed at a point in a BlendSpace.
+type BlendSpaceSample struct {
+	// Animation is the name of the animation in the skeleton.
+	Animation string
+
+	// Position is where the animation is in the blend space, such as the
+	// speed and direction it moves at. Only X is used by 1D blend spaces.
+	Position mgl.Vec2
+}
+
+// BlendSpace blends between animations placed at points in a one or two
+// dimensional space of parameters, such as blending walking and running by
+// speed or strafing by direction. Two dimensional spaces get triangulated
+// and the animations at the corners of the triangle the parameters fall in
+// are blended. A BlendSpace can be shared between many BlendSpacePlayers.
+type BlendSpace struct {
+	// Dimensions is 1 or 2.
+	Dimensions int
+
+	// Samples are the animations in the blend space.
+	Samples []BlendSpaceSample
+
+	// built is true when the order or triangles are up to date with Samples.
+	built     bool
+	order     []int
+	triangles [][3]int
+}
+
+// NewBlendSpace1D creates an empty blend space along one parameter.
+func NewBlendSpace1D() *BlendSpace {
+	bs := new(BlendSpace)
+	bs.Dimensions = 1
+	return bs
+}
+
+// NewBlendSpace2D creates an empty blend space over two parameters.
+func NewBlendSpace2D() *BlendSpace {
+	bs := new(BlendSpace)
+	bs.Dimensions = 2
+	return bs
+}
+
+// AddSample places the animation at the point in the blend space. The y
+// value is ignored by 1D blend spaces.
+func (bs *BlendSpace) AddSample(animation string, x, y float32) {
+	bs.Samples = append(bs.Samples, BlendSpaceSample{animation, mgl.Vec2{x, y}})
+	bs.built = false
+}
+
+// Build sorts or triangulates the samples. It's called by GetWeights when
+// the samples have been added to, but it needs to be called again if the
+// Samples slice is changed directly.
+func (bs *BlendSpace) Build() {
+	bs.order = make([]int, len(bs.Samples))
+	for i := range bs.order {
+		bs.order[i] = i
+	}
+	for i := 1; i < len(bs.order); i++ {
+		for j := i; j > 0 && bs.Samples[bs.order[j]].Position[0] < bs.Samples[bs.order[j-1]].Position[0]; j-- {
+			bs.order[j], bs.order[j-1] = bs.order[j-1], bs.order[j]
+		}
+	}
+
+	bs.triangles = nil
+	if bs.Dimensions == 2 {
+		points := make([]mgl.Vec2, len(bs.Samples))
+		for i, s := range bs.Samples {
+			points[i] = s.Position
+		}
+		bs.triangles = triangulate(points)
+	}
+	bs.built = true
+}
+
+// GetWeights works out how much each sample contributes at the parameters
+// and writes it into the weights slice, which needs an entry for each
+// sample. The weights add up to 1. Parameters outside of the samples use
+// the closest point on the edge of the blend space.
+func (bs *BlendSpace) GetWeights(x, y float32, weights []float32) {
+	if !bs.built {
+		bs.Build()
+	}
+	for i := range weights {
+		weights[i] = 0.0
+	}
+
+	switch {
+	case len(bs.Samples) == 0:
+		return
+	case len(bs.Samples) == 1:
+		weights[0] = 1.0
+	case bs.Dimensions == 2:
+		bs.getWeights2D(mgl.Vec2{x, y}, weights)
+	default:
+		bs.getWeights1D(x, weights)
+	}
+}
+
+// getWeights1D blends the two samples on either side of x.
+func (bs *BlendSpace) getWeights1D(x float32, weights []float32) {
+	first := bs.order[0]
+	last := bs.order[len(bs.order)-1]
+	if x <= bs.Samples[first].Position[0] {
+		weights[first] = 1.0
+		return
+	}
+	if x >= bs.Samples[last].Position[0] {
+		weights[last] = 1.0
+		return
+	}
+
+	for i := 0; i < len(bs.order)-1; i++ {
+		a := bs.order[i]
+		b := bs.order[i+1]
+		ax := bs.Samples[a].Position[0]
+		bx := bs.Samples[b].Position[0]
+		if x > bx {
+			continue
+		}
+		if bx-ax < blendSpaceEpsilon {
+			weights[b] = 1.0
+			return
+		}
+		t := (x - ax) / (bx - ax)
+		weights[a] = 1.0 - t
+		weights[b] = t
+		return
+	}
+}
+
+// getWeights2D blends the corners of the triangle the point is in, or the
+// ends of the closest edge if it's outside of all of them.
+func (bs *BlendSpace) getWeights2D(p mgl.Vec2, weights []float32) {
+	for _, tri := range bs.triangles {
+		a := bs.Samples[tri[0]].Position
+		b := bs.Samples[tri[1]].Position
+		c := bs.Samples[tri[2]].Position
+		u, v, w, okay := barycentric(p, a, b, c)
+		if okay && u >= -blendSpaceEpsilon && v >= -blendSpaceEpsilon && w >= -blendSpaceEpsilon {
+			weights[tri[0]] = u
+			weights[tri[1]] = v
+			weights[tri[2]] = w
+			return
+		}
+	}
+
+	// the point is outside, so use the closest edge of the triangles or of
+	// every pair of samples if they all fall on a line
+	var edges [][2]int
+	for _, tri := range bs.triangles {
+		edges = append(edges, [2]int{tri[0], tri[1]}, [2]int{tri[1], tri[2]}, [2]int{tri[2], tri[0]})
+	}
+	if len(edges) == 0 {
+		for i := range bs.Samples {
+			for j := i + 1; j < len(bs.Samples); j++ {
+				edges = append(edges, [2]int{i, j})
+			}
+		}
+	}
+
+	bestDist := float32(math.MaxFloat32)
+	var best [2]int
+	var bestT float32
+	for _, e := range edges {
+		a := bs.Samples[e[0]].Position
+		b := bs.Samples[e[1]].Position
+		ab := b.Sub(a)
+		var t float32
+		if lenSq := ab.Dot(ab); lenSq > blendSpaceEpsilon {
+			t = mgl.Clamp(p.Sub(a).Dot(ab)/lenSq, 0.0, 1.0)
+		}
+		dist := p.Sub(a.Add(ab.Mul(t))).Len()
+		if dist < bestDist {
+			bestDist = dist
+			best = e
+			bestT = t
+		}
+	}
+	weights[best[0]] = 1.0 - bestT
+	weights[best[1]] += bestT
+}
+
+// barycentric returns the weights of the corners of the triangle for the
+// point and false if the triangle has no area.
+func barycentric(p, a, b, c mgl.Vec2) (float32, float32, float32, bool) {
+	v0 := b.Sub(a)
+	v1 := c.Sub(a)
+	v2 := p.Sub(a)
+	denom := v0[0]*v1[1] - v1[0]*v0[1]
+	if float32(math.Abs(float64(denom))) < blendSpaceEpsilon {
+		return 0.0, 0.0, 0.0, false
+	}
+	v := (v2[0]*v1[1] - v1[0]*v2[1]) / denom
+	w := (v0[0]*v2[1] - v2[0]*v0[1]) / denom
+	return 1.0 - v - w, v, w, true
+}
+
+// triangulate returns the Delaunay triangulation of the points as indexes
+// into the points slice using the Bowyer-Watson algorithm.
+func triangulate(points []mgl.Vec2) [][3]int {
+	if len(points) < 3 {
+		return nil
+	}
+
+	// start with a triangle around all of the points
+	lo := points[0]
+	hi := points[0]
+	for _, p := range points {
+		lo = mgl.Vec2{float32(math.Min(float64(lo[0]), float64(p[0]))), float32(math.Min(float64(lo[1]), float64(p[1])))}
+		hi = mgl.Vec2{float32(math.Max(float64(hi[0]), float64(p[0]))), float32(math.Max(float64(hi[1]), float64(p[1])))}
+	}
+	size := float32(math.Max(float64(hi[0]-lo[0]), float64(hi[1]-lo[1]))) + 1.0
+	center := lo.Add(hi).Mul(0.5)
+	n := len(points)
+	all := make([]mgl.Vec2, n, n+3)
+	copy(all, points)
+	all = append(all,
+		mgl.Vec2{center[0] - 20.0*size, center[1] - size},
+		mgl.Vec2{center[0], center[1] + 20.0*size},
+		mgl.Vec2{center[0] + 20.0*size, center[1] - size})
+	triangles := [][3]int{{n, n + 1, n + 2}}
+
+	for pi := 0; pi < n; pi++ {
+		p := all[pi]
+
+		// remove the triangles whose circumcircle holds the point and keep
+		// the edges of the hole they leave
+		var edges [][2]int
+		kept := triangles[:0]
+		for _, tri := range triangles {
+			if inCircumcircle(p, all[tri[0]], all[tri[1]], all[tri[2]]) {
+				edges = append(edges, [2]int{tri[0], tri[1]}, [2]int{tri[1], tri[2]}, [2]int{tri[2], tri[0]})
+			} else {
+				kept = append(kept, tri)
+			}
+		}
+		triangles = kept
+
+		// fill the hole with triangles from its outside edges to the point
+		for i, e := range edges {
+			shared := false
+			for j, o := range edges {
+				if i != j && ((e[0] == o[0] && e[1] == o[1]) || (e[0] == o[1] && e[1] == o[0])) {
+					shared = true
+					break
+				}
+			}
+			if !shared {
+				triangles = append(triangles, [3]int{e[0], e[1], pi})
+			}
+		}
+	}
+
+	// drop the triangles that use the corners of the starting triangle or
+	// have no area
+	result := make([][3]int, 0, len(triangles))
+	for _, tri := range triangles {
+		if tri[0] >= n || tri[1] >= n || tri[2] >= n {
+			continue
+		}
+		if _, _, _, okay := barycentric(all[tri[0]], all[tri[0]], all[tri[1]], all[tri[2]]); !okay {
+			continue
+		}
+		result = append(result, tri)
+	}
+	return result
+}
+
+// inCircumcircle returns true if the point is inside the circle through the
+// corners of the triangle.
+func inCircumcircle(p, a, b, c mgl.Vec2) bool {
+	ax, ay := float64(a[0]-p[0]), float64(a[1]-p[1])
+	bx, by := float64(b[0]-p[0]), float64(b[1]-p[1])
+	cx, cy := float64(c[0]-p[0]), float64(c[1]-p[1])
+	det := (ax*ax+ay*ay)*(bx*cy-cx*by) - (bx*bx+by*by)*(ax*cy-cx*ay) + (cx*cx+cy*cy)*(ax*by-bx*ay)
+
+	// the sign flips with the winding of the triangle
+	orientation := (bx-ax)*(cy-ay) - (by-ay)*(cx-ax)
+	if orientation < 0.0 {
+		return det < 0.0
+	}
+	return det > 0.0
+}
+
+// BlendSpacePlayer plays a BlendSpace on a Skeleton. The animations are
+// kept in step with each other by playing them all at the same fraction of
+// their lengths, so a blended walk and run keep their feet together.
+type BlendSpacePlayer struct {
+	// Space is the blend space being played.
+	Space *BlendSpace
+
+	// Skeleton is the skeleton that gets posed by Update.
+	Skeleton *Skeleton
+
+	// X and Y are the parameters of the blend space.
+	X float32
+	Y float32
+
+	animations []*gombz.Animation
+	weights    []float32
+	phase      float32
+	pose       []BonePose
+	scratch    []BonePose
+}
+
+// NewBlendSpacePlayer creates a player of the blend space for the skeleton.
+// An error is returned if the skeleton doesn't have one of the animations.
+func NewBlendSpacePlayer(space *BlendSpace, skeleton *Skeleton) (*BlendSpacePlayer, error) {
+	bp := new(BlendSpacePlayer)
+	bp.Space = space
+	bp.Skeleton = skeleton
+	bp.animations = make([]*gombz.Animation, len(space.Samples))
+	for i, s := range space.Samples {
+		bp.animations[i] = skeleton.GetAnimation(s.Animation)
+		if bp.animations[i] == nil {
+			return nil, fmt.Errorf("Failed to create the blend space player because the skeleton doesn't have the animation %s.", s.Animation)
+		}
+	}
+	bp.weights = make([]float32, len(space.Samples))
+	bp.pose = make([]BonePose, len(skeleton.Bones))
+	bp.scratch = make([]BonePose, len(skeleton.Bones))
+	return bp, nil
+}
+
+// SetParameters sets the X and Y parameters of the blend space.
+func (bp *BlendSpacePlayer) SetParameters(x, y float32) {
+	bp.X = x
+	bp.Y = y
+}
+
+// GetWeight returns how much the sample contributed to the last update.
+func (bp *BlendSpacePlayer) GetWeight(sampleIndex int) float32 {
+	return bp.weights[sampleIndex]
+}
+
+// GetNormalizedTime returns how far through the blended animations the
+// playback is in the range of [0, 1).
+func (bp *BlendSpacePlayer) GetNormalizedTime() float32 {
+	return bp.phase
+}
+
+// GetLength returns the length in seconds of the blended animations for
+// the current parameters.
+func (bp *BlendSpacePlayer) GetLength() float32 {
+	bp.Space.GetWeights(bp.X, bp.Y, bp.weights)
+	return bp.getLength()
+}
+
+// getLength blends the lengths of the animations by the current weights.
+func (bp *BlendSpacePlayer) getLength() float32 {
+	var length float32
+	for i, w := range bp.weights {
+		length += w * GetAnimationLength(bp.animations[i])
+	}
+	return length
+}
+
+// Update advances the looping playback by frameDelta seconds and poses the
+// skeleton with the animations blended for the current parameters.
+func (bp *BlendSpacePlayer) Update(frameDelta float64) {
+	if len(bp.animations) == 0 {
+		return
+	}
+
+	bp.Space.GetWeights(bp.X, bp.Y, bp.weights)
+	if length := bp.getLength(); length > 0.0 {
+		bp.phase += float32(frameDelta) / length
+		bp.phase -= float32(math.Floor(float64(bp.phase)))
+	}
+
+	// blend in each animation by its share of the weight so far, using the
+	// root transform of the animation with the most weight
+	var total float32
+	heaviest := 0
+	for i, w := range bp.weights {
+		if w <= 0.0 {
+			continue
+		}
+		if w > bp.weights[heaviest] {
+			heaviest = i
+		}
+
+		animation := bp.animations[i]
+		if total == 0.0 {
+			bp.Skeleton.SamplePose(animation, bp.phase*animation.Duration, bp.pose)
+		} else {
+			bp.Skeleton.SamplePose(animation, bp.phase*animation.Duration, bp.scratch)
+			BlendPoses(bp.pose, bp.scratch, w/(total+w), bp.pose)
+		}
+		total += w
+	}
+
+	bp.Skeleton.ApplyPose(bp.pose, bp.animations[heaviest].Transform)
+}
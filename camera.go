@@ -55,6 +55,9 @@ type OrbitCamera struct {
 	// position is the calculated position of the camera based on the target, the
 	// angle and the distance desired.
 	position mgl.Vec3
+
+	// CullMask is the bitmask of the layers of Renderables the camera sees.
+	CullMask uint32
 }
 
 // NewOrbitCamera that looks at a target at a given vertAngle and at a given distance.
@@ -65,10 +68,16 @@ func NewOrbitCamera(target mgl.Vec3, vertAngle float32, distance float32, rotati
 	cam.vertAngle = vertAngle
 	cam.distance = distance
 	cam.rotation = rotation
+	cam.CullMask = LayerAll
 	cam.generatePosition()
 	return cam
 }
 
+// GetCullMask returns the bitmask of the layers the camera sees.
+func (c *OrbitCamera) GetCullMask() uint32 {
+	return c.CullMask
+}
+
 // generatePosition calculates the position based on the data members in the camera.
 func (c *OrbitCamera) generatePosition() {
 	cVert := float32(math.Cos(float64(c.vertAngle)))
@@ -171,6 +180,9 @@ type YawPitchCamera struct {
 	// derived from camYaw and camPitch and is what is used for the camera
 	rotation mgl.Quat
 	position mgl.Vec3
+
+	// CullMask is the bitmask of the layers of Renderables the camera sees.
+	CullMask uint32
 }
 
 // NewYawPitchCamera will create a new camera at a given position with no rotations applied.
@@ -179,6 +191,7 @@ func NewYawPitchCamera(eyePosition mgl.Vec3) *YawPitchCamera {
 
 	cam := new(YawPitchCamera)
 	cam.position = eyePosition
+	cam.CullMask = LayerAll
 	cam.rotation = mgl.QuatRotate(yaw, mgl.Vec3{0.0, 1.0, 0.0})
 	return cam
 }
@@ -195,6 +208,11 @@ func (c *YawPitchCamera) GetPosition() mgl.Vec3 {
 	return c.position
 }
 
+// GetCullMask returns the bitmask of the layers the camera sees.
+func (c *YawPitchCamera) GetCullMask() uint32 {
+	return c.CullMask
+}
+
 // UpdatePosition adds delta values to the eye position vector.
 func (c *YawPitchCamera) UpdatePosition(dX, dY, dZ float32) {
 	c.position[0] += dX
@@ -330,3 +348,129 @@ func (c *YawPitchCamera) SetRotation(q mgl.Quat) {
 	c.pitch = float32(math.Atan2(float64(2.0*q.X()*q.W-2.0*q.Y()*q.Z()), float64(1.0-2.0*q.X()*q.X()-2.0*q.Z()*q.Z())))
 	c.yaw = float32(math.Asin(float64(2.0*q.X()*q.Y() + 2.0*q.Z()*q.W)))
 }
+
+// OrthoCamera is a 2D camera using an orthographic projection. The camera
+// position is at the center of the view, looking down -Z with +Y being up,
+// and the view covers width by height units at a zoom of 1. Setting the
+// position to half of the width and height puts the origin at the bottom-left,
+// which is handy for HUDs drawn in pixels.
+type OrthoCamera struct {
+	position mgl.Vec2
+	rotation float32
+	zoom     float32
+	width    float32
+	height   float32
+
+	// CullMask is the bitmask of the layers of Renderables the camera sees.
+	CullMask uint32
+}
+
+// NewOrthoCamera creates a new orthographic camera that shows width by
+// height units centered on the origin.
+func NewOrthoCamera(width, height float32) *OrthoCamera {
+	cam := new(OrthoCamera)
+	cam.zoom = 1.0
+	cam.CullMask = LayerAll
+	cam.width = width
+	cam.height = height
+	return cam
+}
+
+// GetPosition returns the position of the camera.
+func (c *OrthoCamera) GetPosition() mgl.Vec3 {
+	return mgl.Vec3{c.position[0], c.position[1], 0.0}
+}
+
+// GetCullMask returns the bitmask of the layers the camera sees.
+func (c *OrthoCamera) GetCullMask() uint32 {
+	return c.CullMask
+}
+
+// SetPosition sets the position at the center of the view.
+func (c *OrthoCamera) SetPosition(x, y float32) {
+	c.position = mgl.Vec2{x, y}
+}
+
+// UpdatePosition adds delta values to the position of the camera.
+func (c *OrthoCamera) UpdatePosition(dX, dY float32) {
+	c.position[0] += dX
+	c.position[1] += dY
+}
+
+// GetRotation returns the rotation of the view in radians.
+func (c *OrthoCamera) GetRotation() float32 {
+	return c.rotation
+}
+
+// SetRotation sets the counter-clockwise rotation of the view in radians.
+func (c *OrthoCamera) SetRotation(r float32) {
+	c.rotation = r
+}
+
+// GetZoom returns the zoom factor of the camera.
+func (c *OrthoCamera) GetZoom() float32 {
+	return c.zoom
+}
+
+// SetZoom sets the zoom factor of the camera, where 2 shows everything twice
+// as big. Values of zero or less are ignored.
+func (c *OrthoCamera) SetZoom(z float32) {
+	if z <= 0.0 {
+		return
+	}
+	c.zoom = z
+}
+
+// SetViewSize changes the width and height of the view in units at a zoom
+// of 1, which is usually done when the window is resized.
+func (c *OrthoCamera) SetViewSize(width, height float32) {
+	c.width = width
+	c.height = height
+}
+
+// GetViewMatrix returns a 4x4 matrix for the view rot/trans/scale.
+func (c *OrthoCamera) GetViewMatrix() mgl.Mat4 {
+	rotMat := mgl.HomogRotate3DZ(-c.rotation)
+	transMat := mgl.Translate3D(-c.position[0], -c.position[1], 0.0)
+	return rotMat.Mul4(transMat)
+}
+
+// GetProjectionMatrix returns the orthographic projection matrix for the
+// camera, which covers the depth range of [-1000, 1000].
+func (c *OrthoCamera) GetProjectionMatrix() mgl.Mat4 {
+	halfWidth := c.width * 0.5 / c.zoom
+	halfHeight := c.height * 0.5 / c.zoom
+	return mgl.Ortho(-halfWidth, halfWidth, -halfHeight, halfHeight, -1000.0, 1000.0)
+}
+
+// ScreenToRay returns the origin and normalized direction in world space of a
+// ray going from the camera through the screen coordinate. The coordinate is
+// in pixels with the origin at the top-left of a screen of width by height
+// pixels, which is how mouse positions are usually reported.
+func ScreenToRay(c Camera, x, y, width, height float32, projection mgl.Mat4) (mgl.Vec3, mgl.Vec3) {
+	// convert the screen coordinate to normalized device coordinates
+	ndcX := 2.0*x/width - 1.0
+	ndcY := 1.0 - 2.0*y/height
+
+	invViewProj := projection.Mul4(c.GetViewMatrix()).Inv()
+	near := invViewProj.Mul4x1(mgl.Vec4{ndcX, ndcY, -1.0, 1.0})
+	far := invViewProj.Mul4x1(mgl.Vec4{ndcX, ndcY, 1.0, 1.0})
+	nearPoint := near.Vec3().Mul(1.0 / near[3])
+	farPoint := far.Vec3().Mul(1.0 / far[3])
+
+	return nearPoint, farPoint.Sub(nearPoint).Normalize()
+}
+
+// WorldToScreen returns the screen coordinate of a point in world space as
+// seen by the camera on a screen of width by height pixels, with the origin
+// at the top-left. False is returned if the point is behind the camera.
+func WorldToScreen(c Camera, point mgl.Vec3, width, height float32, projection mgl.Mat4) (mgl.Vec2, bool) {
+	clip := projection.Mul4(c.GetViewMatrix()).Mul4x1(point.Vec4(1.0))
+	if clip[3] <= 0.0 {
+		return mgl.Vec2{}, false
+	}
+
+	ndcX := clip[0] / clip[3]
+	ndcY := clip[1] / clip[3]
+	return mgl.Vec2{(ndcX + 1.0) * 0.5 * width, (1.0 - ndcY) * 0.5 * height}, true
+}
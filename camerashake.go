@@ -0,0 +1,154 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// CameraShake generates a shaking offset for a camera view matrix. The shake
+// is driven by trauma, which is added by events like explosions and decays
+// over time, along with directional kicks for things like recoil and impacts.
+// Use it with a ShakeCamera to shake any Camera.
+type CameraShake struct {
+	// Trauma is the current amount of shake in the range of [0, 1]. The
+	// strength of the shake goes up with the square of the trauma.
+	Trauma float32
+
+	// TraumaDecay is how much trauma is removed per second.
+	TraumaDecay float32
+
+	// MaxAngle is the largest yaw, pitch and roll in radians at full trauma
+	// and MaxOffset is the largest change in position.
+	MaxAngle  float32
+	MaxOffset float32
+
+	// Frequency is how fast the shake moves around.
+	Frequency float32
+
+	// KickDecay is roughly how many seconds it takes for a kick to settle.
+	KickDecay float32
+
+	kick    mgl.Vec3
+	runtime float32
+}
+
+// NewCameraShake creates a new camera shake with reasonable default settings.
+func NewCameraShake() *CameraShake {
+	shake := new(CameraShake)
+	shake.TraumaDecay = 1.0
+	shake.MaxAngle = mgl.DegToRad(5.0)
+	shake.MaxOffset = 0.1
+	shake.Frequency = 15.0
+	shake.KickDecay = 0.15
+	return shake
+}
+
+// AddTrauma increases the trauma of the shake, capping it at 1.
+func (s *CameraShake) AddTrauma(amount float32) {
+	s.Trauma = mgl.Clamp(s.Trauma+amount, 0.0, 1.0)
+}
+
+// Kick pushes the camera in a direction in camera space, where -Z is
+// forward and +Y is up, that then settles back over time.
+func (s *CameraShake) Kick(direction mgl.Vec3, strength float32) {
+	if direction.Len() == 0.0 {
+		return
+	}
+	s.kick = s.kick.Add(direction.Normalize().Mul(strength))
+}
+
+// Update advances the shake and decays the trauma and kicks.
+func (s *CameraShake) Update(frameDelta float32) {
+	s.runtime += frameDelta
+	s.Trauma = mgl.Clamp(s.Trauma-s.TraumaDecay*frameDelta, 0.0, 1.0)
+
+	if s.KickDecay <= 0.0 {
+		s.kick = mgl.Vec3{}
+	} else {
+		s.kick = s.kick.Mul(float32(math.Exp(float64(-frameDelta / s.KickDecay))))
+	}
+}
+
+// GetOffset returns the rotation and translation in camera space of the
+// shake at the current time.
+func (s *CameraShake) GetOffset() (mgl.Quat, mgl.Vec3) {
+	shake := s.Trauma * s.Trauma
+	t := s.runtime * s.Frequency
+
+	// each channel samples the noise at a different offset to keep them apart
+	yaw := s.MaxAngle * shake * shakeNoise(t)
+	pitch := s.MaxAngle * shake * shakeNoise(t+101.0)
+	roll := s.MaxAngle * shake * shakeNoise(t+211.0)
+	offset := mgl.Vec3{
+		s.MaxOffset * shake * shakeNoise(t+307.0),
+		s.MaxOffset * shake * shakeNoise(t+401.0),
+		s.MaxOffset * shake * shakeNoise(t+503.0),
+	}
+
+	rotation := mgl.AnglesToQuat(pitch, yaw, roll, mgl.XYZ)
+	return rotation, offset.Add(s.kick)
+}
+
+// Apply perturbs a view matrix by the current shake.
+func (s *CameraShake) Apply(view mgl.Mat4) mgl.Mat4 {
+	rotation, offset := s.GetOffset()
+	shakeTransform := rotation.Mat4().Mul4(mgl.Translate3D(-offset[0], -offset[1], -offset[2]))
+	return shakeTransform.Mul4(view)
+}
+
+// ShakeCamera wraps a Camera to apply one or more CameraShake objects to its
+// view matrix. ShakeCameras can wrap other ShakeCameras as well.
+type ShakeCamera struct {
+	Camera
+	Shakes []*CameraShake
+}
+
+// NewShakeCamera creates a camera that shakes the view of another camera.
+func NewShakeCamera(camera Camera, shakes ...*CameraShake) *ShakeCamera {
+	cam := new(ShakeCamera)
+	cam.Camera = camera
+	cam.Shakes = shakes
+	return cam
+}
+
+// Update advances all of the shakes for the camera.
+func (c *ShakeCamera) Update(frameDelta float32) {
+	for _, shake := range c.Shakes {
+		shake.Update(frameDelta)
+	}
+}
+
+// GetViewMatrix returns the view matrix of the wrapped camera with all
+// of the shakes applied.
+func (c *ShakeCamera) GetViewMatrix() mgl.Mat4 {
+	view := c.Camera.GetViewMatrix()
+	for _, shake := range c.Shakes {
+		view = shake.Apply(view)
+	}
+	return view
+}
+
+// shakeNoise returns smooth 1d value noise in the range of [-1, 1].
+func shakeNoise(x float32) float32 {
+	fx := float32(math.Floor(float64(x)))
+	i := int32(fx)
+	t := x - fx
+	t = t * t * (3.0 - 2.0*t)
+
+	a := shakeLatticeValue(i)
+	b := shakeLatticeValue(i + 1)
+	return a + (b-a)*t
+}
+
+// shakeLatticeValue hashes an integer to a value in [-1, 1].
+func shakeLatticeValue(i int32) float32 {
+	h := uint32(i) * 0x9e3779b1
+	h ^= h >> 15
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	return float32(h&0xffff)/32767.5 - 1.0
+}
@@ -0,0 +1,377 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package capture records the rendered frames of an application to a PNG
+sequence, an animated GIF or a video file encoded by ffmpeg.
+
+A Recorder reads back the framebuffer at the end of each frame. When the
+graphics provider supports mapping buffers, the pixels get read into a
+ring of pixel buffer objects so that the GPU copies them asynchronously
+and the CPU only maps a buffer a few frames later, once the copy is done.
+The frames are then handed to a Sink on a separate goroutine so that file
+writes and encoding don't stall the render loop.
+
+Recording can be toggled at runtime, for example from a key binding:
+
+	recorder := capture.NewRecorder()
+	...
+	func toggleRecording() {
+		if recorder.IsRecording() {
+			recorder.Stop()
+			return
+		}
+		sink, _ := capture.NewFFmpegSink("ffmpeg", "capture.mp4", w, h, 60)
+		recorder.Start(sink, w, h)
+	}
+	...
+	// after drawing the frame and before swapping buffers
+	recorder.CaptureFrame()
+
+*/
+package capture
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+const (
+	// DefaultRingSize is the default number of pixel buffer objects the
+	// Recorder cycles through.
+	DefaultRingSize = 3
+
+	// DefaultQueueSize is the default number of read back frames that can
+	// wait for the Sink to write them.
+	DefaultQueueSize = 8
+)
+
+// Sink receives the captured frames. The pixels are tightly packed RGBA
+// bytes with the bottom row first, as OpenGL reads them. The pixel slice
+// is reused after WriteFrame returns so it must not be kept.
+type Sink interface {
+	// WriteFrame writes one captured frame.
+	WriteFrame(pixels []byte, width, height int) error
+
+	// Close finishes writing the frames and releases the Sink.
+	Close() error
+}
+
+// Recorder captures the frames rendered to the framebuffer bound for
+// reading and passes them on to a Sink.
+type Recorder struct {
+	// RingSize is the number of pixel buffer objects used for asynchronous
+	// read back. A frame is handed to the Sink RingSize-1 frames after it
+	// was captured. It takes effect on the next call to Start.
+	RingSize int
+
+	// QueueSize is the number of frames that can wait to be written before
+	// CaptureFrame blocks. It takes effect on the next call to Start.
+	QueueSize int
+
+	// DropFrames makes CaptureFrame drop frames instead of blocking when
+	// the Sink falls behind, keeping the frame rate steady at the cost of
+	// gaps in the recording.
+	DropFrames bool
+
+	sink      Sink
+	width     int32
+	height    int32
+	recording bool
+	async     bool
+
+	pbos    []graphics.Buffer
+	pending []bool
+	current int
+
+	frames  chan []byte
+	free    chan []byte
+	done    sync.WaitGroup
+	errLock sync.Mutex
+	err     error
+
+	captured int
+	dropped  int
+}
+
+// NewRecorder creates a new Recorder that isn't recording yet.
+func NewRecorder() *Recorder {
+	r := new(Recorder)
+	r.RingSize = DefaultRingSize
+	r.QueueSize = DefaultQueueSize
+	return r
+}
+
+// IsRecording returns true if the Recorder is capturing frames.
+func (r *Recorder) IsRecording() bool {
+	return r.recording
+}
+
+// GetFrameCounts returns the number of frames captured and the number of
+// frames dropped since recording started.
+func (r *Recorder) GetFrameCounts() (captured int, dropped int) {
+	return r.captured, r.dropped
+}
+
+// Start begins capturing frames of width by height pixels from the lower
+// left corner of the framebuffer into the sink.
+func (r *Recorder) Start(sink Sink, width, height int32) error {
+	if r.recording {
+		return fmt.Errorf("Failed to start the recording; the recorder is already recording.")
+	}
+	if sink == nil {
+		return fmt.Errorf("Failed to start the recording; no sink was supplied.")
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("Failed to start the recording; invalid size %dx%d.", width, height)
+	}
+
+	r.sink = sink
+	r.width = width
+	r.height = height
+	r.captured = 0
+	r.dropped = 0
+	r.current = 0
+	r.err = nil
+	r.createBuffers()
+
+	queueSize := r.QueueSize
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	r.frames = make(chan []byte, queueSize)
+	r.free = make(chan []byte, queueSize+1)
+	r.done.Add(1)
+	go r.writeFrames(sink, int(width), int(height))
+
+	r.recording = true
+	return nil
+}
+
+// Stop reads back the frames still in flight, waits for the Sink to write
+// all of the frames and then closes it. It returns the first error the
+// Sink reported during the recording, if any.
+func (r *Recorder) Stop() error {
+	if !r.recording {
+		return nil
+	}
+
+	// flush the ring in capture order
+	if r.async {
+		for i := 0; i < len(r.pbos); i++ {
+			r.readBack((r.current + i) % len(r.pbos))
+		}
+	}
+	r.destroyBuffers()
+
+	close(r.frames)
+	r.done.Wait()
+	r.recording = false
+
+	closeErr := r.sink.Close()
+	r.sink = nil
+
+	if err := r.getError(); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Toggle stops the recording if it is running, otherwise it starts a new
+// recording into the Sink returned by newSink.
+func (r *Recorder) Toggle(newSink func() (Sink, error), width, height int32) error {
+	if r.recording {
+		return r.Stop()
+	}
+
+	sink, err := newSink()
+	if err != nil {
+		return fmt.Errorf("Failed to create the sink for the recording: %v", err)
+	}
+	return r.Start(sink, width, height)
+}
+
+// CaptureFrame reads back the current frame. It should be called after the
+// frame has been drawn and before the window buffers get swapped. It does
+// nothing if the Recorder isn't recording.
+func (r *Recorder) CaptureFrame() {
+	if !r.recording {
+		return
+	}
+
+	gfx := fizzle.GetGraphics()
+	r.captured++
+
+	if !r.async {
+		pixels := r.getPixelBuffer()
+		if pixels == nil {
+			r.dropped++
+			return
+		}
+		gfx.ReadPixels(0, 0, r.width, r.height, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(pixels))
+		r.queueFrame(pixels)
+		return
+	}
+
+	// the oldest pbo in the ring should be done copying by now
+	r.readBack(r.current)
+
+	gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, r.pbos[r.current])
+	gfx.ReadPixels(0, 0, r.width, r.height, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.PtrOffset(0))
+	gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, 0)
+	r.pending[r.current] = true
+
+	r.current = (r.current + 1) % len(r.pbos)
+}
+
+// Destroy stops any recording in progress.
+func (r *Recorder) Destroy() {
+	r.Stop()
+}
+
+// createBuffers creates the ring of pixel buffer objects. If the graphics
+// provider can't map them, the Recorder falls back to reading the pixels
+// synchronously.
+func (r *Recorder) createBuffers() {
+	gfx := fizzle.GetGraphics()
+	size := int(r.width * r.height * 4)
+
+	ringSize := r.RingSize
+	if ringSize < 1 {
+		ringSize = 1
+	}
+	r.pbos = make([]graphics.Buffer, ringSize)
+	r.pending = make([]bool, ringSize)
+	for i := range r.pbos {
+		r.pbos[i] = gfx.GenBuffer()
+		gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, r.pbos[i])
+		gfx.BufferData(graphics.PIXEL_PACK_BUFFER, size, nil, graphics.STREAM_READ)
+	}
+
+	// test that the buffers can be mapped before relying on them
+	r.async = gfx.MapBufferRange(graphics.PIXEL_PACK_BUFFER, 0, size, graphics.MAP_READ_BIT) != nil
+	if r.async {
+		gfx.UnmapBuffer(graphics.PIXEL_PACK_BUFFER)
+	}
+	gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, 0)
+
+	if !r.async {
+		r.destroyBuffers()
+	}
+}
+
+// destroyBuffers deletes the ring of pixel buffer objects.
+func (r *Recorder) destroyBuffers() {
+	gfx := fizzle.GetGraphics()
+	for _, pbo := range r.pbos {
+		gfx.DeleteBuffer(pbo)
+	}
+	r.pbos = nil
+	r.pending = nil
+}
+
+// readBack maps the pbo at the index in the ring, if it holds a captured
+// frame, and queues a copy of the pixels for the Sink.
+func (r *Recorder) readBack(index int) {
+	if !r.pending[index] {
+		return
+	}
+	r.pending[index] = false
+
+	pixels := r.getPixelBuffer()
+	if pixels == nil {
+		r.dropped++
+		return
+	}
+
+	gfx := fizzle.GetGraphics()
+	gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, r.pbos[index])
+	ptr := gfx.MapBufferRange(graphics.PIXEL_PACK_BUFFER, 0, len(pixels), graphics.MAP_READ_BIT)
+	if ptr == nil {
+		gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, 0)
+		r.setError(fmt.Errorf("Failed to map the pixel buffer for a captured frame."))
+		r.dropped++
+		r.releasePixelBuffer(pixels)
+		return
+	}
+	copy(pixels, (*[1 << 30]byte)(ptr)[:len(pixels):len(pixels)])
+	gfx.UnmapBuffer(graphics.PIXEL_PACK_BUFFER)
+	gfx.BindBuffer(graphics.PIXEL_PACK_BUFFER, 0)
+
+	r.queueFrame(pixels)
+}
+
+// getPixelBuffer returns a free pixel slice for a frame. It returns nil if
+// the Sink is behind and DropFrames is set.
+func (r *Recorder) getPixelBuffer() []byte {
+	select {
+	case pixels := <-r.free:
+		return pixels
+	default:
+	}
+
+	// only allocate up to the number of frames that can be in flight
+	if len(r.frames) < cap(r.frames) {
+		return make([]byte, r.width*r.height*4)
+	}
+	if r.DropFrames {
+		return nil
+	}
+	return <-r.free
+}
+
+// queueFrame passes the pixels on to the writer goroutine.
+func (r *Recorder) queueFrame(pixels []byte) {
+	if !r.DropFrames {
+		r.frames <- pixels
+		return
+	}
+
+	select {
+	case r.frames <- pixels:
+	default:
+		r.dropped++
+		r.releasePixelBuffer(pixels)
+	}
+}
+
+// releasePixelBuffer puts the pixel slice back into the free list so that
+// it can be reused for another frame.
+func (r *Recorder) releasePixelBuffer(pixels []byte) {
+	select {
+	case r.free <- pixels:
+	default:
+	}
+}
+
+// writeFrames writes the queued frames to the Sink until the frames
+// channel is closed.
+func (r *Recorder) writeFrames(sink Sink, width, height int) {
+	defer r.done.Done()
+	for pixels := range r.frames {
+		if err := sink.WriteFrame(pixels, width, height); err != nil {
+			r.setError(err)
+		}
+		r.releasePixelBuffer(pixels)
+	}
+}
+
+// setError stores the first error that happens during a recording.
+func (r *Recorder) setError(err error) {
+	r.errLock.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.errLock.Unlock()
+}
+
+// getError returns the first error that happened during a recording.
+func (r *Recorder) getError() error {
+	r.errLock.Lock()
+	defer r.errLock.Unlock()
+	return r.err
+}
@@ -0,0 +1,188 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// PNGSequenceSink writes each frame to a numbered PNG file in a directory.
+type PNGSequenceSink struct {
+	// Directory is where the PNG files get written.
+	Directory string
+
+	// Prefix is put in front of the frame number in the file names.
+	Prefix string
+
+	frame int
+}
+
+// NewPNGSequenceSink creates a new PNGSequenceSink that writes files named
+// like <prefix>00000.png into the directory, creating it if needed.
+func NewPNGSequenceSink(directory string, prefix string) (*PNGSequenceSink, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create the directory for the PNG sequence: %v", err)
+	}
+
+	s := new(PNGSequenceSink)
+	s.Directory = directory
+	s.Prefix = prefix
+	return s, nil
+}
+
+// WriteFrame writes the frame to the next PNG file in the sequence.
+func (s *PNGSequenceSink) WriteFrame(pixels []byte, width, height int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	flipRows(img.Pix, pixels, width, height)
+
+	fileName := filepath.Join(s.Directory, fmt.Sprintf("%s%05d.png", s.Prefix, s.frame))
+	s.frame++
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to create the PNG file %s: %v", fileName, err)
+	}
+	defer f.Close()
+
+	// favor speed over size since frames keep coming
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(f, img); err != nil {
+		return fmt.Errorf("Failed to encode the PNG file %s: %v", fileName, err)
+	}
+	return nil
+}
+
+// Close does nothing for a PNGSequenceSink since each file is closed after
+// it is written.
+func (s *PNGSequenceSink) Close() error {
+	return nil
+}
+
+// FFmpegSink pipes the raw frames to an ffmpeg process that encodes them.
+// The container and codec are picked by ffmpeg from the extension of the
+// output file, so a .gif file produces an animated GIF.
+type FFmpegSink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// NewFFmpegSink starts the ffmpeg executable at ffmpegPath to encode frames
+// of width by height pixels at frameRate frames per second into
+// outputFile. Any extra arguments are passed to ffmpeg before the output
+// file, for example to choose a codec.
+func NewFFmpegSink(ffmpegPath string, outputFile string, width, height int32, frameRate int, extraArgs ...string) (*FFmpegSink, error) {
+	args := []string{
+		"-y",
+		"-f", "rawvideo",
+		"-pixel_format", "rgba",
+		"-video_size", fmt.Sprintf("%dx%d", width, height),
+		"-framerate", fmt.Sprintf("%d", frameRate),
+		"-i", "-",
+		"-vf", "vflip",
+	}
+	args = append(args, extraArgs...)
+	args = append(args, outputFile)
+
+	s := new(FFmpegSink)
+	s.cmd = exec.Command(ffmpegPath, args...)
+
+	var err error
+	s.stdin, err = s.cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the pipe to ffmpeg: %v", err)
+	}
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("Failed to start ffmpeg: %v", err)
+	}
+
+	return s, nil
+}
+
+// WriteFrame writes the raw pixels to ffmpeg, which flips them.
+func (s *FFmpegSink) WriteFrame(pixels []byte, width, height int) error {
+	if _, err := s.stdin.Write(pixels); err != nil {
+		return fmt.Errorf("Failed to write the frame to ffmpeg: %v", err)
+	}
+	return nil
+}
+
+// Close closes the pipe and waits for ffmpeg to finish encoding.
+func (s *FFmpegSink) Close() error {
+	s.stdin.Close()
+	if err := s.cmd.Wait(); err != nil {
+		return fmt.Errorf("Failed to finish encoding with ffmpeg: %v", err)
+	}
+	return nil
+}
+
+// GIFSink collects the frames and encodes them as an animated GIF when it
+// is closed. Every frame is kept in memory until then, so it is best
+// suited to short clips at a small resolution.
+type GIFSink struct {
+	// OutputFile is the file the GIF gets written to.
+	OutputFile string
+
+	// Delay is the time between frames in hundredths of a second.
+	Delay int
+
+	anim gif.GIF
+}
+
+// NewGIFSink creates a new GIFSink that plays back at frameRate frames
+// per second. GIF timing only has a resolution of a hundredth of a second.
+func NewGIFSink(outputFile string, frameRate int) *GIFSink {
+	s := new(GIFSink)
+	s.OutputFile = outputFile
+	s.Delay = 1
+	if frameRate > 0 && frameRate < 100 {
+		s.Delay = 100 / frameRate
+	}
+	return s
+}
+
+// WriteFrame converts the frame to a paletted image and adds it to the
+// animation.
+func (s *GIFSink) WriteFrame(pixels []byte, width, height int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	flipRows(img.Pix, pixels, width, height)
+
+	paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, image.ZP)
+
+	s.anim.Image = append(s.anim.Image, paletted)
+	s.anim.Delay = append(s.anim.Delay, s.Delay)
+	return nil
+}
+
+// Close encodes the collected frames to the output file.
+func (s *GIFSink) Close() error {
+	f, err := os.Create(s.OutputFile)
+	if err != nil {
+		return fmt.Errorf("Failed to create the GIF file %s: %v", s.OutputFile, err)
+	}
+	defer f.Close()
+
+	if err := gif.EncodeAll(f, &s.anim); err != nil {
+		return fmt.Errorf("Failed to encode the GIF file %s: %v", s.OutputFile, err)
+	}
+	return nil
+}
+
+// flipRows copies the bottom-up rows read back from OpenGL into dst so that
+// the top row comes first.
+func flipRows(dst []byte, src []byte, width, height int) {
+	stride := width * 4
+	for y := 0; y < height; y++ {
+		copy(dst[y*stride:(y+1)*stride], src[(height-1-y)*stride:(height-y)*stride])
+	}
+}
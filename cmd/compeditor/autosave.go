@@ -0,0 +1,147 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"os"
+	"time"
+
+	gui "github.com/tbogdala/eweygewey"
+
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+const (
+	autosaveWindowID = "AutosaveRecovery"
+
+	// autosaveFileSuffix is appended to the component file name to get the
+	// name of the autosave file. It's kept next to the component file so that
+	// relative file paths in the component still resolve when restoring it.
+	autosaveFileSuffix = ".autosave"
+)
+
+var (
+	// lastAutosave is the time the component was last autosaved.
+	lastAutosave time.Time
+
+	// autosaveRecoveryPending is true while the user hasn't decided to restore
+	// or discard an autosave file left over from a previous session. Autosaving
+	// is suspended until then so that the old file isn't overwritten.
+	autosaveRecoveryPending bool
+)
+
+// getAutosaveFilepath returns the path of the autosave file for the
+// component file currently being edited.
+func getAutosaveFilepath() string {
+	return flagComponentFile + autosaveFileSuffix
+}
+
+// doAutosave writes the component to the autosave file if the autosave
+// interval has passed since the last time it was written.
+func doAutosave(now time.Time) {
+	if flagAutosaveInterval <= 0 || autosaveRecoveryPending {
+		return
+	}
+	if now.Sub(lastAutosave) < time.Duration(flagAutosaveInterval)*time.Second {
+		return
+	}
+
+	lastAutosave = now
+	err := doSaveComponent(&theComponent, getAutosaveFilepath())
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to autosave the component: %v", err)
+	}
+}
+
+// doRemoveAutosave deletes the autosave file for the component, which is
+// done once the component has been saved or the editor exits normally
+// without unsaved edits.
+func doRemoveAutosave() {
+	err := os.Remove(getAutosaveFilepath())
+	if err != nil && !os.IsNotExist(err) {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to remove the autosave file: %v", err)
+	}
+}
+
+// doAutosaveOnExit removes the autosave file when the editor exits normally
+// with the component saved. If there are unsaved edits the component is
+// autosaved one last time instead so that they can be restored next time.
+func doAutosaveOnExit() {
+	// leave an autosave from a previous session alone until the user decides
+	if autosaveRecoveryPending {
+		return
+	}
+	if !isComponentDirty() {
+		doRemoveAutosave()
+		return
+	}
+
+	err := doSaveComponent(&theComponent, getAutosaveFilepath())
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to autosave the component on exit: %v", err)
+	} else {
+		fizzle.Logf(fizzle.LogInfo, "compeditor", "Autosaved the unsaved component edits: %s", getAutosaveFilepath())
+	}
+}
+
+// doAutosaveOnPanic is meant to be deferred in main so that the component
+// is written to the autosave file before the editor crashes. The panic is
+// then resumed.
+func doAutosaveOnPanic() {
+	if r := recover(); r != nil {
+		err := doSaveComponent(&theComponent, getAutosaveFilepath())
+		if err != nil {
+			fizzle.Logf(fizzle.LogError, "compeditor", "Failed to autosave the component after a crash: %v", err)
+		} else {
+			fizzle.Logf(fizzle.LogInfo, "compeditor", "Autosaved the component after a crash: %s", getAutosaveFilepath())
+		}
+		panic(r)
+	}
+}
+
+// doCheckForAutosave looks for an autosave file left over from a previous
+// session and, if one exists, opens a window asking the user whether to
+// restore it or discard it.
+func doCheckForAutosave() {
+	autosaveFilepath := getAutosaveFilepath()
+	autosaveInfo, err := os.Stat(autosaveFilepath)
+	if err != nil {
+		return
+	}
+
+	autosaveRecoveryPending = true
+	recoveryWnd := uiman.NewWindow(autosaveWindowID, 0.35, 0.6, 0.3, 0.2, func(wnd *gui.Window) {
+		wnd.Text("An autosave of the component was found from")
+		wnd.StartRow()
+		wnd.Text(autosaveInfo.ModTime().Format(time.RFC1123))
+		wnd.StartRow()
+		wnd.Text("which may have unsaved changes.")
+		wnd.StartRow()
+		restore, _ := wnd.Button("autosaveRestore", "Restore")
+		discard, _ := wnd.Button("autosaveDiscard", "Discard")
+		if restore {
+			closeAllMeshWindows()
+			doLoadComponentFile(autosaveFilepath)
+			doCloseAutosaveWindow()
+		}
+		if discard {
+			doRemoveAutosave()
+			doCloseAutosaveWindow()
+		}
+	})
+	recoveryWnd.Title = "Restore Autosave"
+	recoveryWnd.ShowTitleBar = true
+	recoveryWnd.IsMoveable = true
+}
+
+// doCloseAutosaveWindow removes the autosave recovery window and resumes
+// autosaving.
+func doCloseAutosaveWindow() {
+	recoveryWnd := uiman.GetWindow(autosaveWindowID)
+	if recoveryWnd != nil {
+		uiman.RemoveWindow(recoveryWnd)
+	}
+	autosaveRecoveryPending = false
+	lastAutosave = time.Now()
+}
@@ -0,0 +1,231 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	glfw "github.com/go-gl/glfw/v3.1/glfw"
+	gui "github.com/tbogdala/eweygewey"
+
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+const (
+	commandPaletteWindowID = "CommandPalette"
+)
+
+// editorCommand is an action in the editor that can be run from the
+// command palette and, optionally, from a key binding.
+type editorCommand struct {
+	// Name is shown in the command palette and identifies the command
+	// in a key binding file.
+	Name string
+
+	// Binding is the key combination that runs the command, such as "Ctrl+S".
+	// If empty, the command can only be run from the command palette.
+	Binding string
+
+	// Action is called to run the command.
+	Action func()
+
+	// key and mods are parsed from Binding
+	key  glfw.Key
+	mods glfw.ModifierKey
+}
+
+var (
+	// editorCommands is the registry of all commands in the editor in the
+	// order they are listed in the command palette.
+	editorCommands []*editorCommand
+
+	// commandPaletteFilter is the search text typed into the command palette.
+	commandPaletteFilter string
+)
+
+// bindingKeyNames maps the key names that can be used in a binding to GLFW keys.
+var bindingKeyNames = map[string]glfw.Key{
+	"A": glfw.KeyA, "B": glfw.KeyB, "C": glfw.KeyC, "D": glfw.KeyD, "E": glfw.KeyE,
+	"F": glfw.KeyF, "G": glfw.KeyG, "H": glfw.KeyH, "I": glfw.KeyI, "J": glfw.KeyJ,
+	"K": glfw.KeyK, "L": glfw.KeyL, "M": glfw.KeyM, "N": glfw.KeyN, "O": glfw.KeyO,
+	"P": glfw.KeyP, "Q": glfw.KeyQ, "R": glfw.KeyR, "S": glfw.KeyS, "T": glfw.KeyT,
+	"U": glfw.KeyU, "V": glfw.KeyV, "W": glfw.KeyW, "X": glfw.KeyX, "Y": glfw.KeyY,
+	"Z": glfw.KeyZ,
+	"0": glfw.Key0, "1": glfw.Key1, "2": glfw.Key2, "3": glfw.Key3, "4": glfw.Key4,
+	"5": glfw.Key5, "6": glfw.Key6, "7": glfw.Key7, "8": glfw.Key8, "9": glfw.Key9,
+	"F1": glfw.KeyF1, "F2": glfw.KeyF2, "F3": glfw.KeyF3, "F4": glfw.KeyF4,
+	"F5": glfw.KeyF5, "F6": glfw.KeyF6, "F7": glfw.KeyF7, "F8": glfw.KeyF8,
+	"F9": glfw.KeyF9, "F10": glfw.KeyF10, "F11": glfw.KeyF11, "F12": glfw.KeyF12,
+	"SPACE": glfw.KeySpace, "ENTER": glfw.KeyEnter, "TAB": glfw.KeyTab,
+	"ESCAPE": glfw.KeyEscape, "DELETE": glfw.KeyDelete,
+}
+
+// bindingModNames maps the modifier names that can be used in a binding to GLFW modifiers.
+var bindingModNames = map[string]glfw.ModifierKey{
+	"CTRL":  glfw.ModControl,
+	"SHIFT": glfw.ModShift,
+	"ALT":   glfw.ModAlt,
+	"SUPER": glfw.ModSuper,
+}
+
+// registerEditorCommand adds a command to the registry. If the binding
+// cannot be parsed the command is still registered, but left unbound.
+func registerEditorCommand(name string, binding string, action func()) {
+	cmd := new(editorCommand)
+	cmd.Name = name
+	cmd.Action = action
+	err := cmd.setBinding(binding)
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to bind the command %s: %v", name, err)
+	}
+	editorCommands = append(editorCommands, cmd)
+}
+
+// initEditorCommands registers all of the editor commands with their
+// default key bindings.
+func initEditorCommands() {
+	registerEditorCommand("Save Component", "Ctrl+S", doSaveCurrentComponent)
+	registerEditorCommand("Load Component", "Ctrl+O", doReloadCurrentComponent)
+	registerEditorCommand("Paste", "Ctrl+V", doPaste)
+	registerEditorCommand("Add Mesh", "Ctrl+M", doAddMesh)
+	registerEditorCommand("Add Collider", "", func() { doAddCollider(&theComponent) })
+	registerEditorCommand("Add Child Reference", "", func() { doAddChildReference(&theComponent) })
+	registerEditorCommand("Close All Mesh Windows", "", closeAllMeshWindows)
+	registerEditorCommand("Previous Render Mode", "Ctrl+Shift+R", doPrevRenderMode)
+	registerEditorCommand("Next Render Mode", "Ctrl+R", doNextRenderMode)
+	registerEditorCommand("Toggle Grid", "Ctrl+G", func() { showGrid = !showGrid })
+	registerEditorCommand("Command Palette", "Ctrl+P", doToggleCommandPalette)
+}
+
+// loadKeyBindings reads a JSON file that maps command names to key bindings
+// and rebinds the matching commands. An empty binding unbinds the command.
+func loadKeyBindings(filepath string) error {
+	bindingsJSON, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return fmt.Errorf("Failed to read the key bindings file %s: %v", filepath, err)
+	}
+
+	var bindings map[string]string
+	err = json.Unmarshal(bindingsJSON, &bindings)
+	if err != nil {
+		return fmt.Errorf("Failed to parse the key bindings file %s: %v", filepath, err)
+	}
+
+	for name, binding := range bindings {
+		cmd := getEditorCommand(name)
+		if cmd == nil {
+			return fmt.Errorf("Failed to rebind %s: no command has that name", name)
+		}
+		err = cmd.setBinding(binding)
+		if err != nil {
+			return fmt.Errorf("Failed to rebind %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// getEditorCommand returns the command with the name or nil if there isn't one.
+func getEditorCommand(name string) *editorCommand {
+	for _, cmd := range editorCommands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// setBinding parses a binding such as "Ctrl+Shift+S" and binds the command to it.
+func (cmd *editorCommand) setBinding(binding string) error {
+	cmd.Binding = ""
+	cmd.key = glfw.KeyUnknown
+	cmd.mods = 0
+	if binding == "" {
+		return nil
+	}
+
+	parts := strings.Split(strings.ToUpper(binding), "+")
+	var mods glfw.ModifierKey
+	for _, part := range parts[:len(parts)-1] {
+		mod, okay := bindingModNames[strings.TrimSpace(part)]
+		if !okay {
+			return fmt.Errorf("unknown modifier %q in binding %q", part, binding)
+		}
+		mods |= mod
+	}
+
+	keyName := strings.TrimSpace(parts[len(parts)-1])
+	key, okay := bindingKeyNames[keyName]
+	if !okay {
+		return fmt.Errorf("unknown key %q in binding %q", keyName, binding)
+	}
+
+	cmd.Binding = binding
+	cmd.key = key
+	cmd.mods = mods
+	return nil
+}
+
+// runKeyBinding runs the command bound to the key and modifiers, returning
+// true if there was one.
+func runKeyBinding(key glfw.Key, mods glfw.ModifierKey) bool {
+	mods &= glfw.ModControl | glfw.ModShift | glfw.ModAlt | glfw.ModSuper
+	for _, cmd := range editorCommands {
+		if cmd.Binding != "" && cmd.key == key && cmd.mods == mods {
+			cmd.Action()
+			return true
+		}
+	}
+	return false
+}
+
+// doToggleCommandPalette opens the command palette, or closes it if it's
+// already open.
+func doToggleCommandPalette() {
+	paletteWnd := uiman.GetWindow(commandPaletteWindowID)
+	if paletteWnd != nil {
+		uiman.RemoveWindow(paletteWnd)
+		return
+	}
+
+	commandPaletteFilter = ""
+	paletteWnd = uiman.NewWindow(commandPaletteWindowID, 0.35, 0.85, 0.3, 0.5, func(wnd *gui.Window) {
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Search")
+		wnd.Editbox("commandPaletteFilter", &commandPaletteFilter)
+
+		wnd.Separator()
+		filter := strings.ToLower(commandPaletteFilter)
+		first := true
+		for i, cmd := range editorCommands {
+			if !strings.Contains(strings.ToLower(cmd.Name), filter) {
+				continue
+			}
+			if !first {
+				wnd.StartRow()
+			}
+			first = false
+
+			label := cmd.Name
+			if cmd.Binding != "" {
+				label = fmt.Sprintf("%s  (%s)", cmd.Name, cmd.Binding)
+			}
+			run, _ := wnd.Button(fmt.Sprintf("commandPaletteCmd%d", i), label)
+			if run {
+				uiman.RemoveWindow(wnd)
+				cmd.Action()
+				break
+			}
+		}
+	})
+	paletteWnd.Title = "Commands"
+	paletteWnd.ShowTitleBar = true
+	paletteWnd.IsMoveable = true
+	paletteWnd.AutoAdjustHeight = false
+	paletteWnd.IsScrollable = true
+	paletteWnd.ShowScrollBar = true
+}
@@ -0,0 +1,159 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gui "github.com/tbogdala/eweygewey"
+
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+const (
+	fileDialogWindowID = "FileDialog"
+)
+
+// fileDialog is a window that lets the user browse the file system
+// and pick a file with one of the allowed extensions.
+type fileDialog struct {
+	// currentDir is the absolute path of the directory being browsed.
+	currentDir string
+
+	// extensions is the list of lowercase file extensions (e.g. ".png")
+	// that can be picked. If empty, all files can be picked.
+	extensions []string
+
+	// entries is the cached directory listing for currentDir.
+	entries []os.FileInfo
+
+	// onSelect is called with the absolute file path of the picked file.
+	onSelect func(fullPath string)
+}
+
+// doShowFileDialog opens a file dialog window that starts in startDir and
+// calls onSelect with the absolute path of the file the user picked.
+// Any file dialog already open is closed first.
+func doShowFileDialog(title string, startDir string, extensions []string, onSelect func(fullPath string)) {
+	doCloseFileDialog()
+
+	fd := new(fileDialog)
+	fd.extensions = extensions
+	fd.onSelect = onSelect
+	absDir, err := filepath.Abs(startDir)
+	if err != nil {
+		absDir = startDir
+	}
+	fd.changeDirectory(absDir)
+
+	dialogWnd := uiman.NewWindow(fileDialogWindowID, 0.35, 0.85, 0.3, 0.6, func(wnd *gui.Window) {
+		goUp, _ := wnd.Button("fileDialogUp", "Up")
+		cancel, _ := wnd.Button("fileDialogCancel", "Cancel")
+		wnd.Text(fd.currentDir)
+		if goUp {
+			fd.changeDirectory(filepath.Dir(fd.currentDir))
+		}
+		if cancel {
+			doCloseFileDialog()
+			return
+		}
+
+		wnd.Separator()
+		for i, entry := range fd.entries {
+			if i > 0 {
+				wnd.StartRow()
+			}
+
+			label := entry.Name()
+			if entry.IsDir() {
+				label += "/"
+			}
+			picked, _ := wnd.Button(fmt.Sprintf("fileDialogEntry%d", i), label)
+			if !picked {
+				continue
+			}
+
+			fullPath := filepath.Join(fd.currentDir, entry.Name())
+			if entry.IsDir() {
+				fd.changeDirectory(fullPath)
+			} else {
+				doCloseFileDialog()
+				fd.onSelect(fullPath)
+			}
+			break
+		}
+	})
+	dialogWnd.Title = title
+	dialogWnd.ShowTitleBar = true
+	dialogWnd.IsMoveable = true
+	dialogWnd.AutoAdjustHeight = false
+	dialogWnd.IsScrollable = true
+	dialogWnd.ShowScrollBar = true
+}
+
+// doCloseFileDialog removes the file dialog window if it's open.
+func doCloseFileDialog() {
+	dialogWnd := uiman.GetWindow(fileDialogWindowID)
+	if dialogWnd != nil {
+		uiman.RemoveWindow(dialogWnd)
+	}
+}
+
+// changeDirectory updates the directory being browsed and caches the
+// directories and matching files contained in it.
+func (fd *fileDialog) changeDirectory(dir string) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to read the directory %s: %v", dir, err)
+		return
+	}
+
+	fd.currentDir = dir
+	fd.entries = fd.entries[:0]
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		if info.IsDir() || fd.isExtensionAllowed(info.Name()) {
+			fd.entries = append(fd.entries, info)
+		}
+	}
+}
+
+// isExtensionAllowed returns true if the file name has one of the extensions
+// the dialog was created with.
+func (fd *fileDialog) isExtensionAllowed(name string) bool {
+	if len(fd.extensions) == 0 {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range fd.extensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// getComponentRelativePath converts a file path to be relative to the
+// directory of the component file so that it can be stored in the component.
+// If a relative path cannot be made, the path is returned unchanged.
+func getComponentRelativePath(fullPath string) string {
+	prefixDir, err := filepath.Abs(getComponentPrefix())
+	if err != nil {
+		return fullPath
+	}
+
+	relPath, err := filepath.Rel(prefixDir, fullPath)
+	if err != nil {
+		return fullPath
+	}
+
+	return filepath.ToSlash(relPath)
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -21,12 +22,13 @@ import (
 	embeddedfonts "github.com/tbogdala/eweygewey/embeddedfonts"
 	guiinput "github.com/tbogdala/eweygewey/glfwinput"
 	gombz "github.com/tbogdala/gombz"
-	groggy "github.com/tbogdala/groggy"
 
 	fizzle "github.com/tbogdala/fizzle"
 	component "github.com/tbogdala/fizzle/component"
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
 	opengl "github.com/tbogdala/fizzle/graphicsprovider/opengl"
+	input "github.com/tbogdala/fizzle/input/glfwinput"
+	render "github.com/tbogdala/fizzle/renderer"
 	forward "github.com/tbogdala/fizzle/renderer/forward"
 )
 
@@ -39,10 +41,15 @@ var (
 	camera       *fizzle.OrbitCamera
 	uiman        *gui.Manager
 	renderer     *forward.ForwardRenderer
+	resizer      *render.ResizeDebouncer
 	textureMan   *fizzle.TextureManager
 
 	clearColor = gui.ColorIToV(32, 32, 32, 32)
 
+	// groundGrid is the infinite grid drawn on the ground when showGrid is set.
+	groundGrid *forward.Grid
+	showGrid   = true
+
 	shaders      map[string]*fizzle.RenderShader
 	componentMan *component.Manager
 
@@ -51,9 +58,18 @@ var (
 	theComponent     component.Component
 	childComponents  []*component.Component
 
+	// savedComponentJSON is the component as it was last loaded from or
+	// saved to the component file, which is compared against to find
+	// unsaved changes.
+	savedComponentJSON []byte
+
 	// childRefFilenames is a map of child reference filename to component name
 	childRefFilenames map[string]string
 
+	// previewTextureIndexes maps a texture to its index in the user interface
+	// texture stack so that thumbnails can be drawn for it.
+	previewTextureIndexes map[graphics.Texture]uint32
+
 	appStartTime time.Time
 	totalTime    float64
 )
@@ -65,6 +81,11 @@ const (
 
 	compMeshWindowID = "ComponentMesh"
 
+	discardChangesWindowID = "DiscardChanges"
+
+	// texturePreviewSize is the size in pixels of the texture thumbnails
+	texturePreviewSize = 64
+
 	segsInSphereWire = 32
 
 	// ui layout constants
@@ -75,22 +96,66 @@ const (
 	meshWndY  = 0.99
 )
 
+// textureFileExtensions are the file extensions that can be picked for textures.
+var textureFileExtensions = []string{".png"}
+
 // block of flags set on the command line
 var (
-	flagDesktopNumber int
-	flagComponentFile string
+	flagDesktopNumber    int
+	flagComponentFile    string
+	flagAutosaveInterval int
+	flagKeyBindingsFile  string
+	flagHotReload        bool
 )
 
 var (
 	wireframeMaterial *fizzle.Material
+
+	// clipboard holds a copy of the last mesh, collider or child reference
+	// that was copied so that it can be pasted back into the component.
+	// Only one of the members is set at a time.
+	clipboard struct {
+		Mesh     *component.Mesh
+		Collider *component.CollisionRef
+		ChildRef *component.ChildRef
+	}
+
+	// prevKeyCallback is the key callback that was set on the main window
+	// before the editor's own handler so that events can be chained.
+	prevKeyCallback glfw.KeyCallback
+
+	// cameraInput maps the keys and mouse buttons used to move the camera
+	// and to pick meshes in the viewport.
+	cameraInput *input.ActionMap
 )
 
 // meshRenderable is used to tie together state for the component mesh,
 // the renderable for this component mesh and any other state information relating.
 type meshRenderable struct {
-	ComponentMesh     *component.Mesh
-	Renderable        *fizzle.Renderable
-	AnimationsEnabled []bool
+	ComponentMesh *component.Mesh
+	Renderable    *fizzle.Renderable
+	Animation     animationPlayback
+}
+
+// animationPlayback keeps track of the preview state for the animations
+// of a component mesh.
+type animationPlayback struct {
+	// Index is the index of the selected animation in the source mesh or
+	// -1 if the mesh should be drawn in its bind pose.
+	Index int
+
+	// IsPlaying indicates whether or not Time advances every frame.
+	IsPlaying bool
+
+	// IsLooping indicates whether or not the animation wraps around to the
+	// start once Time reaches the end of the animation.
+	IsLooping bool
+
+	// Speed is the playback speed multiplier for the animation.
+	Speed float32
+
+	// Time is the current playback position of the animation in seconds.
+	Time float32
 }
 
 // colliderRenderable is used to tie together state for the component collider
@@ -112,6 +177,9 @@ func init() {
 	runtime.LockOSThread()
 	flag.IntVar(&flagDesktopNumber, "desktop", -1, "the index of the desktop to create the main window on")
 	flag.StringVar(&flagComponentFile, "cf", "component.json", "the name of the component file to load and save")
+	flag.IntVar(&flagAutosaveInterval, "autosave", 60, "the number of seconds between autosaves of the component; 0 disables autosaving")
+	flag.StringVar(&flagKeyBindingsFile, "keys", "", "a JSON file mapping command names to key bindings to override the defaults")
+	flag.BoolVar(&flagHotReload, "hotreload", true, "reload textures when their files change")
 }
 
 // guiAddDragSliderVec3 adds drag slider floats for a Vec3.
@@ -124,6 +192,14 @@ func guiAddDragSliderVec3(wnd *gui.Window, widthS float32, idPrefix string, inde
 	wnd.DragSliderFloat(fmt.Sprintf("%s%d_2", idPrefix, index), speed, &v[2])
 }
 
+// guiAddDragSliderVec2 adds drag slider floats for a Vec2.
+func guiAddDragSliderVec2(wnd *gui.Window, widthS float32, idPrefix string, index int, speed float32, v *mgl.Vec2) {
+	wnd.RequestItemWidthMax(widthS)
+	wnd.DragSliderFloat(fmt.Sprintf("%s%d_0", idPrefix, index), speed, &v[0])
+	wnd.RequestItemWidthMax(widthS)
+	wnd.DragSliderFloat(fmt.Sprintf("%s%d_1", idPrefix, index), speed, &v[1])
+}
+
 // guiAddSliderVec4 adds slider floats for a Vec4.
 func guiAddSliderVec4(wnd *gui.Window, widthS float32, idPrefix string, index int, v *mgl.Vec4, min, max float32) {
 	wnd.RequestItemWidthMax(widthS)
@@ -136,6 +212,52 @@ func guiAddSliderVec4(wnd *gui.Window, widthS float32, idPrefix string, index in
 	wnd.SliderFloat(fmt.Sprintf("%s%d_3", idPrefix, index), &v[3], min, max)
 }
 
+// guiAddTexturePicker adds a button that opens a file dialog to pick a texture
+// for a material slot, the file path of the current texture and a preview
+// thumbnail if that texture has been loaded. setTexFile gets called with the
+// component relative path of a newly picked texture which is then loaded.
+func guiAddTexturePicker(wnd *gui.Window, id string, texFile string, setTexFile func(string)) {
+	browse, _ := wnd.Button(id+"Browse", "...")
+	wnd.Text(texFile)
+	if browse {
+		startDir := getComponentPrefix()
+		if len(texFile) > 0 {
+			startDir = filepath.Dir(startDir + texFile)
+		}
+		doShowFileDialog("Pick Texture", startDir, textureFileExtensions, func(fullPath string) {
+			relPath := getComponentRelativePath(fullPath)
+			setTexFile(relPath)
+			err := doLoadTexture(relPath)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "compeditor", "%v", err)
+			}
+		})
+	}
+
+	// show a thumbnail of the texture if it has been loaded
+	if len(texFile) == 0 {
+		return
+	}
+	glTex, texFound := textureMan.GetTexture(texFile)
+	if texFound {
+		wnd.StartRow()
+		wnd.Space(textWidth)
+		thumbW, thumbH := uiman.DisplayToScreen(texturePreviewSize, texturePreviewSize)
+		wnd.Image(id+"Preview", thumbW, thumbH, mgl.Vec4{1, 1, 1, 1}, getPreviewTextureIndex(glTex), mgl.Vec4{0, 1, 1, 0})
+	}
+}
+
+// getPreviewTextureIndex returns the index of the texture in the user interface
+// texture stack, adding it to the stack if it wasn't added already.
+func getPreviewTextureIndex(glTex graphics.Texture) uint32 {
+	texIndex, okay := previewTextureIndexes[glTex]
+	if !okay {
+		texIndex = uiman.AddTextureToStack(glTex)
+		previewTextureIndexes[glTex] = texIndex
+	}
+	return texIndex
+}
+
 // getLoadedChildComponent uses the global childRefFilenames map to look up a
 // component name for a given child reference name and then find that component
 // in the loaded child components slice. Returns nil if no match is found.
@@ -162,24 +284,24 @@ func makeRenderableForMesh(compMesh *component.Mesh) *fizzle.Renderable {
 		meshFilepath := prefixDir + compMesh.SrcFile
 		srcMeshes, parseErr := assimp.ParseFile(meshFilepath)
 		if parseErr != nil {
-			fmt.Printf("Failed to load source mesh %s: %v\n", meshFilepath, parseErr)
+			fizzle.Logf(fizzle.LogError, "compeditor", "Failed to load source mesh %s: %v", meshFilepath, parseErr)
 		} else {
 			if len(srcMeshes) > 0 {
 				compMesh.SrcMesh = srcMeshes[0]
-				fmt.Printf("Loaded source mesh: %s\n", compMesh.SrcFile)
+				fizzle.Logf(fizzle.LogInfo, "compeditor", "Loaded source mesh: %s", compMesh.SrcFile)
 			}
 		}
 	} else if compMesh.BinFile != "" {
 		gombzFilepath := prefixDir + compMesh.BinFile
 		gombzBytes, err := ioutil.ReadFile(gombzFilepath)
 		if err != nil {
-			fmt.Printf("Failed to load Gombz bytes from %s: %v\n", gombzFilepath, err)
+			fizzle.Logf(fizzle.LogError, "compeditor", "Failed to load Gombz bytes from %s: %v", gombzFilepath, err)
 		} else {
 			compMesh.SrcMesh, err = gombz.DecodeMesh(gombzBytes)
 			if err != nil {
-				fmt.Printf("Failed to decode Gombz mesh from %s: %v\n", gombzFilepath, err)
+				fizzle.Logf(fizzle.LogError, "compeditor", "Failed to decode Gombz mesh from %s: %v", gombzFilepath, err)
 			} else {
-				fmt.Printf("Loaded gombz mesh: %s\n", compMesh.SrcFile)
+				fizzle.Logf(fizzle.LogInfo, "compeditor", "Loaded gombz mesh: %s", compMesh.SrcFile)
 			}
 		}
 	}
@@ -205,11 +327,10 @@ func makeRenderableForMesh(compMesh *component.Mesh) *fizzle.Renderable {
 	compRenderable.ComponentMesh = compMesh
 	compRenderable.Renderable = r
 
-	// setup the animation enable flag slice
-	compRenderable.AnimationsEnabled = []bool{}
-	for i := 0; i < len(compMesh.SrcMesh.Animations); i++ {
-		compRenderable.AnimationsEnabled = append(compRenderable.AnimationsEnabled, false)
-	}
+	// start off in the bind pose with default playback settings
+	compRenderable.Animation.Index = -1
+	compRenderable.Animation.IsLooping = true
+	compRenderable.Animation.Speed = 1.0
 
 	visibleMeshes[compMesh.Name] = compRenderable
 	return r
@@ -230,7 +351,7 @@ func doSaveGombz(compMesh *component.Mesh) error {
 		return fmt.Errorf("Error while writing Gombz file: %v", err)
 	}
 
-	fmt.Printf("Wrote Gombz file: %s\n", gombzFilepath)
+	fizzle.Logf(fizzle.LogInfo, "compeditor", "Wrote Gombz file: %s", gombzFilepath)
 	return nil
 }
 
@@ -244,7 +365,7 @@ func doLoadTexture(texFile string) error {
 		return fmt.Errorf("Failed to load texture %s: %v", texFile, err)
 	}
 
-	fmt.Printf("Loaded texture: %s\n", texFile)
+	fizzle.Logf(fizzle.LogInfo, "compeditor", "Loaded texture: %s", texFile)
 	return nil
 }
 
@@ -259,8 +380,59 @@ func doDeleteTexture(texIndex int, matTextures []string) []string {
 	return append(matTextures[:texIndex], matTextures[texIndex+1:]...)
 }
 
-func doAnimation(animation *gombz.Animation, renderable *fizzle.Renderable, totalTime float64) {
-	aniTime := float32(math.Mod(totalTime*float64(animation.TicksPerSecond), float64(animation.Duration)))
+// doSelectAnimation changes the animation being previewed for the mesh
+// and rewinds the playback time. An index of -1 resets the skeleton to
+// the bind pose.
+func doSelectAnimation(compRenderable *meshRenderable, aniIndex int) {
+	compRenderable.Animation.Index = aniIndex
+	compRenderable.Animation.Time = 0.0
+
+	skeleton := compRenderable.Renderable.Core.Skeleton
+	if aniIndex < 0 && skeleton != nil {
+		compRenderable.Animation.IsPlaying = false
+		for i := range skeleton.PoseTransforms {
+			skeleton.PoseTransforms[i] = mgl.Ident4()
+		}
+		skeleton.UpdatePosedBounds()
+	}
+}
+
+// doAnimation advances the playback time of the selected animation if it's
+// playing and then poses the skeleton of the renderable for that time.
+func doAnimation(compRenderable *meshRenderable, frameDelta float64) {
+	srcMesh := compRenderable.ComponentMesh.SrcMesh
+	renderable := compRenderable.Renderable
+	playback := &compRenderable.Animation
+	if srcMesh == nil || renderable == nil || renderable.Core.Skeleton == nil {
+		return
+	}
+	if playback.Index < 0 || playback.Index >= len(srcMesh.Animations) {
+		return
+	}
+
+	animation := &srcMesh.Animations[playback.Index]
+	length := fizzle.GetAnimationLength(animation)
+	if playback.IsPlaying {
+		playback.Time += float32(frameDelta) * playback.Speed
+		if playback.IsLooping && length > 0.0 {
+			playback.Time = float32(math.Mod(float64(playback.Time), float64(length)))
+			if playback.Time < 0.0 {
+				playback.Time += length
+			}
+		} else if playback.Time >= length {
+			playback.Time = length
+			playback.IsPlaying = false
+		} else if playback.Time < 0.0 {
+			playback.Time = 0.0
+			playback.IsPlaying = false
+		}
+	}
+
+	// the skeleton gets animated in ticks and not seconds
+	aniTime := playback.Time
+	if animation.TicksPerSecond > 0.0 {
+		aniTime *= animation.TicksPerSecond
+	}
 	renderable.Core.Skeleton.Animate(animation, aniTime)
 }
 
@@ -287,6 +459,24 @@ func loadAllReferenceTextures(compMesh *component.Mesh) {
 	if len(compMesh.Material.SpecularTexture) > 0 {
 		doLoadTexture(compMesh.Material.SpecularTexture)
 	}
+	if len(compMesh.Material.EmissiveTexture) > 0 {
+		doLoadTexture(compMesh.Material.EmissiveTexture)
+	}
+	if len(compMesh.Material.AOTexture) > 0 {
+		doLoadTexture(compMesh.Material.AOTexture)
+	}
+	if len(compMesh.Material.RoughnessTexture) > 0 {
+		doLoadTexture(compMesh.Material.RoughnessTexture)
+	}
+	if len(compMesh.Material.MetalnessTexture) > 0 {
+		doLoadTexture(compMesh.Material.MetalnessTexture)
+	}
+	if len(compMesh.Material.ORMTexture) > 0 {
+		doLoadTexture(compMesh.Material.ORMTexture)
+	}
+	if len(compMesh.Material.LightmapTexture) > 0 {
+		doLoadTexture(compMesh.Material.LightmapTexture)
+	}
 }
 
 func doLoadComponentFile(componentFilepath string) {
@@ -294,9 +484,12 @@ func doLoadComponentFile(componentFilepath string) {
 	if err == nil {
 		err := json.Unmarshal(existingCompJSON, &theComponent)
 		if err != nil {
-			fmt.Printf("Failed to load component %s: %v\n", componentFilepath, err)
+			fizzle.Logf(fizzle.LogError, "compeditor", "Failed to load component %s: %v", componentFilepath, err)
 		} else {
-			fmt.Printf("Loaded component: %s\n", componentFilepath)
+			fizzle.Logf(fizzle.LogInfo, "compeditor", "Loaded component: %s", componentFilepath)
+			if componentFilepath == flagComponentFile {
+				markComponentSaved()
+			}
 
 			// destroy all existing renderables
 			for _, r := range visibleMeshes {
@@ -341,6 +534,79 @@ func doSaveComponent(comp *component.Component, filepath string) error {
 	return nil
 }
 
+// doSaveCurrentComponent saves the component to the component file set
+// in the user interface.
+func doSaveCurrentComponent() {
+	err := doSaveComponent(&theComponent, flagComponentFile)
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to save the component: %v", err)
+	} else {
+		fizzle.Logf(fizzle.LogInfo, "compeditor", "Saved the component file: %s", flagComponentFile)
+		markComponentSaved()
+		doRemoveAutosave()
+	}
+}
+
+// markComponentSaved remembers the component as it is now so that later
+// edits count as unsaved changes.
+func markComponentSaved() {
+	savedComponentJSON, _ = json.MarshalIndent(&theComponent, "", "    ")
+}
+
+// isComponentDirty returns true if the component has changed since it was
+// last loaded or saved.
+func isComponentDirty() bool {
+	compJSON, err := json.MarshalIndent(&theComponent, "", "    ")
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(compJSON, savedComponentJSON)
+}
+
+// doReloadCurrentComponent loads the component file set in the user interface,
+// replacing the component being edited. If the component has unsaved changes,
+// a window asks the user to confirm discarding them first.
+func doReloadCurrentComponent() {
+	if isComponentDirty() {
+		doConfirmDiscardChanges(reloadCurrentComponent)
+		return
+	}
+	reloadCurrentComponent()
+}
+
+// reloadCurrentComponent loads the component file set in the user interface
+// without checking for unsaved changes.
+func reloadCurrentComponent() {
+	// remove all existing mesh windows
+	closeAllMeshWindows()
+	// load the component file again and create mesh windows / renderables
+	doLoadComponentFile(flagComponentFile)
+}
+
+// doConfirmDiscardChanges opens a window asking the user whether to discard
+// the unsaved changes to the component. The action is run if they do.
+func doConfirmDiscardChanges(action func()) {
+	if uiman.GetWindow(discardChangesWindowID) != nil {
+		return
+	}
+
+	confirmWnd := uiman.NewWindow(discardChangesWindowID, 0.35, 0.6, 0.3, 0.15, func(wnd *gui.Window) {
+		wnd.Text("The component has unsaved changes.")
+		wnd.StartRow()
+		discard, _ := wnd.Button("discardChangesDiscard", "Discard")
+		cancel, _ := wnd.Button("discardChangesCancel", "Cancel")
+		if discard || cancel {
+			uiman.RemoveWindow(wnd)
+		}
+		if discard {
+			action()
+		}
+	})
+	confirmWnd.Title = "Discard Changes"
+	confirmWnd.ShowTitleBar = true
+	confirmWnd.IsMoveable = true
+}
+
 // doAddChildReference adds a new child component reference.
 func doAddChildReference(comp *component.Component) {
 	newChildRef := new(component.ChildRef)
@@ -364,6 +630,83 @@ func doAddMesh() {
 	createMeshWindow(newCompMesh, meshWndX, meshWndY)
 }
 
+// doDuplicateMesh adds a copy of the mesh to the component under a unique
+// name and creates the property window and renderable for it.
+func doDuplicateMesh(compMesh *component.Mesh) {
+	newCompMesh := compMesh.Clone()
+	newCompMesh.Name = getUniqueMeshName(compMesh.Name)
+	theComponent.Meshes = append(theComponent.Meshes, newCompMesh)
+	createMeshWindow(newCompMesh, meshWndX, meshWndY)
+	if newCompMesh.SrcFile != "" || newCompMesh.BinFile != "" {
+		makeRenderableForMesh(newCompMesh)
+	}
+}
+
+// doDuplicateCollider adds a copy of the collider to the component.
+func doDuplicateCollider(collider *component.CollisionRef) {
+	theComponent.Collisions = append(theComponent.Collisions, collider.Clone())
+}
+
+// doDuplicateChildReference adds a copy of the child component reference
+// to the component.
+func doDuplicateChildReference(childRef *component.ChildRef) {
+	theComponent.ChildReferences = append(theComponent.ChildReferences, childRef.Clone())
+}
+
+// getUniqueMeshName returns a name based on baseName that isn't used by
+// any of the meshes in the component.
+func getUniqueMeshName(baseName string) string {
+	name := baseName + " Copy"
+	for i := 2; isMeshNameUsed(name); i++ {
+		name = fmt.Sprintf("%s Copy %d", baseName, i)
+	}
+	return name
+}
+
+// isMeshNameUsed returns true if a mesh in the component has the name.
+func isMeshNameUsed(name string) bool {
+	for _, compMesh := range theComponent.Meshes {
+		if compMesh.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// doCopyMesh places a copy of the mesh in the clipboard.
+func doCopyMesh(compMesh *component.Mesh) {
+	clipboard.Mesh = compMesh.Clone()
+	clipboard.Collider = nil
+	clipboard.ChildRef = nil
+}
+
+// doCopyCollider places a copy of the collider in the clipboard.
+func doCopyCollider(collider *component.CollisionRef) {
+	clipboard.Mesh = nil
+	clipboard.Collider = collider.Clone()
+	clipboard.ChildRef = nil
+}
+
+// doCopyChildReference places a copy of the child component reference
+// in the clipboard.
+func doCopyChildReference(childRef *component.ChildRef) {
+	clipboard.Mesh = nil
+	clipboard.Collider = nil
+	clipboard.ChildRef = childRef.Clone()
+}
+
+// doPaste adds a copy of whatever is in the clipboard to the component.
+func doPaste() {
+	switch {
+	case clipboard.Mesh != nil:
+		doDuplicateMesh(clipboard.Mesh)
+	case clipboard.Collider != nil:
+		doDuplicateCollider(clipboard.Collider)
+	case clipboard.ChildRef != nil:
+		doDuplicateChildReference(clipboard.ChildRef)
+	}
+}
+
 // doDeleteMesh destroys the renderable for a component mesh and then
 // removes the mesh from the map of visibleMeshes.
 func doDeleteMesh(componentMeshName string) {
@@ -497,7 +840,7 @@ func doLoadChildComponent(childComps []*component.Component, childRef *component
 		return childComps, fmt.Errorf("Failed to load child component: %s\n%v\n", fullFilepath, err)
 	}
 
-	fmt.Printf("Loaded child component: %s\n", childRef.File)
+	fizzle.Logf(fizzle.LogInfo, "compeditor", "Loaded child component: %s", childRef.File)
 	childComps = append(childComps, newChildComponent)
 	childRefFilenames[childRef.File] = newChildComponent.Name
 	return childComps, nil
@@ -601,30 +944,120 @@ func createMeshWindow(newCompMesh *component.Mesh, screenX, screenY float32) {
 
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
-		wnd.Text("DiffuseTex")
-		loadDiffuseTexture, _ := wnd.Button(fmt.Sprintf("materialDiffuseTexLoad%d", wndCount), "L")
-		wnd.Editbox(fmt.Sprintf("materialDiffuseTexEditbox%d", wndCount), &newCompMesh.Material.DiffuseTexture)
-		if loadDiffuseTexture {
-			doLoadTexture(newCompMesh.Material.DiffuseTexture)
+		wnd.Text("Emissive")
+		guiAddSliderVec4(wnd, width4Col, "MaterialEmissive", wndCount, &newCompMesh.Material.Emissive, 0.0, 1.0)
+
+		// the sliders need a value to edit, so start from the material defaults
+		if newCompMesh.Material.Roughness == nil {
+			roughness := float32(1.0)
+			newCompMesh.Material.Roughness = &roughness
+		}
+		if newCompMesh.Material.Metalness == nil {
+			metalness := float32(0.0)
+			newCompMesh.Material.Metalness = &metalness
 		}
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Roughness")
+		wnd.DragSliderUFloat(fmt.Sprintf("MaterialRoughness%d", wndCount), 0.01, newCompMesh.Material.Roughness)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Metalness")
+		wnd.DragSliderUFloat(fmt.Sprintf("MaterialMetalness%d", wndCount), 0.01, newCompMesh.Material.Metalness)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("UV Scale")
+		guiAddDragSliderVec2(wnd, width3Col, "MaterialUVScale", wndCount, 0.1, &newCompMesh.Material.UVScale)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("UV Offset")
+		guiAddDragSliderVec2(wnd, width3Col, "MaterialUVOffset", wndCount, 0.01, &newCompMesh.Material.UVOffset)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("UV Rot Deg")
+		wnd.DragSliderFloat(fmt.Sprintf("MaterialUVRotationDegrees%d", wndCount), 0.1, &newCompMesh.Material.UVRotationDegrees)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Exposure")
+		wnd.DragSliderFloat(fmt.Sprintf("MaterialExposure%d", wndCount), 0.05, &newCompMesh.Material.Exposure)
+
+		wnd.StartRow()
+		wnd.Space(textWidth)
+		wnd.Checkbox(fmt.Sprintf("MaterialOverrideExposure%d", wndCount), &newCompMesh.Material.OverrideExposure)
+		wnd.Text("Override Exposure")
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("DiffuseTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialDiffuseTex%d", wndCount), newCompMesh.Material.DiffuseTexture, func(texFile string) {
+			newCompMesh.Material.DiffuseTexture = texFile
+		})
 
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("NormalsTex")
-		loadNormalsTexture, _ := wnd.Button(fmt.Sprintf("materialNormalsTexLoad%d", wndCount), "L")
-		wnd.Editbox(fmt.Sprintf("materialNormalsTexEditbox%d", wndCount), &newCompMesh.Material.NormalsTexture)
-		if loadNormalsTexture {
-			doLoadTexture(newCompMesh.Material.NormalsTexture)
-		}
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialNormalsTex%d", wndCount), newCompMesh.Material.NormalsTexture, func(texFile string) {
+			newCompMesh.Material.NormalsTexture = texFile
+		})
 
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("SpecularTex")
-		loadSpecularTexture, _ := wnd.Button(fmt.Sprintf("materialSpecularTexLoad%d", wndCount), "L")
-		wnd.Editbox(fmt.Sprintf("materialSpecularTexEditbox%d", wndCount), &newCompMesh.Material.SpecularTexture)
-		if loadSpecularTexture {
-			doLoadTexture(newCompMesh.Material.SpecularTexture)
-		}
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialSpecularTex%d", wndCount), newCompMesh.Material.SpecularTexture, func(texFile string) {
+			newCompMesh.Material.SpecularTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("EmissiveTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialEmissiveTex%d", wndCount), newCompMesh.Material.EmissiveTexture, func(texFile string) {
+			newCompMesh.Material.EmissiveTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("AOTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialAOTex%d", wndCount), newCompMesh.Material.AOTexture, func(texFile string) {
+			newCompMesh.Material.AOTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("RoughnessTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialRoughnessTex%d", wndCount), newCompMesh.Material.RoughnessTexture, func(texFile string) {
+			newCompMesh.Material.RoughnessTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("MetalnessTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialMetalnessTex%d", wndCount), newCompMesh.Material.MetalnessTexture, func(texFile string) {
+			newCompMesh.Material.MetalnessTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("ORMTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialORMTex%d", wndCount), newCompMesh.Material.ORMTexture, func(texFile string) {
+			newCompMesh.Material.ORMTexture = texFile
+		})
+
+		wnd.StartRow()
+		wnd.Space(textWidth)
+		wnd.Checkbox(fmt.Sprintf("MaterialORMOcclusion%d", wndCount), &newCompMesh.Material.ORMOcclusion)
+		wnd.Text("ORM Has Occlusion")
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("LightmapTex")
+		guiAddTexturePicker(wnd, fmt.Sprintf("materialLightmapTex%d", wndCount), newCompMesh.Material.LightmapTexture, func(texFile string) {
+			newCompMesh.Material.LightmapTexture = texFile
+		})
 		// add in the custom textures
 		var textureToDelete = -1
 		for i := range newCompMesh.Material.Textures {
@@ -632,15 +1065,16 @@ func createMeshWindow(newCompMesh *component.Mesh, screenX, screenY float32) {
 			wnd.RequestItemWidthMin(textWidth)
 			wnd.Text(fmt.Sprintf("Texture %d", i))
 			deleteTexture, _ := wnd.Button(fmt.Sprintf("materialTexture%dDelete%d", i, wndCount), "X")
-			loadTexture, _ := wnd.Button(fmt.Sprintf("materialTexture%dLoad%d", i, wndCount), "L")
-			wnd.Editbox(fmt.Sprintf("materialTexture%dEditbox%d", i, wndCount), &newCompMesh.Material.Textures[i])
+			texIndex := i
+			guiAddTexturePicker(wnd, fmt.Sprintf("materialTexture%d_%d", i, wndCount), newCompMesh.Material.Textures[i], func(texFile string) {
+				if texIndex < len(newCompMesh.Material.Textures) {
+					newCompMesh.Material.Textures[texIndex] = texFile
+				}
+			})
 
 			if deleteTexture {
 				textureToDelete = i
 			}
-			if loadTexture {
-				doLoadTexture(newCompMesh.Material.Textures[i])
-			}
 		}
 
 		// did we try to delete a texture
@@ -663,22 +1097,64 @@ func createMeshWindow(newCompMesh *component.Mesh, screenX, screenY float32) {
 
 		// do the user interface for animations
 		if newCompMesh.SrcMesh != nil && compRenderable != nil && len(newCompMesh.SrcMesh.Animations) > 0 {
-			for aniIndex, animation := range newCompMesh.SrcMesh.Animations {
-				if aniIndex == 0 {
-					wnd.Separator()
-					wnd.RequestItemWidthMin(textWidth)
-					wnd.Text("Animation: ")
-				} else {
-					wnd.StartRow()
-					wnd.Space(textWidth)
+			playback := &compRenderable.Animation
+
+			wnd.Separator()
+			wnd.RequestItemWidthMin(textWidth)
+			wnd.Text("Animations:")
+			bindPose, _ := wnd.Button(fmt.Sprintf("AnimationBindPose%d", wndCount), "Bind Pose")
+			if bindPose {
+				doSelectAnimation(compRenderable, -1)
+			}
+
+			for aniIndex := range newCompMesh.SrcMesh.Animations {
+				animation := &newCompMesh.SrcMesh.Animations[aniIndex]
+				wnd.StartRow()
+				wnd.Space(textWidth)
+				selectAnimation, _ := wnd.Button(fmt.Sprintf("AnimationSelect%d_%d", aniIndex, wndCount), "Select")
+				aniLabel := fmt.Sprintf("%s (%.2fs)", animation.Name, fizzle.GetAnimationLength(animation))
+				if aniIndex == playback.Index {
+					aniLabel = "> " + aniLabel
 				}
-				wnd.Checkbox(fmt.Sprintf("RunAnimations %d %d", aniIndex, wndCount), &compRenderable.AnimationsEnabled[0])
-				wnd.Text(animation.Name)
-				if compRenderable.AnimationsEnabled[0] {
-					doAnimation(&animation, compRenderable.Renderable, totalTime)
+				wnd.Text(aniLabel)
+				if selectAnimation {
+					doSelectAnimation(compRenderable, aniIndex)
 				}
 			}
 
+			// show the playback controls for the selected animation
+			if playback.Index >= 0 && playback.Index < len(newCompMesh.SrcMesh.Animations) {
+				animation := &newCompMesh.SrcMesh.Animations[playback.Index]
+
+				wnd.StartRow()
+				wnd.Space(textWidth)
+				playLabel := "Play"
+				if playback.IsPlaying {
+					playLabel = "Pause"
+				}
+				togglePlay, _ := wnd.Button(fmt.Sprintf("AnimationPlay%d", wndCount), playLabel)
+				stopAnimation, _ := wnd.Button(fmt.Sprintf("AnimationStop%d", wndCount), "Stop")
+				wnd.Checkbox(fmt.Sprintf("AnimationLoop%d", wndCount), &playback.IsLooping)
+				wnd.Text("Loop")
+				if togglePlay {
+					playback.IsPlaying = !playback.IsPlaying
+				}
+				if stopAnimation {
+					playback.IsPlaying = false
+					playback.Time = 0.0
+				}
+
+				wnd.StartRow()
+				wnd.RequestItemWidthMin(textWidth)
+				wnd.Text("Time")
+				wnd.SliderFloat(fmt.Sprintf("AnimationTime%d", wndCount), &playback.Time, 0.0, fizzle.GetAnimationLength(animation))
+
+				wnd.StartRow()
+				wnd.RequestItemWidthMin(textWidth)
+				wnd.Text("Speed")
+				wnd.DragSliderFloat(fmt.Sprintf("AnimationSpeed%d", wndCount), 0.01, &playback.Speed)
+			}
+
 			wnd.Separator()
 		}
 	})
@@ -694,27 +1170,35 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 	componentWindow := uiman.NewWindow("Component", sX, sY, sW, sH, func(wnd *gui.Window) {
 		loadComponent, _ := wnd.Button("componentFileLoadButton", "Load")
 		saveComponent, _ := wnd.Button("componentFileSaveButton", "Save")
+		pasteClipboard, _ := wnd.Button("componentPasteButton", "Paste")
 		wnd.Editbox("componentFileEditbox", &flagComponentFile)
+		if pasteClipboard {
+			doPaste()
+		}
 		if saveComponent {
-			err := doSaveComponent(&theComponent, flagComponentFile)
-			if err != nil {
-				fmt.Printf("Failed to save the component.\n%v\n", err)
-			} else {
-				fmt.Printf("Saved the component file: %s\n", flagComponentFile)
-			}
+			doSaveCurrentComponent()
 		}
 
 		if loadComponent {
-			// remove all existing mesh windows
-			closeAllMeshWindows()
-			// load the component file again and create mesh windows / renderables
-			doLoadComponentFile(flagComponentFile)
+			doReloadCurrentComponent()
 		}
 
 		wnd.Separator()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Name")
 		wnd.Editbox("componentNameEditbox", &theComponent.Name)
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("View")
+		prevRenderMode, _ := wnd.Button("componentPrevRenderMode", "<")
+		nextRenderMode, _ := wnd.Button("componentNextRenderMode", ">")
+		wnd.Text(renderModeNames[viewportRenderMode])
+		if prevRenderMode {
+			doPrevRenderMode()
+		}
+		if nextRenderMode {
+			doNextRenderMode()
+		}
 
 		// do the user interface for mesh windows
 		wnd.Separator()
@@ -725,6 +1209,7 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			doAddMesh()
 		}
 
+		var meshToDuplicate *component.Mesh
 		meshesThatSurvive := theComponent.Meshes[:0]
 		for compMeshIndex, compMesh := range theComponent.Meshes {
 			wnd.StartRow()
@@ -733,6 +1218,14 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			showMeshWnd, _ := wnd.Button(fmt.Sprintf("buttonShowMesh%d", compMeshIndex), "Show")
 			hideMeshWnd, _ := wnd.Button(fmt.Sprintf("buttonHideMesh%d", compMeshIndex), "Hide")
 			deleteMesh, _ := wnd.Button(fmt.Sprintf("buttonDeleteMesh%d", compMeshIndex), "Delete")
+			dupMesh, _ := wnd.Button(fmt.Sprintf("buttonDupMesh%d", compMeshIndex), "Dup")
+			copyMesh, _ := wnd.Button(fmt.Sprintf("buttonCopyMesh%d", compMeshIndex), "Copy")
+			if dupMesh {
+				meshToDuplicate = compMesh
+			}
+			if copyMesh {
+				doCopyMesh(compMesh)
+			}
 			if showMeshWnd {
 				doShowMeshWindow(compMesh)
 			}
@@ -748,6 +1241,9 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 		}
 		// FIXME: not Destroying renderables for meshes that don't survive
 		theComponent.Meshes = meshesThatSurvive
+		if meshToDuplicate != nil {
+			doDuplicateMesh(meshToDuplicate)
+		}
 
 		// do the user interface for colliders
 		wnd.Separator()
@@ -758,6 +1254,7 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			doAddCollider(&theComponent)
 		}
 
+		var colliderToDuplicate *component.CollisionRef
 		collidersThatSurvive := theComponent.Collisions[:0]
 		visibleCollidersThatSurvive := visibleColliders[:0]
 		for colliderIndex, collider := range theComponent.Collisions {
@@ -768,6 +1265,14 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			delCollider, _ := wnd.Button(fmt.Sprintf("buttonDeleteCollider%d", colliderIndex), "X")
 			prevColliderType, _ := wnd.Button(fmt.Sprintf("buttonPrevColliderType%d", colliderIndex), "<")
 			nextColliderType, _ := wnd.Button(fmt.Sprintf("buttonNextColliderType%d", colliderIndex), ">")
+			dupCollider, _ := wnd.Button(fmt.Sprintf("buttonDupCollider%d", colliderIndex), "Dup")
+			copyCollider, _ := wnd.Button(fmt.Sprintf("buttonCopyCollider%d", colliderIndex), "Copy")
+			if dupCollider {
+				colliderToDuplicate = collider
+			}
+			if copyCollider {
+				doCopyCollider(collider)
+			}
 
 			if !delCollider {
 				collidersThatSurvive = append(collidersThatSurvive, collider)
@@ -818,6 +1323,9 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 		}
 		theComponent.Collisions = collidersThatSurvive
 		visibleColliders = visibleCollidersThatSurvive
+		if colliderToDuplicate != nil {
+			doDuplicateCollider(colliderToDuplicate)
+		}
 
 		wnd.Separator()
 		wnd.RequestItemWidthMin(textWidth)
@@ -827,6 +1335,7 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			doAddChildReference(&theComponent)
 		}
 
+		var childRefToDuplicate *component.ChildRef
 		childRefsThatSurvive := theComponent.ChildReferences[:0]
 		for childRefIndex, childRef := range theComponent.ChildReferences {
 			wnd.StartRow()
@@ -834,6 +1343,14 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 			wnd.Text("File:")
 			removeReference, _ := wnd.Button(fmt.Sprintf("childRefRemove%d", childRefIndex), "X")
 			loadChildReference, _ := wnd.Button(fmt.Sprintf("childRefLoad%d", childRefIndex), "L")
+			dupReference, _ := wnd.Button(fmt.Sprintf("childRefDup%d", childRefIndex), "Dup")
+			copyReference, _ := wnd.Button(fmt.Sprintf("childRefCopy%d", childRefIndex), "Copy")
+			if dupReference {
+				childRefToDuplicate = childRef
+			}
+			if copyReference {
+				doCopyChildReference(childRef)
+			}
 			wnd.Editbox(fmt.Sprintf("childRefFileEditbox%d", childRefIndex), &childRef.File)
 
 			wnd.StartRow()
@@ -867,11 +1384,14 @@ func createComponentWindow(sX, sY, sW, sH float32) *gui.Window {
 				var err error
 				childComponents, err = doLoadChildComponent(childComponents, childRef)
 				if err != nil {
-					fmt.Printf("Failed to load child component.\n%v\n", err)
+					fizzle.Logf(fizzle.LogError, "compeditor", "Failed to load child component: %v", err)
 				}
 			}
 		}
 		theComponent.ChildReferences = childRefsThatSurvive
+		if childRefToDuplicate != nil {
+			doDuplicateChildReference(childRefToDuplicate)
+		}
 
 		// remove any visible child components that no longer have a reference
 		childComponents = removeStaleChildComponents(childComponents, &theComponent, childRefFilenames)
@@ -895,6 +1415,18 @@ func updateVisibleMesh(compRenderable *meshRenderable) {
 
 	compRenderable.Renderable.Material.SpecularColor = compRenderable.ComponentMesh.Material.Specular
 	compRenderable.Renderable.Material.Shininess = compRenderable.ComponentMesh.Material.Shininess
+	compRenderable.Renderable.Material.EmissiveColor = compRenderable.ComponentMesh.Material.Emissive
+	if compRenderable.ComponentMesh.Material.Roughness != nil {
+		compRenderable.Renderable.Material.Roughness = *compRenderable.ComponentMesh.Material.Roughness
+	}
+	if compRenderable.ComponentMesh.Material.Metalness != nil {
+		compRenderable.Renderable.Material.Metalness = *compRenderable.ComponentMesh.Material.Metalness
+	}
+	compRenderable.Renderable.Material.UVScale = compRenderable.ComponentMesh.Material.UVScale
+	compRenderable.Renderable.Material.UVOffset = compRenderable.ComponentMesh.Material.UVOffset
+	compRenderable.Renderable.Material.UVRotation = mgl.DegToRad(compRenderable.ComponentMesh.Material.UVRotationDegrees)
+	compRenderable.Renderable.Material.OverrideExposure = compRenderable.ComponentMesh.Material.OverrideExposure
+	compRenderable.Renderable.Material.Exposure = compRenderable.ComponentMesh.Material.Exposure
 
 	// try to find a shader
 	shader, shaderFound := shaders[compRenderable.ComponentMesh.Material.ShaderName]
@@ -928,6 +1460,37 @@ func updateVisibleMesh(compRenderable *meshRenderable) {
 			compRenderable.Renderable.Material.SpecularTex = glTex
 		}
 	}
+	if len(compRenderable.ComponentMesh.Material.EmissiveTexture) > 0 {
+		glTex, texFound := textureMan.GetTexture(compRenderable.ComponentMesh.Material.EmissiveTexture)
+		if texFound {
+			compRenderable.Renderable.Material.EmissiveTex = glTex
+		}
+	}
+	if len(compRenderable.ComponentMesh.Material.AOTexture) > 0 {
+		glTex, texFound := textureMan.GetTexture(compRenderable.ComponentMesh.Material.AOTexture)
+		if texFound {
+			compRenderable.Renderable.Material.AOTex = glTex
+		}
+	}
+	if len(compRenderable.ComponentMesh.Material.RoughnessTexture) > 0 {
+		glTex, texFound := textureMan.GetTexture(compRenderable.ComponentMesh.Material.RoughnessTexture)
+		if texFound {
+			compRenderable.Renderable.Material.RoughnessTex = glTex
+		}
+	}
+	if len(compRenderable.ComponentMesh.Material.MetalnessTexture) > 0 {
+		glTex, texFound := textureMan.GetTexture(compRenderable.ComponentMesh.Material.MetalnessTexture)
+		if texFound {
+			compRenderable.Renderable.Material.MetalnessTex = glTex
+		}
+	}
+	if len(compRenderable.ComponentMesh.Material.ORMTexture) > 0 {
+		glTex, texFound := textureMan.GetTexture(compRenderable.ComponentMesh.Material.ORMTexture)
+		if texFound {
+			compRenderable.Renderable.Material.ORMTex = glTex
+		}
+	}
+	compRenderable.Renderable.Material.ORMOcclusion = compRenderable.ComponentMesh.Material.ORMOcclusion
 
 }
 
@@ -946,15 +1509,16 @@ func main() {
 	// parse the command line options
 	flag.Parse()
 
-	// Setup the log handlers
-	groggy.Register("INFO", groggy.DefaultSyncHandler)
-	groggy.Register("ERROR", groggy.DefaultSyncHandler)
-	groggy.Register("DEBUG", groggy.DefaultSyncHandler)
+	// log everything, including the debug messages, to stderr
+	fizzle.SetLogger(fizzle.NewStderrLogger(fizzle.LogDebug))
 
 	// start off by initializing the GL and GLFW libraries and creating a window.
 	w, gfx := initGraphics("Component Editor", windowWidth, windowHeight)
 	mainWindow = w
 
+	// write out the component if the editor crashes so work isn't lost
+	defer doAutosaveOnPanic()
+
 	/////////////////////////////////////////////////////////////////////////////
 	// create and initialize the gui Manager
 	uiman = gui.NewManager(gfx)
@@ -963,11 +1527,29 @@ func main() {
 		panic("Failed to initialize the user interface! " + err.Error())
 	}
 	guiinput.SetInputHandlers(uiman, mainWindow)
+	prevKeyCallback = mainWindow.SetKeyCallback(onKeyPress)
+
+	// setup the camera controls
+	cameraInput = input.NewActionMap(mainWindow)
+	cameraInput.BindAction("CameraControl", input.MouseButtonInput(glfw.MouseButton2, 1.0))
+	cameraInput.BindAction("Select", input.MouseButtonInput(glfw.MouseButton1, 1.0))
+	cameraInput.BindAxis("CameraRotate", input.KeyInput(glfw.KeyA, 1.0), input.KeyInput(glfw.KeyD, -1.0))
+	cameraInput.BindAxis("CameraRotateVertical", input.KeyInput(glfw.KeyW, 1.0), input.KeyInput(glfw.KeyS, -1.0))
+	cameraInput.BindAxis("CameraZoom", input.KeyInput(glfw.KeyQ, 1.0), input.KeyInput(glfw.KeyE, -1.0))
+
+	// setup the editor commands and any custom key bindings for them
+	initEditorCommands()
+	if flagKeyBindingsFile != "" {
+		err = loadKeyBindings(flagKeyBindingsFile)
+		if err != nil {
+			fizzle.Logf(fizzle.LogError, "compeditor", "%v", err)
+		}
+	}
 
 	// load a font
 	fontBytes, err := embeddedfonts.OswaldHeavyTtfBytes()
 	if err != nil {
-		fmt.Printf("Failed to load the embedded font: %v", err)
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to load the embedded font: %v", err)
 		return
 	}
 	_, err = uiman.NewFontBytes("Default", fontBytes, fontScale, fontGlyphs)
@@ -979,8 +1561,15 @@ func main() {
 	// setup renderer and shaders
 	renderer = forward.NewForwardRenderer(gfx)
 	renderer.ChangeResolution(int32(windowWidth), int32(windowHeight))
+	renderer.OnResolutionChanged = func(width int32, height int32) {
+		uiman.AdviseResolution(width, height)
+	}
+	resizer = render.NewResizeDebouncer()
 	defer renderer.Destroy()
 	textureMan = fizzle.NewTextureManager()
+	if flagHotReload {
+		textureMan.EnableHotReload(fizzle.DefaultHotReloadInterval)
+	}
 
 	// load the basic shader
 	basicShader, err := forward.CreateBasicShader()
@@ -994,15 +1583,45 @@ func main() {
 		panic("Failed to compile and link the basic skinned shader program! " + err.Error())
 	}
 
+	// load the physically based shaders for glTF assets; the editor still
+	// works without them, so components using them just won't be shaded
+	pbrShader, err := forward.CreatePBRShader()
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to compile and link the PBR shader program: %v", err)
+	}
+	pbrSkinnedShader, err := forward.CreatePBRSkinnedShader()
+	if err != nil {
+		fizzle.Logf(fizzle.LogError, "compeditor", "Failed to compile and link the PBR skinned shader program: %v", err)
+	}
+
 	// load the color shader
 	colorShader, err := forward.CreateColorShader()
 	if err != nil {
 		panic("Failed to compile and link the color shader program! " + err.Error())
 	}
 
+	// create the ground grid
+	groundGrid, err = forward.NewGrid()
+	if err != nil {
+		panic("Failed to compile and link the grid shader program! " + err.Error())
+	}
+	groundGrid.FadeDistance = perspFar
+
+	// load the shaders for the viewport render modes
+	err = initRenderModeShaders()
+	if err != nil {
+		panic("Failed to compile and link the render mode shader programs! " + err.Error())
+	}
+
 	shaders = make(map[string]*fizzle.RenderShader)
 	shaders["Basic"] = basicShader
 	shaders["BasicSkinned"] = basicSkinnedShader
+	if pbrShader != nil {
+		shaders["PBR"] = pbrShader
+	}
+	if pbrSkinnedShader != nil {
+		shaders["PBRSkinned"] = pbrSkinnedShader
+	}
 	shaders["Color"] = colorShader
 
 	// setup a material for the wireframes
@@ -1027,9 +1646,11 @@ func main() {
 	visibleMeshes = make(map[string]*meshRenderable)
 	visibleColliders = make([]*colliderRenderable, 0)
 	childRefFilenames = make(map[string]string)
+	previewTextureIndexes = make(map[graphics.Texture]uint32)
 
 	// if the component file passed in as a flag exists, try to load it
 	doLoadComponentFile(flagComponentFile)
+	markComponentSaved()
 
 	// create the main component window
 	componentWindow := createComponentWindow(0.01, 0.99, 0.25, 0.5)
@@ -1039,6 +1660,9 @@ func main() {
 	componentWindow.IsScrollable = true
 	componentWindow.IsMoveable = true
 
+	// offer to restore the autosave file if the last session didn't exit cleanly
+	doCheckForAutosave()
+
 	/////////////////////////////////////////////////////////////////////////////
 	// loop until something told the mainWindow that it should close
 	// set some OpenGL flags
@@ -1050,6 +1674,7 @@ func main() {
 	gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
 
 	lastFrame := time.Now()
+	lastAutosave = lastFrame
 	appStartTime := time.Now()
 	for !mainWindow.ShouldClose() {
 		// calculate the difference in time to control rotation speed
@@ -1058,7 +1683,15 @@ func main() {
 		frameDelta := thisFrame.Sub(lastFrame).Seconds()
 
 		// check for input
-		handleInput(mainWindow, float32(frameDelta))
+		handleInput(float32(frameDelta))
+
+		// apply any window resize once the window stops changing size
+		if newWidth, newHeight, changed := resizer.Update(); changed {
+			renderer.ChangeResolution(newWidth, newHeight)
+		}
+
+		// upload any textures that were reloaded after their files changed
+		textureMan.UpdateAsync()
 
 		// clear the screen
 		width, height := renderer.GetResolution()
@@ -1070,12 +1703,16 @@ func main() {
 		view := camera.GetViewMatrix()
 
 		// draw the meshes that are visible
+		beginRenderMode(gfx)
 		for _, compRenderable := range visibleMeshes {
 			// push all settings from the component to the renderable
 			updateVisibleMesh(compRenderable)
 
+			// pose the skeleton for the animation being previewed
+			doAnimation(compRenderable, frameDelta)
+
 			// draw the thing
-			renderer.DrawRenderable(compRenderable.Renderable, nil, perspective, view, camera)
+			drawRenderableInMode(compRenderable.Renderable, perspective, view, camera)
 		}
 
 		// draw the child components
@@ -1084,9 +1721,17 @@ func main() {
 			if matchedChild != nil {
 				r := matchedChild.GetRenderable(textureMan, shaders)
 				updateChildComponentRenderable(r, childRef)
-				renderer.DrawRenderable(r, nil, perspective, view, camera)
+				drawRenderableInMode(r, perspective, view, camera)
 			}
 		}
+		endRenderMode(gfx)
+
+		// draw the ground grid where the meshes don't cover it; the grid
+		// turns blending off when it's done so turn it back on
+		if showGrid {
+			groundGrid.Draw(renderer, perspective, view, camera)
+			gfx.Enable(graphics.BLEND)
+		}
 
 		// draw all of the colliders
 		gfx.Disable(graphics.DEPTH_TEST)
@@ -1105,10 +1750,16 @@ func main() {
 		// advise GLFW to poll for input. without this the window appears to hang.
 		glfw.PollEvents()
 
+		// periodically save the component in case of a crash
+		doAutosave(thisFrame)
+
 		// update our last frame time
 		lastFrame = thisFrame
 	}
 
+	// the autosave file is only kept if there are unsaved edits
+	doAutosaveOnExit()
+
 	// cleanup
 	for _, vc := range visibleColliders {
 		vc.Renderable.Destroy()
@@ -1121,6 +1772,8 @@ func main() {
 	for _, shader := range shaders {
 		shader.Destroy()
 	}
+	destroyRenderModeShaders()
+	groundGrid.Destroy()
 
 	renderer.Destroy()
 }
@@ -1174,44 +1827,85 @@ func initGraphics(title string, w int, h int) (*glfw.Window, graphics.GraphicsPr
 }
 
 // handleInput checks for keys and does some updates.
-func handleInput(w *glfw.Window, delta float32) {
+func handleInput(delta float32) {
 	const minDistance float32 = 0.0
 	const zoomSpeed float32 = 3.0
 	const rotSpeed = math.Pi
 
-	rmbStatus := w.GetMouseButton(glfw.MouseButton2)
-	if rmbStatus == glfw.Press {
-		if w.GetKey(glfw.KeyA) == glfw.Press {
-			camera.Rotate(delta * rotSpeed)
-		}
-		if w.GetKey(glfw.KeyD) == glfw.Press {
-			camera.Rotate(delta * rotSpeed * -1.0)
-		}
+	cameraInput.Update()
 
-		if w.GetKey(glfw.KeyW) == glfw.Press {
-			camera.RotateVertical(delta * rotSpeed)
-		}
-		if w.GetKey(glfw.KeyS) == glfw.Press {
-			camera.RotateVertical(delta * rotSpeed * -1.0)
-		}
+	// control clicking in the viewport selects a mesh without getting
+	// confused with clicks on the user interface
+	if cameraInput.JustPressed("Select") && isControlDown() {
+		doPickMesh()
+	}
+
+	if !cameraInput.IsPressed("CameraControl") {
+		return
+	}
 
-		if w.GetKey(glfw.KeyQ) == glfw.Press {
-			d := camera.GetDistance()
-			newD := d + delta*zoomSpeed
+	if rotate := cameraInput.AxisValue("CameraRotate"); rotate != 0.0 {
+		camera.Rotate(delta * rotSpeed * rotate)
+	}
+	if rotate := cameraInput.AxisValue("CameraRotateVertical"); rotate != 0.0 {
+		camera.RotateVertical(delta * rotSpeed * rotate)
+	}
+
+	zoom := cameraInput.AxisValue("CameraZoom")
+	if zoom != 0.0 {
+		newD := camera.GetDistance() + delta*zoomSpeed*zoom
+		if newD > minDistance {
 			camera.SetDistance(newD)
 		}
-		if w.GetKey(glfw.KeyE) == glfw.Press {
-			d := camera.GetDistance()
-			newD := d - delta*zoomSpeed
-			if newD > minDistance {
-				camera.SetDistance(newD)
-			}
-		}
+	}
+}
+
+// isControlDown returns true if either control key is held down.
+func isControlDown() bool {
+	return mainWindow.GetKey(glfw.KeyLeftControl) == glfw.Press ||
+		mainWindow.GetKey(glfw.KeyRightControl) == glfw.Press
+}
+
+// doPickMesh shows the mesh property window for the visible mesh under the
+// mouse cursor, if there is one. Skinned meshes are picked with the bounds
+// of their current pose so animated meshes can be clicked where they're drawn.
+func doPickMesh() {
+	picker := fizzle.NewPicker()
+	meshes := make(map[*fizzle.Renderable]*meshRenderable)
+	for _, compRenderable := range visibleMeshes {
+		picker.Add(compRenderable.Renderable)
+		meshes[compRenderable.Renderable] = compRenderable
+	}
+
+	width, height := mainWindow.GetSize()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	x, y := mainWindow.GetCursorPos()
+	perspective := mgl.Perspective(mgl.DegToRad(60.0), float32(width)/float32(height), perspNear, perspFar)
+	hit, found := picker.Pick(camera, float32(x), float32(y), float32(width), float32(height), perspective)
+	if !found {
+		return
+	}
+	if compRenderable, okay := meshes[hit.Root]; okay {
+		doShowMeshWindow(compRenderable.ComponentMesh)
+	}
+}
+
+// onKeyPress runs the editor command bound to the key, if any, and then chains
+// the event to the key callback that was previously set. Key bindings are
+// skipped while a GUI widget, such as an edit box, has keyboard focus.
+func onKeyPress(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press && uiman.GetActiveInputID() == "" {
+		runKeyBinding(key, mods)
+	}
+
+	if prevKeyCallback != nil {
+		prevKeyCallback(w, key, scancode, action, mods)
 	}
 }
 
 // onWindowResize is called when the window changes size
 func onWindowResize(w *glfw.Window, width int, height int) {
-	uiman.AdviseResolution(int32(width), int32(height))
-	renderer.ChangeResolution(int32(width), int32(height))
+	resizer.Resize(int32(width), int32(height))
 }
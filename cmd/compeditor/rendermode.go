@@ -0,0 +1,125 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	forward "github.com/tbogdala/fizzle/renderer/forward"
+)
+
+// renderMode is the shading used to draw meshes in the viewport.
+type renderMode int
+
+const (
+	renderModeLit renderMode = iota
+	renderModeUnlit
+	renderModeWireframe
+	renderModeNormals
+	renderModeTangents
+	renderModeUVChecker
+	renderModeOverdraw
+	renderModeCount
+)
+
+// renderModeNames are the names shown in the user interface for each renderMode.
+var renderModeNames = []string{
+	"Lit",
+	"Unlit",
+	"Wireframe",
+	"Normals",
+	"Tangents",
+	"UV Checker",
+	"Overdraw",
+}
+
+var (
+	// viewportRenderMode is the render mode currently used in the viewport.
+	viewportRenderMode renderMode
+
+	// renderModeShaders are the shaders that replace the material shader
+	// in the render modes that need one.
+	renderModeShaders map[renderMode]*fizzle.RenderShader
+)
+
+// initRenderModeShaders creates the shaders used by the viewport render modes.
+func initRenderModeShaders() error {
+	creators := map[renderMode]func() (*fizzle.RenderShader, error){
+		renderModeUnlit:     forward.CreateDebugUnlitShader,
+		renderModeNormals:   forward.CreateDebugNormalsShader,
+		renderModeTangents:  forward.CreateDebugTangentsShader,
+		renderModeUVChecker: forward.CreateDebugUVCheckerShader,
+		renderModeOverdraw:  forward.CreateDebugOverdrawShader,
+	}
+
+	renderModeShaders = make(map[renderMode]*fizzle.RenderShader)
+	for mode, create := range creators {
+		shader, err := create()
+		if err != nil {
+			return err
+		}
+		renderModeShaders[mode] = shader
+	}
+	return nil
+}
+
+// destroyRenderModeShaders releases the shaders used by the viewport render modes.
+func destroyRenderModeShaders() {
+	for _, shader := range renderModeShaders {
+		shader.Destroy()
+	}
+}
+
+// doPrevRenderMode switches the viewport to the previous render mode.
+func doPrevRenderMode() {
+	viewportRenderMode--
+	if viewportRenderMode < 0 {
+		viewportRenderMode = renderModeCount - 1
+	}
+}
+
+// doNextRenderMode switches the viewport to the next render mode.
+func doNextRenderMode() {
+	viewportRenderMode++
+	if viewportRenderMode >= renderModeCount {
+		viewportRenderMode = 0
+	}
+}
+
+// beginRenderMode sets the graphics state needed to draw meshes in the
+// current render mode. It should be paired with a call to endRenderMode.
+func beginRenderMode(gfx graphics.GraphicsProvider) {
+	switch viewportRenderMode {
+	case renderModeWireframe:
+		gfx.PolygonMode(graphics.FRONT_AND_BACK, graphics.LINE)
+	case renderModeOverdraw:
+		gfx.Disable(graphics.DEPTH_TEST)
+		gfx.Disable(graphics.CULL_FACE)
+		gfx.BlendFunc(graphics.ONE, graphics.ONE)
+	}
+}
+
+// endRenderMode restores the graphics state changed by beginRenderMode.
+func endRenderMode(gfx graphics.GraphicsProvider) {
+	switch viewportRenderMode {
+	case renderModeWireframe:
+		gfx.PolygonMode(graphics.FRONT_AND_BACK, graphics.FILL)
+	case renderModeOverdraw:
+		gfx.Enable(graphics.DEPTH_TEST)
+		gfx.Enable(graphics.CULL_FACE)
+		gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+	}
+}
+
+// drawRenderableInMode draws the renderable with the shader for the current
+// render mode, or with its own material shader if the mode doesn't replace it.
+func drawRenderableInMode(r *fizzle.Renderable, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	shader, replaced := renderModeShaders[viewportRenderMode]
+	if replaced {
+		renderer.DrawRenderableWithShader(r, shader, nil, perspective, view, camera)
+	} else {
+		renderer.DrawRenderable(r, nil, perspective, view, camera)
+	}
+}
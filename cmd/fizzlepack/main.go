@@ -0,0 +1,224 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Command fizzlepack bakes the assets of component files so that games load
+them quickly and don't need assimp at runtime. Every component JSON file
+found in the input directory is validated and then written to the output
+directory with:
+
+	* meshes converted from their source files to gombz files
+	* textures compressed to DDS files with mipmaps
+	* a manifest of all of the files written
+
+Component files are told apart from other JSON files, such as particle
+emitters, by their Name and Meshes, ChildReferences or Collisions keys;
+the other files are skipped.
+
+Usage:
+
+	fizzlepack -in assets/src -out assets/baked -compress dxt5
+
+With -validate only the component files get checked, which is handy in CI.
+The exit status is 1 if any component had a problem.
+
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	component "github.com/tbogdala/fizzle/component"
+)
+
+// block of flags set on the command line
+var (
+	flagInDir        string
+	flagOutDir       string
+	flagCompress     string
+	flagMipmaps      bool
+	flagManifestFile string
+	flagValidate     bool
+)
+
+func init() {
+	flag.StringVar(&flagInDir, "in", "", "the directory to search for component files")
+	flag.StringVar(&flagOutDir, "out", "", "the directory to write the baked assets to")
+	flag.StringVar(&flagCompress, "compress", compressDXT5, "the texture compression to use: dxt1, dxt5 or none")
+	flag.BoolVar(&flagMipmaps, "mipmaps", true, "generate mipmaps for compressed textures")
+	flag.StringVar(&flagManifestFile, "manifest", "manifest.json", "the file name of the manifest in the output directory")
+	flag.BoolVar(&flagValidate, "validate", false, "only validate the component files")
+}
+
+func main() {
+	flag.Parse()
+	if flagInDir == "" || (flagOutDir == "" && !flagValidate) {
+		fmt.Fprintf(os.Stderr, "Usage of fizzlepack:\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if flagCompress != compressDXT1 && flagCompress != compressDXT5 && flagCompress != compressNone {
+		fmt.Fprintf(os.Stderr, "Unknown texture compression: %s\n", flagCompress)
+		os.Exit(1)
+	}
+
+	componentFiles, err := findComponentFiles(flagInDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to search for component files: %v\n", err)
+		os.Exit(1)
+	}
+
+	packer := newPacker(flagInDir, flagOutDir)
+	failed := 0
+	for _, relPath := range componentFiles {
+		comp, err := loadComponent(filepath.Join(flagInDir, relPath))
+		if err != nil {
+			fmt.Printf("ERROR %s: %v\n", relPath, err)
+			failed++
+			continue
+		}
+
+		problems := validateComponent(comp, filepath.Dir(filepath.Join(flagInDir, relPath)))
+		for _, p := range problems {
+			fmt.Printf("ERROR %s: %s\n", relPath, p)
+		}
+		if len(problems) > 0 {
+			failed++
+			continue
+		}
+		if flagValidate {
+			fmt.Printf("OK %s\n", relPath)
+			continue
+		}
+
+		err = packer.packComponent(comp, relPath)
+		if err != nil {
+			fmt.Printf("ERROR %s: %v\n", relPath, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Packed %s\n", relPath)
+	}
+
+	if !flagValidate {
+		err = packer.writeManifest(flagManifestFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the manifest: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("%d component files, %d failed.\n", len(componentFiles), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// findComponentFiles returns the paths, relative to the directory, of all
+// of the component JSON files under it. Other JSON files, such as particle
+// emitters and manifests written by fizzlepack, are skipped.
+func findComponentFiles(dirPath string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.ToLower(filepath.Ext(path)) != ".json" || info.Name() == flagManifestFile {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		isComponent, err := isComponentFile(path)
+		if err != nil {
+			return err
+		}
+		if isComponent {
+			files = append(files, relPath)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// componentKeys are the top level keys of the component JSON format other
+// than Name, at least one of which a component file has.
+var componentKeys = []string{"Meshes", "ChildReferences", "Collisions"}
+
+// isComponentFile returns true if the JSON file is an object with a Name
+// and one of the componentKeys. Files that aren't valid JSON are treated
+// as components so that broken component files still get reported.
+func isComponentFile(filePath string) (bool, error) {
+	jsonBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return false, fmt.Errorf("Failed to read %s: %v", filePath, err)
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(jsonBytes, &fields) != nil {
+		var v interface{}
+		return json.Unmarshal(jsonBytes, &v) != nil, nil
+	}
+
+	// the JSON decoder matches the keys without regard to case
+	hasName, hasContent := false, false
+	for key := range fields {
+		if strings.EqualFold(key, "Name") {
+			hasName = true
+		}
+		for _, compKey := range componentKeys {
+			if strings.EqualFold(key, compKey) {
+				hasContent = true
+			}
+		}
+	}
+	return hasName && hasContent, nil
+}
+
+// loadComponent reads a component JSON file without loading any of its
+// meshes or textures.
+func loadComponent(filePath string) (*component.Component, error) {
+	jsonBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the component file: %v", err)
+	}
+
+	comp := new(component.Component)
+	err = json.Unmarshal(jsonBytes, comp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the component file: %v", err)
+	}
+	for _, compMesh := range comp.Meshes {
+		compMesh.Parent = comp
+	}
+	return comp, nil
+}
+
+// getTextureFields returns pointers to all of the texture file names of
+// the material so that they can be checked and renamed.
+func getTextureFields(m *component.Material) []*string {
+	fields := []*string{
+		&m.DiffuseTexture,
+		&m.NormalsTexture,
+		&m.SpecularTexture,
+		&m.EmissiveTexture,
+		&m.AOTexture,
+		&m.RoughnessTexture,
+		&m.MetalnessTexture,
+		&m.ORMTexture,
+		&m.LightmapTexture,
+	}
+	for i := range m.Textures {
+		fields = append(fields, &m.Textures[i])
+	}
+	return fields
+}
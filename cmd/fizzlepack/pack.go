@@ -0,0 +1,201 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	assimp "github.com/tbogdala/assimp-go"
+	component "github.com/tbogdala/fizzle/component"
+	gltf "github.com/tbogdala/fizzle/gltf"
+	gombz "github.com/tbogdala/gombz"
+)
+
+// manifestFile is a file written by fizzlepack.
+type manifestFile struct {
+	// Path is relative to the output directory and uses forward slashes.
+	Path string
+
+	// Kind is one of component, mesh or texture.
+	Kind string
+
+	Size   int64
+	SHA256 string
+}
+
+// manifest lists everything fizzlepack wrote so that games and build
+// scripts can tell what assets there are and if they changed.
+type manifest struct {
+	Components []string
+	Files      []manifestFile
+}
+
+// packer writes the baked assets of components to the output directory,
+// converting each mesh and texture file only once even if many components
+// share it.
+type packer struct {
+	inDir    string
+	outDir   string
+	written  map[string]bool
+	manifest manifest
+}
+
+// newPacker creates a new packer from the input directory to the output
+// directory.
+func newPacker(inDir, outDir string) *packer {
+	p := new(packer)
+	p.inDir = inDir
+	p.outDir = outDir
+	p.written = make(map[string]bool)
+	return p
+}
+
+// packComponent bakes the meshes and textures of the component, which was
+// loaded from relPath in the input directory, and writes the component
+// file with its references updated to the baked files.
+func (p *packer) packComponent(comp *component.Component, relPath string) error {
+	compDir := filepath.Dir(relPath)
+
+	for _, compMesh := range comp.Meshes {
+		if compMesh.SrcFile != "" {
+			binFile := strings.TrimSuffix(compMesh.SrcFile, filepath.Ext(compMesh.SrcFile)) + ".gombz"
+			err := p.bake(filepath.Join(compDir, compMesh.SrcFile), filepath.Join(compDir, binFile), "mesh", convertMesh)
+			if err != nil {
+				return err
+			}
+			compMesh.BinFile = filepath.ToSlash(binFile)
+			compMesh.SrcFile = ""
+		} else {
+			err := p.bake(filepath.Join(compDir, compMesh.BinFile), filepath.Join(compDir, compMesh.BinFile), "mesh", copyFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, texture := range getTextureFields(&compMesh.Material) {
+			if *texture == "" {
+				continue
+			}
+			outFile, convert := getTextureOutput(*texture)
+			err := p.bake(filepath.Join(compDir, *texture), filepath.Join(compDir, outFile), "texture", convert)
+			if err != nil {
+				return err
+			}
+			*texture = filepath.ToSlash(outFile)
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(comp, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode the component: %v", err)
+	}
+	err = p.write(relPath, jsonBytes, "component")
+	if err != nil {
+		return err
+	}
+	p.manifest.Components = append(p.manifest.Components, filepath.ToSlash(relPath))
+	return nil
+}
+
+// bake converts the input file to the output file, both relative to their
+// directories, unless it was already done for another component.
+func (p *packer) bake(inRel, outRel, kind string, convert func(string) ([]byte, error)) error {
+	if p.written[filepath.Clean(outRel)] {
+		return nil
+	}
+	data, err := convert(filepath.Join(p.inDir, inRel))
+	if err != nil {
+		return fmt.Errorf("Failed to bake %s: %v", inRel, err)
+	}
+	return p.write(outRel, data, kind)
+}
+
+// write writes a file relative to the output directory and adds it to the
+// manifest.
+func (p *packer) write(outRel string, data []byte, kind string) error {
+	outPath := filepath.Join(p.outDir, outRel)
+	err := os.MkdirAll(filepath.Dir(outPath), 0755)
+	if err != nil {
+		return fmt.Errorf("Failed to create the directory for %s: %v", outRel, err)
+	}
+	err = ioutil.WriteFile(outPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write %s: %v", outRel, err)
+	}
+
+	hash := sha256.Sum256(data)
+	p.manifest.Files = append(p.manifest.Files, manifestFile{
+		Path:   filepath.ToSlash(outRel),
+		Kind:   kind,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(hash[:]),
+	})
+	p.written[filepath.Clean(outRel)] = true
+	return nil
+}
+
+// writeManifest writes the manifest to the file in the output directory.
+func (p *packer) writeManifest(fileName string) error {
+	jsonBytes, err := json.MarshalIndent(p.manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(p.outDir, 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(p.outDir, fileName), jsonBytes, 0644)
+}
+
+// copyFile returns the bytes of a file that doesn't need converting.
+func copyFile(filePath string) ([]byte, error) {
+	return ioutil.ReadFile(filePath)
+}
+
+// convertMesh loads the first mesh of a source file and encodes it as
+// gombz. glTF files are loaded by the gltf package, along with their skin,
+// and other formats go through assimp.
+func convertMesh(filePath string) ([]byte, error) {
+	var srcMesh *gombz.Mesh
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".gltf", ".glb":
+		doc, err := gltf.Load(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		meshIndex, skinIndex := 0, -1
+		for _, node := range doc.Nodes {
+			if node.Mesh != nil {
+				meshIndex = *node.Mesh
+				if node.Skin != nil {
+					skinIndex = *node.Skin
+				}
+				break
+			}
+		}
+		srcMesh, err = doc.CreateMesh(meshIndex, 0, skinIndex)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		srcMeshes, err := assimp.ParseFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(srcMeshes) == 0 {
+			return nil, fmt.Errorf("The file has no meshes.")
+		}
+		srcMesh = srcMeshes[0]
+	}
+
+	return srcMesh.Encode()
+}
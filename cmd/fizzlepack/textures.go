@@ -0,0 +1,307 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // register the JPEG decoder for source textures
+	_ "image/png"  // register the PNG decoder for source textures
+	"os"
+	"path/filepath"
+	"strings"
+
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+// The texture compression options.
+const (
+	compressDXT1 = "dxt1"
+	compressDXT5 = "dxt5"
+	compressNone = "none"
+)
+
+// DDS header values used when writing compressed textures.
+const (
+	ddsHeaderSize      = 124
+	ddsFlagCaps        = 0x1
+	ddsFlagHeight      = 0x2
+	ddsFlagWidth       = 0x4
+	ddsFlagPixelFormat = 0x1000
+	ddsFlagMipMapCount = 0x20000
+	ddsFlagLinearSize  = 0x80000
+	ddsPixelFourCC     = 0x4
+	ddsCapsTexture     = 0x1000
+	ddsCapsComplex     = 0x8
+	ddsCapsMipMap      = 0x400000
+)
+
+// getTextureOutput returns the file name of the baked texture and the
+// function that makes it. Textures already in a container and HDR textures,
+// which can't be block compressed, are copied.
+func getTextureOutput(texture string) (string, func(string) ([]byte, error)) {
+	if flagCompress == compressNone || fizzle.IsTextureContainerFile(texture) || fizzle.IsHDRFile(texture) {
+		return texture, copyFile
+	}
+	return strings.TrimSuffix(texture, filepath.Ext(texture)) + ".dds", compressTexture
+}
+
+// compressTexture loads a PNG or JPEG image and returns it as a DDS file
+// compressed with the format picked on the command line. The image gets
+// flipped so the bottom row is first like fizzle expects from containers.
+func compressTexture(filePath string) ([]byte, error) {
+	imgFile, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(imgFile)
+	imgFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the texture: %v", err)
+	}
+
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	img = flipImage(img)
+
+	levels := []*image.NRGBA{img}
+	if flagMipmaps {
+		for level := img; level.Bounds().Dx() > 1 || level.Bounds().Dy() > 1; {
+			level = halveImage(level)
+			levels = append(levels, level)
+		}
+	}
+
+	fourCC := "DXT5"
+	blockSize := 16
+	if flagCompress == compressDXT1 {
+		fourCC = "DXT1"
+		blockSize = 8
+	}
+
+	var data bytes.Buffer
+	for _, level := range levels {
+		data.Write(compressImage(level, flagCompress == compressDXT5))
+	}
+
+	// the DDS header for the compressed texture
+	w, h := b.Dx(), b.Dy()
+	header := make([]byte, 4+ddsHeaderSize)
+	copy(header, "DDS ")
+	le := binary.LittleEndian
+	hdr := header[4:]
+	flags := uint32(ddsFlagCaps | ddsFlagHeight | ddsFlagWidth | ddsFlagPixelFormat | ddsFlagLinearSize)
+	caps := uint32(ddsCapsTexture)
+	if len(levels) > 1 {
+		flags |= ddsFlagMipMapCount
+		caps |= ddsCapsComplex | ddsCapsMipMap
+	}
+	le.PutUint32(hdr[0:], ddsHeaderSize)
+	le.PutUint32(hdr[4:], flags)
+	le.PutUint32(hdr[8:], uint32(h))
+	le.PutUint32(hdr[12:], uint32(w))
+	le.PutUint32(hdr[16:], uint32(((w+3)/4)*((h+3)/4)*blockSize))
+	le.PutUint32(hdr[24:], uint32(len(levels)))
+	le.PutUint32(hdr[72:], 32)
+	le.PutUint32(hdr[76:], ddsPixelFourCC)
+	copy(hdr[80:84], fourCC)
+	le.PutUint32(hdr[104:], caps)
+
+	return append(header, data.Bytes()...), nil
+}
+
+// flipImage returns the image flipped vertically.
+func flipImage(img *image.NRGBA) *image.NRGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	flipped := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		copy(flipped.Pix[y*flipped.Stride:y*flipped.Stride+w*4], img.Pix[(h-y-1)*img.Stride:(h-y-1)*img.Stride+w*4])
+	}
+	return flipped
+}
+
+// halveImage returns the next mipmap level of the image by averaging each
+// 2x2 block of pixels.
+func halveImage(img *image.NRGBA) *image.NRGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	hw, hh := w/2, h/2
+	if hw < 1 {
+		hw = 1
+	}
+	if hh < 1 {
+		hh = 1
+	}
+
+	half := image.NewNRGBA(image.Rect(0, 0, hw, hh))
+	for y := 0; y < hh; y++ {
+		for x := 0; x < hw; x++ {
+			for c := 0; c < 4; c++ {
+				sum := 0
+				for dy := 0; dy < 2; dy++ {
+					for dx := 0; dx < 2; dx++ {
+						sx := clampInt(x*2+dx, w-1)
+						sy := clampInt(y*2+dy, h-1)
+						sum += int(img.Pix[sy*img.Stride+sx*4+c])
+					}
+				}
+				half.Pix[y*half.Stride+x*4+c] = uint8((sum + 2) / 4)
+			}
+		}
+	}
+	return half
+}
+
+// clampInt clamps v to the range of [0, max].
+func clampInt(v, max int) int {
+	if v > max {
+		return max
+	}
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// compressImage block compresses the image as DXT5 if withAlpha is true or
+// as DXT1 otherwise. The blocks at the edges repeat the last pixels.
+func compressImage(img *image.NRGBA, withAlpha bool) []byte {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	var out []byte
+	var block [16][4]uint8
+	for by := 0; by < (h+3)/4; by++ {
+		for bx := 0; bx < (w+3)/4; bx++ {
+			for i := 0; i < 16; i++ {
+				x := clampInt(bx*4+i%4, w-1)
+				y := clampInt(by*4+i/4, h-1)
+				copy(block[i][:], img.Pix[y*img.Stride+x*4:y*img.Stride+x*4+4])
+			}
+			if withAlpha {
+				out = append(out, compressAlphaBlock(&block)...)
+			}
+			out = append(out, compressColorBlock(&block)...)
+		}
+	}
+	return out
+}
+
+// compressAlphaBlock encodes the alpha of a block in the DXT5 format using
+// the block's lowest and highest alpha as the end points.
+func compressAlphaBlock(block *[16][4]uint8) []byte {
+	a0, a1 := uint8(0), uint8(255)
+	for _, p := range block {
+		if p[3] > a0 {
+			a0 = p[3]
+		}
+		if p[3] < a1 {
+			a1 = p[3]
+		}
+	}
+
+	// with a0 > a1 there are six values between the end points
+	var palette [8]int
+	palette[0], palette[1] = int(a0), int(a1)
+	for i := 1; i <= 6; i++ {
+		palette[i+1] = ((7-i)*int(a0) + i*int(a1)) / 7
+	}
+
+	var indices uint64
+	if a0 > a1 {
+		for i, p := range block {
+			best, bestDist := 0, 256
+			for pi, v := range palette {
+				dist := v - int(p[3])
+				if dist < 0 {
+					dist = -dist
+				}
+				if dist < bestDist {
+					best, bestDist = pi, dist
+				}
+			}
+			indices |= uint64(best) << uint(3*i)
+		}
+	}
+
+	out := []byte{a0, a1, 0, 0, 0, 0, 0, 0}
+	for i := 0; i < 6; i++ {
+		out[2+i] = uint8(indices >> uint(8*i))
+	}
+	return out
+}
+
+// compressColorBlock encodes the color of a block in the DXT1 format using
+// the corners of the block's color bounding box as the end points.
+func compressColorBlock(block *[16][4]uint8) []byte {
+	var lo, hi [3]int
+	lo = [3]int{255, 255, 255}
+	for _, p := range block {
+		for c := 0; c < 3; c++ {
+			if int(p[c]) < lo[c] {
+				lo[c] = int(p[c])
+			}
+			if int(p[c]) > hi[c] {
+				hi[c] = int(p[c])
+			}
+		}
+	}
+
+	// inset the box a little to lower the error of the colors in between
+	for c := 0; c < 3; c++ {
+		inset := (hi[c] - lo[c]) / 16
+		lo[c] += inset
+		hi[c] -= inset
+	}
+
+	c0 := to565(hi)
+	c1 := to565(lo)
+	if c0 < c1 {
+		c0, c1 = c1, c0
+	}
+
+	var indices uint32
+	if c0 != c1 {
+		// with c0 > c1 there are two colors between the end points
+		p0, p1 := from565(c0), from565(c1)
+		var palette [4][3]int
+		palette[0], palette[1] = p0, p1
+		for c := 0; c < 3; c++ {
+			palette[2][c] = (2*p0[c] + p1[c]) / 3
+			palette[3][c] = (p0[c] + 2*p1[c]) / 3
+		}
+
+		for i, p := range block {
+			best, bestDist := 0, 1<<30
+			for pi, v := range palette {
+				dr, dg, db := v[0]-int(p[0]), v[1]-int(p[1]), v[2]-int(p[2])
+				dist := dr*dr + dg*dg + db*db
+				if dist < bestDist {
+					best, bestDist = pi, dist
+				}
+			}
+			indices |= uint32(best) << uint(2*i)
+		}
+	}
+
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint16(out[0:], c0)
+	binary.LittleEndian.PutUint16(out[2:], c1)
+	binary.LittleEndian.PutUint32(out[4:], indices)
+	return out
+}
+
+// to565 packs a color into 16 bits.
+func to565(c [3]int) uint16 {
+	return uint16((c[0]*31+127)/255)<<11 | uint16((c[1]*63+127)/255)<<5 | uint16((c[2]*31+127)/255)
+}
+
+// from565 unpacks a 16 bit color.
+func from565(c uint16) [3]int {
+	r := int(c>>11) & 31
+	g := int(c>>5) & 63
+	b := int(c) & 31
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
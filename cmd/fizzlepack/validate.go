@@ -0,0 +1,84 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	component "github.com/tbogdala/fizzle/component"
+	gombz "github.com/tbogdala/gombz"
+)
+
+// validateComponent checks that a component refers to files that exist
+// and has sensible values. The problems found are returned.
+func validateComponent(comp *component.Component, dirPath string) []string {
+	var problems []string
+	if comp.Name == "" {
+		problems = append(problems, "the component has no name")
+	}
+
+	meshNames := make(map[string]bool)
+	for i, compMesh := range comp.Meshes {
+		if compMesh.Name == "" {
+			problems = append(problems, fmt.Sprintf("mesh #%d has no name", i))
+		} else if meshNames[compMesh.Name] {
+			problems = append(problems, fmt.Sprintf("mesh name %s is used more than once", compMesh.Name))
+		}
+		meshNames[compMesh.Name] = true
+
+		switch {
+		case compMesh.SrcFile != "":
+			if !fileExists(filepath.Join(dirPath, compMesh.SrcFile)) {
+				problems = append(problems, fmt.Sprintf("mesh %s source file %s doesn't exist", compMesh.Name, compMesh.SrcFile))
+			}
+		case compMesh.BinFile != "":
+			binBytes, err := ioutil.ReadFile(filepath.Join(dirPath, compMesh.BinFile))
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("mesh %s binary file %s can't be read", compMesh.Name, compMesh.BinFile))
+			} else if _, err = gombz.DecodeMesh(binBytes); err != nil {
+				problems = append(problems, fmt.Sprintf("mesh %s binary file %s can't be decoded: %v", compMesh.Name, compMesh.BinFile, err))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("mesh %s has no source or binary file", compMesh.Name))
+		}
+
+		for _, texture := range getTextureFields(&compMesh.Material) {
+			if *texture != "" && !fileExists(filepath.Join(dirPath, *texture)) {
+				problems = append(problems, fmt.Sprintf("mesh %s texture %s doesn't exist", compMesh.Name, *texture))
+			}
+		}
+	}
+
+	for _, childRef := range comp.ChildReferences {
+		if !fileExists(filepath.Join(dirPath, childRef.File)) {
+			problems = append(problems, fmt.Sprintf("child reference %s doesn't exist", childRef.File))
+		}
+	}
+
+	for i, collision := range comp.Collisions {
+		switch collision.Type {
+		case component.ColliderTypeAABB:
+			if collision.Min[0] > collision.Max[0] || collision.Min[1] > collision.Max[1] || collision.Min[2] > collision.Max[2] {
+				problems = append(problems, fmt.Sprintf("collision #%d has a Min greater than its Max", i))
+			}
+		case component.ColliderTypeSphere:
+			if collision.Radius <= 0.0 {
+				problems = append(problems, fmt.Sprintf("collision #%d has no radius", i))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("collision #%d has the unknown type %d", i, collision.Type))
+		}
+	}
+
+	return problems
+}
+
+// fileExists returns true if the path is a file that exists.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
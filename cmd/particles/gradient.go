@@ -0,0 +1,92 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	gui "github.com/tbogdala/eweygewey"
+
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+// doGradientEditor adds rows to the window for editing a gradient: one row
+// per key with its position, its RGBA color and a button to remove it,
+// followed by a row to add a key and change the interpolation. The id keeps
+// the widget ids unique when more than one gradient is being edited.
+func doGradientEditor(wnd *gui.Window, id string, gradient *fizzle.Gradient) {
+	const textWidth = 0.33
+	const positionWidth = 0.12
+	const colorWidth = 0.1
+	const buttonWidth = 0.08
+
+	removeIndex := -1
+	positionChanged := false
+	for i := range gradient.Keys {
+		key := &gradient.Keys[i]
+		keyID := fmt.Sprintf("%sKey%d", id, i)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text(fmt.Sprintf("Key %d", i))
+		wnd.RequestItemWidthMax(positionWidth)
+		oldPosition := key.Position
+		wnd.DragSliderFloat(keyID+"Position", 0.01, &key.Position)
+		if key.Position != oldPosition {
+			positionChanged = true
+		}
+		for c := 0; c < 4; c++ {
+			wnd.RequestItemWidthMax(colorWidth)
+			wnd.SliderFloat(fmt.Sprintf("%sColor%d", keyID, c), &key.Color[c], 0.0, 1.0)
+		}
+		wnd.RequestItemWidthMin(buttonWidth)
+		removePressed, _ := wnd.Button(keyID+"Remove", "X")
+		if removePressed {
+			removeIndex = i
+		}
+	}
+
+	if removeIndex >= 0 {
+		gradient.RemoveKey(removeIndex)
+	}
+	if positionChanged {
+		gradient.Sort()
+	}
+
+	wnd.StartRow()
+	wnd.Space(textWidth)
+	wnd.RequestItemWidthMin(0.15)
+	addPressed, _ := wnd.Button(id+"AddKey", "Add Key")
+	if addPressed {
+		addGradientKey(gradient)
+	}
+	wnd.RequestItemWidthMin(0.1)
+	prevPressed, _ := wnd.Button(id+"PrevInterp", "<")
+	wnd.RequestItemWidthMin(0.1)
+	nextPressed, _ := wnd.Button(id+"NextInterp", ">")
+	if prevPressed && gradient.Interpolation > 0 {
+		gradient.Interpolation--
+	}
+	if nextPressed && gradient.Interpolation < fizzle.GradientInterpolationCount-1 {
+		gradient.Interpolation++
+	}
+	wnd.Text(gradient.Interpolation.String())
+}
+
+// addGradientKey adds a key to the gradient halfway between the last two
+// keys with the color the gradient already has there, so adding a key
+// doesn't change how the gradient looks.
+func addGradientKey(gradient *fizzle.Gradient) {
+	switch len(gradient.Keys) {
+	case 0:
+		gradient.AddKey(0.0, mgl.Vec4{1.0, 1.0, 1.0, 1.0})
+	case 1:
+		gradient.AddKey(gradient.Keys[0].Position+1.0, gradient.Keys[0].Color)
+	default:
+		last := len(gradient.Keys) - 1
+		position := (gradient.Keys[last-1].Position + gradient.Keys[last].Position) * 0.5
+		gradient.AddKey(position, gradient.Sample(position))
+	}
+}
@@ -40,6 +40,8 @@ const (
 // block of flags set on the command line
 var (
 	flagDesktopNumber int
+	flagEmitterFile   string
+	flagGPU           bool
 )
 
 // spawnerPrototypes keeps track of possible spawner interface implementations
@@ -47,7 +49,7 @@ var (
 type spawnerPrototypes struct {
 	Name string
 	particles.ParticleSpawner
-	RenderUI func(wnd *gui.Window)
+	RenderUI func(wnd *gui.Window, spawner particles.ParticleSpawner)
 }
 
 var (
@@ -63,6 +65,8 @@ var (
 func init() {
 	runtime.LockOSThread()
 	flag.IntVar(&flagDesktopNumber, "desktop", -1, "the index of the desktop to create the main window on")
+	flag.StringVar(&flagEmitterFile, "ef", "emitter.json", "the name of the emitter file to load and save")
+	flag.BoolVar(&flagGPU, "gpu", false, "simulate the particles on the GPU with transform feedback")
 }
 
 // initSpawners create prototype instances of all known spawner types
@@ -70,8 +74,9 @@ func initSpawners() {
 	knownSpawners = []spawnerPrototypes{}
 
 	cone := particles.NewConeSpawner(nil, 0.5, 1, 1)
-	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: cone.GetName(), ParticleSpawner: cone, RenderUI: func(wnd *gui.Window) {
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: cone.GetName(), ParticleSpawner: cone, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
 		const textWidth = 0.33
+		cone := spawner.(*particles.ConeSpawner)
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Top Radius")
 		wnd.DragSliderUFloat("tradius", 0.1, &cone.TopRadius)
@@ -88,9 +93,10 @@ func initSpawners() {
 	}})
 
 	cube := particles.NewCubeSpawner(nil, mgl.Vec3{-1, -1, -1}, mgl.Vec3{1, 1, 1})
-	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: cube.GetName(), ParticleSpawner: cube, RenderUI: func(wnd *gui.Window) {
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: cube.GetName(), ParticleSpawner: cube, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
 		const textWidth = 0.33
 		const width3Col = 0.22
+		cube := spawner.(*particles.CubeSpawner)
 
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Bottom Left")
@@ -111,6 +117,70 @@ func initSpawners() {
 		wnd.RequestItemWidthMax(width3Col)
 		wnd.DragSliderFloat("cubetr3", 0.1, &cube.TopRight[2])
 	}})
+
+	sphere := particles.NewSphereSpawner(nil, 1, false)
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: sphere.GetName(), ParticleSpawner: sphere, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
+		const textWidth = 0.33
+		sphere := spawner.(*particles.SphereSpawner)
+
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Radius")
+		wnd.DragSliderUFloat("sphereradius", 0.1, &sphere.Radius)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Hemisphere")
+		wnd.Checkbox("spherehemisphere", &sphere.IsHemisphere)
+	}})
+
+	disc := particles.NewDiscSpawner(nil, 0, 1)
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: disc.GetName(), ParticleSpawner: disc, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
+		const textWidth = 0.33
+		disc := spawner.(*particles.DiscSpawner)
+
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Inner Radius")
+		wnd.DragSliderUFloat("discinner", 0.1, &disc.InnerRadius)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Outer Radius")
+		wnd.DragSliderUFloat("discouter", 0.1, &disc.OuterRadius)
+	}})
+
+	line := particles.NewLineSpawner(nil, mgl.Vec3{-1, 0, 0}, mgl.Vec3{1, 0, 0})
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: line.GetName(), ParticleSpawner: line, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
+		const textWidth = 0.33
+		const width3Col = 0.22
+		line := spawner.(*particles.LineSpawner)
+
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Start")
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("linestart1", 0.1, &line.Start[0])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("linestart2", 0.1, &line.Start[1])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("linestart3", 0.1, &line.Start[2])
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("End")
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("lineend1", 0.1, &line.End[0])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("lineend2", 0.1, &line.End[1])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("lineend3", 0.1, &line.End[2])
+	}})
+
+	// the mesh surface spawner can't be edited here, but it needs a prototype
+	// so that emitter files using it can be loaded
+	mesh := particles.NewMeshSurfaceSpawner(nil, nil, nil)
+	knownSpawners = append(knownSpawners, spawnerPrototypes{Name: mesh.GetName(), ParticleSpawner: mesh, RenderUI: func(wnd *gui.Window, spawner particles.ParticleSpawner) {
+		mesh := spawner.(*particles.MeshSurfaceSpawner)
+		wnd.Text(fmt.Sprintf("Triangles: %d", len(mesh.Faces)))
+	}})
 }
 
 // getSpawnerIndex returns the slice index within known spawners for a given spawner interface instance
@@ -161,13 +231,24 @@ func main() {
 	renderer.ChangeResolution(particleWindowSize, particleWindowSize)
 	defer renderer.Destroy()
 
-	// load the particle shader
-	particleShader, err := fizzle.LoadShaderProgram(particles.VertShader330, particles.FragShader330, nil)
+	// load the particle shader; GPU simulated particles use a different vertex shader
+	particleVertShader := particles.VertShader330
+	if flagGPU {
+		particleVertShader = particles.GPUVertShader330
+	}
+	particleShader, err := fizzle.LoadShaderProgram(particleVertShader, particles.FragShader330, nil)
 	if err != nil {
 		panic("Failed to compile and link the particle shader program! " + err.Error())
 	}
 	defer particleShader.Destroy()
 
+	// load the particle trail shader
+	trailShader, err := fizzle.LoadShaderProgram(particles.TrailVertShader330, particles.TrailFragShader330, nil)
+	if err != nil {
+		panic("Failed to compile and link the particle trail shader program! " + err.Error())
+	}
+	defer trailShader.Destroy()
+
 	// load the color shader
 	colorShader, err := forward.CreateColorShader()
 	if err != nil {
@@ -175,9 +256,25 @@ func main() {
 	}
 	defer colorShader.Destroy()
 
+	// create the ground grid under the emitters
+	groundGrid, err := forward.NewGrid()
+	if err != nil {
+		panic("Failed to compile and link the grid shader program! " + err.Error())
+	}
+	groundGrid.FadeDistance = 50.0
+	defer groundGrid.Destroy()
+
 	// create a particle system
 	particleSystem := particles.NewSystem(gfx)
-	emitter := particleSystem.NewEmitter(nil)
+	var emitter *particles.Emitter
+	if flagGPU {
+		emitter, err = particleSystem.NewGPUEmitter(nil)
+		if err != nil {
+			panic("Failed to create the GPU particle emitter! " + err.Error())
+		}
+	} else {
+		emitter = particleSystem.NewEmitter(nil)
+	}
 	emitter.Properties.TextureFilepath = textureFilepath
 	emitter.Properties.MaxParticles = 300
 	emitter.Properties.SpawnRate = 40
@@ -186,7 +283,9 @@ func main() {
 	emitter.Properties.Velocity = mgl.Vec3{0, 1, 0}
 	emitter.Properties.Acceleration = mgl.Vec3{0, -0.1, 0}
 	emitter.Properties.TTL = 3.0
+	emitter.Properties.TrailWidth = []float32{0.2, 0.0}
 	emitter.Shader = particleShader.Prog
+	emitter.TrailShader = trailShader.Prog
 
 	// load the texture
 	err = emitter.LoadTexture()
@@ -194,6 +293,10 @@ func main() {
 		panic(err.Error())
 	}
 
+	// add a wind force field that can be edited in the UI
+	wind := particles.NewWindField(mgl.Vec3{0, 0, 0}, 0.0, 1.0)
+	particleSystem.ForceFields = append(particleSystem.ForceFields, wind)
+
 	// reset the spawner to the first known spawner instance
 	emitter.Spawner = knownSpawners[0]
 	emitter.Spawner.SetOwner(emitter)
@@ -210,6 +313,7 @@ func main() {
 
 			perspective := mgl.Perspective(mgl.DegToRad(60.0), float32(particleWindowSize)/float32(particleWindowSize), 0.1, 50.0)
 			view := camera.GetViewMatrix()
+			groundGrid.Draw(renderer, perspective, view, camera)
 			particleSystem.Draw(perspective, view)
 
 			// draw the emitter volumes
@@ -232,6 +336,46 @@ func main() {
 		props := &emitter.Properties
 
 		wnd.StartRow()
+		loadEmitterPressed, _ := wnd.Button("LoadEmitter", "Load")
+		saveEmitterPressed, _ := wnd.Button("SaveEmitter", "Save")
+		wnd.Editbox("emitterfileedit", &flagEmitterFile)
+		if saveEmitterPressed {
+			err := emitter.Save(flagEmitterFile)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "particles", "Failed to save the emitter: %v", err)
+			} else {
+				fizzle.Logf(fizzle.LogInfo, "particles", "Saved the emitter file: %s", flagEmitterFile)
+			}
+		}
+		if loadEmitterPressed {
+			err := emitter.Load(flagEmitterFile)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "particles", "Failed to load the emitter: %v", err)
+			} else {
+				// make the loaded spawner the one edited for its type
+				knownSpawners[getSpawnerIndex(emitter.Spawner)].ParticleSpawner = emitter.Spawner
+				yaw, pitch, roll = quatToYawPitchRoll(emitter.Properties.Rotation)
+				err = emitter.LoadTexture()
+				if err != nil {
+					fizzle.Logf(fizzle.LogError, "particles", "Failed to load new texture: %v", err)
+				}
+			}
+		}
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Seed")
+		seed := int(emitter.Properties.Seed)
+		wnd.DragSliderInt("seed", 0.5, &seed)
+		if int64(seed) != emitter.Properties.Seed {
+			emitter.SetSeed(int64(seed))
+		}
+		resetPressed, _ := wnd.Button("resetSystem", "Reset")
+		if resetPressed {
+			particleSystem.Reset()
+		}
+
+		wnd.Separator()
 		wnd.Checkbox("isAlive", &emitter.Owner.IsActive)
 		wnd.Text("Is Alive")
 		wnd.Space(0.05)
@@ -263,7 +407,7 @@ func main() {
 
 		// render the spawner interface
 		wnd.StartRow()
-		knownSpawners[ki].RenderUI(wnd)
+		knownSpawners[ki].RenderUI(wnd, emitter.Spawner)
 
 		wnd.Separator()
 		wnd.RequestItemWidthMin(textWidth)
@@ -272,10 +416,78 @@ func main() {
 		if loadBillboardPressed {
 			err := emitter.LoadTexture()
 			if err != nil {
-				fmt.Printf("Failed to load new texture: %v\n", err)
+				fizzle.Logf(fizzle.LogError, "particles", "Failed to load new texture: %v", err)
 			}
 		}
 
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Atlas Grid")
+		wnd.DragSliderUInt("atlascols", 0.1, &props.AtlasColumns)
+		wnd.DragSliderUInt("atlasrows", 0.1, &props.AtlasRows)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Atlas FPS")
+		wnd.DragSliderUFloat("atlasfps", 0.1, &props.AtlasFPS)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Random Frame")
+		wnd.Checkbox("atlasrandom", &props.AtlasRandomStartFrame)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Blend Mode")
+		wnd.RequestItemWidthMin(0.1)
+		prevBlendPressed, _ := wnd.Button("prevBlend", "<")
+		wnd.RequestItemWidthMin(0.1)
+		nextBlendPressed, _ := wnd.Button("nextBlend", ">")
+		if prevBlendPressed && props.BlendMode > 0 {
+			props.BlendMode--
+		}
+		if nextBlendPressed && props.BlendMode < particles.BlendModeCount-1 {
+			props.BlendMode++
+		}
+		wnd.Text(props.BlendMode.String())
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Sort Particles")
+		wnd.Checkbox("sortparticles", &props.SortParticles)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Frustum Culling")
+		wnd.Checkbox("frustumculling", &props.FrustumCulling)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("LOD Distances")
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderUFloat("lodnear", 0.1, &props.LODNearDistance)
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderUFloat("lodfar", 0.1, &props.LODFarDistance)
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.SliderFloat("lodminscale", &props.LODMinSpawnScale, 0.0, 1.0)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Simulation Space")
+		wnd.RequestItemWidthMin(0.1)
+		prevSpacePressed, _ := wnd.Button("prevSpace", "<")
+		wnd.RequestItemWidthMin(0.1)
+		nextSpacePressed, _ := wnd.Button("nextSpace", ">")
+		if prevSpacePressed && props.SimulationSpace > 0 {
+			props.SimulationSpace--
+			emitter.Particles = emitter.Particles[:0]
+		}
+		if nextSpacePressed && props.SimulationSpace < particles.SimulationSpaceCount-1 {
+			props.SimulationSpace++
+			emitter.Particles = emitter.Particles[:0]
+		}
+		wnd.Text(props.SimulationSpace.String())
+
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Max Particles")
@@ -286,6 +498,32 @@ func main() {
 		wnd.Text("Spawn Rate")
 		wnd.DragSliderUInt("spawnrate", 0.5, &props.SpawnRate)
 
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Burst Count")
+		wnd.DragSliderUInt("burstcount", 0.5, &props.BurstCount)
+		triggerPressed, _ := wnd.Button("triggerburst", "Trigger")
+		if triggerPressed {
+			emitter.Trigger()
+		}
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Duration")
+		wnd.DragSliderUFloat64("duration", 0.1, &props.Duration)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Loop Delay")
+		wnd.DragSliderUFloat64("loopdelay", 0.1, &props.LoopDelay)
+
+		wnd.StartRow()
+		wnd.Checkbox("looping", &props.Looping)
+		wnd.Text("Looping")
+		wnd.Space(0.05)
+		wnd.Checkbox("prewarm", &props.Prewarm)
+		wnd.Text("Prewarm")
+
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("TTL")
@@ -301,6 +539,73 @@ func main() {
 		wnd.Text("Speed")
 		wnd.DragSliderUFloat("speed", 0.1, &props.Speed)
 
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Trail Segments")
+		wnd.DragSliderUInt("trailsegments", 0.1, &props.TrailSegments)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Trail Interval")
+		wnd.DragSliderUFloat64("trailinterval", 0.01, &props.TrailInterval)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Trail Width")
+		for i := range props.TrailWidth {
+			wnd.RequestItemWidthMax(width4Col)
+			wnd.DragSliderUFloat(fmt.Sprintf("trailwidth%d", i), 0.01, &props.TrailWidth[i])
+		}
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Checkbox("trailstretch", &props.TrailStretchUV)
+		wnd.Text("Stretch Trail UV")
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Wind")
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("wind1", 0.1, &wind.Force[0])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("wind2", 0.1, &wind.Force[1])
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderFloat("wind3", 0.1, &wind.Force[2])
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Turbulence")
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderUFloat("turbulence", 0.1, &wind.Turbulence)
+		wnd.RequestItemWidthMax(width3Col)
+		wnd.DragSliderUFloat("turbulencefreq", 0.1, &wind.Frequency)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Collision")
+		wnd.RequestItemWidthMin(0.1)
+		prevCollisionPressed, _ := wnd.Button("prevCollision", "<")
+		wnd.RequestItemWidthMin(0.1)
+		nextCollisionPressed, _ := wnd.Button("nextCollision", ">")
+		if prevCollisionPressed && props.CollisionMode > 0 {
+			props.CollisionMode--
+		}
+		if nextCollisionPressed && props.CollisionMode < particles.CollisionModeCount-1 {
+			props.CollisionMode++
+		}
+		wnd.Text(props.CollisionMode.String())
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Restitution")
+		wnd.SliderFloat("restitution", &props.Restitution, 0.0, 1.0)
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Checkbox("groundcollision", &props.GroundCollision)
+		wnd.Text("Ground Height")
+		wnd.DragSliderFloat("groundheight", 0.1, &props.GroundHeight)
+
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Color")
@@ -313,6 +618,20 @@ func main() {
 		wnd.RequestItemWidthMax(width4Col)
 		wnd.SliderFloat("color4", &props.Color[3], 0.0, 1.0)
 
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(textWidth)
+		wnd.Text("Color Over Life")
+		useColorOverLife := props.ColorOverLife != nil
+		wnd.Checkbox("coloroverlife", &useColorOverLife)
+		if useColorOverLife && props.ColorOverLife == nil {
+			props.ColorOverLife = fizzle.NewGradient(mgl.Vec4{1, 1, 1, 1}, mgl.Vec4{1, 1, 1, 0})
+		} else if !useColorOverLife {
+			props.ColorOverLife = nil
+		}
+		if props.ColorOverLife != nil {
+			doGradientEditor(wnd, "coloroverlife", props.ColorOverLife)
+		}
+
 		wnd.StartRow()
 		wnd.RequestItemWidthMin(textWidth)
 		wnd.Text("Origin")
@@ -383,6 +702,23 @@ func main() {
 	}
 }
 
+// quatToYawPitchRoll returns the yaw, pitch and roll in whole degrees of a rotation
+// that was made with mgl.AnglesToQuat(pitch, yaw, roll, mgl.XYZ) so that the
+// sliders in the user interface can be synced to a loaded rotation.
+func quatToYawPitchRoll(q mgl.Quat) (yaw, pitch, roll int) {
+	// the rotation matrix is Rx(pitch) * Ry(yaw) * Rz(roll)
+	m := q.Mat4()
+	y := math.Asin(float64(mgl.Clamp(m.At(0, 2), -1, 1)))
+	p := math.Atan2(float64(-m.At(1, 2)), float64(m.At(2, 2)))
+	r := math.Atan2(float64(-m.At(0, 1)), float64(m.At(0, 0)))
+
+	toDegrees := func(rads float64) int {
+		degrees := int(math.Floor(rads*180.0/math.Pi + 0.5))
+		return (degrees%360 + 360) % 360
+	}
+	return toDegrees(y), toDegrees(p), toDegrees(r)
+}
+
 // initGraphics creates an OpenGL window and initializes the required graphics libraries.
 // It will either succeed or panic.
 func initGraphics(title string, w int, h int) (*glfw.Window, graphics.GraphicsProvider) {
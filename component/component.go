@@ -9,7 +9,6 @@ import (
 	mgl "github.com/go-gl/mathgl/mgl32"
 	"github.com/tbogdala/fizzle"
 	"github.com/tbogdala/gombz"
-	"github.com/tbogdala/groggy"
 )
 
 // Mesh defines a mesh reference for a component and everything
@@ -61,6 +60,23 @@ func NewMesh() *Mesh {
 	return cm
 }
 
+// Clone makes a deep copy of the Mesh. The SrcMesh data is treated as
+// read-only and is shared between the clones.
+func (cm *Mesh) Clone() *Mesh {
+	clone := new(Mesh)
+	*clone = *cm
+	clone.Material.Textures = append([]string(nil), cm.Material.Textures...)
+	if cm.Material.Roughness != nil {
+		roughness := *cm.Material.Roughness
+		clone.Material.Roughness = &roughness
+	}
+	if cm.Material.Metalness != nil {
+		metalness := *cm.Material.Metalness
+		clone.Material.Metalness = &metalness
+	}
+	return clone
+}
+
 // ChildRef defines a reference to another component JSON file
 // so that Components can be built from other Component parts.
 type ChildRef struct {
@@ -83,6 +99,13 @@ type ChildRef struct {
 	Scale mgl.Vec3
 }
 
+// Clone makes a copy of the ChildRef.
+func (cref *ChildRef) Clone() *ChildRef {
+	clone := new(ChildRef)
+	*clone = *cref
+	return clone
+}
+
 // Material defines the visual appearance of the component.
 type Material struct {
 	// ShaderName is the name of the shader program to use for rendering.
@@ -110,6 +133,52 @@ type Material struct {
 	// SpecularTexture is the relative file path for the specular map texture.
 	SpecularTexture string
 
+	// Emissive is the color of the light given off by the material when
+	// there is no EmissiveTexture.
+	Emissive mgl.Vec4
+
+	// Roughness and Metalness are the material properties in the range of
+	// [0, 1] used when there is no RoughnessTexture or MetalnessTexture.
+	// A nil value keeps the default from fizzle.NewMaterial, which is 1.0
+	// for Roughness and 0.0 for Metalness.
+	Roughness *float32 `json:",omitempty"`
+	Metalness *float32 `json:",omitempty"`
+
+	// UVScale, UVOffset and UVRotationDegrees transform the texture
+	// coordinates of the mesh, such as to tile a texture across a large
+	// floor. A UVScale of 0 is treated as 1.
+	UVScale           mgl.Vec2
+	UVOffset          mgl.Vec2
+	UVRotationDegrees float32
+
+	// OverrideExposure makes renderers use Exposure, in stops, for the
+	// material instead of their own exposure compensation.
+	OverrideExposure bool
+	Exposure         float32
+
+	// EmissiveTexture is the relative file path for the emissive texture.
+	EmissiveTexture string
+
+	// AOTexture is the relative file path for the ambient occlusion map texture.
+	AOTexture string
+
+	// RoughnessTexture is the relative file path for the roughness map texture.
+	RoughnessTexture string
+
+	// MetalnessTexture is the relative file path for the metalness map texture.
+	MetalnessTexture string
+
+	// ORMTexture is the relative file path for the texture with ambient
+	// occlusion, roughness and metalness in its red, green and blue
+	// channels, used by the PBR shaders. ORMOcclusion is set if the red
+	// channel holds occlusion.
+	ORMTexture   string
+	ORMOcclusion bool
+
+	// LightmapTexture is the relative file path for the baked lightmap
+	// texture, which is mapped with the second UV channel of the mesh.
+	LightmapTexture string
+
 	// Textures specifies the texture files to load for mesh, relative
 	// to the component file. They will be found to RenderableCore
 	// Tex* properties in order defined.
@@ -151,6 +220,55 @@ type CollisionRef struct {
 	Tags []string
 }
 
+// Clone makes a deep copy of the CollisionRef.
+func (cr *CollisionRef) Clone() *CollisionRef {
+	clone := new(CollisionRef)
+	*clone = *cr
+	clone.Tags = append([]string(nil), cr.Tags...)
+	return clone
+}
+
+// SoundRef specifies a sound emitter attached to the component, such as the
+// hum of a machine or a crackling fire. The audio package creates emitters
+// for them that follow the component's Renderable.
+type SoundRef struct {
+	// Name is the user identifier for the sound in the component.
+	Name string
+
+	// File is the sound file to play and should be relative to the
+	// component's file path.
+	File string
+
+	// Offset is the location of the emitter relative to the component.
+	Offset mgl.Vec3
+
+	// Gain is the volume of the sound. A Gain of 0 is treated as 1.
+	Gain float32
+
+	// Pitch is the playback speed of the sound. A Pitch of 0 is treated as 1.
+	Pitch float32
+
+	// Looping indicates that the sound should start over when it ends.
+	Looping bool
+
+	// Autoplay indicates that the sound should start playing as soon as
+	// the emitter gets created.
+	Autoplay bool
+
+	// ReferenceDistance is the distance where the sound is at its full
+	// volume and MaxDistance is the distance after which it doesn't get
+	// any quieter. Values of 0 use the audio backend's defaults.
+	ReferenceDistance float32
+	MaxDistance       float32
+}
+
+// Clone makes a copy of the SoundRef.
+func (sr *SoundRef) Clone() *SoundRef {
+	clone := new(SoundRef)
+	*clone = *sr
+	return clone
+}
+
 // Component is the main structure that defines a component and also defines
 // what fields to use in component JSON files.
 type Component struct {
@@ -173,6 +291,9 @@ type Component struct {
 	// the user.
 	Collisions []*CollisionRef
 
+	// Sounds are the sound emitters of the component.
+	Sounds []*SoundRef
+
 	// Properties is a map for client code's custom properties for the component.
 	Properties map[string]string
 
@@ -183,6 +304,10 @@ type Component struct {
 	// cachedRenderable is the cached renerable object for the component that can
 	// be used as a prototype.
 	cachedRenderable *fizzle.Renderable
+
+	// textureRefs are the texture keys the Manager holds a reference to in
+	// the texture manager for this component; one for each time it used them.
+	textureRefs []string
 }
 
 // Destroy will destroy the cached Renderable object if it exists.
@@ -204,6 +329,7 @@ func (c *Component) Clone() *Component {
 	clone.Meshes = c.Meshes
 	clone.ChildReferences = c.ChildReferences
 	clone.Collisions = c.Collisions
+	clone.Sounds = c.Sounds
 	clone.Properties = c.Properties
 	clone.componentDirPath = c.componentDirPath
 	clone.cachedRenderable = c.cachedRenderable
@@ -266,6 +392,12 @@ func (cm *Mesh) GetFullTexturePath(textureIndex int) string {
 	return cm.Parent.componentDirPath + cm.Material.Textures[textureIndex]
 }
 
+// GetFullSoundPath returns the full file path for the sound file of the
+// component. The soundIndex is an index into Component.Sounds.
+func (c *Component) GetFullSoundPath(soundIndex int) string {
+	return c.componentDirPath + c.Sounds[soundIndex].File
+}
+
 // GetVertices returns the vector slice containing the vertices for the mesh from
 // the cached source gombz structure.
 func (cm *Mesh) GetVertices() ([]mgl.Vec3, error) {
@@ -300,17 +432,17 @@ func CreateRenderableForMesh(tm *fizzle.TextureManager, shaders map[string]*fizz
 	for i := 0; i < textureCount; i++ {
 		r.Material.CustomTex[i], okay = tm.GetTexture(compMesh.Material.Textures[i])
 		if !okay {
-			groggy.Logsf("ERROR", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.Textures[i])
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.Textures[i])
 		}
 		if compMesh.Material.GenerateMipmaps {
 			fizzle.GenerateMipmaps(r.Material.CustomTex[i])
 		}
 	}
 	if len(compMesh.Material.DiffuseTexture) > 0 {
-		groggy.Logsf("DEBUG", "createRenderableForMesh DiffuseTexturer loading: %s.", compMesh.Material.DiffuseTexture)
+		fizzle.Logf(fizzle.LogDebug, "component", "createRenderableForMesh DiffuseTexturer loading: %s.", compMesh.Material.DiffuseTexture)
 		r.Material.DiffuseTex, okay = tm.GetTexture(compMesh.Material.DiffuseTexture)
 		if !okay {
-			groggy.Logsf("ERROR", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.DiffuseTexture)
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.DiffuseTexture)
 		}
 		if compMesh.Material.GenerateMipmaps {
 			fizzle.GenerateMipmaps(r.Material.DiffuseTex)
@@ -319,7 +451,7 @@ func CreateRenderableForMesh(tm *fizzle.TextureManager, shaders map[string]*fizz
 	if len(compMesh.Material.NormalsTexture) > 0 {
 		r.Material.NormalsTex, okay = tm.GetTexture(compMesh.Material.NormalsTexture)
 		if !okay {
-			groggy.Logsf("ERROR", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.NormalsTexture)
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.NormalsTexture)
 		}
 		if compMesh.Material.GenerateMipmaps {
 			fizzle.GenerateMipmaps(r.Material.NormalsTex)
@@ -328,17 +460,82 @@ func CreateRenderableForMesh(tm *fizzle.TextureManager, shaders map[string]*fizz
 	if len(compMesh.Material.SpecularTexture) > 0 {
 		r.Material.SpecularTex, okay = tm.GetTexture(compMesh.Material.SpecularTexture)
 		if !okay {
-			groggy.Logsf("ERROR", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.SpecularTexture)
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.SpecularTexture)
 		}
 		if compMesh.Material.GenerateMipmaps {
 			fizzle.GenerateMipmaps(r.Material.SpecularTex)
 		}
 	}
+	if len(compMesh.Material.EmissiveTexture) > 0 {
+		r.Material.EmissiveTex, okay = tm.GetTexture(compMesh.Material.EmissiveTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.EmissiveTexture)
+		}
+		if compMesh.Material.GenerateMipmaps {
+			fizzle.GenerateMipmaps(r.Material.EmissiveTex)
+		}
+	}
+	if len(compMesh.Material.AOTexture) > 0 {
+		r.Material.AOTex, okay = tm.GetTexture(compMesh.Material.AOTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.AOTexture)
+		}
+		if compMesh.Material.GenerateMipmaps {
+			fizzle.GenerateMipmaps(r.Material.AOTex)
+		}
+	}
+	if len(compMesh.Material.RoughnessTexture) > 0 {
+		r.Material.RoughnessTex, okay = tm.GetTexture(compMesh.Material.RoughnessTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.RoughnessTexture)
+		}
+		if compMesh.Material.GenerateMipmaps {
+			fizzle.GenerateMipmaps(r.Material.RoughnessTex)
+		}
+	}
+	if len(compMesh.Material.MetalnessTexture) > 0 {
+		r.Material.MetalnessTex, okay = tm.GetTexture(compMesh.Material.MetalnessTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.MetalnessTexture)
+		}
+		if compMesh.Material.GenerateMipmaps {
+			fizzle.GenerateMipmaps(r.Material.MetalnessTex)
+		}
+	}
+	if len(compMesh.Material.ORMTexture) > 0 {
+		r.Material.ORMTex, okay = tm.GetTexture(compMesh.Material.ORMTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.ORMTexture)
+		}
+		if compMesh.Material.GenerateMipmaps {
+			fizzle.GenerateMipmaps(r.Material.ORMTex)
+		}
+	}
+	r.Material.ORMOcclusion = compMesh.Material.ORMOcclusion
+	if len(compMesh.Material.LightmapTexture) > 0 {
+		// no mipmaps for lightmaps since they would bleed between the charts
+		r.Material.LightmapTex, okay = tm.GetTexture(compMesh.Material.LightmapTexture)
+		if !okay {
+			fizzle.Logf(fizzle.LogError, "component", "createRenderableForMesh failed to assign a texture gl id for %s.", compMesh.Material.LightmapTexture)
+		}
+	}
 
 	// assign material properties if specified
 	r.Material.DiffuseColor = compMesh.Material.Diffuse
 	r.Material.SpecularColor = compMesh.Material.Specular
 	r.Material.Shininess = compMesh.Material.Shininess
+	r.Material.EmissiveColor = compMesh.Material.Emissive
+	if compMesh.Material.Roughness != nil {
+		r.Material.Roughness = *compMesh.Material.Roughness
+	}
+	if compMesh.Material.Metalness != nil {
+		r.Material.Metalness = *compMesh.Material.Metalness
+	}
+	r.Material.UVScale = compMesh.Material.UVScale
+	r.Material.UVOffset = compMesh.Material.UVOffset
+	r.Material.UVRotation = mgl.DegToRad(compMesh.Material.UVRotationDegrees)
+	r.Material.OverrideExposure = compMesh.Material.OverrideExposure
+	r.Material.Exposure = compMesh.Material.Exposure
 	loadedShader, okay := shaders[compMesh.Material.ShaderName]
 	if okay {
 		r.Material.Shader = loadedShader
@@ -22,7 +22,6 @@ import (
 
 	"github.com/tbogdala/fizzle"
 	"github.com/tbogdala/gombz"
-	"github.com/tbogdala/groggy"
 )
 
 // Manager loads and manages access to Component objects.
@@ -61,13 +60,18 @@ func NewManager(tm *fizzle.TextureManager, shaders map[string]*fizzle.RenderShad
 func (cm *Manager) Destroy() {
 	for _, c := range cm.storage {
 		c.Destroy()
+		cm.releaseTextures(c)
 	}
 	cm.storage = make(map[string]*Component)
 }
 
 // AddComponent adds a new component to the collection. If one existed previous using
-// the same name, then it is overwritten.
+// the same name, then it is overwritten and the references to its textures are
+// released so that TextureManager.UnloadUnused can free them.
 func (cm *Manager) AddComponent(name string, component *Component) {
+	if oldComp, okay := cm.storage[name]; okay && oldComp != component {
+		cm.releaseTextures(oldComp)
+	}
 	cm.storage[name] = component
 }
 
@@ -91,7 +95,7 @@ func (cm *Manager) GetRenderableInstance(component *Component) *fizzle.Renderabl
 		_, childFileName := filepath.Split(cref.File)
 		crComponent, okay := cm.GetComponent(childFileName)
 		if !okay {
-			groggy.Logsf("ERROR", "GetRenderableInstance: Component %s has a ChildInstance (%s) that wasn't loaded.\n",
+			fizzle.Logf(fizzle.LogError, "component", "GetRenderableInstance: Component %s has a ChildInstance (%s) that wasn't loaded.",
 				component.Name, cref.File)
 			continue
 		}
@@ -110,6 +114,28 @@ func (cm *Manager) GetRenderableInstance(component *Component) *fizzle.Renderabl
 	return r
 }
 
+// loadTexture adds a reference for the component to the texture stored under
+// the key, loading it from the path first if it's not in the texture manager yet.
+func (cm *Manager) loadTexture(component *Component, keyToUse string, path string) error {
+	if _, okay := cm.textureManager.Retain(keyToUse); !okay {
+		_, err := cm.textureManager.LoadTexture(keyToUse, path)
+		if err != nil {
+			return err
+		}
+	}
+	component.textureRefs = append(component.textureRefs, keyToUse)
+	return nil
+}
+
+// releaseTextures releases the references the component holds to textures
+// in the texture manager.
+func (cm *Manager) releaseTextures(component *Component) {
+	for _, key := range component.textureRefs {
+		cm.textureManager.Release(key)
+	}
+	component.textureRefs = nil
+}
+
 // LoadComponentFromFile loads a component from a JSON file and stores it under
 // the name speicified. This function returns the new component and a possible
 // error value.
@@ -157,42 +183,92 @@ func (cm *Manager) LoadComponentFromBytes(jsonBytes []byte, storageName string,
 	// load the associated textures
 	for meshIndex, compMesh := range component.Meshes {
 		for i := range compMesh.Material.Textures {
-			_, err = cm.textureManager.LoadTexture(compMesh.Material.Textures[i], compMesh.GetFullTexturePath(i))
+			err = cm.loadTexture(component, compMesh.Material.Textures[i], compMesh.GetFullTexturePath(i))
 			if err != nil {
-				groggy.Logsf("ERROR", "Mesh #%d failed to load texture: %s", meshIndex, compMesh.Material.Textures[i])
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load texture: %s", meshIndex, compMesh.Material.Textures[i])
 			} else {
-				groggy.Logsf("DEBUG", "Mesh #%d loaded texture: %s", meshIndex, compMesh.Material.Textures[i])
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded texture: %s", meshIndex, compMesh.Material.Textures[i])
 			}
 		}
 		if len(compMesh.Material.DiffuseTexture) > 0 {
-			_, err = cm.textureManager.LoadTexture(compMesh.Material.DiffuseTexture, compMesh.Parent.componentDirPath+compMesh.Material.DiffuseTexture)
+			err = cm.loadTexture(component, compMesh.Material.DiffuseTexture, compMesh.Parent.componentDirPath+compMesh.Material.DiffuseTexture)
 			if err != nil {
-				groggy.Logsf("ERROR", "Mesh #%d failed to load diffuse texture: %s", meshIndex, compMesh.Material.DiffuseTexture)
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load diffuse texture: %s", meshIndex, compMesh.Material.DiffuseTexture)
 			} else {
-				groggy.Logsf("DEBUG", "Mesh #%d loaded diffuse texture: %s", meshIndex, compMesh.Material.DiffuseTexture)
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded diffuse texture: %s", meshIndex, compMesh.Material.DiffuseTexture)
 			}
 		}
 		if len(compMesh.Material.NormalsTexture) > 0 {
-			_, err = cm.textureManager.LoadTexture(compMesh.Material.NormalsTexture, compMesh.Parent.componentDirPath+compMesh.Material.NormalsTexture)
+			err = cm.loadTexture(component, compMesh.Material.NormalsTexture, compMesh.Parent.componentDirPath+compMesh.Material.NormalsTexture)
 			if err != nil {
-				groggy.Logsf("ERROR", "Mesh #%d failed to load normal map texture: %s", meshIndex, compMesh.Material.NormalsTexture)
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load normal map texture: %s", meshIndex, compMesh.Material.NormalsTexture)
 			} else {
-				groggy.Logsf("DEBUG", "Mesh #%d loaded normal map texture: %s", meshIndex, compMesh.Material.NormalsTexture)
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded normal map texture: %s", meshIndex, compMesh.Material.NormalsTexture)
 			}
 		}
 		if len(compMesh.Material.SpecularTexture) > 0 {
-			_, err = cm.textureManager.LoadTexture(compMesh.Material.SpecularTexture, compMesh.Parent.componentDirPath+compMesh.Material.SpecularTexture)
+			err = cm.loadTexture(component, compMesh.Material.SpecularTexture, compMesh.Parent.componentDirPath+compMesh.Material.SpecularTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load specular map texture: %s", meshIndex, compMesh.Material.SpecularTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded specular map texture: %s", meshIndex, compMesh.Material.SpecularTexture)
+			}
+		}
+		if len(compMesh.Material.EmissiveTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.EmissiveTexture, compMesh.Parent.componentDirPath+compMesh.Material.EmissiveTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load emissive map texture: %s", meshIndex, compMesh.Material.EmissiveTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded emissive map texture: %s", meshIndex, compMesh.Material.EmissiveTexture)
+			}
+		}
+		if len(compMesh.Material.AOTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.AOTexture, compMesh.Parent.componentDirPath+compMesh.Material.AOTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load AO map texture: %s", meshIndex, compMesh.Material.AOTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded AO map texture: %s", meshIndex, compMesh.Material.AOTexture)
+			}
+		}
+		if len(compMesh.Material.RoughnessTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.RoughnessTexture, compMesh.Parent.componentDirPath+compMesh.Material.RoughnessTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load roughness map texture: %s", meshIndex, compMesh.Material.RoughnessTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded roughness map texture: %s", meshIndex, compMesh.Material.RoughnessTexture)
+			}
+		}
+		if len(compMesh.Material.MetalnessTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.MetalnessTexture, compMesh.Parent.componentDirPath+compMesh.Material.MetalnessTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load metalness map texture: %s", meshIndex, compMesh.Material.MetalnessTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded metalness map texture: %s", meshIndex, compMesh.Material.MetalnessTexture)
+			}
+		}
+		if len(compMesh.Material.ORMTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.ORMTexture, compMesh.Parent.componentDirPath+compMesh.Material.ORMTexture)
+			if err != nil {
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load ORM texture: %s", meshIndex, compMesh.Material.ORMTexture)
+			} else {
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded ORM texture: %s", meshIndex, compMesh.Material.ORMTexture)
+			}
+		}
+		if len(compMesh.Material.LightmapTexture) > 0 {
+			err = cm.loadTexture(component, compMesh.Material.LightmapTexture, compMesh.Parent.componentDirPath+compMesh.Material.LightmapTexture)
 			if err != nil {
-				groggy.Logsf("ERROR", "Mesh #%d failed to load specular map texture: %s", meshIndex, compMesh.Material.SpecularTexture)
+				fizzle.Logf(fizzle.LogError, "component", "Mesh #%d failed to load lightmap texture: %s", meshIndex, compMesh.Material.LightmapTexture)
 			} else {
-				groggy.Logsf("DEBUG", "Mesh #%d loaded specular map texture: %s", meshIndex, compMesh.Material.SpecularTexture)
+				fizzle.Logf(fizzle.LogDebug, "component", "Mesh #%d loaded lightmap texture: %s", meshIndex, compMesh.Material.LightmapTexture)
 			}
 		}
 	}
 
 	// place the new component into storage before parsing children
-	// to avoid a possible infinite loop
-	cm.storage[storageName] = component
+	// to avoid a possible infinite loop; the textures of a component being
+	// reloaded get released after the new ones are referenced so the ones
+	// they share stay loaded
+	cm.AddComponent(storageName, component)
 
 	// For all of the child references, see if we have a component loaded
 	// for it already. If not, then load those components too.
@@ -204,11 +280,11 @@ func (cm *Manager) LoadComponentFromBytes(jsonBytes []byte, storageName string,
 
 		_, err := cm.LoadComponentFromFile(componentDirPath+childRef.File, storageName)
 		if err != nil {
-			groggy.Logsf("ERROR", "Component %s has a ChildInstance (%s) could not be loaded.\n%v", component.Name, childRef.File, err)
+			fizzle.Logf(fizzle.LogError, "component", "Component %s has a ChildInstance (%s) could not be loaded: %v", component.Name, childRef.File, err)
 		}
 	}
 
-	groggy.Logsf("DEBUG", "Component \"%s\" has been loaded", component.Name)
+	fizzle.Logf(fizzle.LogDebug, "component", "Component \"%s\" has been loaded", component.Name)
 	return component, nil
 }
 
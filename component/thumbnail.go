@@ -0,0 +1,125 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package component
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer/forward"
+)
+
+const (
+	// thumbnailFieldOfView is the vertical field of view of the thumbnail
+	// camera in radians.
+	thumbnailFieldOfView = math.Pi / 4.0
+
+	// thumbnailPadding scales the camera distance to leave a margin around
+	// the object.
+	thumbnailPadding = 1.15
+)
+
+// RenderThumbnail draws an instance of the component into a square image
+// of size by size pixels. See the package level RenderThumbnail for how
+// the object is framed and lit.
+func (cm *Manager) RenderThumbnail(comp *Component, size int32) (image.Image, error) {
+	r := cm.GetRenderableInstance(comp)
+	return RenderThumbnail(r, size)
+}
+
+// RenderThumbnail draws the renderable into a square image of size by size
+// pixels with a transparent background. The camera looks down at the
+// object from the front-right at a distance that fits its whole bounding
+// sphere and it is lit by a key, fill and back light, which is good for
+// asset browsers and tooling. Renderables in LayerGizmos are left out. The
+// rendering happens in an offscreen framebuffer with the current graphics
+// provider and leaves the default framebuffer bound and depth testing
+// enabled; the viewport is left for the caller to restore.
+func RenderThumbnail(r *fizzle.Renderable, size int32) (image.Image, error) {
+	gfx := fizzle.GetGraphics()
+	if size <= 0 {
+		return nil, fmt.Errorf("Failed to render the thumbnail; invalid size %d.", size)
+	}
+
+	colorBuffer := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, colorBuffer)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.RGBA8, size, size)
+
+	depth := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, depth)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.DEPTH_COMPONENT24, size, size)
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, 0)
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.RENDERBUFFER, colorBuffer)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.RENDERBUFFER, depth)
+	defer func() {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		gfx.DeleteRenderbuffer(depth)
+		gfx.DeleteRenderbuffer(colorBuffer)
+	}()
+
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		return nil, fmt.Errorf("Failed to create the framebuffer for the thumbnail (status 0x%x).", status)
+	}
+
+	// frame the bounding sphere of the object
+	min, max := fizzle.GetWorldBounds(r)
+	center := min.Add(max).Mul(0.5)
+	radius := max.Sub(min).Len() * 0.5
+	if radius <= 0.0 {
+		radius = 1.0
+	}
+	distance := radius / float32(math.Sin(thumbnailFieldOfView/2.0)) * thumbnailPadding
+	camera := fizzle.NewOrbitCamera(center, math.Pi/3.0, distance, math.Pi/4.0)
+	perspective := mgl.Perspective(thumbnailFieldOfView, 1.0, distance*0.01, distance+radius*2.0)
+	view := camera.GetViewMatrix()
+
+	// a default three point light rig
+	fr := forward.NewForwardRenderer(gfx)
+	fr.ChangeResolution(size, size)
+	fr.CullMask = fizzle.LayerAll &^ fizzle.LayerGizmos
+	key := fr.NewDirectionalLight(mgl.Vec3{-1.0, -1.0, -1.0}.Normalize())
+	key.DiffuseIntensity = 0.8
+	key.AmbientIntensity = 0.25
+	key.SpecularIntensity = 0.3
+	fill := fr.NewDirectionalLight(mgl.Vec3{1.0, -0.25, -0.5}.Normalize())
+	fill.DiffuseIntensity = 0.35
+	fill.AmbientIntensity = 0.0
+	fill.SpecularIntensity = 0.0
+	back := fr.NewDirectionalLight(mgl.Vec3{0.0, -0.5, 1.0}.Normalize())
+	back.DiffuseIntensity = 0.3
+	back.AmbientIntensity = 0.0
+	back.SpecularIntensity = 0.2
+	fr.ActiveLights[0] = key
+	fr.ActiveLights[1] = fill
+	fr.ActiveLights[2] = back
+
+	gfx.Viewport(0, 0, size, size)
+	gfx.Enable(graphics.DEPTH_TEST)
+	gfx.ClearColor(0.0, 0.0, 0.0, 0.0)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+	fr.DrawRenderable(r, nil, perspective, view, camera)
+	fr.EndRenderFrame()
+
+	pixels := make([]byte, size*size*4)
+	gfx.ReadPixels(0, 0, size, size, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(pixels))
+
+	// OpenGL rows start at the bottom so flip them for the image
+	img := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	stride := int(size) * 4
+	for y := 0; y < int(size); y++ {
+		src := pixels[(int(size)-1-y)*stride : (int(size)-y)*stride]
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], src)
+	}
+
+	return img, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package crowd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// BakedClip is the range of rows in an AnimationTexture that hold the
+// frames of one animation.
+type BakedClip struct {
+	// Name is the name of the animation that was baked.
+	Name string
+
+	// FirstFrame is the row of the texture with the first frame.
+	FirstFrame int32
+
+	// FrameCount is the number of rows the clip takes.
+	FrameCount int32
+
+	// Length is the length of the animation in seconds.
+	Length float32
+}
+
+// AnimationTexture is a float texture with the bone matrices of every
+// frame of a skeleton's animations. Each row is one frame and each bone
+// takes four texels on the row, one for each column of its matrix.
+type AnimationTexture struct {
+	// Texture is the RGBA32F texture holding the bone matrices.
+	Texture graphics.Texture
+
+	// BoneCount is the number of bones of the baked skeleton.
+	BoneCount int
+
+	// FramesPerSecond is the rate the animations were sampled at.
+	FramesPerSecond float32
+
+	// Clips are the baked animations in the order of the skeleton's
+	// Animations slice.
+	Clips []BakedClip
+
+	// Width and Height are the size of the texture in texels.
+	Width  int32
+	Height int32
+}
+
+// BakeAnimations samples every animation of the skeleton at fps frames per
+// second and stores the pose transforms in a new AnimationTexture. The
+// skeleton passed in is not modified.
+func BakeAnimations(skel *fizzle.Skeleton, fps float32) (*AnimationTexture, error) {
+	if skel == nil || len(skel.Bones) == 0 {
+		return nil, fmt.Errorf("Failed to bake the animations because the skeleton has no bones.")
+	}
+	if len(skel.Animations) == 0 {
+		return nil, fmt.Errorf("Failed to bake the animations because the skeleton has no animations.")
+	}
+	if fps <= 0.0 {
+		return nil, fmt.Errorf("Failed to bake the animations because the frame rate %f is not positive.", fps)
+	}
+
+	at := new(AnimationTexture)
+	at.BoneCount = len(skel.Bones)
+	at.FramesPerSecond = fps
+	at.Clips = make([]BakedClip, len(skel.Animations))
+
+	// figure out the rows each clip needs before allocating the texture
+	var frames int32
+	for ai := range skel.Animations {
+		anim := &skel.Animations[ai]
+		clip := &at.Clips[ai]
+		clip.Name = anim.Name
+		clip.Length = fizzle.GetAnimationLength(anim)
+		clip.FirstFrame = frames
+		clip.FrameCount = int32(math.Ceil(float64(clip.Length * fps)))
+		if clip.FrameCount < 1 {
+			clip.FrameCount = 1
+		}
+		frames += clip.FrameCount
+	}
+
+	at.Width = int32(at.BoneCount * 4)
+	at.Height = frames
+	data := make([]float32, int(at.Width)*int(at.Height)*4)
+
+	// use a skeleton of our own so that the one passed in keeps its pose
+	baker := fizzle.NewSkeleton(skel.Bones, skel.Animations)
+	for ai := range baker.Animations {
+		anim := &baker.Animations[ai]
+		clip := &at.Clips[ai]
+		ticksPerSecond := anim.TicksPerSecond
+		if ticksPerSecond <= 0.0 {
+			ticksPerSecond = 1.0
+		}
+
+		for f := int32(0); f < clip.FrameCount; f++ {
+			baker.Animate(anim, float32(f)/fps*ticksPerSecond)
+			row := int(clip.FirstFrame+f) * int(at.Width) * 4
+			for bi, m := range baker.PoseTransforms {
+				copy(data[row+bi*16:row+bi*16+16], m[:])
+			}
+		}
+	}
+
+	gfx := fizzle.GetGraphics()
+	at.Texture = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, at.Texture)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA32F, at.Width, at.Height, 0, graphics.RGBA, graphics.FLOAT, gfx.Ptr(data), len(data)*4)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	return at, nil
+}
+
+// GetClip returns the baked clip for the animation with the name or nil if
+// there isn't one.
+func (at *AnimationTexture) GetClip(name string) *BakedClip {
+	for i := range at.Clips {
+		if at.Clips[i].Name == name {
+			return &at.Clips[i]
+		}
+	}
+	return nil
+}
+
+// Destroy deletes the texture from OpenGL.
+func (at *AnimationTexture) Destroy() {
+	fizzle.GetGraphics().DeleteTexture(at.Texture)
+}
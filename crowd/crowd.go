@@ -0,0 +1,257 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package crowd draws large numbers of animated characters that share a
+skinned mesh with one instanced draw call.
+
+BakeAnimations samples every animation of a skeleton into the rows of a
+float texture once at load time. A Crowd then keeps a list of instances,
+each with its own transform, clip and time, and the crowd shader fetches
+and blends the bone matrices of each instance's current frame from the
+texture. This trades the per-character bone uploads and draw calls of
+the basic skinned shader for a small buffer of instance data.
+
+A simple example:
+
+	anims, err := crowd.BakeAnimations(soldier.Core.Skeleton, 30.0)
+	...
+	shader, err := forward.CreateCrowdShader()
+	...
+	c := crowd.NewCrowd(soldier, anims, shader)
+	walk := anims.GetClip("walk")
+	for i := 0; i < 500; i++ {
+		inst := c.AddInstance(mgl.Vec3{float32(i%25) * 2.0, 0.0, float32(i/25) * 2.0}, walk)
+		inst.Time = rand.Float32() * walk.Length
+	}
+
+	// every frame
+	c.Update(frameDelta)
+	c.Draw(renderer, perspective, view, camera)
+
+*/
+package crowd
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+	"github.com/tbogdala/fizzle/renderer/forward"
+)
+
+const (
+	// instanceFloats is the number of floats in the instance buffer for
+	// each instance: the model matrix followed by the animation vector.
+	instanceFloats = 20
+
+	// floatSize is the size of a float32 in bytes.
+	floatSize = 4
+)
+
+// Instance is one character of a Crowd.
+type Instance struct {
+	// Location, Rotation and Scale are the transform of the instance
+	// relative to the crowd's Renderable.
+	Location mgl.Vec3
+	Rotation mgl.Quat
+	Scale    mgl.Vec3
+
+	// Clip is the baked animation the instance plays. Instances without a
+	// clip are drawn in the first frame of the texture.
+	Clip *BakedClip
+
+	// Time is the time into the clip in seconds.
+	Time float32
+
+	// Speed scales how fast Update advances Time.
+	Speed float32
+
+	// Loop wraps the time around the end of the clip; otherwise the
+	// instance holds the last frame.
+	Loop bool
+
+	// Hidden instances are skipped when drawing.
+	Hidden bool
+}
+
+// Crowd draws many instances of a skinned Renderable animated with an
+// AnimationTexture.
+type Crowd struct {
+	// Renderable is the skinned mesh drawn for every instance. Its
+	// transform is applied on top of each instance's transform. It needs
+	// to keep its bind pose in the vertices, so it can't be skinned on the
+	// CPU.
+	Renderable *fizzle.Renderable
+
+	// Animations is the baked animation texture for the Renderable's
+	// skeleton.
+	Animations *AnimationTexture
+
+	// Instances are the characters of the crowd.
+	Instances []*Instance
+
+	// Shader is the shader used to draw the crowd, which is normally
+	// created with forward.CreateCrowdShader.
+	Shader *fizzle.RenderShader
+
+	instanceVBO  graphics.Buffer
+	data         []float32
+	boundAttribs []uint32
+}
+
+// NewCrowd creates a new Crowd without any instances.
+func NewCrowd(r *fizzle.Renderable, animations *AnimationTexture, shader *fizzle.RenderShader) *Crowd {
+	c := new(Crowd)
+	c.Renderable = r
+	c.Animations = animations
+	c.Shader = shader
+	c.Instances = []*Instance{}
+	c.instanceVBO = fizzle.GetGraphics().GenBuffer()
+	return c
+}
+
+// Destroy deletes the instance buffer from OpenGL. The Renderable and the
+// AnimationTexture are not destroyed since they can be shared.
+func (c *Crowd) Destroy() {
+	fizzle.GetGraphics().DeleteBuffer(c.instanceVBO)
+}
+
+// AddInstance adds a new looping instance at the location that plays the
+// clip and returns it so that it can be customized further.
+func (c *Crowd) AddInstance(location mgl.Vec3, clip *BakedClip) *Instance {
+	inst := new(Instance)
+	inst.Location = location
+	inst.Rotation = mgl.QuatIdent()
+	inst.Scale = mgl.Vec3{1.0, 1.0, 1.0}
+	inst.Clip = clip
+	inst.Speed = 1.0
+	inst.Loop = true
+	c.Instances = append(c.Instances, inst)
+	return inst
+}
+
+// Update advances the time of every instance by the number of seconds
+// scaled by the instance's Speed.
+func (c *Crowd) Update(dt float32) {
+	for _, inst := range c.Instances {
+		inst.Time += dt * inst.Speed
+	}
+}
+
+// Draw uploads the instance data and draws all of the visible instances
+// with one instanced draw call.
+func (c *Crowd) Draw(fr *forward.ForwardRenderer, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	count := c.fillInstanceData()
+	if count == 0 {
+		return
+	}
+
+	gfx := fizzle.GetGraphics()
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, c.instanceVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(c.data), gfx.Ptr(&c.data[0]), graphics.DYNAMIC_DRAW)
+
+	c.boundAttribs = c.boundAttribs[:0]
+	fr.DrawRenderableInstanced(c.Renderable, c.Shader, count, c.bindInstances, perspective, view, camera)
+
+	// the vertex array keeps the divisors, so reset them or other shaders
+	// drawing the same core would read the instance buffer
+	if len(c.boundAttribs) > 0 {
+		gfx.BindVertexArray(c.Renderable.Core.Vao)
+		for _, attrib := range c.boundAttribs {
+			gfx.VertexAttribDivisor(attrib, 0)
+			gfx.DisableVertexAttribArray(attrib)
+		}
+		gfx.BindVertexArray(0)
+	}
+}
+
+// fillInstanceData writes the model matrix and animation vector of every
+// visible instance to the data slice and returns the number written.
+func (c *Crowd) fillInstanceData() int32 {
+	c.data = c.data[:0]
+	var count int32
+	for _, inst := range c.Instances {
+		if inst.Hidden {
+			continue
+		}
+
+		model := mgl.Translate3D(inst.Location[0], inst.Location[1], inst.Location[2])
+		model = model.Mul4(inst.Rotation.Mat4())
+		model = model.Mul4(mgl.Scale3D(inst.Scale[0], inst.Scale[1], inst.Scale[2]))
+		c.data = append(c.data, model[:]...)
+
+		var first, frames, frame float32
+		frames = 1.0
+		if inst.Clip != nil {
+			first = float32(inst.Clip.FirstFrame)
+			frames = float32(inst.Clip.FrameCount)
+			frame = c.getFrame(inst)
+		}
+		c.data = append(c.data, first, frames, frame, 0.0)
+		count++
+	}
+	return count
+}
+
+// getFrame returns the frame of the instance's clip at its time, with the
+// fraction being how far to blend into the next frame.
+func (c *Crowd) getFrame(inst *Instance) float32 {
+	frames := float32(inst.Clip.FrameCount)
+	frame := inst.Time * c.Animations.FramesPerSecond
+	if inst.Loop {
+		frame = float32(math.Mod(float64(frame), float64(frames)))
+		if frame < 0.0 {
+			frame += frames
+		}
+		return frame
+	}
+
+	// hold the last frame without blending back to the first
+	if frame < 0.0 {
+		return 0.0
+	} else if frame > frames-1.0 {
+		return frames - 1.0
+	}
+	return frame
+}
+
+// bindInstances is the RenderBinder that sets up the per-instance vertex
+// attributes and binds the animation texture.
+func (c *Crowd) bindInstances(rend renderer.Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+	gfx := rend.GetGraphics()
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, c.instanceVBO)
+	const stride = instanceFloats * floatSize
+
+	// a mat4 attribute takes four locations, one for each column
+	shaderModel := shader.GetAttribLocation("INSTANCE_MODEL")
+	if shaderModel >= 0 {
+		for col := 0; col < 4; col++ {
+			attrib := uint32(shaderModel) + uint32(col)
+			gfx.EnableVertexAttribArray(attrib)
+			gfx.VertexAttribPointer(attrib, 4, graphics.FLOAT, false, stride, gfx.PtrOffset(col*4*floatSize))
+			gfx.VertexAttribDivisor(attrib, 1)
+			c.boundAttribs = append(c.boundAttribs, attrib)
+		}
+	}
+
+	shaderAnimation := shader.GetAttribLocation("INSTANCE_ANIMATION")
+	if shaderAnimation >= 0 {
+		attrib := uint32(shaderAnimation)
+		gfx.EnableVertexAttribArray(attrib)
+		gfx.VertexAttribPointer(attrib, 4, graphics.FLOAT, false, stride, gfx.PtrOffset(16*floatSize))
+		gfx.VertexAttribDivisor(attrib, 1)
+		c.boundAttribs = append(c.boundAttribs, attrib)
+	}
+
+	shaderTexture := shader.GetUniformLocation("CROWD_ANIMATION")
+	if shaderTexture >= 0 && c.Animations != nil {
+		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+		gfx.BindTexture(graphics.TEXTURE_2D, c.Animations.Texture)
+		gfx.Uniform1i(shaderTexture, *texturesBound)
+		*texturesBound++
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// CubeMapFaceCount is the number of faces in a cube map.
+const CubeMapFaceCount = 6
+
+// cubeMapTargets are the texture targets for each face of a cube map in the
+// order that face images are passed to the cube map loading functions:
+// +X, -X, +Y, -Y, +Z, -Z.
+var cubeMapTargets = [CubeMapFaceCount]graphics.Enum{
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_X,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_X,
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_Y,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_Y,
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_Z,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_Z,
+}
+
+// LoadCubeMapFromFiles loads six image files into a cube map texture. The
+// files should be in the order of +X, -X, +Y, -Y, +Z, -Z and all be square
+// images of the same size.
+func LoadCubeMapFromFiles(filePaths [CubeMapFaceCount]string) (graphics.Texture, error) {
+	faces, err := loadCubeMapFaceFiles(filePaths)
+	if err != nil {
+		return 0, err
+	}
+	return LoadImagesToCubeMap(faces)
+}
+
+// LoadCubeMapFromFile loads a single image file into a cube map texture. The
+// image can be a horizontal cross (4x3 faces), a vertical cross (3x4 faces)
+// or an equirectangular panorama (2x1), which is detected by the aspect
+// ratio of the image.
+func LoadCubeMapFromFile(filePath string) (graphics.Texture, error) {
+	faces, err := loadCubeMapFacesFromFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return LoadImagesToCubeMap(faces)
+}
+
+// loadCubeMapFaceFiles loads the six face images of a cube map.
+func loadCubeMapFaceFiles(filePaths [CubeMapFaceCount]string) ([CubeMapFaceCount]*image.NRGBA, error) {
+	var faces [CubeMapFaceCount]*image.NRGBA
+	for i, filePath := range filePaths {
+		face, err := loadFileUnflipped(filePath)
+		if err != nil {
+			return faces, err
+		}
+		faces[i] = face
+	}
+	return faces, nil
+}
+
+// loadCubeMapFacesFromFile splits a cross or equirectangular image into
+// the six face images of a cube map.
+func loadCubeMapFacesFromFile(filePath string) ([CubeMapFaceCount]*image.NRGBA, error) {
+	var faces [CubeMapFaceCount]*image.NRGBA
+	img, err := loadFileUnflipped(filePath)
+	if err != nil {
+		return faces, err
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	switch {
+	case w*3 == h*4:
+		faces = cubeFacesFromHorizontalCross(img, w/4)
+	case w*4 == h*3:
+		faces = cubeFacesFromVerticalCross(img, w/3)
+	case w == h*2:
+		faces = cubeFacesFromEquirect(img, w/4)
+	default:
+		return faces, fmt.Errorf("Failed to load the cube map %s because a %dx%d image is not a cross or equirectangular layout.", filePath, w, h)
+	}
+	return faces, nil
+}
+
+// LoadImagesToCubeMap creates a cube map texture from six square face images
+// of the same size in the order of +X, -X, +Y, -Y, +Z, -Z. The first row of
+// each image is the top of the face. The texture gets mipmaps generated
+// and is set to trilinear filtering that clamps to the edges.
+func LoadImagesToCubeMap(faces [CubeMapFaceCount]*image.NRGBA) (graphics.Texture, error) {
+	size := faces[0].Bounds().Dx()
+	for _, face := range faces {
+		if face.Bounds().Dx() != size || face.Bounds().Dy() != size {
+			return 0, fmt.Errorf("Failed to create the cube map because the faces are not all square images of the same size.")
+		}
+	}
+
+	tex := gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, tex)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR_MIPMAP_LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_R, graphics.CLAMP_TO_EDGE)
+
+	for i, face := range faces {
+		gfx.TexImage2D(cubeMapTargets[i], 0, graphics.RGBA, int32(size), int32(size), 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(face.Pix), len(face.Pix))
+	}
+
+	gfx.GenerateMipmap(graphics.TEXTURE_CUBE_MAP)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+	return tex, nil
+}
+
+// loadFileUnflipped loads a PNG file into an NRGBA image without flipping it,
+// which is the orientation cube map faces are expected in.
+func loadFileUnflipped(filePath string) (*image.NRGBA, error) {
+	imgFile, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the texture file: %v\n", err)
+	}
+
+	img, err := png.Decode(imgFile)
+	imgFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the texture: %v\n", err)
+	}
+
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba, nil
+}
+
+// cubeFaceFromImage copies a size x size region of the image at the face
+// cell column and row, optionally rotated 180 degrees.
+func cubeFaceFromImage(img *image.NRGBA, size, col, row int, rotate bool) *image.NRGBA {
+	face := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			sx, sy := x, y
+			if rotate {
+				sx, sy = size-x-1, size-y-1
+			}
+			soffset := (row*size+sy)*img.Stride + (col*size+sx)*4
+			doffset := y*face.Stride + x*4
+			copy(face.Pix[doffset:doffset+4], img.Pix[soffset:soffset+4])
+		}
+	}
+	return face
+}
+
+// cubeFacesFromHorizontalCross splits an image laid out as:
+//
+//	    +Y
+//	-X  +Z  +X  -Z
+//	    -Y
+func cubeFacesFromHorizontalCross(img *image.NRGBA, size int) [CubeMapFaceCount]*image.NRGBA {
+	return [CubeMapFaceCount]*image.NRGBA{
+		cubeFaceFromImage(img, size, 2, 1, false),
+		cubeFaceFromImage(img, size, 0, 1, false),
+		cubeFaceFromImage(img, size, 1, 0, false),
+		cubeFaceFromImage(img, size, 1, 2, false),
+		cubeFaceFromImage(img, size, 1, 1, false),
+		cubeFaceFromImage(img, size, 3, 1, false),
+	}
+}
+
+// cubeFacesFromVerticalCross splits an image laid out as below, where the
+// -Z face is upside down:
+//
+//	    +Y
+//	-X  +Z  +X
+//	    -Y
+//	    -Z
+func cubeFacesFromVerticalCross(img *image.NRGBA, size int) [CubeMapFaceCount]*image.NRGBA {
+	return [CubeMapFaceCount]*image.NRGBA{
+		cubeFaceFromImage(img, size, 2, 1, false),
+		cubeFaceFromImage(img, size, 0, 1, false),
+		cubeFaceFromImage(img, size, 1, 0, false),
+		cubeFaceFromImage(img, size, 1, 2, false),
+		cubeFaceFromImage(img, size, 1, 1, false),
+		cubeFaceFromImage(img, size, 1, 3, true),
+	}
+}
+
+// cubeFaceDirection returns the direction out of the cube for a face at the
+// face coordinates s and t in the range of [-1, 1], with t going down the face.
+func cubeFaceDirection(face int, s, t float64) (float64, float64, float64) {
+	switch face {
+	case 0:
+		return 1.0, -t, -s
+	case 1:
+		return -1.0, -t, s
+	case 2:
+		return s, 1.0, t
+	case 3:
+		return s, -1.0, -t
+	case 4:
+		return s, -t, 1.0
+	default:
+		return -s, -t, -1.0
+	}
+}
+
+// cubeFacesFromEquirect projects an equirectangular panorama onto the six
+// faces of a cube using bilinear filtering.
+func cubeFacesFromEquirect(img *image.NRGBA, size int) [CubeMapFaceCount]*image.NRGBA {
+	var faces [CubeMapFaceCount]*image.NRGBA
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	for f := range faces {
+		face := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				s := 2.0*(float64(x)+0.5)/float64(size) - 1.0
+				t := 2.0*(float64(y)+0.5)/float64(size) - 1.0
+				dx, dy, dz := cubeFaceDirection(f, s, t)
+				length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+
+				// longitude goes around the panorama with -Z in the center
+				// and latitude goes from the top to the bottom
+				lon := math.Atan2(dx, -dz)
+				lat := math.Asin(dy / length)
+				u := (lon/(2.0*math.Pi) + 0.5) * float64(w)
+				v := (0.5 - lat/math.Pi) * float64(h)
+
+				doffset := y*face.Stride + x*4
+				sampleBilinear(img, u-0.5, v-0.5, face.Pix[doffset:doffset+4])
+			}
+		}
+		faces[f] = face
+	}
+
+	return faces
+}
+
+// sampleBilinear writes the bilinearly filtered color of the image at the
+// pixel coordinates to dst. The coordinates wrap horizontally and clamp
+// vertically.
+func sampleBilinear(img *image.NRGBA, u, v float64, dst []uint8) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	fu, fv := math.Floor(u), math.Floor(v)
+	tu, tv := u-fu, v-fv
+
+	x0 := ((int(fu) % w) + w) % w
+	x1 := (x0 + 1) % w
+	y0 := clampInt(int(fv), 0, h-1)
+	y1 := clampInt(int(fv)+1, 0, h-1)
+
+	p00 := img.Pix[y0*img.Stride+x0*4:]
+	p10 := img.Pix[y0*img.Stride+x1*4:]
+	p01 := img.Pix[y1*img.Stride+x0*4:]
+	p11 := img.Pix[y1*img.Stride+x1*4:]
+	for c := 0; c < 4; c++ {
+		top := float64(p00[c])*(1.0-tu) + float64(p10[c])*tu
+		bottom := float64(p01[c])*(1.0-tu) + float64(p11[c])*tu
+		dst[c] = uint8(top*(1.0-tv) + bottom*tv + 0.5)
+	}
+}
+
+// clampInt clamps an integer to the range of [min, max].
+func clampInt(i, min, max int) int {
+	if i < min {
+		return min
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
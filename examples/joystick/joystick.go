@@ -6,15 +6,14 @@ package main
 import (
 	"fmt"
 	"os"
-	"runtime"
 
 	gl "github.com/go-gl/gl/v3.3-core/gl"
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
 
 	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/app"
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
-	"github.com/tbogdala/fizzle/graphicsprovider/opengl"
 	forward "github.com/tbogdala/fizzle/renderer/forward"
 
 	gui "github.com/tbogdala/eweygewey"
@@ -26,12 +25,9 @@ import (
 
   Coincidently, this also can be used as a tool to see what axis/buttons do
   for a given joystick in GLFW.
-*/
 
-// GLFW event handling must run on the main OS thread.
-func init() {
-	runtime.LockOSThread()
-}
+  The window setup and main loop are handled by the app package.
+*/
 
 const (
 	width  = 1280
@@ -44,20 +40,25 @@ const (
 
 // main is the entry point for the application.
 func main() {
-	// start off by initializing the GL and GLFW libraries and creating a window.
-	// the default window size we use is 800x600
-	mainWindow, gfx := initGraphics("Joystick Mapper", width, height)
+	// start off by initializing the GL and GLFW libraries and creating a window
+	// along with a renderer.
+	opts := app.DefaultOptions()
+	opts.Title = "Joystick Mapper"
+	opts.Width = width
+	opts.Height = height
+	opts.Resizable = false
+	mainWindow, gfx, renderer, err := app.NewWindow(opts)
+	if err != nil {
+		fmt.Printf("Failed to create the window!\n%v", err)
+		os.Exit(1)
+	}
 
 	// set the callback function for key input
 	mainWindow.SetKeyCallback(keyCallback)
 
-	// create a new renderer
-	renderer := forward.NewForwardRenderer(gfx)
-	defer renderer.Destroy()
-
 	// create and initialize the gui Manager
 	uiman := gui.NewManager(gfx)
-	err := uiman.Initialize(gui.VertShader330, gui.FragShader330, width, height, height)
+	err = uiman.Initialize(gui.VertShader330, gui.FragShader330, width, height, height)
 	if err != nil {
 		fmt.Printf("Failed to initialize the user interface!\n%v", err)
 		os.Exit(1)
@@ -126,53 +127,17 @@ func main() {
 	//joystickWindow.Style.WindowBgColor[3] = 1.0 // turn off transparent bg
 
 	// loop until something told the mainWindow that it should close
-	for !mainWindow.ShouldClose() {
+	app.Run(func(dt float32) {
 		// clear the screen
-		gl.Viewport(0, 0, int32(width), int32(height))
+		w, h := renderer.GetResolution()
+		gl.Viewport(0, 0, w, h)
 		gl.ClearColor(0.05, 0.05, 0.05, 1.0)
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		// draw the user interface
 		uiman.Construct(0)
 		uiman.Draw()
-
-		// draw the screen
-		mainWindow.SwapBuffers()
-		glfw.PollEvents()
-	}
-}
-
-// initGraphics creates an OpenGL window and initializes the required graphics libraries.
-// It will either succeed or panic.
-func initGraphics(title string, w int, h int) (*glfw.Window, graphics.GraphicsProvider) {
-	// GLFW must be initialized before it's called
-	err := glfw.Init()
-	if err != nil {
-		panic("Can't init glfw! " + err.Error())
-	}
-
-	// request a OpenGL 3.3 core context
-	glfw.WindowHint(glfw.Samples, 4)
-	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-
-	// do the actual window creation
-	mainWindow, err := glfw.CreateWindow(w, h, title, nil, nil)
-	if err != nil {
-		panic("Failed to create the main window! " + err.Error())
-	}
-	mainWindow.MakeContextCurrent()
-
-	// initialize OpenGL
-	gfx, err := opengl.InitOpenGL()
-	if err != nil {
-		panic("Failed to initialize OpenGL! " + err.Error())
-	}
-	fizzle.SetGraphics(gfx)
-
-	return mainWindow, gfx
+	})
 }
 
 // keyCallback is set as a callback in main() and is used to close the window
@@ -75,21 +75,25 @@ func main() {
 	camera := fizzle.NewYawPitchCamera(mgl.Vec3{0.0, 5.0, 5.0})
 	camera.SetYawAndPitch(0.0, mgl.DegToRad(60))
 
+	// the resource manager owns the shaders, textures and meshes and
+	// destroys all of them at once when the example exits
+	resources := fizzle.NewResourceManager()
+	defer resources.Destroy()
+
 	// load the basic shader
 	basicShader, err := forward.CreateBasicShader()
 	if err != nil {
 		fmt.Printf("Failed to compile and link the basic shader program!\n%v", err)
 		os.Exit(1)
 	}
-	defer basicShader.Destroy()
+	resources.AddShader("basic", basicShader)
 
 	// load the shader used to draw the shadowmap as a texture in the UI
-	shadowmapTextureShader, err := fizzle.LoadShaderProgramFromFiles(shadowmapTextureShaderPath, nil)
+	shadowmapTextureShader, err := resources.LoadShaderFromFiles("shadowmap_texture", shadowmapTextureShaderPath, nil)
 	if err != nil {
 		fmt.Printf("Failed to compile and link the shadowmap texture shader program!\n%v", err)
 		os.Exit(1)
 	}
-	defer shadowmapTextureShader.Destroy()
 
 	// loadup the shadowmap shader used to generate the shadows
 	shadowmapShader, err := forward.CreateShadowmapGeneratorShader()
@@ -97,10 +101,10 @@ func main() {
 		fmt.Printf("Failed to compile and link the shadowmap generator shader program!\n%v", err)
 		os.Exit(1)
 	}
-	defer shadowmapShader.Destroy()
+	resources.AddShader("shadowmap_generator", shadowmapShader)
 
 	// load up some textures
-	textureMan := fizzle.NewTextureManager()
+	textureMan := resources.Textures
 
 	diffuseTex, err := textureMan.LoadTexture("cube_diffuse", testDiffusePath)
 	if err != nil {
@@ -109,7 +113,7 @@ func main() {
 	}
 	fmt.Printf("Loaded the diffuse texture at %s(%d).\n", testDiffusePath, diffuseTex)
 
-	normalsTex, err := textureMan.LoadTexture("cube_diffuse", testNormalsPath)
+	normalsTex, err := textureMan.LoadTexture("cube_normals", testNormalsPath)
 	if err != nil {
 		fmt.Printf("Failed to load the normals texture at %s!\n%v", testNormalsPath, err)
 		os.Exit(1)
@@ -128,6 +132,7 @@ func main() {
 	floorPlane := fizzle.CreatePlaneXZ(-0.5, 0.5, 0.5, -0.5)
 	floorPlane.Scale = mgl.Vec3{10, 10, 10}
 	floorPlane.Material = floorMaterial
+	resources.AddCore("floor", floorPlane.Core)
 
 	// create the test cube to rotate
 	cubeMaterial := fizzle.NewMaterial()
@@ -141,6 +146,7 @@ func main() {
 	testCube := fizzle.CreateCube(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5)
 	testCube.Location = mgl.Vec3{-2.5, 1.0, 0.0}
 	testCube.Material = cubeMaterial
+	resources.AddCore("cube", testCube.Core)
 
 	// enable shadow mapping in the renderer
 	renderer.SetupShadowMapRendering()
@@ -168,6 +174,7 @@ func main() {
 
 	shadowMapUIQuad := fizzle.CreatePlaneXY(0, 0, 256, 256)
 	shadowMapUIQuad.Material = shadowMapUIMat
+	resources.AddCore("shadowmap_quad", shadowMapUIQuad.Core)
 
 	// set some OpenGL flags
 	gfx.Enable(graphics.CULL_FACE)
@@ -36,6 +36,7 @@ func (s *InputSystem) Initialize(w *glfw.Window) {
 	// set the callback functions for key input
 	s.kbModel = input.NewKeyboardModel(s.mainWindow)
 	s.kbModel.BindTrigger(glfw.KeyEscape, setShouldClose)
+	s.kbModel.BindTrigger(glfw.KeyF3, toggleOverlay)
 	s.kbModel.SetupCallbacks()
 
 }
@@ -80,3 +81,10 @@ func setShouldClose() {
 	inputSystem := system.(*InputSystem)
 	inputSystem.mainWindow.SetShouldClose(true)
 }
+
+// toggleOverlay shows or hides the performance overlay.
+func toggleOverlay() {
+	system := sceneMan.GetSystemByName(scene.RenderSystemName)
+	renderSystem := system.(*RenderSystem)
+	renderSystem.Overlay.Toggle()
+}
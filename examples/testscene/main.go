@@ -10,6 +10,7 @@ import (
 	"time"
 
 	input "github.com/tbogdala/fizzle/input/glfwinput"
+	scene "github.com/tbogdala/fizzle/scene"
 )
 
 // GLFW event handling must run on the main OS thread.
@@ -44,6 +45,7 @@ func main() {
 
 	// create a scene manager
 	sceneMan = NewTestScene()
+	sceneMan.AddSystem(scene.NewTransformSystem())
 	sceneMan.AddSystem(renderSystem)
 	sceneMan.AddSystem(inputSystem)
 
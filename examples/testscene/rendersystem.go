@@ -7,36 +7,41 @@ import (
 	"fmt"
 
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
-	mgl "github.com/go-gl/mathgl/mgl32"
 
 	fizzle "github.com/tbogdala/fizzle"
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
 	opengl "github.com/tbogdala/fizzle/graphicsprovider/opengl"
+	"github.com/tbogdala/fizzle/profiler"
+	renderer "github.com/tbogdala/fizzle/renderer"
 	forward "github.com/tbogdala/fizzle/renderer/forward"
 	"github.com/tbogdala/fizzle/scene"
+	"github.com/tbogdala/fizzle/text"
 )
 
 const (
-	renderSystemPriority = 100.0
-	renderSystemName     = "RenderSystem"
+	overlayFontPath = "../assets/HammersmithOne.ttf"
 )
 
-// RenderSystem implements fizzle/scene/System interface and handles the rendering
-// of entities in the scene.
+// RenderSystem wraps the library's scene.RenderSystem with the window
+// handling for the example.
 type RenderSystem struct {
-	Renderer   *forward.ForwardRenderer
+	*scene.RenderSystem
+
 	MainWindow *glfw.Window
-	Camera     fizzle.Camera
 
-	gfx graphics.GraphicsProvider
+	// Profiler collects the frame statistics shown by Overlay, which is
+	// toggled with F3.
+	Profiler *profiler.Profiler
+	Overlay  *profiler.Overlay
 
-	visibleEntities []scene.Entity
+	gfx     graphics.GraphicsProvider
+	resizer *renderer.ResizeDebouncer
 }
 
 // NewRenderSystem allocates a new RenderSystem object.
 func NewRenderSystem() *RenderSystem {
 	rs := new(RenderSystem)
-	rs.visibleEntities = []scene.Entity{}
+	rs.resizer = renderer.NewResizeDebouncer()
 	return rs
 }
 
@@ -49,9 +54,24 @@ func (rs *RenderSystem) Initialize(windowName string, w int, h int) error {
 		return err
 	}
 
-	// setup the forward renderer
-	rs.Renderer = forward.NewForwardRenderer(rs.gfx)
-	rs.Renderer.ChangeResolution(int32(w), int32(h))
+	// setup the forward renderer and the scene system that draws with it
+	fr := forward.NewForwardRenderer(rs.gfx)
+	fr.ChangeResolution(int32(w), int32(h))
+	rs.RenderSystem = scene.NewRenderSystem(fr)
+	rs.Near = 1.0
+	rs.BeforeDraw = rs.beforeDraw
+	rs.AfterDraw = rs.afterDraw
+
+	// setup the performance overlay
+	font, err := text.LoadFont(overlayFontPath, 32, text.DefaultRunes)
+	if err != nil {
+		return fmt.Errorf("Failed to load the font for the profiler overlay. %v", err)
+	}
+	rs.Profiler = profiler.NewProfiler(profiler.DefaultHistorySize)
+	rs.Overlay, err = profiler.NewOverlay(rs.Profiler, font)
+	if err != nil {
+		return err
+	}
 
 	// set some OpenGL flags
 	rs.gfx.Enable(graphics.CULL_FACE)
@@ -82,9 +102,9 @@ func (rs *RenderSystem) initGraphics(title string, w int, h int) error {
 		return fmt.Errorf("Failed to create the main window. %v", err)
 	}
 
-	// set a function to update the renderer on window resize
+	// set a function to update the renderer once the window stops changing size
 	rs.MainWindow.SetSizeCallback(func(w *glfw.Window, width int, height int) {
-		rs.Renderer.ChangeResolution(int32(width), int32(height))
+		rs.resizer.Resize(int32(width), int32(height))
 	})
 
 	rs.MainWindow.MakeContextCurrent()
@@ -102,70 +122,23 @@ func (rs *RenderSystem) initGraphics(title string, w int, h int) error {
 	return nil
 }
 
-// SetLight puts a light in the specified slot for the renderer.
-func (rs *RenderSystem) SetLight(i int, l *forward.Light) {
-	rs.Renderer.ActiveLights[i] = l
-}
+// beforeDraw applies window size changes and clears the screen.
+func (rs *RenderSystem) beforeDraw(*scene.RenderSystem) {
+	rs.Profiler.BeginFrame()
 
-// GetRequestedPriority returns the requested priority level for the System
-// which may be of significance to a Manager if they want to order Update() calls.
-func (rs *RenderSystem) GetRequestedPriority() float32 {
-	return renderSystemPriority
-}
-
-// GetName returns the name of the system that can be used to identify
-// the System within Manager.
-func (rs *RenderSystem) GetName() string {
-	return renderSystemName
-}
-
-// OnAddEntity should get called by the scene Manager each time a new entity
-// has been added to the scene.
-func (rs *RenderSystem) OnAddEntity(newEntity scene.Entity) {
-	_, okay := newEntity.(RenderableEntity)
-	if okay {
-		rs.visibleEntities = append(rs.visibleEntities, newEntity)
+	if newWidth, newHeight, changed := rs.resizer.Update(); changed {
+		rs.Renderer.ChangeResolution(newWidth, newHeight)
 	}
-}
-
-// OnRemoveEntity should get called by the scene Manager each time an entity
-// has been removed from the scene.
-func (rs *RenderSystem) OnRemoveEntity(oldEntity scene.Entity) {
-	surviving := rs.visibleEntities[:0]
-	for _, e := range rs.visibleEntities {
-		if e.GetID() != oldEntity.GetID() {
-			surviving = append(surviving, e)
-		}
-	}
-	rs.visibleEntities = surviving
-}
 
-// Update renderers the known entities.
-func (rs *RenderSystem) Update(frameDelta float32) {
-	// clear the screen
 	width, height := rs.Renderer.GetResolution()
-	rs.gfx.Viewport(0, 0, int32(width), int32(height))
+	rs.gfx.Viewport(0, 0, width, height)
 	rs.gfx.ClearColor(0.25, 0.25, 0.25, 1.0)
 	rs.gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+}
 
-	// make the projection and view matrixes
-	projection := mgl.Perspective(mgl.DegToRad(60.0), float32(width)/float32(height), 1.0, 100.0)
-	var view mgl.Mat4
-	if rs.Camera != nil {
-		view = rs.Camera.GetViewMatrix()
-	} else {
-		view = mgl.Ident4()
-	}
-
-	// draw stuff the visible entities
-	for _, e := range rs.visibleEntities {
-		visibleEntity, okay := e.(RenderableEntity)
-		if okay {
-			r := visibleEntity.GetRenderable()
-			rs.Renderer.DrawRenderable(r, nil, projection, view, rs.Camera)
-		}
-	}
-
-	// draw the screen
+// afterDraw draws the performance overlay and shows the frame.
+func (rs *RenderSystem) afterDraw(*scene.RenderSystem) {
+	rs.Profiler.EndFrame()
+	rs.Overlay.Draw(rs.Renderer)
 	rs.MainWindow.SwapBuffers()
 }
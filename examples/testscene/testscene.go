@@ -33,18 +33,21 @@ func NewTestScene() *TestScene {
 // SetupScene initializes the scene's assets and sets up the initial entities.
 func (s *TestScene) SetupScene() error {
 	// pull a reference to the render system
-	system := s.BasicSceneManager.GetSystemByName(renderSystemName)
+	system := s.BasicSceneManager.GetSystemByName(scene.RenderSystemName)
 	renderSystem := system.(*RenderSystem)
 
 	// setup the camera to look at the cube
 	renderSystem.Camera = fizzle.NewOrbitCamera(mgl.Vec3{0, 0, 0}, math.Pi/2.0, 5.0, math.Pi/2.0)
 
 	// put a light in there
-	light := renderSystem.Renderer.NewDirectionalLight(mgl.Vec3{1.0, -0.5, -1.0})
+	fr := renderSystem.Renderer.(*forward.ForwardRenderer)
+	light := fr.NewDirectionalLight(mgl.Vec3{1.0, -0.5, -1.0})
 	light.AmbientIntensity = 0.3
 	light.DiffuseIntensity = 0.5
 	light.SpecularIntensity = 0.3
-	renderSystem.SetLight(0, light)
+	lightEntity := scene.NewLightSourceEntity(light)
+	lightEntity.ID = s.GetNextID()
+	s.AddEntity(lightEntity)
 
 	// load the basic shader
 	basicShader, err := forward.CreateBasicShader()
@@ -60,9 +63,8 @@ func (s *TestScene) SetupScene() error {
 	redMaterial.Shininess = 4.8
 
 	// create the test cube
-	cubeEntity := NewVisibleEntity()
+	cubeEntity := scene.NewVisibleEntity(fizzle.CreateCube(-1, -1, -1, 1, 1, 1))
 	cubeEntity.ID = s.GetNextID()
-	cubeEntity.Renderable = fizzle.CreateCube(-1, -1, -1, 1, 1, 1)
 	cubeEntity.Renderable.Material = redMaterial
 	s.AddEntity(cubeEntity)
 
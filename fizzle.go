@@ -13,7 +13,6 @@ package fizzle
 
 import (
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
-	"github.com/tbogdala/groggy"
 )
 
 // gfx is the currently initialized GraphicsProvider. It is accessed
@@ -61,7 +60,7 @@ func DebugCheckForError(msg string) {
 			default:
 				errTypeStr = "Undefined Error"
 			}
-			groggy.Logsf("DEBUG", "OpenGL error %d(0x%x) detected (%s): %s", int(err), int(err), msg, errTypeStr)
+			Logf(LogDebug, "gl", "OpenGL error %d(0x%x) detected (%s): %s", int(err), int(err), msg, errTypeStr)
 		}
 		err = gfx.GetError()
 	}
@@ -0,0 +1,187 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// CameraCollider is an interface for objects that a FollowCamera should
+// not pass through.
+type CameraCollider interface {
+	// SphereCast moves a sphere of the given radius from origin along the
+	// normalized direction and returns the distance travelled when it first
+	// touches the collider. False is returned if it doesn't touch within
+	// maxDistance.
+	SphereCast(origin, direction mgl.Vec3, radius, maxDistance float32) (float32, bool)
+}
+
+// SphereCameraCollider is a sphere that cameras can collide with.
+type SphereCameraCollider struct {
+	Center mgl.Vec3
+	Radius float32
+}
+
+// SphereCast moves a sphere along a direction and returns the distance at
+// which it touches the collider.
+func (s *SphereCameraCollider) SphereCast(origin, direction mgl.Vec3, radius, maxDistance float32) (float32, bool) {
+	// this is the same as a ray against a sphere grown by the cast radius
+	combined := s.Radius + radius
+	toOrigin := origin.Sub(s.Center)
+	b := toOrigin.Dot(direction)
+	c := toOrigin.Dot(toOrigin) - combined*combined
+
+	// starting inside counts as touching right away
+	if c <= 0.0 {
+		return 0.0, true
+	}
+
+	// moving away from the sphere
+	if b > 0.0 {
+		return 0.0, false
+	}
+
+	discriminant := b*b - c
+	if discriminant < 0.0 {
+		return 0.0, false
+	}
+
+	dist := -b - float32(math.Sqrt(float64(discriminant)))
+	if dist > maxDistance {
+		return 0.0, false
+	}
+	return dist, true
+}
+
+// AABBCameraCollider is an axis aligned box that cameras can collide with.
+type AABBCameraCollider struct {
+	Min mgl.Vec3
+	Max mgl.Vec3
+}
+
+// SphereCast moves a sphere along a direction and returns the distance at
+// which it touches the collider. The box is grown by the radius of the
+// sphere, which is slightly conservative around the edges and corners.
+func (b *AABBCameraCollider) SphereCast(origin, direction mgl.Vec3, radius, maxDistance float32) (float32, bool) {
+	grow := mgl.Vec3{radius, radius, radius}
+	return rayVsAABB(origin, direction, b.Min.Sub(grow), b.Max.Add(grow), maxDistance)
+}
+
+// FollowCamera is a third-person camera that follows behind a target and
+// keeps from going through the colliders it has been given.
+//
+// The camera sits Distance units behind the target, as determined by the
+// target rotation with forward being -Z, and Height units above it. It looks
+// at the point LookHeight units above the target.
+type FollowCamera struct {
+	// Distance is how far behind the target the camera wants to be.
+	Distance float32
+
+	// Height is how far above the target the camera wants to be.
+	Height float32
+
+	// LookHeight is how far above the target the point the camera looks at is.
+	LookHeight float32
+
+	// Lag is roughly how many seconds the camera takes to catch up to where
+	// it wants to be. A value of 0 keeps it right behind the target.
+	Lag float32
+
+	// Radius is the size of the sphere used to keep the camera from
+	// getting too close to the colliders.
+	Radius float32
+
+	// Colliders are the objects the camera is kept from passing through.
+	Colliders []CameraCollider
+
+	targetPosition mgl.Vec3
+	targetRotation mgl.Quat
+
+	// desiredPosition is where the camera is moving towards with lag while
+	// position is where it ends up after collision.
+	desiredPosition mgl.Vec3
+	position        mgl.Vec3
+	lookAt          mgl.Vec3
+}
+
+// NewFollowCamera creates a new camera following a target at a given
+// distance behind and height above it.
+func NewFollowCamera(targetPosition mgl.Vec3, targetRotation mgl.Quat, distance, height float32) *FollowCamera {
+	cam := new(FollowCamera)
+	cam.Distance = distance
+	cam.Height = height
+	cam.Radius = 0.2
+	cam.SetTarget(targetPosition, targetRotation)
+
+	// start out where the camera wants to be
+	cam.desiredPosition = cam.getIdealPosition()
+	cam.Update(0.0)
+	return cam
+}
+
+// SetTarget sets the transform of the target to follow. Update should be
+// called afterwards to move the camera.
+func (c *FollowCamera) SetTarget(position mgl.Vec3, rotation mgl.Quat) {
+	c.targetPosition = position
+	c.targetRotation = rotation
+}
+
+// GetTarget returns the position of the target being followed.
+func (c *FollowCamera) GetTarget() mgl.Vec3 {
+	return c.targetPosition
+}
+
+// getIdealPosition returns where the camera wants to be without lag or collision.
+func (c *FollowCamera) getIdealPosition() mgl.Vec3 {
+	behind := c.targetRotation.Rotate(mgl.Vec3{0.0, 0.0, c.Distance})
+	return c.targetPosition.Add(behind).Add(mgl.Vec3{0.0, c.Height, 0.0})
+}
+
+// Update moves the camera towards its spot behind the target and then
+// pulls it in towards the target if there are colliders in the way.
+func (c *FollowCamera) Update(frameDelta float32) {
+	ideal := c.getIdealPosition()
+	if c.Lag <= 0.0 {
+		c.desiredPosition = ideal
+	} else {
+		// exponential smoothing so the lag is independent of frame rate
+		t := 1.0 - float32(math.Exp(float64(-frameDelta/c.Lag)))
+		c.desiredPosition = c.desiredPosition.Add(ideal.Sub(c.desiredPosition).Mul(t))
+	}
+
+	c.lookAt = c.targetPosition.Add(mgl.Vec3{0.0, c.LookHeight, 0.0})
+	c.position = c.desiredPosition
+
+	// cast from the look at point out to the camera to find the closest hit
+	toCamera := c.desiredPosition.Sub(c.lookAt)
+	maxDistance := toCamera.Len()
+	if maxDistance <= 0.0 {
+		return
+	}
+	direction := toCamera.Mul(1.0 / maxDistance)
+	for _, collider := range c.Colliders {
+		dist, hit := collider.SphereCast(c.lookAt, direction, c.Radius, maxDistance)
+		if hit && dist < maxDistance {
+			maxDistance = dist
+		}
+	}
+	c.position = c.lookAt.Add(direction.Mul(maxDistance))
+}
+
+// GetPosition returns the eye position of the camera.
+func (c *FollowCamera) GetPosition() mgl.Vec3 {
+	return c.position
+}
+
+// GetForwardVector returns the vector representing the forward direction of the camera.
+func (c *FollowCamera) GetForwardVector() mgl.Vec3 {
+	return c.lookAt.Sub(c.position).Normalize()
+}
+
+// GetViewMatrix returns a 4x4 matrix for the view rot/trans/scale.
+func (c *FollowCamera) GetViewMatrix() mgl.Mat4 {
+	return mgl.LookAtV(c.position, c.lookAt, upVector)
+}
@@ -0,0 +1,84 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Frustum is the set of six planes bounding the view volume of a camera in
+// world space, in the order of left, right, bottom, top, near and far. Each
+// plane is stored as the normal pointing into the frustum in XYZ and the
+// distance in W, so a point p is inside a plane if dot(n, p) + d >= 0.
+type Frustum [6]mgl.Vec4
+
+// NewFrustum extracts the normalized frustum planes from the projection and
+// view matrices.
+func NewFrustum(projection mgl.Mat4, view mgl.Mat4) Frustum {
+	var f Frustum
+	m := projection.Mul4(view)
+	r0, r1, r2, r3 := m.Row(0), m.Row(1), m.Row(2), m.Row(3)
+	f[0] = r3.Add(r0)
+	f[1] = r3.Sub(r0)
+	f[2] = r3.Add(r1)
+	f[3] = r3.Sub(r1)
+	f[4] = r3.Add(r2)
+	f[5] = r3.Sub(r2)
+
+	// normalize the planes so that distances can be compared with radii
+	for i, plane := range f {
+		length := plane.Vec3().Len()
+		if length > 0.0 {
+			f[i] = plane.Mul(1.0 / length)
+		}
+	}
+
+	return f
+}
+
+// GetFrustum returns the frustum for the camera with a projection matrix.
+func GetFrustum(c Camera, projection mgl.Mat4) Frustum {
+	return NewFrustum(projection, c.GetViewMatrix())
+}
+
+// ContainsPoint returns true if the point is inside the frustum.
+func (f *Frustum) ContainsPoint(point mgl.Vec3) bool {
+	for _, plane := range f {
+		if plane.Vec3().Dot(point)+plane[3] < 0.0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsSphere returns true if any part of the sphere is inside the frustum.
+func (f *Frustum) ContainsSphere(center mgl.Vec3, radius float32) bool {
+	for _, plane := range f {
+		if plane.Vec3().Dot(center)+plane[3] < -radius {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAABB returns true if any part of the axis aligned box is inside
+// the frustum. Boxes near the corners of the frustum may be reported as
+// inside when they are not, which is fine for culling.
+func (f *Frustum) ContainsAABB(min, max mgl.Vec3) bool {
+	for _, plane := range f {
+		// test the corner of the box farthest along the plane normal
+		var corner mgl.Vec3
+		for i := 0; i < 3; i++ {
+			if plane[i] >= 0.0 {
+				corner[i] = max[i]
+			} else {
+				corner[i] = min[i]
+			}
+		}
+		if plane.Vec3().Dot(corner)+plane[3] < 0.0 {
+			return false
+		}
+	}
+	return true
+}
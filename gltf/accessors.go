@@ -0,0 +1,236 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// The component types of an Accessor.
+const (
+	ComponentByte          = 5120
+	ComponentUnsignedByte  = 5121
+	ComponentShort         = 5122
+	ComponentUnsignedShort = 5123
+	ComponentUnsignedInt   = 5125
+	ComponentFloat         = 5126
+)
+
+// getComponentSize returns the size in bytes of a component type or 0 if
+// the type isn't known.
+func getComponentSize(componentType int) int {
+	switch componentType {
+	case ComponentByte, ComponentUnsignedByte:
+		return 1
+	case ComponentShort, ComponentUnsignedShort:
+		return 2
+	case ComponentUnsignedInt, ComponentFloat:
+		return 4
+	}
+	return 0
+}
+
+// getTypeComponents returns the number of components of an accessor type
+// or 0 if the type isn't known.
+func getTypeComponents(accessorType string) int {
+	switch accessorType {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+	return 0
+}
+
+// readComponent reads one component as a float, scaling it into the range
+// of [0, 1] or [-1, 1] if it's normalized.
+func readComponent(data []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case ComponentByte:
+		v := float32(int8(data[0]))
+		if normalized {
+			return float32(math.Max(float64(v/127.0), -1.0))
+		}
+		return v
+	case ComponentUnsignedByte:
+		v := float32(data[0])
+		if normalized {
+			return v / 255.0
+		}
+		return v
+	case ComponentShort:
+		v := float32(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return float32(math.Max(float64(v/32767.0), -1.0))
+		}
+		return v
+	case ComponentUnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535.0
+		}
+		return v
+	case ComponentUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(data))
+	case ComponentFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	}
+	return 0.0
+}
+
+// ReadAccessor reads all of the elements of an accessor as floats, one
+// after another, and returns them with the number of components in each
+// element. Accessors without a buffer view read as zeros.
+func (doc *Document) ReadAccessor(index int) ([]float32, int, error) {
+	if index < 0 || index >= len(doc.Accessors) {
+		return nil, 0, fmt.Errorf("Failed to find accessor %d.", index)
+	}
+	acc := doc.Accessors[index]
+	if len(acc.Sparse) > 0 {
+		return nil, 0, fmt.Errorf("Failed to read accessor %d because sparse accessors aren't supported.", index)
+	}
+	components := getTypeComponents(acc.Type)
+	componentSize := getComponentSize(acc.ComponentType)
+	if components == 0 || componentSize == 0 {
+		return nil, 0, fmt.Errorf("Failed to read accessor %d because its type %s or component type %d isn't known.", index, acc.Type, acc.ComponentType)
+	}
+
+	values := make([]float32, acc.Count*components)
+	if acc.BufferView == nil {
+		return values, components, nil
+	}
+
+	data, err := doc.getBufferViewData(*acc.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	stride := doc.BufferViews[*acc.BufferView].ByteStride
+	if stride == 0 {
+		stride = components * componentSize
+	}
+	elementSize := components * componentSize
+	if acc.Count > 0 && acc.ByteOffset+(acc.Count-1)*stride+elementSize > len(data) {
+		return nil, 0, fmt.Errorf("Failed to read accessor %d because it runs past the end of its buffer view.", index)
+	}
+
+	for i := 0; i < acc.Count; i++ {
+		offset := acc.ByteOffset + i*stride
+		for c := 0; c < components; c++ {
+			values[i*components+c] = readComponent(data[offset+c*componentSize:], acc.ComponentType, acc.Normalized)
+		}
+	}
+	return values, components, nil
+}
+
+// readVec2s reads an accessor of VEC2 elements.
+func (doc *Document) readVec2s(index int) ([]mgl.Vec2, error) {
+	values, components, err := doc.ReadAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if components != 2 {
+		return nil, fmt.Errorf("Failed to read accessor %d as VEC2.", index)
+	}
+	result := make([]mgl.Vec2, len(values)/2)
+	for i := range result {
+		result[i] = mgl.Vec2{values[i*2], values[i*2+1]}
+	}
+	return result, nil
+}
+
+// readVec3s reads an accessor of VEC3 elements, or the first three
+// components of VEC4 elements such as tangents.
+func (doc *Document) readVec3s(index int) ([]mgl.Vec3, error) {
+	values, components, err := doc.ReadAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if components != 3 && components != 4 {
+		return nil, fmt.Errorf("Failed to read accessor %d as VEC3.", index)
+	}
+	result := make([]mgl.Vec3, len(values)/components)
+	for i := range result {
+		result[i] = mgl.Vec3{values[i*components], values[i*components+1], values[i*components+2]}
+	}
+	return result, nil
+}
+
+// readVec4s reads an accessor of VEC4 elements.
+func (doc *Document) readVec4s(index int) ([]mgl.Vec4, error) {
+	values, components, err := doc.ReadAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if components != 4 {
+		return nil, fmt.Errorf("Failed to read accessor %d as VEC4.", index)
+	}
+	result := make([]mgl.Vec4, len(values)/4)
+	for i := range result {
+		result[i] = mgl.Vec4{values[i*4], values[i*4+1], values[i*4+2], values[i*4+3]}
+	}
+	return result, nil
+}
+
+// readMat4s reads an accessor of MAT4 elements, which are stored in column
+// major order like mgl.Mat4.
+func (doc *Document) readMat4s(index int) ([]mgl.Mat4, error) {
+	values, components, err := doc.ReadAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if components != 16 {
+		return nil, fmt.Errorf("Failed to read accessor %d as MAT4.", index)
+	}
+	result := make([]mgl.Mat4, len(values)/16)
+	for i := range result {
+		copy(result[i][:], values[i*16:i*16+16])
+	}
+	return result, nil
+}
+
+// readIndices reads an accessor of SCALAR integer elements.
+func (doc *Document) readIndices(index int) ([]uint32, error) {
+	values, components, err := doc.ReadAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	acc := doc.Accessors[index]
+	if acc.ComponentType == ComponentFloat {
+		return nil, fmt.Errorf("Failed to read accessor %d as indices because it's made of floats.", index)
+	}
+	if components != 1 {
+		return nil, fmt.Errorf("Failed to read accessor %d as SCALAR indices.", index)
+	}
+
+	// large unsigned ints lose precision as floats so read them again raw
+	result := make([]uint32, len(values))
+	if acc.ComponentType == ComponentUnsignedInt && acc.BufferView != nil {
+		data, _ := doc.getBufferViewData(*acc.BufferView)
+		stride := doc.BufferViews[*acc.BufferView].ByteStride
+		if stride == 0 {
+			stride = 4
+		}
+		for i := range result {
+			offset := acc.ByteOffset + i*stride
+			result[i] = binary.LittleEndian.Uint32(data[offset:])
+		}
+		return result, nil
+	}
+	for i, v := range values {
+		result[i] = uint32(v)
+	}
+	return result, nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for embedded images
+	_ "image/png"  // register the PNG decoder for embedded images
+	"math"
+	"path/filepath"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/component"
+)
+
+// GetImageKey returns the key the image is stored under in a TextureManager
+// by LoadTextures. Images in files use their URI and embedded images use
+// the name of the glTF file and their index.
+func (doc *Document) GetImageKey(imageIndex int) string {
+	img := doc.Images[imageIndex]
+	if img.URI != "" && !strings.HasPrefix(img.URI, "data:") {
+		return img.URI
+	}
+	return fmt.Sprintf("%s#image%d", doc.name, imageIndex)
+}
+
+// getTextureKey returns the TextureManager key of the image used by the
+// texture reference or an empty string if there isn't one.
+func (doc *Document) getTextureKey(info *TextureInfo) string {
+	if info == nil || info.Index < 0 || info.Index >= len(doc.Textures) {
+		return ""
+	}
+	source := doc.Textures[info.Index].Source
+	if source == nil || *source < 0 || *source >= len(doc.Images) {
+		return ""
+	}
+	return doc.GetImageKey(*source)
+}
+
+// ReadImage decodes a PNG or JPEG image of the document.
+func (doc *Document) ReadImage(imageIndex int) (image.Image, error) {
+	if imageIndex < 0 || imageIndex >= len(doc.Images) {
+		return nil, fmt.Errorf("Failed to find image %d.", imageIndex)
+	}
+
+	var data []byte
+	var err error
+	img := doc.Images[imageIndex]
+	if img.BufferView != nil {
+		data, err = doc.getBufferViewData(*img.BufferView)
+	} else {
+		data, err = doc.readURI(img.URI)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode image %d of the glTF document. %v", imageIndex, err)
+	}
+	return decoded, nil
+}
+
+// LoadTextures loads all of the images of the document into the texture
+// manager under the keys returned by GetImageKey, skipping the ones that
+// are already loaded.
+func (doc *Document) LoadTextures(tm *fizzle.TextureManager) error {
+	for i := range doc.Images {
+		key := doc.GetImageKey(i)
+		if _, okay := tm.GetTexture(key); okay {
+			continue
+		}
+
+		img, err := doc.ReadImage(i)
+		if err != nil {
+			return err
+		}
+		_, err = tm.LoadTextureFromImage(key, img)
+		if err != nil {
+			return fmt.Errorf("Failed to load image %d of the glTF document as a texture. %v", i, err)
+		}
+	}
+	return nil
+}
+
+// CreateMaterial converts a material of the document to a component
+// material. The metallic-roughness texture is used for both the roughness
+// and metalness textures since glTF packs them into the green and blue
+// channels of one image. It is also the ORM texture, with occlusion in the
+// red channel if the occlusion texture is the same image.
+func (doc *Document) CreateMaterial(materialIndex int) component.Material {
+	var m component.Material
+	m.Diffuse = mgl.Vec4{1, 1, 1, 1}
+	m.Specular = mgl.Vec4{1, 1, 1, 1}
+	metalness := float32(1.0)
+	m.Metalness = &metalness
+	m.GenerateMipmaps = true
+	if materialIndex < 0 || materialIndex >= len(doc.Materials) {
+		return m
+	}
+
+	src := &doc.Materials[materialIndex]
+	if pbr := src.PBRMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			m.Diffuse = mgl.Vec4{pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2], pbr.BaseColorFactor[3]}
+		}
+		if pbr.RoughnessFactor != nil {
+			roughness := *pbr.RoughnessFactor
+			m.Roughness = &roughness
+		}
+		if pbr.MetallicFactor != nil {
+			metalness := *pbr.MetallicFactor
+			m.Metalness = &metalness
+		}
+		m.DiffuseTexture = doc.getTextureKey(pbr.BaseColorTexture)
+		m.RoughnessTexture = doc.getTextureKey(pbr.MetallicRoughnessTexture)
+		m.MetalnessTexture = m.RoughnessTexture
+		m.ORMTexture = m.RoughnessTexture
+	}
+	if len(src.EmissiveFactor) == 3 {
+		m.Emissive = mgl.Vec4{src.EmissiveFactor[0], src.EmissiveFactor[1], src.EmissiveFactor[2], 1.0}
+	}
+	m.NormalsTexture = doc.getTextureKey(src.NormalTexture)
+	m.AOTexture = doc.getTextureKey(src.OcclusionTexture)
+	m.ORMOcclusion = m.ORMTexture != "" && m.ORMTexture == m.AOTexture
+	m.EmissiveTexture = doc.getTextureKey(src.EmissiveTexture)
+	return m
+}
+
+// CreateComponent creates a component with a mesh for every primitive of
+// the meshes in the document's scene. Static meshes get the shader named
+// by shaderName and are placed by the transforms of their nodes. Skinned
+// meshes get the shader named by skinnedShaderName and are placed by their
+// skeleton instead, as glTF specifies. The textures should be loaded with
+// LoadTextures before the component's renderable gets created.
+func (doc *Document) CreateComponent(shaderName, skinnedShaderName string) (*component.Component, error) {
+	comp := new(component.Component)
+	comp.Name = strings.TrimSuffix(doc.name, filepath.Ext(doc.name))
+	comp.Properties = make(map[string]string)
+
+	// use the scene's root nodes or every node without a parent if there
+	// are no scenes
+	var roots []int
+	if len(doc.Scenes) > 0 {
+		sceneIndex := 0
+		if doc.Scene != nil && *doc.Scene >= 0 && *doc.Scene < len(doc.Scenes) {
+			sceneIndex = *doc.Scene
+		}
+		roots = doc.Scenes[sceneIndex].Nodes
+	} else {
+		for i, parent := range doc.getParents() {
+			if parent < 0 {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	var addNode func(nodeIndex int, parentTransform mgl.Mat4) error
+	addNode = func(nodeIndex int, parentTransform mgl.Mat4) error {
+		if nodeIndex < 0 || nodeIndex >= len(doc.Nodes) {
+			return fmt.Errorf("Failed to find node %d.", nodeIndex)
+		}
+		node := &doc.Nodes[nodeIndex]
+		transform := parentTransform.Mul4(node.GetLocalTransform())
+
+		if node.Mesh != nil {
+			skinIndex := -1
+			if node.Skin != nil {
+				skinIndex = *node.Skin
+			}
+			err := doc.addMeshes(comp, node, skinIndex, transform, shaderName, skinnedShaderName)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, child := range node.Children {
+			err := addNode(child, transform)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		err := addNode(root, mgl.Ident4())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return comp, nil
+}
+
+// addMeshes adds a component mesh for each primitive of the node's mesh.
+func (doc *Document) addMeshes(comp *component.Component, node *Node, skinIndex int, transform mgl.Mat4, shaderName, skinnedShaderName string) error {
+	meshIndex := *node.Mesh
+	if meshIndex < 0 || meshIndex >= len(doc.Meshes) {
+		return fmt.Errorf("Failed to find mesh %d.", meshIndex)
+	}
+
+	for pi, prim := range doc.Meshes[meshIndex].Primitives {
+		srcMesh, err := doc.CreateMesh(meshIndex, pi, skinIndex)
+		if err != nil {
+			return err
+		}
+
+		cm := component.NewMesh()
+		cm.Name = doc.Meshes[meshIndex].Name
+		if cm.Name == "" {
+			cm.Name = node.Name
+		}
+		if len(doc.Meshes[meshIndex].Primitives) > 1 {
+			cm.Name = fmt.Sprintf("%s.%d", cm.Name, pi)
+		}
+		cm.Parent = comp
+		cm.SrcMesh = srcMesh
+
+		materialIndex := -1
+		if prim.Material != nil {
+			materialIndex = *prim.Material
+		}
+		cm.Material = doc.CreateMaterial(materialIndex)
+		cm.Material.ShaderName = shaderName
+
+		if skinIndex >= 0 {
+			cm.Material.ShaderName = skinnedShaderName
+		} else {
+			pose := fizzle.BonePoseFromMat4(transform)
+			cm.Offset = pose.Position
+			cm.Scale = pose.Scale
+			angle := 2.0 * math.Acos(math.Min(1.0, math.Abs(float64(pose.Rotation.W))))
+			if angle > 0.0001 {
+				axis := pose.Rotation.V.Normalize()
+				if pose.Rotation.W < 0.0 {
+					axis = axis.Mul(-1.0)
+				}
+				cm.RotationAxis = axis
+				cm.RotationDegrees = mgl.RadToDeg(float32(angle))
+			}
+		}
+
+		comp.Meshes = append(comp.Meshes, cm)
+	}
+	return nil
+}
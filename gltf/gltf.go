@@ -0,0 +1,353 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package gltf loads glTF 2.0 files, both .gltf JSON files and .glb binary
+files, without needing assimp. Meshes, skins and animations are converted to
+gombz structures and the PBR materials to component materials so that a
+whole file can be turned into a component.Component.
+
+A typical use loads the file, its textures and then creates the component
+and its renderable:
+
+	doc, err := gltf.Load("assets/robot.glb")
+	...
+	err = doc.LoadTextures(textureMan)
+	...
+	comp, err := doc.CreateComponent("PBR", "PBRSkinned")
+	...
+	robot := comp.GetRenderable(textureMan, shaders)
+
+The materials look their best with the shaders from forward.CreatePBRShader
+and forward.CreatePBRSkinnedShader registered under the names passed to
+CreateComponent, since those use the roughness, metalness, occlusion and
+emissive textures of the file.
+
+*/
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// glbMagic is the magic number that starts .glb files.
+	glbMagic = 0x46546C67
+
+	// glbChunkJSON and glbChunkBIN are the types of the .glb chunks.
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// Document is a glTF file. The exported fields mirror the glTF JSON, using
+// pointers for the optional indexes since 0 is a valid index.
+type Document struct {
+	Asset       Asset
+	Scene       *int
+	Scenes      []Scene
+	Nodes       []Node
+	Meshes      []Mesh
+	Materials   []Material
+	Textures    []Texture
+	Images      []Image
+	Accessors   []Accessor
+	BufferViews []BufferView
+	Buffers     []Buffer
+	Skins       []Skin
+	Animations  []Animation
+
+	// dirPath is the directory of the file for resolving relative URIs.
+	dirPath string
+
+	// name is the file name used to make keys for embedded images.
+	name string
+
+	// buffers is the loaded data of each of the Buffers.
+	buffers [][]byte
+}
+
+// Asset is the information about the glTF file.
+type Asset struct {
+	Version   string
+	Generator string
+}
+
+// Scene is a set of root nodes.
+type Scene struct {
+	Name  string
+	Nodes []int
+}
+
+// Node is a node of the scene hierarchy. Its transform is either a Matrix
+// or a Translation, Rotation and Scale.
+type Node struct {
+	Name        string
+	Children    []int
+	Mesh        *int
+	Skin        *int
+	Matrix      []float32
+	Translation []float32
+	Rotation    []float32
+	Scale       []float32
+}
+
+// Mesh is a set of primitives drawn together.
+type Mesh struct {
+	Name       string
+	Primitives []Primitive
+}
+
+// Primitive is geometry with one material. Attributes maps attribute
+// names like POSITION to accessor indexes.
+type Primitive struct {
+	Attributes map[string]int
+	Indices    *int
+	Material   *int
+	Mode       *int
+}
+
+// Material is a metallic-roughness PBR material.
+type Material struct {
+	Name                 string
+	PBRMetallicRoughness *PBRMetallicRoughness
+	NormalTexture        *TextureInfo
+	OcclusionTexture     *TextureInfo
+	EmissiveTexture      *TextureInfo
+	EmissiveFactor       []float32
+	AlphaMode            string
+	DoubleSided          bool
+}
+
+// PBRMetallicRoughness is the metallic-roughness part of a Material.
+type PBRMetallicRoughness struct {
+	BaseColorFactor          []float32
+	BaseColorTexture         *TextureInfo
+	MetallicFactor           *float32
+	RoughnessFactor          *float32
+	MetallicRoughnessTexture *TextureInfo
+}
+
+// TextureInfo is a reference from a Material to a Texture.
+type TextureInfo struct {
+	Index    int
+	TexCoord int
+}
+
+// Texture is an Image and sampler pair.
+type Texture struct {
+	Source  *int
+	Sampler *int
+}
+
+// Image is image data in a file, a data URI or a BufferView.
+type Image struct {
+	Name       string
+	URI        string
+	MimeType   string
+	BufferView *int
+}
+
+// Accessor describes how to read typed data out of a BufferView.
+type Accessor struct {
+	BufferView    *int
+	ByteOffset    int
+	ComponentType int
+	Normalized    bool
+	Count         int
+	Type          string
+	Sparse        json.RawMessage
+}
+
+// BufferView is a slice of a Buffer.
+type BufferView struct {
+	Buffer     int
+	ByteOffset int
+	ByteLength int
+	ByteStride int
+}
+
+// Buffer is binary data in a file, a data URI or the BIN chunk of a .glb file.
+type Buffer struct {
+	URI        string
+	ByteLength int
+}
+
+// Skin is the set of joint nodes that deform a mesh.
+type Skin struct {
+	Name                string
+	InverseBindMatrices *int
+	Skeleton            *int
+	Joints              []int
+}
+
+// Animation is a set of channels that animate nodes.
+type Animation struct {
+	Name     string
+	Channels []AnimationChannel
+	Samplers []AnimationSampler
+}
+
+// AnimationChannel connects a sampler to the property of a node.
+type AnimationChannel struct {
+	Sampler int
+	Target  AnimationTarget
+}
+
+// AnimationTarget is the node and the property, one of translation,
+// rotation, scale or weights, that gets animated.
+type AnimationTarget struct {
+	Node *int
+	Path string
+}
+
+// AnimationSampler is the key times in the Input accessor and the values
+// in the Output accessor.
+type AnimationSampler struct {
+	Input         int
+	Output        int
+	Interpolation string
+}
+
+// Load reads a .gltf or .glb file along with the buffers it refers to.
+func Load(filePath string) (*Document, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the glTF file %s. %v", filePath, err)
+	}
+
+	doc, err := Parse(data, filepath.Dir(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the glTF file %s. %v", filePath, err)
+	}
+	doc.name = filepath.Base(filePath)
+	return doc, nil
+}
+
+// Parse reads a glTF document from the bytes of a .gltf or .glb file. The
+// dirPath is used to find the files of buffers and images with relative URIs.
+func Parse(data []byte, dirPath string) (*Document, error) {
+	jsonBytes := data
+	var binChunk []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data[0:4]) == glbMagic {
+		var err error
+		jsonBytes, binChunk, err = parseGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc := new(Document)
+	err := json.Unmarshal(jsonBytes, doc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the glTF JSON. %v", err)
+	}
+	if !strings.HasPrefix(doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("Failed to load the glTF document because version %s isn't supported.", doc.Asset.Version)
+	}
+	doc.dirPath = dirPath
+	doc.name = "gltf"
+
+	// load the buffers, where the first one without a URI in a .glb file
+	// is the BIN chunk
+	doc.buffers = make([][]byte, len(doc.Buffers))
+	for i, b := range doc.Buffers {
+		if b.URI == "" {
+			if i != 0 || binChunk == nil {
+				return nil, fmt.Errorf("Failed to find the data for buffer %d.", i)
+			}
+			doc.buffers[i] = binChunk
+		} else {
+			doc.buffers[i], err = doc.readURI(b.URI)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(doc.buffers[i]) < b.ByteLength {
+			return nil, fmt.Errorf("Failed to load buffer %d because it's shorter than %d bytes.", i, b.ByteLength)
+		}
+	}
+
+	return doc, nil
+}
+
+// parseGLB splits a .glb file into its JSON and BIN chunks.
+func parseGLB(data []byte) ([]byte, []byte, error) {
+	version := binary.LittleEndian.Uint32(data[4:8])
+	if version != 2 {
+		return nil, nil, fmt.Errorf("Failed to load the .glb data because version %d isn't supported.", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:12]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("Failed to load the .glb data because it's shorter than its header says.")
+	}
+
+	var jsonChunk, binChunk []byte
+	offset := 12
+	for offset+8 <= length {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		start := offset + 8
+		if start+chunkLength > length {
+			return nil, nil, fmt.Errorf("Failed to load the .glb data because a chunk runs past the end.")
+		}
+
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = data[start : start+chunkLength]
+		case glbChunkBIN:
+			binChunk = data[start : start+chunkLength]
+		}
+		offset = start + chunkLength
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("Failed to find the JSON chunk in the .glb data.")
+	}
+	return bytes.TrimRight(jsonChunk, " \x00"), binChunk, nil
+}
+
+// readURI returns the data of a base64 data URI or of a file relative to
+// the document.
+func (doc *Document) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.Index(uri, ",")
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("Failed to read the data URI because only base64 is supported.")
+		}
+		data, err := base64.StdEncoding.DecodeString(uri[comma+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the base64 data URI. %v", err)
+		}
+		return data, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(doc.dirPath, filepath.FromSlash(uri)))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the file %s referenced by the glTF document. %v", uri, err)
+	}
+	return data, nil
+}
+
+// getBufferViewData returns the bytes of a buffer view.
+func (doc *Document) getBufferViewData(index int) ([]byte, error) {
+	if index < 0 || index >= len(doc.BufferViews) {
+		return nil, fmt.Errorf("Failed to find buffer view %d.", index)
+	}
+	view := doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(doc.buffers) {
+		return nil, fmt.Errorf("Failed to find buffer %d for buffer view %d.", view.Buffer, index)
+	}
+	data := doc.buffers[view.Buffer]
+	if view.ByteOffset+view.ByteLength > len(data) {
+		return nil, fmt.Errorf("Failed to read buffer view %d because it runs past the end of its buffer.", index)
+	}
+	return data[view.ByteOffset : view.ByteOffset+view.ByteLength], nil
+}
@@ -0,0 +1,381 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package gltf
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/gombz"
+)
+
+// modeTriangles is the only primitive mode that gets loaded.
+const modeTriangles = 4
+
+// GetLocalTransform returns the transform of the node relative to its parent.
+func (n *Node) GetLocalTransform() mgl.Mat4 {
+	if len(n.Matrix) == 16 {
+		var m mgl.Mat4
+		copy(m[:], n.Matrix)
+		return m
+	}
+
+	transform := mgl.Ident4()
+	if len(n.Translation) == 3 {
+		transform = mgl.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if len(n.Rotation) == 4 {
+		rotation := mgl.Quat{W: n.Rotation[3], V: mgl.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+		transform = transform.Mul4(rotation.Normalize().Mat4())
+	}
+	if len(n.Scale) == 3 {
+		transform = transform.Mul4(mgl.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+	return transform
+}
+
+// getParents returns the index of the parent of each node or -1 for nodes
+// without a parent.
+func (doc *Document) getParents() []int {
+	parents := make([]int, len(doc.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for i, n := range doc.Nodes {
+		for _, child := range n.Children {
+			if child >= 0 && child < len(parents) {
+				parents[child] = i
+			}
+		}
+	}
+	return parents
+}
+
+// GetWorldTransform returns the transform of the node in the scene, which
+// includes the transforms of all of its parents.
+func (doc *Document) GetWorldTransform(nodeIndex int) mgl.Mat4 {
+	parents := doc.getParents()
+	transform := mgl.Ident4()
+	for i := nodeIndex; i >= 0; i = parents[i] {
+		transform = doc.Nodes[i].GetLocalTransform().Mul4(transform)
+	}
+	return transform
+}
+
+// CreateMesh converts a primitive of a mesh to a gombz mesh. If skinIndex
+// isn't -1, the bones and animations of the skin are added to the mesh so
+// that it gets a Skeleton when turned into a Renderable. Only triangle
+// primitives are supported. The V texture coordinate gets flipped to match
+// the way fizzle loads textures.
+func (doc *Document) CreateMesh(meshIndex, primitiveIndex, skinIndex int) (*gombz.Mesh, error) {
+	if meshIndex < 0 || meshIndex >= len(doc.Meshes) {
+		return nil, fmt.Errorf("Failed to find mesh %d.", meshIndex)
+	}
+	if primitiveIndex < 0 || primitiveIndex >= len(doc.Meshes[meshIndex].Primitives) {
+		return nil, fmt.Errorf("Failed to find primitive %d of mesh %d.", primitiveIndex, meshIndex)
+	}
+	prim := doc.Meshes[meshIndex].Primitives[primitiveIndex]
+	if prim.Mode != nil && *prim.Mode != modeTriangles {
+		return nil, fmt.Errorf("Failed to create mesh %d because primitive mode %d isn't supported.", meshIndex, *prim.Mode)
+	}
+
+	positionIndex, okay := prim.Attributes["POSITION"]
+	if !okay {
+		return nil, fmt.Errorf("Failed to create mesh %d because it has no POSITION attribute.", meshIndex)
+	}
+
+	var err error
+	mesh := new(gombz.Mesh)
+	mesh.Vertices, err = doc.readVec3s(positionIndex)
+	if err != nil {
+		return nil, err
+	}
+	mesh.VertexCount = uint32(len(mesh.Vertices))
+
+	if index, okay := prim.Attributes["NORMAL"]; okay {
+		mesh.Normals, err = doc.readVec3s(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if index, okay := prim.Attributes["TANGENT"]; okay {
+		mesh.Tangents, err = doc.readVec3s(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var uvs []mgl.Vec2
+	if index, okay := prim.Attributes["TEXCOORD_0"]; okay {
+		uvs, err = doc.readVec2s(index)
+		if err != nil {
+			return nil, err
+		}
+		for i := range uvs {
+			uvs[i][1] = 1.0 - uvs[i][1]
+		}
+	}
+	mesh.UVChannelCount = 1
+	mesh.UVChannels = [][]mgl.Vec2{uvs}
+
+	// meshes without indices draw their vertices in order
+	var indices []uint32
+	if prim.Indices != nil {
+		indices, err = doc.readIndices(*prim.Indices)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		indices = make([]uint32, mesh.VertexCount)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	mesh.FaceCount = uint32(len(indices) / 3)
+	mesh.Faces = make([]gombz.Face, mesh.FaceCount)
+	for i := range mesh.Faces {
+		mesh.Faces[i] = gombz.Face{indices[i*3], indices[i*3+1], indices[i*3+2]}
+	}
+
+	if skinIndex < 0 {
+		return mesh, nil
+	}
+
+	jointsIndex, hasJoints := prim.Attributes["JOINTS_0"]
+	weightsIndex, hasWeights := prim.Attributes["WEIGHTS_0"]
+	if !hasJoints || !hasWeights {
+		return nil, fmt.Errorf("Failed to create skinned mesh %d because it has no JOINTS_0 or WEIGHTS_0 attribute.", meshIndex)
+	}
+	mesh.VertexWeightIds, err = doc.readVec4s(jointsIndex)
+	if err != nil {
+		return nil, err
+	}
+	mesh.VertexWeights, err = doc.readVec4s(weightsIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	mesh.Bones, err = doc.CreateBones(skinIndex)
+	if err != nil {
+		return nil, err
+	}
+	mesh.BoneCount = uint32(len(mesh.Bones))
+
+	mesh.Animations, err = doc.CreateAnimations(skinIndex)
+	if err != nil {
+		return nil, err
+	}
+	mesh.AnimationCount = uint32(len(mesh.Animations))
+
+	return mesh, nil
+}
+
+// getJointIndexes maps the node indexes of the joints of a skin to their
+// index in the skin.
+func (doc *Document) getJointIndexes(skin *Skin) map[int]int {
+	jointIndexes := make(map[int]int, len(skin.Joints))
+	for i, node := range skin.Joints {
+		jointIndexes[node] = i
+	}
+	return jointIndexes
+}
+
+// CreateBones converts the joints of a skin to gombz bones, which have the
+// same indexes as the joints so that the JOINTS_0 attribute refers to them.
+func (doc *Document) CreateBones(skinIndex int) ([]gombz.Bone, error) {
+	if skinIndex < 0 || skinIndex >= len(doc.Skins) {
+		return nil, fmt.Errorf("Failed to find skin %d.", skinIndex)
+	}
+	skin := &doc.Skins[skinIndex]
+
+	var inverseBinds []mgl.Mat4
+	if skin.InverseBindMatrices != nil {
+		var err error
+		inverseBinds, err = doc.readMat4s(*skin.InverseBindMatrices)
+		if err != nil {
+			return nil, err
+		}
+		if len(inverseBinds) < len(skin.Joints) {
+			return nil, fmt.Errorf("Failed to create the bones of skin %d because there aren't enough inverse bind matrices.", skinIndex)
+		}
+	}
+
+	parents := doc.getParents()
+	jointIndexes := doc.getJointIndexes(skin)
+	bones := make([]gombz.Bone, len(skin.Joints))
+	for i, nodeIndex := range skin.Joints {
+		if nodeIndex < 0 || nodeIndex >= len(doc.Nodes) {
+			return nil, fmt.Errorf("Failed to find joint node %d of skin %d.", nodeIndex, skinIndex)
+		}
+		node := &doc.Nodes[nodeIndex]
+
+		bone := &bones[i]
+		bone.Name = node.Name
+		if bone.Name == "" {
+			bone.Name = fmt.Sprintf("joint%d", i)
+		}
+		bone.Id = int32(i)
+		bone.Parent = -1
+		if parent, okay := jointIndexes[parents[nodeIndex]]; okay {
+			bone.Parent = int32(parent)
+		}
+		bone.Transform = node.GetLocalTransform()
+		bone.Offset = mgl.Ident4()
+		if inverseBinds != nil {
+			bone.Offset = inverseBinds[i]
+		}
+	}
+
+	return bones, nil
+}
+
+// getSkinRootTransform returns the world transform of the node above the
+// root joint of the skin, which takes the joints into the space of the mesh.
+func (doc *Document) getSkinRootTransform(skin *Skin) mgl.Mat4 {
+	parents := doc.getParents()
+	jointIndexes := doc.getJointIndexes(skin)
+	for _, nodeIndex := range skin.Joints {
+		parent := parents[nodeIndex]
+		if _, okay := jointIndexes[parent]; okay {
+			continue
+		}
+		if parent < 0 {
+			return mgl.Ident4()
+		}
+		return doc.GetWorldTransform(parent)
+	}
+	return mgl.Ident4()
+}
+
+// CreateAnimations converts the animations that move the joints of a skin
+// to gombz animations with times in seconds. Animations that don't move any
+// of the joints are left out. Step interpolation is treated as linear and
+// cubic spline interpolation uses the values without the tangents.
+func (doc *Document) CreateAnimations(skinIndex int) ([]gombz.Animation, error) {
+	if skinIndex < 0 || skinIndex >= len(doc.Skins) {
+		return nil, fmt.Errorf("Failed to find skin %d.", skinIndex)
+	}
+	skin := &doc.Skins[skinIndex]
+	jointIndexes := doc.getJointIndexes(skin)
+	rootTransform := doc.getSkinRootTransform(skin)
+
+	var animations []gombz.Animation
+	for ai, anim := range doc.Animations {
+		var result gombz.Animation
+		result.Name = anim.Name
+		if result.Name == "" {
+			result.Name = fmt.Sprintf("animation%d", ai)
+		}
+		result.TicksPerSecond = 1.0
+		result.Transform = rootTransform
+
+		channels := make(map[int]*gombz.AnimationChannel)
+		var order []int
+		for _, ac := range anim.Channels {
+			if ac.Target.Node == nil {
+				continue
+			}
+			boneIndex, okay := jointIndexes[*ac.Target.Node]
+			if !okay {
+				continue
+			}
+			if ac.Sampler < 0 || ac.Sampler >= len(anim.Samplers) {
+				return nil, fmt.Errorf("Failed to find sampler %d of animation %s.", ac.Sampler, result.Name)
+			}
+			sampler := anim.Samplers[ac.Sampler]
+
+			times, _, err := doc.ReadAccessor(sampler.Input)
+			if err != nil {
+				return nil, err
+			}
+			values, components, err := doc.ReadAccessor(sampler.Output)
+			if err != nil {
+				return nil, err
+			}
+
+			// cubic splines store an in tangent, value and out tangent per key
+			valueStep := components
+			valueOffset := 0
+			if sampler.Interpolation == "CUBICSPLINE" {
+				valueStep = components * 3
+				valueOffset = components
+			}
+			if len(values) < len(times)*valueStep {
+				return nil, fmt.Errorf("Failed to read animation %s because a sampler has fewer values than times.", result.Name)
+			}
+
+			channel, okay := channels[boneIndex]
+			if !okay {
+				channel = new(gombz.AnimationChannel)
+				channel.BoneId = int32(boneIndex)
+				channels[boneIndex] = channel
+				order = append(order, boneIndex)
+			}
+
+			for ki, t := range times {
+				if t > result.Duration {
+					result.Duration = t
+				}
+				v := values[ki*valueStep+valueOffset:]
+				switch {
+				case ac.Target.Path == "translation" && components == 3:
+					channel.PositionKeys = append(channel.PositionKeys, gombz.AnimationVec3Key{Time: t, Key: mgl.Vec3{v[0], v[1], v[2]}})
+				case ac.Target.Path == "scale" && components == 3:
+					channel.ScaleKeys = append(channel.ScaleKeys, gombz.AnimationVec3Key{Time: t, Key: mgl.Vec3{v[0], v[1], v[2]}})
+				case ac.Target.Path == "rotation" && components == 4:
+					q := mgl.Quat{W: v[3], V: mgl.Vec3{v[0], v[1], v[2]}}
+					channel.RotationKeys = append(channel.RotationKeys, gombz.AnimationQuatKey{Time: t, Key: q.Normalize()})
+				}
+			}
+		}
+		if len(order) == 0 {
+			continue
+		}
+
+		// a channel needs keys for all three properties, so the ones that
+		// aren't animated hold the joint's own transform
+		for _, boneIndex := range order {
+			channel := channels[boneIndex]
+			node := &doc.Nodes[skin.Joints[boneIndex]]
+			translation, rotation, scale := decomposeNode(node)
+			if len(channel.PositionKeys) == 0 {
+				channel.PositionKeys = []gombz.AnimationVec3Key{{Time: 0.0, Key: translation}}
+			}
+			if len(channel.RotationKeys) == 0 {
+				channel.RotationKeys = []gombz.AnimationQuatKey{{Time: 0.0, Key: rotation}}
+			}
+			if len(channel.ScaleKeys) == 0 {
+				channel.ScaleKeys = []gombz.AnimationVec3Key{{Time: 0.0, Key: scale}}
+			}
+			result.Channels = append(result.Channels, *channel)
+		}
+
+		animations = append(animations, result)
+	}
+
+	return animations, nil
+}
+
+// decomposeNode returns the translation, rotation and scale of the node.
+func decomposeNode(node *Node) (mgl.Vec3, mgl.Quat, mgl.Vec3) {
+	if len(node.Matrix) == 16 {
+		pose := fizzle.BonePoseFromMat4(node.GetLocalTransform())
+		return pose.Position, pose.Rotation, pose.Scale
+	}
+
+	translation := mgl.Vec3{}
+	rotation := mgl.QuatIdent()
+	scale := mgl.Vec3{1, 1, 1}
+	if len(node.Translation) == 3 {
+		translation = mgl.Vec3{node.Translation[0], node.Translation[1], node.Translation[2]}
+	}
+	if len(node.Rotation) == 4 {
+		rotation = mgl.Quat{W: node.Rotation[3], V: mgl.Vec3{node.Rotation[0], node.Rotation[1], node.Rotation[2]}}.Normalize()
+	}
+	if len(node.Scale) == 3 {
+		scale = mgl.Vec3{node.Scale[0], node.Scale[1], node.Scale[2]}
+	}
+	return translation, rotation, scale
+}
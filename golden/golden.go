@@ -0,0 +1,262 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package golden helps regression test rendering by comparing frames drawn
+offscreen against stored reference images.
+
+Capture draws a scene into an offscreen framebuffer with the current
+graphics provider and reads the pixels back into an image. Check compares
+an image against a reference PNG file with a per-channel tolerance; if the
+images differ it writes the actual image and a difference image next to
+the reference so the failure can be inspected.
+
+A test using it needs a graphics context, such as a hidden window, and
+should use fixed resolutions, cameras and timings so frames are
+deterministic:
+
+	img, err := golden.Capture(256, 256, func() {
+		renderer.DrawRenderable(cube, nil, perspective, view, camera)
+	})
+	...
+	opts := golden.DefaultOptions()
+	opts.Update = *updateGolden
+	if err := golden.Check("testdata/cube.png", img, opts); err != nil {
+		t.Error(err)
+	}
+
+Set Options.Update to write new reference images after an intended change
+or for a new test; a missing reference is an error otherwise.
+
+*/
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// Options control how images are compared.
+type Options struct {
+	// Tolerance is the largest difference allowed in any color channel of
+	// a pixel before the pixel counts as mismatched. Some tolerance is
+	// needed to compare frames between different GPUs and drivers.
+	Tolerance uint8
+
+	// MaxMismatched is the fraction of pixels, from 0 to 1, that may be
+	// mismatched for the images to still be considered the same.
+	MaxMismatched float32
+
+	// Update makes Check write the image as the new reference instead of
+	// comparing against it.
+	Update bool
+}
+
+// DefaultOptions returns options with a small tolerance that allows for
+// minor differences between drivers.
+func DefaultOptions() Options {
+	return Options{
+		Tolerance:     2,
+		MaxMismatched: 0.001,
+	}
+}
+
+// Result is the outcome of comparing two images.
+type Result struct {
+	// Mismatched is the number of pixels that differed by more than the
+	// tolerance.
+	Mismatched int
+
+	// Total is the number of pixels compared.
+	Total int
+
+	// MaxDifference is the largest channel difference found.
+	MaxDifference uint8
+
+	// Diff highlights the mismatched pixels in red over a faded copy of
+	// the expected image.
+	Diff *image.NRGBA
+}
+
+// Capture draws a frame into an offscreen framebuffer of the given size
+// with the draw function and reads it back. The viewport is set to the
+// size of the framebuffer, which gets cleared to transparent black before
+// draw is called. The default framebuffer is bound afterwards but the
+// viewport is left for the caller to restore.
+func Capture(width, height int32, draw func()) (*image.NRGBA, error) {
+	gfx := fizzle.GetGraphics()
+
+	colorBuffer := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, colorBuffer)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.RGBA8, width, height)
+
+	depth := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, depth)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.DEPTH_COMPONENT24, width, height)
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, 0)
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.RENDERBUFFER, colorBuffer)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.RENDERBUFFER, depth)
+	defer func() {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		gfx.DeleteRenderbuffer(depth)
+		gfx.DeleteRenderbuffer(colorBuffer)
+	}()
+
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		return nil, fmt.Errorf("Failed to create the framebuffer to capture to (status 0x%x).", status)
+	}
+
+	gfx.Viewport(0, 0, width, height)
+	gfx.ClearColor(0.0, 0.0, 0.0, 0.0)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+	draw()
+
+	pixels := make([]byte, width*height*4)
+	gfx.ReadPixels(0, 0, width, height, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(pixels))
+
+	// OpenGL rows start at the bottom so flip them for the image
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	stride := int(width) * 4
+	for y := 0; y < int(height); y++ {
+		src := pixels[(int(height)-1-y)*stride : (int(height)-y)*stride]
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], src)
+	}
+
+	return img, nil
+}
+
+// Compare compares two images pixel by pixel. An error is returned if the
+// images aren't the same size.
+func Compare(expected, actual image.Image, tolerance uint8) (*Result, error) {
+	eb, ab := expected.Bounds(), actual.Bounds()
+	if eb.Dx() != ab.Dx() || eb.Dy() != ab.Dy() {
+		return nil, fmt.Errorf("Failed to compare images of different sizes: expected %dx%d but got %dx%d.",
+			eb.Dx(), eb.Dy(), ab.Dx(), ab.Dy())
+	}
+
+	result := new(Result)
+	result.Total = eb.Dx() * eb.Dy()
+	result.Diff = image.NewNRGBA(image.Rect(0, 0, eb.Dx(), eb.Dy()))
+	for y := 0; y < eb.Dy(); y++ {
+		for x := 0; x < eb.Dx(); x++ {
+			ec := color.NRGBAModel.Convert(expected.At(eb.Min.X+x, eb.Min.Y+y)).(color.NRGBA)
+			ac := color.NRGBAModel.Convert(actual.At(ab.Min.X+x, ab.Min.Y+y)).(color.NRGBA)
+
+			diff := maxChannelDiff(ec, ac)
+			if diff > result.MaxDifference {
+				result.MaxDifference = diff
+			}
+			if diff > tolerance {
+				result.Mismatched++
+				result.Diff.SetNRGBA(x, y, color.NRGBA{255, 0, 0, 255})
+			} else {
+				gray := uint8((uint16(ec.R) + uint16(ec.G) + uint16(ec.B)) / 6)
+				result.Diff.SetNRGBA(x, y, color.NRGBA{gray, gray, gray, 255})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Check compares the image against the reference PNG at goldenPath. If
+// they differ by more than the options allow, an error is returned and the
+// image and the difference are written next to the reference with
+// .actual.png and .diff.png suffixes. If the reference doesn't exist, an
+// error is returned and only the .actual.png image is written so that a
+// missing file can't pass silently. If opts.Update is set, the image is
+// written as the new reference instead.
+func Check(goldenPath string, actual image.Image, opts Options) error {
+	if opts.Update {
+		return writePNG(goldenPath, actual)
+	}
+
+	base := strings.TrimSuffix(goldenPath, ".png")
+	expected, err := readPNG(goldenPath)
+	if os.IsNotExist(err) {
+		if err := writePNG(base+".actual.png", actual); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s: the reference image is missing; check %s.actual.png and set Options.Update to make it the reference", goldenPath, base)
+	} else if err != nil {
+		return err
+	}
+
+	result, err := Compare(expected, actual, opts.Tolerance)
+	if err != nil {
+		return err
+	}
+	if float32(result.Mismatched) <= opts.MaxMismatched*float32(result.Total) {
+		return nil
+	}
+
+	if err := writePNG(base+".actual.png", actual); err != nil {
+		return err
+	}
+	if err := writePNG(base+".diff.png", result.Diff); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s: %d of %d pixels differ (max channel difference %d); see %s.diff.png",
+		goldenPath, result.Mismatched, result.Total, result.MaxDifference, base)
+}
+
+// maxChannelDiff returns the largest difference between the channels of
+// two colors.
+func maxChannelDiff(a, b color.NRGBA) uint8 {
+	var max uint8
+	for _, d := range [4]uint8{
+		absDiff(a.R, b.R), absDiff(a.G, b.G), absDiff(a.B, b.B), absDiff(a.A, b.A),
+	} {
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(filePath string) (image.Image, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the reference image %s: %v", filePath, err)
+	}
+	return img, nil
+}
+
+func writePNG(filePath string, img image.Image) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("Failed to create the image file %s: %v", filePath, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("Failed to encode the image file %s: %v", filePath, err)
+	}
+	return nil
+}
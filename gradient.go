@@ -0,0 +1,154 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// GradientInterpolation is the way a Gradient blends between its keys.
+type GradientInterpolation int
+
+const (
+	// GradientLinear blends linearly between the keys.
+	GradientLinear GradientInterpolation = iota
+
+	// GradientSmooth blends between the keys with a smoothstep curve so
+	// the color eases in and out of each key.
+	GradientSmooth
+
+	// GradientStep holds the color of each key until the next key.
+	GradientStep
+
+	// GradientInterpolationCount is the number of interpolation modes.
+	GradientInterpolationCount
+)
+
+// gradientInterpolationNames are the user friendly names of the modes.
+var gradientInterpolationNames = []string{"Linear", "Smooth", "Step"}
+
+// String returns a user friendly name for the interpolation mode.
+func (mode GradientInterpolation) String() string {
+	if mode < 0 || mode >= GradientInterpolationCount {
+		return "Unknown"
+	}
+	return gradientInterpolationNames[mode]
+}
+
+// GradientKey is a color at a position along a Gradient.
+type GradientKey struct {
+	Position float32
+	Color    mgl.Vec4
+}
+
+// Gradient is a set of keyed colors that can be sampled anywhere between
+// them, such as over the life of a particle or the hours of a day. The
+// positions are usually in the range of [0, 1], but can be any values.
+// Sampling before the first key or after the last key returns the color of
+// that key.
+type Gradient struct {
+	// Keys are the colors of the gradient. They must be sorted by their
+	// position, which AddKey and Sort take care of.
+	Keys []GradientKey
+
+	// Interpolation is how the colors blend between the keys.
+	Interpolation GradientInterpolation
+}
+
+// NewGradient creates a new Gradient that blends linearly from the start
+// color at 0 to the end color at 1.
+func NewGradient(start, end mgl.Vec4) *Gradient {
+	g := new(Gradient)
+	g.Keys = []GradientKey{{0.0, start}, {1.0, end}}
+	return g
+}
+
+// Clone makes a copy of the gradient that doesn't share its keys.
+func (g *Gradient) Clone() *Gradient {
+	clone := new(Gradient)
+	clone.Keys = append([]GradientKey(nil), g.Keys...)
+	clone.Interpolation = g.Interpolation
+	return clone
+}
+
+// AddKey inserts a new key keeping the keys sorted and returns its index.
+func (g *Gradient) AddKey(position float32, color mgl.Vec4) int {
+	i := sort.Search(len(g.Keys), func(i int) bool {
+		return g.Keys[i].Position > position
+	})
+	g.Keys = append(g.Keys, GradientKey{})
+	copy(g.Keys[i+1:], g.Keys[i:])
+	g.Keys[i] = GradientKey{position, color}
+	return i
+}
+
+// RemoveKey removes the key at the index.
+func (g *Gradient) RemoveKey(i int) {
+	if i < 0 || i >= len(g.Keys) {
+		return
+	}
+	g.Keys = append(g.Keys[:i], g.Keys[i+1:]...)
+}
+
+// Sort puts the keys back in order after their positions have been changed.
+func (g *Gradient) Sort() {
+	sort.Stable(gradientKeysByPosition(g.Keys))
+}
+
+// GetLength returns the position of the last key, or 0 if there are none.
+func (g *Gradient) GetLength() float32 {
+	if len(g.Keys) == 0 {
+		return 0.0
+	}
+	return g.Keys[len(g.Keys)-1].Position
+}
+
+// Sample returns the color of the gradient at the position. A gradient
+// without keys is white.
+func (g *Gradient) Sample(position float32) mgl.Vec4 {
+	keys := g.Keys
+	if len(keys) == 0 {
+		return mgl.Vec4{1.0, 1.0, 1.0, 1.0}
+	}
+	if position <= keys[0].Position {
+		return keys[0].Color
+	}
+
+	for i := 1; i < len(keys); i++ {
+		if position >= keys[i].Position {
+			continue
+		}
+
+		prev := keys[i-1]
+		span := keys[i].Position - prev.Position
+		if g.Interpolation == GradientStep || span <= 0.0 {
+			return prev.Color
+		}
+
+		f := (position - prev.Position) / span
+		if g.Interpolation == GradientSmooth {
+			f = f * f * (3.0 - 2.0*f)
+		}
+		return prev.Color.Add(keys[i].Color.Sub(prev.Color).Mul(f))
+	}
+	return keys[len(keys)-1].Color
+}
+
+// gradientKeysByPosition implements sort.Interface to sort gradient keys by
+// their position.
+type gradientKeysByPosition []GradientKey
+
+func (s gradientKeysByPosition) Len() int {
+	return len(s)
+}
+
+func (s gradientKeysByPosition) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s gradientKeysByPosition) Less(i, j int) bool {
+	return s[i].Position < s[j].Position
+}
@@ -37,6 +37,23 @@ type Shader uint32
 // Bitfield is a typ indicating the uint32 use as an OpenGL bitfield
 type Bitfield uint32
 
+// Capabilities describes the optional features a GraphicsProvider supports
+// so that fizzle can pick fallback paths for the ones that are missing.
+type Capabilities struct {
+	// GPUSkinning is true if vertex shaders can transform vertices with
+	// an array of bone matrices. Without it, skinned meshes get skinned on
+	// the CPU instead.
+	GPUSkinning bool
+
+	// Instancing is true if DrawElementsInstanced and VertexAttribDivisor
+	// are implemented.
+	Instancing bool
+
+	// TimerQueries is true if TIME_ELAPSED queries can be used with
+	// BeginQuery and EndQuery to time work on the GPU.
+	TimerQueries bool
+}
+
 // GraphicsProvider represents a common way to interface with graphics
 // 'drivers' like OpenGL or OpenGL ES.
 type GraphicsProvider interface {
@@ -46,9 +63,19 @@ type GraphicsProvider interface {
 	// AttachShader attaches a shader object to a program object
 	AttachShader(p Program, s Shader)
 
+	// BeginQuery starts a query object, such as a TIME_ELAPSED timer query
+	BeginQuery(target Enum, q uint32)
+
+	// BeginTransformFeedback starts capturing the vertex shader outputs
+	// into the buffers bound to TRANSFORM_FEEDBACK_BUFFER
+	BeginTransformFeedback(primitiveMode Enum)
+
 	// BindBuffer binds a buffer to the OpenGL target specified by enum
 	BindBuffer(target Enum, b Buffer)
 
+	// BindBufferBase binds a buffer to an indexed buffer target
+	BindBufferBase(target Enum, index uint32, b Buffer)
+
 	// BindFragDataLocation binds a user-defined varying out variable
 	// to a fragment shader color number
 	BindFragDataLocation(p Program, color uint32, name string)
@@ -78,18 +105,28 @@ type GraphicsProvider interface {
 	// BufferData creates a new data store for the bound buffer object.
 	BufferData(target Enum, size int, data unsafe.Pointer, usage Enum)
 
+	// BufferSubData updates a subset of the data store for the bound buffer object.
+	BufferSubData(target Enum, offset int, size int, data unsafe.Pointer)
+
 	// CheckFramebufferStatus checks the completeness status of a framebuffer
 	CheckFramebufferStatus(target Enum) Enum
 
 	// Clear clears the window buffer specified in mask
 	Clear(mask Enum)
 
+	// ClearBufferuiv clears the unsigned integer color buffer of the current
+	// framebuffer at the draw buffer index to the value
+	ClearBufferuiv(buffer Enum, drawbuffer int32, value []uint32)
+
 	// ClearColor specifies the RGBA value used to clear the color buffers
 	ClearColor(red, green, blue, alpha float32)
 
 	// CompileShader compiles the shader object
 	CompileShader(s Shader)
 
+	// CompressedTexImage2D writes a 2D texture image in a compressed format.
+	CompressedTexImage2D(target Enum, level int32, intfmt Enum, width, height, border int32, imageSize int32, ptr unsafe.Pointer)
+
 	// CreateProgram creates a new shader program object
 	CreateProgram() Program
 
@@ -105,6 +142,9 @@ type GraphicsProvider interface {
 	// DeleteFramebuffer deletes the framebuffer object
 	DeleteFramebuffer(fb Buffer)
 
+	// DeleteQuery deletes a query object
+	DeleteQuery(q uint32)
+
 	// DeleteProgram deletes the shader program object
 	DeleteProgram(p Program)
 
@@ -126,12 +166,18 @@ type GraphicsProvider interface {
 	// Disable disables various GL capabilities
 	Disable(e Enum)
 
+	// DisableVertexAttribArray disables a vertex attribute array
+	DisableVertexAttribArray(a uint32)
+
 	// DrawBuffers specifies a list of color buffers to be drawn into
 	DrawBuffers(buffers []uint32)
 
 	// DrawElements renders primitives from array data
 	DrawElements(mode Enum, count int32, xtype Enum, indices unsafe.Pointer)
 
+	// DrawElementsInstanced renders multiple instances of primitives from array data
+	DrawElementsInstanced(mode Enum, count int32, xtype Enum, indices unsafe.Pointer, instanceCount int32)
+
 	// DrawArrays renders primitives from array data
 	DrawArrays(mode Enum, first int32, count int32)
 
@@ -141,6 +187,12 @@ type GraphicsProvider interface {
 	// EnableVertexAttribArray enables a vertex attribute array
 	EnableVertexAttribArray(a uint32)
 
+	// EndQuery ends the active query object of the target
+	EndQuery(target Enum)
+
+	// EndTransformFeedback stops capturing the vertex shader outputs
+	EndTransformFeedback()
+
 	// FramebufferRenderbuffer attaches a renderbuffer as a logical buffer
 	// of a framebuffer object
 	FramebufferRenderbuffer(target, attachment, renderbuffertarget Enum, renderbuffer Buffer)
@@ -157,6 +209,9 @@ type GraphicsProvider interface {
 	// GenFramebuffer generates a OpenGL framebuffer object
 	GenFramebuffer() Buffer
 
+	// GenQuery generates a query object
+	GenQuery() uint32
+
 	// GenRenderbuffer generates a OpenGL renderbuffer object
 	GenRenderbuffer() Buffer
 
@@ -169,6 +224,9 @@ type GraphicsProvider interface {
 	// GetAttribLocation returns the location of a attribute variable
 	GetAttribLocation(p Program, name string) int32
 
+	// GetCapabilities returns the optional features the provider supports.
+	GetCapabilities() Capabilities
+
 	// GetError returns the next error
 	GetError() uint32
 
@@ -178,6 +236,10 @@ type GraphicsProvider interface {
 	// GetProgramiv returns a parameter from the program object
 	GetProgramiv(p Program, pname Enum, params *int32)
 
+	// GetQueryObjectui64v returns a parameter of a query object, such as
+	// QUERY_RESULT_AVAILABLE or QUERY_RESULT
+	GetQueryObjectui64v(q uint32, pname Enum, params *uint64)
+
 	// GetShaderInfoLog returns the information log for a shader object
 	GetShaderInfoLog(s Shader) string
 
@@ -190,6 +252,10 @@ type GraphicsProvider interface {
 	// LinkProgram links a program object
 	LinkProgram(p Program)
 
+	// MapBufferRange maps a section of the bound buffer object's data store
+	// into client memory and returns a pointer to it, or nil on failure.
+	MapBufferRange(target Enum, offset int, length int, access Bitfield) unsafe.Pointer
+
 	// PolygonMode sets a polygon rasterization mode.
 	PolygonMode(face, mode Enum)
 
@@ -207,6 +273,9 @@ type GraphicsProvider interface {
 	// ReadBuffer specifies the color buffer source for pixels
 	ReadBuffer(src Enum)
 
+	// ReadPixels reads a block of pixels from the frame buffer
+	ReadPixels(x, y, width, height int32, format, ty Enum, pixels unsafe.Pointer)
+
 	// RenderbufferStorage establishes the format and dimensions of a renderbuffer
 	RenderbufferStorage(target Enum, internalformat Enum, width int32, height int32)
 
@@ -238,9 +307,16 @@ type GraphicsProvider interface {
 	// two-dimensional array or cube-map array texture
 	TexStorage3D(target Enum, level int32, intfmt uint32, width, height, depth int32)
 
+	// TexSubImage2D specifies a two-dimensonal texture subimage
+	TexSubImage2D(target Enum, level, xoff, yoff, width, height int32, fmt, ty Enum, ptr unsafe.Pointer)
+
 	// TexSubImage3D specifies a three-dimensonal texture subimage
 	TexSubImage3D(target Enum, level, xoff, yoff, zoff, width, height, depth int32, fmt, ty Enum, ptr unsafe.Pointer)
 
+	// TransformFeedbackVaryings specifies the vertex shader outputs to capture
+	// with transform feedback. This must be called before the program is linked.
+	TransformFeedbackVaryings(p Program, varyings []string, bufferMode Enum)
+
 	// Uniform1i specifies the value of a uniform variable for the current program object
 	Uniform1i(location int32, v int32)
 
@@ -253,6 +329,9 @@ type GraphicsProvider interface {
 	// Uniform1fv specifies the value of a uniform variable for the current program object
 	Uniform1fv(location int32, values []float32)
 
+	// Uniform2f specifies the value of a uniform variable for the current program object
+	Uniform2f(location int32, v0, v1 float32)
+
 	// Uniform3f specifies the value of a uniform variable for the current program object
 	Uniform3f(location int32, v0, v1, v2 float32)
 
@@ -269,6 +348,10 @@ type GraphicsProvider interface {
 	// NOTE: value should be a mgl.Mat4 or []mgl.Mat4, else it will panic.
 	UniformMatrix4fv(location, count int32, transpose bool, value interface{})
 
+	// UnmapBuffer releases the mapping of the bound buffer object's data store
+	// and returns false if the data store became corrupt while mapped.
+	UnmapBuffer(target Enum) bool
+
 	// UseProgram installs a program object as part of the current rendering state
 	UseProgram(p Program)
 
@@ -279,6 +362,10 @@ type GraphicsProvider interface {
 	// consecutive vertex attributes.
 	VertexAttribPointer(dst uint32, size int32, ty Enum, normalized bool, stride int32, ptr unsafe.Pointer)
 
+	// VertexAttribDivisor modifies the rate at which generic vertex attributes
+	// advance during instanced rendering
+	VertexAttribDivisor(index uint32, divisor uint32)
+
 	// VertexAttribIPointer uses a bound buffer to define vertex attribute data.
 	// Only integer types are accepted by this function.
 	VertexAttribIPointer(dst uint32, size int32, ty Enum, stride int32, ptr unsafe.Pointer)
@@ -43,11 +43,27 @@ func (impl *GraphicsImpl) AttachShader(p graphics.Program, s graphics.Shader) {
 	gl.AttachShader(uint32(p), uint32(s))
 }
 
+// BeginQuery starts a query object, such as a TIME_ELAPSED timer query
+func (impl *GraphicsImpl) BeginQuery(target graphics.Enum, q uint32) {
+	gl.BeginQuery(uint32(target), q)
+}
+
+// BeginTransformFeedback starts capturing the vertex shader outputs
+// into the buffers bound to TRANSFORM_FEEDBACK_BUFFER
+func (impl *GraphicsImpl) BeginTransformFeedback(primitiveMode graphics.Enum) {
+	gl.BeginTransformFeedback(uint32(primitiveMode))
+}
+
 // BindBuffer binds a buffer to the OpenGL target specified by enum
 func (impl *GraphicsImpl) BindBuffer(target graphics.Enum, b graphics.Buffer) {
 	gl.BindBuffer(uint32(target), uint32(b))
 }
 
+// BindBufferBase binds a buffer to an indexed buffer target
+func (impl *GraphicsImpl) BindBufferBase(target graphics.Enum, index uint32, b graphics.Buffer) {
+	gl.BindBufferBase(uint32(target), index, uint32(b))
+}
+
 // BindFragDataLocation binds a user-defined varying out variable
 // to a fragment shader color number
 func (impl *GraphicsImpl) BindFragDataLocation(p graphics.Program, color uint32, name string) {
@@ -97,6 +113,11 @@ func (impl *GraphicsImpl) BufferData(target graphics.Enum, size int, data unsafe
 	gl.BufferData(uint32(target), size, data, uint32(usage))
 }
 
+// BufferSubData updates a subset of the data store for the bound buffer object.
+func (impl *GraphicsImpl) BufferSubData(target graphics.Enum, offset int, size int, data unsafe.Pointer) {
+	gl.BufferSubData(uint32(target), offset, size, data)
+}
+
 // CheckFramebufferStatus checks the completeness status of a framebuffer
 func (impl *GraphicsImpl) CheckFramebufferStatus(target graphics.Enum) graphics.Enum {
 	return graphics.Enum(gl.CheckFramebufferStatus(uint32(target)))
@@ -107,6 +128,12 @@ func (impl *GraphicsImpl) Clear(mask graphics.Enum) {
 	gl.Clear(uint32(mask))
 }
 
+// ClearBufferuiv clears the unsigned integer color buffer of the current
+// framebuffer at the draw buffer index to the value
+func (impl *GraphicsImpl) ClearBufferuiv(buffer graphics.Enum, drawbuffer int32, value []uint32) {
+	gl.ClearBufferuiv(uint32(buffer), drawbuffer, &value[0])
+}
+
 // ClearColor specifies the RGBA value used to clear the color buffers
 func (impl *GraphicsImpl) ClearColor(red, green, blue, alpha float32) {
 	gl.ClearColor(red, green, blue, alpha)
@@ -117,6 +144,11 @@ func (impl *GraphicsImpl) CompileShader(s graphics.Shader) {
 	gl.CompileShader(uint32(s))
 }
 
+// CompressedTexImage2D writes a 2D texture image in a compressed format.
+func (impl *GraphicsImpl) CompressedTexImage2D(target graphics.Enum, level int32, intfmt graphics.Enum, width, height, border int32, imageSize int32, ptr unsafe.Pointer) {
+	gl.CompressedTexImage2D(uint32(target), level, uint32(intfmt), width, height, border, imageSize, ptr)
+}
+
 // CreateProgram creates a new shader program object
 func (impl *GraphicsImpl) CreateProgram() graphics.Program {
 	return graphics.Program(gl.CreateProgram())
@@ -144,6 +176,12 @@ func (impl *GraphicsImpl) DeleteFramebuffer(fb graphics.Buffer) {
 	gl.DeleteFramebuffers(1, &uintV)
 }
 
+// DeleteQuery deletes a query object
+func (impl *GraphicsImpl) DeleteQuery(q uint32) {
+	uintV := uint32(q)
+	gl.DeleteQueries(1, &uintV)
+}
+
 // DeleteProgram deletes the shader program object
 func (impl *GraphicsImpl) DeleteProgram(p graphics.Program) {
 	gl.DeleteProgram(uint32(p))
@@ -182,6 +220,11 @@ func (impl *GraphicsImpl) Disable(e graphics.Enum) {
 	gl.Disable(uint32(e))
 }
 
+// DisableVertexAttribArray disables a vertex attribute array
+func (impl *GraphicsImpl) DisableVertexAttribArray(a uint32) {
+	gl.DisableVertexAttribArray(a)
+}
+
 // DrawBuffers specifies a list of color buffers to be drawn into
 func (impl *GraphicsImpl) DrawBuffers(buffers []uint32) {
 	c := int32(len(buffers))
@@ -193,6 +236,11 @@ func (impl *GraphicsImpl) DrawElements(mode graphics.Enum, count int32, ty graph
 	gl.DrawElements(uint32(mode), count, uint32(ty), indices)
 }
 
+// DrawElementsInstanced renders multiple instances of primitives from array data
+func (impl *GraphicsImpl) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instanceCount int32) {
+	gl.DrawElementsInstanced(uint32(mode), count, uint32(ty), indices, instanceCount)
+}
+
 // DrawArrays renders primitives from array data
 func (impl *GraphicsImpl) DrawArrays(mode graphics.Enum, first int32, count int32) {
 	gl.DrawArrays(uint32(mode), first, count)
@@ -210,6 +258,16 @@ func (impl *GraphicsImpl) EnableVertexAttribArray(a uint32) {
 	gl.EnableVertexAttribArray(a)
 }
 
+// EndQuery ends the active query object of the target
+func (impl *GraphicsImpl) EndQuery(target graphics.Enum) {
+	gl.EndQuery(uint32(target))
+}
+
+// EndTransformFeedback stops capturing the vertex shader outputs
+func (impl *GraphicsImpl) EndTransformFeedback() {
+	gl.EndTransformFeedback()
+}
+
 // FramebufferRenderbuffer attaches a renderbuffer as a logical buffer
 // of a framebuffer object
 func (impl *GraphicsImpl) FramebufferRenderbuffer(target, attachment, renderbuffertarget graphics.Enum, renderbuffer graphics.Buffer) {
@@ -240,6 +298,13 @@ func (impl *GraphicsImpl) GenFramebuffer() graphics.Buffer {
 	return graphics.Buffer(b)
 }
 
+// GenQuery generates a query object
+func (impl *GraphicsImpl) GenQuery() uint32 {
+	var q uint32
+	gl.GenQueries(1, &q)
+	return q
+}
+
 // GenRenderbuffer generates a OpenGL renderbuffer object
 func (impl *GraphicsImpl) GenRenderbuffer() graphics.Buffer {
 	var b uint32
@@ -267,6 +332,15 @@ func (impl *GraphicsImpl) GetAttribLocation(p graphics.Program, name string) int
 	return gl.GetAttribLocation(uint32(p), gl.Str(glName))
 }
 
+// GetCapabilities returns the optional features the provider supports.
+func (impl *GraphicsImpl) GetCapabilities() graphics.Capabilities {
+	return graphics.Capabilities{
+		GPUSkinning:  true,
+		Instancing:   true,
+		TimerQueries: true,
+	}
+}
+
 // GetError returns the next error
 func (impl *GraphicsImpl) GetError() uint32 {
 	return gl.GetError()
@@ -289,6 +363,12 @@ func (impl *GraphicsImpl) GetProgramiv(p graphics.Program, pname graphics.Enum,
 	gl.GetProgramiv(uint32(p), uint32(pname), params)
 }
 
+// GetQueryObjectui64v returns a parameter of a query object, such as
+// QUERY_RESULT_AVAILABLE or QUERY_RESULT
+func (impl *GraphicsImpl) GetQueryObjectui64v(q uint32, pname graphics.Enum, params *uint64) {
+	gl.GetQueryObjectui64v(q, uint32(pname), params)
+}
+
 // GetShaderInfoLog returns the information log for a shader object
 func (impl *GraphicsImpl) GetShaderInfoLog(s graphics.Shader) string {
 	var logLength int32
@@ -317,6 +397,12 @@ func (impl *GraphicsImpl) LinkProgram(p graphics.Program) {
 	gl.LinkProgram(uint32(p))
 }
 
+// MapBufferRange maps a section of the bound buffer object's data store
+// into client memory and returns a pointer to it, or nil on failure.
+func (impl *GraphicsImpl) MapBufferRange(target graphics.Enum, offset int, length int, access graphics.Bitfield) unsafe.Pointer {
+	return gl.MapBufferRange(uint32(target), offset, length, uint32(access))
+}
+
 // PolygonMode sets a polygon rasterization mode.
 func (impl *GraphicsImpl) PolygonMode(face, mode graphics.Enum) {
 	gl.PolygonMode(uint32(face), uint32(mode))
@@ -344,6 +430,11 @@ func (impl *GraphicsImpl) ReadBuffer(src graphics.Enum) {
 	gl.ReadBuffer(uint32(src))
 }
 
+// ReadPixels reads a block of pixels from the frame buffer
+func (impl *GraphicsImpl) ReadPixels(x, y, width, height int32, format, ty graphics.Enum, pixels unsafe.Pointer) {
+	gl.ReadPixels(x, y, width, height, uint32(format), uint32(ty), pixels)
+}
+
 // RenderbufferStorage establishes the format and dimensions of a renderbuffer
 func (impl *GraphicsImpl) RenderbufferStorage(target graphics.Enum, internalformat graphics.Enum, width int32, height int32) {
 	gl.RenderbufferStorage(uint32(target), uint32(internalformat), width, height)
@@ -397,11 +488,29 @@ func (impl *GraphicsImpl) TexStorage3D(target graphics.Enum, level int32, intfmt
 	gl.TexStorage3D(uint32(target), level, intfmt, width, height, depth)
 }
 
+// TexSubImage2D specifies a two-dimensonal texture subimage
+func (impl *GraphicsImpl) TexSubImage2D(target graphics.Enum, level, xoff, yoff, width, height int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
+	gl.TexSubImage2D(uint32(target), level, xoff, yoff, width, height, uint32(fmt), uint32(ty), ptr)
+}
+
 // TexSubImage3D specifies a three-dimensonal texture subimage
 func (impl *GraphicsImpl) TexSubImage3D(target graphics.Enum, level, xoff, yoff, zoff, width, height, depth int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
 	gl.TexSubImage3D(uint32(target), level, xoff, yoff, zoff, width, height, depth, uint32(fmt), uint32(ty), ptr)
 }
 
+// TransformFeedbackVaryings specifies the vertex shader outputs to capture
+// with transform feedback. This must be called before the program is linked.
+func (impl *GraphicsImpl) TransformFeedbackVaryings(p graphics.Program, varyings []string, bufferMode graphics.Enum) {
+	// names have to be zero terminated for gl.Strs()
+	glVaryings := make([]string, len(varyings))
+	for i, v := range varyings {
+		glVaryings[i] = v + "\x00"
+	}
+	cVaryings, free := gl.Strs(glVaryings...)
+	defer free()
+	gl.TransformFeedbackVaryings(uint32(p), int32(len(varyings)), cVaryings, uint32(bufferMode))
+}
+
 // Uniform1i specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform1i(location int32, v int32) {
 	gl.Uniform1i(location, v)
@@ -422,6 +531,11 @@ func (impl *GraphicsImpl) Uniform1fv(location int32, values []float32) {
 	gl.Uniform1fv(location, int32(len(values)), &values[0])
 }
 
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (impl *GraphicsImpl) Uniform2f(location int32, v0, v1 float32) {
+	gl.Uniform2f(location, v0, v1)
+}
+
 // Uniform3f specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform3f(location int32, v0, v1, v2 float32) {
 	gl.Uniform3f(location, v0, v1, v2)
@@ -455,6 +569,12 @@ func (impl *GraphicsImpl) UniformMatrix4fv(location, count int32, transpose bool
 	}
 }
 
+// UnmapBuffer releases the mapping of the bound buffer object's data store
+// and returns false if the data store became corrupt while mapped.
+func (impl *GraphicsImpl) UnmapBuffer(target graphics.Enum) bool {
+	return gl.UnmapBuffer(uint32(target))
+}
+
 // UseProgram installs a program object as part of the current rendering state
 func (impl *GraphicsImpl) UseProgram(p graphics.Program) {
 	gl.UseProgram(uint32(p))
@@ -469,6 +589,12 @@ func (impl *GraphicsImpl) VertexAttribPointer(dst uint32, size int32, ty graphic
 	gl.VertexAttribPointer(dst, size, uint32(ty), normalized, stride, ptr)
 }
 
+// VertexAttribDivisor modifies the rate at which generic vertex attributes
+// advance during instanced rendering
+func (impl *GraphicsImpl) VertexAttribDivisor(index uint32, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
 // VertexAttribPointer uses a bound buffer to define vertex attribute data.
 // Only integer types are accepted by this function.
 func (impl *GraphicsImpl) VertexAttribIPointer(dst uint32, size int32, ty graphics.Enum, stride int32, ptr unsafe.Pointer) {
@@ -36,11 +36,30 @@ func (impl *GraphicsImpl) AttachShader(p graphics.Program, s graphics.Shader) {
 	gles.AttachShader(uint32(p), uint32(s))
 }
 
+// BeginQuery starts a query object, such as a TIME_ELAPSED timer query
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) BeginQuery(target graphics.Enum, q uint32) {
+	// NO-OP
+}
+
+// BeginTransformFeedback starts capturing the vertex shader outputs
+// into the buffers bound to TRANSFORM_FEEDBACK_BUFFER
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) BeginTransformFeedback(primitiveMode graphics.Enum) {
+	// NO-OP
+}
+
 // BindBuffer binds a buffer to the OpenGL target specified by enum
 func (impl *GraphicsImpl) BindBuffer(target graphics.Enum, b graphics.Buffer) {
 	gles.BindBuffer(gles.Enum(target), uint32(b))
 }
 
+// BindBufferBase binds a buffer to an indexed buffer target
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) BindBufferBase(target graphics.Enum, index uint32, b graphics.Buffer) {
+	// NO-OP
+}
+
 // BindFragDataLocation binds a user-defined varying out variable
 // to a fragment shader color number.
 // NOTE: not implemented in OpenGL ES 2
@@ -90,6 +109,11 @@ func (impl *GraphicsImpl) BufferData(target graphics.Enum, size int, data unsafe
 	gles.BufferData(gles.Enum(target), gles.SizeiPtr(size), gles.Void(data), gles.Enum(usage))
 }
 
+// BufferSubData updates a subset of the data store for the bound buffer object.
+func (impl *GraphicsImpl) BufferSubData(target graphics.Enum, offset int, size int, data unsafe.Pointer) {
+	gles.BufferSubData(gles.Enum(target), gles.IntPtr(offset), gles.SizeiPtr(size), gles.Void(data))
+}
+
 // CheckFramebufferStatus checks the completeness status of a framebuffer
 func (impl *GraphicsImpl) CheckFramebufferStatus(target graphics.Enum) graphics.Enum {
 	return graphics.Enum(gles.CheckFramebufferStatus(gles.Enum(target)))
@@ -100,6 +124,13 @@ func (impl *GraphicsImpl) Clear(mask graphics.Enum) {
 	gles.Clear(gles.Bitfield(mask))
 }
 
+// ClearBufferuiv clears the unsigned integer color buffer of the current
+// framebuffer at the draw buffer index to the value
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) ClearBufferuiv(buffer graphics.Enum, drawbuffer int32, value []uint32) {
+	// NO-OP
+}
+
 // ClearColor specifies the RGBA value used to clear the color buffers
 func (impl *GraphicsImpl) ClearColor(red, green, blue, alpha float32) {
 	gles.ClearColor(gles.Clampf(red), gles.Clampf(green), gles.Clampf(blue), gles.Clampf(alpha))
@@ -110,6 +141,11 @@ func (impl *GraphicsImpl) CompileShader(s graphics.Shader) {
 	gles.CompileShader(uint32(s))
 }
 
+// CompressedTexImage2D writes a 2D texture image in a compressed format.
+func (impl *GraphicsImpl) CompressedTexImage2D(target graphics.Enum, level int32, intfmt graphics.Enum, width, height, border int32, imageSize int32, ptr unsafe.Pointer) {
+	gles.CompressedTexImage2D(gles.Enum(target), level, gles.Enum(intfmt), gles.Sizei(width), gles.Sizei(height), border, gles.Sizei(imageSize), gles.Void(ptr))
+}
+
 // CreateProgram creates a new shader program object
 func (impl *GraphicsImpl) CreateProgram() graphics.Program {
 	return graphics.Program(gles.CreateProgram())
@@ -137,6 +173,12 @@ func (impl *GraphicsImpl) DeleteFramebuffer(fb graphics.Buffer) {
 	gles.DeleteFramebuffers(1, &ui)
 }
 
+// DeleteQuery deletes a query object
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) DeleteQuery(q uint32) {
+	// NO-OP
+}
+
 // DeleteProgram deletes the shader program object
 func (impl *GraphicsImpl) DeleteProgram(p graphics.Program) {
 	gles.DeleteProgram(uint32(p))
@@ -175,6 +217,11 @@ func (impl *GraphicsImpl) Disable(e graphics.Enum) {
 	gles.Disable(gles.Enum(e))
 }
 
+// DisableVertexAttribArray disables a vertex attribute array
+func (impl *GraphicsImpl) DisableVertexAttribArray(a uint32) {
+	gles.DisableVertexAttribArray(a)
+}
+
 // DrawBuffers specifies a list of color buffers to be drawn into
 // NOTE: not implemented in OpenGL ES 2
 func (impl *GraphicsImpl) DrawBuffers(buffers []uint32) {
@@ -186,6 +233,12 @@ func (impl *GraphicsImpl) DrawElements(mode graphics.Enum, count int32, ty graph
 	gles.DrawElements(gles.Enum(mode), gles.Sizei(count), gles.Enum(ty), gles.Void(indices))
 }
 
+// DrawElementsInstanced renders multiple instances of primitives from array data
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instanceCount int32) {
+	// NO-OP
+}
+
 // DrawArrays renders primitives from array data
 func (impl *GraphicsImpl) DrawArrays(mode graphics.Enum, first int32, count int32) {
 	gles.DrawArrays(gles.Enum(mode), first, gles.Sizei(count))
@@ -201,6 +254,18 @@ func (impl *GraphicsImpl) EnableVertexAttribArray(a uint32) {
 	gles.EnableVertexAttribArray(a)
 }
 
+// EndQuery ends the active query object of the target
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) EndQuery(target graphics.Enum) {
+	// NO-OP
+}
+
+// EndTransformFeedback stops capturing the vertex shader outputs
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) EndTransformFeedback() {
+	// NO-OP
+}
+
 // Finish blocks until the effects of all previously called GL commands are complete
 func (impl *GraphicsImpl) Finish() {
 	gles.Finish()
@@ -241,6 +306,13 @@ func (impl *GraphicsImpl) GenFramebuffer() graphics.Buffer {
 	return graphics.Buffer(b)
 }
 
+// GenQuery generates a query object
+// NOTE: not implemented in OpenGL ES 2 and always returns 0
+func (impl *GraphicsImpl) GenQuery() uint32 {
+	// NO-OP
+	return 0
+}
+
 // GenRenderbuffer generates a OpenGL renderbuffer object
 func (impl *GraphicsImpl) GenRenderbuffer() graphics.Buffer {
 	var b uint32
@@ -267,6 +339,17 @@ func (impl *GraphicsImpl) GetAttribLocation(p graphics.Program, name string) int
 	return int32(gles.GetAttribLocation(uint32(p), name))
 }
 
+// GetCapabilities returns the optional features the provider supports.
+func (impl *GraphicsImpl) GetCapabilities() graphics.Capabilities {
+	// OpenGL ES 2 only guarantees enough vertex uniforms for a handful of
+	// bone matrices and has no instanced drawing or query objects
+	return graphics.Capabilities{
+		GPUSkinning:  false,
+		Instancing:   false,
+		TimerQueries: false,
+	}
+}
+
 // GetError returns the next error
 func (impl *GraphicsImpl) GetError() uint32 {
 	return uint32(gles.GetError())
@@ -284,6 +367,13 @@ func (impl *GraphicsImpl) GetProgramiv(p graphics.Program, pname graphics.Enum,
 	gles.GetProgramiv(uint32(p), gles.Enum(pname), params)
 }
 
+// GetQueryObjectui64v returns a parameter of a query object, such as
+// QUERY_RESULT_AVAILABLE or QUERY_RESULT
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) GetQueryObjectui64v(q uint32, pname graphics.Enum, params *uint64) {
+	// NO-OP
+}
+
 // GetShaderInfoLog returns the information log for a shader object
 func (impl *GraphicsImpl) GetShaderInfoLog(s graphics.Shader) string {
 	var logLength int32
@@ -306,6 +396,13 @@ func (impl *GraphicsImpl) LinkProgram(p graphics.Program) {
 	gles.LinkProgram(uint32(p))
 }
 
+// MapBufferRange maps a section of the bound buffer object's data store
+// into client memory and returns a pointer to it, or nil on failure.
+// NOTE: not implemented in OpenGL ES 2 and always returns nil
+func (impl *GraphicsImpl) MapBufferRange(target graphics.Enum, offset int, length int, access graphics.Bitfield) unsafe.Pointer {
+	return nil
+}
+
 // PolygonMode sets a polygon rasterization mode.
 func (impl *GraphicsImpl) PolygonMode(face, mode graphics.Enum) {
 	// NO-OP: no support in OpenGL ES
@@ -360,6 +457,11 @@ func (impl *GraphicsImpl) ReadBuffer(src graphics.Enum) {
 	// NO-OP
 }
 
+// ReadPixels reads a block of pixels from the frame buffer
+func (impl *GraphicsImpl) ReadPixels(x, y, width, height int32, format, ty graphics.Enum, pixels unsafe.Pointer) {
+	gles.ReadPixels(x, y, gles.Sizei(width), gles.Sizei(height), gles.Enum(format), gles.Enum(ty), gles.Void(pixels))
+}
+
 // RenderbufferStorage establishes the format and dimensions of a renderbuffer
 func (impl *GraphicsImpl) RenderbufferStorage(target graphics.Enum, internalformat graphics.Enum, width int32, height int32) {
 	gles.RenderbufferStorage(gles.Enum(target), gles.Enum(internalformat), gles.Sizei(width), gles.Sizei(height))
@@ -412,12 +514,24 @@ func (impl *GraphicsImpl) TexStorage3D(target graphics.Enum, level int32, intfmt
 	// NO-OP
 }
 
+// TexSubImage2D specifies a two-dimensonal texture subimage
+func (impl *GraphicsImpl) TexSubImage2D(target graphics.Enum, level, xoff, yoff, width, height int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
+	gles.TexSubImage2D(gles.Enum(target), level, xoff, yoff, gles.Sizei(width), gles.Sizei(height), gles.Enum(fmt), gles.Enum(ty), gles.Void(ptr))
+}
+
 // TexSubImage3D specifies a three-dimensonal texture subimage
 // NOTE: not implemented in OpenGL ES 2
 func (impl *GraphicsImpl) TexSubImage3D(target graphics.Enum, level, xoff, yoff, zoff, width, height, depth int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
 	// NO-OP
 }
 
+// TransformFeedbackVaryings specifies the vertex shader outputs to capture
+// with transform feedback. This must be called before the program is linked.
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) TransformFeedbackVaryings(p graphics.Program, varyings []string, bufferMode graphics.Enum) {
+	// NO-OP
+}
+
 // Uniform1i specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform1i(location int32, v int32) {
 	gles.Uniform1i(location, v)
@@ -438,6 +552,11 @@ func (impl *GraphicsImpl) Uniform1fv(location int32, values []float32) {
 	gles.Uniform1fv(location, gles.Sizei(len(values)), &values[0])
 }
 
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (impl *GraphicsImpl) Uniform2f(location int32, v0, v1 float32) {
+	gles.Uniform2f(location, v0, v1)
+}
+
 // Uniform3f specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform3f(location int32, v0, v1, v2 float32) {
 	gles.Uniform3f(location, v0, v1, v2)
@@ -471,6 +590,13 @@ func (impl *GraphicsImpl) UniformMatrix4fv(location, count int32, transpose bool
 	}
 }
 
+// UnmapBuffer releases the mapping of the bound buffer object's data store
+// and returns false if the data store became corrupt while mapped.
+// NOTE: not implemented in OpenGL ES 2 and always returns false
+func (impl *GraphicsImpl) UnmapBuffer(target graphics.Enum) bool {
+	return false
+}
+
 // UseProgram installs a program object as part of the current rendering state
 func (impl *GraphicsImpl) UseProgram(p graphics.Program) {
 	gles.UseProgram(uint32(p))
@@ -485,6 +611,13 @@ func (impl *GraphicsImpl) VertexAttribPointer(dst uint32, size int32, ty graphic
 	gles.VertexAttribPointer(dst, size, gles.Enum(ty), normalized, gles.Sizei(stride), ptr)
 }
 
+// VertexAttribDivisor modifies the rate at which generic vertex attributes
+// advance during instanced rendering
+// NOTE: not implemented in OpenGL ES 2
+func (impl *GraphicsImpl) VertexAttribDivisor(index uint32, divisor uint32) {
+	// NO-OP
+}
+
 // VertexAttribIPointer uses a bound buffer to define vertex attribute data.
 // Only integer types are accepted by this function.
 // Note: not implemented in OpenGL ES 2
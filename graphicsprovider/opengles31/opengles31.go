@@ -17,6 +17,7 @@ import "C"
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"unsafe"
 
 	mgl "github.com/go-gl/mathgl/mgl32"
@@ -27,7 +28,10 @@ import (
 // GraphicsImpl is the graphics provider for the mobile
 // implementation of OpenGL.
 type GraphicsImpl struct {
-	// nothing at present
+	// timerQueries is set if the GL_EXT_disjoint_timer_query extension is
+	// available once timerQueriesChecked is set.
+	timerQueries        bool
+	timerQueriesChecked bool
 }
 
 // InitOpenGLES2 initializes the OpenGL ES 2 graphics provider and
@@ -47,11 +51,28 @@ func (impl *GraphicsImpl) AttachShader(p graphics.Program, s graphics.Shader) {
 	gles.AttachShader(uint32(p), uint32(s))
 }
 
+// BeginQuery starts a query object, such as a TIME_ELAPSED timer query
+// NOTE: timer queries need the GL_EXT_disjoint_timer_query extension
+func (impl *GraphicsImpl) BeginQuery(target graphics.Enum, q uint32) {
+	C.glBeginQuery(C.GLenum(target), C.GLuint(q))
+}
+
+// BeginTransformFeedback starts capturing the vertex shader outputs
+// into the buffers bound to TRANSFORM_FEEDBACK_BUFFER
+func (impl *GraphicsImpl) BeginTransformFeedback(primitiveMode graphics.Enum) {
+	C.glBeginTransformFeedback(C.GLenum(primitiveMode))
+}
+
 // BindBuffer binds a buffer to the OpenGL target specified by enum
 func (impl *GraphicsImpl) BindBuffer(target graphics.Enum, b graphics.Buffer) {
 	gles.BindBuffer(gles.Enum(target), uint32(b))
 }
 
+// BindBufferBase binds a buffer to an indexed buffer target
+func (impl *GraphicsImpl) BindBufferBase(target graphics.Enum, index uint32, b graphics.Buffer) {
+	C.glBindBufferBase(C.GLenum(target), C.GLuint(index), C.GLuint(b))
+}
+
 // BindFragDataLocation binds a user-defined varying out variable
 // to a fragment shader color number.
 // NOTE: not implemented in OpenGL ES 2
@@ -102,6 +123,11 @@ func (impl *GraphicsImpl) BufferData(target graphics.Enum, size int, data unsafe
 	gles.BufferData(gles.Enum(target), gles.SizeiPtr(size), gles.Void(data), gles.Enum(usage))
 }
 
+// BufferSubData updates a subset of the data store for the bound buffer object.
+func (impl *GraphicsImpl) BufferSubData(target graphics.Enum, offset int, size int, data unsafe.Pointer) {
+	gles.BufferSubData(gles.Enum(target), gles.IntPtr(offset), gles.SizeiPtr(size), gles.Void(data))
+}
+
 // CheckFramebufferStatus checks the completeness status of a framebuffer
 func (impl *GraphicsImpl) CheckFramebufferStatus(target graphics.Enum) graphics.Enum {
 	return graphics.Enum(gles.CheckFramebufferStatus(gles.Enum(target)))
@@ -112,6 +138,12 @@ func (impl *GraphicsImpl) Clear(mask graphics.Enum) {
 	gles.Clear(gles.Bitfield(mask))
 }
 
+// ClearBufferuiv clears the unsigned integer color buffer of the current
+// framebuffer at the draw buffer index to the value
+func (impl *GraphicsImpl) ClearBufferuiv(buffer graphics.Enum, drawbuffer int32, value []uint32) {
+	C.glClearBufferuiv(C.GLenum(buffer), C.GLint(drawbuffer), (*C.GLuint)(unsafe.Pointer(&value[0])))
+}
+
 // ClearColor specifies the RGBA value used to clear the color buffers
 func (impl *GraphicsImpl) ClearColor(red, green, blue, alpha float32) {
 	gles.ClearColor(gles.Clampf(red), gles.Clampf(green), gles.Clampf(blue), gles.Clampf(alpha))
@@ -122,6 +154,11 @@ func (impl *GraphicsImpl) CompileShader(s graphics.Shader) {
 	gles.CompileShader(uint32(s))
 }
 
+// CompressedTexImage2D writes a 2D texture image in a compressed format.
+func (impl *GraphicsImpl) CompressedTexImage2D(target graphics.Enum, level int32, intfmt graphics.Enum, width, height, border int32, imageSize int32, ptr unsafe.Pointer) {
+	gles.CompressedTexImage2D(gles.Enum(target), level, gles.Enum(intfmt), gles.Sizei(width), gles.Sizei(height), border, gles.Sizei(imageSize), gles.Void(ptr))
+}
+
 // CreateProgram creates a new shader program object
 func (impl *GraphicsImpl) CreateProgram() graphics.Program {
 	return graphics.Program(gles.CreateProgram())
@@ -149,6 +186,12 @@ func (impl *GraphicsImpl) DeleteFramebuffer(fb graphics.Buffer) {
 	gles.DeleteFramebuffers(1, &ui)
 }
 
+// DeleteQuery deletes a query object
+func (impl *GraphicsImpl) DeleteQuery(q uint32) {
+	uintV := C.GLuint(q)
+	C.glDeleteQueries(1, &uintV)
+}
+
 // DeleteProgram deletes the shader program object
 func (impl *GraphicsImpl) DeleteProgram(p graphics.Program) {
 	gles.DeleteProgram(uint32(p))
@@ -187,6 +230,11 @@ func (impl *GraphicsImpl) Disable(e graphics.Enum) {
 	gles.Disable(gles.Enum(e))
 }
 
+// DisableVertexAttribArray disables a vertex attribute array
+func (impl *GraphicsImpl) DisableVertexAttribArray(a uint32) {
+	gles.DisableVertexAttribArray(a)
+}
+
 // DrawBuffers specifies a list of color buffers to be drawn into
 // NOTE: not implemented in OpenGL ES 2
 func (impl *GraphicsImpl) DrawBuffers(buffers []uint32) {
@@ -198,6 +246,11 @@ func (impl *GraphicsImpl) DrawElements(mode graphics.Enum, count int32, ty graph
 	gles.DrawElements(gles.Enum(mode), gles.Sizei(count), gles.Enum(ty), gles.Void(indices))
 }
 
+// DrawElementsInstanced renders multiple instances of primitives from array data
+func (impl *GraphicsImpl) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instanceCount int32) {
+	C.glDrawElementsInstanced(C.GLenum(mode), C.GLsizei(count), C.GLenum(ty), indices, C.GLsizei(instanceCount))
+}
+
 // DrawArrays renders primitives from array data
 func (impl *GraphicsImpl) DrawArrays(mode graphics.Enum, first int32, count int32) {
 	gles.DrawArrays(gles.Enum(mode), first, gles.Sizei(count))
@@ -213,6 +266,16 @@ func (impl *GraphicsImpl) EnableVertexAttribArray(a uint32) {
 	gles.EnableVertexAttribArray(a)
 }
 
+// EndQuery ends the active query object of the target
+func (impl *GraphicsImpl) EndQuery(target graphics.Enum) {
+	C.glEndQuery(C.GLenum(target))
+}
+
+// EndTransformFeedback stops capturing the vertex shader outputs
+func (impl *GraphicsImpl) EndTransformFeedback() {
+	C.glEndTransformFeedback()
+}
+
 // Finish blocks until the effects of all previously called GL commands are complete
 func (impl *GraphicsImpl) Finish() {
 	gles.Finish()
@@ -253,6 +316,13 @@ func (impl *GraphicsImpl) GenFramebuffer() graphics.Buffer {
 	return graphics.Buffer(b)
 }
 
+// GenQuery generates a query object
+func (impl *GraphicsImpl) GenQuery() uint32 {
+	var q C.GLuint
+	C.glGenQueries(1, &q)
+	return uint32(q)
+}
+
 // GenRenderbuffer generates a OpenGL renderbuffer object
 func (impl *GraphicsImpl) GenRenderbuffer() graphics.Buffer {
 	var b uint32
@@ -279,6 +349,22 @@ func (impl *GraphicsImpl) GetAttribLocation(p graphics.Program, name string) int
 	return int32(gles.GetAttribLocation(uint32(p), name))
 }
 
+// GetCapabilities returns the optional features the provider supports.
+func (impl *GraphicsImpl) GetCapabilities() graphics.Capabilities {
+	// timer queries aren't part of OpenGL ES 3 and need an extension
+	if !impl.timerQueriesChecked {
+		extensions := C.GoString((*C.char)(unsafe.Pointer(C.glGetString(C.GL_EXTENSIONS))))
+		impl.timerQueries = strings.Contains(extensions, "GL_EXT_disjoint_timer_query")
+		impl.timerQueriesChecked = true
+	}
+
+	return graphics.Capabilities{
+		GPUSkinning:  true,
+		Instancing:   true,
+		TimerQueries: impl.timerQueries,
+	}
+}
+
 // GetError returns the next error
 func (impl *GraphicsImpl) GetError() uint32 {
 	return uint32(gles.GetError())
@@ -296,6 +382,15 @@ func (impl *GraphicsImpl) GetProgramiv(p graphics.Program, pname graphics.Enum,
 	gles.GetProgramiv(uint32(p), gles.Enum(pname), params)
 }
 
+// GetQueryObjectui64v returns a parameter of a query object, such as
+// QUERY_RESULT_AVAILABLE or QUERY_RESULT
+// NOTE: OpenGL ES 3 only has 32-bit query results
+func (impl *GraphicsImpl) GetQueryObjectui64v(q uint32, pname graphics.Enum, params *uint64) {
+	var result C.GLuint
+	C.glGetQueryObjectuiv(C.GLuint(q), C.GLenum(pname), &result)
+	*params = uint64(result)
+}
+
 // GetShaderInfoLog returns the information log for a shader object
 func (impl *GraphicsImpl) GetShaderInfoLog(s graphics.Shader) string {
 	var logLength int32
@@ -318,6 +413,12 @@ func (impl *GraphicsImpl) LinkProgram(p graphics.Program) {
 	gles.LinkProgram(uint32(p))
 }
 
+// MapBufferRange maps a section of the bound buffer object's data store
+// into client memory and returns a pointer to it, or nil on failure.
+func (impl *GraphicsImpl) MapBufferRange(target graphics.Enum, offset int, length int, access graphics.Bitfield) unsafe.Pointer {
+	return C.glMapBufferRange(C.GLenum(target), C.GLintptr(offset), C.GLsizeiptr(length), C.GLbitfield(access))
+}
+
 // PolygonMode sets a polygon rasterization mode.
 func (impl *GraphicsImpl) PolygonMode(face, mode graphics.Enum) {
 	// NO-OP: no support in OpenGL ES
@@ -372,6 +473,11 @@ func (impl *GraphicsImpl) ReadBuffer(src graphics.Enum) {
 	// NO-OP
 }
 
+// ReadPixels reads a block of pixels from the frame buffer
+func (impl *GraphicsImpl) ReadPixels(x, y, width, height int32, format, ty graphics.Enum, pixels unsafe.Pointer) {
+	gles.ReadPixels(x, y, gles.Sizei(width), gles.Sizei(height), gles.Enum(format), gles.Enum(ty), gles.Void(pixels))
+}
+
 // RenderbufferStorage establishes the format and dimensions of a renderbuffer
 func (impl *GraphicsImpl) RenderbufferStorage(target graphics.Enum, internalformat graphics.Enum, width int32, height int32) {
 	gles.RenderbufferStorage(gles.Enum(target), gles.Enum(internalformat), gles.Sizei(width), gles.Sizei(height))
@@ -431,6 +537,11 @@ func (impl *GraphicsImpl) TexStorage3D(target graphics.Enum, level int32, intfmt
 	C.glTexStorage3D(C.GLenum(target), C.GLsizei(level), C.GLenum(intfmt), C.GLsizei(width), C.GLsizei(height), C.GLsizei(depth))
 }
 
+// TexSubImage2D specifies a two-dimensonal texture subimage
+func (impl *GraphicsImpl) TexSubImage2D(target graphics.Enum, level, xoff, yoff, width, height int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
+	gles.TexSubImage2D(gles.Enum(target), level, xoff, yoff, gles.Sizei(width), gles.Sizei(height), gles.Enum(fmt), gles.Enum(ty), gles.Void(ptr))
+}
+
 // TexSubImage3D specifies a three-dimensonal texture subimage
 // NOTE: not implemented in OpenGL ES 2
 func (impl *GraphicsImpl) TexSubImage3D(target graphics.Enum, level, xoff, yoff, zoff, width, height, depth int32, fmt, ty graphics.Enum, ptr unsafe.Pointer) {
@@ -438,6 +549,17 @@ func (impl *GraphicsImpl) TexSubImage3D(target graphics.Enum, level, xoff, yoff,
 		C.GLsizei(height), C.GLsizei(depth), C.GLenum(fmt), C.GLenum(ty), unsafe.Pointer(ptr))
 }
 
+// TransformFeedbackVaryings specifies the vertex shader outputs to capture
+// with transform feedback. This must be called before the program is linked.
+func (impl *GraphicsImpl) TransformFeedbackVaryings(p graphics.Program, varyings []string, bufferMode graphics.Enum) {
+	cVaryings := make([]*C.GLchar, len(varyings))
+	for i, v := range varyings {
+		cVaryings[i] = (*C.GLchar)(C.CString(v))
+		defer C.free(unsafe.Pointer(cVaryings[i]))
+	}
+	C.glTransformFeedbackVaryings(C.GLuint(p), C.GLsizei(len(varyings)), &cVaryings[0], C.GLenum(bufferMode))
+}
+
 // Uniform1i specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform1i(location int32, v int32) {
 	gles.Uniform1i(location, v)
@@ -458,6 +580,11 @@ func (impl *GraphicsImpl) Uniform1fv(location int32, values []float32) {
 	gles.Uniform1fv(location, gles.Sizei(len(values)), &values[0])
 }
 
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (impl *GraphicsImpl) Uniform2f(location int32, v0, v1 float32) {
+	gles.Uniform2f(location, v0, v1)
+}
+
 // Uniform3f specifies the value of a uniform variable for the current program object
 func (impl *GraphicsImpl) Uniform3f(location int32, v0, v1, v2 float32) {
 	gles.Uniform3f(location, v0, v1, v2)
@@ -491,6 +618,12 @@ func (impl *GraphicsImpl) UniformMatrix4fv(location, count int32, transpose bool
 	}
 }
 
+// UnmapBuffer releases the mapping of the bound buffer object's data store
+// and returns false if the data store became corrupt while mapped.
+func (impl *GraphicsImpl) UnmapBuffer(target graphics.Enum) bool {
+	return C.glUnmapBuffer(C.GLenum(target)) == C.GL_TRUE
+}
+
 // UseProgram installs a program object as part of the current rendering state
 func (impl *GraphicsImpl) UseProgram(p graphics.Program) {
 	gles.UseProgram(uint32(p))
@@ -505,6 +638,12 @@ func (impl *GraphicsImpl) VertexAttribPointer(dst uint32, size int32, ty graphic
 	gles.VertexAttribPointer(dst, size, gles.Enum(ty), normalized, gles.Sizei(stride), ptr)
 }
 
+// VertexAttribDivisor modifies the rate at which generic vertex attributes
+// advance during instanced rendering
+func (impl *GraphicsImpl) VertexAttribDivisor(index uint32, divisor uint32) {
+	C.glVertexAttribDivisor(C.GLuint(index), C.GLuint(divisor))
+}
+
 // VertexAttribPointer uses a bound buffer to define vertex attribute data.
 // Only integer types are accepted by this function.
 // Note: not implemented in OpenGL ES 2
@@ -0,0 +1,203 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// HDRImage is a high dynamic range image read from a Radiance .hdr file.
+type HDRImage struct {
+	Width  int32
+	Height int32
+
+	// Pix holds the red, green and blue values of each pixel as floats,
+	// starting with the bottom row like images loaded from PNG files.
+	Pix []float32
+}
+
+// IsHDRFile returns true if the file extension is .hdr.
+func IsHDRFile(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".hdr"
+}
+
+// LoadHDRFile reads a Radiance .hdr file.
+func LoadHDRFile(filePath string) (*HDRImage, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the texture file: %v\n", err)
+	}
+
+	img, err := ParseHDR(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the texture %s: %v", filePath, err)
+	}
+	return img, nil
+}
+
+// ParseHDR decodes the RGBE pixels of a Radiance .hdr file, with either run
+// length encoded or flat scanlines.
+func ParseHDR(data []byte) (*HDRImage, error) {
+	if !bytes.HasPrefix(data, []byte("#?")) {
+		return nil, fmt.Errorf("the data is not a Radiance HDR file")
+	}
+
+	// the header is a list of lines ending with an empty line
+	offset := 0
+	readLine := func() (string, bool) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			return "", false
+		}
+		line := string(data[offset : offset+end])
+		offset += end + 1
+		return strings.TrimRight(line, "\r"), true
+	}
+	for {
+		line, okay := readLine()
+		if !okay {
+			return nil, fmt.Errorf("the header doesn't end")
+		}
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("the pixel format %s is not supported", strings.TrimPrefix(line, "FORMAT="))
+		}
+	}
+
+	// only the standard orientations with rows along the X axis are supported
+	resolution, okay := readLine()
+	if !okay {
+		return nil, fmt.Errorf("the resolution is missing")
+	}
+	var yAxis, xAxis string
+	var width, height int32
+	_, err := fmt.Sscanf(resolution, "%s %d %s %d", &yAxis, &height, &xAxis, &width)
+	if err != nil || (yAxis != "-Y" && yAxis != "+Y") || xAxis != "+X" || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("the resolution %q is not supported", resolution)
+	}
+
+	img := new(HDRImage)
+	img.Width = width
+	img.Height = height
+	img.Pix = make([]float32, int(width)*int(height)*3)
+
+	scanline := make([]byte, int(width)*4)
+	for y := int32(0); y < height; y++ {
+		offset, err = readHDRScanline(data, offset, scanline)
+		if err != nil {
+			return nil, err
+		}
+
+		// -Y files start with the top row, so flip them
+		row := y
+		if yAxis == "-Y" {
+			row = height - y - 1
+		}
+		pix := img.Pix[int(row)*int(width)*3:]
+		for x := 0; x < int(width); x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			if rgbe[3] == 0 {
+				continue
+			}
+			f := float32(math.Ldexp(1.0, int(rgbe[3])-(128+8)))
+			pix[x*3] = (float32(rgbe[0]) + 0.5) * f
+			pix[x*3+1] = (float32(rgbe[1]) + 0.5) * f
+			pix[x*3+2] = (float32(rgbe[2]) + 0.5) * f
+		}
+	}
+
+	return img, nil
+}
+
+// readHDRScanline reads the RGBE pixels of one scanline starting at the
+// offset into the scanline slice and returns the offset after it.
+func readHDRScanline(data []byte, offset int, scanline []byte) (int, error) {
+	width := len(scanline) / 4
+	if offset+4 > len(data) {
+		return offset, fmt.Errorf("the pixel data ends early")
+	}
+
+	// run length encoded scanlines start with 2, 2 and the width, and then
+	// store each of the four components separately
+	header := data[offset : offset+4]
+	if width < 8 || width > 0x7fff || header[0] != 2 || header[1] != 2 || int(header[2])<<8|int(header[3]) != width {
+		if offset+len(scanline) > len(data) {
+			return offset, fmt.Errorf("the pixel data ends early")
+		}
+		copy(scanline, data[offset:offset+len(scanline)])
+		return offset + len(scanline), nil
+	}
+	offset += 4
+
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			if offset >= len(data) {
+				return offset, fmt.Errorf("the pixel data ends early")
+			}
+			count := int(data[offset])
+			offset++
+
+			if count > 128 {
+				// a run of the same value
+				count -= 128
+				if count > width-x || offset >= len(data) {
+					return offset, fmt.Errorf("the run length encoding is corrupt")
+				}
+				for ; count > 0; count-- {
+					scanline[x*4+c] = data[offset]
+					x++
+				}
+				offset++
+			} else {
+				// a run of different values
+				if count == 0 || count > width-x || offset+count > len(data) {
+					return offset, fmt.Errorf("the run length encoding is corrupt")
+				}
+				for ; count > 0; count-- {
+					scanline[x*4+c] = data[offset]
+					x++
+					offset++
+				}
+			}
+		}
+	}
+	return offset, nil
+}
+
+// LoadHDRToTexture uploads the image into a new RGB16F texture with linear
+// filtering that repeats horizontally and clamps vertically, which is what
+// equirectangular environment images need.
+func LoadHDRToTexture(img *HDRImage) graphics.Texture {
+	tex := gfx.GenTexture()
+	uploadHDR(tex, img)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+	return tex
+}
+
+// ByteSize returns the number of bytes the image takes up on the GPU as an
+// RGB16F texture.
+func (img *HDRImage) ByteSize() int {
+	return int(img.Width) * int(img.Height) * 3 * 2
+}
+
+// uploadHDR replaces the image of an OpenGL texture with the HDR image and
+// sets the filtering and wrapping parameters, leaving the texture bound.
+func uploadHDR(tex graphics.Texture, img *HDRImage) {
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.REPEAT)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGB16F, img.Width, img.Height, 0, graphics.RGB, graphics.FLOAT, gfx.Ptr(img.Pix), len(img.Pix)*4)
+}
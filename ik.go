@@ -0,0 +1,222 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// ikEpsilon is the smallest bone length or distance the IK solver works with.
+const ikEpsilon = 0.0001
+
+// TwoBoneIK bends a chain of three bones, such as a hip, knee and ankle or
+// a shoulder, elbow and wrist, so that the end bone reaches a target. It is
+// solved after the skeleton is animated and before it gets drawn.
+type TwoBoneIK struct {
+	// RootBone, MidBone and EndBone are the names of the bones in the chain,
+	// where each one is the parent of the next.
+	RootBone string
+	MidBone  string
+	EndBone  string
+
+	// Target is where the end bone should reach in the space of the mesh.
+	Target mgl.Vec3
+
+	// Pole is a point in the space of the mesh that the middle joint bends
+	// toward when UsePole is true. Otherwise the chain keeps bending the
+	// way the animation has it.
+	Pole    mgl.Vec3
+	UsePole bool
+
+	// Weight blends between the animated pose at 0 and the solved pose at 1.
+	Weight float32
+
+	// KeepEndRotation keeps the end bone facing the way the animation has
+	// it instead of turning with the chain, such as to keep a foot flat.
+	KeepEndRotation bool
+}
+
+// NewTwoBoneIK creates a new IK chain for the bones with a weight of 1.
+func NewTwoBoneIK(rootBone, midBone, endBone string) *TwoBoneIK {
+	ik := new(TwoBoneIK)
+	ik.RootBone = rootBone
+	ik.MidBone = midBone
+	ik.EndBone = endBone
+	ik.Weight = 1.0
+	return ik
+}
+
+// GetBoneMeshPosition returns the position of the bone in the space of the
+// mesh for the current pose of the skeleton.
+func (skel *Skeleton) GetBoneMeshPosition(boneIndex int) mgl.Vec3 {
+	return skel.rootTransform.Mul4(skel.globalTransforms[boneIndex]).Col(3).Vec3()
+}
+
+// SolveTwoBoneIK bends the bone chain of the IK so that the end bone reaches
+// for the target and then updates the pose transforms. False is returned if
+// the skeleton doesn't have the bones or they have no length.
+func (skel *Skeleton) SolveTwoBoneIK(ik *TwoBoneIK) bool {
+	a := skel.GetBoneIndex(ik.RootBone)
+	b := skel.GetBoneIndex(ik.MidBone)
+	c := skel.GetBoneIndex(ik.EndBone)
+	if a < 0 || b < 0 || c < 0 {
+		return false
+	}
+	if ik.Weight <= 0.0 {
+		return true
+	}
+
+	pa := skel.GetBoneMeshPosition(a)
+	pb := skel.GetBoneMeshPosition(b)
+	pc := skel.GetBoneMeshPosition(c)
+	lab := pb.Sub(pa).Len()
+	lbc := pc.Sub(pb).Len()
+	toTarget := ik.Target.Sub(pa)
+	lat := toTarget.Len()
+	if lab < ikEpsilon || lbc < ikEpsilon || lat < ikEpsilon {
+		return false
+	}
+
+	// the target can only be reached between a fully folded and a fully
+	// stretched chain
+	minReach := float32(math.Abs(float64(lab-lbc))) + ikEpsilon
+	maxReach := lab + lbc - ikEpsilon
+	lat = mgl.Clamp(lat, minReach, maxReach)
+	dir := toTarget.Normalize()
+
+	// the middle joint bends in the plane of the target and the pole, or
+	// the current middle joint if there's no pole
+	bend := pb.Sub(pa)
+	if ik.UsePole {
+		bend = ik.Pole.Sub(pa)
+	}
+	bend = bend.Sub(dir.Mul(bend.Dot(dir)))
+	if bend.Len() < ikEpsilon {
+		bend = dir.Cross(mgl.Vec3{0, 0, 1})
+		if bend.Len() < ikEpsilon {
+			bend = dir.Cross(mgl.Vec3{1, 0, 0})
+		}
+	}
+	bend = bend.Normalize()
+
+	// law of cosines for the angle at the root joint
+	cosA := mgl.Clamp((lab*lab+lat*lat-lbc*lbc)/(2.0*lab*lat), -1.0, 1.0)
+	sinA := float32(math.Sqrt(float64(1.0 - cosA*cosA)))
+	newB := pa.Add(dir.Mul(lab * cosA)).Add(bend.Mul(lab * sinA))
+	newC := pa.Add(dir.Mul(lat))
+
+	endGlobal := skel.globalTransforms[c]
+
+	// turn the root bone so the middle joint gets to its new spot and
+	// then turn the middle bone so the end gets to the target
+	rotA := mgl.QuatBetweenVectors(pb.Sub(pa).Normalize(), newB.Sub(pa).Normalize())
+	skel.rotateBone(a, pa, weightQuat(rotA, ik.Weight))
+
+	pb = skel.GetBoneMeshPosition(b)
+	pc = skel.GetBoneMeshPosition(c)
+	rotB := mgl.QuatBetweenVectors(pc.Sub(pb).Normalize(), newC.Sub(pb).Normalize())
+	skel.rotateBone(b, pb, weightQuat(rotB, ik.Weight))
+
+	if ik.KeepEndRotation {
+		newEnd := endGlobal
+		newEnd.SetCol(3, skel.globalTransforms[c].Col(3))
+		skel.setBoneGlobal(c, newEnd)
+	}
+
+	skel.updatePoseTransforms(skel.rootTransform)
+	return true
+}
+
+// weightQuat blends from no rotation to the rotation by the weight.
+func weightQuat(q mgl.Quat, weight float32) mgl.Quat {
+	if weight >= 1.0 {
+		return q
+	}
+	return mgl.QuatSlerp(mgl.QuatIdent(), q, weight)
+}
+
+// rotateBone turns a bone, and so all of its children, by a rotation in the
+// space of the mesh around the pivot point.
+func (skel *Skeleton) rotateBone(boneIndex int, pivot mgl.Vec3, rotation mgl.Quat) {
+	meshRotation := mgl.Translate3D(pivot[0], pivot[1], pivot[2]).Mul4(rotation.Mat4()).Mul4(mgl.Translate3D(-pivot[0], -pivot[1], -pivot[2]))
+	m := skel.rootTransform.Inv().Mul4(meshRotation).Mul4(skel.rootTransform)
+	skel.setBoneGlobal(boneIndex, m.Mul4(skel.globalTransforms[boneIndex]))
+}
+
+// setBoneGlobal changes the local transform of a bone so that it ends up
+// with the global transform and then updates the global transforms.
+func (skel *Skeleton) setBoneGlobal(boneIndex int, global mgl.Mat4) {
+	parent := skel.Bones[boneIndex].Parent
+	if parent >= 0 {
+		skel.localTransforms[boneIndex] = skel.globalTransforms[parent].Inv().Mul4(global)
+	} else {
+		skel.localTransforms[boneIndex] = global
+	}
+	skel.updateGlobalTransforms()
+}
+
+// GroundRaycaster casts a ray in world space against the ground and returns
+// the location and normal of the hit and true if there was a hit. A Picker
+// can be used by wrapping PickRay and returning an up normal.
+type GroundRaycaster func(origin mgl.Vec3, direction mgl.Vec3) (mgl.Vec3, mgl.Vec3, bool)
+
+// FootPlacement plants a foot on uneven ground with a leg IK chain. The
+// animation is expected to be made for flat ground at the height of the
+// model's origin and the foot gets moved by how far the ground under it is
+// above or below that.
+type FootPlacement struct {
+	// Leg is the IK chain from the hip to the ankle.
+	Leg TwoBoneIK
+
+	// RayHeight is how far above the animated foot the ground ray starts
+	// and MaxDrop is how far below the foot the ground is looked for.
+	RayHeight float32
+	MaxDrop   float32
+
+	// Grounded and GroundNormal are set by PlaceFoot to whether the ground
+	// was found and its world space normal, such as to tilt the foot.
+	Grounded     bool
+	GroundNormal mgl.Vec3
+}
+
+// NewFootPlacement creates a foot placement helper for the leg bones.
+func NewFootPlacement(hipBone, kneeBone, ankleBone string) *FootPlacement {
+	fp := new(FootPlacement)
+	fp.Leg = *NewTwoBoneIK(hipBone, kneeBone, ankleBone)
+	fp.Leg.KeepEndRotation = true
+	fp.RayHeight = 0.5
+	fp.MaxDrop = 0.5
+	return fp
+}
+
+// PlaceFoot casts a ray down from above the animated foot and solves the
+// leg IK so the foot sits on the ground. The model matrix places the
+// skeleton's mesh in the world and the world up direction is +Y. False is
+// returned if no ground was found, which leaves the pose alone.
+func (skel *Skeleton) PlaceFoot(fp *FootPlacement, model mgl.Mat4, raycast GroundRaycaster) bool {
+	fp.Grounded = false
+	ankle := skel.GetBoneIndex(fp.Leg.EndBone)
+	if ankle < 0 {
+		return false
+	}
+
+	up := mgl.Vec3{0, 1, 0}
+	footWorld := mgl.TransformCoordinate(skel.GetBoneMeshPosition(ankle), model)
+	origin := footWorld.Add(up.Mul(fp.RayHeight))
+	hit, normal, okay := raycast(origin, up.Mul(-1.0))
+	if !okay || origin[1]-hit[1] > fp.RayHeight+fp.MaxDrop {
+		return false
+	}
+	fp.Grounded = true
+	fp.GroundNormal = normal
+
+	// move the foot by how far the ground is from the flat ground the
+	// animation was made for
+	groundOffset := hit[1] - model.Col(3)[1]
+	targetWorld := footWorld.Add(up.Mul(groundOffset))
+	fp.Leg.Target = mgl.TransformCoordinate(targetWorld, model.Inv())
+	return skel.SolveTwoBoneIK(&fp.Leg)
+}
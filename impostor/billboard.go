@@ -0,0 +1,74 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package impostor
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// Impostor swaps a Renderable for a billboard of its baked atlas when the
+// camera is far enough away.
+type Impostor struct {
+	// Atlas is the baked frames shown by the billboard.
+	Atlas *Atlas
+
+	// Source is the full detail Renderable shown up close.
+	Source *fizzle.Renderable
+
+	// Billboard is the quad shown in the distance. It shares its core with
+	// the other impostors of the atlas but has its own material.
+	Billboard *fizzle.Renderable
+
+	// Distance is how far the camera has to be from the center of the
+	// Source before the billboard is shown instead.
+	Distance float32
+
+	// Frame is the frame of the atlas the billboard currently shows.
+	Frame int
+}
+
+// NewImpostor creates an impostor for the source Renderable, which should
+// be an instance of the Renderable the atlas was baked from with the same
+// rotation and scale.
+func NewImpostor(atlas *Atlas, source *fizzle.Renderable, distance float32) *Impostor {
+	imp := new(Impostor)
+	imp.Atlas = atlas
+	imp.Source = source
+	imp.Distance = distance
+
+	imp.Billboard = atlas.quad.Clone()
+	imp.Billboard.Material = fizzle.NewMaterial()
+	imp.Billboard.Material.Shader = atlas.Shader
+	imp.Billboard.Material.DiffuseTex = atlas.Texture
+	imp.Billboard.Material.AtlasRegion = &atlas.Regions[0]
+	imp.Billboard.IsVisible = false
+	return imp
+}
+
+// Update shows the Source or the Billboard depending on the distance to
+// the camera and turns the billboard to face the camera with the closest
+// frame of the atlas.
+func (imp *Impostor) Update(cameraPos mgl.Vec3) {
+	center := imp.Source.Location.Add(imp.Atlas.Offset)
+	toCamera := cameraPos.Sub(center)
+	if toCamera.Len() <= imp.Distance {
+		imp.Source.IsVisible = true
+		imp.Billboard.IsVisible = false
+		return
+	}
+
+	imp.Source.IsVisible = false
+	imp.Billboard.IsVisible = true
+	imp.Billboard.Location = center
+
+	// the quad faces +Z so turn it to point at the camera around the Y axis
+	angle := math.Atan2(float64(toCamera[2]), float64(toCamera[0]))
+	imp.Billboard.LocalRotation = mgl.QuatRotate(float32(math.Pi/2.0-angle), mgl.Vec3{0.0, 1.0, 0.0})
+
+	imp.Frame = imp.Atlas.GetFrame(toCamera)
+	imp.Billboard.Material.AtlasRegion = &imp.Atlas.Regions[imp.Frame]
+}
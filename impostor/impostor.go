@@ -0,0 +1,216 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package impostor replaces distant objects with camera facing billboards.
+
+Bake renders a Renderable from a number of angles around it into the
+frames of an atlas texture. An Impostor then pairs a Renderable with a
+billboard that shows the frame closest to the camera's direction and
+swaps the two when the camera moves past a distance. Baking once and
+sharing the atlas between every instance of a model, such as all of the
+trees in a forest, turns thousands of meshes into quads.
+
+A simple example:
+
+	atlas, err := impostor.Bake(renderer, treeRenderable, 16, 128)
+	...
+	shader, err := impostor.CreateImpostorShader()
+	atlas.Shader = shader
+	for _, tree := range trees {
+		impostors = append(impostors, impostor.NewImpostor(atlas, tree, 50.0))
+	}
+
+	// every frame
+	for _, imp := range impostors {
+		imp.Update(camera.GetPosition())
+		renderer.DrawRenderable(imp.Source, nil, perspective, view, camera)
+		renderer.DrawRenderable(imp.Billboard, nil, perspective, view, camera)
+	}
+
+*/
+package impostor
+
+import (
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// Atlas is a texture with a Renderable drawn from evenly spaced angles
+// around the Y axis.
+type Atlas struct {
+	// Texture holds the frames in a grid starting at the bottom left.
+	Texture graphics.Texture
+
+	// Size is the width and height of the texture and FrameSize is the
+	// width and height of each frame.
+	Size      int32
+	FrameSize int32
+
+	// Regions are the areas of the texture for each frame. Frame i was
+	// drawn from the angle of i*2*Pi/len(Regions) radians around the Y
+	// axis, where 0 is looking from +X towards -X.
+	Regions []fizzle.AtlasRegion
+
+	// Radius is the radius of the bounding sphere of the baked Renderable,
+	// which is half the size of the billboard.
+	Radius float32
+
+	// Offset is the center of the bounding sphere relative to the baked
+	// Renderable's location.
+	Offset mgl.Vec3
+
+	// Shader is the shader given to the billboards of new impostors.
+	Shader *fizzle.RenderShader
+
+	quad *fizzle.Renderable
+}
+
+// Bake draws the source Renderable, with its shader and current transform,
+// from frames angles around it into a new atlas with square frames of
+// frameSize pixels. The lighting of the renderer at the time gets baked
+// into the frames. The viewport is set back to the renderer's resolution
+// afterwards but the clear color is left as transparent black.
+func Bake(r renderer.Renderer, source *fizzle.Renderable, frames int, frameSize int32) (*Atlas, error) {
+	if frames < 1 {
+		return nil, fmt.Errorf("Failed to bake the impostor. At least one frame is needed.")
+	}
+
+	gfx := r.GetGraphics()
+	columns := int32(math.Ceil(math.Sqrt(float64(frames))))
+	a := new(Atlas)
+	a.FrameSize = frameSize
+	a.Size = columns * frameSize
+
+	// find the bounding sphere of the source in world space
+	center, radius := getBoundingSphere(source)
+	a.Radius = radius
+	a.Offset = center.Sub(source.Location)
+
+	// setup the framebuffer to draw the frames into
+	a.Texture = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, a.Texture)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, a.Size, a.Size, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, nil, 0)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR_MIPMAP_LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	depth := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, depth)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.DEPTH_COMPONENT24, a.Size, a.Size)
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, a.Texture, 0)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.RENDERBUFFER, depth)
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		gfx.DeleteRenderbuffer(depth)
+		gfx.DeleteTexture(a.Texture)
+		return nil, fmt.Errorf("Failed to create the impostor framebuffer. Code 0x%x", status)
+	}
+
+	gfx.ClearColor(0.0, 0.0, 0.0, 0.0)
+	gfx.Viewport(0, 0, a.Size, a.Size)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+
+	// an orthographic projection that fits the bounding sphere from any side
+	projection := mgl.Ortho(-radius, radius, -radius, radius, radius, radius*3.0)
+	size := float32(a.Size)
+	for i := 0; i < frames; i++ {
+		col := int32(i) % columns
+		row := int32(i) / columns
+		gfx.Viewport(col*frameSize, row*frameSize, frameSize, frameSize)
+
+		angle := float32(i) * 2.0 * math.Pi / float32(frames)
+		camera := fizzle.NewOrbitCamera(center, math.Pi/2.0, radius*2.0, angle)
+		r.DrawRenderable(source, nil, projection, camera.GetViewMatrix(), camera)
+
+		var region fizzle.AtlasRegion
+		region.Name = fmt.Sprintf("frame%d", i)
+		region.UV = mgl.Vec4{
+			float32(col*frameSize) / size, float32(row*frameSize) / size,
+			float32((col+1)*frameSize) / size, float32((row+1)*frameSize) / size,
+		}
+		region.Width = frameSize
+		region.Height = frameSize
+		a.Regions = append(a.Regions, region)
+	}
+
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	gfx.DeleteFramebuffer(fbo)
+	gfx.DeleteRenderbuffer(depth)
+	width, height := r.GetResolution()
+	gfx.Viewport(0, 0, width, height)
+	fizzle.GenerateMipmaps(a.Texture)
+
+	a.quad = fizzle.CreatePlaneXY(-radius, -radius, radius, radius)
+	return a, nil
+}
+
+// Destroy releases the atlas texture and the billboard quad shared by the
+// impostors.
+func (a *Atlas) Destroy() {
+	fizzle.GetGraphics().DeleteTexture(a.Texture)
+	a.quad.Destroy()
+}
+
+// GetFrame returns the frame that was drawn from the angle closest to the
+// direction from the object to the viewer on the XZ plane.
+func (a *Atlas) GetFrame(toViewer mgl.Vec3) int {
+	angle := math.Atan2(float64(toViewer[2]), float64(toViewer[0]))
+	step := 2.0 * math.Pi / float64(len(a.Regions))
+	frame := int(math.Floor(angle/step+0.5)) % len(a.Regions)
+	if frame < 0 {
+		frame += len(a.Regions)
+	}
+	return frame
+}
+
+// getBoundingSphere returns the center and radius of a sphere around the
+// world space bounding box of the Renderable.
+func getBoundingSphere(r *fizzle.Renderable) (mgl.Vec3, float32) {
+	transform := r.GetTransformMat4()
+	b := r.BoundingRect
+	min := mgl.Vec3{float32(math.MaxFloat32), float32(math.MaxFloat32), float32(math.MaxFloat32)}
+	max := mgl.Vec3{-float32(math.MaxFloat32), -float32(math.MaxFloat32), -float32(math.MaxFloat32)}
+	for i := 0; i < 8; i++ {
+		corner := b.Bottom
+		if i&1 != 0 {
+			corner[0] = b.Top[0]
+		}
+		if i&2 != 0 {
+			corner[1] = b.Top[1]
+		}
+		if i&4 != 0 {
+			corner[2] = b.Top[2]
+		}
+		p := mgl.TransformCoordinate(corner, transform)
+		for c := 0; c < 3; c++ {
+			if p[c] < min[c] {
+				min[c] = p[c]
+			}
+			if p[c] > max[c] {
+				max[c] = p[c]
+			}
+		}
+	}
+
+	center := min.Add(max).Mul(0.5)
+	radius := max.Sub(min).Len() * 0.5
+	if radius <= 0.0 {
+		radius = 1.0
+	}
+	return center, radius
+}
@@ -0,0 +1,55 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package impostor
+
+import (
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// ImpostorShaderV330 is the vertex shader for impostor billboards. It
+	// maps the quad's UVs into the frame's region of the atlas.
+	ImpostorShaderV330 = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+    uniform vec4 MATERIAL_ATLAS_REGION;
+
+    in vec3 VERTEX_POSITION;
+    in vec2 VERTEX_UV_0;
+
+    out vec2 vs_tex0_uv;
+
+    void main(void) {
+    	gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+    	vs_tex0_uv = MATERIAL_ATLAS_REGION.xy + VERTEX_UV_0 * MATERIAL_ATLAS_REGION.zw;
+    }
+    `
+
+	// ImpostorShaderF330 is the fragment shader for impostor billboards. The
+	// lighting is already baked into the frames so the color is drawn as is,
+	// and the empty space around the object gets discarded.
+	ImpostorShaderF330 = `#version 330
+    precision highp float;
+
+    uniform sampler2D MATERIAL_TEX_DIFFUSE;
+    uniform vec4 MATERIAL_DIFFUSE;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	vec4 color = texture(MATERIAL_TEX_DIFFUSE, vs_tex0_uv) * MATERIAL_DIFFUSE;
+    	if (color.a < 0.5) {
+    		discard;
+    	}
+    	frag_color = vec4(color.rgb, 1.0);
+    }
+    `
+)
+
+// CreateImpostorShader creates the shader that draws impostor billboards.
+func CreateImpostorShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(ImpostorShaderV330, ImpostorShaderF330, nil)
+}
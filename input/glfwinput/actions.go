@@ -0,0 +1,287 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package glfwinput
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	glfw "github.com/go-gl/glfw/v3.1/glfw"
+)
+
+// InputType is the kind of device input an InputBinding reads.
+type InputType int
+
+const (
+	// InputKey reads a keyboard key.
+	InputKey InputType = iota
+
+	// InputMouseButton reads a mouse button.
+	InputMouseButton
+
+	// InputJoystickButton reads a joystick button.
+	InputJoystickButton
+
+	// InputJoystickAxis reads a joystick axis.
+	InputJoystickAxis
+)
+
+// InputBinding maps one input on a device to an action or axis.
+type InputBinding struct {
+	// Type is the kind of input to read.
+	Type InputType
+
+	// Code is the key, mouse button, joystick button or joystick axis id.
+	Code int
+
+	// Joystick is the joystick to read for joystick inputs.
+	Joystick glfw.Joystick
+
+	// Scale is the value the input adds to an axis. Buttons and keys add
+	// Scale when held and joystick axes are multiplied by it. For actions,
+	// a joystick axis only counts as pressed when moved in the direction of
+	// Scale.
+	Scale float32
+
+	// DeadZone is how far a joystick axis has to move from the center
+	// before it counts as input.
+	DeadZone float32
+}
+
+// KeyInput creates a binding for a keyboard key.
+func KeyInput(key glfw.Key, scale float32) InputBinding {
+	return InputBinding{Type: InputKey, Code: int(key), Scale: scale}
+}
+
+// MouseButtonInput creates a binding for a mouse button.
+func MouseButtonInput(button glfw.MouseButton, scale float32) InputBinding {
+	return InputBinding{Type: InputMouseButton, Code: int(button), Scale: scale}
+}
+
+// JoystickButtonInput creates a binding for a joystick button.
+func JoystickButtonInput(j glfw.Joystick, button int, scale float32) InputBinding {
+	return InputBinding{Type: InputJoystickButton, Code: button, Joystick: j, Scale: scale}
+}
+
+// JoystickAxisInput creates a binding for a joystick axis.
+func JoystickAxisInput(j glfw.Joystick, axis int, scale float32, deadZone float32) InputBinding {
+	return InputBinding{Type: InputJoystickAxis, Code: axis, Joystick: j, Scale: scale, DeadZone: deadZone}
+}
+
+// ActionMap maps named actions and axes to device inputs so that game code
+// can ask about "jump" or "move_x" instead of checking keys directly.
+// Call Update once per frame to poll the devices.
+type ActionMap struct {
+	// Actions are the named buttons that are either pressed or not.
+	Actions map[string][]InputBinding
+
+	// Axes are the named values in the range of [-1, 1].
+	Axes map[string][]InputBinding
+
+	// window is the GLFW window to poll for key and mouse input
+	window *glfw.Window
+
+	pressed    map[string]bool
+	wasPressed map[string]bool
+	axisValues map[string]float32
+	joyButtons map[glfw.Joystick][]byte
+	joyAxes    map[glfw.Joystick][]float32
+}
+
+// NewActionMap returns a newly created action map object
+func NewActionMap(w *glfw.Window) *ActionMap {
+	am := new(ActionMap)
+	am.Actions = make(map[string][]InputBinding)
+	am.Axes = make(map[string][]InputBinding)
+	am.window = w
+	am.pressed = make(map[string]bool)
+	am.wasPressed = make(map[string]bool)
+	am.axisValues = make(map[string]float32)
+	am.joyButtons = make(map[glfw.Joystick][]byte)
+	am.joyAxes = make(map[glfw.Joystick][]float32)
+	return am
+}
+
+// BindAction adds input bindings to a named action.
+func (am *ActionMap) BindAction(name string, bindings ...InputBinding) {
+	am.Actions[name] = append(am.Actions[name], bindings...)
+}
+
+// BindAxis adds input bindings to a named axis.
+func (am *ActionMap) BindAxis(name string, bindings ...InputBinding) {
+	am.Axes[name] = append(am.Axes[name], bindings...)
+}
+
+// RebindAction replaces all of the input bindings for a named action.
+func (am *ActionMap) RebindAction(name string, bindings ...InputBinding) {
+	am.Actions[name] = bindings
+}
+
+// RebindAxis replaces all of the input bindings for a named axis.
+func (am *ActionMap) RebindAxis(name string, bindings ...InputBinding) {
+	am.Axes[name] = bindings
+}
+
+// Update polls the devices and updates the state of all of the actions
+// and axes. It should be called once per frame.
+func (am *ActionMap) Update() {
+	// poll each joystick in use once
+	for j := range am.joyButtons {
+		delete(am.joyButtons, j)
+	}
+	for j := range am.joyAxes {
+		delete(am.joyAxes, j)
+	}
+
+	for name, bindings := range am.Actions {
+		am.wasPressed[name] = am.pressed[name]
+		pressed := false
+		for _, b := range bindings {
+			if am.readInput(b)*signOf(b.Scale) > 0.5 {
+				pressed = true
+				break
+			}
+		}
+		am.pressed[name] = pressed
+	}
+
+	for name, bindings := range am.Axes {
+		var value float32
+		for _, b := range bindings {
+			value += am.readInput(b) * b.Scale
+		}
+		am.axisValues[name] = float32(math.Max(-1.0, math.Min(1.0, float64(value))))
+	}
+}
+
+// IsPressed returns true if the action is currently held down.
+func (am *ActionMap) IsPressed(name string) bool {
+	return am.pressed[name]
+}
+
+// JustPressed returns true if the action was pressed since the last Update.
+func (am *ActionMap) JustPressed(name string) bool {
+	return am.pressed[name] && !am.wasPressed[name]
+}
+
+// JustReleased returns true if the action was released since the last Update.
+func (am *ActionMap) JustReleased(name string) bool {
+	return !am.pressed[name] && am.wasPressed[name]
+}
+
+// AxisValue returns the value of the axis in the range of [-1, 1].
+func (am *ActionMap) AxisValue(name string) float32 {
+	return am.axisValues[name]
+}
+
+// readInput returns the raw value of an input; 1 for pressed buttons and
+// the position past the dead zone for joystick axes.
+func (am *ActionMap) readInput(b InputBinding) float32 {
+	switch b.Type {
+	case InputKey:
+		if am.window.GetKey(glfw.Key(b.Code)) == glfw.Press {
+			return 1.0
+		}
+	case InputMouseButton:
+		if am.window.GetMouseButton(glfw.MouseButton(b.Code)) == glfw.Press {
+			return 1.0
+		}
+	case InputJoystickButton:
+		buttons := am.getJoystickButtons(b.Joystick)
+		if b.Code >= 0 && b.Code < len(buttons) && buttons[b.Code] > 0 {
+			return 1.0
+		}
+	case InputJoystickAxis:
+		axes := am.getJoystickAxes(b.Joystick)
+		if b.Code >= 0 && b.Code < len(axes) {
+			v := axes[b.Code]
+			if float32(math.Abs(float64(v))) > b.DeadZone {
+				return v
+			}
+		}
+	}
+	return 0.0
+}
+
+// getJoystickButtons returns the button state of a joystick, polling it
+// only once per Update.
+func (am *ActionMap) getJoystickButtons(j glfw.Joystick) []byte {
+	buttons, okay := am.joyButtons[j]
+	if !okay {
+		if glfw.JoystickPresent(j) {
+			buttons = glfw.GetJoystickButtons(j)
+		}
+		am.joyButtons[j] = buttons
+	}
+	return buttons
+}
+
+// getJoystickAxes returns the axis state of a joystick, polling it
+// only once per Update.
+func (am *ActionMap) getJoystickAxes(j glfw.Joystick) []float32 {
+	axes, okay := am.joyAxes[j]
+	if !okay {
+		if glfw.JoystickPresent(j) {
+			axes = glfw.GetJoystickAxes(j)
+		}
+		am.joyAxes[j] = axes
+	}
+	return axes
+}
+
+// signOf returns -1 for negative values and 1 otherwise.
+func signOf(v float32) float32 {
+	if v < 0.0 {
+		return -1.0
+	}
+	return 1.0
+}
+
+// actionMapDefinition is the serialized form of an ActionMap.
+type actionMapDefinition struct {
+	Actions map[string][]InputBinding
+	Axes    map[string][]InputBinding
+}
+
+// Save writes the action and axis bindings to a JSON file.
+func (am *ActionMap) Save(filepath string) error {
+	def := actionMapDefinition{Actions: am.Actions, Axes: am.Axes}
+	jsonBytes, err := json.MarshalIndent(&def, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the input bindings to JSON. %v", err)
+	}
+
+	err = ioutil.WriteFile(filepath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the input bindings file %s. %v", filepath, err)
+	}
+
+	return nil
+}
+
+// Load reads a JSON file written by Save and replaces the bindings of the
+// actions and axes in the file, keeping any others.
+func (am *ActionMap) Load(filepath string) error {
+	jsonBytes, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return fmt.Errorf("Failed to read the input bindings file %s. %v", filepath, err)
+	}
+
+	var def actionMapDefinition
+	err = json.Unmarshal(jsonBytes, &def)
+	if err != nil {
+		return fmt.Errorf("Failed to decode the JSON for the input bindings. %v", err)
+	}
+
+	for name, bindings := range def.Actions {
+		am.Actions[name] = bindings
+	}
+	for name, bindings := range def.Axes {
+		am.Axes[name] = bindings
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+const (
+	// LayerDefault is the layer of Renderables that haven't been assigned
+	// to any layers.
+	LayerDefault uint32 = 1 << 0
+
+	// LayerGizmos is the layer for editor helpers, such as handles and
+	// bounding boxes, that should not show up in captures like thumbnails.
+	LayerGizmos uint32 = 1 << 31
+
+	// LayerAll is a cull mask that includes every layer.
+	LayerAll uint32 = 0xFFFFFFFF
+)
+
+// CullMaskCamera is implemented by cameras that only see Renderables in some
+// of the layers. Cameras that don't implement it see all of the layers.
+type CullMaskCamera interface {
+	// GetCullMask returns the bitmask of the layers the camera sees.
+	GetCullMask() uint32
+}
+
+// GetCameraCullMask returns the bitmask of the layers the camera sees, which
+// is LayerAll for cameras that don't implement CullMaskCamera.
+func GetCameraCullMask(camera Camera) uint32 {
+	if maskCam, okay := camera.(CullMaskCamera); okay {
+		return maskCam.GetCullMask()
+	}
+	return LayerAll
+}
+
+// GetLayers returns the bitmask of the layers the Renderable is in. A
+// Renderable with no layers set is in LayerDefault.
+func (r *Renderable) GetLayers() uint32 {
+	if r.Layers == 0 {
+		return LayerDefault
+	}
+	return r.Layers
+}
+
+// IsInLayers returns true if the Renderable is in any of the layers of the
+// bitmask.
+func (r *Renderable) IsInLayers(mask uint32) bool {
+	return r.GetLayers()&mask != 0
+}
+
+// SetLayersRecursive sets the layers of the Renderable and all of its
+// children, such as to move a whole first-person weapon into its own layer.
+func (r *Renderable) SetLayersRecursive(layers uint32) {
+	r.Layers = layers
+	for _, child := range r.Children {
+		child.SetLayersRecursive(layers)
+	}
+}
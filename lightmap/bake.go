@@ -0,0 +1,541 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package lightmap bakes the static lighting of components into lightmap
+textures offline.
+
+Meshes without a second UV channel get one generated by grouping their
+faces into flat charts and packing those into the lightmap. Every texel of
+the lightmap is then lit by the scene lights, with shadows traced against
+all of the components added to the Baker, and darkened by ambient
+occlusion. Saving writes the lightmaps as PNG files next to the components
+and updates the component files so that their materials reference them:
+
+	baker := lightmap.NewBaker(lightmap.LightsFromScene(s), lightmap.DefaultOptions())
+	baker.AddComponent(comp, "assets/room.json", mgl.Ident4())
+	baker.Bake()
+	err := baker.Save()
+
+The lightmaps can be drawn with forward.CreateLightmappedShader or any
+shader that reads MATERIAL_TEX_LIGHTMAP with the VERTEX_UV_1 coordinates.
+
+*/
+package lightmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/fizzle/scene"
+	gombz "github.com/tbogdala/gombz"
+)
+
+// Light is a light that gets baked into the lightmaps. The fields mirror
+// the ones in the forward renderer's Light so that baked lighting matches
+// the dynamic lighting.
+type Light struct {
+	// Position is the location of point lights.
+	Position mgl.Vec3
+
+	// Direction is the direction directional lights shine in and is zero
+	// for point lights.
+	Direction mgl.Vec3
+
+	DiffuseColor         mgl.Vec3
+	DiffuseIntensity     float32
+	AmbientIntensity     float32
+	ConstAttenuation     float32
+	LinearAttenuation    float32
+	QuadraticAttenuation float32
+	Strength             float32
+}
+
+// LightsFromScene returns the lights of the scene for baking.
+func LightsFromScene(s *scene.Scene) []Light {
+	lights := make([]Light, 0, len(s.Lights))
+	for _, desc := range s.Lights {
+		l := Light{
+			Position:             desc.Position,
+			DiffuseColor:         desc.DiffuseColor.Vec3(),
+			DiffuseIntensity:     desc.DiffuseIntensity,
+			AmbientIntensity:     desc.AmbientIntensity,
+			ConstAttenuation:     desc.ConstAttenuation,
+			LinearAttenuation:    desc.LinearAttenuation,
+			QuadraticAttenuation: desc.QuadraticAttenuation,
+			Strength:             desc.Strength,
+		}
+		if desc.Type == scene.LightTypeDirectional {
+			l.Direction = desc.Direction
+		}
+		lights = append(lights, l)
+	}
+	return lights
+}
+
+// Options control the quality of the bake.
+type Options struct {
+	// TexelsPerUnit is the lightmap resolution in texels per world unit
+	// for meshes that get their UVs generated.
+	TexelsPerUnit float32
+
+	// MaxSize is the largest width and height of a lightmap.
+	MaxSize int
+
+	// Padding is the number of texels between charts that get filled with
+	// the color of the nearest chart so that filtering doesn't bleed.
+	Padding int
+
+	// AOSamples is the number of rays traced per texel for ambient
+	// occlusion. A value of 0 disables it.
+	AOSamples int
+
+	// AODistance is how far away geometry still occludes a texel.
+	AODistance float32
+
+	// AOStrength is how dark fully occluded texels get, from 0 to 1.
+	AOStrength float32
+
+	// Ambient is the ambient light added to every texel before occlusion.
+	Ambient mgl.Vec3
+
+	// ShadowBias is how far rays start off the surface to avoid hitting it.
+	ShadowBias float32
+}
+
+// DefaultOptions returns options suited to rooms and props a few units in
+// size.
+func DefaultOptions() Options {
+	return Options{
+		TexelsPerUnit: 16.0,
+		MaxSize:       1024,
+		Padding:       2,
+		AOSamples:     32,
+		AODistance:    2.0,
+		AOStrength:    1.0,
+		Ambient:       mgl.Vec3{0.1, 0.1, 0.1},
+		ShadowBias:    0.01,
+	}
+}
+
+// target is a component mesh that gets a lightmap.
+type target struct {
+	comp          *component.Component
+	componentFile string
+	mesh          *component.Mesh
+	meshIndex     int
+	transform     mgl.Mat4
+	normalMatrix  mgl.Mat3
+	unwrapped     bool
+
+	size     int
+	pixels   []mgl.Vec3
+	covered  []bool
+	texelPos []mgl.Vec3
+	texelNrm []mgl.Vec3
+}
+
+// Baker bakes the lighting of the components added to it.
+type Baker struct {
+	// Lights are the lights baked into the lightmaps.
+	Lights []Light
+
+	// Options control the quality of the bake.
+	Options Options
+
+	targets   []*target
+	triangles []triangle
+	tree      *bvh
+}
+
+// NewBaker creates a new Baker for the lights.
+func NewBaker(lights []Light, options Options) *Baker {
+	b := new(Baker)
+	b.Lights = lights
+	b.Options = options
+	b.targets = []*target{}
+	b.triangles = []triangle{}
+	return b
+}
+
+// AddComponent adds the meshes of a loaded component to be lightmapped and
+// to cast shadows. The componentFile is the JSON file the component was
+// loaded from, which gets rewritten by Save, and the transform places the
+// component in the world. A component should only be added once since its
+// instances all share the same lightmaps.
+func (b *Baker) AddComponent(comp *component.Component, componentFile string, transform mgl.Mat4) error {
+	for i, compMesh := range comp.Meshes {
+		if compMesh.SrcMesh == nil {
+			continue
+		}
+
+		t := new(target)
+		t.comp = comp
+		t.componentFile = componentFile
+		t.mesh = compMesh
+		t.meshIndex = i
+		t.transform = transform.Mul4(getMeshTransform(compMesh))
+		t.normalMatrix = t.transform.Mat3().Inv().Transpose()
+
+		t.size = GetLightmapSize(compMesh.SrcMesh, t.transform, b.Options.TexelsPerUnit, b.Options.MaxSize)
+		if !HasUV2(compMesh.SrcMesh) {
+			err := GenerateUV2(compMesh.SrcMesh, t.size, b.Options.Padding)
+			if err != nil {
+				return fmt.Errorf("Failed to generate the lightmap UVs for mesh %d of %s: %v", i, componentFile, err)
+			}
+			t.unwrapped = true
+		}
+
+		b.targets = append(b.targets, t)
+		b.AddOccluder(compMesh.SrcMesh, t.transform)
+	}
+	return nil
+}
+
+// AddOccluder adds a mesh that casts shadows and occludes but doesn't get
+// a lightmap, placed in the world by the transform.
+func (b *Baker) AddOccluder(mesh *gombz.Mesh, transform mgl.Mat4) {
+	for _, f := range mesh.Faces {
+		var t triangle
+		t.a = mgl.TransformCoordinate(mesh.Vertices[f[0]], transform)
+		t.b = mgl.TransformCoordinate(mesh.Vertices[f[1]], transform)
+		t.c = mgl.TransformCoordinate(mesh.Vertices[f[2]], transform)
+		t.centroid = t.a.Add(t.b).Add(t.c).Mul(1.0 / 3.0)
+		b.triangles = append(b.triangles, t)
+	}
+	b.tree = nil
+}
+
+// Bake lights the texels of every lightmap. The work is spread over all
+// of the CPUs.
+func (b *Baker) Bake() {
+	if b.tree == nil {
+		b.tree = newBVH(b.triangles)
+	}
+
+	for _, t := range b.targets {
+		b.rasterize(t)
+
+		workers := runtime.NumCPU()
+		rows := make(chan int, t.size)
+		for y := 0; y < t.size; y++ {
+			rows <- y
+		}
+		close(rows)
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				rng := rand.New(rand.NewSource(seed))
+				for y := range rows {
+					for x := 0; x < t.size; x++ {
+						i := y*t.size + x
+						if t.covered[i] {
+							t.pixels[i] = b.lightTexel(t.texelPos[i], t.texelNrm[i], rng)
+						}
+					}
+				}
+			}(int64(w + 1))
+		}
+		wg.Wait()
+
+		dilate(t, b.Options.Padding)
+		t.texelPos = nil
+		t.texelNrm = nil
+	}
+}
+
+// Save writes each lightmap as a PNG file next to its component, sets the
+// LightmapTexture of the mesh materials and rewrites the component files.
+// Meshes that had their UVs generated get their binary files rewritten too.
+func (b *Baker) Save() error {
+	written := make(map[*component.Component]string)
+	for _, t := range b.targets {
+		if t.pixels == nil {
+			return fmt.Errorf("Failed to save the lightmaps; Bake hasn't been called.")
+		}
+
+		compDir := filepath.Dir(t.componentFile)
+		baseName := strings.TrimSuffix(filepath.Base(t.componentFile), filepath.Ext(t.componentFile))
+		texFile := fmt.Sprintf("%s_lightmap%d.png", baseName, t.meshIndex)
+		err := writeLightmap(filepath.Join(compDir, texFile), t)
+		if err != nil {
+			return err
+		}
+		t.mesh.Material.LightmapTexture = texFile
+
+		if t.unwrapped {
+			gombzBytes, err := t.mesh.SrcMesh.Encode()
+			if err != nil {
+				return fmt.Errorf("Failed to encode the mesh with lightmap UVs: %v", err)
+			}
+			if t.mesh.BinFile == "" {
+				t.mesh.BinFile = fmt.Sprintf("%s_mesh%d.gombz", baseName, t.meshIndex)
+				t.mesh.SrcFile = ""
+			}
+			err = ioutil.WriteFile(filepath.Join(compDir, t.mesh.BinFile), gombzBytes, 0644)
+			if err != nil {
+				return fmt.Errorf("Failed to write the mesh file %s: %v", t.mesh.BinFile, err)
+			}
+		}
+
+		written[t.comp] = t.componentFile
+	}
+
+	for comp, componentFile := range written {
+		jsonBytes, err := json.MarshalIndent(comp, "", "    ")
+		if err != nil {
+			return fmt.Errorf("Failed to encode the component: %v", err)
+		}
+		err = ioutil.WriteFile(componentFile, jsonBytes, 0644)
+		if err != nil {
+			return fmt.Errorf("Failed to write the component file %s: %v", componentFile, err)
+		}
+	}
+	return nil
+}
+
+// rasterize finds the world position and normal of every texel that the
+// faces of the mesh cover in the lightmap.
+func (b *Baker) rasterize(t *target) {
+	texelCount := t.size * t.size
+	t.pixels = make([]mgl.Vec3, texelCount)
+	t.covered = make([]bool, texelCount)
+	t.texelPos = make([]mgl.Vec3, texelCount)
+	t.texelNrm = make([]mgl.Vec3, texelCount)
+
+	mesh := t.mesh.SrcMesh
+	uvs := mesh.UVChannels[1]
+	size := float32(t.size)
+	for _, f := range mesh.Faces {
+		var p [3]mgl.Vec3
+		var n [3]mgl.Vec3
+		var uv [3]mgl.Vec2
+		for j, vi := range f {
+			p[j] = mgl.TransformCoordinate(mesh.Vertices[vi], t.transform)
+			uv[j] = uvs[vi].Mul(size)
+		}
+		faceNormal := p[1].Sub(p[0]).Cross(p[2].Sub(p[0]))
+		if faceNormal.Len() == 0.0 {
+			continue
+		}
+		faceNormal = faceNormal.Normalize()
+		for j, vi := range f {
+			n[j] = faceNormal
+			if len(mesh.Normals) > int(vi) {
+				n[j] = t.normalMatrix.Mul3x1(mesh.Normals[vi]).Normalize()
+			}
+		}
+
+		area := edgeFunction(uv[0], uv[1], uv[2])
+		if area == 0.0 {
+			continue
+		}
+		minX := int(math.Floor(float64(min32(uv[0][0], min32(uv[1][0], uv[2][0])))))
+		maxX := int(math.Ceil(float64(max32(uv[0][0], max32(uv[1][0], uv[2][0])))))
+		minY := int(math.Floor(float64(min32(uv[0][1], min32(uv[1][1], uv[2][1])))))
+		maxY := int(math.Ceil(float64(max32(uv[0][1], max32(uv[1][1], uv[2][1])))))
+		for y := maxInt(minY, 0); y < minInt(maxY, t.size); y++ {
+			for x := maxInt(minX, 0); x < minInt(maxX, t.size); x++ {
+				center := mgl.Vec2{float32(x) + 0.5, float32(y) + 0.5}
+				w0 := edgeFunction(uv[1], uv[2], center) / area
+				w1 := edgeFunction(uv[2], uv[0], center) / area
+				w2 := 1.0 - w0 - w1
+				if w0 < 0.0 || w1 < 0.0 || w2 < 0.0 {
+					continue
+				}
+
+				i := y*t.size + x
+				t.covered[i] = true
+				t.texelPos[i] = p[0].Mul(w0).Add(p[1].Mul(w1)).Add(p[2].Mul(w2))
+				t.texelNrm[i] = n[0].Mul(w0).Add(n[1].Mul(w1)).Add(n[2].Mul(w2)).Normalize()
+			}
+		}
+	}
+}
+
+// lightTexel returns the baked light for a texel at the world position
+// with the normal.
+func (b *Baker) lightTexel(pos, normal mgl.Vec3, rng *rand.Rand) mgl.Vec3 {
+	origin := pos.Add(normal.Mul(b.Options.ShadowBias))
+	occlusion := b.getOcclusion(origin, normal, rng)
+
+	ambient := b.Options.Ambient
+	var direct mgl.Vec3
+	for _, l := range b.Lights {
+		var incidence mgl.Vec3
+		var distance float32
+		attenuation := l.Strength
+		if l.Direction[0] == 0.0 && l.Direction[1] == 0.0 && l.Direction[2] == 0.0 {
+			toLight := l.Position.Sub(pos)
+			distance = toLight.Len()
+			if distance == 0.0 {
+				continue
+			}
+			attenuation = l.Strength / (1.0 + l.ConstAttenuation + l.LinearAttenuation*distance + l.QuadraticAttenuation*distance*distance)
+			incidence = toLight.Mul(1.0 / distance)
+		} else {
+			distance = math.MaxFloat32
+			incidence = l.Direction.Normalize().Mul(-1.0)
+		}
+
+		ambient = ambient.Add(l.DiffuseColor.Mul(l.AmbientIntensity * attenuation))
+
+		diffuseF := normal.Dot(incidence)
+		if diffuseF <= 0.0 || b.tree.isOccluded(origin, incidence, distance) {
+			continue
+		}
+		direct = direct.Add(l.DiffuseColor.Mul(l.DiffuseIntensity * diffuseF * attenuation))
+	}
+
+	return direct.Add(ambient.Mul(occlusion))
+}
+
+// getOcclusion returns how much of the hemisphere around the normal is
+// open, from 1 for fully open to 1-AOStrength for fully occluded.
+func (b *Baker) getOcclusion(origin, normal mgl.Vec3, rng *rand.Rand) float32 {
+	if b.Options.AOSamples <= 0 || b.Options.AOStrength <= 0.0 {
+		return 1.0
+	}
+
+	// build a basis around the normal for the cosine weighted samples
+	up := mgl.Vec3{0.0, 1.0, 0.0}
+	if abs32(normal[1]) > 0.99 {
+		up = mgl.Vec3{1.0, 0.0, 0.0}
+	}
+	tangent := up.Cross(normal).Normalize()
+	bitangent := normal.Cross(tangent)
+
+	hits := 0
+	for s := 0; s < b.Options.AOSamples; s++ {
+		r := float32(math.Sqrt(rng.Float64()))
+		theta := 2.0 * math.Pi * rng.Float64()
+		x := r * float32(math.Cos(theta))
+		y := r * float32(math.Sin(theta))
+		z := float32(math.Sqrt(float64(max32(0.0, 1.0-x*x-y*y))))
+		dir := tangent.Mul(x).Add(bitangent.Mul(y)).Add(normal.Mul(z))
+		if b.tree.isOccluded(origin, dir, b.Options.AODistance) {
+			hits++
+		}
+	}
+
+	return 1.0 - b.Options.AOStrength*float32(hits)/float32(b.Options.AOSamples)
+}
+
+// dilate grows the covered texels into the padding around the charts by
+// averaging the covered neighbours of each uncovered texel.
+func dilate(t *target, passes int) {
+	size := t.size
+	for pass := 0; pass < passes; pass++ {
+		grown := append([]bool(nil), t.covered...)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if t.covered[y*size+x] {
+					continue
+				}
+				var sum mgl.Vec3
+				count := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						nx, ny := x+dx, y+dy
+						if nx < 0 || ny < 0 || nx >= size || ny >= size || !t.covered[ny*size+nx] {
+							continue
+						}
+						sum = sum.Add(t.pixels[ny*size+nx])
+						count++
+					}
+				}
+				if count > 0 {
+					t.pixels[y*size+x] = sum.Mul(1.0 / float32(count))
+					grown[y*size+x] = true
+				}
+			}
+		}
+		t.covered = grown
+	}
+}
+
+// writeLightmap writes the lightmap of the target to a PNG file. The rows
+// are flipped since textures get flipped when they are loaded.
+func writeLightmap(fileName string, t *target) error {
+	img := image.NewNRGBA(image.Rect(0, 0, t.size, t.size))
+	for y := 0; y < t.size; y++ {
+		for x := 0; x < t.size; x++ {
+			c := t.pixels[y*t.size+x]
+			offset := (t.size-1-y)*img.Stride + x*4
+			img.Pix[offset] = toByte(c[0])
+			img.Pix[offset+1] = toByte(c[1])
+			img.Pix[offset+2] = toByte(c[2])
+			img.Pix[offset+3] = 255
+		}
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to create the lightmap file %s: %v", fileName, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("Failed to encode the lightmap file %s: %v", fileName, err)
+	}
+	return nil
+}
+
+// getMeshTransform returns the transform of the mesh within its component
+// in the same way the component renderables are built.
+func getMeshTransform(compMesh *component.Mesh) mgl.Mat4 {
+	transform := mgl.Translate3D(compMesh.Offset[0], compMesh.Offset[1], compMesh.Offset[2])
+	if compMesh.RotationDegrees != 0.0 {
+		transform = transform.Mul4(mgl.QuatRotate(mgl.DegToRad(compMesh.RotationDegrees), compMesh.RotationAxis).Mat4())
+	}
+	if compMesh.Scale[0] != 0.0 || compMesh.Scale[1] != 0.0 || compMesh.Scale[2] != 0.0 {
+		transform = transform.Mul4(mgl.Scale3D(compMesh.Scale[0], compMesh.Scale[1], compMesh.Scale[2]))
+	}
+	return transform
+}
+
+// edgeFunction returns twice the signed area of the triangle a, b, c.
+func edgeFunction(a, b, c mgl.Vec2) float32 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// toByte converts a light value to a color byte, clamping it to 1.
+func toByte(v float32) uint8 {
+	if v <= 0.0 {
+		return 0
+	}
+	if v >= 1.0 {
+		return 255
+	}
+	return uint8(v*255.0 + 0.5)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,203 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package lightmap
+
+import (
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// bvhLeafSize is the most triangles a leaf node of the bvh holds.
+	bvhLeafSize = 4
+
+	// rayEpsilon is the smallest determinant and distance considered a hit.
+	rayEpsilon = 0.000001
+)
+
+// triangle is a world space triangle that blocks rays.
+type triangle struct {
+	a, b, c  mgl.Vec3
+	centroid mgl.Vec3
+}
+
+// bvhNode is a node of the bounding volume hierarchy. Leaves have a count
+// of triangles starting at first; other nodes have two children.
+type bvhNode struct {
+	min, max    mgl.Vec3
+	left, right int
+	first       int
+	count       int
+}
+
+// bvh is a bounding volume hierarchy of triangles for tracing shadow and
+// occlusion rays.
+type bvh struct {
+	triangles []triangle
+	nodes     []bvhNode
+}
+
+// newBVH builds a bvh over the triangles, which get reordered.
+func newBVH(triangles []triangle) *bvh {
+	tree := new(bvh)
+	tree.triangles = triangles
+	tree.nodes = make([]bvhNode, 0, len(triangles)*2/bvhLeafSize+1)
+	if len(triangles) > 0 {
+		tree.build(0, len(triangles))
+	}
+	return tree
+}
+
+// build adds the node for the triangles from first to last, exclusive,
+// and returns its index.
+func (tree *bvh) build(first, last int) int {
+	index := len(tree.nodes)
+	tree.nodes = append(tree.nodes, bvhNode{first: first, count: last - first})
+
+	node := &tree.nodes[index]
+	node.min = tree.triangles[first].a
+	node.max = tree.triangles[first].a
+	cmin, cmax := tree.triangles[first].centroid, tree.triangles[first].centroid
+	for _, t := range tree.triangles[first:last] {
+		for _, v := range [3]mgl.Vec3{t.a, t.b, t.c} {
+			node.min = minVec3(node.min, v)
+			node.max = maxVec3(node.max, v)
+		}
+		cmin = minVec3(cmin, t.centroid)
+		cmax = maxVec3(cmax, t.centroid)
+	}
+	if last-first <= bvhLeafSize {
+		return index
+	}
+
+	// split at the median along the longest axis of the centroids
+	extent := cmax.Sub(cmin)
+	axis := 0
+	if extent[1] > extent[axis] {
+		axis = 1
+	}
+	if extent[2] > extent[axis] {
+		axis = 2
+	}
+	sort.Sort(trianglesByAxis{tree.triangles[first:last], axis})
+	middle := (first + last) / 2
+
+	left := tree.build(first, middle)
+	right := tree.build(middle, last)
+	node = &tree.nodes[index]
+	node.left, node.right = left, right
+	node.count = 0
+	return index
+}
+
+// isOccluded returns true if any triangle is hit by the ray from origin
+// along the direction closer than maxDistance.
+func (tree *bvh) isOccluded(origin, direction mgl.Vec3, maxDistance float32) bool {
+	if len(tree.nodes) == 0 {
+		return false
+	}
+
+	var invDir mgl.Vec3
+	for i := 0; i < 3; i++ {
+		if direction[i] != 0.0 {
+			invDir[i] = 1.0 / direction[i]
+		} else {
+			invDir[i] = 1.0 / rayEpsilon
+		}
+	}
+
+	stack := make([]int, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		node := &tree.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !intersectBox(origin, invDir, node.min, node.max, maxDistance) {
+			continue
+		}
+
+		if node.count == 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+		for _, t := range tree.triangles[node.first : node.first+node.count] {
+			if d, hit := intersectTriangle(origin, direction, t); hit && d < maxDistance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// intersectBox tests the ray against the box with the slab method.
+func intersectBox(origin, invDir, boxMin, boxMax mgl.Vec3, maxDistance float32) bool {
+	tmin, tmax := float32(0.0), maxDistance
+	for i := 0; i < 3; i++ {
+		t0 := (boxMin[i] - origin[i]) * invDir[i]
+		t1 := (boxMax[i] - origin[i]) * invDir[i]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tmin = max32(tmin, t0)
+		tmax = min32(tmax, t1)
+		if tmin > tmax {
+			return false
+		}
+	}
+	return true
+}
+
+// intersectTriangle tests the ray against the triangle with the
+// Möller–Trumbore algorithm and returns the distance along the ray.
+func intersectTriangle(origin, direction mgl.Vec3, t triangle) (float32, bool) {
+	edge1 := t.b.Sub(t.a)
+	edge2 := t.c.Sub(t.a)
+	p := direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if det > -rayEpsilon && det < rayEpsilon {
+		return 0.0, false
+	}
+	invDet := 1.0 / det
+
+	s := origin.Sub(t.a)
+	u := s.Dot(p) * invDet
+	if u < 0.0 || u > 1.0 {
+		return 0.0, false
+	}
+	q := s.Cross(edge1)
+	v := direction.Dot(q) * invDet
+	if v < 0.0 || u+v > 1.0 {
+		return 0.0, false
+	}
+
+	d := edge2.Dot(q) * invDet
+	return d, d > rayEpsilon
+}
+
+// trianglesByAxis implements sort.Interface to sort triangles by their
+// centroid along an axis.
+type trianglesByAxis struct {
+	triangles []triangle
+	axis      int
+}
+
+func (s trianglesByAxis) Len() int {
+	return len(s.triangles)
+}
+
+func (s trianglesByAxis) Swap(i, j int) {
+	s.triangles[i], s.triangles[j] = s.triangles[j], s.triangles[i]
+}
+
+func (s trianglesByAxis) Less(i, j int) bool {
+	return s.triangles[i].centroid[s.axis] < s.triangles[j].centroid[s.axis]
+}
+
+func minVec3(a, b mgl.Vec3) mgl.Vec3 {
+	return mgl.Vec3{min32(a[0], b[0]), min32(a[1], b[1]), min32(a[2], b[2])}
+}
+
+func maxVec3(a, b mgl.Vec3) mgl.Vec3 {
+	return mgl.Vec3{max32(a[0], b[0]), max32(a[1], b[1]), max32(a[2], b[2])}
+}
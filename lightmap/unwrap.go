@@ -0,0 +1,381 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package lightmap
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	gombz "github.com/tbogdala/gombz"
+)
+
+// chart is a group of connected faces that face the same way and get
+// projected flat into the lightmap together.
+type chart struct {
+	faces []int
+	axis  int
+	min   mgl.Vec2
+	max   mgl.Vec2
+
+	// x, y and scale place the projected chart in texels once packed.
+	x, y  float32
+	scale float32
+}
+
+// HasUV2 returns true if the mesh has a second UV channel for lightmaps.
+func HasUV2(mesh *gombz.Mesh) bool {
+	return len(mesh.UVChannels) > 1 && len(mesh.UVChannels[1]) == len(mesh.Vertices)
+}
+
+// GetLightmapSize returns the power of two width and height of a lightmap
+// that covers the surface of the mesh, placed in the world by transform,
+// at texelsPerUnit with room for the padding around the charts, clamped
+// to maxSize.
+func GetLightmapSize(mesh *gombz.Mesh, transform mgl.Mat4, texelsPerUnit float32, maxSize int) int {
+	var area float32
+	for _, f := range mesh.Faces {
+		a := mgl.TransformCoordinate(mesh.Vertices[f[0]], transform)
+		b := mgl.TransformCoordinate(mesh.Vertices[f[1]], transform)
+		c := mgl.TransformCoordinate(mesh.Vertices[f[2]], transform)
+		area += b.Sub(a).Cross(c.Sub(a)).Len() * 0.5
+	}
+
+	// leave some slack for the space lost to packing and padding
+	needed := int(math.Sqrt(float64(area*1.5)) * float64(texelsPerUnit))
+	size := 16
+	for size < needed && size < maxSize {
+		size *= 2
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	return size
+}
+
+// GenerateUV2 creates the second UV channel of the mesh for a lightmap of
+// size by size texels. Faces are grouped into charts of connected faces
+// that point along the same major axis, each chart is projected flat along
+// that axis and the charts are packed into the lightmap with padding texels
+// between them. Vertices shared between charts get split, so every per
+// vertex array of the mesh grows to match.
+func GenerateUV2(mesh *gombz.Mesh, size int, padding int) error {
+	if len(mesh.Faces) == 0 || len(mesh.Vertices) == 0 {
+		return fmt.Errorf("Failed to generate the lightmap UVs; the mesh has no faces.")
+	}
+
+	charts := buildCharts(mesh)
+	if !packCharts(charts, size, padding) {
+		return fmt.Errorf("Failed to pack %d charts into a %dx%d lightmap.", len(charts), size, size)
+	}
+
+	splitVertices(mesh, charts, float32(size))
+	return nil
+}
+
+// buildCharts groups the faces of the mesh into charts and projects them.
+func buildCharts(mesh *gombz.Mesh) []*chart {
+	faceCount := len(mesh.Faces)
+	axes := make([]int, faceCount)
+	for i, f := range mesh.Faces {
+		a, b, c := mesh.Vertices[f[0]], mesh.Vertices[f[1]], mesh.Vertices[f[2]]
+		axes[i] = getMajorAxis(b.Sub(a).Cross(c.Sub(a)))
+	}
+
+	// join faces that share an edge and the same axis
+	parents := make([]int, faceCount)
+	for i := range parents {
+		parents[i] = i
+	}
+	edges := make(map[uint64]int)
+	for i, f := range mesh.Faces {
+		for e := 0; e < 3; e++ {
+			key := getEdgeKey(f[e], f[(e+1)%3])
+			other, found := edges[key]
+			if !found {
+				edges[key] = i
+				continue
+			}
+			if axes[other] == axes[i] {
+				parents[findRoot(parents, i)] = findRoot(parents, other)
+			}
+		}
+	}
+
+	byRoot := make(map[int]*chart)
+	charts := []*chart{}
+	for i := 0; i < faceCount; i++ {
+		root := findRoot(parents, i)
+		c, found := byRoot[root]
+		if !found {
+			c = &chart{axis: axes[i]}
+			byRoot[root] = c
+			charts = append(charts, c)
+		}
+		c.faces = append(c.faces, i)
+	}
+
+	for _, c := range charts {
+		first := true
+		for _, fi := range c.faces {
+			for _, vi := range mesh.Faces[fi] {
+				p := projectToAxis(mesh.Vertices[vi], c.axis)
+				if first {
+					c.min, c.max = p, p
+					first = false
+					continue
+				}
+				c.min = mgl.Vec2{min32(c.min[0], p[0]), min32(c.min[1], p[1])}
+				c.max = mgl.Vec2{max32(c.max[0], p[0]), max32(c.max[1], p[1])}
+			}
+		}
+	}
+
+	return charts
+}
+
+// packCharts places the charts in rows across a lightmap of size texels,
+// scaling them down until they all fit. It returns false if they can't fit
+// at any scale.
+func packCharts(charts []*chart, size int, padding int) bool {
+	var area float32
+	for _, c := range charts {
+		extent := c.max.Sub(c.min)
+		area += extent[0] * extent[1]
+	}
+
+	// start from the scale where the charts fill the lightmap and shrink
+	pad := float32(padding)
+	scale := float32(size)
+	if area > 0.0 {
+		scale = float32(size) / float32(math.Sqrt(float64(area)))
+	}
+
+	sort.Sort(chartsByHeight(charts))
+	for attempt := 0; attempt < 64; attempt++ {
+		if placeCharts(charts, float32(size), pad, scale) {
+			for _, c := range charts {
+				c.scale = scale
+			}
+			return true
+		}
+		scale *= 0.9
+	}
+	return false
+}
+
+// placeCharts tries to place the charts at the scale in rows from the
+// bottom left and returns false if they run off the top.
+func placeCharts(charts []*chart, size float32, pad float32, scale float32) bool {
+	x, y, rowHeight := pad, pad, float32(0.0)
+	for _, c := range charts {
+		extent := c.max.Sub(c.min).Mul(scale)
+		w, h := extent[0]+1.0, extent[1]+1.0
+		if w+pad*2.0 > size {
+			return false
+		}
+		if x+w+pad > size {
+			x = pad
+			y += rowHeight + pad
+			rowHeight = 0.0
+		}
+		if y+h+pad > size {
+			return false
+		}
+		c.x, c.y = x, y
+		x += w + pad
+		if h > rowHeight {
+			rowHeight = h
+		}
+	}
+	return true
+}
+
+// splitVertices rebuilds the vertex arrays of the mesh so that each chart
+// has its own vertices and fills in the second UV channel.
+func splitVertices(mesh *gombz.Mesh, charts []*chart, size float32) {
+	type chartVertex struct {
+		chart  int
+		vertex uint32
+	}
+
+	remap := make(map[chartVertex]uint32)
+	sources := []uint32{}
+	uv2 := []mgl.Vec2{}
+	faces := make([]gombz.Face, len(mesh.Faces))
+	for ci, c := range charts {
+		for _, fi := range c.faces {
+			var face gombz.Face
+			for j, vi := range mesh.Faces[fi] {
+				key := chartVertex{ci, vi}
+				newIndex, found := remap[key]
+				if !found {
+					newIndex = uint32(len(sources))
+					remap[key] = newIndex
+					sources = append(sources, vi)
+					uv2 = append(uv2, getChartUV(mesh.Vertices[vi], c, size))
+				}
+				face[j] = newIndex
+			}
+			faces[fi] = face
+		}
+	}
+
+	mesh.Vertices = remapVec3s(mesh.Vertices, sources)
+	mesh.Normals = remapVec3s(mesh.Normals, sources)
+	mesh.Tangents = remapVec3s(mesh.Tangents, sources)
+	for i := range mesh.UVChannels {
+		mesh.UVChannels[i] = remapVec2s(mesh.UVChannels[i], sources)
+	}
+	mesh.VertexWeightIds = remapVec4s(mesh.VertexWeightIds, sources)
+	mesh.VertexWeights = remapVec4s(mesh.VertexWeights, sources)
+
+	if len(mesh.UVChannels) == 0 {
+		mesh.UVChannels = append(mesh.UVChannels, nil)
+	}
+	if len(mesh.UVChannels) > 1 {
+		mesh.UVChannels[1] = uv2
+	} else {
+		mesh.UVChannels = append(mesh.UVChannels, uv2)
+	}
+	if mesh.UVChannelCount < 2 {
+		mesh.UVChannelCount = 2
+	}
+	mesh.VertexCount = uint32(len(mesh.Vertices))
+	mesh.Faces = faces
+}
+
+// getChartUV returns the lightmap UV of the vertex position in the chart,
+// offset by half a texel so the chart's texels cover its edges.
+func getChartUV(v mgl.Vec3, c *chart, size float32) mgl.Vec2 {
+	p := projectToAxis(v, c.axis).Sub(c.min).Mul(c.scale)
+	return mgl.Vec2{(c.x + p[0] + 0.5) / size, (c.y + p[1] + 0.5) / size}
+}
+
+// getMajorAxis returns which of the six axis directions, +X, -X, +Y, -Y,
+// +Z and -Z numbered from 0 to 5, the normal points along the most.
+func getMajorAxis(n mgl.Vec3) int {
+	ax, ay, az := abs32(n[0]), abs32(n[1]), abs32(n[2])
+	axis := 0
+	if ay >= ax && ay >= az {
+		axis = 2
+		if n[1] < 0.0 {
+			axis++
+		}
+	} else if az >= ax && az >= ay {
+		axis = 4
+		if n[2] < 0.0 {
+			axis++
+		}
+	} else if n[0] < 0.0 {
+		axis = 1
+	}
+	return axis
+}
+
+// projectToAxis projects the position onto the plane of the major axis.
+func projectToAxis(v mgl.Vec3, axis int) mgl.Vec2 {
+	switch axis / 2 {
+	case 0:
+		return mgl.Vec2{v[2], v[1]}
+	case 1:
+		return mgl.Vec2{v[0], v[2]}
+	default:
+		return mgl.Vec2{v[0], v[1]}
+	}
+}
+
+// getEdgeKey returns a key for the edge between the vertices that is the
+// same for either winding.
+func getEdgeKey(a, b uint32) uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return uint64(a)<<32 | uint64(b)
+}
+
+// findRoot returns the root of the set the index belongs to, compressing
+// the path along the way.
+func findRoot(parents []int, i int) int {
+	for parents[i] != i {
+		parents[i] = parents[parents[i]]
+		i = parents[i]
+	}
+	return i
+}
+
+// remapVec3s returns the values reordered by the source indexes. Empty
+// slices stay empty.
+func remapVec3s(values []mgl.Vec3, sources []uint32) []mgl.Vec3 {
+	if len(values) == 0 {
+		return values
+	}
+	remapped := make([]mgl.Vec3, len(sources))
+	for i, src := range sources {
+		remapped[i] = values[src]
+	}
+	return remapped
+}
+
+// remapVec2s returns the values reordered by the source indexes.
+func remapVec2s(values []mgl.Vec2, sources []uint32) []mgl.Vec2 {
+	if len(values) == 0 {
+		return values
+	}
+	remapped := make([]mgl.Vec2, len(sources))
+	for i, src := range sources {
+		remapped[i] = values[src]
+	}
+	return remapped
+}
+
+// remapVec4s returns the values reordered by the source indexes.
+func remapVec4s(values []mgl.Vec4, sources []uint32) []mgl.Vec4 {
+	if len(values) == 0 {
+		return values
+	}
+	remapped := make([]mgl.Vec4, len(sources))
+	for i, src := range sources {
+		remapped[i] = values[src]
+	}
+	return remapped
+}
+
+// chartsByHeight implements sort.Interface to sort charts from the tallest
+// to the shortest, which packs rows tightly.
+type chartsByHeight []*chart
+
+func (s chartsByHeight) Len() int {
+	return len(s)
+}
+
+func (s chartsByHeight) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s chartsByHeight) Less(i, j int) bool {
+	return s[i].max[1]-s[i].min[1] > s[j].max[1]-s[j].min[1]
+}
+
+func abs32(v float32) float32 {
+	if v < 0.0 {
+		return -v
+	}
+	return v
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
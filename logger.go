@@ -0,0 +1,134 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	// LogDebug is for detailed messages that help track down problems.
+	LogDebug LogLevel = iota
+
+	// LogInfo is for messages about normal operation.
+	LogInfo
+
+	// LogWarning is for problems that were worked around.
+	LogWarning
+
+	// LogError is for failures that lose data or functionality.
+	LogError
+)
+
+// String returns the name of the level as shown in log output.
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarning:
+		return "WARNING"
+	case LogError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL%d", int(l))
+	}
+}
+
+// Logger receives the log messages of fizzle and its subpackages. The tag
+// names the subsystem the message came from, such as "component" or
+// "shader", so that messages can be filtered or routed by it.
+type Logger interface {
+	Logf(level LogLevel, tag string, format string, v ...interface{})
+}
+
+// WriterLogger is a Logger that writes each message on its own line to a
+// writer with the time, level and tag in front of it.
+type WriterLogger struct {
+	// Writer is where the messages get written.
+	Writer io.Writer
+
+	// MinLevel is the lowest level of messages that get written.
+	MinLevel LogLevel
+
+	// Tags, if not empty, limits the messages written to the tags in it.
+	Tags map[string]bool
+
+	lock sync.Mutex
+}
+
+// NewWriterLogger creates a new WriterLogger writing messages of minLevel
+// and up to the writer.
+func NewWriterLogger(w io.Writer, minLevel LogLevel) *WriterLogger {
+	l := new(WriterLogger)
+	l.Writer = w
+	l.MinLevel = minLevel
+	return l
+}
+
+// NewStderrLogger creates a new WriterLogger writing messages of minLevel
+// and up to standard error, which is the default Logger.
+func NewStderrLogger(minLevel LogLevel) *WriterLogger {
+	return NewWriterLogger(os.Stderr, minLevel)
+}
+
+// Logf writes the message if its level and tag are enabled. It is safe to
+// call from multiple goroutines.
+func (l *WriterLogger) Logf(level LogLevel, tag string, format string, v ...interface{}) {
+	if level < l.MinLevel {
+		return
+	}
+	if len(l.Tags) > 0 && !l.Tags[tag] {
+		return
+	}
+
+	msg := strings.TrimRight(fmt.Sprintf(format, v...), "\n")
+	line := fmt.Sprintf("%s [%s] %s: %s\n", time.Now().Format("15:04:05.000"), level, tag, msg)
+
+	l.lock.Lock()
+	io.WriteString(l.Writer, line)
+	l.lock.Unlock()
+}
+
+// logger is the Logger messages get sent to. It is accessed externally
+// through the GetLogger() and SetLogger() functions.
+var logger Logger = NewStderrLogger(LogInfo)
+
+// loggerLock guards logger since messages get logged from the texture
+// loading goroutines as well.
+var loggerLock sync.RWMutex
+
+// GetLogger returns the Logger that fizzle and its subpackages log to.
+func GetLogger() Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+	return logger
+}
+
+// SetLogger sets the Logger that fizzle and its subpackages log to so that
+// applications can redirect or filter the messages. Setting it to nil
+// discards all messages. It is safe to call from multiple goroutines.
+func SetLogger(l Logger) {
+	loggerLock.Lock()
+	logger = l
+	loggerLock.Unlock()
+}
+
+// Logf sends a message to the current Logger. Subpackages of fizzle use it
+// for all of their logging.
+func Logf(level LogLevel, tag string, format string, v ...interface{}) {
+	l := GetLogger()
+	if l != nil {
+		l.Logf(level, tag, format, v...)
+	}
+}
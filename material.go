@@ -30,6 +30,43 @@ type Material struct {
 	// SpecularTex is the spcular map texture for the material.
 	SpecularTex graphics.Texture
 
+	// EmissiveTex is the texture for the light given off by the material.
+	EmissiveTex graphics.Texture
+
+	// AOTex is the ambient occlusion map texture for the material.
+	AOTex graphics.Texture
+
+	// RoughnessTex is the roughness map texture for the material.
+	RoughnessTex graphics.Texture
+
+	// MetalnessTex is the metalness map texture for the material.
+	MetalnessTex graphics.Texture
+
+	// ORMTex packs the ambient occlusion, roughness and metalness maps into
+	// the red, green and blue channels of one texture, the way glTF does,
+	// and is what the built-in PBR shaders use instead of the separate maps.
+	// ORMOcclusion is set if the red channel holds occlusion; glTF
+	// metallic-roughness textures leave it unused unless the occlusion
+	// texture is the same image.
+	ORMTex       graphics.Texture
+	ORMOcclusion bool
+
+	// LightmapTex is the baked lighting for the material, which is mapped
+	// with the second UV channel of the mesh.
+	LightmapTex graphics.Texture
+
+	// EnvironmentTex is the cube map of the surroundings used for
+	// reflections, with each mip level prefiltered for a rougher surface.
+	EnvironmentTex graphics.Texture
+
+	// IrradianceTex is the cube map of the diffuse light arriving from each
+	// direction, sampled with the surface normal.
+	IrradianceTex graphics.Texture
+
+	// BRDFLutTex is the lookup table of the split sum approximation used
+	// with EnvironmentTex for image based lighting.
+	BRDFLutTex graphics.Texture
+
 	// CustomTex is an array of textures that can be used for specific purposes
 	// by client code that are not covered by other textures specified in this
 	// structure.
@@ -52,6 +89,41 @@ type Material struct {
 	// be raised to -- therefore values between (0.0 - 1.0) will yield different
 	// results than values >= 1.0.
 	Shininess float32
+
+	// EmissiveColor is the color of the light given off by the material
+	// when EmissiveTex is not set. The built-in PBR shaders multiply it with
+	// EmissiveTex instead, like the emissive factor of glTF.
+	EmissiveColor mgl.Vec4
+
+	// Roughness and Metalness are the material properties in the range of
+	// [0, 1] used by physically based shaders when RoughnessTex or
+	// MetalnessTex are not set. The built-in PBR shaders multiply them with
+	// the channels of ORMTex instead, like the factors of glTF.
+	Roughness float32
+	Metalness float32
+
+	// UVScale, UVOffset and UVRotation transform the texture coordinates of
+	// the mesh in shaders that use MATERIAL_UV_TRANSFORM, such as to tile a
+	// texture across a large floor. The rotation is in radians around the
+	// center of the texture.
+	UVScale    mgl.Vec2
+	UVOffset   mgl.Vec2
+	UVRotation float32
+
+	// AtlasRegion is the area of the textures to draw when DiffuseTex is a
+	// TextureAtlas. The UVs of the mesh get mapped into the region by
+	// shaders that use MATERIAL_ATLAS_REGION. A nil value uses the
+	// whole texture.
+	AtlasRegion *AtlasRegion
+
+	// OverrideExposure makes renderers use the Exposure of the material
+	// instead of their own exposure compensation, such as for emissive UI
+	// elements that should look the same however bright the scene is.
+	OverrideExposure bool
+
+	// Exposure is the exposure compensation in stops used for the material
+	// when OverrideExposure is set.
+	Exposure float32
 }
 
 // NewMaterial creates a new material with sane defaults.
@@ -60,5 +132,49 @@ func NewMaterial() *Material {
 	m.DiffuseColor = mgl.Vec4{1, 1, 1, 1}
 	m.SpecularColor = mgl.Vec4{1, 1, 1, 1}
 	m.Shininess = 1.0
+	m.Roughness = 1.0
+	m.UVScale = mgl.Vec2{1, 1}
 	return m
 }
+
+// Clone makes a copy of the material that can be changed without affecting
+// the original. The shader, textures and atlas region are shared since
+// they are not owned by the material.
+func (m *Material) Clone() *Material {
+	clone := new(Material)
+	*clone = *m
+	return clone
+}
+
+// SetAtlasRegion sets the diffuse texture of the material to the atlas and
+// the region to draw to the named image in the atlas. False is returned if
+// the image isn't in the atlas.
+func (m *Material) SetAtlasRegion(atlas *TextureAtlas, name string) bool {
+	region, okay := atlas.GetRegion(name)
+	if !okay {
+		return false
+	}
+
+	m.DiffuseTex = atlas.Texture
+	m.AtlasRegion = region
+	return true
+}
+
+// GetUVTransform returns the matrix that transforms the texture coordinates
+// of the mesh by the UVScale, UVRotation and UVOffset of the material. A
+// UVScale component of 0 is treated as 1 so that materials without a scale
+// set draw the textures once.
+func (m *Material) GetUVTransform() mgl.Mat4 {
+	scaleU, scaleV := m.UVScale[0], m.UVScale[1]
+	if scaleU == 0.0 {
+		scaleU = 1.0
+	}
+	if scaleV == 0.0 {
+		scaleV = 1.0
+	}
+
+	transform := mgl.Translate3D(m.UVOffset[0]+0.5, m.UVOffset[1]+0.5, 0.0)
+	transform = transform.Mul4(mgl.HomogRotate3DZ(m.UVRotation))
+	transform = transform.Mul4(mgl.Translate3D(-0.5, -0.5, 0.0))
+	return transform.Mul4(mgl.Scale3D(scaleU, scaleV, 1.0))
+}
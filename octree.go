@@ -0,0 +1,442 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"container/heap"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// DefaultOctreeMaxDepth is the default number of times the space of an
+	// Octree gets subdivided.
+	DefaultOctreeMaxDepth = 8
+
+	// DefaultOctreeMaxItems is the default number of renderables a node of
+	// an Octree holds before it gets subdivided.
+	DefaultOctreeMaxItems = 8
+)
+
+// Octree is a spatial index of Renderables that speeds up finding the
+// objects in a frustum, under a ray, in a volume or nearest to a point,
+// which is what culling, picking and light assignment need in large
+// scenes.
+//
+// Renderables are indexed by the world space bounding box of their whole
+// hierarchy. After moving a Renderable call Update, or UpdateAll once a
+// frame, so that the index stays correct. Renderables outside of the
+// octree's bounds are still tracked but aren't sped up.
+type Octree struct {
+	// MaxDepth is the number of times the space can be subdivided.
+	MaxDepth int
+
+	// MaxItems is the number of renderables a node holds before it gets
+	// subdivided.
+	MaxItems int
+
+	root  *octreeNode
+	items map[*Renderable]*octreeItem
+}
+
+// octreeNode is a cube of space in the octree. Items that don't fit fully
+// in one of the children stay in the parent.
+type octreeNode struct {
+	min      mgl.Vec3
+	max      mgl.Vec3
+	depth    int
+	items    []*octreeItem
+	children []*octreeNode
+}
+
+// octreeItem is a renderable tracked by the octree.
+type octreeItem struct {
+	renderable *Renderable
+	min        mgl.Vec3
+	max        mgl.Vec3
+	node       *octreeNode
+}
+
+// NewOctree creates a new octree covering the space from min to max.
+func NewOctree(min, max mgl.Vec3) *Octree {
+	o := new(Octree)
+	o.MaxDepth = DefaultOctreeMaxDepth
+	o.MaxItems = DefaultOctreeMaxItems
+	o.root = &octreeNode{min: min, max: max}
+	o.items = make(map[*Renderable]*octreeItem)
+	return o
+}
+
+// Len returns the number of renderables in the octree.
+func (o *Octree) Len() int {
+	return len(o.items)
+}
+
+// Add puts the renderable in the octree. Adding a renderable that is
+// already in the octree updates it instead.
+func (o *Octree) Add(r *Renderable) {
+	if _, found := o.items[r]; found {
+		o.Update(r)
+		return
+	}
+
+	item := &octreeItem{renderable: r}
+	item.min, item.max = GetWorldBounds(r)
+	o.items[r] = item
+	o.insert(o.root, item)
+}
+
+// Remove takes the renderable out of the octree.
+func (o *Octree) Remove(r *Renderable) {
+	item, found := o.items[r]
+	if !found {
+		return
+	}
+	item.node.removeItem(item)
+	delete(o.items, r)
+}
+
+// Update moves the renderable to the right place in the octree after its
+// transform has changed.
+func (o *Octree) Update(r *Renderable) {
+	item, found := o.items[r]
+	if !found {
+		return
+	}
+
+	item.min, item.max = GetWorldBounds(r)
+
+	// a leaf that still holds the whole item can keep it
+	if item.node.children == nil && item.node.containsBox(item.min, item.max) {
+		return
+	}
+	item.node.removeItem(item)
+	o.insert(o.root, item)
+}
+
+// UpdateAll updates every renderable in the octree.
+func (o *Octree) UpdateAll() {
+	for r := range o.items {
+		o.Update(r)
+	}
+}
+
+// QueryFrustum appends the renderables whose bounds are at least partially
+// inside the frustum to results and returns it.
+func (o *Octree) QueryFrustum(f *Frustum, results []*Renderable) []*Renderable {
+	return o.query(o.root, results, func(min, max mgl.Vec3) bool {
+		return f.ContainsAABB(min, max)
+	})
+}
+
+// QueryAABB appends the renderables whose bounds intersect the axis aligned
+// box to results and returns it.
+func (o *Octree) QueryAABB(min, max mgl.Vec3, results []*Renderable) []*Renderable {
+	return o.query(o.root, results, func(bmin, bmax mgl.Vec3) bool {
+		return boxesOverlap(min, max, bmin, bmax)
+	})
+}
+
+// QuerySphere appends the renderables whose bounds intersect the sphere to
+// results and returns it. This finds the objects in the range of a light.
+func (o *Octree) QuerySphere(center mgl.Vec3, radius float32, results []*Renderable) []*Renderable {
+	radiusSq := radius * radius
+	return o.query(o.root, results, func(min, max mgl.Vec3) bool {
+		return distanceSqToBox(center, min, max) <= radiusSq
+	})
+}
+
+// PickRay finds the closest visible renderable hit by a ray in world space,
+// testing the renderables like a Picker does. False is returned if nothing
+// was hit.
+func (o *Octree) PickRay(origin, direction mgl.Vec3) (PickHit, bool) {
+	var closest PickHit
+	closest.Distance = math.MaxFloat32
+	found := false
+	o.pickNode(o.root, origin, direction, &closest, &found)
+	if !found {
+		return PickHit{}, false
+	}
+	closest.Location = origin.Add(direction.Mul(closest.Distance))
+	return closest, true
+}
+
+// Pick finds the closest visible renderable under the screen coordinate like
+// Picker.Pick does.
+func (o *Octree) Pick(c Camera, x, y, width, height float32, projection mgl.Mat4) (PickHit, bool) {
+	origin, direction := ScreenToRay(c, x, y, width, height, projection)
+	return o.PickRay(origin, direction)
+}
+
+// Nearest returns up to count renderables closest to the point, ordered
+// from nearest to farthest, measured to their bounds and no farther away
+// than maxDistance.
+func (o *Octree) Nearest(point mgl.Vec3, count int, maxDistance float32) []*Renderable {
+	var results []*Renderable
+	if count <= 0 {
+		return results
+	}
+
+	// best-first search where nodes and items share a queue by distance;
+	// an item popped from the queue is closer than anything left in it
+	maxDistSq := maxDistance * maxDistance
+	queue := &octreeQueue{}
+	heap.Push(queue, octreeQueueEntry{node: o.root})
+	for queue.Len() > 0 && len(results) < count {
+		entry := heap.Pop(queue).(octreeQueueEntry)
+		if entry.item != nil {
+			results = append(results, entry.item.renderable)
+			continue
+		}
+
+		for _, item := range entry.node.items {
+			if d := distanceSqToBox(point, item.min, item.max); d <= maxDistSq {
+				heap.Push(queue, octreeQueueEntry{distSq: d, item: item})
+			}
+		}
+		for _, child := range entry.node.children {
+			if d := distanceSqToBox(point, child.min, child.max); d <= maxDistSq {
+				heap.Push(queue, octreeQueueEntry{distSq: d, node: child})
+			}
+		}
+	}
+
+	return results
+}
+
+// insert puts the item in the deepest node that fully contains it.
+func (o *Octree) insert(node *octreeNode, item *octreeItem) {
+	for {
+		child := node.getContainingChild(item.min, item.max)
+		if child == nil {
+			break
+		}
+		node = child
+	}
+
+	node.items = append(node.items, item)
+	item.node = node
+
+	if node.children == nil && len(node.items) > o.MaxItems && node.depth < o.MaxDepth {
+		node.split()
+	}
+}
+
+// query walks the nodes whose bounds pass the test and appends the items
+// that pass it.
+func (o *Octree) query(node *octreeNode, results []*Renderable, test func(min, max mgl.Vec3) bool) []*Renderable {
+	for _, item := range node.items {
+		if test(item.min, item.max) {
+			results = append(results, item.renderable)
+		}
+	}
+	for _, child := range node.children {
+		if test(child.min, child.max) {
+			results = o.query(child, results, test)
+		}
+	}
+	return results
+}
+
+// pickNode tests the ray against the items of the node and its children,
+// skipping nodes the ray misses or only hits beyond the closest hit.
+func (o *Octree) pickNode(node *octreeNode, origin, direction mgl.Vec3, closest *PickHit, found *bool) {
+	for _, item := range node.items {
+		if _, hit := rayVsAABB(origin, direction, item.min, item.max, closest.Distance); !hit {
+			continue
+		}
+
+		root := item.renderable
+		if !root.IsVisible || !isRenderableVisible(root) {
+			continue
+		}
+		root.Map(func(r *Renderable) {
+			if !r.IsVisible || r.IsGroup || !isRenderableVisible(r) {
+				return
+			}
+			dist, hit := rayVsRenderable(origin, direction, r)
+			if hit && dist < closest.Distance {
+				*found = true
+				closest.Renderable = r
+				closest.Root = root
+				closest.Distance = dist
+			}
+		})
+	}
+
+	for _, child := range node.children {
+		if _, hit := rayVsAABB(origin, direction, child.min, child.max, closest.Distance); hit {
+			o.pickNode(child, origin, direction, closest, found)
+		}
+	}
+}
+
+// split creates the children of the node and moves the items that fit in
+// them down.
+func (n *octreeNode) split() {
+	center := n.min.Add(n.max).Mul(0.5)
+	n.children = make([]*octreeNode, 8)
+	for i := range n.children {
+		child := &octreeNode{depth: n.depth + 1}
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				child.min[axis], child.max[axis] = center[axis], n.max[axis]
+			} else {
+				child.min[axis], child.max[axis] = n.min[axis], center[axis]
+			}
+		}
+		n.children[i] = child
+	}
+
+	remaining := n.items[:0]
+	for _, item := range n.items {
+		if child := n.getContainingChild(item.min, item.max); child != nil {
+			child.items = append(child.items, item)
+			item.node = child
+		} else {
+			remaining = append(remaining, item)
+		}
+	}
+	for i := len(remaining); i < len(n.items); i++ {
+		n.items[i] = nil
+	}
+	n.items = remaining
+}
+
+// getContainingChild returns the child of the node that fully contains the
+// box or nil if there isn't one.
+func (n *octreeNode) getContainingChild(min, max mgl.Vec3) *octreeNode {
+	for _, child := range n.children {
+		if child.containsBox(min, max) {
+			return child
+		}
+	}
+	return nil
+}
+
+// containsBox returns true if the box is fully inside the node.
+func (n *octreeNode) containsBox(min, max mgl.Vec3) bool {
+	return min[0] >= n.min[0] && max[0] <= n.max[0] &&
+		min[1] >= n.min[1] && max[1] <= n.max[1] &&
+		min[2] >= n.min[2] && max[2] <= n.max[2]
+}
+
+// removeItem takes the item out of the node.
+func (n *octreeNode) removeItem(item *octreeItem) {
+	for i, other := range n.items {
+		if other == item {
+			last := len(n.items) - 1
+			n.items[i] = n.items[last]
+			n.items[last] = nil
+			n.items = n.items[:last]
+			break
+		}
+	}
+	item.node = nil
+}
+
+// octreeQueueEntry is either a node or an item in the Nearest search.
+type octreeQueueEntry struct {
+	distSq float32
+	node   *octreeNode
+	item   *octreeItem
+}
+
+// octreeQueue implements heap.Interface as a min-heap by distance.
+type octreeQueue []octreeQueueEntry
+
+func (q octreeQueue) Len() int {
+	return len(q)
+}
+
+func (q octreeQueue) Less(i, j int) bool {
+	return q[i].distSq < q[j].distSq
+}
+
+func (q octreeQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *octreeQueue) Push(x interface{}) {
+	*q = append(*q, x.(octreeQueueEntry))
+}
+
+func (q *octreeQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// GetWorldBounds returns the world space axis aligned box enclosing the
+// bounding rectangles of the renderable and all of its children.
+func GetWorldBounds(r *Renderable) (mgl.Vec3, mgl.Vec3) {
+	var min, max mgl.Vec3
+	first := true
+	r.Map(func(child *Renderable) {
+		if child.IsGroup {
+			return
+		}
+
+		transform := child.GetTransformMat4()
+		bounds := child.BoundingRect
+		for i := 0; i < 8; i++ {
+			corner := bounds.Bottom
+			if i&1 != 0 {
+				corner[0] = bounds.Top[0]
+			}
+			if i&2 != 0 {
+				corner[1] = bounds.Top[1]
+			}
+			if i&4 != 0 {
+				corner[2] = bounds.Top[2]
+			}
+			p := mgl.TransformCoordinate(corner, transform)
+			if first {
+				min, max = p, p
+				first = false
+				continue
+			}
+			for axis := 0; axis < 3; axis++ {
+				if p[axis] < min[axis] {
+					min[axis] = p[axis]
+				}
+				if p[axis] > max[axis] {
+					max[axis] = p[axis]
+				}
+			}
+		}
+	})
+
+	// a renderable without any geometry is just a point
+	if first {
+		p := mgl.TransformCoordinate(mgl.Vec3{}, r.GetTransformMat4())
+		return p, p
+	}
+	return min, max
+}
+
+// boxesOverlap returns true if the two axis aligned boxes intersect.
+func boxesOverlap(aMin, aMax, bMin, bMax mgl.Vec3) bool {
+	return aMin[0] <= bMax[0] && aMax[0] >= bMin[0] &&
+		aMin[1] <= bMax[1] && aMax[1] >= bMin[1] &&
+		aMin[2] <= bMax[2] && aMax[2] >= bMin[2]
+}
+
+// distanceSqToBox returns the squared distance from the point to the
+// closest point of the box, which is 0 if the point is inside.
+func distanceSqToBox(point, min, max mgl.Vec3) float32 {
+	var distSq float32
+	for axis := 0; axis < 3; axis++ {
+		if point[axis] < min[axis] {
+			d := min[axis] - point[axis]
+			distSq += d * d
+		} else if point[axis] > max[axis] {
+			d := point[axis] - max[axis]
+			distSq += d * d
+		}
+	}
+	return distSq
+}
@@ -0,0 +1,96 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// BlendMode is the way particles of an emitter are blended with what has
+// already been drawn.
+type BlendMode int
+
+const (
+	// BlendAlpha is standard alpha blending.
+	BlendAlpha BlendMode = iota
+
+	// BlendAdditive adds the particle color scaled by its alpha, which
+	// works well for fire and sparks and doesn't need sorting.
+	BlendAdditive
+
+	// BlendPremultiplied is alpha blending for textures that have the
+	// alpha already multiplied into the color.
+	BlendPremultiplied
+
+	// BlendModeCount is the number of blend modes supported.
+	BlendModeCount
+)
+
+// blendModeNames are the user friendly names of the blend modes.
+var blendModeNames = []string{"Alpha", "Additive", "Premultiplied"}
+
+// String returns a user friendly name for the blend mode.
+func (mode BlendMode) String() string {
+	if mode < 0 || mode >= BlendModeCount {
+		return "Unknown"
+	}
+	return blendModeNames[mode]
+}
+
+// beginBlending enables blending with the blend mode of the emitter.
+func (e *Emitter) beginBlending() {
+	gfx := e.Owner.gfx
+	gfx.Enable(graphics.BLEND)
+	switch e.Properties.BlendMode {
+	case BlendAdditive:
+		gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE)
+	case BlendPremultiplied:
+		gfx.BlendFunc(graphics.ONE, graphics.ONE_MINUS_SRC_ALPHA)
+	default:
+		gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+	}
+}
+
+// endBlending puts the blend function back to standard alpha blending.
+func (e *Emitter) endBlending() {
+	e.Owner.gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+}
+
+// particlesByDistance sorts particles from the farthest to the closest
+// to a location.
+type particlesByDistance struct {
+	particles []Particle
+	distances []float32
+}
+
+func (s *particlesByDistance) Len() int {
+	return len(s.particles)
+}
+
+func (s *particlesByDistance) Less(i, j int) bool {
+	return s.distances[i] > s.distances[j]
+}
+
+func (s *particlesByDistance) Swap(i, j int) {
+	s.particles[i], s.particles[j] = s.particles[j], s.particles[i]
+	s.distances[i], s.distances[j] = s.distances[j], s.distances[i]
+}
+
+// sortParticles orders the particles back to front as seen from the camera
+// so that alpha blended particles layer correctly.
+func (e *Emitter) sortParticles(view mgl.Mat4) {
+	// particle locations may be relative to the emitter, so move the camera there too
+	cameraLocation := view.Inv().Col(3).Vec3().Sub(e.getParticleOrigin())
+
+	distances := e.sortDistances[:0]
+	for i := range e.Particles {
+		distances = append(distances, e.Particles[i].Location.Sub(cameraLocation).LenSqr())
+	}
+	e.sortDistances = distances
+
+	sort.Sort(&particlesByDistance{e.Particles, distances})
+}
@@ -0,0 +1,196 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// CollisionMode is the way particles respond to hitting the ground plane
+// or one of the colliders of an emitter.
+type CollisionMode int
+
+const (
+	// CollisionNone disables particle collision.
+	CollisionNone CollisionMode = iota
+
+	// CollisionBounce reflects the particle off of the surface, scaling the
+	// speed into the surface by EmitterProperties.Restitution.
+	CollisionBounce
+
+	// CollisionKill removes the particle when it hits a surface.
+	CollisionKill
+
+	// CollisionStick stops the particle on the surface it hits.
+	CollisionStick
+
+	// CollisionModeCount is the number of collision modes supported.
+	CollisionModeCount
+)
+
+// collisionModeNames are the user friendly names of the collision modes.
+var collisionModeNames = []string{"None", "Bounce", "Kill", "Stick"}
+
+// String returns a user friendly name for the collision mode.
+func (mode CollisionMode) String() string {
+	if mode < 0 || mode >= CollisionModeCount {
+		return "Unknown"
+	}
+	return collisionModeNames[mode]
+}
+
+// ParticleCollider is a type of interface for objects that particles
+// can collide with.
+type ParticleCollider interface {
+	// Collide tests a location in world space against the collider. If the
+	// location is inside, it returns true along with the closest point on
+	// the surface of the collider and the surface normal at that point.
+	Collide(location mgl.Vec3) (bool, mgl.Vec3, mgl.Vec3)
+}
+
+// SphereCollider is a sphere in world space that particles can collide with.
+type SphereCollider struct {
+	Center mgl.Vec3
+	Radius float32
+}
+
+// NewSphereCollider creates a new sphere collider for particles.
+func NewSphereCollider(center mgl.Vec3, radius float32) *SphereCollider {
+	sphere := new(SphereCollider)
+	sphere.Center = center
+	sphere.Radius = radius
+	return sphere
+}
+
+// Collide tests a location in world space against the sphere.
+func (sphere *SphereCollider) Collide(location mgl.Vec3) (bool, mgl.Vec3, mgl.Vec3) {
+	offset := location.Sub(sphere.Center)
+	dist := offset.Len()
+	if dist >= sphere.Radius {
+		return false, location, mgl.Vec3{}
+	}
+
+	// pick an arbitrary normal for a particle right at the center
+	normal := mgl.Vec3{0, 1, 0}
+	if dist > 0.0 {
+		normal = offset.Mul(1.0 / dist)
+	}
+	return true, sphere.Center.Add(normal.Mul(sphere.Radius)), normal
+}
+
+// BoxCollider is an axis aligned box in world space that particles can
+// collide with.
+type BoxCollider struct {
+	Min mgl.Vec3
+	Max mgl.Vec3
+}
+
+// NewBoxCollider creates a new axis aligned box collider for particles.
+func NewBoxCollider(min, max mgl.Vec3) *BoxCollider {
+	box := new(BoxCollider)
+	box.Min = min
+	box.Max = max
+	return box
+}
+
+// Collide tests a location in world space against the box. Particles inside
+// the box are pushed out through the closest face.
+func (box *BoxCollider) Collide(location mgl.Vec3) (bool, mgl.Vec3, mgl.Vec3) {
+	for i := 0; i < 3; i++ {
+		if location[i] <= box.Min[i] || location[i] >= box.Max[i] {
+			return false, location, mgl.Vec3{}
+		}
+	}
+
+	// find the face closest to the location
+	surface := location
+	var normal mgl.Vec3
+	closest := float32(-1.0)
+	for i := 0; i < 3; i++ {
+		toMin := location[i] - box.Min[i]
+		if closest < 0.0 || toMin < closest {
+			closest = toMin
+			surface = location
+			surface[i] = box.Min[i]
+			normal = mgl.Vec3{}
+			normal[i] = -1.0
+		}
+
+		toMax := box.Max[i] - location[i]
+		if toMax < closest {
+			closest = toMax
+			surface = location
+			surface[i] = box.Max[i]
+			normal = mgl.Vec3{}
+			normal[i] = 1.0
+		}
+	}
+
+	return true, surface, normal
+}
+
+// collideParticles tests all of the particles against the ground plane and
+// the colliders of the emitter, applying the collision response and
+// removing the particles that were killed.
+func (e *Emitter) collideParticles() {
+	props := &e.Properties
+	if props.CollisionMode == CollisionNone || (!props.GroundCollision && len(e.Colliders) == 0) {
+		return
+	}
+
+	// particle locations are relative to the emitter in local space
+	particleOrigin := e.getParticleOrigin()
+
+	stillAlive := e.Particles[:0]
+	for _, particle := range e.Particles {
+		location := particleOrigin.Add(particle.Location)
+		hit, surface, normal := e.collide(location)
+		if hit {
+			if e.OnCollision != nil {
+				e.OnCollision(e, surface, normal)
+			}
+			if props.CollisionMode == CollisionKill {
+				continue
+			}
+
+			particle.Location = surface.Sub(particleOrigin)
+			switch props.CollisionMode {
+			case CollisionStick:
+				particle.Speed = 0.0
+			case CollisionBounce:
+				// reflect the part of the velocity going into the surface
+				v := particle.Velocity.Mul(particle.Speed)
+				vn := v.Dot(normal)
+				if vn < 0.0 {
+					v = v.Sub(normal.Mul((1.0 + props.Restitution) * vn))
+				}
+				particle.Speed = v.Len()
+				if particle.Speed > 0.0 {
+					particle.Velocity = v.Mul(1.0 / particle.Speed)
+				}
+			}
+		}
+		stillAlive = append(stillAlive, particle)
+	}
+	e.Particles = stillAlive
+}
+
+// collide tests a location in world space against the ground plane and then
+// the colliders of the emitter, returning the first collision found.
+func (e *Emitter) collide(location mgl.Vec3) (bool, mgl.Vec3, mgl.Vec3) {
+	if e.Properties.GroundCollision && location[1] < e.Properties.GroundHeight {
+		surface := location
+		surface[1] = e.Properties.GroundHeight
+		return true, surface, mgl.Vec3{0, 1, 0}
+	}
+
+	for _, collider := range e.Colliders {
+		hit, surface, normal := collider.Collide(location)
+		if hit {
+			return true, surface, normal
+		}
+	}
+
+	return false, location, mgl.Vec3{}
+}
@@ -17,7 +17,7 @@ type ConeSpawner struct {
 	BottomRadius float32
 	TopRadius    float32
 	Length       float32
-	Owner        *Emitter
+	Owner        *Emitter `json:"-"`
 
 	volumeRenderable *fizzle.Renderable
 }
@@ -96,6 +96,14 @@ func (cone *ConeSpawner) CreateRenderable() *fizzle.Renderable {
 	return cone.volumeRenderable
 }
 
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (cone *ConeSpawner) DestroyRenderable() {
+	if cone.volumeRenderable != nil {
+		cone.volumeRenderable.Destroy()
+		cone.volumeRenderable = nil
+	}
+}
+
 // DrawSpawnVolume renders a visual representation of the particle spawning volume.
 func (cone *ConeSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
 	if cone.volumeRenderable == nil {
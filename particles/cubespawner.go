@@ -14,7 +14,7 @@ import (
 type CubeSpawner struct {
 	BottomLeft mgl.Vec3
 	TopRight   mgl.Vec3
-	Owner      *Emitter
+	Owner      *Emitter `json:"-"`
 
 	volumeRenderable *fizzle.Renderable
 }
@@ -81,6 +81,14 @@ func (cube *CubeSpawner) CreateRenderable() *fizzle.Renderable {
 	return cube.volumeRenderable
 }
 
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (cube *CubeSpawner) DestroyRenderable() {
+	if cube.volumeRenderable != nil {
+		cube.volumeRenderable.Destroy()
+		cube.volumeRenderable = nil
+	}
+}
+
 // DrawSpawnVolume renders a visual representation of the particle spawning volume.
 func (cube *CubeSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
 	if cube.volumeRenderable == nil {
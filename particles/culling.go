@@ -0,0 +1,66 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+)
+
+// GetBounds returns the minimum and maximum corners of an axis aligned box
+// in world space containing the emitter location and all of its particles.
+// For emitters simulated on the GPU the particles are not available, so the
+// box is estimated from how far a particle can travel in its lifetime.
+func (e *Emitter) GetBounds() (mgl.Vec3, mgl.Vec3) {
+	location := e.GetLocation()
+	if e.gpu != nil {
+		reach := e.Properties.Speed * float32(e.Properties.TTL)
+		extent := mgl.Vec3{reach, reach, reach}
+		return location.Sub(extent), location.Add(extent)
+	}
+
+	min, max := location, location
+	particleOrigin := e.getParticleOrigin()
+	for i := range e.Particles {
+		p := particleOrigin.Add(e.Particles[i].Location)
+		for j := 0; j < 3; j++ {
+			if p[j] < min[j] {
+				min[j] = p[j]
+			}
+			if p[j] > max[j] {
+				max[j] = p[j]
+			}
+		}
+	}
+
+	return min, max
+}
+
+// IsCulled returns true if the emitter was outside of the view frustum the
+// last time it was drawn and FrustumCulling is enabled.
+func (e *Emitter) IsCulled() bool {
+	return e.isCulled
+}
+
+// updateLOD checks the emitter against the camera used to draw it to set
+// the culling state and the spawn rate scale used by the next update.
+func (e *Emitter) updateLOD(projection mgl.Mat4, view mgl.Mat4) {
+	props := &e.Properties
+	min, max := e.GetBounds()
+
+	e.isCulled = false
+	if props.FrustumCulling {
+		f := fizzle.NewFrustum(projection, view)
+		e.isCulled = !f.ContainsAABB(min, max)
+	}
+
+	// scale the spawn rate down linearly between the LOD distances
+	e.lodScale = 1.0
+	if props.LODFarDistance > props.LODNearDistance {
+		cameraLocation := view.Inv().Col(3).Vec3()
+		dist := cameraLocation.Sub(e.GetLocation()).Len()
+		t := mgl.Clamp((dist-props.LODNearDistance)/(props.LODFarDistance-props.LODNearDistance), 0.0, 1.0)
+		e.lodScale = 1.0 + (props.LODMinSpawnScale-1.0)*t
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	renderer "github.com/tbogdala/fizzle/renderer"
+)
+
+// DiscSpawner is a particle spawner that creates particles on a flat disc
+// in the XZ plane. If InnerRadius is greater than zero, particles are only
+// spawned in the ring between InnerRadius and OuterRadius.
+type DiscSpawner struct {
+	InnerRadius float32
+	OuterRadius float32
+	Owner       *Emitter `json:"-"`
+
+	volumeRenderable *fizzle.Renderable
+}
+
+// NewDiscSpawner creates a new disc or ring shaped particle spawner.
+func NewDiscSpawner(owner *Emitter, innerRadius, outerRadius float32) *DiscSpawner {
+	disc := new(DiscSpawner)
+	disc.InnerRadius = innerRadius
+	disc.OuterRadius = outerRadius
+	disc.Owner = owner
+	return disc
+}
+
+// GetName returns a user friendly name for the spawner
+func (disc *DiscSpawner) GetName() string {
+	return "Disc Spawner"
+}
+
+// SetOwner sets the owning emitter for the spawner
+func (disc *DiscSpawner) SetOwner(e *Emitter) {
+	disc.Owner = e
+}
+
+// GetLocation returns the location in world space for the spawner.
+func (disc *DiscSpawner) GetLocation() mgl.Vec3 {
+	return disc.Owner.GetLocation()
+}
+
+// NewParticle creates a new particle that fits within the area of the disc.
+func (disc *DiscSpawner) NewParticle() (p Particle) {
+	// get the standard properties from the emitter itself
+	p.StartTime = disc.Owner.Owner.runtime
+	p.Size = disc.Owner.Properties.Size
+	p.Speed = disc.Owner.Properties.Speed
+	p.Color = disc.Owner.Properties.Color
+	p.Acceleration = disc.Owner.Properties.Acceleration
+	p.EndTime = disc.Owner.Properties.TTL + p.StartTime
+
+	// interpolating the squared radius keeps the points evenly spread over the area
+	rng := disc.Owner.rng
+	innerSq := float64(disc.InnerRadius * disc.InnerRadius)
+	outerSq := float64(disc.OuterRadius * disc.OuterRadius)
+	radius := float32(math.Sqrt(innerSq + rng.Float64()*(outerSq-innerSq)))
+	angle := rng.Float64() * math.Pi * 2.0
+
+	p.Location[0] = radius * float32(math.Cos(angle))
+	p.Location[2] = radius * float32(math.Sin(angle))
+	p.Location = disc.Owner.Properties.Rotation.Rotate(p.Location)
+
+	p.Velocity = disc.Owner.Properties.Velocity.Normalize()
+	p.Velocity = disc.Owner.Properties.Rotation.Rotate(p.Velocity)
+
+	return p
+}
+
+// CreateRenderable creates a cached renderable for the spawner that represents
+// the spawning volume for particles.
+func (disc *DiscSpawner) CreateRenderable() *fizzle.Renderable {
+	const circleSegments = 16
+
+	disc.volumeRenderable = fizzle.CreateWireframeRingXZ(0, 0, 0, disc.InnerRadius, disc.OuterRadius, circleSegments)
+	return disc.volumeRenderable
+}
+
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (disc *DiscSpawner) DestroyRenderable() {
+	if disc.volumeRenderable != nil {
+		disc.volumeRenderable.Destroy()
+		disc.volumeRenderable = nil
+	}
+}
+
+// DrawSpawnVolume renders a visual representation of the particle spawning volume.
+func (disc *DiscSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	if disc.volumeRenderable == nil {
+		disc.CreateRenderable()
+	}
+
+	// sync the position and rotation
+	disc.volumeRenderable.Location = disc.Owner.Properties.Origin
+	disc.volumeRenderable.LocalRotation = disc.Owner.Properties.Rotation
+
+	r.DrawLines(disc.volumeRenderable, shader, nil, projection, view, camera)
+}
@@ -0,0 +1,202 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// ForceField is a type of interface for objects that push particles around.
+// Force fields can be added to a System to affect all of its emitters or to
+// a single Emitter.
+type ForceField interface {
+	// GetForce returns the acceleration applied to a particle at the
+	// location in world space at the runtime of the particle system.
+	GetForce(location mgl.Vec3, runtime float64) mgl.Vec3
+}
+
+// WindField is a force field that pushes particles in a direction with
+// some optional turbulence added on top.
+type WindField struct {
+	// Force is the direction and strength of the wind.
+	Force mgl.Vec3
+
+	// Turbulence is the strength of the noise added to the wind and
+	// Frequency is how quickly the noise changes over space and time.
+	Turbulence float32
+	Frequency  float32
+}
+
+// NewWindField creates a new wind force field.
+func NewWindField(force mgl.Vec3, turbulence, frequency float32) *WindField {
+	wind := new(WindField)
+	wind.Force = force
+	wind.Turbulence = turbulence
+	wind.Frequency = frequency
+	return wind
+}
+
+// GetForce returns the wind force at the location.
+func (wind *WindField) GetForce(location mgl.Vec3, runtime float64) mgl.Vec3 {
+	if wind.Turbulence == 0.0 {
+		return wind.Force
+	}
+
+	// offset the noise for each axis so they don't move in lock step
+	p := location.Mul(wind.Frequency)
+	t := float32(runtime) * wind.Frequency
+	noise := mgl.Vec3{
+		valueNoise(p[0]+t, p[1], p[2]),
+		valueNoise(p[0]+31.7, p[1]+t, p[2]+17.3),
+		valueNoise(p[0]+53.1, p[1]+11.9, p[2]+t),
+	}
+	return wind.Force.Add(noise.Mul(wind.Turbulence))
+}
+
+// AttractorField is a force field that pulls particles towards a point, or
+// pushes them away if Strength is negative.
+type AttractorField struct {
+	Location mgl.Vec3
+	Strength float32
+
+	// Radius is the distance the force fades out to nothing over. A value
+	// of 0 gives the same force at any distance.
+	Radius float32
+}
+
+// NewAttractorField creates a new point attractor force field. Use a
+// negative strength to create a repulsor.
+func NewAttractorField(location mgl.Vec3, strength, radius float32) *AttractorField {
+	attractor := new(AttractorField)
+	attractor.Location = location
+	attractor.Strength = strength
+	attractor.Radius = radius
+	return attractor
+}
+
+// GetForce returns the attraction force at the location.
+func (attractor *AttractorField) GetForce(location mgl.Vec3, runtime float64) mgl.Vec3 {
+	toPoint := attractor.Location.Sub(location)
+	dist := toPoint.Len()
+	if dist == 0.0 {
+		return mgl.Vec3{}
+	}
+
+	strength := attractor.Strength
+	if attractor.Radius > 0.0 {
+		if dist >= attractor.Radius {
+			return mgl.Vec3{}
+		}
+		strength *= 1.0 - dist/attractor.Radius
+	}
+
+	return toPoint.Mul(strength / dist)
+}
+
+// VortexField is a force field that swirls particles around an axis.
+type VortexField struct {
+	Location mgl.Vec3
+	Axis     mgl.Vec3 // should be normalized
+	Strength float32
+
+	// Radius is the distance from the axis the force fades out to nothing
+	// over. A value of 0 gives the same force at any distance.
+	Radius float32
+}
+
+// NewVortexField creates a new vortex force field that spins particles
+// counter-clockwise around the axis for a positive strength.
+func NewVortexField(location, axis mgl.Vec3, strength, radius float32) *VortexField {
+	vortex := new(VortexField)
+	vortex.Location = location
+	vortex.Axis = axis
+	vortex.Strength = strength
+	vortex.Radius = radius
+	return vortex
+}
+
+// GetForce returns the swirling force at the location.
+func (vortex *VortexField) GetForce(location mgl.Vec3, runtime float64) mgl.Vec3 {
+	// get the offset from the closest point on the axis
+	offset := location.Sub(vortex.Location)
+	offset = offset.Sub(vortex.Axis.Mul(offset.Dot(vortex.Axis)))
+	dist := offset.Len()
+	if dist == 0.0 {
+		return mgl.Vec3{}
+	}
+
+	strength := vortex.Strength
+	if vortex.Radius > 0.0 {
+		if dist >= vortex.Radius {
+			return mgl.Vec3{}
+		}
+		strength *= 1.0 - dist/vortex.Radius
+	}
+
+	return vortex.Axis.Cross(offset).Mul(strength / dist)
+}
+
+// applyForceFields accelerates all of the particles with the force fields
+// of the emitter and the owning system.
+func (e *Emitter) applyForceFields(frameDelta float64) {
+	if len(e.ForceFields) == 0 && len(e.Owner.ForceFields) == 0 {
+		return
+	}
+
+	// particle locations are relative to the emitter in local space
+	particleOrigin := e.getParticleOrigin()
+	runtime := e.Owner.runtime
+
+	for i := range e.Particles {
+		p := &e.Particles[i]
+		location := particleOrigin.Add(p.Location)
+
+		var force mgl.Vec3
+		for _, field := range e.Owner.ForceFields {
+			force = force.Add(field.GetForce(location, runtime))
+		}
+		for _, field := range e.ForceFields {
+			force = force.Add(field.GetForce(location, runtime))
+		}
+
+		v := p.Velocity.Mul(p.Speed).Add(force.Mul(float32(frameDelta)))
+		p.Speed = v.Len()
+		if p.Speed > 0.0 {
+			p.Velocity = v.Mul(1.0 / p.Speed)
+		}
+	}
+}
+
+// valueNoise returns smoothly interpolated 3d value noise in the range of
+// [-1, 1] for the coordinates.
+func valueNoise(x, y, z float32) float32 {
+	fx, fy, fz := float32(math.Floor(float64(x))), float32(math.Floor(float64(y))), float32(math.Floor(float64(z)))
+	ix, iy, iz := int32(fx), int32(fy), int32(fz)
+
+	// smoothstep the fractional parts for the interpolation
+	tx, ty, tz := smoothStep(x-fx), smoothStep(y-fy), smoothStep(z-fz)
+
+	lerp := func(a, b, t float32) float32 { return a + (b-a)*t }
+	x00 := lerp(latticeValue(ix, iy, iz), latticeValue(ix+1, iy, iz), tx)
+	x10 := lerp(latticeValue(ix, iy+1, iz), latticeValue(ix+1, iy+1, iz), tx)
+	x01 := lerp(latticeValue(ix, iy, iz+1), latticeValue(ix+1, iy, iz+1), tx)
+	x11 := lerp(latticeValue(ix, iy+1, iz+1), latticeValue(ix+1, iy+1, iz+1), tx)
+	return lerp(lerp(x00, x10, ty), lerp(x01, x11, ty), tz)
+}
+
+// smoothStep eases t in the range of [0, 1].
+func smoothStep(t float32) float32 {
+	return t * t * (3.0 - 2.0*t)
+}
+
+// latticeValue hashes the integer lattice point to a value in [-1, 1].
+func latticeValue(x, y, z int32) float32 {
+	h := uint32(x)*73856093 ^ uint32(y)*19349663 ^ uint32(z)*83492791
+	h ^= h >> 13
+	h *= 0x5bd1e995
+	h ^= h >> 15
+	return float32(h&0xffff)/32767.5 - 1.0
+}
@@ -0,0 +1,333 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+var (
+	// GPUVertShader330 is the GLSL vertex shader program for drawing the particles
+	// of an emitter simulated on the GPU. It should be paired with FragShader330.
+	GPUVertShader330 = `#version 330
+  uniform mat4 MVP;
+  uniform float TIME;
+  uniform float ATLAS_FPS;
+  uniform vec2 ATLAS_SIZE;
+  in vec3 POSITION;
+  in vec4 COLOR;
+  in float SIZE;
+  in float END_TIME;
+  in float START_TIME;
+  in float START_FRAME;
+
+  out vec4 vs_color;
+  out float vs_frame;
+
+  void main()
+  {
+    vs_color = COLOR;
+
+    vec2 atlasSize = max(ATLAS_SIZE, vec2(1.0));
+    vs_frame = mod(START_FRAME + floor((TIME - START_TIME) * ATLAS_FPS), atlasSize.x * atlasSize.y);
+
+    if (TIME > END_TIME) {
+      // dead particles get moved outside of clip space so they get culled
+      gl_PointSize = 0.0;
+      gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+    } else {
+      gl_PointSize = SIZE;
+      gl_Position = MVP * vec4(POSITION, 1.0);
+    }
+  }`
+
+	// gpuUpdateVertShader330 advances every particle by one frame, accelerating
+	// it by the emitter's acceleration, and the outputs are captured with
+	// transform feedback.
+	gpuUpdateVertShader330 = `#version 330
+  uniform float DELTA_TIME;
+  uniform vec3 ACCELERATION;
+  in vec3 POSITION;
+  in vec3 VELOCITY;
+  in vec4 COLOR;
+  in float SIZE;
+  in float END_TIME;
+  in float START_TIME;
+  in float START_FRAME;
+
+  out vec3 tf_position;
+  out vec3 tf_velocity;
+  out vec4 tf_color;
+  out float tf_size;
+  out float tf_end_time;
+  out float tf_start_time;
+  out float tf_start_frame;
+
+  void main()
+  {
+    tf_velocity = VELOCITY + ACCELERATION * DELTA_TIME;
+    tf_position = POSITION + tf_velocity * DELTA_TIME;
+    tf_color = COLOR;
+    tf_size = SIZE;
+    tf_end_time = END_TIME;
+    tf_start_time = START_TIME;
+    tf_start_frame = START_FRAME;
+  }`
+
+	// gpuUpdateFragShader330 is never run because rasterization is disabled
+	// for the update pass, but a program needs a fragment shader to link.
+	gpuUpdateFragShader330 = `#version 330
+  out vec4 frag_color;
+
+  void main()
+  {
+    frag_color = vec4(1.0);
+  }`
+
+	// gpuUpdateVaryings are the outputs of the update shader in the order
+	// they are interleaved in the particle buffers.
+	gpuUpdateVaryings = []string{"tf_position", "tf_velocity", "tf_color", "tf_size", "tf_end_time", "tf_start_time", "tf_start_frame"}
+)
+
+const (
+	// gpuParticleFloats is the number of floats stored per particle in the
+	// GPU buffers: position (3), velocity (3), color (4), size (1), end time (1),
+	// start time (1), start frame (1).
+	gpuParticleFloats = 3 + 3 + 4 + 1 + 1 + 1 + 1
+	gpuStride         = floatSize * gpuParticleFloats
+
+	gpuPositionOffset   = 0
+	gpuVelocityOffset   = floatSize * 3
+	gpuColorOffset      = floatSize * 6
+	gpuSizeOffset       = floatSize * 10
+	gpuEndTimeOffset    = floatSize * 11
+	gpuStartTimeOffset  = floatSize * 12
+	gpuStartFrameOffset = floatSize * 13
+)
+
+// gpuSimulation holds the state for an Emitter whose particles are stored
+// in GPU buffers and updated with a transform feedback shader pass so that
+// only newly spawned particles are sent from the CPU each frame.
+type gpuSimulation struct {
+	updateShader *fizzle.RenderShader
+
+	// buffers are ping-ponged each frame: one is read by the update pass
+	// while the other captures its output.
+	buffers [2]graphics.Buffer
+
+	// current is the index into buffers of the most recent particle state.
+	current int
+
+	// capacity is the number of particle slots allocated in each buffer.
+	capacity int
+
+	// nextSlot is the index of the slot the next spawned particle is written
+	// to. Slots are reused in a ring so the oldest particles get replaced.
+	nextSlot int
+
+	spawnBuffer []float32
+}
+
+// NewGPUEmitter creates a new particle emitter that simulates its particles
+// on the GPU with transform feedback. It behaves like an emitter made with
+// NewEmitter and works with the same spawners and properties, but supports
+// far higher particle counts. The Shader for the emitter should be created
+// with GPUVertShader330 and FragShader330.
+//
+// This requires a graphics provider with transform feedback support,
+// such as OpenGL 3.3 or OpenGL ES 3.
+func (s *System) NewGPUEmitter(optProps *EmitterProperties) (*Emitter, error) {
+	updateShader, err := fizzle.LoadShaderProgram(gpuUpdateVertShader330, gpuUpdateFragShader330, func(p graphics.Program) {
+		s.gfx.TransformFeedbackVaryings(p, gpuUpdateVaryings, graphics.INTERLEAVED_ATTRIBS)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e := s.NewEmitter(optProps)
+	e.gpu = new(gpuSimulation)
+	e.gpu.updateShader = updateShader
+	e.gpu.buffers[0] = s.gfx.GenBuffer()
+	e.gpu.buffers[1] = s.gfx.GenBuffer()
+
+	return e, nil
+}
+
+// IsGPUSimulated returns true if the emitter was created with NewGPUEmitter.
+func (e *Emitter) IsGPUSimulated() bool {
+	return e.gpu != nil
+}
+
+// destroy releases the graphics objects for the GPU simulation.
+func (sim *gpuSimulation) destroy(gfx graphics.GraphicsProvider) {
+	sim.updateShader.Destroy()
+	gfx.DeleteBuffer(sim.buffers[0])
+	gfx.DeleteBuffer(sim.buffers[1])
+}
+
+// resize reallocates the particle buffers to hold capacity particles. All
+// existing particles are lost, and the zeroed slots count as dead particles.
+func (sim *gpuSimulation) resize(gfx graphics.GraphicsProvider, capacity int) {
+	sim.capacity = capacity
+	sim.current = 0
+	sim.nextSlot = 0
+	if capacity <= 0 {
+		return
+	}
+
+	emptyData := make([]float32, capacity*gpuParticleFloats)
+	for i := range sim.buffers {
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, sim.buffers[i])
+		gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(emptyData), gfx.Ptr(&emptyData[0]), graphics.DYNAMIC_COPY)
+	}
+}
+
+// updateGPU spawns new particles into the GPU buffers and then runs the
+// transform feedback pass to advance all of the particles.
+func (e *Emitter) updateGPU(frameDelta float64, spawnCount int) {
+	gfx := e.Owner.gfx
+	sim := e.gpu
+
+	if sim.capacity != int(e.Properties.MaxParticles) {
+		sim.resize(gfx, int(e.Properties.MaxParticles))
+	}
+	if sim.capacity <= 0 {
+		return
+	}
+
+	// write the new particles into the ring of slots
+	if e.Owner.IsEmitting && spawnCount > 0 {
+		if spawnCount > sim.capacity {
+			spawnCount = sim.capacity
+		}
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, sim.buffers[sim.current])
+		for spawnCount > 0 {
+			// spawn up to the end of the buffer in one upload before wrapping around
+			batchCount := spawnCount
+			if sim.nextSlot+batchCount > sim.capacity {
+				batchCount = sim.capacity - sim.nextSlot
+			}
+
+			buffer := sim.spawnBuffer[:0]
+			for i := 0; i < batchCount; i++ {
+				p := e.newParticle()
+				v := p.Velocity.Mul(p.Speed)
+				buffer = append(buffer, p.Location[0], p.Location[1], p.Location[2])
+				buffer = append(buffer, v[0], v[1], v[2])
+				buffer = append(buffer, p.Color[0], p.Color[1], p.Color[2], p.Color[3])
+				buffer = append(buffer, p.Size, float32(p.EndTime))
+				buffer = append(buffer, float32(p.StartTime), float32(p.StartFrame))
+			}
+			sim.spawnBuffer = buffer
+
+			gfx.BufferSubData(graphics.ARRAY_BUFFER, sim.nextSlot*gpuStride, floatSize*len(buffer), gfx.Ptr(&buffer[0]))
+			sim.nextSlot = (sim.nextSlot + batchCount) % sim.capacity
+			spawnCount -= batchCount
+		}
+	}
+
+	// run the update shader over every slot, capturing into the other buffer
+	shader := sim.updateShader
+	gfx.UseProgram(shader.Prog)
+	gfx.BindVertexArray(e.vao)
+
+	deltaTime := shader.GetUniformLocation("DELTA_TIME")
+	if deltaTime >= 0 {
+		gfx.Uniform1f(deltaTime, float32(frameDelta))
+	}
+
+	acceleration := shader.GetUniformLocation("ACCELERATION")
+	if acceleration >= 0 {
+		a := e.Properties.Acceleration
+		gfx.Uniform3f(acceleration, a[0], a[1], a[2])
+	}
+
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, sim.buffers[sim.current])
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("POSITION"), 3, gpuPositionOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("VELOCITY"), 3, gpuVelocityOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("COLOR"), 4, gpuColorOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("SIZE"), 1, gpuSizeOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("END_TIME"), 1, gpuEndTimeOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("START_TIME"), 1, gpuStartTimeOffset)
+	bindGPUParticleAttrib(gfx, shader.GetAttribLocation("START_FRAME"), 1, gpuStartFrameOffset)
+
+	next := 1 - sim.current
+	gfx.Enable(graphics.RASTERIZER_DISCARD)
+	gfx.BindBufferBase(graphics.TRANSFORM_FEEDBACK_BUFFER, 0, sim.buffers[next])
+	gfx.BeginTransformFeedback(graphics.POINTS)
+	gfx.DrawArrays(graphics.POINTS, 0, int32(sim.capacity))
+	gfx.EndTransformFeedback()
+	gfx.BindBufferBase(graphics.TRANSFORM_FEEDBACK_BUFFER, 0, 0)
+	gfx.Disable(graphics.RASTERIZER_DISCARD)
+
+	gfx.BindVertexArray(0)
+	sim.current = next
+}
+
+// drawGPU renders every particle slot of the emitter, leaving it to the
+// shader to skip the dead particles.
+func (e *Emitter) drawGPU(mvp mgl.Mat4) {
+	sim := e.gpu
+	if sim.capacity <= 0 {
+		return
+	}
+
+	gfx := e.Owner.gfx
+	gfx.BindVertexArray(e.vao)
+	gfx.UseProgram(e.Shader)
+
+	mvpMatrix := gfx.GetUniformLocation(e.Shader, "MVP")
+	if mvpMatrix >= 0 {
+		gfx.UniformMatrix4fv(mvpMatrix, 1, false, mvp)
+	}
+
+	shaderTime := gfx.GetUniformLocation(e.Shader, "TIME")
+	if shaderTime >= 0 {
+		gfx.Uniform1f(shaderTime, float32(e.Owner.runtime))
+	}
+
+	shaderAtlasFPS := gfx.GetUniformLocation(e.Shader, "ATLAS_FPS")
+	if shaderAtlasFPS >= 0 {
+		gfx.Uniform1f(shaderAtlasFPS, e.Properties.AtlasFPS)
+	}
+
+	shaderAtlasSize := gfx.GetUniformLocation(e.Shader, "ATLAS_SIZE")
+	if shaderAtlasSize >= 0 {
+		cols, rows := e.Properties.getAtlasSize()
+		gfx.Uniform2f(shaderAtlasSize, cols, rows)
+	}
+
+	e.bindExposure(e.Shader)
+
+	shaderTex0 := gfx.GetUniformLocation(e.Shader, "TEX")
+	if shaderTex0 >= 0 {
+		gfx.ActiveTexture(graphics.TEXTURE0)
+		gfx.BindTexture(graphics.TEXTURE_2D, e.Texture)
+		gfx.Uniform1i(shaderTex0, 0)
+	}
+
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, sim.buffers[sim.current])
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "POSITION"), 3, gpuPositionOffset)
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "COLOR"), 4, gpuColorOffset)
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "SIZE"), 1, gpuSizeOffset)
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "END_TIME"), 1, gpuEndTimeOffset)
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "START_TIME"), 1, gpuStartTimeOffset)
+	bindGPUParticleAttrib(gfx, gfx.GetAttribLocation(e.Shader, "START_FRAME"), 1, gpuStartFrameOffset)
+
+	gfx.DrawArrays(graphics.POINTS, 0, int32(sim.capacity))
+
+	gfx.BindVertexArray(0)
+}
+
+// bindGPUParticleAttrib enables a float vertex attribute from the interleaved
+// particle data in the bound buffer if the shader uses it.
+func bindGPUParticleAttrib(gfx graphics.GraphicsProvider, location int32, size int32, offset int) {
+	if location < 0 {
+		return
+	}
+	gfx.EnableVertexAttribArray(uint32(location))
+	gfx.VertexAttribPointer(uint32(location), size, graphics.FLOAT, false, gpuStride, gfx.PtrOffset(offset))
+}
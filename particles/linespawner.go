@@ -0,0 +1,92 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	renderer "github.com/tbogdala/fizzle/renderer"
+)
+
+// LineSpawner is a particle spawner that creates particles along the
+// line segment between Start and End.
+type LineSpawner struct {
+	Start mgl.Vec3
+	End   mgl.Vec3
+	Owner *Emitter `json:"-"`
+
+	volumeRenderable *fizzle.Renderable
+}
+
+// NewLineSpawner creates a new line segment particle spawner.
+func NewLineSpawner(owner *Emitter, start, end mgl.Vec3) *LineSpawner {
+	line := new(LineSpawner)
+	line.Start = start
+	line.End = end
+	line.Owner = owner
+	return line
+}
+
+// GetName returns a user friendly name for the spawner
+func (line *LineSpawner) GetName() string {
+	return "Line Spawner"
+}
+
+// SetOwner sets the owning emitter for the spawner
+func (line *LineSpawner) SetOwner(e *Emitter) {
+	line.Owner = e
+}
+
+// GetLocation returns the location in world space for the spawner.
+func (line *LineSpawner) GetLocation() mgl.Vec3 {
+	return line.Owner.GetLocation()
+}
+
+// NewParticle creates a new particle at a random point on the line segment.
+func (line *LineSpawner) NewParticle() (p Particle) {
+	// get the standard properties from the emitter itself
+	p.StartTime = line.Owner.Owner.runtime
+	p.Size = line.Owner.Properties.Size
+	p.Speed = line.Owner.Properties.Speed
+	p.Color = line.Owner.Properties.Color
+	p.Acceleration = line.Owner.Properties.Acceleration
+	p.EndTime = line.Owner.Properties.TTL + p.StartTime
+
+	t := line.Owner.rng.Float32()
+	p.Location = line.Start.Add(line.End.Sub(line.Start).Mul(t))
+	p.Location = line.Owner.Properties.Rotation.Rotate(p.Location)
+
+	p.Velocity = line.Owner.Properties.Velocity.Normalize()
+	p.Velocity = line.Owner.Properties.Rotation.Rotate(p.Velocity)
+
+	return p
+}
+
+// CreateRenderable creates a cached renderable for the spawner that represents
+// the spawning volume for particles.
+func (line *LineSpawner) CreateRenderable() *fizzle.Renderable {
+	line.volumeRenderable = fizzle.CreateLineV(line.Start, line.End)
+	return line.volumeRenderable
+}
+
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (line *LineSpawner) DestroyRenderable() {
+	if line.volumeRenderable != nil {
+		line.volumeRenderable.Destroy()
+		line.volumeRenderable = nil
+	}
+}
+
+// DrawSpawnVolume renders a visual representation of the particle spawning volume.
+func (line *LineSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	if line.volumeRenderable == nil {
+		line.CreateRenderable()
+	}
+
+	// sync the position and rotation
+	line.volumeRenderable.Location = line.Owner.Properties.Origin
+	line.volumeRenderable.LocalRotation = line.Owner.Properties.Rotation
+
+	r.DrawLines(line.volumeRenderable, shader, nil, projection, view, camera)
+}
@@ -0,0 +1,151 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	renderer "github.com/tbogdala/fizzle/renderer"
+)
+
+// MeshSurfaceSpawner is a particle spawner that creates particles on the
+// surface of a triangle mesh. Triangles are picked weighted by their area
+// so that particles are evenly spread over the surface, and particles move
+// along the normal of the triangle they spawned on.
+//
+// Since a Renderable only keeps its vertex data on the GPU, the triangles
+// should come from the source data, such as the Vertices and Faces of the
+// gombz.Mesh the Renderable was created with.
+type MeshSurfaceSpawner struct {
+	Vertices []mgl.Vec3
+	Faces    [][3]uint32
+	Owner    *Emitter `json:"-"`
+
+	// cumulativeAreas is the running total of the triangle areas in Faces
+	// order and is used to pick a triangle weighted by area.
+	cumulativeAreas []float32
+
+	volumeRenderable *fizzle.Renderable
+}
+
+// NewMeshSurfaceSpawner creates a new particle spawner that uses the surface
+// of the triangles specified by faces, which index into verts.
+func NewMeshSurfaceSpawner(owner *Emitter, verts []mgl.Vec3, faces [][3]uint32) *MeshSurfaceSpawner {
+	mesh := new(MeshSurfaceSpawner)
+	mesh.Vertices = verts
+	mesh.Faces = faces
+	mesh.Owner = owner
+	mesh.UpdateAreas()
+	return mesh
+}
+
+// GetName returns a user friendly name for the spawner
+func (mesh *MeshSurfaceSpawner) GetName() string {
+	return "Mesh Surface Spawner"
+}
+
+// SetOwner sets the owning emitter for the spawner
+func (mesh *MeshSurfaceSpawner) SetOwner(e *Emitter) {
+	mesh.Owner = e
+}
+
+// GetLocation returns the location in world space for the spawner.
+func (mesh *MeshSurfaceSpawner) GetLocation() mgl.Vec3 {
+	return mesh.Owner.GetLocation()
+}
+
+// UpdateAreas recalculates the triangle areas used to pick where particles
+// spawn. This needs to be called if Vertices or Faces are changed.
+func (mesh *MeshSurfaceSpawner) UpdateAreas() {
+	mesh.cumulativeAreas = make([]float32, len(mesh.Faces))
+	var total float32
+	for i, f := range mesh.Faces {
+		a, b, c := mesh.Vertices[f[0]], mesh.Vertices[f[1]], mesh.Vertices[f[2]]
+		total += b.Sub(a).Cross(c.Sub(a)).Len() * 0.5
+		mesh.cumulativeAreas[i] = total
+	}
+}
+
+// NewParticle creates a new particle at a random point on the mesh surface.
+func (mesh *MeshSurfaceSpawner) NewParticle() (p Particle) {
+	// get the standard properties from the emitter itself
+	p.StartTime = mesh.Owner.Owner.runtime
+	p.Size = mesh.Owner.Properties.Size
+	p.Speed = mesh.Owner.Properties.Speed
+	p.Color = mesh.Owner.Properties.Color
+	p.Acceleration = mesh.Owner.Properties.Acceleration
+	p.EndTime = mesh.Owner.Properties.TTL + p.StartTime
+
+	// the areas won't be set if the spawner was deserialized
+	if len(mesh.cumulativeAreas) != len(mesh.Faces) {
+		mesh.UpdateAreas()
+	}
+	if len(mesh.Faces) == 0 {
+		p.Velocity = mesh.Owner.Properties.Rotation.Rotate(mesh.Owner.Properties.Velocity.Normalize())
+		return p
+	}
+
+	// pick a triangle weighted by area
+	rng := mesh.Owner.rng
+	totalArea := mesh.cumulativeAreas[len(mesh.cumulativeAreas)-1]
+	target := rng.Float32() * totalArea
+	faceIndex := sort.Search(len(mesh.cumulativeAreas), func(i int) bool {
+		return mesh.cumulativeAreas[i] > target
+	})
+	if faceIndex >= len(mesh.Faces) {
+		faceIndex = len(mesh.Faces) - 1
+	}
+
+	// pick a uniformly distributed point in the triangle
+	f := mesh.Faces[faceIndex]
+	a, b, c := mesh.Vertices[f[0]], mesh.Vertices[f[1]], mesh.Vertices[f[2]]
+	r1 := float32(math.Sqrt(rng.Float64()))
+	r2 := rng.Float32()
+	p.Location = a.Mul(1.0 - r1).Add(b.Mul(r1 * (1.0 - r2))).Add(c.Mul(r1 * r2))
+	p.Location = mesh.Owner.Properties.Rotation.Rotate(p.Location)
+
+	normal := b.Sub(a).Cross(c.Sub(a))
+	if normal.Len() > 0.0 {
+		normal = normal.Normalize()
+	}
+	p.Velocity = mesh.Owner.Properties.Rotation.Rotate(normal)
+
+	return p
+}
+
+// CreateRenderable creates a cached renderable for the spawner that represents
+// the spawning volume for particles.
+func (mesh *MeshSurfaceSpawner) CreateRenderable() *fizzle.Renderable {
+	mesh.volumeRenderable = fizzle.CreateWireframeTriangles(mesh.Vertices, mesh.Faces)
+	return mesh.volumeRenderable
+}
+
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (mesh *MeshSurfaceSpawner) DestroyRenderable() {
+	if mesh.volumeRenderable != nil {
+		mesh.volumeRenderable.Destroy()
+		mesh.volumeRenderable = nil
+	}
+}
+
+// DrawSpawnVolume renders a visual representation of the particle spawning volume.
+func (mesh *MeshSurfaceSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	if mesh.volumeRenderable == nil {
+		mesh.CreateRenderable()
+	}
+
+	// there's nothing to draw for an empty mesh
+	if mesh.volumeRenderable == nil {
+		return
+	}
+
+	// sync the position and rotation
+	mesh.volumeRenderable.Location = mesh.Owner.Properties.Origin
+	mesh.volumeRenderable.LocalRotation = mesh.Owner.Properties.Rotation
+
+	r.DrawLines(mesh.volumeRenderable, shader, nil, projection, view, camera)
+}
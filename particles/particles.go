@@ -21,12 +21,15 @@ var (
   in vec3 POSITION;
   in vec4 COLOR;
   in float SIZE;
+  in float FRAME;
 
   out vec4 vs_color;
+  out float vs_frame;
 
   void main()
   {
     vs_color = COLOR;
+    vs_frame = FRAME;
 
     gl_PointSize = SIZE;
     gl_Position = MVP * vec4(POSITION, 1.0);
@@ -35,13 +38,22 @@ var (
 	// FragShader330 is the GLSL fragment shader program for the asic bparticle emitter.
 	FragShader330 = `#version 330
   uniform sampler2D TEX;
+  uniform vec2 ATLAS_SIZE;
+  uniform float EXPOSURE;
   in vec4 vs_color;
+  in float vs_frame;
 
   out vec4 frag_color;
 
   void main()
   {
-	frag_color = vs_color * texture(TEX, gl_PointCoord.st);
+	// pick the cell of the texture atlas for the frame with row 0 being
+	// the top of the image; an unset ATLAS_SIZE samples the whole texture
+	vec2 atlasSize = max(ATLAS_SIZE, vec2(1.0));
+	float col = mod(vs_frame, atlasSize.x);
+	float row = floor(vs_frame / atlasSize.x);
+	vec2 uv = (vec2(col, atlasSize.y - 1.0 - row) + gl_PointCoord.st) / atlasSize;
+	frag_color = vs_color * texture(TEX, uv) * vec4(vec3(EXPOSURE), 1.0);
   }`
 )
 
@@ -52,8 +64,30 @@ type System struct {
 	Origin     mgl.Vec3
 	IsActive   bool
 	IsEmitting bool
-	gfx        graphics.GraphicsProvider
-	runtime    float64
+
+	// ForceFields push around the particles of every emitter in the system
+	// that is not simulated on the GPU.
+	ForceFields []ForceField
+
+	// FixedTimestep makes Update advance the simulation in steps of this many
+	// seconds, carrying the remaining time over to the next frame, so that
+	// playback is the same regardless of frame rate. A value of 0 updates
+	// once with the frame delta.
+	FixedTimestep float64
+
+	// MaxFixedSteps limits the number of fixed steps taken in one frame so
+	// that a slow frame doesn't cause more and more steps to be needed. Time
+	// beyond the limit is dropped. A value of 0 doesn't limit the steps.
+	MaxFixedSteps int
+
+	gfx     graphics.GraphicsProvider
+	runtime float64
+
+	// timestep runs the fixed steps with the settings above.
+	timestep fizzle.FixedTimestep
+
+	// exposure scales the color of the particles while they're drawn.
+	exposure float32
 }
 
 // ParticleSpawner is a type of interface for objects that are able to spawn
@@ -89,11 +123,71 @@ type Emitter struct {
 	Properties EmitterProperties
 	Spawner    ParticleSpawner
 
+	// TrailShader and TrailTexture are used to draw the particle trails when
+	// Properties.TrailSegments is set. The shader should be created with
+	// TrailVertShader330 and TrailFragShader330 and the particle Texture
+	// is used if TrailTexture is not set.
+	TrailShader  graphics.Program
+	TrailTexture graphics.Texture
+
+	// Colliders are tested against the particles when the collision mode in
+	// Properties is set. They are not used by emitters simulated on the GPU.
+	Colliders []ParticleCollider
+
+	// OnCollision is called with the world space location and surface
+	// normal whenever a particle hits the ground plane or a collider, which
+	// can be used to spawn splashes with EmitAt on another emitter.
+	OnCollision func(e *Emitter, location, normal mgl.Vec3)
+
+	// ForceFields push around the particles of this emitter in addition to
+	// the ones in the owning System. They are not used by emitters
+	// simulated on the GPU.
+	ForceFields []ForceField
+
+	// Parent is the renderable the emitter is attached to with SetParent
+	// so that it follows the renderable, or the named ParentBone of its
+	// skeleton, as it moves. Properties.Origin and Rotation are then
+	// relative to the parent instead of the owning System's Origin.
+	// Particles simulated in local space move along with the parent but
+	// keep the orientation they were spawned with.
+	Parent     *fizzle.Renderable
+	ParentBone string
+
 	vao            uint32
 	comboVBO       graphics.Buffer
 	comboBuffer    []float32
 	timeSinceSpawn float64
 	rng            *rand.Rand
+
+	trailVAO       uint32
+	trailVBO       graphics.Buffer
+	trailBuffer    []float32
+	timeSinceTrail float64
+	sortDistances  []float32
+
+	// lodScale is the spawn rate multiplier for the distance to the camera
+	// and isCulled is set if the emitter was outside of the view frustum.
+	lodScale float32
+	isCulled bool
+
+	// cycleTime is the time since the current emission cycle started and
+	// pendingBurst is the number of burst particles waiting to be spawned.
+	cycleTime    float64
+	pendingBurst uint
+	isStarted    bool
+	isRunning    bool
+
+	// gpu is set for emitters created with System.NewGPUEmitter
+	gpu *gpuSimulation
+
+	// lastLocation is the world location of the emitter at the last update.
+	// Particles simulated in world space are spawned along the way from it
+	// to the current location, starting at spawnLocation and moving by
+	// spawnStep for each one, so moving emitters leave an even stream.
+	lastLocation    mgl.Vec3
+	hasLastLocation bool
+	spawnLocation   mgl.Vec3
+	spawnStep       mgl.Vec3
 }
 
 // EmitterProperties describes the behavior of an Emitter object and is it's own
@@ -106,10 +200,132 @@ type EmitterProperties struct {
 	Speed           float32
 	Acceleration    mgl.Vec3
 	TTL             float64  // in seconds
-	Origin          mgl.Vec3 // relative to Emitter.Owner.Origin
+	Origin          mgl.Vec3 // relative to Emitter.Owner.Origin or Emitter.Parent
 	Rotation        mgl.Quat
 	Color           mgl.Vec4
 	Size            float32
+
+	// ColorOverLife is multiplied into the Color of each particle over its
+	// life, going from position 0 when it spawns to 1 when it dies. It is
+	// not used by emitters simulated on the GPU.
+	ColorOverLife *fizzle.Gradient
+
+	// AtlasRows and AtlasColumns describe how the texture is divided into
+	// a grid of animation frames, which are played left to right and then
+	// top to bottom at AtlasFPS. A value of 0 is treated like 1.
+	AtlasRows    uint
+	AtlasColumns uint
+	AtlasFPS     float32
+
+	// AtlasRandomStartFrame makes each particle start the animation on a
+	// random frame of the atlas instead of the first one.
+	AtlasRandomStartFrame bool
+
+	// BurstCount is the number of particles spawned all at once at the
+	// start of every emission cycle and whenever Emitter.Trigger is called.
+	BurstCount uint
+
+	// Duration is how long in seconds the emitter spawns particles at
+	// SpawnRate for each emission cycle. A value of 0 emits forever.
+	Duration float64
+
+	// Looping restarts the emission cycle after Duration has passed,
+	// waiting LoopDelay seconds before starting the next one.
+	Looping   bool
+	LoopDelay float64
+
+	// Prewarm simulates TTL seconds of emission on the first update so that
+	// the emitter starts out as if it had already been running.
+	Prewarm bool
+
+	// WaitForTrigger keeps the emitter from starting its first emission
+	// cycle until Emitter.Trigger is called.
+	WaitForTrigger bool
+
+	// CollisionMode is how particles respond to hitting the ground plane
+	// or one of the colliders of the emitter. Restitution is the fraction
+	// of speed into the surface kept by bouncing particles.
+	CollisionMode CollisionMode
+	Restitution   float32
+
+	// GroundCollision enables collision with a ground plane at GroundHeight
+	// on the Y axis in world space.
+	GroundCollision bool
+	GroundHeight    float32
+
+	// TrailSegments is the number of segments in the ribbon trailing behind
+	// each particle; 0 disables trails. A new point is added to the trail
+	// every TrailInterval seconds, or every update if it is 0.
+	TrailSegments uint
+	TrailInterval float64
+
+	// TrailWidth is the width curve of the trail, evenly spread out from the
+	// particle to the end of the trail and linearly interpolated. If it is
+	// empty, the trail is 1 unit wide.
+	TrailWidth []float32
+
+	// TrailStretchUV stretches the texture over the whole trail instead of
+	// repeating it for every segment.
+	TrailStretchUV bool
+
+	// TrailTextureFilepath is the texture to load for the trails.
+	TrailTextureFilepath string
+
+	// BlendMode is how the particles are blended when drawn.
+	BlendMode BlendMode
+
+	// SortParticles draws the particles from back to front as seen from the
+	// camera, which alpha blended particles need to layer correctly. It is
+	// not supported by emitters simulated on the GPU.
+	SortParticles bool
+
+	// FrustumCulling skips updating and drawing the emitter while its bounds
+	// are outside of the view frustum it was last drawn with.
+	FrustumCulling bool
+
+	// LODNearDistance and LODFarDistance are the distances from the camera
+	// that the spawn rate gets scaled between, going from the full rate at
+	// the near distance down to LODMinSpawnScale times the rate at the far
+	// distance. LOD scaling is disabled if the far distance isn't greater.
+	LODNearDistance  float32
+	LODFarDistance   float32
+	LODMinSpawnScale float32
+
+	// SimulationSpace is the space the particle locations are kept in. It
+	// should not be changed while the emitter has live particles.
+	SimulationSpace SimulationSpace
+
+	// Seed is used to seed the random number generator of the emitter when
+	// it is created and when it is Reset.
+	Seed int64
+}
+
+// SimulationSpace is the coordinate space the particles of an emitter
+// are simulated in.
+type SimulationSpace int
+
+const (
+	// SimulationLocal keeps particle locations relative to the emitter so
+	// they move along with it, like rocket exhaust.
+	SimulationLocal SimulationSpace = iota
+
+	// SimulationWorld keeps particle locations in world space so they are
+	// left behind when the emitter moves, like a smoke trail.
+	SimulationWorld
+
+	// SimulationSpaceCount is the number of simulation spaces supported.
+	SimulationSpaceCount
+)
+
+// simulationSpaceNames are the user friendly names of the simulation spaces.
+var simulationSpaceNames = []string{"Local", "World"}
+
+// String returns a user friendly name for the simulation space.
+func (space SimulationSpace) String() string {
+	if space < 0 || space >= SimulationSpaceCount {
+		return "Unknown"
+	}
+	return simulationSpaceNames[space]
 }
 
 // Particle is an individual particle in an Emitter.
@@ -122,6 +338,11 @@ type Particle struct {
 	Acceleration mgl.Vec3
 	EndTime      float64
 	StartTime    float64
+	StartFrame   uint // the first atlas frame of the animation
+
+	// Trail is the list of previous locations of the particle with the
+	// most recent first.
+	Trail []mgl.Vec3
 }
 
 // NewSystem creates a new particle system.
@@ -130,9 +351,16 @@ func NewSystem(gfx graphics.GraphicsProvider) *System {
 	s.gfx = gfx
 	s.IsActive = true
 	s.IsEmitting = true
+	s.MaxFixedSteps = fizzle.DefaultMaxUpdatesPerFrame
+	s.exposure = 1.0
 	return s
 }
 
+// GetLocation returns the origin of the system in world space.
+func (s *System) GetLocation() mgl.Vec3 {
+	return s.Origin
+}
+
 // GetTransform returns the transform matrix for the system as a whole.
 func (s *System) GetTransform() mgl.Mat4 {
 	return mgl.Translate3D(s.Origin[0], s.Origin[1], s.Origin[2])
@@ -144,8 +372,7 @@ func (s *System) NewEmitter(optProps *EmitterProperties) *Emitter {
 	e := new(Emitter)
 	e.Owner = s
 
-	// setup the rng for the emitter with a default seed of 1
-	e.rng = rand.New(rand.NewSource(1))
+	e.lodScale = 1.0
 
 	// for now, create a default spawner
 	e.Spawner = NewConeSpawner(e, 0.5, 1.0, 2.0)
@@ -161,11 +388,17 @@ func (s *System) NewEmitter(optProps *EmitterProperties) *Emitter {
 		e.Properties.Speed = 1.0
 		e.Properties.Velocity = mgl.Vec3{0, 1, 0}
 		e.Properties.Rotation = mgl.QuatIdent()
+		e.Properties.Seed = 1
 	}
 
+	// setup the rng for the emitter with the seed from the properties
+	e.rng = rand.New(rand.NewSource(e.Properties.Seed))
+
 	// construct the objects needed for rendering
 	e.vao = s.gfx.GenVertexArray()
 	e.comboVBO = s.gfx.GenBuffer()
+	e.trailVAO = s.gfx.GenVertexArray()
+	e.trailVBO = s.gfx.GenBuffer()
 
 	// keep track of it
 	s.Emitters = append(s.Emitters, e)
@@ -173,17 +406,80 @@ func (s *System) NewEmitter(optProps *EmitterProperties) *Emitter {
 	return e
 }
 
+// RemoveEmitter stops tracking the emitter in the system and releases the
+// graphics objects created for it.
+func (s *System) RemoveEmitter(e *Emitter) {
+	for i, emitter := range s.Emitters {
+		if emitter == e {
+			s.Emitters = append(s.Emitters[:i], s.Emitters[i+1:]...)
+			break
+		}
+	}
+
+	s.gfx.DeleteVertexArray(e.vao)
+	s.gfx.DeleteBuffer(e.comboVBO)
+	s.gfx.DeleteVertexArray(e.trailVAO)
+	s.gfx.DeleteBuffer(e.trailVBO)
+	if e.gpu != nil {
+		e.gpu.destroy(s.gfx)
+	}
+}
+
 // Update will update all of the emitters currently tracked by the system if
-// the system is active.
+// the system is active. Emitters that were culled when last drawn are skipped.
 func (s *System) Update(frameDelta float64) {
-	if s.IsActive {
-		s.runtime += frameDelta
-		for _, emitter := range s.Emitters {
+	if !s.IsActive {
+		return
+	}
+
+	if s.FixedTimestep <= 0.0 {
+		s.step(frameDelta)
+		return
+	}
+
+	s.timestep.Step = float32(s.FixedTimestep)
+	s.timestep.MaxUpdatesPerFrame = s.MaxFixedSteps
+	s.timestep.Advance(float32(frameDelta), func(step float32) {
+		s.step(float64(step))
+	})
+}
+
+// step advances the runtime of the system and updates the emitters.
+func (s *System) step(frameDelta float64) {
+	s.runtime += frameDelta
+	for _, emitter := range s.Emitters {
+		if !emitter.isCulled {
 			emitter.Update(frameDelta)
 		}
 	}
 }
 
+// GetParticleCount returns the number of particles in all of the emitters.
+// Emitters simulated on the GPU count all of their allocated slots since
+// the live particles are not known on the CPU.
+func (s *System) GetParticleCount() int {
+	count := 0
+	for _, e := range s.Emitters {
+		if e.gpu != nil {
+			count += e.gpu.capacity
+		} else {
+			count += len(e.Particles)
+		}
+	}
+	return count
+}
+
+// Reset puts the runtime of the system back to zero and resets all of the
+// emitters, so that the same updates will play the effect back exactly the
+// same way again.
+func (s *System) Reset() {
+	s.runtime = 0.0
+	s.timestep.Reset()
+	for _, emitter := range s.Emitters {
+		emitter.Reset()
+	}
+}
+
 // Draw renders all particle emitters.
 func (s *System) Draw(projection mgl.Mat4, view mgl.Mat4) {
 	for _, emitter := range s.Emitters {
@@ -191,13 +487,123 @@ func (s *System) Draw(projection mgl.Mat4, view mgl.Mat4) {
 	}
 }
 
+// DrawExposed renders all particle emitters with the color of the particles
+// scaled by exposure, which should be the exposure scale of the renderer the
+// scene is drawn with. renderer.DrawParticleSystem calls this.
+func (s *System) DrawExposed(projection mgl.Mat4, view mgl.Mat4, exposure float32) {
+	s.exposure = exposure
+	s.Draw(projection, view)
+	s.exposure = 1.0
+}
+
 // GetLocation returns the emitter location in world space.
 func (e *Emitter) GetLocation() mgl.Vec3 {
-	return e.Owner.Origin.Add(e.Properties.Origin)
+	return e.getParentTransform().Mul4x1(e.Properties.Origin.Vec4(1.0)).Vec3()
+}
+
+// SetParent attaches the emitter to the renderable, or to the named bone of
+// its skeleton if boneName isn't empty, so that it follows it around. A nil
+// renderable puts the emitter back at the owning System's Origin. Particles
+// already simulated in world space stay where they are and the next ones
+// spawn at the new location.
+func (e *Emitter) SetParent(r *fizzle.Renderable, boneName string) {
+	e.Parent = r
+	e.ParentBone = boneName
+	e.hasLastLocation = false
+}
+
+// getParentTransform returns the world transform that Properties.Origin and
+// Rotation are relative to, which is the Parent, its ParentBone or the
+// Origin of the owning System.
+func (e *Emitter) getParentTransform() mgl.Mat4 {
+	if e.Parent == nil {
+		return e.Owner.GetTransform()
+	}
+
+	model := e.Parent.GetTransformMat4()
+	if e.ParentBone != "" && e.Parent.Core != nil && e.Parent.Core.Skeleton != nil {
+		if boneTransform, okay := e.Parent.Core.Skeleton.GetBoneWorldTransform(e.ParentBone, model); okay {
+			return boneTransform
+		}
+	}
+	return model
+}
+
+// getParticleOrigin returns the world space location that particle
+// locations are relative to.
+func (e *Emitter) getParticleOrigin() mgl.Vec3 {
+	if e.Properties.SimulationSpace == SimulationWorld {
+		return mgl.Vec3{}
+	}
+	return e.GetLocation()
+}
+
+// GetAtlasFrameCount returns the number of animation frames in the
+// texture atlas described by the properties.
+func (props *EmitterProperties) GetAtlasFrameCount() uint {
+	rows, cols := props.AtlasRows, props.AtlasColumns
+	if rows == 0 {
+		rows = 1
+	}
+	if cols == 0 {
+		cols = 1
+	}
+	return rows * cols
+}
+
+// getAtlasSize returns the size of the atlas grid as columns and rows.
+func (props *EmitterProperties) getAtlasSize() (float32, float32) {
+	rows, cols := props.AtlasRows, props.AtlasColumns
+	if rows == 0 {
+		rows = 1
+	}
+	if cols == 0 {
+		cols = 1
+	}
+	return float32(cols), float32(rows)
+}
+
+// newParticle creates a new particle with the spawner and then sets
+// the emitter wide properties that spawners don't deal with.
+func (e *Emitter) newParticle() Particle {
+	p := e.Spawner.NewParticle()
+
+	// spawners work relative to the emitter, so turn the particle with the
+	// parent without moving it there
+	if e.Parent != nil {
+		parentTransform := e.getParentTransform()
+		p.Location = parentTransform.Mul4x1(p.Location.Vec4(0.0)).Vec3()
+		speed := p.Velocity.Len()
+		if speed > 0.0 {
+			p.Velocity = parentTransform.Mul4x1(p.Velocity.Vec4(0.0)).Vec3().Normalize().Mul(speed)
+		}
+	}
+
+	if e.Properties.SimulationSpace == SimulationWorld {
+		e.spawnLocation = e.spawnLocation.Add(e.spawnStep)
+		p.Location = p.Location.Add(e.spawnLocation)
+	}
+	frameCount := e.Properties.GetAtlasFrameCount()
+	if e.Properties.AtlasRandomStartFrame && frameCount > 1 {
+		p.StartFrame = uint(e.rng.Intn(int(frameCount)))
+	}
+	return p
+}
+
+// getAtlasFrame returns the texture atlas frame the particle should
+// currently be drawn with.
+func (e *Emitter) getAtlasFrame(p *Particle) uint {
+	frameCount := e.Properties.GetAtlasFrameCount()
+	if frameCount <= 1 {
+		return 0
+	}
+	elapsed := uint(math.Floor((e.Owner.runtime - p.StartTime) * float64(e.Properties.AtlasFPS)))
+	return (p.StartFrame + elapsed) % frameCount
 }
 
 // LoadTexture will load the Properties.TextureFilepath and create
-// an OpenGL texture with it.
+// an OpenGL texture with it. Properties.TrailTextureFilepath is also
+// loaded into TrailTexture if it is set.
 func (e *Emitter) LoadTexture() error {
 	var err error
 	e.Texture, err = fizzle.LoadImageToTexture(e.Properties.TextureFilepath)
@@ -205,12 +611,160 @@ func (e *Emitter) LoadTexture() error {
 		return fmt.Errorf("Failed to load the particle emitter texture: %s. %v", e.Properties.TextureFilepath, err)
 	}
 
+	if len(e.Properties.TrailTextureFilepath) > 0 {
+		e.TrailTexture, err = fizzle.LoadImageToTexture(e.Properties.TrailTextureFilepath)
+		if err != nil {
+			return fmt.Errorf("Failed to load the particle trail texture: %s. %v", e.Properties.TrailTextureFilepath, err)
+		}
+	}
+
 	return nil
 }
 
+// SetSeed changes the seed in the properties and reseeds the random number
+// generator of the emitter with it.
+func (e *Emitter) SetSeed(seed int64) {
+	e.Properties.Seed = seed
+	e.rng.Seed(seed)
+}
+
+// Reset removes all of the particles, reseeds the random number generator
+// with Properties.Seed and puts the emission cycle back to the start as if
+// the emitter had just been created.
+func (e *Emitter) Reset() {
+	e.Particles = e.Particles[:0]
+	e.rng.Seed(e.Properties.Seed)
+	e.timeSinceSpawn = 0.0
+	e.timeSinceTrail = 0.0
+	e.cycleTime = 0.0
+	e.pendingBurst = 0
+	e.isStarted = false
+	e.isRunning = false
+	e.hasLastLocation = false
+
+	if e.gpu != nil {
+		e.gpu.resize(e.Owner.gfx, e.gpu.capacity)
+	}
+}
+
+// Trigger starts a new emission cycle for the emitter, spawning
+// BurstCount particles on the next update.
+func (e *Emitter) Trigger() {
+	e.isStarted = true
+	e.restartCycle()
+}
+
+// restartCycle resets the emission timers and queues up the burst
+// for the start of a new cycle.
+func (e *Emitter) restartCycle() {
+	e.isRunning = true
+	e.cycleTime = 0.0
+	e.timeSinceSpawn = 0.0
+	e.pendingBurst += e.Properties.BurstCount
+}
+
+// EmitAt spawns count particles right away at the location in world space
+// instead of at the emitter, such as for splashes where the particles of
+// another emitter hit something. The emission cycle is not affected, but
+// MaxParticles and System.IsEmitting still are. It does nothing for
+// emitters simulated on the GPU.
+func (e *Emitter) EmitAt(location mgl.Vec3, count uint) {
+	if e.gpu != nil || !e.Owner.IsEmitting {
+		return
+	}
+
+	// newParticle offsets world space particles by the spawn location, so
+	// point it at the location for now
+	oldLocation, oldStep := e.spawnLocation, e.spawnStep
+	var offset mgl.Vec3
+	if e.Properties.SimulationSpace == SimulationWorld {
+		e.spawnLocation = location
+		e.spawnStep = mgl.Vec3{}
+	} else {
+		offset = location.Sub(e.GetLocation())
+	}
+
+	for ; count > 0 && len(e.Particles) < int(e.Properties.MaxParticles); count-- {
+		p := e.newParticle()
+		p.Location = p.Location.Add(offset)
+		e.Particles = append(e.Particles, p)
+	}
+
+	e.spawnLocation, e.spawnStep = oldLocation, oldStep
+}
+
 // Update will update all of the particles for the emitter and then
 // update the graphics buffers.
 func (e *Emitter) Update(frameDelta float64) {
+	if !e.isStarted {
+		e.isStarted = true
+		if !e.Properties.WaitForTrigger {
+			e.restartCycle()
+		}
+		if e.Properties.Prewarm && e.Properties.TTL > 0.0 {
+			e.prewarm(frameDelta)
+		}
+	}
+
+	e.update(frameDelta)
+}
+
+// prewarm simulates TTL seconds of emission leading up to the current
+// frame by temporarily rewinding the runtime of the owning system.
+func (e *Emitter) prewarm(frameDelta float64) {
+	const prewarmStep = 1.0 / 30.0
+
+	endTime := e.Owner.runtime - frameDelta
+	e.Owner.runtime = endTime - e.Properties.TTL
+	for e.Owner.runtime < endTime {
+		step := math.Min(prewarmStep, endTime-e.Owner.runtime)
+		e.Owner.runtime += step
+		e.update(step)
+	}
+	e.Owner.runtime = endTime + frameDelta
+}
+
+// getSpawnCount advances the emission cycle and returns the number of
+// particles that should be spawned this frame.
+func (e *Emitter) getSpawnCount(frameDelta float64) int {
+	if !e.isRunning {
+		return 0
+	}
+	props := &e.Properties
+
+	// particles are only spawned at the spawn rate during the cycle duration
+	emitTime := frameDelta
+	if props.Duration > 0.0 {
+		emitTime = math.Max(0.0, math.Min(frameDelta, props.Duration-e.cycleTime))
+	}
+
+	var spawnCount float64
+	spawnRate := float64(props.SpawnRate) * float64(e.lodScale)
+	if spawnRate > 0.0 {
+		spawnInterval := 1.0 / spawnRate
+		e.timeSinceSpawn += emitTime
+		spawnCount = math.Floor(e.timeSinceSpawn / spawnInterval)
+		e.timeSinceSpawn -= spawnCount * spawnInterval
+	}
+
+	// move on to the next cycle or stop emitting when the cycle ends
+	e.cycleTime += frameDelta
+	if props.Duration > 0.0 && e.cycleTime >= props.Duration {
+		if !props.Looping {
+			e.isRunning = false
+		} else if e.cycleTime >= props.Duration+props.LoopDelay {
+			e.restartCycle()
+		}
+	}
+
+	spawnCount += float64(e.pendingBurst)
+	e.pendingBurst = 0
+
+	return int(spawnCount)
+}
+
+// update advances the particles for the emitter by frameDelta seconds.
+func (e *Emitter) update(frameDelta float64) {
 	// filter out all of the dead particles
 	stillAlive := e.Particles[:0]
 	for _, particle := range e.Particles {
@@ -221,56 +775,106 @@ func (e *Emitter) Update(frameDelta float64) {
 	e.Particles = stillAlive
 
 	// how many particle to spawn?
-	var spawnInterval = float64(1.0)
-	e.timeSinceSpawn += frameDelta
-	if e.Properties.SpawnRate != 0.0 {
-		spawnInterval = 1.0 / float64(e.Properties.SpawnRate)
-	}
-	spawnCount := math.Floor(e.timeSinceSpawn / spawnInterval)
+	spawnCount := e.getSpawnCount(frameDelta)
+	e.beginSpawning(spawnCount)
 
-	// update the timers
-	e.timeSinceSpawn -= spawnCount * spawnInterval
+	// particles simulated on the GPU are not tracked in e.Particles
+	if e.gpu != nil {
+		e.updateGPU(frameDelta, spawnCount)
+		return
+	}
 
-	// update the particles
-	for i, particle := range e.Particles {
-		dV := particle.Velocity.Mul(float32(frameDelta) * particle.Speed)
-		//dA := particle.Acceleration.Mul(float32(frameDelta))
-		e.Particles[i].Location = particle.Location.Add(dV)
-		//e.Particles[i].Velocity = particle.Velocity.Add(dA)
+	// accelerate the particles with the force fields
+	e.applyForceFields(frameDelta)
+
+	// update the particles, accelerating them before moving them to match
+	// the transform feedback shader used for GPU particles
+	for i := range e.Particles {
+		p := &e.Particles[i]
+		v := p.Velocity.Mul(p.Speed).Add(p.Acceleration.Mul(float32(frameDelta)))
+		p.Speed = v.Len()
+		if p.Speed > 0.0 {
+			p.Velocity = v.Mul(1.0 / p.Speed)
+		}
+		p.Location = p.Location.Add(v.Mul(float32(frameDelta)))
 	}
 
+	// keep the particles from going through the ground and colliders
+	e.collideParticles()
+
+	// add the new locations to the particle trails
+	e.updateTrails(frameDelta)
+
 	// add the particles if we're still emitting
 	if e.Owner.IsEmitting {
 		var newParticle Particle
 		for spawnCount > 0 && len(e.Particles) < int(e.Properties.MaxParticles) {
-			newParticle = e.Spawner.NewParticle()
+			newParticle = e.newParticle()
 			e.Particles = append(e.Particles, newParticle)
 			spawnCount--
 		}
 	}
 }
 
+// beginSpawning sets up the world space locations for the particles spawned
+// this update, spread out from where the emitter was at the last update to
+// where it is now.
+func (e *Emitter) beginSpawning(spawnCount int) {
+	location := e.GetLocation()
+	if !e.hasLastLocation || spawnCount <= 0 {
+		e.spawnLocation = location
+		e.spawnStep = mgl.Vec3{}
+	} else {
+		e.spawnLocation = e.lastLocation
+		e.spawnStep = location.Sub(e.lastLocation).Mul(1.0 / float32(spawnCount))
+	}
+	e.lastLocation = location
+	e.hasLastLocation = true
+}
+
 const (
 	floatSize = 4
 )
 
+// getParticleColor returns the color of the particle with the
+// ColorOverLife gradient applied for how far along its life it is.
+func (e *Emitter) getParticleColor(p *Particle) mgl.Vec4 {
+	gradient := e.Properties.ColorOverLife
+	if gradient == nil || len(gradient.Keys) == 0 {
+		return p.Color
+	}
+
+	var life float32
+	if p.EndTime > p.StartTime {
+		life = float32((e.Owner.runtime - p.StartTime) / (p.EndTime - p.StartTime))
+	}
+	c := gradient.Sample(life)
+	return mgl.Vec4{p.Color[0] * c[0], p.Color[1] * c[1], p.Color[2] * c[2], p.Color[3] * c[3]}
+}
+
 func (e *Emitter) renderToVBO() {
 	buffer := e.comboBuffer[:0]
 
-	for _, p := range e.Particles {
+	for i := range e.Particles {
+		p := &e.Particles[i]
+
 		// 3f = vertex
 		buffer = append(buffer, p.Location[0])
 		buffer = append(buffer, p.Location[1])
 		buffer = append(buffer, p.Location[2])
 
 		// 4f = color
-		buffer = append(buffer, p.Color[0])
-		buffer = append(buffer, p.Color[1])
-		buffer = append(buffer, p.Color[2])
-		buffer = append(buffer, p.Color[3])
+		color := e.getParticleColor(p)
+		buffer = append(buffer, color[0])
+		buffer = append(buffer, color[1])
+		buffer = append(buffer, color[2])
+		buffer = append(buffer, color[3])
 
 		// 1f = size
 		buffer = append(buffer, p.Size)
+
+		// 1f = atlas frame
+		buffer = append(buffer, float32(e.getAtlasFrame(p)))
 	}
 
 	// we didn't buffer anything
@@ -283,24 +887,62 @@ func (e *Emitter) renderToVBO() {
 	e.Owner.gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(buffer), e.Owner.gfx.Ptr(&buffer[0]), graphics.STREAM_DRAW)
 }
 
-// Draw renders the particle emitter.
+// bindExposure sets the EXPOSURE uniform of the shader if it uses it.
+func (e *Emitter) bindExposure(shader graphics.Program) {
+	gfx := e.Owner.gfx
+	shaderExposure := gfx.GetUniformLocation(shader, "EXPOSURE")
+	if shaderExposure >= 0 {
+		gfx.Uniform1f(shaderExposure, e.Owner.exposure)
+	}
+}
+
+// getMVP returns the model-view-projection matrix used to draw the particles.
+func (e *Emitter) getMVP(projection mgl.Mat4, view mgl.Mat4) mgl.Mat4 {
+	if e.Properties.SimulationSpace == SimulationWorld {
+		return projection.Mul4(view)
+	}
+
+	location := e.GetLocation()
+	model := mgl.Translate3D(location[0], location[1], location[2])
+	return projection.Mul4(view).Mul4(model)
+}
+
+// Draw renders the particle emitter with blending enabled. Afterwards the
+// blend function is left set to standard alpha blending.
+// The camera is also used to set the culling and LOD state of the
+// emitter for the next update.
 func (e *Emitter) Draw(projection mgl.Mat4, view mgl.Mat4) {
+	e.updateLOD(projection, view)
+	if e.isCulled {
+		return
+	}
+
+	if e.gpu != nil {
+		e.beginBlending()
+		e.drawGPU(e.getMVP(projection, view))
+		e.endBlending()
+		return
+	}
+
 	if e.Particles == nil || len(e.Particles) <= 0 {
 		return
 	}
 
+	if e.Properties.SortParticles {
+		e.sortParticles(view)
+	}
+
 	gfx := e.Owner.gfx
 	gfx.BindVertexArray(e.vao)
+	e.beginBlending()
+	defer e.endBlending()
 
 	// update the graphics buffers
 	e.renderToVBO()
 
 	gfx.UseProgram(e.Shader)
 
-	parentTransform := e.Owner.GetTransform()
-	modelTransform := mgl.Translate3D(e.Properties.Origin[0], e.Properties.Origin[1], e.Properties.Origin[2])
-	model := parentTransform.Mul4(modelTransform)
-	mvp := projection.Mul4(view).Mul4(model)
+	mvp := e.getMVP(projection, view)
 
 	// bind the uniforms and attributes
 	mvpMatrix := gfx.GetUniformLocation(e.Shader, "MVP")
@@ -315,10 +957,19 @@ func (e *Emitter) Draw(projection mgl.Mat4, view mgl.Mat4) {
 		gfx.Uniform1i(shaderTex0, 0)
 	}
 
+	shaderAtlasSize := gfx.GetUniformLocation(e.Shader, "ATLAS_SIZE")
+	if shaderAtlasSize >= 0 {
+		cols, rows := e.Properties.getAtlasSize()
+		gfx.Uniform2f(shaderAtlasSize, cols, rows)
+	}
+
+	e.bindExposure(e.Shader)
+
 	const posOffset = 0
 	const colorOffset = floatSize * 3
 	const sizeOffset = floatSize * 7
-	const Stride = floatSize * (3 + 4 + 1) // vert / color / size
+	const frameOffset = floatSize * 8
+	const Stride = floatSize * (3 + 4 + 1 + 1) // vert / color / size / frame
 
 	shaderPosition := gfx.GetAttribLocation(e.Shader, "POSITION")
 	gfx.BindBuffer(graphics.ARRAY_BUFFER, e.comboVBO)
@@ -333,7 +984,15 @@ func (e *Emitter) Draw(projection mgl.Mat4, view mgl.Mat4) {
 	gfx.EnableVertexAttribArray(uint32(shaderSize))
 	gfx.VertexAttribPointer(uint32(shaderSize), 1, graphics.FLOAT, false, Stride, gfx.PtrOffset(sizeOffset))
 
+	shaderFrame := gfx.GetAttribLocation(e.Shader, "FRAME")
+	if shaderFrame >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderFrame))
+		gfx.VertexAttribPointer(uint32(shaderFrame), 1, graphics.FLOAT, false, Stride, gfx.PtrOffset(frameOffset))
+	}
+
 	gfx.DrawArrays(graphics.POINTS, 0, int32(len(e.Particles)))
 
 	gfx.BindVertexArray(0)
+
+	e.drawTrails(mvp, view)
 }
@@ -0,0 +1,166 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// emitterDefinition is the serialized form of an Emitter. It stores the
+// emitter properties along with the spawner settings so that an effect
+// can be recreated from the data alone.
+type emitterDefinition struct {
+	Properties EmitterProperties
+
+	// SpawnerType is the name of the spawner as returned by GetName() and
+	// is used to find the factory to create the spawner with.
+	SpawnerType string
+
+	// Spawner is the JSON encoding of the spawner object.
+	Spawner json.RawMessage
+}
+
+// renderableDestroyer is implemented by spawners that cache a renderable
+// of their spawning volume, such as the built in spawners.
+type renderableDestroyer interface {
+	DestroyRenderable()
+}
+
+// spawnerFactories maps a spawner name to a function that creates a
+// new spawner of that type with default settings.
+var spawnerFactories = map[string]func() ParticleSpawner{
+	"Cone Spawner":         func() ParticleSpawner { return NewConeSpawner(nil, 0.5, 1.0, 2.0) },
+	"Cube Spawner":         func() ParticleSpawner { return NewCubeSpawner(nil, mgl.Vec3{-1, -1, -1}, mgl.Vec3{1, 1, 1}) },
+	"Sphere Spawner":       func() ParticleSpawner { return NewSphereSpawner(nil, 1.0, false) },
+	"Disc Spawner":         func() ParticleSpawner { return NewDiscSpawner(nil, 0.0, 1.0) },
+	"Line Spawner":         func() ParticleSpawner { return NewLineSpawner(nil, mgl.Vec3{-1, 0, 0}, mgl.Vec3{1, 0, 0}) },
+	"Mesh Surface Spawner": func() ParticleSpawner { return NewMeshSurfaceSpawner(nil, nil, nil) },
+}
+
+// RegisterSpawnerType makes a custom ParticleSpawner implementation known
+// so that emitters using it can be loaded. The name should match what
+// the spawner returns from GetName() and the factory function should
+// return a new spawner that the JSON data can be decoded into.
+func RegisterSpawnerType(name string, factory func() ParticleSpawner) {
+	spawnerFactories[name] = factory
+}
+
+// Save writes the emitter properties and spawner settings to a JSON file.
+func (e *Emitter) Save(filepath string) error {
+	jsonBytes, err := e.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the particle emitter file %s. %v", filepath, err)
+	}
+
+	return nil
+}
+
+// Load reads a JSON file written by Save and replaces the emitter properties
+// and spawner with the ones in the file. The texture is not loaded by
+// this function; call LoadTexture afterwards if needed.
+func (e *Emitter) Load(filepath string) error {
+	jsonBytes, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return fmt.Errorf("Failed to read the particle emitter file %s. %v", filepath, err)
+	}
+
+	return e.UnmarshalJSON(jsonBytes)
+}
+
+// LoadEmitter creates a new emitter in the system from a JSON file written
+// by Emitter.Save and then loads the texture for it. The shader for the
+// emitter still needs to be set by the caller.
+func (s *System) LoadEmitter(filepath string) (*Emitter, error) {
+	e := s.NewEmitter(nil)
+	err := e.Load(filepath)
+	if err != nil {
+		s.RemoveEmitter(e)
+		return nil, err
+	}
+
+	if len(e.Properties.TextureFilepath) > 0 {
+		err = e.LoadTexture()
+		if err != nil {
+			s.RemoveEmitter(e)
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// MarshalJSON encodes the emitter properties and spawner settings to JSON.
+func (e *Emitter) MarshalJSON() ([]byte, error) {
+	var def emitterDefinition
+	def.Properties = e.Properties
+
+	if e.Spawner != nil {
+		spawnerBytes, err := json.Marshal(e.Spawner)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to serialize the particle spawner to JSON. %v", err)
+		}
+		def.SpawnerType = e.Spawner.GetName()
+		def.Spawner = spawnerBytes
+	}
+
+	jsonBytes, err := json.MarshalIndent(&def, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize the particle emitter to JSON. %v", err)
+	}
+
+	return jsonBytes, nil
+}
+
+// UnmarshalJSON decodes the emitter properties and spawner settings from JSON
+// and replaces the ones in the emitter. The spawner type must be one of the
+// built in spawners or have been registered with RegisterSpawnerType. The
+// random number generator is reseeded with the decoded Properties.Seed and
+// the spawning volume renderable of the old spawner is destroyed.
+func (e *Emitter) UnmarshalJSON(jsonBytes []byte) error {
+	var def emitterDefinition
+	err := json.Unmarshal(jsonBytes, &def)
+	if err != nil {
+		return fmt.Errorf("Failed to decode the JSON for the particle emitter. %v", err)
+	}
+
+	var spawner ParticleSpawner
+	if def.SpawnerType != "" {
+		factory, okay := spawnerFactories[def.SpawnerType]
+		if !okay {
+			return fmt.Errorf("Failed to create the particle spawner; unknown spawner type %s", def.SpawnerType)
+		}
+
+		spawner = factory()
+		err = json.Unmarshal(def.Spawner, spawner)
+		if err != nil {
+			return fmt.Errorf("Failed to decode the JSON for the particle spawner. %v", err)
+		}
+		spawner.SetOwner(e)
+	}
+
+	e.Properties = def.Properties
+	if e.rng == nil {
+		e.rng = rand.New(rand.NewSource(e.Properties.Seed))
+	} else {
+		e.rng.Seed(e.Properties.Seed)
+	}
+	if spawner != nil {
+		if old, okay := e.Spawner.(renderableDestroyer); okay {
+			old.DestroyRenderable()
+		}
+		e.Spawner = spawner
+	}
+
+	return nil
+}
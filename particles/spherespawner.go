@@ -0,0 +1,110 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
+	renderer "github.com/tbogdala/fizzle/renderer"
+)
+
+// SphereSpawner is a particle spawner that creates particles within the
+// volume of a sphere, or the upper (+Y) half of one, as specified by the
+// settings in the struct. Particles move away from the center of the sphere.
+type SphereSpawner struct {
+	Radius       float32
+	IsHemisphere bool
+	Owner        *Emitter `json:"-"`
+
+	volumeRenderable *fizzle.Renderable
+}
+
+// NewSphereSpawner creates a new sphere shaped particle spawner.
+func NewSphereSpawner(owner *Emitter, radius float32, hemisphere bool) *SphereSpawner {
+	sphere := new(SphereSpawner)
+	sphere.Radius = radius
+	sphere.IsHemisphere = hemisphere
+	sphere.Owner = owner
+	return sphere
+}
+
+// GetName returns a user friendly name for the spawner
+func (sphere *SphereSpawner) GetName() string {
+	return "Sphere Spawner"
+}
+
+// SetOwner sets the owning emitter for the spawner
+func (sphere *SphereSpawner) SetOwner(e *Emitter) {
+	sphere.Owner = e
+}
+
+// GetLocation returns the location in world space for the spawner.
+func (sphere *SphereSpawner) GetLocation() mgl.Vec3 {
+	return sphere.Owner.GetLocation()
+}
+
+// NewParticle creates a new particle that fits within the volume of the sphere.
+func (sphere *SphereSpawner) NewParticle() (p Particle) {
+	// get the standard properties from the emitter itself
+	p.StartTime = sphere.Owner.Owner.runtime
+	p.Size = sphere.Owner.Properties.Size
+	p.Speed = sphere.Owner.Properties.Speed
+	p.Color = sphere.Owner.Properties.Color
+	p.Acceleration = sphere.Owner.Properties.Acceleration
+	p.EndTime = sphere.Owner.Properties.TTL + p.StartTime
+
+	// get a uniformly distributed direction by picking a height and an angle
+	rng := sphere.Owner.rng
+	y := rng.Float32()*2.0 - 1.0
+	if sphere.IsHemisphere && y < 0.0 {
+		y = -y
+	}
+	angle := rng.Float64() * math.Pi * 2.0
+	ring := float32(math.Sqrt(float64(1.0 - y*y)))
+	dir := mgl.Vec3{ring * float32(math.Cos(angle)), y, ring * float32(math.Sin(angle))}
+
+	// the cube root keeps the points evenly spread through the volume
+	distance := sphere.Radius * float32(math.Cbrt(rng.Float64()))
+
+	p.Velocity = sphere.Owner.Properties.Rotation.Rotate(dir)
+	p.Location = sphere.Owner.Properties.Rotation.Rotate(dir.Mul(distance))
+
+	return p
+}
+
+// CreateRenderable creates a cached renderable for the spawner that represents
+// the spawning volume for particles.
+func (sphere *SphereSpawner) CreateRenderable() *fizzle.Renderable {
+	const circleSegments = 16
+
+	if sphere.IsHemisphere {
+		sphere.volumeRenderable = fizzle.CreateWireframeHemisphere(0, 0, 0, sphere.Radius, circleSegments)
+	} else {
+		sphere.volumeRenderable = fizzle.CreateWireframeSphere(0, 0, 0, sphere.Radius, circleSegments)
+	}
+	return sphere.volumeRenderable
+}
+
+// DestroyRenderable destroys the cached renderable of the spawning volume.
+func (sphere *SphereSpawner) DestroyRenderable() {
+	if sphere.volumeRenderable != nil {
+		sphere.volumeRenderable.Destroy()
+		sphere.volumeRenderable = nil
+	}
+}
+
+// DrawSpawnVolume renders a visual representation of the particle spawning volume.
+func (sphere *SphereSpawner) DrawSpawnVolume(r renderer.Renderer, shader *fizzle.RenderShader, projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	if sphere.volumeRenderable == nil {
+		sphere.CreateRenderable()
+	}
+
+	// sync the position and rotation
+	sphere.volumeRenderable.Location = sphere.Owner.Properties.Origin
+	sphere.volumeRenderable.LocalRotation = sphere.Owner.Properties.Rotation
+
+	r.DrawLines(sphere.volumeRenderable, shader, nil, projection, view, camera)
+}
@@ -0,0 +1,258 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package particles
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+var (
+	// TrailVertShader330 is the GLSL vertex shader program for drawing the
+	// particle trails of an emitter.
+	TrailVertShader330 = `#version 330
+  uniform mat4 MVP;
+  in vec3 POSITION;
+  in vec4 COLOR;
+  in vec2 UV;
+
+  out vec4 vs_color;
+  out vec2 vs_uv;
+
+  void main()
+  {
+    vs_color = COLOR;
+    vs_uv = UV;
+    gl_Position = MVP * vec4(POSITION, 1.0);
+  }`
+
+	// TrailFragShader330 is the GLSL fragment shader program for drawing the
+	// particle trails of an emitter.
+	TrailFragShader330 = `#version 330
+  uniform sampler2D TEX;
+  uniform float EXPOSURE;
+  in vec4 vs_color;
+  in vec2 vs_uv;
+
+  out vec4 frag_color;
+
+  void main()
+  {
+    frag_color = vs_color * texture(TEX, vs_uv) * vec4(vec3(EXPOSURE), 1.0);
+  }`
+)
+
+const (
+	// trailVertexFloats is the number of floats per trail vertex:
+	// position (3), color (4), uv (2).
+	trailVertexFloats = 3 + 4 + 2
+	trailStride       = floatSize * trailVertexFloats
+)
+
+// getTrailWidth returns the width of a trail at t, which goes from 0 at the
+// particle to 1 at the end of the trail, by linearly interpolating the
+// TrailWidth curve.
+func (props *EmitterProperties) getTrailWidth(t float32) float32 {
+	count := len(props.TrailWidth)
+	switch {
+	case count == 0:
+		return 1.0
+	case count == 1 || t <= 0.0:
+		return props.TrailWidth[0]
+	case t >= 1.0:
+		return props.TrailWidth[count-1]
+	}
+
+	scaled := t * float32(count-1)
+	i := int(scaled)
+	frac := scaled - float32(i)
+	return props.TrailWidth[i]*(1.0-frac) + props.TrailWidth[i+1]*frac
+}
+
+// updateTrails records the current location of every particle at the
+// start of its trail once TrailInterval seconds have passed.
+func (e *Emitter) updateTrails(frameDelta float64) {
+	maxPoints := int(e.Properties.TrailSegments)
+	if maxPoints == 0 {
+		return
+	}
+
+	e.timeSinceTrail += frameDelta
+	if e.timeSinceTrail < e.Properties.TrailInterval {
+		return
+	}
+	e.timeSinceTrail = 0.0
+
+	for i := range e.Particles {
+		p := &e.Particles[i]
+		if len(p.Trail) < maxPoints {
+			p.Trail = append(p.Trail, p.Location)
+		} else if len(p.Trail) > maxPoints {
+			p.Trail = p.Trail[:maxPoints]
+		}
+
+		// shift the older points down the trail
+		copy(p.Trail[1:], p.Trail[:len(p.Trail)-1])
+		p.Trail[0] = p.Location
+	}
+}
+
+// renderTrailsToVBO builds the ribbon mesh for all of the particle trails as
+// camera facing triangles and returns the number of vertices buffered.
+func (e *Emitter) renderTrailsToVBO(cameraLocation mgl.Vec3) int {
+	props := &e.Properties
+	buffer := e.trailBuffer[:0]
+
+	for pi := range e.Particles {
+		p := &e.Particles[pi]
+		if len(p.Trail) == 0 {
+			continue
+		}
+
+		// the trail starts at the particle and runs through the recorded points
+		points := make([]mgl.Vec3, 0, len(p.Trail)+1)
+		points = append(points, p.Location)
+		points = append(points, p.Trail...)
+		segments := len(points) - 1
+
+		// calculate the edges of the ribbon for each point, facing the camera
+		var side mgl.Vec3
+		left := make([]mgl.Vec3, len(points))
+		right := make([]mgl.Vec3, len(points))
+		for i, point := range points {
+			var dir mgl.Vec3
+			if i < segments {
+				dir = point.Sub(points[i+1])
+			} else {
+				dir = points[i-1].Sub(point)
+			}
+
+			newSide := dir.Cross(cameraLocation.Sub(point))
+			if newSide.Len() > 0.0 {
+				side = newSide.Normalize()
+			}
+
+			t := float32(i) / float32(segments)
+			halfWidth := side.Mul(props.getTrailWidth(t) * 0.5)
+			left[i] = point.Add(halfWidth)
+			right[i] = point.Sub(halfWidth)
+		}
+
+		// write out two triangles for each segment
+		color := e.getParticleColor(p)
+		for i := 0; i < segments; i++ {
+			t0 := float32(i) / float32(segments)
+			t1 := float32(i+1) / float32(segments)
+			u0, u1 := float32(i), float32(i+1)
+			if props.TrailStretchUV {
+				u0, u1 = t0, t1
+			}
+
+			c0 := color
+			c0[3] *= 1.0 - t0
+			c1 := color
+			c1[3] *= 1.0 - t1
+
+			buffer = appendTrailVertex(buffer, left[i], c0, u0, 0.0)
+			buffer = appendTrailVertex(buffer, right[i], c0, u0, 1.0)
+			buffer = appendTrailVertex(buffer, right[i+1], c1, u1, 1.0)
+
+			buffer = appendTrailVertex(buffer, left[i], c0, u0, 0.0)
+			buffer = appendTrailVertex(buffer, right[i+1], c1, u1, 1.0)
+			buffer = appendTrailVertex(buffer, left[i+1], c1, u1, 0.0)
+		}
+	}
+	e.trailBuffer = buffer
+
+	// we didn't buffer anything
+	if len(buffer) <= 0 {
+		return 0
+	}
+
+	// buffer the data
+	e.Owner.gfx.BindBuffer(graphics.ARRAY_BUFFER, e.trailVBO)
+	e.Owner.gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(buffer), e.Owner.gfx.Ptr(&buffer[0]), graphics.STREAM_DRAW)
+
+	return len(buffer) / trailVertexFloats
+}
+
+// getTrailTexture returns the texture to draw the trails with, falling
+// back on the particle texture if no trail texture was loaded.
+func (e *Emitter) getTrailTexture() graphics.Texture {
+	if e.TrailTexture != 0 {
+		return e.TrailTexture
+	}
+	return e.Texture
+}
+
+// appendTrailVertex adds the interleaved data for one trail vertex to the buffer.
+func appendTrailVertex(buffer []float32, location mgl.Vec3, color mgl.Vec4, u, v float32) []float32 {
+	buffer = append(buffer, location[0], location[1], location[2])
+	buffer = append(buffer, color[0], color[1], color[2], color[3])
+	buffer = append(buffer, u, v)
+	return buffer
+}
+
+// drawTrails renders the trails of the particles if the emitter has
+// trails enabled and a TrailShader set.
+func (e *Emitter) drawTrails(mvp mgl.Mat4, view mgl.Mat4) {
+	if e.Properties.TrailSegments == 0 || e.TrailShader == 0 {
+		return
+	}
+
+	// trail points may be relative to the emitter, so move the camera there too
+	cameraLocation := view.Inv().Col(3).Vec3().Sub(e.getParticleOrigin())
+
+	gfx := e.Owner.gfx
+	gfx.BindVertexArray(e.trailVAO)
+
+	vertexCount := e.renderTrailsToVBO(cameraLocation)
+	if vertexCount <= 0 {
+		gfx.BindVertexArray(0)
+		return
+	}
+
+	gfx.UseProgram(e.TrailShader)
+
+	mvpMatrix := gfx.GetUniformLocation(e.TrailShader, "MVP")
+	if mvpMatrix >= 0 {
+		gfx.UniformMatrix4fv(mvpMatrix, 1, false, mvp)
+	}
+
+	shaderTex0 := gfx.GetUniformLocation(e.TrailShader, "TEX")
+	if shaderTex0 >= 0 {
+		gfx.ActiveTexture(graphics.TEXTURE0)
+		gfx.BindTexture(graphics.TEXTURE_2D, e.getTrailTexture())
+		gfx.Uniform1i(shaderTex0, 0)
+	}
+
+	e.bindExposure(e.TrailShader)
+
+	const posOffset = 0
+	const colorOffset = floatSize * 3
+	const uvOffset = floatSize * 7
+
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, e.trailVBO)
+	shaderPosition := gfx.GetAttribLocation(e.TrailShader, "POSITION")
+	if shaderPosition >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderPosition))
+		gfx.VertexAttribPointer(uint32(shaderPosition), 3, graphics.FLOAT, false, trailStride, gfx.PtrOffset(posOffset))
+	}
+
+	shaderColor := gfx.GetAttribLocation(e.TrailShader, "COLOR")
+	if shaderColor >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderColor))
+		gfx.VertexAttribPointer(uint32(shaderColor), 4, graphics.FLOAT, false, trailStride, gfx.PtrOffset(colorOffset))
+	}
+
+	shaderUV := gfx.GetAttribLocation(e.TrailShader, "UV")
+	if shaderUV >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderUV))
+		gfx.VertexAttribPointer(uint32(shaderUV), 2, graphics.FLOAT, false, trailStride, gfx.PtrOffset(uvOffset))
+	}
+
+	gfx.DrawArrays(graphics.TRIANGLES, 0, int32(vertexCount))
+
+	gfx.BindVertexArray(0)
+}
@@ -0,0 +1,146 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package physics
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/component"
+	glider "github.com/tbogdala/glider"
+)
+
+// Shape is a glider collider created from a collision reference.
+type Shape struct {
+	// Ref is the collision reference the shape was created from.
+	Ref *component.CollisionRef
+
+	// Collider is the glider collider in world space; either a
+	// *glider.AABBox or a *glider.Sphere depending on Ref.Type.
+	Collider glider.Collider
+
+	// Body is the body the shape belongs to.
+	Body *Body
+}
+
+// Body is a set of shapes that follow a Renderable.
+type Body struct {
+	// Renderable is the object the shapes follow. If it is nil the
+	// collision references are in world space.
+	Renderable *fizzle.Renderable
+
+	// Shapes are the collision shapes of the body.
+	Shapes []*Shape
+
+	// Static bodies don't get synced by World.Update. Call Sync directly
+	// if a static body gets moved.
+	Static bool
+
+	// UserData is for client code to link the body back to its own
+	// objects, such as an entity.
+	UserData interface{}
+}
+
+// newBody creates the shapes for the collision references. Unknown
+// collider types are skipped.
+func newBody(refs []*component.CollisionRef, r *fizzle.Renderable) *Body {
+	body := new(Body)
+	body.Renderable = r
+	for _, ref := range refs {
+		shape := &Shape{Ref: ref, Body: body}
+		switch ref.Type {
+		case component.ColliderTypeAABB:
+			shape.Collider = glider.NewAABBox()
+		case component.ColliderTypeSphere:
+			shape.Collider = glider.NewSphere()
+		default:
+			continue
+		}
+		body.Shapes = append(body.Shapes, shape)
+	}
+	return body
+}
+
+// Sync moves the shapes of the body into world space using the transform
+// of the Renderable.
+func (b *Body) Sync() {
+	transform := mgl.Ident4()
+	if b.Renderable != nil {
+		transform = b.Renderable.GetTransformMat4()
+	}
+
+	for _, shape := range b.Shapes {
+		switch collider := shape.Collider.(type) {
+		case *glider.AABBox:
+			collider.Min, collider.Max = transformBox(
+				shape.Ref.Min.Add(shape.Ref.Offset),
+				shape.Ref.Max.Add(shape.Ref.Offset),
+				transform)
+		case *glider.Sphere:
+			collider.Center = mgl.TransformCoordinate(shape.Ref.Offset, transform)
+			collider.Radius = shape.Ref.Radius * getMaxScale(transform)
+		}
+	}
+}
+
+// hasTag returns true if the shape's collision reference has one of the
+// tags or if no tags were given.
+func (s *Shape) hasTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, t := range tags {
+		for _, st := range s.Ref.Tags {
+			if t == st {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// transformBox returns the axis aligned box that encloses the transformed
+// corners of the box.
+func transformBox(min, max mgl.Vec3, transform mgl.Mat4) (mgl.Vec3, mgl.Vec3) {
+	var outMin, outMax mgl.Vec3
+	for i := 0; i < 8; i++ {
+		corner := min
+		if i&1 != 0 {
+			corner[0] = max[0]
+		}
+		if i&2 != 0 {
+			corner[1] = max[1]
+		}
+		if i&4 != 0 {
+			corner[2] = max[2]
+		}
+		p := mgl.TransformCoordinate(corner, transform)
+		if i == 0 {
+			outMin, outMax = p, p
+			continue
+		}
+		for axis := 0; axis < 3; axis++ {
+			if p[axis] < outMin[axis] {
+				outMin[axis] = p[axis]
+			}
+			if p[axis] > outMax[axis] {
+				outMax[axis] = p[axis]
+			}
+		}
+	}
+	return outMin, outMax
+}
+
+// getMaxScale returns the largest scale of the transform's axes so that
+// spheres still enclose the object under non-uniform scaling.
+func getMaxScale(transform mgl.Mat4) float32 {
+	maxScale := transform.Col(0).Vec3().Len()
+	if s := transform.Col(1).Vec3().Len(); s > maxScale {
+		maxScale = s
+	}
+	if s := transform.Col(2).Vec3().Len(); s > maxScale {
+		maxScale = s
+	}
+	return maxScale
+}
@@ -0,0 +1,78 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package physics sets up glider collision shapes from the CollisionRef
+entries of components and keeps them in world space with the Renderables
+they belong to.
+
+Add a component instance to a World with AddComponent, call World.Update
+once a frame after moving Renderables and then query the shapes with
+Raycast, OverlapSphere and OverlapAABB. Queries can be limited to shapes
+whose CollisionRef has one of a set of tags.
+
+AABB colliders stay axis aligned, so for a rotated Renderable the shape is
+the box that encloses the rotated collider.
+
+*/
+package physics
+
+import (
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/component"
+)
+
+// World is the set of bodies that queries run against.
+type World struct {
+	// Bodies are all of the bodies added to the world.
+	Bodies []*Body
+}
+
+// NewWorld creates a new empty world.
+func NewWorld() *World {
+	w := new(World)
+	w.Bodies = []*Body{}
+	return w
+}
+
+// AddComponent creates a body with shapes for all of the component's
+// collision references that follows the Renderable, which should be an
+// instance of the component. The shapes are synced to the Renderable's
+// current transform. Nil is returned if the component has no collisions.
+func (w *World) AddComponent(comp *component.Component, r *fizzle.Renderable) *Body {
+	if comp == nil || len(comp.Collisions) <= 0 {
+		return nil
+	}
+	return w.AddCollisions(comp.Collisions, r)
+}
+
+// AddCollisions creates a body with shapes for the collision references
+// that follows the Renderable. If the Renderable is nil the collision
+// references are treated as being in world space.
+func (w *World) AddCollisions(refs []*component.CollisionRef, r *fizzle.Renderable) *Body {
+	body := newBody(refs, r)
+	body.Sync()
+	w.Bodies = append(w.Bodies, body)
+	return body
+}
+
+// RemoveBody removes the body from the world.
+func (w *World) RemoveBody(body *Body) {
+	for i, other := range w.Bodies {
+		if other == body {
+			w.Bodies = append(w.Bodies[:i], w.Bodies[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update syncs the shapes of all of the bodies that aren't static to the
+// transforms of their Renderables.
+func (w *World) Update() {
+	for _, body := range w.Bodies {
+		if !body.Static {
+			body.Sync()
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package physics
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	glider "github.com/tbogdala/glider"
+)
+
+// RaycastHit is the result of a ray hitting a shape.
+type RaycastHit struct {
+	// Shape is the shape that was hit.
+	Shape *Shape
+
+	// Distance is the distance along the ray to the hit.
+	Distance float32
+
+	// Point is where the ray hit the shape in world space.
+	Point mgl.Vec3
+}
+
+// Raycast finds the closest shape hit by the ray within maxDistance,
+// considering only shapes with one of the tags if any are given. The
+// direction doesn't have to be normalized. A ray starting inside a shape
+// hits it at distance 0.
+func (w *World) Raycast(origin, direction mgl.Vec3, maxDistance float32, tags ...string) (RaycastHit, bool) {
+	var closest RaycastHit
+	found := false
+	if direction.Len() == 0.0 {
+		return closest, false
+	}
+	direction = direction.Normalize()
+
+	for _, body := range w.Bodies {
+		for _, shape := range body.Shapes {
+			if !shape.hasTag(tags) {
+				continue
+			}
+
+			var dist float32
+			var hit bool
+			switch collider := shape.Collider.(type) {
+			case *glider.AABBox:
+				dist, hit = rayVsBox(origin, direction, collider.Min, collider.Max)
+			case *glider.Sphere:
+				dist, hit = rayVsSphere(origin, direction, collider.Center, collider.Radius)
+			}
+			if !hit || dist > maxDistance || (found && dist >= closest.Distance) {
+				continue
+			}
+			closest.Shape = shape
+			closest.Distance = dist
+			closest.Point = origin.Add(direction.Mul(dist))
+			found = true
+		}
+	}
+
+	return closest, found
+}
+
+// OverlapSphere returns all of the shapes that intersect the sphere,
+// considering only shapes with one of the tags if any are given.
+func (w *World) OverlapSphere(center mgl.Vec3, radius float32, tags ...string) []*Shape {
+	var shapes []*Shape
+	for _, body := range w.Bodies {
+		for _, shape := range body.Shapes {
+			if !shape.hasTag(tags) {
+				continue
+			}
+
+			var overlap bool
+			switch collider := shape.Collider.(type) {
+			case *glider.AABBox:
+				overlap = sphereVsBox(center, radius, collider.Min, collider.Max)
+			case *glider.Sphere:
+				r := radius + collider.Radius
+				overlap = center.Sub(collider.Center).LenSqr() <= r*r
+			}
+			if overlap {
+				shapes = append(shapes, shape)
+			}
+		}
+	}
+	return shapes
+}
+
+// OverlapAABB returns all of the shapes that intersect the axis aligned box,
+// considering only shapes with one of the tags if any are given.
+func (w *World) OverlapAABB(min, max mgl.Vec3, tags ...string) []*Shape {
+	var shapes []*Shape
+	for _, body := range w.Bodies {
+		for _, shape := range body.Shapes {
+			if !shape.hasTag(tags) {
+				continue
+			}
+
+			var overlap bool
+			switch collider := shape.Collider.(type) {
+			case *glider.AABBox:
+				overlap = min[0] <= collider.Max[0] && max[0] >= collider.Min[0] &&
+					min[1] <= collider.Max[1] && max[1] >= collider.Min[1] &&
+					min[2] <= collider.Max[2] && max[2] >= collider.Min[2]
+			case *glider.Sphere:
+				overlap = sphereVsBox(collider.Center, collider.Radius, min, max)
+			}
+			if overlap {
+				shapes = append(shapes, shape)
+			}
+		}
+	}
+	return shapes
+}
+
+// rayVsBox does a slab test of the ray against the box and returns the
+// distance to the hit. The direction must be normalized.
+func rayVsBox(origin, direction, min, max mgl.Vec3) (float32, bool) {
+	tMin := float32(0.0)
+	tMax := float32(math.MaxFloat32)
+	for axis := 0; axis < 3; axis++ {
+		if direction[axis] == 0.0 {
+			if origin[axis] < min[axis] || origin[axis] > max[axis] {
+				return 0.0, false
+			}
+			continue
+		}
+
+		invDir := 1.0 / direction[axis]
+		t1 := (min[axis] - origin[axis]) * invDir
+		t2 := (max[axis] - origin[axis]) * invDir
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0.0, false
+		}
+	}
+	return tMin, true
+}
+
+// rayVsSphere returns the distance along the ray to the sphere. The
+// direction must be normalized.
+func rayVsSphere(origin, direction, center mgl.Vec3, radius float32) (float32, bool) {
+	toOrigin := origin.Sub(center)
+	c := toOrigin.LenSqr() - radius*radius
+	if c <= 0.0 {
+		// the ray starts inside the sphere
+		return 0.0, true
+	}
+
+	b := toOrigin.Dot(direction)
+	if b > 0.0 {
+		// outside and pointing away
+		return 0.0, false
+	}
+	discriminant := b*b - c
+	if discriminant < 0.0 {
+		return 0.0, false
+	}
+	return -b - float32(math.Sqrt(float64(discriminant))), true
+}
+
+// sphereVsBox returns true if the sphere intersects the box.
+func sphereVsBox(center mgl.Vec3, radius float32, min, max mgl.Vec3) bool {
+	var distSqr float32
+	for axis := 0; axis < 3; axis++ {
+		if center[axis] < min[axis] {
+			d := min[axis] - center[axis]
+			distSqr += d * d
+		} else if center[axis] > max[axis] {
+			d := center[axis] - max[axis]
+			distSqr += d * d
+		}
+	}
+	return distSqr <= radius*radius
+}
@@ -0,0 +1,147 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// PickHit describes a Renderable found by a Picker.
+type PickHit struct {
+	// Renderable is the closest renderable hit by the ray. If it is a child
+	// renderable, Root is the top level renderable that was added to the
+	// Picker, otherwise both are the same.
+	Renderable *Renderable
+	Root       *Renderable
+
+	// Distance is how far along the ray the hit was and Location is the
+	// world space location of the hit.
+	Distance float32
+	Location mgl.Vec3
+}
+
+// Picker finds the Renderable objects under the cursor by testing a ray from
+// the camera against the bounding boxes of the renderables it tracks. The
+// bounding box is oriented with the renderable's transform, so rotated and
+// scaled objects pick correctly.
+type Picker struct {
+	// Renderables are the objects that can be picked. Child renderables
+	// are tested as well.
+	Renderables []*Renderable
+}
+
+// NewPicker creates a new picker with no renderables to pick from.
+func NewPicker() *Picker {
+	p := new(Picker)
+	p.Renderables = make([]*Renderable, 0)
+	return p
+}
+
+// Add makes the renderable able to be picked.
+func (p *Picker) Add(r *Renderable) {
+	p.Renderables = append(p.Renderables, r)
+}
+
+// Remove stops the renderable from being picked.
+func (p *Picker) Remove(r *Renderable) {
+	for i, pr := range p.Renderables {
+		if pr == r {
+			p.Renderables = append(p.Renderables[:i], p.Renderables[i+1:]...)
+			return
+		}
+	}
+}
+
+// Pick finds the closest visible renderable under the screen coordinate, in
+// pixels with the origin at the top-left of a screen of width by height
+// pixels. False is returned if nothing was hit.
+func (p *Picker) Pick(c Camera, x, y, width, height float32, projection mgl.Mat4) (PickHit, bool) {
+	origin, direction := ScreenToRay(c, x, y, width, height, projection)
+	return p.PickRay(origin, direction)
+}
+
+// PickRay finds the closest visible renderable hit by a ray in world space.
+// False is returned if nothing was hit.
+func (p *Picker) PickRay(origin, direction mgl.Vec3) (PickHit, bool) {
+	var closest PickHit
+	found := false
+	for _, root := range p.Renderables {
+		root.Map(func(r *Renderable) {
+			if !r.IsVisible || r.IsGroup || !isRenderableVisible(r) {
+				return
+			}
+
+			dist, hit := rayVsRenderable(origin, direction, r)
+			if hit && (!found || dist < closest.Distance) {
+				found = true
+				closest.Renderable = r
+				closest.Root = root
+				closest.Distance = dist
+			}
+		})
+	}
+
+	if found {
+		closest.Location = origin.Add(direction.Mul(closest.Distance))
+	}
+	return closest, found
+}
+
+// isRenderableVisible returns false if any parent of the renderable is hidden.
+func isRenderableVisible(r *Renderable) bool {
+	for parent := r.Parent; parent != nil; parent = parent.Parent {
+		if !parent.IsVisible {
+			return false
+		}
+	}
+	return true
+}
+
+// rayVsRenderable tests a ray in world space against the bounding box of the
+// renderable and returns the distance along the ray to the hit.
+func rayVsRenderable(origin, direction mgl.Vec3, r *Renderable) (float32, bool) {
+	// move the ray into the model space of the renderable. the direction is
+	// not normalized afterwards so the distance stays in world units.
+	invTransform := r.GetTransformMat4().Inv()
+	localOrigin := invTransform.Mul4x1(origin.Vec4(1.0)).Vec3()
+	localDirection := invTransform.Mul4x1(direction.Vec4(0.0)).Vec3()
+
+	bounds := r.GetPickBounds()
+	return rayVsAABB(localOrigin, localDirection, bounds.Bottom, bounds.Top, math.MaxFloat32)
+}
+
+// rayVsAABB tests a ray against an axis aligned box and returns the distance
+// along the ray to where it enters the box, or zero if the origin is inside.
+// False is returned if the box is not hit within maxDistance.
+func rayVsAABB(origin, direction, min, max mgl.Vec3, maxDistance float32) (float32, bool) {
+	tNear := float32(0.0)
+	tFar := maxDistance
+	for i := 0; i < 3; i++ {
+		if direction[i] == 0.0 {
+			if origin[i] < min[i] || origin[i] > max[i] {
+				return 0.0, false
+			}
+			continue
+		}
+
+		t1 := (min[i] - origin[i]) / direction[i]
+		t2 := (max[i] - origin[i]) / direction[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tNear {
+			tNear = t1
+		}
+		if t2 < tFar {
+			tFar = t2
+		}
+		if tNear > tFar {
+			return 0.0, false
+		}
+	}
+
+	return tNear, true
+}
@@ -0,0 +1,249 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// DefaultLUTSize is the size of the identity LUT used by a ColorGrading
+	// effect created without one.
+	DefaultLUTSize = 16
+
+	// maxLUTSize is the largest LUT size accepted when loading strips.
+	maxLUTSize = 64
+)
+
+// LUT is a 3D color lookup table used for color grading. The red, green and
+// blue of a color are the coordinates of the graded color in the texture.
+type LUT struct {
+	// Texture is the OpenGL 3D texture holding the table.
+	Texture graphics.Texture
+
+	// Size is the number of entries along each side of the table.
+	Size int32
+}
+
+// LoadLUT loads a LUT from a PNG strip, the standard layout that color
+// grading tools export, such as a 256x16 image for a size of 16 or a
+// 1024x32 image for a size of 32. The strip is a row of square slices, one
+// for each blue value, where red increases to the right and green increases
+// downwards.
+func LoadLUT(path string) (*LUT, error) {
+	imgFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the LUT file %s.\n%v", path, err)
+	}
+	defer imgFile.Close()
+
+	img, err := png.Decode(imgFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the LUT file %s.\n%v", path, err)
+	}
+
+	return LoadLUTFromImage(img)
+}
+
+// LoadLUTFromImage creates a LUT from a strip image that was decoded
+// elsewhere. See LoadLUT for the layout of the strip.
+func LoadLUTFromImage(img image.Image) (*LUT, error) {
+	bounds := img.Bounds()
+	size := bounds.Dy()
+	if size < 2 || size > maxLUTSize || bounds.Dx() != size*size {
+		return nil, fmt.Errorf("Failed to load the LUT because a %dx%d image is not a strip of square slices.", bounds.Dx(), bounds.Dy())
+	}
+
+	data := make([]uint8, size*size*size*4)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				c := img.At(bounds.Min.X+b*size+r, bounds.Min.Y+g)
+				cr, cg, cb, _ := c.RGBA()
+				i := ((b*size+g)*size + r) * 4
+				data[i] = uint8(cr >> 8)
+				data[i+1] = uint8(cg >> 8)
+				data[i+2] = uint8(cb >> 8)
+				data[i+3] = 255
+			}
+		}
+	}
+
+	return newLUT(int32(size), data), nil
+}
+
+// NewIdentityLUT creates a LUT of the size specified that leaves colors
+// unchanged. It can be used as a starting point or to fade grading out.
+func NewIdentityLUT(size int32) *LUT {
+	n := int(size)
+	data := make([]uint8, n*n*n*4)
+	for b := 0; b < n; b++ {
+		for g := 0; g < n; g++ {
+			for r := 0; r < n; r++ {
+				i := ((b*n+g)*n + r) * 4
+				data[i] = uint8(r * 255 / (n - 1))
+				data[i+1] = uint8(g * 255 / (n - 1))
+				data[i+2] = uint8(b * 255 / (n - 1))
+				data[i+3] = 255
+			}
+		}
+	}
+
+	return newLUT(size, data)
+}
+
+// newLUT uploads the RGBA table data, ordered by blue, green and then red,
+// into a new 3D texture.
+func newLUT(size int32, data []uint8) *LUT {
+	gfx := fizzle.GetGraphics()
+	lut := new(LUT)
+	lut.Size = size
+	lut.Texture = gfx.GenTexture()
+
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_3D, lut.Texture)
+	gfx.TexStorage3D(graphics.TEXTURE_3D, 1, graphics.RGBA8, size, size, size)
+	gfx.TexSubImage3D(graphics.TEXTURE_3D, 0, 0, 0, 0, size, size, size, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(data))
+	gfx.TexParameteri(graphics.TEXTURE_3D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_3D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_3D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_3D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_3D, graphics.TEXTURE_WRAP_R, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_3D, 0)
+
+	return lut
+}
+
+// Destroy deletes the LUT texture from OpenGL.
+func (lut *LUT) Destroy() {
+	fizzle.GetGraphics().DeleteTexture(lut.Texture)
+}
+
+// ColorGrading is an Effect that remaps the colors of the scene through a
+// LUT. It can cross fade to another LUT over time, such as for shifting the
+// mood between day and night.
+type ColorGrading struct {
+	// Enabled indicates whether or not the effect gets applied.
+	Enabled bool
+
+	// Weight blends between the original colors at 0.0 and the graded
+	// colors at 1.0.
+	Weight float32
+
+	// LUT is the table the colors are graded with. The LUTs are not owned
+	// by the effect and do not get destroyed with it.
+	LUT *LUT
+
+	// NextLUT is the table being faded to by TransitionTo, if any.
+	NextLUT *LUT
+
+	// Mix is how far the transition to NextLUT has progressed in the range
+	// of [0, 1].
+	Mix float32
+
+	// transitionDuration is the length of the transition in seconds.
+	transitionDuration float32
+
+	// identity is the LUT used when none is set.
+	identity *LUT
+
+	// shader is the shader used to draw the effect.
+	shader *fizzle.RenderShader
+}
+
+// NewColorGrading creates a new ColorGrading effect using the LUT, which
+// can be nil to start with an identity table.
+func NewColorGrading(lut *LUT) (*ColorGrading, error) {
+	shader, err := fizzle.LoadShaderProgram(PostShaderV330, ColorGradeShaderF330, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile the color grading shader.\n%v", err)
+	}
+
+	cg := new(ColorGrading)
+	cg.Enabled = true
+	cg.Weight = 1.0
+	cg.LUT = lut
+	cg.identity = NewIdentityLUT(DefaultLUTSize)
+	cg.shader = shader
+	return cg, nil
+}
+
+// IsEnabled returns true if the effect should be applied.
+func (cg *ColorGrading) IsEnabled() bool {
+	return cg.Enabled
+}
+
+// Destroy deletes the shader and identity LUT from OpenGL.
+func (cg *ColorGrading) Destroy() {
+	cg.shader.Destroy()
+	cg.identity.Destroy()
+}
+
+// SetLUT swaps the LUT immediately, cancelling any transition.
+func (cg *ColorGrading) SetLUT(lut *LUT) {
+	cg.LUT = lut
+	cg.NextLUT = nil
+	cg.Mix = 0.0
+}
+
+// TransitionTo starts a cross fade from the current LUT to the one passed
+// in lasting the number of seconds specified. Update must be called each
+// frame to advance it.
+func (cg *ColorGrading) TransitionTo(lut *LUT, seconds float32) {
+	if seconds <= 0.0 {
+		cg.SetLUT(lut)
+		return
+	}
+
+	cg.NextLUT = lut
+	cg.Mix = 0.0
+	cg.transitionDuration = seconds
+}
+
+// Update advances the transition by the frame time in seconds. Once it
+// finishes, NextLUT becomes the LUT.
+func (cg *ColorGrading) Update(frameDelta float32) {
+	if cg.NextLUT == nil {
+		return
+	}
+
+	cg.Mix += frameDelta / cg.transitionDuration
+	if cg.Mix >= 1.0 {
+		cg.SetLUT(cg.NextLUT)
+	}
+}
+
+// Apply draws the source with the colors graded.
+func (cg *ColorGrading) Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target) {
+	lut := cg.LUT
+	if lut == nil {
+		lut = cg.identity
+	}
+	next := cg.NextLUT
+	if next == nil {
+		next = cg.identity
+	}
+
+	s.DrawQuad(r, cg.shader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+		gfx := r.GetGraphics()
+		BindTexture(gfx, shader, "POST_SOURCE", source.Color, texturesBound)
+		bindTextureTarget(gfx, shader, "POST_LUT", graphics.TEXTURE_3D, lut.Texture, texturesBound)
+		bindTextureTarget(gfx, shader, "POST_LUT_NEXT", graphics.TEXTURE_3D, next.Texture, texturesBound)
+		setUniform1f(gfx, shader, "POST_LUT_SIZE", float32(lut.Size))
+		setUniform1f(gfx, shader, "POST_LUT_NEXT_SIZE", float32(next.Size))
+		if cg.NextLUT != nil {
+			setUniform1f(gfx, shader, "POST_LUT_MIX", cg.Mix)
+		} else {
+			setUniform1f(gfx, shader, "POST_LUT_MIX", 0.0)
+		}
+		setUniform1f(gfx, shader, "POST_WEIGHT", cg.Weight)
+	})
+}
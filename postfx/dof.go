@@ -0,0 +1,103 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// minSampleSpacing keeps the gather loop of the depth of field shader
+	// from running away with a spacing of zero.
+	minSampleSpacing = 0.1
+)
+
+// DepthOfField is an Effect that blurs the parts of the scene that are out
+// of focus. The blur size comes from the depth of the scene, so the Near
+// and Far planes need to match the projection the scene was drawn with.
+type DepthOfField struct {
+	// Enabled indicates whether or not the effect gets applied.
+	Enabled bool
+
+	// FocalDistance is the distance from the camera that is in focus.
+	FocalDistance float32
+
+	// FocalRange is the distance in front of and behind the FocalDistance
+	// that stays completely sharp.
+	FocalRange float32
+
+	// Aperture scales how quickly the blur grows away from the focal range;
+	// larger values give a shallower depth of field.
+	Aperture float32
+
+	// MaxRadius is the largest blur radius in pixels.
+	MaxRadius float32
+
+	// SampleSpacing controls how densely the blur gets sampled. Larger
+	// values take fewer samples and are faster but grainier.
+	SampleSpacing float32
+
+	// Near and Far are the near and far planes of the projection.
+	Near float32
+	Far  float32
+
+	// shader is the shader used to draw the effect.
+	shader *fizzle.RenderShader
+}
+
+// NewDepthOfField creates a new DepthOfField effect for a projection with
+// the near and far planes specified, focused 10 units from the camera.
+func NewDepthOfField(near, far float32) (*DepthOfField, error) {
+	shader, err := fizzle.LoadShaderProgram(PostShaderV330, DepthOfFieldShaderF330, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile the depth of field shader.\n%v", err)
+	}
+
+	dof := new(DepthOfField)
+	dof.Enabled = true
+	dof.FocalDistance = 10.0
+	dof.FocalRange = 2.0
+	dof.Aperture = 1.0
+	dof.MaxRadius = 12.0
+	dof.SampleSpacing = 1.0
+	dof.Near = near
+	dof.Far = far
+	dof.shader = shader
+	return dof, nil
+}
+
+// IsEnabled returns true if the effect should be applied.
+func (dof *DepthOfField) IsEnabled() bool {
+	return dof.Enabled
+}
+
+// Destroy deletes the shader from OpenGL.
+func (dof *DepthOfField) Destroy() {
+	dof.shader.Destroy()
+}
+
+// Apply draws the source blurred by the depth of the scene.
+func (dof *DepthOfField) Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target) {
+	spacing := dof.SampleSpacing
+	if spacing < minSampleSpacing {
+		spacing = minSampleSpacing
+	}
+
+	s.DrawQuad(r, dof.shader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+		gfx := r.GetGraphics()
+		BindTexture(gfx, shader, "POST_SOURCE", source.Color, texturesBound)
+		BindTexture(gfx, shader, "POST_DEPTH", s.Scene.Depth, texturesBound)
+		setTexelSize(gfx, shader, source)
+		setUniform1f(gfx, shader, "POST_NEAR", dof.Near)
+		setUniform1f(gfx, shader, "POST_FAR", dof.Far)
+		setUniform1f(gfx, shader, "POST_DOF_FOCAL_DISTANCE", dof.FocalDistance)
+		setUniform1f(gfx, shader, "POST_DOF_FOCAL_RANGE", dof.FocalRange)
+		setUniform1f(gfx, shader, "POST_DOF_APERTURE", dof.Aperture)
+		setUniform1f(gfx, shader, "POST_DOF_MAX_RADIUS", dof.MaxRadius)
+		setUniform1f(gfx, shader, "POST_DOF_SPACING", spacing)
+	})
+}
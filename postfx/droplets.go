@@ -0,0 +1,90 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// ScreenDroplets is an Effect that draws rain drops running down the screen
+// as if they were on the lens of the camera. The drops are procedural, so
+// no textures are needed, and Update must be called each frame to animate
+// them.
+type ScreenDroplets struct {
+	// Enabled indicates whether or not the effect gets applied.
+	Enabled bool
+
+	// Amount is the fraction of the cells of the screen that have a drop in
+	// them, from 0.0 for none to 1.0 for all of them.
+	Amount float32
+
+	// Size is the size of the cells the drops are placed in, relative to the
+	// height of the screen.
+	Size float32
+
+	// Speed is how many times a second each drop goes through its life of
+	// landing, sliding down and drying up.
+	Speed float32
+
+	// Refraction is how much the drops bend the image behind them.
+	Refraction float32
+
+	// time is the time in seconds the drops are animated with.
+	time float32
+
+	// shader is the shader used to draw the effect.
+	shader *fizzle.RenderShader
+}
+
+// NewScreenDroplets creates a new ScreenDroplets effect with light rain.
+func NewScreenDroplets() (*ScreenDroplets, error) {
+	shader, err := fizzle.LoadShaderProgram(PostShaderV330, ScreenDropletsShaderF330, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile the screen droplets shader.\n%v", err)
+	}
+
+	drops := new(ScreenDroplets)
+	drops.Enabled = true
+	drops.Amount = 0.3
+	drops.Size = 0.08
+	drops.Speed = 0.2
+	drops.Refraction = 0.5
+	drops.shader = shader
+	return drops, nil
+}
+
+// IsEnabled returns true if the effect should be applied. Nothing is drawn
+// while Amount is 0 either, so the effect can fade in and out with the rain.
+func (drops *ScreenDroplets) IsEnabled() bool {
+	return drops.Enabled && drops.Amount > 0.0
+}
+
+// Destroy deletes the shader from OpenGL.
+func (drops *ScreenDroplets) Destroy() {
+	drops.shader.Destroy()
+}
+
+// Update advances the animation of the drops by frameDelta seconds.
+func (drops *ScreenDroplets) Update(frameDelta float32) {
+	drops.time += frameDelta
+}
+
+// Apply draws the source seen through the drops.
+func (drops *ScreenDroplets) Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target) {
+	aspect := float32(source.Width) / float32(source.Height)
+
+	s.DrawQuad(r, drops.shader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+		gfx := r.GetGraphics()
+		BindTexture(gfx, shader, "POST_SOURCE", source.Color, texturesBound)
+		setUniform1f(gfx, shader, "POST_TIME", drops.time)
+		setUniform1f(gfx, shader, "POST_ASPECT", aspect)
+		setUniform1f(gfx, shader, "POST_DROPLET_AMOUNT", drops.Amount)
+		setUniform1f(gfx, shader, "POST_DROPLET_SIZE", drops.Size)
+		setUniform1f(gfx, shader, "POST_DROPLET_SPEED", drops.Speed)
+		setUniform1f(gfx, shader, "POST_DROPLET_REFRACTION", drops.Refraction)
+	})
+}
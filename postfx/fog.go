@@ -0,0 +1,141 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// VolumetricFog is an Effect that raymarches fog through the scene with the
+// light of the forward renderer scattered in it. The lights, their colors
+// and their shadow maps come from the ActiveLights of the forward renderer
+// the Stack is ended with; other renderers only get the ambient fog color.
+//
+// SetMatrixes must be called each frame with the projection and view the
+// scene was drawn with so that the depth can be turned back into positions.
+type VolumetricFog struct {
+	// Enabled indicates whether or not the effect gets applied.
+	Enabled bool
+
+	// Density is how much light the fog absorbs and scatters per unit of
+	// distance.
+	Density float32
+
+	// Anisotropy is the Henyey-Greenstein phase function parameter in the
+	// range of (-1, 1). Positive values scatter light forward so lights
+	// glow when looking toward them; 0.0 scatters evenly.
+	Anisotropy float32
+
+	// Color is the ambient light scattered by the fog regardless of the
+	// lights.
+	Color mgl.Vec3
+
+	// LightScale scales the light scattered from the active lights.
+	LightScale float32
+
+	// MaxDistance is the farthest distance from the camera that gets
+	// marched, which also limits the fog over the sky.
+	MaxDistance float32
+
+	// Steps is the number of samples taken along each ray.
+	Steps int32
+
+	// Height is the height where the fog starts to thin out.
+	Height float32
+
+	// HeightFalloff is how quickly the fog thins out above Height; 0.0 makes
+	// the fog even at all heights.
+	HeightFalloff float32
+
+	// invViewProjection is the inverse of the view projection matrix.
+	invViewProjection mgl.Mat4
+
+	// cameraPosition is the world position of the camera.
+	cameraPosition mgl.Vec3
+
+	// shader is the shader used to draw the effect.
+	shader *fizzle.RenderShader
+}
+
+// NewVolumetricFog creates a new VolumetricFog effect with a light, evenly
+// spread fog.
+func NewVolumetricFog() (*VolumetricFog, error) {
+	shader, err := fizzle.LoadShaderProgram(PostShaderV330, VolumetricFogShaderF330, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile the volumetric fog shader.\n%v", err)
+	}
+
+	fog := new(VolumetricFog)
+	fog.Enabled = true
+	fog.Density = 0.05
+	fog.Anisotropy = 0.3
+	fog.Color = mgl.Vec3{0.02, 0.02, 0.025}
+	fog.LightScale = 1.0
+	fog.MaxDistance = 100.0
+	fog.Steps = 32
+	fog.invViewProjection = mgl.Ident4()
+	fog.shader = shader
+	return fog, nil
+}
+
+// IsEnabled returns true if the effect should be applied.
+func (fog *VolumetricFog) IsEnabled() bool {
+	return fog.Enabled
+}
+
+// Destroy deletes the shader from OpenGL.
+func (fog *VolumetricFog) Destroy() {
+	fog.shader.Destroy()
+}
+
+// SetMatrixes sets the projection and view matrixes the scene was drawn
+// with for the frame.
+func (fog *VolumetricFog) SetMatrixes(projection, view mgl.Mat4) {
+	fog.invViewProjection = projection.Mul4(view).Inv()
+	fog.cameraPosition = view.Inv().Col(3).Vec3()
+}
+
+// Apply draws the source seen through the fog.
+func (fog *VolumetricFog) Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target) {
+	steps := fog.Steps
+	if steps < 1 {
+		steps = 1
+	}
+	anisotropy := mgl.Clamp(fog.Anisotropy, -0.99, 0.99)
+
+	s.DrawQuad(r, fog.shader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+		gfx := r.GetGraphics()
+		BindTexture(gfx, shader, "POST_SOURCE", source.Color, texturesBound)
+		BindTexture(gfx, shader, "POST_DEPTH", s.Scene.Depth, texturesBound)
+
+		shaderInvVP := shader.GetUniformLocation("POST_INV_VIEW_PROJECTION")
+		if shaderInvVP >= 0 {
+			gfx.UniformMatrix4fv(shaderInvVP, 1, false, fog.invViewProjection)
+		}
+		shaderCamera := shader.GetUniformLocation("POST_CAMERA_POSITION")
+		if shaderCamera >= 0 {
+			gfx.Uniform3f(shaderCamera, fog.cameraPosition[0], fog.cameraPosition[1], fog.cameraPosition[2])
+		}
+		shaderColor := shader.GetUniformLocation("POST_FOG_COLOR")
+		if shaderColor >= 0 {
+			gfx.Uniform3f(shaderColor, fog.Color[0], fog.Color[1], fog.Color[2])
+		}
+		shaderSteps := shader.GetUniformLocation("POST_FOG_STEPS")
+		if shaderSteps >= 0 {
+			gfx.Uniform1i(shaderSteps, steps)
+		}
+
+		setUniform1f(gfx, shader, "POST_FOG_DENSITY", fog.Density)
+		setUniform1f(gfx, shader, "POST_FOG_ANISOTROPY", anisotropy)
+		setUniform1f(gfx, shader, "POST_FOG_LIGHT_SCALE", fog.LightScale)
+		setUniform1f(gfx, shader, "POST_FOG_MAX_DISTANCE", fog.MaxDistance)
+		setUniform1f(gfx, shader, "POST_FOG_HEIGHT", fog.Height)
+		setUniform1f(gfx, shader, "POST_FOG_HEIGHT_FALLOFF", fog.HeightFalloff)
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// FXAA is an Effect that smooths jagged edges, which offscreen targets get
+// since they lose the multisampling of the window. It works on the final
+// colors, so it should be added after effects that change them, such as
+// color grading.
+//
+// SMAA is not provided since it needs precomputed area and search textures.
+type FXAA struct {
+	// Enabled indicates whether or not the effect gets applied.
+	Enabled bool
+
+	// EdgeThreshold is the contrast in luma, relative to the brightest
+	// neighbor, needed for a pixel to be treated as an edge. Lower values
+	// smooth more edges; 0.125 is a good default and 0.063 is high quality.
+	EdgeThreshold float32
+
+	// EdgeThresholdMin is the smallest contrast treated as an edge so that
+	// dark areas don't get smoothed.
+	EdgeThresholdMin float32
+
+	// Subpixel is the amount of blending for features thinner than a pixel,
+	// from 0.0 for none to 1.0 for the softest result.
+	Subpixel float32
+
+	// shader is the shader used to draw the effect.
+	shader *fizzle.RenderShader
+}
+
+// NewFXAA creates a new FXAA effect with the default quality settings.
+func NewFXAA() (*FXAA, error) {
+	shader, err := fizzle.LoadShaderProgram(PostShaderV330, FXAAShaderF330, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile the FXAA shader.\n%v", err)
+	}
+
+	fxaa := new(FXAA)
+	fxaa.Enabled = true
+	fxaa.EdgeThreshold = 0.125
+	fxaa.EdgeThresholdMin = 0.0312
+	fxaa.Subpixel = 0.75
+	fxaa.shader = shader
+	return fxaa, nil
+}
+
+// IsEnabled returns true if the effect should be applied.
+func (fxaa *FXAA) IsEnabled() bool {
+	return fxaa.Enabled
+}
+
+// Destroy deletes the shader from OpenGL.
+func (fxaa *FXAA) Destroy() {
+	fxaa.shader.Destroy()
+}
+
+// Apply draws the source with the edges smoothed.
+func (fxaa *FXAA) Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target) {
+	s.DrawQuad(r, fxaa.shader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+		gfx := r.GetGraphics()
+		BindTexture(gfx, shader, "POST_SOURCE", source.Color, texturesBound)
+		setTexelSize(gfx, shader, source)
+		setUniform1f(gfx, shader, "POST_FXAA_EDGE_THRESHOLD", fxaa.EdgeThreshold)
+		setUniform1f(gfx, shader, "POST_FXAA_EDGE_THRESHOLD_MIN", fxaa.EdgeThresholdMin)
+		setUniform1f(gfx, shader, "POST_FXAA_SUBPIXEL", fxaa.Subpixel)
+	})
+}
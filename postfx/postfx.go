@@ -0,0 +1,250 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package postfx is a post-processing stack for the renderers. The scene gets
+drawn into an offscreen Target with a color and depth texture and then each
+enabled Effect is applied in order as a full screen pass, with the last one
+drawing to the screen.
+
+A frame drawn with post-processing looks like:
+
+	stack.Begin()
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+	renderer.DrawRenderable(...)
+	stack.End(renderer)
+
+*/
+package postfx
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// Effect is a full screen post-processing pass.
+type Effect interface {
+	// IsEnabled returns true if the effect should be applied.
+	IsEnabled() bool
+
+	// Apply draws the effect reading the color of the source Target into
+	// dest, which is nil for the screen. The output is already bound when
+	// Apply is called; effects that draw intermediate passes can bind it
+	// again with BindOutput. The depth of the scene is available in the
+	// Scene Target of the Stack.
+	Apply(s *Stack, r renderer.Renderer, source *Target, dest *Target)
+
+	// Destroy frees the OpenGL objects owned by the effect.
+	Destroy()
+}
+
+// Stack is a list of post-processing effects and the offscreen targets they
+// get applied with.
+type Stack struct {
+	// Effects are the effects applied in order by End.
+	Effects []Effect
+
+	// Scene is the target the scene gets drawn into between Begin and End.
+	Scene *Target
+
+	// targets are the two targets the effects alternate between.
+	targets [2]*Target
+
+	// quad is the full screen quad the passes are drawn with.
+	quad *fizzle.Renderable
+
+	// copyShader draws the scene to the screen when no effects are enabled.
+	copyShader *fizzle.RenderShader
+}
+
+// NewStack creates a new Stack with targets of the size specified, which
+// should match the resolution of the renderer. If hdr is true, the targets
+// use half float color textures.
+func NewStack(width, height int32, hdr bool) (*Stack, error) {
+	s := new(Stack)
+	s.Effects = make([]Effect, 0, 4)
+
+	if err := s.createTargets(width, height, hdr); err != nil {
+		return nil, err
+	}
+
+	var err error
+	s.copyShader, err = fizzle.LoadShaderProgram(PostShaderV330, CopyShaderF330, nil)
+	if err != nil {
+		s.destroyTargets()
+		return nil, fmt.Errorf("Failed to compile the post-processing copy shader.\n%v", err)
+	}
+
+	s.quad = fizzle.CreatePlaneXY(-1.0, -1.0, 1.0, 1.0)
+	return s, nil
+}
+
+// createTargets creates the scene and ping-pong targets.
+func (s *Stack) createTargets(width, height int32, hdr bool) error {
+	var err error
+	s.Scene, err = NewTarget(width, height, hdr)
+	if err != nil {
+		return err
+	}
+	for i := range s.targets {
+		s.targets[i], err = NewTarget(width, height, hdr)
+		if err != nil {
+			s.destroyTargets()
+			return err
+		}
+	}
+	return nil
+}
+
+// destroyTargets destroys the scene and ping-pong targets that exist.
+func (s *Stack) destroyTargets() {
+	if s.Scene != nil {
+		s.Scene.Destroy()
+		s.Scene = nil
+	}
+	for i, t := range s.targets {
+		if t != nil {
+			t.Destroy()
+			s.targets[i] = nil
+		}
+	}
+}
+
+// Destroy deletes the targets, shaders and all of the effects in the stack
+// from OpenGL.
+func (s *Stack) Destroy() {
+	s.destroyTargets()
+	s.copyShader.Destroy()
+	s.quad.Destroy()
+	for _, e := range s.Effects {
+		e.Destroy()
+	}
+	s.Effects = s.Effects[:0]
+}
+
+// Add appends the effect to the end of the stack.
+func (s *Stack) Add(e Effect) {
+	s.Effects = append(s.Effects, e)
+}
+
+// Resize recreates the targets at a new size, which should be called when
+// the resolution of the renderer changes.
+func (s *Stack) Resize(width, height int32) error {
+	if s.Scene != nil && s.Scene.Width == width && s.Scene.Height == height {
+		return nil
+	}
+
+	hdr := s.Scene != nil && s.Scene.HDR
+	s.destroyTargets()
+	return s.createTargets(width, height, hdr)
+}
+
+// Begin binds the scene target so that the scene gets drawn into it. The
+// target is not cleared.
+func (s *Stack) Begin() {
+	s.Scene.Bind()
+}
+
+// End applies the enabled effects to the scene, alternating between the
+// offscreen targets, and draws the last one to the default framebuffer at
+// the resolution of the renderer. Depth testing gets disabled for the
+// passes and is enabled again afterwards.
+func (s *Stack) End(r renderer.Renderer) {
+	gfx := r.GetGraphics()
+	gfx.Disable(graphics.DEPTH_TEST)
+
+	enabled := make([]Effect, 0, len(s.Effects))
+	for _, e := range s.Effects {
+		if e.IsEnabled() {
+			enabled = append(enabled, e)
+		}
+	}
+
+	source := s.Scene
+	for i, e := range enabled {
+		var dest *Target
+		if i < len(enabled)-1 {
+			dest = s.targets[i%2]
+		}
+
+		s.BindOutput(r, dest)
+		e.Apply(s, r, source, dest)
+		source = dest
+	}
+
+	// with nothing enabled the scene still needs to make it to the screen
+	if len(enabled) == 0 {
+		s.BindOutput(r, nil)
+		s.DrawQuad(r, s.copyShader, func(r renderer.Renderer, _ *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+			BindTexture(r.GetGraphics(), shader, "POST_SOURCE", source.Color, texturesBound)
+		})
+	}
+
+	gfx.Enable(graphics.DEPTH_TEST)
+}
+
+// DrawQuad draws a full screen quad with the shader, which should use
+// PostShaderV330 or a vertex shader like it, into the framebuffer that is
+// currently bound. The binder sets the uniforms of the effect.
+func (s *Stack) DrawQuad(r renderer.Renderer, shader *fizzle.RenderShader, binder renderer.RenderBinder) {
+	ident := mgl.Ident4()
+	r.DrawRenderableWithShader(s.quad, shader, binder, ident, ident, nil)
+}
+
+// BindOutput binds the target for drawing, or the default framebuffer at
+// the resolution of the renderer if the target is nil.
+func (s *Stack) BindOutput(r renderer.Renderer, dest *Target) {
+	if dest != nil {
+		dest.Bind()
+		return
+	}
+
+	gfx := r.GetGraphics()
+	width, height := r.GetResolution()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	gfx.Viewport(0, 0, width, height)
+}
+
+// BindTexture binds a 2D texture to the next texture unit and sets the
+// sampler uniform to it, if the shader uses it. It is meant to be called
+// from the binders of effects.
+func BindTexture(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, tex graphics.Texture, texturesBound *int32) {
+	bindTextureTarget(gfx, shader, uniformName, graphics.TEXTURE_2D, tex, texturesBound)
+}
+
+// bindTextureTarget binds the texture to the texture target on the next
+// texture unit and sets the sampler uniform to it.
+func bindTextureTarget(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, target graphics.Enum, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(uniformName)
+	if shaderTex < 0 {
+		return
+	}
+
+	gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+	gfx.BindTexture(target, tex)
+	gfx.Uniform1i(shaderTex, *texturesBound)
+	*texturesBound++
+}
+
+// setUniform1f sets the float uniform if the shader uses it.
+func setUniform1f(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, v float32) {
+	loc := shader.GetUniformLocation(uniformName)
+	if loc >= 0 {
+		gfx.Uniform1f(loc, v)
+	}
+}
+
+// setTexelSize sets the POST_TEXEL_SIZE uniform to the size of a texel of
+// the target in texture coordinates if the shader uses it.
+func setTexelSize(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, t *Target) {
+	loc := shader.GetUniformLocation("POST_TEXEL_SIZE")
+	if loc >= 0 {
+		gfx.Uniform2f(loc, 1.0/float32(t.Width), 1.0/float32(t.Height))
+	}
+}
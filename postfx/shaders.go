@@ -0,0 +1,471 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+const (
+	// PostShaderV330 is the vertex shader shared by the post-processing
+	// passes. It draws the full screen quad and passes the screen UV to
+	// each fragment.
+	PostShaderV330 = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+
+    in vec3 VERTEX_POSITION;
+    in vec2 VERTEX_UV_0;
+
+    out vec2 vs_tex0_uv;
+
+    void main(void) {
+    	vs_tex0_uv = VERTEX_UV_0;
+    	gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+    }
+    `
+
+	// CopyShaderF330 is the fragment shader that copies the source texture
+	// to the screen unchanged.
+	CopyShaderF330 = `#version 330
+    precision highp float;
+
+    uniform sampler2D POST_SOURCE;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	frag_color = texture(POST_SOURCE, vs_tex0_uv);
+    }
+    `
+
+	// ColorGradeShaderF330 is the fragment shader for the color grading
+	// effect. The color is looked up in two 3D LUTs which get mixed by
+	// POST_LUT_MIX for transitions, and the graded color is then blended
+	// with the original by POST_WEIGHT.
+	ColorGradeShaderF330 = `#version 330
+    precision highp float;
+
+    uniform sampler2D POST_SOURCE;
+    uniform sampler3D POST_LUT;
+    uniform sampler3D POST_LUT_NEXT;
+    uniform float POST_LUT_SIZE;
+    uniform float POST_LUT_NEXT_SIZE;
+    uniform float POST_LUT_MIX;
+    uniform float POST_WEIGHT;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    vec3 lookup(sampler3D lut, float size, vec3 color) {
+    	/* scale and offset so that 0 and 1 land on the centers of the edge texels */
+    	vec3 uvw = color * ((size - 1.0) / size) + 0.5 / size;
+    	return texture(lut, uvw).rgb;
+    }
+
+    void main (void) {
+    	vec4 source = texture(POST_SOURCE, vs_tex0_uv);
+    	vec3 color = clamp(source.rgb, 0.0, 1.0);
+
+    	vec3 graded = lookup(POST_LUT, POST_LUT_SIZE, color);
+    	if (POST_LUT_MIX > 0.0) {
+    		graded = mix(graded, lookup(POST_LUT_NEXT, POST_LUT_NEXT_SIZE, color), POST_LUT_MIX);
+    	}
+
+    	frag_color = vec4(mix(source.rgb, graded, POST_WEIGHT), source.a);
+    }
+    `
+
+	// DepthOfFieldShaderF330 is the fragment shader for the depth of field
+	// effect. The circle of confusion for each pixel is worked out from the
+	// linearized scene depth and the source is gathered in a golden angle
+	// spiral out to that radius. Samples only count if their own circle of
+	// confusion reaches the pixel, which gives the blur round bokeh shapes
+	// and keeps sharp backgrounds from bleeding onto blurry foregrounds.
+	DepthOfFieldShaderF330 = `#version 330
+    precision highp float;
+
+    const float GOLDEN_ANGLE = 2.39996323;
+
+    uniform sampler2D POST_SOURCE;
+    uniform sampler2D POST_DEPTH;
+    uniform vec2 POST_TEXEL_SIZE;
+    uniform float POST_NEAR;
+    uniform float POST_FAR;
+    uniform float POST_DOF_FOCAL_DISTANCE;
+    uniform float POST_DOF_FOCAL_RANGE;
+    uniform float POST_DOF_APERTURE;
+    uniform float POST_DOF_MAX_RADIUS;
+    uniform float POST_DOF_SPACING;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    float linearDepth(vec2 uv) {
+    	float z = texture(POST_DEPTH, uv).r * 2.0 - 1.0;
+    	return 2.0 * POST_NEAR * POST_FAR / (POST_FAR + POST_NEAR - z * (POST_FAR - POST_NEAR));
+    }
+
+    float blurSize(float depth) {
+    	float offFocus = max(abs(depth - POST_DOF_FOCAL_DISTANCE) - POST_DOF_FOCAL_RANGE, 0.0);
+    	float coc = clamp(offFocus / max(depth, 0.0001) * POST_DOF_APERTURE, 0.0, 1.0);
+    	return coc * POST_DOF_MAX_RADIUS;
+    }
+
+    void main (void) {
+    	vec4 source = texture(POST_SOURCE, vs_tex0_uv);
+    	float centerDepth = linearDepth(vs_tex0_uv);
+    	float centerSize = blurSize(centerDepth);
+
+    	vec3 color = source.rgb;
+    	float total = 1.0;
+    	float radius = POST_DOF_SPACING;
+    	for (float angle = 0.0; radius < POST_DOF_MAX_RADIUS; angle += GOLDEN_ANGLE) {
+    		vec2 uv = vs_tex0_uv + vec2(cos(angle), sin(angle)) * POST_TEXEL_SIZE * radius;
+    		vec3 sampleColor = texture(POST_SOURCE, uv).rgb;
+    		float sampleDepth = linearDepth(uv);
+    		float sampleSize = blurSize(sampleDepth);
+
+    		/* samples behind the pixel can't blur further than the pixel does */
+    		if (sampleDepth > centerDepth) {
+    			sampleSize = clamp(sampleSize, 0.0, centerSize * 2.0);
+    		}
+
+    		float m = smoothstep(radius - 0.5, radius + 0.5, sampleSize);
+    		color += mix(color / total, sampleColor, m);
+    		total += 1.0;
+    		radius += POST_DOF_SPACING / radius;
+    	}
+
+    	frag_color = vec4(color / total, source.a);
+    }
+    `
+
+	// FXAAShaderF330 is the fragment shader for the FXAA effect. It finds
+	// edges by the contrast in luma around each pixel, searches along the
+	// edge for its ends and blends across it by how close the pixel is to
+	// the nearer end, with a subpixel blend for thin features.
+	FXAAShaderF330 = `#version 330
+    precision highp float;
+
+    const int SEARCH_STEPS = 10;
+    const float SEARCH_STEP_SIZES[10] = float[](1.0, 1.0, 1.0, 1.0, 1.5, 2.0, 2.0, 2.0, 4.0, 8.0);
+
+    uniform sampler2D POST_SOURCE;
+    uniform vec2 POST_TEXEL_SIZE;
+    uniform float POST_FXAA_EDGE_THRESHOLD;
+    uniform float POST_FXAA_EDGE_THRESHOLD_MIN;
+    uniform float POST_FXAA_SUBPIXEL;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    float luma(vec3 color) {
+    	/* perceptual luma of the gamma space color */
+    	return sqrt(dot(clamp(color, 0.0, 1.0), vec3(0.299, 0.587, 0.114)));
+    }
+
+    float lumaAt(vec2 uv) {
+    	return luma(texture(POST_SOURCE, uv).rgb);
+    }
+
+    void main (void) {
+    	vec2 uv = vs_tex0_uv;
+    	vec2 texel = POST_TEXEL_SIZE;
+    	vec4 source = texture(POST_SOURCE, uv);
+
+    	float lumaM = luma(source.rgb);
+    	float lumaN = lumaAt(uv + vec2(0.0, texel.y));
+    	float lumaS = lumaAt(uv - vec2(0.0, texel.y));
+    	float lumaE = lumaAt(uv + vec2(texel.x, 0.0));
+    	float lumaW = lumaAt(uv - vec2(texel.x, 0.0));
+
+    	float lumaMax = max(lumaM, max(max(lumaN, lumaS), max(lumaE, lumaW)));
+    	float lumaMin = min(lumaM, min(min(lumaN, lumaS), min(lumaE, lumaW)));
+    	float lumaRange = lumaMax - lumaMin;
+    	if (lumaRange < max(POST_FXAA_EDGE_THRESHOLD_MIN, lumaMax * POST_FXAA_EDGE_THRESHOLD)) {
+    		frag_color = source;
+    		return;
+    	}
+
+    	float lumaNE = lumaAt(uv + vec2(texel.x, texel.y));
+    	float lumaNW = lumaAt(uv + vec2(-texel.x, texel.y));
+    	float lumaSE = lumaAt(uv + vec2(texel.x, -texel.y));
+    	float lumaSW = lumaAt(uv + vec2(-texel.x, -texel.y));
+
+    	/* decide if the edge runs horizontally or vertically */
+    	float edgeH = abs(lumaNW + lumaNE - 2.0 * lumaN) +
+    		2.0 * abs(lumaW + lumaE - 2.0 * lumaM) +
+    		abs(lumaSW + lumaSE - 2.0 * lumaS);
+    	float edgeV = abs(lumaNW + lumaSW - 2.0 * lumaW) +
+    		2.0 * abs(lumaN + lumaS - 2.0 * lumaM) +
+    		abs(lumaNE + lumaSE - 2.0 * lumaE);
+    	bool horizontal = edgeH >= edgeV;
+
+    	/* pick the side of the edge with the larger gradient */
+    	float luma1 = horizontal ? lumaS : lumaW;
+    	float luma2 = horizontal ? lumaN : lumaE;
+    	float gradient1 = abs(luma1 - lumaM);
+    	float gradient2 = abs(luma2 - lumaM);
+    	float stepLength = horizontal ? texel.y : texel.x;
+    	float lumaLocal;
+    	float gradient;
+    	if (gradient1 >= gradient2) {
+    		stepLength = -stepLength;
+    		lumaLocal = 0.5 * (luma1 + lumaM);
+    		gradient = gradient1;
+    	} else {
+    		lumaLocal = 0.5 * (luma2 + lumaM);
+    		gradient = gradient2;
+    	}
+    	float gradientScaled = 0.25 * gradient;
+
+    	/* walk along the edge in both directions until it ends */
+    	vec2 edgeUV = uv;
+    	vec2 offset;
+    	if (horizontal) {
+    		edgeUV.y += stepLength * 0.5;
+    		offset = vec2(texel.x, 0.0);
+    	} else {
+    		edgeUV.x += stepLength * 0.5;
+    		offset = vec2(0.0, texel.y);
+    	}
+
+    	vec2 uv1 = edgeUV - offset;
+    	vec2 uv2 = edgeUV + offset;
+    	float lumaEnd1 = lumaAt(uv1) - lumaLocal;
+    	float lumaEnd2 = lumaAt(uv2) - lumaLocal;
+    	bool reached1 = abs(lumaEnd1) >= gradientScaled;
+    	bool reached2 = abs(lumaEnd2) >= gradientScaled;
+    	for (int i = 1; i < SEARCH_STEPS && !(reached1 && reached2); i++) {
+    		if (!reached1) {
+    			uv1 -= offset * SEARCH_STEP_SIZES[i];
+    			lumaEnd1 = lumaAt(uv1) - lumaLocal;
+    			reached1 = abs(lumaEnd1) >= gradientScaled;
+    		}
+    		if (!reached2) {
+    			uv2 += offset * SEARCH_STEP_SIZES[i];
+    			lumaEnd2 = lumaAt(uv2) - lumaLocal;
+    			reached2 = abs(lumaEnd2) >= gradientScaled;
+    		}
+    	}
+
+    	float distance1 = horizontal ? (uv.x - uv1.x) : (uv.y - uv1.y);
+    	float distance2 = horizontal ? (uv2.x - uv.x) : (uv2.y - uv.y);
+    	bool direction1 = distance1 < distance2;
+    	float distanceFinal = min(distance1, distance2);
+    	float edgeLength = distance1 + distance2;
+
+    	/* only blend if the luma at the nearer end varies the right way */
+    	bool lumaMSmaller = lumaM < lumaLocal;
+    	bool correctVariation = ((direction1 ? lumaEnd1 : lumaEnd2) < 0.0) != lumaMSmaller;
+    	float edgeOffset = correctVariation ? (0.5 - distanceFinal / edgeLength) : 0.0;
+
+    	/* blend thin features by how much the pixel differs from its neighbors */
+    	float lumaAverage = (2.0 * (lumaN + lumaS + lumaE + lumaW) + lumaNE + lumaNW + lumaSE + lumaSW) / 12.0;
+    	float subpixel = clamp(abs(lumaAverage - lumaM) / lumaRange, 0.0, 1.0);
+    	subpixel = (-2.0 * subpixel + 3.0) * subpixel * subpixel;
+    	float subpixelOffset = subpixel * subpixel * POST_FXAA_SUBPIXEL;
+
+    	float finalOffset = max(edgeOffset, subpixelOffset);
+    	vec2 finalUV = uv;
+    	if (horizontal) {
+    		finalUV.y += finalOffset * stepLength;
+    	} else {
+    		finalUV.x += finalOffset * stepLength;
+    	}
+
+    	frag_color = vec4(texture(POST_SOURCE, finalUV).rgb, source.a);
+    }
+    `
+
+	// VolumetricFogShaderF330 is the fragment shader for the volumetric fog
+	// effect. It marches from the camera to the scene depth, accumulating the
+	// ambient fog color and the light scattered toward the camera by each
+	// active light of the forward renderer, using the Henyey-Greenstein
+	// phase function and the light shadow maps, and attenuates the scene
+	// behind the fog by the transmittance.
+	VolumetricFogShaderF330 = `#version 330
+    precision highp float;
+
+    const int MAX_LIGHTS = 4;
+    const float PI = 3.14159265359;
+
+    uniform sampler2D POST_SOURCE;
+    uniform sampler2D POST_DEPTH;
+    uniform mat4 POST_INV_VIEW_PROJECTION;
+    uniform vec3 POST_CAMERA_POSITION;
+    uniform float POST_FOG_DENSITY;
+    uniform float POST_FOG_ANISOTROPY;
+    uniform vec3 POST_FOG_COLOR;
+    uniform float POST_FOG_LIGHT_SCALE;
+    uniform float POST_FOG_MAX_DISTANCE;
+    uniform int POST_FOG_STEPS;
+    uniform float POST_FOG_HEIGHT;
+    uniform float POST_FOG_HEIGHT_FALLOFF;
+
+    uniform sampler2DShadow SHADOW_MAPS[4];
+    uniform mat4 SHADOW_MATRIX[4];
+    uniform vec3 LIGHT_POSITION[MAX_LIGHTS];
+    uniform vec3 LIGHT_DIRECTION[MAX_LIGHTS];
+    uniform vec4 LIGHT_DIFFUSE[MAX_LIGHTS];
+    uniform float LIGHT_DIFFUSE_INTENSITY[MAX_LIGHTS];
+    uniform float LIGHT_CONST_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_LINEAR_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_QUADRATIC_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_STRENGTH[MAX_LIGHTS];
+    uniform int LIGHT_COUNT;
+    uniform int SHADOW_COUNT;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    float phaseHG(float cosTheta, float g) {
+    	float g2 = g * g;
+    	return (1.0 - g2) / (4.0 * PI * pow(1.0 + g2 - 2.0 * g * cosTheta, 1.5));
+    }
+
+    float shadowFactor(int i, vec3 p) {
+    	/* samplers can only be indexed by constants */
+    	if (i >= SHADOW_COUNT) {
+    		return 1.0;
+    	}
+    	vec4 coord = SHADOW_MATRIX[i] * vec4(p, 1.0);
+    	if (i == 0) {
+    		return textureProj(SHADOW_MAPS[0], coord);
+    	} else if (i == 1) {
+    		return textureProj(SHADOW_MAPS[1], coord);
+    	} else if (i == 2) {
+    		return textureProj(SHADOW_MAPS[2], coord);
+    	}
+    	return textureProj(SHADOW_MAPS[3], coord);
+    }
+
+    float fogDensity(vec3 p) {
+    	return POST_FOG_DENSITY * exp(-POST_FOG_HEIGHT_FALLOFF * max(p.y - POST_FOG_HEIGHT, 0.0));
+    }
+
+    vec3 inscatteredLight(vec3 p, vec3 viewDir) {
+    	/* viewDir points away from the camera, so looking into a light is forward scattering */
+    	vec3 light = POST_FOG_COLOR;
+    	for (int i = 0; i < MAX_LIGHTS; i++) {
+    		if (i >= LIGHT_COUNT) {
+    			break;
+    		}
+
+    		vec3 toLight;
+    		float attenuation = LIGHT_STRENGTH[i];
+    		vec3 lightDir = LIGHT_DIRECTION[i];
+    		if (lightDir.x == 0.0 && lightDir.y == 0.0 && lightDir.z == 0.0) {
+    			// point light
+    			toLight = LIGHT_POSITION[i] - p;
+    			float distance = length(toLight);
+    			attenuation = LIGHT_STRENGTH[i] / (1.0 +
+    				(LIGHT_CONST_ATTENUATION[i] +
+    				 LIGHT_LINEAR_ATTENUATION[i] * distance +
+    				 LIGHT_QUADRATIC_ATTENUATION[i] * distance * distance));
+    			toLight = toLight / distance;
+    		} else {
+    			// directional light
+    			toLight = -normalize(lightDir);
+    		}
+
+    		float phase = phaseHG(dot(viewDir, toLight), POST_FOG_ANISOTROPY);
+    		light += LIGHT_DIFFUSE[i].rgb * LIGHT_DIFFUSE_INTENSITY[i] * attenuation *
+    			phase * shadowFactor(i, p) * POST_FOG_LIGHT_SCALE;
+    	}
+    	return light;
+    }
+
+    void main (void) {
+    	vec4 source = texture(POST_SOURCE, vs_tex0_uv);
+    	float depth = texture(POST_DEPTH, vs_tex0_uv).r;
+
+    	vec4 world = POST_INV_VIEW_PROJECTION * vec4(vec3(vs_tex0_uv, depth) * 2.0 - 1.0, 1.0);
+    	vec3 ray = world.xyz / world.w - POST_CAMERA_POSITION;
+    	float rayLength = min(length(ray), POST_FOG_MAX_DISTANCE);
+    	vec3 viewDir = normalize(ray);
+
+    	/* jitter the start of the march per pixel to trade banding for noise */
+    	float noise = fract(52.9829189 * fract(dot(gl_FragCoord.xy, vec2(0.06711056, 0.00583715))));
+    	float stepLength = rayLength / float(POST_FOG_STEPS);
+
+    	vec3 inscatter = vec3(0.0);
+    	float transmittance = 1.0;
+    	for (int i = 0; i < POST_FOG_STEPS; i++) {
+    		vec3 p = POST_CAMERA_POSITION + viewDir * stepLength * (float(i) + noise);
+    		float density = fogDensity(p);
+    		if (density <= 0.0) {
+    			continue;
+    		}
+
+    		float stepTransmittance = exp(-density * stepLength);
+    		/* integrate the scattering over the step analytically */
+    		inscatter += transmittance * inscatteredLight(p, viewDir) * (1.0 - stepTransmittance);
+    		transmittance *= stepTransmittance;
+    		if (transmittance < 0.01) {
+    			break;
+    		}
+    	}
+
+    	frag_color = vec4(source.rgb * transmittance + inscatter, source.a);
+    }
+    `
+
+	// ScreenDropletsShaderF330 is the fragment shader for the screen droplets
+	// effect. Two layers of procedural drops are laid over a grid of cells;
+	// each drop lands, slides down its cell and dries up, and the source is
+	// sampled through it with an offset that bends the image like a lens.
+	ScreenDropletsShaderF330 = `#version 330
+    precision highp float;
+
+    uniform sampler2D POST_SOURCE;
+    uniform float POST_TIME;
+    uniform float POST_ASPECT;
+    uniform float POST_DROPLET_AMOUNT;
+    uniform float POST_DROPLET_SIZE;
+    uniform float POST_DROPLET_SPEED;
+    uniform float POST_DROPLET_REFRACTION;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    float hash(vec2 p) {
+    	return fract(sin(dot(p, vec2(127.1, 311.7))) * 43758.5453);
+    }
+
+    /* returns the direction from the center of the drop covering the uv,
+       scaled by how thick the drop is there, or zero outside of the drops */
+    vec2 dropLayer(vec2 uv, float size, float seed) {
+    	vec2 grid = uv * vec2(POST_ASPECT, 1.0) / size;
+    	vec2 cell = floor(grid);
+    	vec2 local = fract(grid) - 0.5;
+
+    	if (hash(cell + seed) >= POST_DROPLET_AMOUNT) {
+    		return vec2(0.0);
+    	}
+
+    	float life = fract(POST_TIME * POST_DROPLET_SPEED + hash(cell.yx + seed * 7.0));
+    	vec2 center = vec2(hash(cell + seed + 3.1) - 0.5, 0.5 - life) * 0.5;
+    	float radius = 0.3 * (1.0 - life * 0.6) * (0.6 + 0.4 * hash(cell + seed + 5.7));
+
+    	/* drops get stretched out as they slide */
+    	vec2 d = (local - center) * vec2(1.0, 1.0 + life * 0.5) / radius;
+    	float dist2 = dot(d, d);
+    	if (dist2 >= 1.0) {
+    		return vec2(0.0);
+    	}
+
+    	float fade = 1.0 - smoothstep(0.7, 1.0, life);
+    	return d * sqrt(1.0 - dist2) * fade;
+    }
+
+    void main (void) {
+    	vec2 offset = dropLayer(vs_tex0_uv, POST_DROPLET_SIZE, 0.0);
+    	offset += dropLayer(vs_tex0_uv, POST_DROPLET_SIZE * 0.6, 17.0);
+
+    	vec2 uv = vs_tex0_uv - offset * POST_DROPLET_REFRACTION * POST_DROPLET_SIZE;
+    	vec4 source = texture(POST_SOURCE, vs_tex0_uv);
+    	frag_color = vec4(texture(POST_SOURCE, uv).rgb, source.a);
+    }
+    `
+)
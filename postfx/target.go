@@ -0,0 +1,96 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package postfx
+
+import (
+	"fmt"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// Target is an offscreen framebuffer with a color texture and a depth
+// texture that the scene or a post-processing pass can be drawn into and
+// then sampled by the next pass.
+type Target struct {
+	// Framebuffer is the OpenGL framebuffer object.
+	Framebuffer graphics.Buffer
+
+	// Color is the color texture attached to the framebuffer.
+	Color graphics.Texture
+
+	// Depth is the depth texture attached to the framebuffer.
+	Depth graphics.Texture
+
+	// Width and Height are the size of the textures in pixels.
+	Width  int32
+	Height int32
+
+	// HDR indicates that the color texture stores half floats so that
+	// values above 1.0 are kept.
+	HDR bool
+}
+
+// NewTarget creates a new Target of the size specified. If hdr is true, the
+// color texture uses a half float format instead of 8 bits per channel.
+func NewTarget(width, height int32, hdr bool) (*Target, error) {
+	gfx := fizzle.GetGraphics()
+	t := new(Target)
+	t.Width = width
+	t.Height = height
+	t.HDR = hdr
+
+	t.Color = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, t.Color)
+	if hdr {
+		gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA16F, width, height, 0, graphics.RGBA, graphics.FLOAT, nil, 0)
+	} else {
+		gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, width, height, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, nil, 0)
+	}
+	setTargetTextureParameters(gfx)
+
+	t.Depth = gfx.GenTexture()
+	gfx.BindTexture(graphics.TEXTURE_2D, t.Depth)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.DEPTH_COMPONENT24, width, height, 0, graphics.DEPTH_COMPONENT, graphics.UNSIGNED_INT, nil, 0)
+	setTargetTextureParameters(gfx)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	t.Framebuffer = gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, t.Framebuffer)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, t.Color, 0)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.TEXTURE_2D, t.Depth, 0)
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		t.Destroy()
+		return nil, fmt.Errorf("Failed to create the post-processing framebuffer. Code 0x%x", status)
+	}
+
+	return t, nil
+}
+
+// setTargetTextureParameters sets the filtering and wrapping for the
+// texture bound to TEXTURE_2D so that it can be sampled across the screen.
+func setTargetTextureParameters(gfx graphics.GraphicsProvider) {
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+}
+
+// Destroy deletes the framebuffer and textures from OpenGL.
+func (t *Target) Destroy() {
+	gfx := fizzle.GetGraphics()
+	gfx.DeleteFramebuffer(t.Framebuffer)
+	gfx.DeleteTexture(t.Color)
+	gfx.DeleteTexture(t.Depth)
+}
+
+// Bind binds the framebuffer for drawing and sets the viewport to its size.
+func (t *Target) Bind() {
+	gfx := fizzle.GetGraphics()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, t.Framebuffer)
+	gfx.Viewport(0, 0, t.Width, t.Height)
+}
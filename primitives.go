@@ -4,7 +4,6 @@
 package fizzle
 
 import (
-	"fmt"
 	"math"
 
 	mgl "github.com/go-gl/mathgl/mgl32"
@@ -517,6 +516,149 @@ func CreateWireframeConeSegmentXZ(xmin, ymin, zmin, bottomRadius, topRadius, len
 	return r
 }
 
+// CreateWireframeSphere makes a sphere out of three circles, one on each of
+// the XZ, XY and ZY planes, designed to be rendered as graphics.LINES.
+func CreateWireframeSphere(xmin, ymin, zmin, radius float32, segments int) *Renderable {
+	// sanity check
+	if segments == 0 {
+		return nil
+	}
+
+	verts := []float32{}
+	indexes := []uint32{}
+	for _, axis := range []int{X | Z, X | Y, Z | Y} {
+		circleVerts, circleIndexes := genCircleSegData(xmin, ymin, zmin, radius, segments, axis)
+		indexOffset := uint32(len(verts) / 3)
+		verts = append(verts, circleVerts...)
+		for _, index := range circleIndexes {
+			indexes = append(indexes, index+indexOffset)
+		}
+	}
+
+	r := createWireframe(verts, indexes)
+	r.BoundingRect.Bottom = mgl.Vec3{xmin - radius, ymin - radius, zmin - radius}
+	r.BoundingRect.Top = mgl.Vec3{xmin + radius, ymin + radius, zmin + radius}
+	return r
+}
+
+// CreateWireframeHemisphere makes the upper (+Y) half of a sphere out of a circle
+// on the XZ plane and two half circles on the XY and ZY planes, designed to be
+// rendered as graphics.LINES.
+func CreateWireframeHemisphere(xmin, ymin, zmin, radius float32, segments int) *Renderable {
+	// sanity check
+	if segments < 2 {
+		return nil
+	}
+
+	// the base circle
+	verts, indexes := genCircleSegData(xmin, ymin, zmin, radius, segments, X|Z)
+
+	// the arcs over the top from -X to +X and from -Z to +Z
+	radsPerSeg := math.Pi / float64(segments/2)
+	for _, overX := range []bool{true, false} {
+		indexOffset := uint32(len(verts) / 3)
+		for i := 0; i <= segments/2; i++ {
+			horizontal := radius * float32(math.Cos(radsPerSeg*float64(i)))
+			vertical := radius * float32(math.Sin(radsPerSeg*float64(i)))
+			if overX {
+				verts = append(verts, xmin+horizontal, ymin+vertical, zmin)
+			} else {
+				verts = append(verts, xmin, ymin+vertical, zmin+horizontal)
+			}
+			if i > 0 {
+				indexes = append(indexes, indexOffset+uint32(i)-1, indexOffset+uint32(i))
+			}
+		}
+	}
+
+	r := createWireframe(verts, indexes)
+	r.BoundingRect.Bottom = mgl.Vec3{xmin - radius, ymin, zmin - radius}
+	r.BoundingRect.Top = mgl.Vec3{xmin + radius, ymin + radius, zmin + radius}
+	return r
+}
+
+// CreateWireframeRingXZ makes a ring on the XZ plane out of an outer and an inner
+// circle designed to be rendered as graphics.LINES. If innerRadius is zero, only
+// the outer circle is made so that it represents a disc.
+func CreateWireframeRingXZ(xmin, ymin, zmin, innerRadius, outerRadius float32, segments int) *Renderable {
+	// sanity check
+	if segments == 0 {
+		return nil
+	}
+
+	verts, indexes := genCircleSegData(xmin, ymin, zmin, outerRadius, segments, X|Z)
+	if innerRadius > 0.0 {
+		innerVerts, innerIndexes := genCircleSegData(xmin, ymin, zmin, innerRadius, segments, X|Z)
+		verts = append(verts, innerVerts...)
+		for _, index := range innerIndexes {
+			indexes = append(indexes, index+uint32(segments))
+		}
+	}
+
+	r := createWireframe(verts, indexes)
+	r.BoundingRect.Bottom = mgl.Vec3{xmin - outerRadius, ymin, zmin - outerRadius}
+	r.BoundingRect.Top = mgl.Vec3{xmin + outerRadius, ymin, zmin + outerRadius}
+	return r
+}
+
+// CreateWireframeTriangles makes the edges of the triangles specified by faces,
+// which index into verts, designed to be rendered as graphics.LINES.
+func CreateWireframeTriangles(verts []mgl.Vec3, faces [][3]uint32) *Renderable {
+	// sanity check
+	if len(verts) == 0 || len(faces) == 0 {
+		return nil
+	}
+
+	flatVerts := make([]float32, 0, len(verts)*3)
+	var bottom, top mgl.Vec3
+	for i, v := range verts {
+		flatVerts = append(flatVerts, v[0], v[1], v[2])
+		if i == 0 {
+			bottom, top = v, v
+			continue
+		}
+		for axis := 0; axis < 3; axis++ {
+			bottom[axis] = float32(math.Min(float64(bottom[axis]), float64(v[axis])))
+			top[axis] = float32(math.Max(float64(top[axis]), float64(v[axis])))
+		}
+	}
+
+	indexes := make([]uint32, 0, len(faces)*6)
+	for _, f := range faces {
+		indexes = append(indexes, f[0], f[1], f[1], f[2], f[2], f[0])
+	}
+
+	r := createWireframe(flatVerts, indexes)
+	r.BoundingRect.Bottom = bottom
+	r.BoundingRect.Top = top
+	return r
+}
+
+// createWireframe makes a Renderable with vertex and element VBO objects for
+// the line segments specified by pairs of indexes designed to be rendered
+// as graphics.LINES.
+func createWireframe(verts []float32, indexes []uint32) *Renderable {
+	// calculate the memory size of floats used to calculate total memory size of float arrays
+	const floatSize = 4
+	const uintSize = 4
+
+	r := NewRenderable()
+	r.Core = NewRenderableCore()
+	r.FaceCount = uint32(len(indexes) / 2)
+
+	// create a VBO to hold the vertex data
+	r.Core.VertVBO = gfx.GenBuffer()
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(verts), gfx.Ptr(&verts[0]), graphics.STATIC_DRAW)
+
+	// create a VBO to hold the face indexes
+	r.Core.ElementsVBO = gfx.GenBuffer()
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	gfx.BufferData(graphics.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gfx.Ptr(&indexes[0]), graphics.STATIC_DRAW)
+
+	return r
+}
+
 // CreateSphere generates a 3d uv-sphere with the given radius and returns a Renderable.
 func CreateSphere(radius float32, rings int, sectors int) *Renderable {
 	// nothing to create
@@ -827,7 +969,7 @@ func CreateCubeMappedSphere(gridSize int, radius float32, cubemapUvs bool) *Rend
 	r.BoundingRect.Top = mgl.Vec3{xmax, ymax, zmax}
 	r.FaceCount = uint32(len(indexes) / 3)
 	byteCount := floatSize*len(vnutBuffer) + uintSize*len(indexes)
-	fmt.Printf("Face count = %d ; bytes = %dB (%.2fKB)\n", r.FaceCount, byteCount, float32(byteCount)/1024.0)
+	Logf(LogDebug, "primitives", "Face count = %d ; bytes = %dB (%.2fKB)", r.FaceCount, byteCount, float32(byteCount)/1024.0)
 
 	// create a VBO to hold the vertex data
 	r.Core.VertVBO = gfx.GenBuffer()
@@ -0,0 +1,109 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package probe
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// probeCache is the file format for a captured probe.
+type probeCache struct {
+	// Size is the width and height of the largest faces.
+	Size int32
+
+	// Levels are the RGBA pixels of each face for every mip level.
+	Levels [][fizzle.CubeMapFaceCount][]byte
+}
+
+// SaveCache writes the prefiltered cube map of the probe to a file so that
+// it can be loaded with LoadCache instead of capturing it again. The
+// default framebuffer is bound afterwards.
+func (p *ReflectionProbe) SaveCache(filePath string) error {
+	if p.Texture == 0 {
+		return fmt.Errorf("Failed to save the reflection probe; it hasn't been captured.")
+	}
+
+	gfx := fizzle.GetGraphics()
+	cache := probeCache{Size: p.Size}
+
+	// read the faces back through a framebuffer
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	for level := int32(0); level < p.GetMipLevelCount(); level++ {
+		mipSize := p.Size >> uint32(level)
+		var faces [fizzle.CubeMapFaceCount][]byte
+		for face := 0; face < fizzle.CubeMapFaceCount; face++ {
+			gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, cubeMapTargets[face], p.Texture, level)
+			faces[face] = make([]byte, mipSize*mipSize*4)
+			gfx.ReadPixels(0, 0, mipSize, mipSize, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(faces[face]))
+		}
+		cache.Levels = append(cache.Levels, faces)
+	}
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	gfx.DeleteFramebuffer(fbo)
+
+	var buffer bytes.Buffer
+	zipper := gzip.NewWriter(&buffer)
+	err := gob.NewEncoder(zipper).Encode(&cache)
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the reflection probe. %v", err)
+	}
+	zipper.Close()
+
+	err = ioutil.WriteFile(filePath, buffer.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the reflection probe file %s. %v", filePath, err)
+	}
+	return nil
+}
+
+// LoadCache loads the prefiltered cube map of the probe from a file
+// written by SaveCache, replacing the probe's Texture. The file must have
+// been saved from a probe of the same Size.
+func (p *ReflectionProbe) LoadCache(filePath string) error {
+	fileBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("Failed to read the reflection probe file %s. %v", filePath, err)
+	}
+
+	unzipper, err := gzip.NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		return fmt.Errorf("Failed to decompress the reflection probe file %s. %v", filePath, err)
+	}
+	var cache probeCache
+	err = gob.NewDecoder(unzipper).Decode(&cache)
+	if err != nil {
+		return fmt.Errorf("Failed to decode the reflection probe file %s. %v", filePath, err)
+	}
+	if cache.Size != p.Size || int32(len(cache.Levels)) != p.GetMipLevelCount() {
+		return fmt.Errorf("Failed to load the reflection probe file %s; it was saved with a size of %d instead of %d.", filePath, cache.Size, p.Size)
+	}
+
+	tex := p.createCubeMap()
+	gfx := fizzle.GetGraphics()
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, tex)
+	for level, faces := range cache.Levels {
+		mipSize := p.Size >> uint32(level)
+		for face, pixels := range faces {
+			if len(pixels) != int(mipSize*mipSize*4) {
+				gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+				gfx.DeleteTexture(tex)
+				return fmt.Errorf("Failed to load the reflection probe file %s; mip level %d is the wrong size.", filePath, level)
+			}
+			gfx.TexImage2D(cubeMapTargets[face], int32(level), graphics.RGBA, mipSize, mipSize, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(pixels), len(pixels))
+		}
+	}
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+
+	p.Destroy()
+	p.Texture = tex
+	return nil
+}
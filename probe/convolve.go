@@ -0,0 +1,254 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package probe
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// IrradianceSize is the width and height of each face of the irradiance
+	// cube maps made by ConvolveEnvironment, which is plenty since they are
+	// very blurry.
+	IrradianceSize = 32
+
+	// BRDFLutSize is the width and height of the BRDF lookup table made by
+	// ConvolveEnvironment.
+	BRDFLutSize = 512
+)
+
+// EnvironmentLighting is the set of textures that physically based shaders
+// need for image based lighting from an environment cube map.
+type EnvironmentLighting struct {
+	// Irradiance is the cube map of the diffuse light arriving from each
+	// direction, which shaders sample with the surface normal.
+	Irradiance graphics.Texture
+
+	// Specular is the cube map with each mip level prefiltered for a
+	// rougher surface, like the cube map of a ReflectionProbe.
+	Specular graphics.Texture
+
+	// BRDFLut is the 2D lookup table of the split sum approximation with
+	// the scale and bias for the Fresnel reflectance in the red and green
+	// channels, indexed by the cosine of the view angle and the roughness.
+	BRDFLut graphics.Texture
+}
+
+// ConvolveEnvironment makes the irradiance map, the prefiltered specular
+// map and the BRDF lookup table for the environment cube map, which needs
+// to have faces of environmentSize by environmentSize pixels. The mip levels
+// of the environment cube map get regenerated. The viewport is set back to
+// the renderer's resolution afterwards, the default framebuffer is bound
+// and depth testing and face culling are left enabled.
+func ConvolveEnvironment(r renderer.Renderer, environment graphics.Texture, environmentSize int32) (*EnvironmentLighting, error) {
+	irradiance, err := ConvolveIrradiance(r, environment, environmentSize, IrradianceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	specular, err := PrefilterSpecular(r, environment, environmentSize, environmentSize)
+	if err != nil {
+		r.GetGraphics().DeleteTexture(irradiance)
+		return nil, err
+	}
+
+	lut, err := CreateBRDFLut(r, BRDFLutSize)
+	if err != nil {
+		r.GetGraphics().DeleteTexture(irradiance)
+		r.GetGraphics().DeleteTexture(specular)
+		return nil, err
+	}
+
+	el := new(EnvironmentLighting)
+	el.Irradiance = irradiance
+	el.Specular = specular
+	el.BRDFLut = lut
+	return el, nil
+}
+
+// Destroy releases the textures.
+func (el *EnvironmentLighting) Destroy() {
+	gfx := fizzle.GetGraphics()
+	gfx.DeleteTexture(el.Irradiance)
+	gfx.DeleteTexture(el.Specular)
+	gfx.DeleteTexture(el.BRDFLut)
+}
+
+// Apply sets the textures as the environment, irradiance and BRDF lookup
+// textures of the material.
+func (el *EnvironmentLighting) Apply(m *fizzle.Material) {
+	m.EnvironmentTex = el.Specular
+	m.IrradianceTex = el.Irradiance
+	m.BRDFLutTex = el.BRDFLut
+}
+
+// ConvolveIrradiance convolves the environment cube map into a new floating
+// point cube map of the diffuse irradiance with faces of size by size
+// pixels. The mip levels of the environment cube map get regenerated.
+func ConvolveIrradiance(r renderer.Renderer, environment graphics.Texture, environmentSize int32, size int32) (graphics.Texture, error) {
+	shader, err := CreateIrradianceShader()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create the irradiance shader: %v", err)
+	}
+	defer shader.Destroy()
+
+	generateMipmaps(r.GetGraphics(), environment)
+	irradiance := createCubeMapTexture(size, 1, graphics.RGBA16F, graphics.FLOAT)
+	err = renderCubeMap(r, shader, irradiance, size, 1, func(level int32) renderer.RenderBinder {
+		return func(rend renderer.Renderer, _ *fizzle.Renderable, s *fizzle.RenderShader, texturesBound *int32) {
+			bindSource(rend.GetGraphics(), s, environment, float32(environmentSize), 1.0, texturesBound)
+		}
+	})
+	if err != nil {
+		r.GetGraphics().DeleteTexture(irradiance)
+		return 0, err
+	}
+	return irradiance, nil
+}
+
+// PrefilterSpecular prefilters the environment cube map into a new floating
+// point cube map with faces of size by size pixels, where each mip level is
+// prefiltered for a rougher surface up to RoughnessLevels-1. The mip levels
+// of the environment cube map get regenerated. The size must be a power of
+// two.
+func PrefilterSpecular(r renderer.Renderer, environment graphics.Texture, environmentSize int32, size int32) (graphics.Texture, error) {
+	if size <= 0 || size&(size-1) != 0 {
+		return 0, fmt.Errorf("Failed to prefilter the environment; the size %d is not a power of two.", size)
+	}
+
+	generateMipmaps(r.GetGraphics(), environment)
+	specular := createCubeMapTexture(size, getMipLevelCount(size), graphics.RGBA16F, graphics.FLOAT)
+	err := prefilterCubeMap(r, environment, environmentSize, specular, size)
+	if err != nil {
+		r.GetGraphics().DeleteTexture(specular)
+		return 0, err
+	}
+	return specular, nil
+}
+
+// CreateBRDFLut bakes the BRDF lookup table of the split sum approximation
+// into a new size by size RG16F texture. It only depends on the BRDF, so
+// one table can be shared by every environment.
+func CreateBRDFLut(r renderer.Renderer, size int32) (graphics.Texture, error) {
+	shader, err := CreateBRDFLutShader()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create the BRDF lookup table shader: %v", err)
+	}
+	defer shader.Destroy()
+	quad := fizzle.CreatePlaneXY(-1.0, -1.0, 1.0, 1.0)
+	defer quad.Destroy()
+
+	gfx := r.GetGraphics()
+	lut := gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, lut)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RG16F, size, size, 0, graphics.RG, graphics.FLOAT, nil, 0)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	defer func() {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		restoreState(r)
+	}()
+
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, lut, 0)
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		gfx.DeleteTexture(lut)
+		return 0, fmt.Errorf("Failed to create the framebuffer for the BRDF lookup table (status 0x%x).", status)
+	}
+
+	gfx.Viewport(0, 0, size, size)
+	gfx.Disable(graphics.DEPTH_TEST)
+	gfx.Disable(graphics.CULL_FACE)
+	r.DrawRenderableWithShader(quad, shader, nil, mgl.Ident4(), mgl.Ident4(), nil)
+	return lut, nil
+}
+
+// prefilterCubeMap draws every mip level of the target cube map prefiltered
+// for roughness from the source cube map, which needs its mip levels.
+func prefilterCubeMap(r renderer.Renderer, source graphics.Texture, sourceSize int32, target graphics.Texture, size int32) error {
+	shader, err := CreatePrefilterShader()
+	if err != nil {
+		return fmt.Errorf("Failed to create the prefilter shader: %v", err)
+	}
+	defer shader.Destroy()
+
+	return renderCubeMap(r, shader, target, size, getMipLevelCount(size), func(level int32) renderer.RenderBinder {
+		roughness := float32(level) / float32(RoughnessLevels-1)
+		if roughness > 1.0 {
+			roughness = 1.0
+		}
+		return func(rend renderer.Renderer, _ *fizzle.Renderable, s *fizzle.RenderShader, texturesBound *int32) {
+			bindSource(rend.GetGraphics(), s, source, float32(sourceSize), roughness, texturesBound)
+		}
+	})
+}
+
+// renderCubeMap draws a cube around the origin with the shader into each
+// face of the mip levels of the target cube map, using the binder returned
+// for each level to set up the shader.
+func renderCubeMap(r renderer.Renderer, shader *fizzle.RenderShader, target graphics.Texture, size int32, levels int32,
+	binderForLevel func(level int32) renderer.RenderBinder) error {
+	gfx := r.GetGraphics()
+	cube := fizzle.CreateCube(-1.0, -1.0, -1.0, 1.0, 1.0, 1.0)
+	defer cube.Destroy()
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	defer func() {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		restoreState(r)
+	}()
+
+	gfx.Disable(graphics.DEPTH_TEST)
+	gfx.Disable(graphics.CULL_FACE)
+	projection := mgl.Perspective(mgl.DegToRad(90.0), 1.0, 0.1, 10.0)
+	for level := int32(0); level < levels; level++ {
+		binder := binderForLevel(level)
+		mipSize := size >> uint32(level)
+		gfx.Viewport(0, 0, mipSize, mipSize)
+		for face := 0; face < fizzle.CubeMapFaceCount; face++ {
+			gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, cubeMapTargets[face], target, level)
+			status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+			if status != graphics.FRAMEBUFFER_COMPLETE {
+				return fmt.Errorf("Failed to create the framebuffer for the cube map (status 0x%x).", status)
+			}
+
+			camera := getFaceCamera(mgl.Vec3{}, face)
+			r.DrawRenderableWithShader(cube, shader, binder, projection, camera.view, camera)
+		}
+	}
+	return nil
+}
+
+// generateMipmaps regenerates the mip levels of the cube map.
+func generateMipmaps(gfx graphics.GraphicsProvider, cubeMap graphics.Texture) {
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, cubeMap)
+	gfx.GenerateMipmap(graphics.TEXTURE_CUBE_MAP)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+}
+
+// restoreState sets the viewport back to the renderer's resolution and
+// enables depth testing and face culling again.
+func restoreState(r renderer.Renderer) {
+	gfx := r.GetGraphics()
+	width, height := r.GetResolution()
+	gfx.Viewport(0, 0, width, height)
+	gfx.Enable(graphics.DEPTH_TEST)
+	gfx.Enable(graphics.CULL_FACE)
+}
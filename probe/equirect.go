@@ -0,0 +1,72 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package probe
+
+import (
+	"fmt"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// EquirectToCubeMap draws the equirectangular image in the 2D texture into
+// a new floating point cube map with faces of size by size pixels and all
+// of its mip levels generated, ready for ConvolveEnvironment. The size must
+// be a power of two.
+func EquirectToCubeMap(r renderer.Renderer, equirect graphics.Texture, size int32) (graphics.Texture, error) {
+	if size <= 0 || size&(size-1) != 0 {
+		return 0, fmt.Errorf("Failed to convert the environment; the size %d is not a power of two.", size)
+	}
+
+	shader, err := CreateEquirectShader()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create the equirectangular shader: %v", err)
+	}
+	defer shader.Destroy()
+
+	cubeMap := createCubeMapTexture(size, getMipLevelCount(size), graphics.RGBA16F, graphics.FLOAT)
+	err = renderCubeMap(r, shader, cubeMap, size, 1, func(level int32) renderer.RenderBinder {
+		return func(rend renderer.Renderer, _ *fizzle.Renderable, s *fizzle.RenderShader, texturesBound *int32) {
+			gfx := rend.GetGraphics()
+			shaderEquirect := s.GetUniformLocation("PROBE_EQUIRECT")
+			if shaderEquirect >= 0 {
+				gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+				gfx.BindTexture(graphics.TEXTURE_2D, equirect)
+				gfx.Uniform1i(shaderEquirect, *texturesBound)
+				*texturesBound++
+			}
+		}
+	})
+	if err != nil {
+		r.GetGraphics().DeleteTexture(cubeMap)
+		return 0, err
+	}
+
+	generateMipmaps(r.GetGraphics(), cubeMap)
+	return cubeMap, nil
+}
+
+// LoadEnvironment loads an equirectangular environment image, normally a
+// Radiance .hdr file, converts it into a cube map with faces of size by
+// size pixels with EquirectToCubeMap and stores the cube map in the texture
+// manager under keyToUse. The equirectangular texture isn't kept.
+func LoadEnvironment(r renderer.Renderer, tm *fizzle.TextureManager, keyToUse string, path string, size int32) (graphics.Texture, error) {
+	equirect, err := fizzle.LoadImageToTexture(path)
+	if err != nil {
+		r.GetGraphics().DeleteTexture(equirect)
+		return 0, err
+	}
+	defer r.GetGraphics().DeleteTexture(equirect)
+
+	cubeMap, err := EquirectToCubeMap(r, equirect, size)
+	if err != nil {
+		return 0, err
+	}
+
+	// six half float RGBA faces with a third more for the mipmaps
+	byteSize := int(size) * int(size) * 8 * fizzle.CubeMapFaceCount * 4 / 3
+	tm.RegisterTexture(keyToUse, cubeMap, byteSize, true)
+	return cubeMap, nil
+}
@@ -0,0 +1,334 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package probe captures the surroundings of points in a scene into cube
+maps for reflections.
+
+A ReflectionProbe renders the scene in all six directions from its
+position into a cube map and then prefilters the mip levels of the cube
+map for increasing roughness, so that physically based shaders can pick
+blurrier reflections for rougher materials by sampling a lower mip level.
+Probes are slow to capture, so the result can be cached to a file and
+loaded back on later runs.
+
+BindProbes gives every Renderable within the radius of a probe the cube
+map of the closest one as the EnvironmentTex of its material, which
+shaders sample as MATERIAL_TEX_ENVIRONMENT:
+
+	p := probe.NewReflectionProbe(mgl.Vec3{0.0, 2.0, 0.0}, 10.0, 128)
+	err := p.CaptureCached("room.probe", renderer, func(projection, view mgl.Mat4, camera fizzle.Camera) {
+		gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+		for _, r := range staticRenderables {
+			renderer.DrawRenderable(r, nil, projection, view, camera)
+		}
+	})
+	...
+	probe.BindProbes(probes, renderables...)
+
+	// in the fragment shader, scaling the roughness by RoughnessLevels-1
+	vec3 reflection = textureLod(MATERIAL_TEX_ENVIRONMENT, r, MATERIAL_ROUGHNESS * 4.0).rgb;
+
+*/
+package probe
+
+import (
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// RoughnessLevels is the number of mip levels that get prefiltered for
+	// increasing roughness, from a mirror at level 0 to fully rough at
+	// level RoughnessLevels-1. Any smaller levels are fully rough as well.
+	RoughnessLevels = 5
+)
+
+// cubeMapTargets are the texture targets for the faces of a cube map in
+// the order of +X, -X, +Y, -Y, +Z, -Z.
+var cubeMapTargets = [fizzle.CubeMapFaceCount]graphics.Enum{
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_X,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_X,
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_Y,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_Y,
+	graphics.TEXTURE_CUBE_MAP_POSITIVE_Z,
+	graphics.TEXTURE_CUBE_MAP_NEGATIVE_Z,
+}
+
+// cubeMapDirections are the directions and up vectors to look along to
+// draw each face of a cube map.
+var cubeMapDirections = [fizzle.CubeMapFaceCount][2]mgl.Vec3{
+	{{1.0, 0.0, 0.0}, {0.0, -1.0, 0.0}},
+	{{-1.0, 0.0, 0.0}, {0.0, -1.0, 0.0}},
+	{{0.0, 1.0, 0.0}, {0.0, 0.0, 1.0}},
+	{{0.0, -1.0, 0.0}, {0.0, 0.0, -1.0}},
+	{{0.0, 0.0, 1.0}, {0.0, -1.0, 0.0}},
+	{{0.0, 0.0, -1.0}, {0.0, -1.0, 0.0}},
+}
+
+// DrawFunc draws the scene for one face of a probe's cube map. The
+// framebuffer and viewport are already set up but not cleared.
+type DrawFunc func(projection mgl.Mat4, view mgl.Mat4, camera fizzle.Camera)
+
+// faceCamera is the camera passed to a DrawFunc for a face of the cube map.
+type faceCamera struct {
+	position mgl.Vec3
+	view     mgl.Mat4
+}
+
+func (c *faceCamera) GetViewMatrix() mgl.Mat4 {
+	return c.view
+}
+
+func (c *faceCamera) GetPosition() mgl.Vec3 {
+	return c.position
+}
+
+// ReflectionProbe is a cube map of the scene as seen from a point, which
+// gets used for the reflections of objects within its radius.
+type ReflectionProbe struct {
+	// Position is where the scene is captured from.
+	Position mgl.Vec3
+
+	// Radius is the distance from the Position within which objects use
+	// the probe.
+	Radius float32
+
+	// Size is the width and height of each face of the cube map.
+	Size int32
+
+	// Near and Far are the clipping distances used to capture the scene.
+	Near float32
+	Far  float32
+
+	// Texture is the prefiltered cube map, which is 0 until the probe is
+	// captured or loaded.
+	Texture graphics.Texture
+}
+
+// NewReflectionProbe creates a new probe at the position that affects
+// objects within radius and captures faces of size by size pixels.
+func NewReflectionProbe(position mgl.Vec3, radius float32, size int32) *ReflectionProbe {
+	p := new(ReflectionProbe)
+	p.Position = position
+	p.Radius = radius
+	p.Size = size
+	p.Near = 0.1
+	p.Far = 1000.0
+	return p
+}
+
+// Destroy releases the cube map of the probe.
+func (p *ReflectionProbe) Destroy() {
+	if p.Texture != 0 {
+		fizzle.GetGraphics().DeleteTexture(p.Texture)
+		p.Texture = 0
+	}
+}
+
+// Contains returns true if the point is within the radius of the probe.
+func (p *ReflectionProbe) Contains(point mgl.Vec3) bool {
+	return point.Sub(p.Position).Len() <= p.Radius
+}
+
+// GetMipLevelCount returns the number of mip levels of the cube map.
+func (p *ReflectionProbe) GetMipLevelCount() int32 {
+	return getMipLevelCount(p.Size)
+}
+
+// getMipLevelCount returns the number of mip levels of a texture of the
+// size down to 1x1.
+func getMipLevelCount(size int32) int32 {
+	return int32(math.Log2(float64(size))) + 1
+}
+
+// Capture draws the scene into the six faces of a new cube map with draw
+// and prefilters its mip levels for roughness, replacing the probe's
+// Texture. The renderer is used to draw the prefiltering passes. The
+// viewport is set back to the renderer's resolution afterwards, the
+// default framebuffer is bound and depth testing and face culling are
+// left enabled.
+func (p *ReflectionProbe) Capture(r renderer.Renderer, draw DrawFunc) error {
+	if p.Size <= 0 || p.Size&(p.Size-1) != 0 {
+		return fmt.Errorf("Failed to capture the reflection probe; the size %d is not a power of two.", p.Size)
+	}
+
+	gfx := r.GetGraphics()
+	source := p.createCubeMap()
+
+	depth := gfx.GenRenderbuffer()
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, depth)
+	gfx.RenderbufferStorage(graphics.RENDERBUFFER, graphics.DEPTH_COMPONENT24, p.Size, p.Size)
+	gfx.BindRenderbuffer(graphics.RENDERBUFFER, 0)
+
+	fbo := gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, fbo)
+	gfx.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.RENDERBUFFER, depth)
+	defer func() {
+		gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		gfx.DeleteFramebuffer(fbo)
+		gfx.DeleteRenderbuffer(depth)
+		gfx.DeleteTexture(source)
+		width, height := r.GetResolution()
+		gfx.Viewport(0, 0, width, height)
+		gfx.Enable(graphics.DEPTH_TEST)
+		gfx.Enable(graphics.CULL_FACE)
+	}()
+
+	// draw the scene into each face
+	projection := mgl.Perspective(mgl.DegToRad(90.0), 1.0, p.Near, p.Far)
+	gfx.Viewport(0, 0, p.Size, p.Size)
+	gfx.Enable(graphics.DEPTH_TEST)
+	for face := 0; face < fizzle.CubeMapFaceCount; face++ {
+		gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, cubeMapTargets[face], source, 0)
+		status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+		if status != graphics.FRAMEBUFFER_COMPLETE {
+			return fmt.Errorf("Failed to create the framebuffer for the reflection probe (status 0x%x).", status)
+		}
+
+		camera := getFaceCamera(p.Position, face)
+		draw(projection, camera.view, camera)
+	}
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, source)
+	gfx.GenerateMipmap(graphics.TEXTURE_CUBE_MAP)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+
+	// prefilter every mip level of a new cube map from the source
+	prefiltered := p.createCubeMap()
+	err := prefilterCubeMap(r, source, p.Size, prefiltered, p.Size)
+	if err != nil {
+		gfx.DeleteTexture(prefiltered)
+		return err
+	}
+
+	p.Destroy()
+	p.Texture = prefiltered
+	return nil
+}
+
+// CaptureCached loads the probe from the cache file if it exists and
+// matches the probe's size. Otherwise it captures the probe and writes
+// the cache file.
+func (p *ReflectionProbe) CaptureCached(filePath string, r renderer.Renderer, draw DrawFunc) error {
+	if err := p.LoadCache(filePath); err == nil {
+		return nil
+	}
+
+	if err := p.Capture(r, draw); err != nil {
+		return err
+	}
+	return p.SaveCache(filePath)
+}
+
+// createCubeMap creates an empty cube map texture with all of its mip
+// levels.
+func (p *ReflectionProbe) createCubeMap() graphics.Texture {
+	return createCubeMapTexture(p.Size, getMipLevelCount(p.Size), graphics.RGBA, graphics.UNSIGNED_BYTE)
+}
+
+// createCubeMapTexture creates an empty cube map texture of the size with
+// the number of mip levels and the internal format and pixel type.
+func createCubeMapTexture(size int32, levels int32, internalFormat int32, ty graphics.Enum) graphics.Texture {
+	gfx := fizzle.GetGraphics()
+	tex := gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, tex)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR_MIPMAP_LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_R, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_BASE_LEVEL, 0)
+	gfx.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MAX_LEVEL, levels-1)
+	for level := int32(0); level < levels; level++ {
+		mipSize := size >> uint32(level)
+		for face := 0; face < fizzle.CubeMapFaceCount; face++ {
+			gfx.TexImage2D(cubeMapTargets[face], level, internalFormat, mipSize, mipSize, 0, graphics.RGBA, ty, nil, 0)
+		}
+	}
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, 0)
+	return tex
+}
+
+// getFaceCamera returns the camera looking out of the face of the cube
+// map from the position.
+func getFaceCamera(position mgl.Vec3, face int) *faceCamera {
+	c := new(faceCamera)
+	c.position = position
+	c.view = mgl.LookAtV(position, position.Add(cubeMapDirections[face][0]), cubeMapDirections[face][1])
+	return c
+}
+
+// bindSource binds the captured cube map and the prefilter settings for
+// the prefilter shader.
+func bindSource(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, source graphics.Texture, size float32, roughness float32, texturesBound *int32) {
+	shaderSource := shader.GetUniformLocation("PROBE_SOURCE")
+	if shaderSource >= 0 {
+		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+		gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, source)
+		gfx.Uniform1i(shaderSource, *texturesBound)
+		*texturesBound++
+	}
+
+	shaderSize := shader.GetUniformLocation("PROBE_SOURCE_SIZE")
+	if shaderSize >= 0 {
+		gfx.Uniform1f(shaderSize, size)
+	}
+
+	shaderRoughness := shader.GetUniformLocation("PROBE_ROUGHNESS")
+	if shaderRoughness >= 0 {
+		gfx.Uniform1f(shaderRoughness, roughness)
+	}
+}
+
+// BindProbes sets the EnvironmentTex of the material of each Renderable,
+// and of their children, to the cube map of the closest probe that
+// contains it. Renderables outside of every probe get no cube map. A
+// Renderable that needs a different cube map than its material has gets
+// its own material with InstanceMaterial so that the clones sharing the
+// material are left alone. Call it again when objects or probes move.
+func BindProbes(probes []*ReflectionProbe, renderables ...*fizzle.Renderable) {
+	for _, r := range renderables {
+		bindProbe(probes, r)
+	}
+}
+
+// bindProbe binds the closest probe to the renderable and its children.
+func bindProbe(probes []*ReflectionProbe, r *fizzle.Renderable) {
+	if r.Material != nil {
+		position := r.GetTransformMat4().Col(3).Vec3()
+		var closest *ReflectionProbe
+		var closestDistance float32
+		for _, p := range probes {
+			if p.Texture == 0 || !p.Contains(position) {
+				continue
+			}
+			distance := position.Sub(p.Position).Len()
+			if closest == nil || distance < closestDistance {
+				closest = p
+				closestDistance = distance
+			}
+		}
+
+		var environment graphics.Texture
+		if closest != nil {
+			environment = closest.Texture
+		}
+
+		// materials are shared between clones, so give the renderable its
+		// own material before changing it
+		if r.Material.EnvironmentTex != environment {
+			r.InstanceMaterial().EnvironmentTex = environment
+		}
+	}
+
+	for _, child := range r.Children {
+		bindProbe(probes, child)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package probe
+
+import (
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// PrefilterShaderV330 is the vertex shader for prefiltering a captured
+	// cube map. It is drawn on a cube around the origin and passes the
+	// direction to each fragment.
+	PrefilterShaderV330 = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+
+    in vec3 VERTEX_POSITION;
+
+    out vec3 vs_direction;
+
+    void main(void) {
+    	vs_direction = VERTEX_POSITION;
+    	gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+    }
+    `
+
+	// PrefilterShaderF330 is the fragment shader for prefiltering a captured
+	// cube map. It convolves the source cube map with the GGX distribution
+	// for the roughness using importance sampling, reading from lower mip
+	// levels of the source for samples that cover more of the sphere to
+	// avoid bright speckles.
+	PrefilterShaderF330 = `#version 330
+    precision highp float;
+
+    const int SAMPLE_COUNT = 64;
+    const float PI = 3.14159265359;
+
+    uniform samplerCube PROBE_SOURCE;
+    uniform float PROBE_SOURCE_SIZE;
+    uniform float PROBE_ROUGHNESS;
+
+    in vec3 vs_direction;
+    out vec4 frag_color;
+
+    float radicalInverse(uint bits) {
+    	bits = (bits << 16u) | (bits >> 16u);
+    	bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+    	bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+    	bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+    	bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+    	return float(bits) * 2.3283064365386963e-10;
+    }
+
+    vec3 importanceSampleGGX(vec2 xi, vec3 n, float roughness) {
+    	float a = roughness * roughness;
+    	float phi = 2.0 * PI * xi.x;
+    	float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a*a - 1.0) * xi.y));
+    	float sinTheta = sqrt(1.0 - cosTheta*cosTheta);
+    	vec3 h = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+
+    	vec3 up = abs(n.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+    	vec3 tangent = normalize(cross(up, n));
+    	vec3 bitangent = cross(n, tangent);
+    	return normalize(tangent * h.x + bitangent * h.y + n * h.z);
+    }
+
+    float distributionGGX(float nDotH, float roughness) {
+    	float a = roughness * roughness;
+    	float a2 = a * a;
+    	float d = nDotH * nDotH * (a2 - 1.0) + 1.0;
+    	return a2 / (PI * d * d);
+    }
+
+    void main (void) {
+    	/* the view direction is assumed to be the normal for the lobe */
+    	vec3 n = normalize(vs_direction);
+    	if (PROBE_ROUGHNESS <= 0.0) {
+    		frag_color = vec4(textureLod(PROBE_SOURCE, n, 0.0).rgb, 1.0);
+    		return;
+    	}
+
+    	float texelSolidAngle = 4.0 * PI / (6.0 * PROBE_SOURCE_SIZE * PROBE_SOURCE_SIZE);
+    	vec3 color = vec3(0.0);
+    	float totalWeight = 0.0;
+    	for (int i = 0; i < SAMPLE_COUNT; i++) {
+    		vec2 xi = vec2(float(i) / float(SAMPLE_COUNT), radicalInverse(uint(i)));
+    		vec3 h = importanceSampleGGX(xi, n, PROBE_ROUGHNESS);
+    		vec3 l = normalize(2.0 * dot(n, h) * h - n);
+    		float nDotL = dot(n, l);
+    		if (nDotL <= 0.0) {
+    			continue;
+    		}
+
+    		float nDotH = max(dot(n, h), 0.0);
+    		float pdf = distributionGGX(nDotH, PROBE_ROUGHNESS) * 0.25 + 0.0001;
+    		float sampleSolidAngle = 1.0 / (float(SAMPLE_COUNT) * pdf);
+    		float lod = 0.5 * log2(sampleSolidAngle / texelSolidAngle);
+
+    		color += textureLod(PROBE_SOURCE, l, max(lod, 0.0)).rgb * nDotL;
+    		totalWeight += nDotL;
+    	}
+    	frag_color = vec4(color / max(totalWeight, 0.0001), 1.0);
+    }
+    `
+
+	// IrradianceShaderF330 is the fragment shader for convolving an
+	// environment cube map into a diffuse irradiance cube map. It is drawn
+	// with PrefilterShaderV330 and integrates the cosine weighted radiance
+	// over the hemisphere around each direction, reading from a lower mip
+	// level of the source since the result is very blurry anyway.
+	IrradianceShaderF330 = `#version 330
+    precision highp float;
+
+    const float PI = 3.14159265359;
+    const float SAMPLE_DELTA = 0.05;
+
+    uniform samplerCube PROBE_SOURCE;
+    uniform float PROBE_SOURCE_SIZE;
+
+    in vec3 vs_direction;
+    out vec4 frag_color;
+
+    void main (void) {
+    	vec3 n = normalize(vs_direction);
+    	vec3 up = abs(n.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(1.0, 0.0, 0.0);
+    	vec3 right = normalize(cross(up, n));
+    	up = cross(n, right);
+
+    	/* sample a level that has about 32 texels per face */
+    	float lod = max(log2(PROBE_SOURCE_SIZE / 32.0), 0.0);
+
+    	vec3 irradiance = vec3(0.0);
+    	float sampleCount = 0.0;
+    	for (float phi = 0.0; phi < 2.0 * PI; phi += SAMPLE_DELTA) {
+    		for (float theta = 0.0; theta < 0.5 * PI; theta += SAMPLE_DELTA) {
+    			vec3 tangentSample = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+    			vec3 l = tangentSample.x * right + tangentSample.y * up + tangentSample.z * n;
+    			irradiance += textureLod(PROBE_SOURCE, l, lod).rgb * cos(theta) * sin(theta);
+    			sampleCount += 1.0;
+    		}
+    	}
+    	frag_color = vec4(PI * irradiance / sampleCount, 1.0);
+    }
+    `
+
+	// EquirectShaderF330 is the fragment shader for converting an
+	// equirectangular environment image into a cube map. It is drawn with
+	// PrefilterShaderV330 and uses the same layout as the panoramas loaded
+	// by fizzle.LoadCubeMapFromFile, with -Z in the center of the image.
+	EquirectShaderF330 = `#version 330
+    precision highp float;
+
+    const float PI = 3.14159265359;
+
+    uniform sampler2D PROBE_EQUIRECT;
+
+    in vec3 vs_direction;
+    out vec4 frag_color;
+
+    void main (void) {
+    	vec3 d = normalize(vs_direction);
+    	vec2 uv = vec2(atan(d.x, -d.z) / (2.0 * PI) + 0.5, asin(d.y) / PI + 0.5);
+    	frag_color = vec4(texture(PROBE_EQUIRECT, uv).rgb, 1.0);
+    }
+    `
+
+	// BRDFLutShaderV330 is the vertex shader for baking the BRDF lookup
+	// table. It is drawn on a quad covering the viewport.
+	BRDFLutShaderV330 = `#version 330
+    precision highp float;
+
+    in vec3 VERTEX_POSITION;
+
+    out vec2 vs_uv;
+
+    void main(void) {
+    	vs_uv = VERTEX_POSITION.xy * 0.5 + 0.5;
+    	gl_Position = vec4(VERTEX_POSITION.xy, 0.0, 1.0);
+    }
+    `
+
+	// BRDFLutShaderF330 is the fragment shader for baking the BRDF lookup
+	// table of the split sum approximation. The U axis is the cosine of the
+	// angle between the normal and view direction and the V axis is the
+	// roughness. The red channel is the scale and the green channel is the
+	// bias applied to the Fresnel reflectance at normal incidence.
+	BRDFLutShaderF330 = `#version 330
+    precision highp float;
+
+    const int SAMPLE_COUNT = 1024;
+    const float PI = 3.14159265359;
+
+    in vec2 vs_uv;
+    out vec4 frag_color;
+
+    float radicalInverse(uint bits) {
+    	bits = (bits << 16u) | (bits >> 16u);
+    	bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+    	bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+    	bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+    	bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+    	return float(bits) * 2.3283064365386963e-10;
+    }
+
+    vec3 importanceSampleGGX(vec2 xi, float roughness) {
+    	float a = roughness * roughness;
+    	float phi = 2.0 * PI * xi.x;
+    	float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a*a - 1.0) * xi.y));
+    	float sinTheta = sqrt(1.0 - cosTheta*cosTheta);
+    	return vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+    }
+
+    float geometrySchlickGGX(float nDotV, float roughness) {
+    	/* image based lighting uses a different k than direct lighting */
+    	float k = (roughness * roughness) / 2.0;
+    	return nDotV / (nDotV * (1.0 - k) + k);
+    }
+
+    void main (void) {
+    	float nDotV = max(vs_uv.x, 0.0001);
+    	float roughness = vs_uv.y;
+    	vec3 v = vec3(sqrt(1.0 - nDotV*nDotV), 0.0, nDotV);
+
+    	float scale = 0.0;
+    	float bias = 0.0;
+    	for (int i = 0; i < SAMPLE_COUNT; i++) {
+    		vec2 xi = vec2(float(i) / float(SAMPLE_COUNT), radicalInverse(uint(i)));
+    		vec3 h = importanceSampleGGX(xi, roughness);
+    		vec3 l = normalize(2.0 * dot(v, h) * h - v);
+
+    		float nDotL = max(l.z, 0.0);
+    		float nDotH = max(h.z, 0.0);
+    		float vDotH = max(dot(v, h), 0.0);
+    		if (nDotL > 0.0) {
+    			float g = geometrySchlickGGX(nDotV, roughness) * geometrySchlickGGX(nDotL, roughness);
+    			float gVis = (g * vDotH) / (nDotH * nDotV);
+    			float fc = pow(1.0 - vDotH, 5.0);
+    			scale += (1.0 - fc) * gVis;
+    			bias += fc * gVis;
+    		}
+    	}
+    	frag_color = vec4(scale / float(SAMPLE_COUNT), bias / float(SAMPLE_COUNT), 0.0, 1.0);
+    }
+    `
+)
+
+// CreatePrefilterShader creates the shader that prefilters captured cube
+// maps for each roughness level.
+func CreatePrefilterShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(PrefilterShaderV330, PrefilterShaderF330, nil)
+}
+
+// CreateIrradianceShader creates the shader that convolves environment
+// cube maps into diffuse irradiance cube maps.
+func CreateIrradianceShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(PrefilterShaderV330, IrradianceShaderF330, nil)
+}
+
+// CreateEquirectShader creates the shader that converts equirectangular
+// environment images into cube maps.
+func CreateEquirectShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(PrefilterShaderV330, EquirectShaderF330, nil)
+}
+
+// CreateBRDFLutShader creates the shader that bakes the BRDF lookup table.
+func CreateBRDFLutShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(BRDFLutShaderV330, BRDFLutShaderF330, nil)
+}
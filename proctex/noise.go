@@ -0,0 +1,155 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package proctex
+
+import (
+	"image"
+	"math"
+	"math/rand"
+)
+
+// perlinGradients are the directions of the gradients at the lattice points.
+var perlinGradients = [8][2]float64{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{math.Sqrt2 / 2, math.Sqrt2 / 2}, {-math.Sqrt2 / 2, math.Sqrt2 / 2},
+	{math.Sqrt2 / 2, -math.Sqrt2 / 2}, {-math.Sqrt2 / 2, -math.Sqrt2 / 2},
+}
+
+// newPermutation returns a shuffled table of the numbers 0-255 repeated
+// twice so that lookups can be chained without wrapping.
+func newPermutation(seed int64) []int {
+	rng := rand.New(rand.NewSource(seed))
+	perm := make([]int, 512)
+	for i, p := range rng.Perm(256) {
+		perm[i] = p
+		perm[i+256] = p
+	}
+	return perm
+}
+
+// PerlinNoise creates a grayscale image of Perlin noise. The frequency is
+// the number of noise cells across the image for the first octave and each
+// following octave doubles it while its strength gets multiplied by the
+// persistence. The seed picks the pattern of the noise.
+func PerlinNoise(size int32, frequency int32, octaves int32, persistence float32, seed int64) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	perm := newPermutation(seed)
+
+	for y := int32(0); y < size; y++ {
+		for x := int32(0); x < size; x++ {
+			value := 0.0
+			amplitude := 1.0
+			total := 0.0
+			period := int(frequency)
+			for o := int32(0); o < octaves; o++ {
+				fx := float64(x) * float64(period) / float64(size)
+				fy := float64(y) * float64(period) / float64(size)
+				value += perlin(perm, fx, fy, period) * amplitude
+				total += amplitude
+				amplitude *= float64(persistence)
+				period *= 2
+			}
+
+			// perlin noise is in the range of about [-0.7, 0.7] so scale
+			// it up a bit into [0, 1]
+			if total > 0.0 {
+				value /= total
+			}
+			setGray(img, x, y, float32(value*0.7+0.5))
+		}
+	}
+	return img
+}
+
+// perlin returns the noise at the point, with the lattice wrapping around
+// every period cells so that the noise tiles.
+func perlin(perm []int, x, y float64, period int) float64 {
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	tx := x - float64(x0)
+	ty := y - float64(y0)
+
+	ix0 := wrap(x0, period)
+	iy0 := wrap(y0, period)
+	ix1 := wrap(x0+1, period)
+	iy1 := wrap(y0+1, period)
+
+	n00 := perlinDot(perm, ix0, iy0, tx, ty)
+	n10 := perlinDot(perm, ix1, iy0, tx-1.0, ty)
+	n01 := perlinDot(perm, ix0, iy1, tx, ty-1.0)
+	n11 := perlinDot(perm, ix1, iy1, tx-1.0, ty-1.0)
+
+	u := fade(tx)
+	v := fade(ty)
+	bottom := n00 + (n10-n00)*u
+	top := n01 + (n11-n01)*u
+	return bottom + (top-bottom)*v
+}
+
+// perlinDot returns the dot product of the gradient at the lattice point
+// and the offset from it.
+func perlinDot(perm []int, ix, iy int, dx, dy float64) float64 {
+	g := perlinGradients[perm[perm[ix&255]+iy&255]&7]
+	return g[0]*dx + g[1]*dy
+}
+
+// fade is the quintic curve used to smooth the interpolation between
+// lattice points.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6.0-15.0) + 10.0)
+}
+
+// wrap returns i modulo the period, keeping it positive.
+func wrap(i, period int) int {
+	if period <= 0 {
+		return i
+	}
+	return ((i % period) + period) % period
+}
+
+// WorleyNoise creates a grayscale image of Worley (cellular) noise with a
+// random point in each of the cells x cells grid squares. Each pixel is the
+// distance to the closest point, so the points are black and the value
+// brightens toward the cell borders. The seed picks the placement of the
+// points.
+func WorleyNoise(size int32, cells int32, seed int64) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	if cells < 1 {
+		cells = 1
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	points := make([][2]float64, cells*cells)
+	for i := range points {
+		points[i] = [2]float64{rng.Float64(), rng.Float64()}
+	}
+
+	for y := int32(0); y < size; y++ {
+		for x := int32(0); x < size; x++ {
+			fx := float64(x) * float64(cells) / float64(size)
+			fy := float64(y) * float64(cells) / float64(size)
+			cx := int(fx)
+			cy := int(fy)
+
+			// the closest point is always in this cell or one next to it;
+			// the cells wrap so the noise tiles
+			closest := math.MaxFloat64
+			for oy := -1; oy <= 1; oy++ {
+				for ox := -1; ox <= 1; ox++ {
+					p := points[wrap(cy+oy, int(cells))*int(cells)+wrap(cx+ox, int(cells))]
+					dx := float64(cx+ox) + p[0] - fx
+					dy := float64(cy+oy) + p[1] - fy
+					dist := dx*dx + dy*dy
+					if dist < closest {
+						closest = dist
+					}
+				}
+			}
+
+			// the distance to the closest point is under about one cell
+			setGray(img, x, y, float32(math.Sqrt(closest)))
+		}
+	}
+	return img
+}
@@ -0,0 +1,47 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package proctex
+
+import (
+	"image"
+	"math"
+)
+
+// NormalMapFromHeight creates a tangent space normal map from the red
+// channel of a height map, such as one made by PerlinNoise. The strength
+// scales how steep the slopes get. The edges of the height map wrap so that
+// tiling height maps make tiling normal maps.
+func NormalMapFromHeight(height *image.NRGBA, strength float32) *image.NRGBA {
+	w := height.Bounds().Dx()
+	h := height.Bounds().Dy()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	sample := func(x, y int) float64 {
+		x = wrap(x, w)
+		y = wrap(y, h)
+		return float64(height.Pix[y*height.Stride+x*4]) / 255.0
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// sobel filter for the slope in each direction
+			dx := (sample(x+1, y-1) + 2.0*sample(x+1, y) + sample(x+1, y+1)) -
+				(sample(x-1, y-1) + 2.0*sample(x-1, y) + sample(x-1, y+1))
+			dy := (sample(x-1, y+1) + 2.0*sample(x, y+1) + sample(x+1, y+1)) -
+				(sample(x-1, y-1) + 2.0*sample(x, y-1) + sample(x+1, y-1))
+
+			nx := -dx * float64(strength)
+			ny := -dy * float64(strength)
+			nz := 1.0
+			length := math.Sqrt(nx*nx + ny*ny + nz*nz)
+
+			offset := y*img.Stride + x*4
+			img.Pix[offset+0] = toByte(float32(nx/length*0.5 + 0.5))
+			img.Pix[offset+1] = toByte(float32(ny/length*0.5 + 0.5))
+			img.Pix[offset+2] = toByte(float32(nz/length*0.5 + 0.5))
+			img.Pix[offset+3] = 255
+		}
+	}
+	return img
+}
@@ -0,0 +1,103 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package proctex generates textures procedurally, such as noise, gradients,
+checker patterns and normal maps from height maps. They are useful for
+prototyping and for default assets when no texture files are around.
+
+The generators return square NRGBA images with the first row at the bottom
+of the texture, the same orientation as textures loaded by fizzle, so that
+they can be changed or combined before CreateTexture uploads them to OpenGL.
+The noise and pattern images tile seamlessly.
+
+*/
+package proctex
+
+import (
+	"image"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// CreateTexture uploads a square image to a new OpenGL texture that repeats,
+// uses trilinear filtering and has mipmaps generated.
+func CreateTexture(img *image.NRGBA) graphics.Texture {
+	size := int32(img.Bounds().Dx())
+	tex := fizzle.LoadRGBAToTextureExt(img.Pix, size, graphics.LINEAR, graphics.LINEAR_MIPMAP_LINEAR, graphics.REPEAT, graphics.REPEAT)
+	fizzle.GenerateMipmaps(tex)
+	return tex
+}
+
+// Checker creates an image of a checker pattern with cells squares across
+// alternating between the two colors.
+func Checker(size int32, cells int32, a, b mgl.Vec4) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	for y := int32(0); y < size; y++ {
+		for x := int32(0); x < size; x++ {
+			cx := x * cells / size
+			cy := y * cells / size
+			if (cx+cy)%2 == 0 {
+				setPixel(img, x, y, a)
+			} else {
+				setPixel(img, x, y, b)
+			}
+		}
+	}
+	return img
+}
+
+// Gradient creates an image blending from the start color to the end
+// color, going from the bottom to the top or from the left to the right
+// if horizontal is true.
+func Gradient(size int32, start, end mgl.Vec4, horizontal bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(size), int(size)))
+	for y := int32(0); y < size; y++ {
+		for x := int32(0); x < size; x++ {
+			t := float32(y)
+			if horizontal {
+				t = float32(x)
+			}
+			if size > 1 {
+				t /= float32(size - 1)
+			}
+			setPixel(img, x, y, start.Mul(1.0-t).Add(end.Mul(t)))
+		}
+	}
+	return img
+}
+
+// setPixel sets a pixel of the image to a color with components in the
+// range of [0, 1].
+func setPixel(img *image.NRGBA, x, y int32, c mgl.Vec4) {
+	offset := int(y)*img.Stride + int(x)*4
+	for i := 0; i < 4; i++ {
+		img.Pix[offset+i] = toByte(c[i])
+	}
+}
+
+// setGray sets a pixel of the image to an opaque gray with the value in
+// the range of [0, 1].
+func setGray(img *image.NRGBA, x, y int32, v float32) {
+	offset := int(y)*img.Stride + int(x)*4
+	b := toByte(v)
+	img.Pix[offset+0] = b
+	img.Pix[offset+1] = b
+	img.Pix[offset+2] = b
+	img.Pix[offset+3] = 255
+}
+
+// toByte converts a value in the range of [0, 1] to a byte, clamping it
+// to the range.
+func toByte(v float32) uint8 {
+	if v <= 0.0 {
+		return 0
+	}
+	if v >= 1.0 {
+		return 255
+	}
+	return uint8(v*255.0 + 0.5)
+}
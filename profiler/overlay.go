@@ -0,0 +1,292 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package profiler
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+	"github.com/tbogdala/fizzle/renderer/forward"
+	"github.com/tbogdala/fizzle/text"
+)
+
+const (
+	// DefaultTextRefresh is how often the text of the overlay gets rebuilt
+	// so that the numbers are readable.
+	DefaultTextRefresh = 250 * time.Millisecond
+
+	// overlayPadding is the space in pixels around the contents of the
+	// overlay's background.
+	overlayPadding = 6.0
+)
+
+// Overlay draws the statistics of a Profiler and a graph of the recent frame
+// times in the top left corner of the screen.
+type Overlay struct {
+	// Profiler supplies the statistics to show.
+	Profiler *Profiler
+
+	// Visible determines if Draw shows the overlay.
+	Visible bool
+
+	// Margin is the distance in pixels from the top left corner of the screen.
+	Margin float32
+
+	// TextSize is the height of the text in pixels.
+	TextSize float32
+
+	// GraphWidth and GraphHeight are the size of the frame time graph in pixels.
+	GraphWidth  float32
+	GraphHeight float32
+
+	// GraphScale is the frame time in milliseconds at the top of the graph.
+	GraphScale float32
+
+	// TargetFrameTime is the frame time in milliseconds marked by a line on
+	// the graph, which defaults to 60 frames per second.
+	TargetFrameTime float32
+
+	// TextRefresh is how often the text gets rebuilt.
+	TextRefresh time.Duration
+
+	// Colors of the parts of the overlay.
+	BackgroundColor mgl.Vec4
+	TextColor       mgl.Vec4
+	FrameBarColor   mgl.Vec4
+	GPUBarColor     mgl.Vec4
+	TargetColor     mgl.Vec4
+
+	label       *text.Text
+	background  *fizzle.Renderable
+	frameBars   *fizzle.Renderable
+	gpuBars     *fizzle.Renderable
+	target      *fizzle.Renderable
+	textShader  *fizzle.RenderShader
+	colorShader *fizzle.RenderShader
+
+	lastRefresh time.Time
+	frameTimes  []float32
+	gpuTimes    []float32
+	rects       []float32
+}
+
+// NewOverlay creates a new Overlay for the Profiler that draws its text with
+// the font. The overlay starts out hidden.
+func NewOverlay(p *Profiler, font *text.Font) (*Overlay, error) {
+	o := new(Overlay)
+	o.Profiler = p
+	o.Margin = 8.0
+	o.TextSize = 14.0
+	o.GraphWidth = 240.0
+	o.GraphHeight = 60.0
+	o.GraphScale = 1000.0 / 30.0
+	o.TargetFrameTime = 1000.0 / 60.0
+	o.TextRefresh = DefaultTextRefresh
+	o.BackgroundColor = mgl.Vec4{0.0, 0.0, 0.0, 0.6}
+	o.TextColor = mgl.Vec4{1.0, 1.0, 1.0, 1.0}
+	o.FrameBarColor = mgl.Vec4{0.2, 0.8, 0.2, 0.9}
+	o.GPUBarColor = mgl.Vec4{0.9, 0.6, 0.1, 0.9}
+	o.TargetColor = mgl.Vec4{0.9, 0.1, 0.1, 0.9}
+
+	var err error
+	o.textShader, err = text.CreateSDFShader()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the text shader for the profiler overlay: %v", err)
+	}
+	o.colorShader, err = forward.CreateColorShader()
+	if err != nil {
+		o.textShader.Destroy()
+		return nil, fmt.Errorf("Failed to create the color shader for the profiler overlay: %v", err)
+	}
+
+	o.label = text.NewText(font, "", o.TextSize)
+	o.label.Renderable.Material.Shader = o.textShader
+	o.background = o.newQuads()
+	o.frameBars = o.newQuads()
+	o.gpuBars = o.newQuads()
+	o.target = o.newQuads()
+
+	return o, nil
+}
+
+// Destroy releases the OpenGL objects of the overlay but not the font.
+func (o *Overlay) Destroy() {
+	o.label.Destroy()
+	o.background.Destroy()
+	o.frameBars.Destroy()
+	o.gpuBars.Destroy()
+	o.target.Destroy()
+	o.textShader.Destroy()
+	o.colorShader.Destroy()
+}
+
+// Toggle shows the overlay if it is hidden and hides it otherwise. It can be
+// bound to a key directly.
+func (o *Overlay) Toggle() {
+	o.Visible = !o.Visible
+}
+
+// Draw draws the overlay over the screen with the renderer if it is
+// visible. It should be called after Profiler.EndFrame so that the overlay
+// isn't counted in the statistics. It disables depth testing and enables
+// blending while drawing and leaves depth testing enabled and blending
+// disabled afterwards.
+func (o *Overlay) Draw(r renderer.Renderer) {
+	if !o.Visible {
+		return
+	}
+
+	width, height := r.GetResolution()
+	o.update(float32(height))
+
+	gfx := r.GetGraphics()
+	gfx.Disable(graphics.DEPTH_TEST)
+	gfx.Enable(graphics.BLEND)
+	gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+
+	projection := text.GetScreenProjection(width, height)
+	view := mgl.Ident4()
+	r.DrawRenderable(o.background, nil, projection, view, nil)
+	r.DrawRenderable(o.frameBars, nil, projection, view, nil)
+	if o.Profiler.HasGPUTimer() {
+		r.DrawRenderable(o.gpuBars, nil, projection, view, nil)
+	}
+	r.DrawRenderable(o.target, nil, projection, view, nil)
+	r.DrawRenderable(o.label.Renderable, nil, projection, view, nil)
+
+	gfx.Disable(graphics.BLEND)
+	gfx.Enable(graphics.DEPTH_TEST)
+}
+
+// update rebuilds the text and the graph for a screen of the height given.
+func (o *Overlay) update(screenHeight float32) {
+	if time.Since(o.lastRefresh) >= o.TextRefresh {
+		o.label.Size = o.TextSize
+		o.label.SetString(o.buildText())
+		o.lastRefresh = time.Now()
+	}
+	o.label.Renderable.Material.DiffuseColor = o.TextColor
+	o.background.Material.DiffuseColor = o.BackgroundColor
+	o.frameBars.Material.DiffuseColor = o.FrameBarColor
+	o.gpuBars.Material.DiffuseColor = o.GPUBarColor
+	o.target.Material.DiffuseColor = o.TargetColor
+
+	left := o.Margin + overlayPadding
+	top := screenHeight - o.Margin
+	o.label.Renderable.Location = mgl.Vec3{left, top - overlayPadding - o.label.Renderable.BoundingRect.Top[1], 0.0}
+
+	graphTop := top - overlayPadding*2.0 - o.label.Height
+	graphBottom := graphTop - o.GraphHeight
+	contentWidth := o.GraphWidth
+	if o.label.Width > contentWidth {
+		contentWidth = o.label.Width
+	}
+	o.setQuads(o.background, []float32{
+		o.Margin, graphBottom - overlayPadding, o.Margin + contentWidth + overlayPadding*2.0, top,
+	})
+
+	// the frame and gpu times share the width of each bar
+	o.frameTimes = o.Profiler.GetFrameTimes(o.frameTimes)
+	o.gpuTimes = o.Profiler.GetGPUTimes(o.gpuTimes)
+	barWidth := o.GraphWidth / float32(len(o.frameTimes))
+	o.setQuads(o.frameBars, o.buildBars(o.frameTimes, left, graphBottom, barWidth, 0.0, 1.0))
+	o.setQuads(o.gpuBars, o.buildBars(o.gpuTimes, left, graphBottom, barWidth, 0.25, 0.75))
+
+	targetY := graphBottom + o.scaleTime(o.TargetFrameTime)
+	o.setQuads(o.target, []float32{left, targetY, left + o.GraphWidth, targetY + 1.0})
+}
+
+// buildText returns the lines of statistics shown above the graph.
+func (o *Overlay) buildText() string {
+	p := o.Profiler
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FPS %.1f (%.2f ms)\n", p.GetFPS(), p.GetAverageFrameTime())
+	fmt.Fprintf(&buf, "CPU %.2f ms", p.GetLastCPUTime())
+	if p.HasGPUTimer() {
+		fmt.Fprintf(&buf, "  GPU %.2f ms", p.GetLastGPUTime())
+	}
+	fmt.Fprintf(&buf, "\nDraw calls %d  Triangles %d", p.Stats.DrawCalls, p.Stats.Triangles)
+	for _, s := range p.Sections {
+		fmt.Fprintf(&buf, "\n%s %.2f ms", s.Name, s.Average)
+	}
+	for _, c := range p.Counters {
+		fmt.Fprintf(&buf, "\n%s %d", c.Name, c.Value)
+	}
+	return buf.String()
+}
+
+// buildBars returns the rectangles for the bars of a time graph. The start
+// and end are the fraction of each bar's slot that the bar covers.
+func (o *Overlay) buildBars(times []float32, left, bottom, barWidth, start, end float32) []float32 {
+	o.rects = o.rects[:0]
+	for i, t := range times {
+		if t <= 0.0 {
+			continue
+		}
+		x := left + float32(i)*barWidth
+		o.rects = append(o.rects, x+barWidth*start, bottom, x+barWidth*end, bottom+o.scaleTime(t))
+	}
+	return o.rects
+}
+
+// scaleTime returns the height in pixels of a time on the graph, clamped
+// to the top of the graph.
+func (o *Overlay) scaleTime(t float32) float32 {
+	h := t / o.GraphScale * o.GraphHeight
+	if h > o.GraphHeight {
+		h = o.GraphHeight
+	}
+	return h
+}
+
+// newQuads creates a Renderable for drawing rectangles in a flat color.
+func (o *Overlay) newQuads() *fizzle.Renderable {
+	gfx := fizzle.GetGraphics()
+	r := fizzle.NewRenderable()
+	r.Material = fizzle.NewMaterial()
+	r.Material.Shader = o.colorShader
+	r.Core.VertVBO = gfx.GenBuffer()
+	r.Core.ElementsVBO = gfx.GenBuffer()
+	return r
+}
+
+// setQuads replaces the rectangles of a Renderable made by newQuads. The
+// rects are packed as (x0, y0, x1, y1) for each rectangle.
+func (o *Overlay) setQuads(r *fizzle.Renderable, rects []float32) {
+	const floatSize = 4
+	const uintSize = 4
+
+	quadCount := len(rects) / 4
+	r.FaceCount = uint32(quadCount * 2)
+	if quadCount == 0 {
+		return
+	}
+
+	verts := make([]float32, 0, quadCount*4*3)
+	indexes := make([]uint32, 0, quadCount*6)
+	for i := 0; i < quadCount; i++ {
+		x0, y0, x1, y1 := rects[i*4], rects[i*4+1], rects[i*4+2], rects[i*4+3]
+		base := uint32(len(verts) / 3)
+		verts = append(verts,
+			x0, y0, 0.0,
+			x1, y0, 0.0,
+			x0, y1, 0.0,
+			x1, y1, 0.0,
+		)
+		indexes = append(indexes, base, base+1, base+2, base+1, base+3, base+2)
+	}
+
+	gfx := fizzle.GetGraphics()
+	r.Core.VertVBOOffset = 0
+	r.Core.VBOStride = floatSize * 3
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(verts), gfx.Ptr(&verts[0]), graphics.STREAM_DRAW)
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	gfx.BufferData(graphics.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gfx.Ptr(&indexes[0]), graphics.STREAM_DRAW)
+}
@@ -0,0 +1,319 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package profiler collects per frame performance statistics and draws them
+as an on-screen overlay for quick performance triage.
+
+A Profiler records the frame times, the GPU time of each frame from timer
+queries when the graphics provider supports them, the draw calls and
+triangles submitted through the renderers, named CPU timing sections and
+any counters the application registers, like particle counts.
+
+An Overlay draws the statistics and a graph of the recent frame times on
+top of the screen with any Renderer:
+
+	prof := profiler.NewProfiler(profiler.DefaultHistorySize)
+	prof.AddCounter("particles", particleSystem.GetParticleCount)
+	overlay, err := profiler.NewOverlay(prof, font)
+	kbModel.BindTrigger(glfw.KeyF3, overlay.Toggle)
+	...
+	for !window.ShouldClose() {
+		prof.BeginFrame()
+		prof.BeginSection("update")
+		...
+		prof.EndSection("update")
+		... draw the scene ...
+		prof.EndFrame()
+		overlay.Draw(renderer)
+		window.SwapBuffers()
+	}
+
+*/
+package profiler
+
+import (
+	"time"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// DefaultHistorySize is the default number of frames a Profiler keeps
+	// the timings of.
+	DefaultHistorySize = 120
+
+	// gpuQueryCount is the number of timer queries cycled through so that
+	// results are read a few frames after they were issued without
+	// stalling the pipeline.
+	gpuQueryCount = 4
+
+	// sectionSmoothing is the weight of a new sample in the smoothed time
+	// of a Section.
+	sectionSmoothing = 0.1
+)
+
+// Section is a named part of the frame timed on the CPU.
+type Section struct {
+	// Name identifies the section.
+	Name string
+
+	// Last is the duration of the last time the section ran in milliseconds.
+	Last float32
+
+	// Average is the smoothed duration of the section in milliseconds.
+	Average float32
+
+	start time.Time
+}
+
+// Counter is a named value polled once per frame.
+type Counter struct {
+	// Name identifies the counter.
+	Name string
+
+	// Value is the value the counter returned at the end of the last frame.
+	Value int
+
+	poll func() int
+}
+
+// Profiler collects the performance statistics of frames.
+type Profiler struct {
+	// Sections are the CPU timing sections in the order they were first used.
+	Sections []*Section
+
+	// Counters are the polled counters in the order they were added.
+	Counters []*Counter
+
+	// Stats are the draw statistics of the last frame.
+	Stats renderer.FrameStats
+
+	frameTimes   []float32
+	gpuTimes     []float32
+	historyIndex int
+	frameStart   time.Time
+	cpuTime      float32
+	inFrame      bool
+
+	gpuQueries  [gpuQueryCount]uint32
+	gpuPending  [gpuQueryCount]bool
+	gpuCurrent  int
+	gpuLastTime float32
+}
+
+// NewProfiler creates a new Profiler that keeps the timings of the last
+// historySize frames. GPU timer queries are used if the graphics provider
+// supports them.
+func NewProfiler(historySize int) *Profiler {
+	if historySize < 1 {
+		historySize = DefaultHistorySize
+	}
+
+	p := new(Profiler)
+	p.Sections = []*Section{}
+	p.Counters = []*Counter{}
+	p.frameTimes = make([]float32, historySize)
+	p.gpuTimes = make([]float32, historySize)
+
+	gfx := fizzle.GetGraphics()
+	if gfx.GetCapabilities().TimerQueries {
+		for i := range p.gpuQueries {
+			p.gpuQueries[i] = gfx.GenQuery()
+		}
+	}
+	return p
+}
+
+// Destroy releases the timer queries of the Profiler.
+func (p *Profiler) Destroy() {
+	gfx := fizzle.GetGraphics()
+	for i, q := range p.gpuQueries {
+		if q != 0 {
+			gfx.DeleteQuery(q)
+			p.gpuQueries[i] = 0
+		}
+	}
+}
+
+// HasGPUTimer returns true if the GPU time of frames can be measured.
+func (p *Profiler) HasGPUTimer() bool {
+	return p.gpuQueries[0] != 0
+}
+
+// AddCounter adds a counter that gets polled at the end of every frame.
+func (p *Profiler) AddCounter(name string, poll func() int) {
+	p.Counters = append(p.Counters, &Counter{Name: name, poll: poll})
+}
+
+// BeginFrame starts timing a new frame and resets the renderer draw
+// statistics. The time since the last call is recorded as the frame time,
+// so it should be called at the same point of every frame.
+func (p *Profiler) BeginFrame() {
+	if p.HasGPUTimer() {
+		p.readGPUQuery(p.gpuCurrent)
+	}
+
+	now := time.Now()
+	if !p.frameStart.IsZero() {
+		p.frameTimes[p.historyIndex] = float32(now.Sub(p.frameStart).Seconds() * 1000.0)
+		p.gpuTimes[p.historyIndex] = p.gpuLastTime
+		p.historyIndex = (p.historyIndex + 1) % len(p.frameTimes)
+	}
+	p.frameStart = now
+	p.inFrame = true
+	renderer.ResetFrameStats()
+
+	if p.HasGPUTimer() {
+		gfx := fizzle.GetGraphics()
+		gfx.BeginQuery(graphics.TIME_ELAPSED, p.gpuQueries[p.gpuCurrent])
+	}
+}
+
+// EndFrame stops timing the work of the frame on the CPU and GPU and
+// records its statistics. Anything drawn after EndFrame, like the Overlay,
+// is not included.
+func (p *Profiler) EndFrame() {
+	if !p.inFrame {
+		return
+	}
+	p.inFrame = false
+
+	if p.HasGPUTimer() {
+		gfx := fizzle.GetGraphics()
+		gfx.EndQuery(graphics.TIME_ELAPSED)
+		p.gpuPending[p.gpuCurrent] = true
+		p.gpuCurrent = (p.gpuCurrent + 1) % gpuQueryCount
+	}
+
+	p.cpuTime = float32(time.Since(p.frameStart).Seconds() * 1000.0)
+	p.Stats = renderer.GetFrameStats()
+	for _, c := range p.Counters {
+		c.Value = c.poll()
+	}
+}
+
+// BeginSection starts timing the named section of the frame on the CPU.
+func (p *Profiler) BeginSection(name string) {
+	p.getSection(name).start = time.Now()
+}
+
+// EndSection stops timing the named section of the frame.
+func (p *Profiler) EndSection(name string) {
+	s := p.getSection(name)
+	if s.start.IsZero() {
+		return
+	}
+
+	s.Last = float32(time.Since(s.start).Seconds() * 1000.0)
+	if s.Average == 0.0 {
+		s.Average = s.Last
+	} else {
+		s.Average += (s.Last - s.Average) * sectionSmoothing
+	}
+	s.start = time.Time{}
+}
+
+// GetSection returns the named section or nil if it hasn't been timed.
+func (p *Profiler) GetSection(name string) *Section {
+	for _, s := range p.Sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// GetFrameTimes returns the recorded frame times in milliseconds, oldest
+// first, in the slice passed in, which gets grown as needed.
+func (p *Profiler) GetFrameTimes(times []float32) []float32 {
+	return p.getHistory(p.frameTimes, times)
+}
+
+// GetGPUTimes returns the recorded GPU times in milliseconds, oldest first,
+// in the slice passed in, which gets grown as needed. The GPU times trail
+// the frame times by a few frames.
+func (p *Profiler) GetGPUTimes(times []float32) []float32 {
+	return p.getHistory(p.gpuTimes, times)
+}
+
+// GetLastFrameTime returns the time between the last two frames in
+// milliseconds.
+func (p *Profiler) GetLastFrameTime() float32 {
+	return p.frameTimes[(p.historyIndex+len(p.frameTimes)-1)%len(p.frameTimes)]
+}
+
+// GetLastCPUTime returns the time spent on the CPU between BeginFrame and
+// EndFrame of the last frame in milliseconds.
+func (p *Profiler) GetLastCPUTime() float32 {
+	return p.cpuTime
+}
+
+// GetLastGPUTime returns the most recent GPU time measured in milliseconds.
+func (p *Profiler) GetLastGPUTime() float32 {
+	return p.gpuLastTime
+}
+
+// GetAverageFrameTime returns the average time of the recorded frames in
+// milliseconds.
+func (p *Profiler) GetAverageFrameTime() float32 {
+	var total float32
+	count := 0
+	for _, t := range p.frameTimes {
+		if t > 0.0 {
+			total += t
+			count++
+		}
+	}
+	if count == 0 {
+		return 0.0
+	}
+	return total / float32(count)
+}
+
+// GetFPS returns the frames per second based on the average frame time.
+func (p *Profiler) GetFPS() float32 {
+	average := p.GetAverageFrameTime()
+	if average <= 0.0 {
+		return 0.0
+	}
+	return 1000.0 / average
+}
+
+// getSection returns the named section, creating it if needed.
+func (p *Profiler) getSection(name string) *Section {
+	s := p.GetSection(name)
+	if s == nil {
+		s = &Section{Name: name}
+		p.Sections = append(p.Sections, s)
+	}
+	return s
+}
+
+// getHistory copies the ring buffer into times in order, oldest first.
+func (p *Profiler) getHistory(ring []float32, times []float32) []float32 {
+	times = times[:0]
+	for i := 0; i < len(ring); i++ {
+		times = append(times, ring[(p.historyIndex+i)%len(ring)])
+	}
+	return times
+}
+
+// readGPUQuery reads the result of the timer query at the index if it was
+// issued.
+func (p *Profiler) readGPUQuery(index int) {
+	if !p.gpuPending[index] {
+		return
+	}
+
+	// the result should be ready after cycling through the other queries,
+	// otherwise this waits for it rather than reusing a query in flight
+	gfx := fizzle.GetGraphics()
+	var elapsed uint64
+	gfx.GetQueryObjectui64v(p.gpuQueries[index], graphics.QUERY_RESULT, &elapsed)
+	p.gpuLastTime = float32(float64(elapsed) / 1000000.0)
+	p.gpuPending[index] = false
+}
@@ -17,6 +17,11 @@ type RenderableCore struct {
 	// Skeleton is the animatable skeleton object for the renderable.
 	Skeleton *Skeleton
 
+	// CPUSkin holds the bind pose of the mesh when it gets skinned on the
+	// CPU instead of in the vertex shader, which is decided by
+	// UseCPUSkinning() when the mesh is created. It is nil otherwise.
+	CPUSkin *CPUSkin
+
 	// Vao is the OpenGL vertex array object for the renderable.
 	Vao uint32
 
@@ -29,6 +34,10 @@ type RenderableCore struct {
 	// UvVBO indicates the VBO that contains the UV data.
 	UvVBO graphics.Buffer
 
+	// Uv2VBO indicates the VBO that contains the second UV channel, which
+	// is used for lightmaps.
+	Uv2VBO graphics.Buffer
+
 	// NormsVBO indicates the VBO that contains the normal vector data.
 	NormsVBO graphics.Buffer
 
@@ -68,6 +77,10 @@ type RenderableCore struct {
 	// to read the UV information.
 	UvVBOOffset int
 
+	// Uv2VBOOffset is the offset in bytes from the start of a vertex definition needed
+	// to read the second UV channel's information.
+	Uv2VBOOffset int
+
 	// NormsVBOOffset is the offset in bytes from the start of a vertex definition needed
 	// to read the normal vector information.
 	NormsVBOOffset int
@@ -120,6 +133,24 @@ func (rect *Rectangle3D) DeltaZ() float32 {
 	return rect.Top[2] - rect.Bottom[2]
 }
 
+const (
+	// RenderOrderOpaque is the default RenderOrder for solid geometry.
+	RenderOrderOpaque = 0
+
+	// RenderOrderSkybox is the RenderOrder for skyboxes, which are drawn after
+	// the opaque geometry so that the covered pixels get skipped by the
+	// depth test.
+	RenderOrderSkybox = 1000
+
+	// RenderOrderTransparent is the RenderOrder for blended geometry. A
+	// RenderQueue sorts everything at or after it back to front.
+	RenderOrderTransparent = 2000
+
+	// RenderOrderOverlay is the RenderOrder for geometry drawn over the
+	// scene, such as UI elements in world space.
+	RenderOrderOverlay = 3000
+)
+
 // Renderable defines the data necessary to draw an object in OpenGL.
 // This structure focuses more on 'instance' type of data which is
 // typically not sharable between multiple Renderable instances.
@@ -152,6 +183,30 @@ type Renderable struct {
 	// IsVisible should be set to true if the object is to be rendered.
 	IsVisible bool
 
+	// Layers is the bitmask of layers the Renderable is in. Renderers skip
+	// the Renderable, and its children, when none of its layers are in their
+	// cull mask and the camera's. A value of 0 is treated as LayerDefault.
+	Layers uint32
+
+	// RenderOrder groups Renderables in a renderer.RenderQueue, which draws
+	// lower orders first, such as RenderOrderSkybox after RenderOrderOpaque.
+	RenderOrder int32
+
+	// SortBias is added to the camera distance used to sort the Renderable
+	// within its RenderOrder in a RenderQueue, where larger values draw
+	// later. It can make a decal draw after the surface it sits on.
+	SortBias float32
+
+	// DepthBias offsets the depth of the Renderable toward the camera when
+	// positive to keep coplanar geometry, such as decals, from z-fighting.
+	DepthBias float32
+
+	// ObjectID is written by the basic shaders to the IDs of a forward
+	// IDBuffer so that the Renderable can be picked by pixel. 0 is reserved
+	// for pixels where nothing was drawn. It isn't copied by Clone since it
+	// identifies a single instance.
+	ObjectID uint32
+
 	// IsGroup should be set to true if the renderable should only render its children objects
 	// and that this Renderable itself should not be drawn.
 	IsGroup bool
@@ -161,7 +216,8 @@ type Renderable struct {
 	Core *RenderableCore
 
 	// Material is the material for the object that will controll visible properties
-	// used during rendering.
+	// used during rendering. Clones share the material until InstanceMaterial
+	// is called, so changing it affects all of them.
 	Material *Material
 
 	// Parent can be set to a Renderable that should be considered this Renderable's
@@ -169,9 +225,18 @@ type Renderable struct {
 	// Location).
 	Parent *Renderable
 
+	// ParentBone is the name of a bone in the Parent's skeleton that this
+	// Renderable is attached to so that it follows the animated bone.
+	// See AttachRenderableToBone.
+	ParentBone string
+
 	// Children is a slice of Renderables that are the Renderable's children objects
 	// that should be drawn with this renderable.
 	Children []*Renderable
+
+	// instancedMaterial is the material InstanceMaterial gave the
+	// Renderable, which it owns as long as Material still points to it.
+	instancedMaterial *Material
 }
 
 // NewRenderable creates a new Renderable object and a new RenderableCore.
@@ -206,6 +271,7 @@ func (r *Renderable) Destroy() {
 func (r *RenderableCore) DestroyCore() {
 	gfx.DeleteBuffer(r.VertVBO)
 	gfx.DeleteBuffer(r.UvVBO)
+	gfx.DeleteBuffer(r.Uv2VBO)
 	gfx.DeleteBuffer(r.ElementsVBO)
 	gfx.DeleteBuffer(r.TangentsVBO)
 	gfx.DeleteBuffer(r.NormsVBO)
@@ -228,7 +294,12 @@ func (r *Renderable) Clone() *Renderable {
 	clone.LocalRotation = r.LocalRotation
 	clone.IsVisible = r.IsVisible
 	clone.IsGroup = r.IsGroup
+	clone.Layers = r.Layers
+	clone.RenderOrder = r.RenderOrder
+	clone.SortBias = r.SortBias
+	clone.DepthBias = r.DepthBias
 	clone.BoundingRect = r.BoundingRect
+	clone.ParentBone = r.ParentBone
 
 	// The render core and material are shared in the clone
 	clone.Core = r.Core
@@ -243,6 +314,37 @@ func (r *Renderable) Clone() *Renderable {
 	return clone
 }
 
+// InstanceMaterial gives the Renderable its own copy of its Material the
+// first time it is called and returns it, so that the appearance of one
+// clone can be changed, such as tinting its DiffuseColor, while the
+// geometry in Core stays shared. Later calls return the same material
+// unless Material was replaced in the meantime.
+func (r *Renderable) InstanceMaterial() *Material {
+	if r.Material != nil && r.Material == r.instancedMaterial {
+		return r.Material
+	}
+
+	if r.Material != nil {
+		r.Material = r.Material.Clone()
+	} else {
+		r.Material = NewMaterial()
+	}
+	r.instancedMaterial = r.Material
+	return r.Material
+}
+
+// GetPickBounds returns the unscaled, unrotated bounding rectangle to use
+// when picking the renderable. Renderables with a skeleton use the bounds of
+// the current pose so that animated meshes can be picked where they are drawn.
+func (r *Renderable) GetPickBounds() Rectangle3D {
+	if r.Core != nil && r.Core.Skeleton != nil {
+		if bounds, okay := r.Core.Skeleton.GetPosedBounds(); okay {
+			return bounds
+		}
+	}
+	return r.BoundingRect
+}
+
 // HasSkeleton returns true if the Renderable has bones associated with it.
 func (r *Renderable) HasSkeleton() bool {
 	if r.Core.Skeleton != nil {
@@ -295,6 +397,11 @@ func (r *Renderable) GetTransformMat4() mgl.Mat4 {
 
 	// if there's a parent, apply the transform as well
 	parentTransform := r.Parent.GetTransformMat4()
+	if r.ParentBone != "" && r.Parent.Core != nil && r.Parent.Core.Skeleton != nil {
+		if boneTransform, okay := r.Parent.Core.Skeleton.GetBoneTransform(r.ParentBone); okay {
+			parentTransform = parentTransform.Mul4(boneTransform)
+		}
+	}
 	return parentTransform.Mul4(modelTransform)
 }
 
@@ -304,6 +411,21 @@ func (r *Renderable) AddChild(child *Renderable) {
 	child.Parent = r
 }
 
+// AttachRenderableToBone adds the child to the parent so that it follows
+// the named bone of the parent's skeleton as it gets animated, such as for
+// a weapon held in a hand. The child's location, rotation and scale are
+// then relative to the bone. False is returned if the parent doesn't have
+// a skeleton with the bone.
+func AttachRenderableToBone(parent *Renderable, child *Renderable, boneName string) bool {
+	if parent.Core == nil || parent.Core.Skeleton == nil || parent.Core.Skeleton.GetBoneIndex(boneName) < 0 {
+		return false
+	}
+
+	child.ParentBone = boneName
+	parent.AddChild(child)
+	return true
+}
+
 // GetBoundingRect calculates a bounding Rectangle3D for all of the vertices pssed in.
 func GetBoundingRect(verts []float32) (r Rectangle3D) {
 	var minx, miny, minz float32 = math.MaxFloat32, math.MaxFloat32, math.MaxFloat32
@@ -355,6 +477,17 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 	// setup a skeleton if the mesh has bones associated with it
 	if srcMesh.BoneCount > 0 {
 		r.Core.Skeleton = NewSkeleton(srcMesh.Bones, srcMesh.Animations)
+		r.Core.Skeleton.BoneBounds = CalculateBoneBounds(srcMesh)
+	}
+
+	// skinning on the CPU keeps a copy of the bind pose and updates the
+	// vertex data every time the pose changes
+	cpuSkinning := srcMesh.BoneCount > 0 && len(srcMesh.VertexWeightIds) > 0 &&
+		len(srcMesh.VertexWeights) > 0 && UseCPUSkinning()
+	var skinnedUsage graphics.Enum = graphics.STATIC_DRAW
+	var skinPositions, skinNormals, skinTangents, skinIds []float32
+	if cpuSkinning {
+		skinnedUsage = graphics.DYNAMIC_DRAW
 	}
 
 	// set some basic properties up
@@ -372,7 +505,10 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 	}
 	r.Core.VertVBO = gfx.GenBuffer()
 	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
-	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), graphics.STATIC_DRAW)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), skinnedUsage)
+	if cpuSkinning {
+		skinPositions = append(skinPositions, vertBuffer...)
+	}
 
 	// calculate the bounding rectangle for the mesh
 	r.BoundingRect = GetBoundingRect(vertBuffer)
@@ -387,7 +523,10 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 		}
 		r.Core.NormsVBO = gfx.GenBuffer()
 		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.NormsVBO)
-		gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), graphics.STATIC_DRAW)
+		gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), skinnedUsage)
+		if cpuSkinning {
+			skinNormals = append(skinNormals, vertBuffer...)
+		}
 	}
 
 	// setup tangents
@@ -400,7 +539,10 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 		}
 		r.Core.TangentsVBO = gfx.GenBuffer()
 		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.TangentsVBO)
-		gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), graphics.STATIC_DRAW)
+		gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vertBuffer), gfx.Ptr(&vertBuffer[0]), skinnedUsage)
+		if cpuSkinning {
+			skinTangents = append(skinTangents, vertBuffer...)
+		}
 	}
 
 	// setup UVs
@@ -417,6 +559,20 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 		gfx.BufferData(graphics.ARRAY_BUFFER, int(floatSize*srcMesh.VertexCount*2), gfx.Ptr(&vertBuffer[0]), graphics.STATIC_DRAW)
 	}
 
+	// setup the second UV channel for lightmaps
+	if len(srcMesh.UVChannels) > 1 && len(srcMesh.UVChannels[1]) > 0 {
+		uvChan := srcMesh.UVChannels[1]
+		for i := uint32(0); i < srcMesh.VertexCount; i++ {
+			uv := uvChan[i]
+			offset := i * 2
+			vertBuffer[offset] = uv[0]
+			vertBuffer[offset+1] = uv[1]
+		}
+		r.Core.Uv2VBO = gfx.GenBuffer()
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.Uv2VBO)
+		gfx.BufferData(graphics.ARRAY_BUFFER, int(floatSize*srcMesh.VertexCount*2), gfx.Ptr(&vertBuffer[0]), graphics.STATIC_DRAW)
+	}
+
 	// setup vertex weight Ids for bones
 	var weightBuffer []float32
 	if len(srcMesh.VertexWeightIds) > 0 {
@@ -433,6 +589,9 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 		r.Core.BoneFidsVBO = gfx.GenBuffer()
 		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.BoneFidsVBO)
 		gfx.BufferData(graphics.ARRAY_BUFFER, int(floatSize*srcMesh.VertexCount*4), gfx.Ptr(&weightBuffer[0]), graphics.STATIC_DRAW)
+		if cpuSkinning {
+			skinIds = append(skinIds, weightBuffer...)
+		}
 	}
 
 	// setup the vertex weights
@@ -450,6 +609,9 @@ func CreateFromGombz(srcMesh *gombz.Mesh) *Renderable {
 		r.Core.BoneWeightsVBO = gfx.GenBuffer()
 		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.BoneWeightsVBO)
 		gfx.BufferData(graphics.ARRAY_BUFFER, int(floatSize*srcMesh.VertexCount*4), gfx.Ptr(&weightBuffer[0]), graphics.STATIC_DRAW)
+		if cpuSkinning {
+			r.Core.CPUSkin = newCPUSkin(skinPositions, skinNormals, skinTangents, skinIds, weightBuffer)
+		}
 	}
 
 	// setup the face indices
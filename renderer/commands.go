@@ -0,0 +1,274 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"unsafe"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// DrawCommand is one draw call recorded by a CommandRecorder.
+type DrawCommand struct {
+	// Program is the shader program used for the draw.
+	Program graphics.Program
+
+	// Vao is the vertex array object of the mesh that was drawn.
+	Vao uint32
+
+	// FaceCount and ObjectID come from the Renderable that was drawn, if
+	// the draw was made by BindAndDraw or BindAndDrawInstanced.
+	FaceCount uint32
+	ObjectID  uint32
+
+	// Mode is the primitive type, such as graphics.TRIANGLES.
+	Mode graphics.Enum
+
+	// Count is the number of elements or vertices drawn.
+	Count int32
+
+	// InstanceCount is the number of instances drawn, which is 1 for draws
+	// that are not instanced.
+	InstanceCount int32
+
+	// Uniforms are the values of the uniforms set since the program was
+	// made current, by name. Uniforms whose names weren't looked up through
+	// the RenderShader are named by location, like "location 3".
+	Uniforms map[string]interface{}
+
+	// Renderable is the Renderable that was drawn, if known. It isn't
+	// written to JSON.
+	Renderable *fizzle.Renderable `json:"-"`
+}
+
+// CommandList is the list of draw calls recorded by a CommandRecorder.
+type CommandList struct {
+	Commands []DrawCommand
+}
+
+// Reset removes all of the commands from the list.
+func (cl *CommandList) Reset() {
+	cl.Commands = cl.Commands[:0]
+}
+
+// Len returns the number of commands in the list.
+func (cl *CommandList) Len() int {
+	return len(cl.Commands)
+}
+
+// ToJSON encodes the command list as indented JSON.
+func (cl *CommandList) ToJSON() ([]byte, error) {
+	jsonBytes, err := json.MarshalIndent(cl, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode the command list. %v", err)
+	}
+	return jsonBytes, nil
+}
+
+// Save writes the command list to a JSON file.
+func (cl *CommandList) Save(filepath string) error {
+	jsonBytes, err := cl.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the command list file %s. %v", filepath, err)
+	}
+	return nil
+}
+
+// CommandRecorder is a GraphicsProvider that passes every call through to
+// another provider and, while Recording is set, appends each draw call with
+// the shader uniforms set for it to List. Creating a renderer with the
+// recorder as its provider allows the draws of a frame to be inspected or
+// dumped to JSON for debugging.
+//
+//	recorder := renderer.NewCommandRecorder(gfx)
+//	fr := forward.NewForwardRenderer(recorder)
+//	...
+//	recorder.Recording = true
+//	drawScene()
+//	recorder.Recording = false
+//	recorder.List.Save("frame.json")
+type CommandRecorder struct {
+	graphics.GraphicsProvider
+
+	// List is the list the draw calls get recorded to.
+	List *CommandList
+
+	// Recording enables the recording of draw calls.
+	Recording bool
+
+	program    graphics.Program
+	vao        uint32
+	uniforms   map[int32]interface{}
+	shader     *fizzle.RenderShader
+	renderable *fizzle.Renderable
+}
+
+// NewCommandRecorder creates a new CommandRecorder that passes the calls
+// through to the provider. Recording is off until Recording is set.
+func NewCommandRecorder(provider graphics.GraphicsProvider) *CommandRecorder {
+	rec := new(CommandRecorder)
+	rec.GraphicsProvider = provider
+	rec.List = new(CommandList)
+	rec.uniforms = make(map[int32]interface{})
+	return rec
+}
+
+// setDrawSource tells the recorder which shader and Renderable the next draw
+// is for so that uniforms can be named.
+func (rec *CommandRecorder) setDrawSource(shader *fizzle.RenderShader, r *fizzle.Renderable) {
+	rec.shader = shader
+	rec.renderable = r
+}
+
+// record appends a draw to the list.
+func (rec *CommandRecorder) record(mode graphics.Enum, count int32, instanceCount int32) {
+	if !rec.Recording {
+		return
+	}
+
+	cmd := DrawCommand{
+		Program:       rec.program,
+		Vao:           rec.vao,
+		Mode:          mode,
+		Count:         count,
+		InstanceCount: instanceCount,
+		Uniforms:      make(map[string]interface{}, len(rec.uniforms)),
+		Renderable:    rec.renderable,
+	}
+	if rec.renderable != nil {
+		cmd.FaceCount = rec.renderable.FaceCount
+		cmd.ObjectID = rec.renderable.ObjectID
+	}
+
+	for location, value := range rec.uniforms {
+		name := ""
+		if rec.shader != nil && rec.shader.Prog == rec.program {
+			name = rec.shader.GetUniformName(location)
+		}
+		if name == "" {
+			name = fmt.Sprintf("location %d", location)
+		}
+		cmd.Uniforms[name] = value
+	}
+
+	rec.List.Commands = append(rec.List.Commands, cmd)
+	rec.shader = nil
+	rec.renderable = nil
+}
+
+// setUniform stores the value of a uniform while recording.
+func (rec *CommandRecorder) setUniform(location int32, value interface{}) {
+	if rec.Recording && location >= 0 {
+		rec.uniforms[location] = value
+	}
+}
+
+// UseProgram installs a program object as part of the current rendering state
+func (rec *CommandRecorder) UseProgram(p graphics.Program) {
+	if p != rec.program {
+		rec.uniforms = make(map[int32]interface{})
+	}
+	rec.program = p
+	rec.GraphicsProvider.UseProgram(p)
+}
+
+// BindVertexArray binds a vertex array object
+func (rec *CommandRecorder) BindVertexArray(a uint32) {
+	rec.vao = a
+	rec.GraphicsProvider.BindVertexArray(a)
+}
+
+// DrawArrays renders primitives from array data
+func (rec *CommandRecorder) DrawArrays(mode graphics.Enum, first int32, count int32) {
+	rec.record(mode, count, 1)
+	rec.GraphicsProvider.DrawArrays(mode, first, count)
+}
+
+// DrawElements renders primitives from array data
+func (rec *CommandRecorder) DrawElements(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer) {
+	rec.record(mode, count, 1)
+	rec.GraphicsProvider.DrawElements(mode, count, ty, indices)
+}
+
+// DrawElementsInstanced renders multiple instances of primitives from array data
+func (rec *CommandRecorder) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instanceCount int32) {
+	rec.record(mode, count, instanceCount)
+	rec.GraphicsProvider.DrawElementsInstanced(mode, count, ty, indices, instanceCount)
+}
+
+// Uniform1i specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform1i(location int32, v int32) {
+	rec.setUniform(location, v)
+	rec.GraphicsProvider.Uniform1i(location, v)
+}
+
+// Uniform1iv specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform1iv(location int32, values []int32) {
+	rec.setUniform(location, append([]int32(nil), values...))
+	rec.GraphicsProvider.Uniform1iv(location, values)
+}
+
+// Uniform1f specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform1f(location int32, v float32) {
+	rec.setUniform(location, v)
+	rec.GraphicsProvider.Uniform1f(location, v)
+}
+
+// Uniform1fv specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform1fv(location int32, values []float32) {
+	rec.setUniform(location, append([]float32(nil), values...))
+	rec.GraphicsProvider.Uniform1fv(location, values)
+}
+
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform2f(location int32, v0, v1 float32) {
+	rec.setUniform(location, []float32{v0, v1})
+	rec.GraphicsProvider.Uniform2f(location, v0, v1)
+}
+
+// Uniform3f specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform3f(location int32, v0, v1, v2 float32) {
+	rec.setUniform(location, []float32{v0, v1, v2})
+	rec.GraphicsProvider.Uniform3f(location, v0, v1, v2)
+}
+
+// Uniform3fv specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform3fv(location int32, value []float32) {
+	rec.setUniform(location, append([]float32(nil), value...))
+	rec.GraphicsProvider.Uniform3fv(location, value)
+}
+
+// Uniform4f specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform4f(location int32, v0, v1, v2, v3 float32) {
+	rec.setUniform(location, []float32{v0, v1, v2, v3})
+	rec.GraphicsProvider.Uniform4f(location, v0, v1, v2, v3)
+}
+
+// Uniform4fv specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) Uniform4fv(location int32, value []float32) {
+	rec.setUniform(location, append([]float32(nil), value...))
+	rec.GraphicsProvider.Uniform4fv(location, value)
+}
+
+// UniformMatrix4fv specifies the value of a uniform variable for the current program object
+func (rec *CommandRecorder) UniformMatrix4fv(location, count int32, transpose bool, value interface{}) {
+	// copy slices since callers like the skeletons reuse them every frame
+	switch t := value.(type) {
+	case []mgl.Mat4:
+		rec.setUniform(location, append([]mgl.Mat4(nil), t...))
+	default:
+		rec.setUniform(location, value)
+	}
+	rec.GraphicsProvider.UniformMatrix4fv(location, count, transpose, value)
+}
@@ -9,8 +9,9 @@ import (
 
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
-	"github.com/tbogdala/groggy"
+	renderer "github.com/tbogdala/fizzle/renderer"
 )
 
 // ScreenSizeChanged is the type of the function called by the renderer after
@@ -64,6 +65,14 @@ type DeferredRenderer struct {
 	// a screen size change is detected.
 	OnScreenSizeChanged ScreenSizeChanged
 
+	// OnResolutionChanged is the function called by the renderer after its
+	// resolution has changed. The forward renderer has the same hook.
+	OnResolutionChanged func(width int32, height int32)
+
+	// Resizer waits for the window to stop changing size before RenderLoop
+	// recreates the framebuffers.
+	Resizer *renderer.ResizeDebouncer
+
 	// MainWindow the window used to show the rendered composite plane to.
 	MainWindow *glfw.Window
 
@@ -82,6 +91,7 @@ func NewDeferredRenderer(window *glfw.Window) *DeferredRenderer {
 	dr := new(DeferredRenderer)
 	dr.shaders = make(map[string]*RenderShader)
 	dr.MainWindow = window
+	dr.Resizer = renderer.NewResizeDebouncer()
 	dr.OnScreenSizeChanged = func(r *DeferredRenderer, width int32, height int32) {}
 	dr.BeforeDraw = func(r *DeferredRenderer, deltaFrameTime float32) {}
 	dr.AfterDraw = func(r *DeferredRenderer, deltaFrameTime float32) {}
@@ -102,13 +112,22 @@ func (dr *DeferredRenderer) Destroy() {
 }
 
 // ChangeResolution internally changes the size of the framebuffers and compositing
-// plane that are used for rendering.
+// plane that are used for rendering. Nothing happens if the size is the same
+// as the current one or if either dimension is zero, such as when the window
+// gets minimized.
 func (dr *DeferredRenderer) ChangeResolution(width, height int32) {
+	if width <= 0 || height <= 0 || (width == dr.width && height == dr.height) {
+		return
+	}
+
 	dr.Destroy()
 	dr.Init(width, height)
 	if dr.OnScreenSizeChanged != nil {
 		dr.OnScreenSizeChanged(dr, width, height)
 	}
+	if dr.OnResolutionChanged != nil {
+		dr.OnResolutionChanged(width, height)
+	}
 }
 
 // GetResolution returns the current dimensions of the renderer.
@@ -188,7 +207,7 @@ func (dr *DeferredRenderer) Init(width, height int32) error {
 	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
 
 	// create a plane for the composite pass
-	groggy.Logsf("DEBUG", "Creatiing composite plane %dx%d.", width, height)
+	fizzle.Logf(fizzle.LogDebug, "renderer", "Creatiing composite plane %dx%d.", width, height)
 	cp := CreatePlaneXY("composite", 0, 0, float32(width), float32(height))
 	cp.Core.Tex0 = gfx.GenTexture()
 	gfx.BindTexture(graphics.TEXTURE_2D, cp.Core.Tex0)
@@ -418,13 +437,12 @@ func (dr *DeferredRenderer) RenderLoop() {
 		currentFrameTime := time.Now()
 		deltaFrameTime := float32(currentFrameTime.Sub(dr.lastFrameTime).Seconds())
 
-		// setup the camera matrixes
+		// only recreate the framebuffers once the window stops changing size
 		tempW, tempH := dr.MainWindow.GetFramebufferSize()
-		currentWidth, currentHeight := int32(tempW), int32(tempH)
-		if dr.width != currentWidth || dr.height != currentHeight {
-			fmt.Printf("Updating resoluation to %d,%d\n", currentWidth, currentHeight)
-			dr.ChangeResolution(currentWidth, currentHeight)
-			dr.width, dr.height = currentWidth, currentHeight
+		dr.Resizer.Resize(int32(tempW), int32(tempH))
+		if newWidth, newHeight, changed := dr.Resizer.Update(); changed {
+			fizzle.Logf(fizzle.LogDebug, "renderer", "Updating resolution to %d,%d.", newWidth, newHeight)
+			dr.ChangeResolution(newWidth, newHeight)
 		}
 
 		////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,388 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+const (
+	// DefaultClusterTilesX and DefaultClusterTilesY are the default number
+	// of screen tiles the view is split into for clustered lighting.
+	DefaultClusterTilesX = 16
+	DefaultClusterTilesY = 9
+
+	// DefaultClusterSlices is the default number of depth slices the view
+	// is split into for clustered lighting.
+	DefaultClusterSlices = 24
+
+	// clusterIndexWidth is the width of the light index texture; the light
+	// index lists wrap onto more rows as needed. It matches the
+	// CLUSTER_INDEX_WIDTH constant in the clustered shader.
+	clusterIndexWidth = 1024
+
+	// clusterLightTexels is the number of RGBA texels each light takes up in
+	// the light texture.
+	clusterLightTexels = 4
+)
+
+// LightClusters splits the view frustum into clusters of screen tiles and
+// exponentially spaced depth slices and finds the point lights whose
+// sphere of influence touches each cluster. The clustered shader made by
+// CreateClusteredShader then only lights each fragment with the lights of
+// its cluster, which keeps scenes with hundreds of lights fast in the
+// forward renderer.
+//
+// The lights and the light index lists of the clusters are stored in float
+// textures that are updated on the CPU with Update each frame before
+// drawing. Directional lights light every cluster and are always applied.
+// The clustered lights don't cast shadows or use projectors or IES
+// profiles; those still need the ActiveLights of the basic shader.
+//
+//	clusters := forward.NewLightClusters(renderer)
+//	renderer.Clusters = clusters
+//	shader, err := forward.CreateClusteredShader()
+//	...
+//	// every frame
+//	clusters.Update(lights, perspective, view)
+//	renderer.DrawRenderableWithShader(mesh, shader, nil, perspective, view, camera)
+type LightClusters struct {
+	// TilesX and TilesY are the number of screen tiles across and down.
+	TilesX int32
+	TilesY int32
+
+	// Slices is the number of depth slices between the near and far planes
+	// of the projection.
+	Slices int32
+
+	// Influence is the attenuation below which a point light no longer
+	// lights anything, which determines the radius used to assign it to
+	// clusters like LightManager.Influence.
+	Influence float32
+
+	owner *ForwardRenderer
+
+	lightsTex  graphics.Texture
+	gridTex    graphics.Texture
+	indicesTex graphics.Texture
+
+	// lightData, gridData and indexData are the texture contents.
+	lightData []float32
+	gridData  []float32
+	indexData []float32
+
+	// clusterLights are the indexes of the point lights in each cluster.
+	clusterLights [][]int32
+
+	globalCount int32
+	lightCount  int32
+	near        float32
+	depthScale  float32
+}
+
+// NewLightClusters creates a new LightClusters for the renderer with the
+// default number of tiles and slices. The textures are filled by Update.
+func NewLightClusters(fr *ForwardRenderer) *LightClusters {
+	lc := new(LightClusters)
+	lc.owner = fr
+	lc.TilesX = DefaultClusterTilesX
+	lc.TilesY = DefaultClusterTilesY
+	lc.Slices = DefaultClusterSlices
+	lc.Influence = DefaultLightInfluence
+
+	gfx := fr.GetGraphics()
+	lc.lightsTex = createClusterTexture(gfx)
+	lc.gridTex = createClusterTexture(gfx)
+	lc.indicesTex = createClusterTexture(gfx)
+	return lc
+}
+
+// Destroy deletes the textures from OpenGL.
+func (lc *LightClusters) Destroy() {
+	gfx := lc.owner.GetGraphics()
+	gfx.DeleteTexture(lc.lightsTex)
+	gfx.DeleteTexture(lc.gridTex)
+	gfx.DeleteTexture(lc.indicesTex)
+}
+
+// GetLightCount returns the number of lights that were visible in the last
+// Update, including directional lights.
+func (lc *LightClusters) GetLightCount() int {
+	return int(lc.lightCount)
+}
+
+// GetMaxClusterLights returns the most point lights assigned to a single
+// cluster in the last Update, which is the worst case number of lights a
+// fragment is lit with on top of the directional lights.
+func (lc *LightClusters) GetMaxClusterLights() int {
+	most := 0
+	for _, lights := range lc.clusterLights {
+		if len(lights) > most {
+			most = len(lights)
+		}
+	}
+	return most
+}
+
+// Update assigns the lights to the clusters of the view with the
+// perspective projection and uploads the textures. Lights with animations
+// are evaluated at the renderer's animation time.
+func (lc *LightClusters) Update(lights []*Light, perspective mgl.Mat4, view mgl.Mat4) {
+	clusterCount := int(lc.TilesX * lc.TilesY * lc.Slices)
+	if clusterCount <= 0 {
+		return
+	}
+	if len(lc.clusterLights) != clusterCount {
+		lc.clusterLights = make([][]int32, clusterCount)
+	}
+	for i := range lc.clusterLights {
+		lc.clusterLights[i] = lc.clusterLights[i][:0]
+	}
+
+	// recover the near and far planes from the perspective projection
+	near := perspective[14] / (perspective[10] - 1.0)
+	far := perspective[14] / (perspective[10] + 1.0)
+	lc.near = near
+	lc.depthScale = float32(lc.Slices) / float32(math.Log(float64(far/near)))
+
+	// directional lights go first so that the shader can loop over them
+	lc.lightData = lc.lightData[:0]
+	lc.globalCount = 0
+	for _, l := range lights {
+		if l != nil && isDirectionalLight(l) {
+			lc.appendLight(l)
+			lc.globalCount++
+		}
+	}
+
+	lc.lightCount = lc.globalCount
+	for _, l := range lights {
+		if l == nil || isDirectionalLight(l) {
+			continue
+		}
+		radius := getInfluenceRadius(l, lc.Influence)
+		if radius <= 0.0 {
+			continue
+		}
+		if lc.assignLight(lc.lightCount, l.Position, radius, perspective, view, near, far) {
+			lc.appendLight(l)
+			lc.lightCount++
+		}
+	}
+
+	// flatten the index lists of the clusters into one list with the offset
+	// and count of each cluster in the grid
+	lc.gridData = lc.gridData[:0]
+	lc.indexData = lc.indexData[:0]
+	for _, clusterLights := range lc.clusterLights {
+		lc.gridData = append(lc.gridData, float32(len(lc.indexData)), float32(len(clusterLights)))
+		for _, index := range clusterLights {
+			lc.indexData = append(lc.indexData, float32(index))
+		}
+	}
+
+	lc.upload()
+}
+
+// assignLight adds the light index to every cluster touched by the sphere
+// and returns false if it doesn't touch any.
+func (lc *LightClusters) assignLight(index int32, position mgl.Vec3, radius float32, perspective, view mgl.Mat4, near, far float32) bool {
+	center := view.Mul4x1(position.Vec4(1.0)).Vec3()
+
+	// view space looks down -Z
+	minDepth := -center[2] - radius
+	maxDepth := -center[2] + radius
+	if maxDepth < near || minDepth > far {
+		return false
+	}
+	firstSlice := lc.getSlice(minDepth)
+	lastSlice := lc.getSlice(maxDepth)
+
+	// project the corners of the box around the sphere to find the tiles;
+	// if any of them is behind the near plane all of the tiles are used
+	minX, minY := float32(-1.0), float32(-1.0)
+	maxX, maxY := float32(1.0), float32(1.0)
+	if minDepth > near {
+		minX, minY = float32(math.MaxFloat32), float32(math.MaxFloat32)
+		maxX, maxY = float32(-math.MaxFloat32), float32(-math.MaxFloat32)
+		for corner := 0; corner < 8; corner++ {
+			offset := mgl.Vec3{-radius, -radius, -radius}
+			if corner&1 != 0 {
+				offset[0] = radius
+			}
+			if corner&2 != 0 {
+				offset[1] = radius
+			}
+			if corner&4 != 0 {
+				offset[2] = radius
+			}
+			clip := perspective.Mul4x1(center.Add(offset).Vec4(1.0))
+			x, y := clip[0]/clip[3], clip[1]/clip[3]
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+		if maxX < -1.0 || minX > 1.0 || maxY < -1.0 || minY > 1.0 {
+			return false
+		}
+	}
+	firstX, lastX := lc.getTile(minX, lc.TilesX), lc.getTile(maxX, lc.TilesX)
+	firstY, lastY := lc.getTile(minY, lc.TilesY), lc.getTile(maxY, lc.TilesY)
+
+	for slice := firstSlice; slice <= lastSlice; slice++ {
+		for y := firstY; y <= lastY; y++ {
+			for x := firstX; x <= lastX; x++ {
+				cluster := (slice*lc.TilesY+y)*lc.TilesX + x
+				lc.clusterLights[cluster] = append(lc.clusterLights[cluster], index)
+			}
+		}
+	}
+	return true
+}
+
+// getSlice returns the depth slice for the view space distance, matching
+// the calculation in the clustered shader.
+func (lc *LightClusters) getSlice(depth float32) int32 {
+	if depth <= lc.near {
+		return 0
+	}
+	slice := int32(float32(math.Log(float64(depth/lc.near))) * lc.depthScale)
+	if slice >= lc.Slices {
+		return lc.Slices - 1
+	}
+	return slice
+}
+
+// getTile returns the tile for the normalized device coordinate.
+func (lc *LightClusters) getTile(ndc float32, tiles int32) int32 {
+	tile := int32((ndc*0.5 + 0.5) * float32(tiles))
+	if tile < 0 {
+		return 0
+	} else if tile >= tiles {
+		return tiles - 1
+	}
+	return tile
+}
+
+// appendLight writes the light to the light texture data. The texels are
+// the position and diffuse intensity, the color and strength, the
+// attenuation and specular intensity and the direction and ambient
+// intensity.
+func (lc *LightClusters) appendLight(l *Light) {
+	strength := l.Strength
+	color := l.DiffuseColor
+	if l.Animation != nil {
+		intensity, animColor := l.Animation.Evaluate(lc.owner.animationTime)
+		strength *= intensity
+		color = mgl.Vec4{color[0] * animColor[0], color[1] * animColor[1], color[2] * animColor[2], color[3]}
+	}
+
+	lc.lightData = append(lc.lightData,
+		l.Position[0], l.Position[1], l.Position[2], l.DiffuseIntensity,
+		color[0], color[1], color[2], strength,
+		l.ConstAttenuation, l.LinearAttenuation, l.QuadraticAttenuation, l.SpecularIntensity,
+		l.Direction[0], l.Direction[1], l.Direction[2], l.AmbientIntensity)
+}
+
+// upload sends the light, grid and index data to the textures.
+func (lc *LightClusters) upload() {
+	gfx := lc.owner.GetGraphics()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+
+	// textures can't be empty, so pad the light and index data
+	lightRows := lc.lightCount
+	if lightRows == 0 {
+		lc.lightData = append(lc.lightData, make([]float32, clusterLightTexels*4)...)
+		lightRows = 1
+	}
+	gfx.BindTexture(graphics.TEXTURE_2D, lc.lightsTex)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA32F, clusterLightTexels, lightRows, 0, graphics.RGBA, graphics.FLOAT, gfx.Ptr(lc.lightData), len(lc.lightData)*4)
+
+	gfx.BindTexture(graphics.TEXTURE_2D, lc.gridTex)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RG32F, lc.TilesX*lc.TilesY, lc.Slices, 0, graphics.RG, graphics.FLOAT, gfx.Ptr(lc.gridData), len(lc.gridData)*4)
+
+	indexRows := (len(lc.indexData) + clusterIndexWidth - 1) / clusterIndexWidth
+	if indexRows == 0 {
+		indexRows = 1
+	}
+	for len(lc.indexData) < indexRows*clusterIndexWidth {
+		lc.indexData = append(lc.indexData, 0.0)
+	}
+	gfx.BindTexture(graphics.TEXTURE_2D, lc.indicesTex)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.R32F, clusterIndexWidth, int32(indexRows), 0, graphics.RED, graphics.FLOAT, gfx.Ptr(lc.indexData), len(lc.indexData)*4)
+
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+}
+
+// bind sets the cluster textures and uniforms for the clustered shader.
+func (lc *LightClusters) bind(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, texturesBound *int32) {
+	bindClusterTexture(gfx, shader, "CLUSTER_LIGHTS", lc.lightsTex, texturesBound)
+	bindClusterTexture(gfx, shader, "CLUSTER_GRID", lc.gridTex, texturesBound)
+	bindClusterTexture(gfx, shader, "CLUSTER_INDICES", lc.indicesTex, texturesBound)
+
+	shaderTiles := shader.GetUniformLocation("CLUSTER_TILES")
+	if shaderTiles >= 0 {
+		gfx.Uniform3f(shaderTiles, float32(lc.TilesX), float32(lc.TilesY), float32(lc.Slices))
+	}
+
+	shaderTileSize := shader.GetUniformLocation("CLUSTER_TILE_SIZE")
+	if shaderTileSize >= 0 {
+		width, height := lc.owner.GetResolution()
+		gfx.Uniform2f(shaderTileSize, float32(width)/float32(lc.TilesX), float32(height)/float32(lc.TilesY))
+	}
+
+	shaderDepth := shader.GetUniformLocation("CLUSTER_DEPTH")
+	if shaderDepth >= 0 {
+		gfx.Uniform2f(shaderDepth, lc.near, lc.depthScale)
+	}
+
+	shaderGlobalCount := shader.GetUniformLocation("CLUSTER_GLOBAL_COUNT")
+	if shaderGlobalCount >= 0 {
+		gfx.Uniform1i(shaderGlobalCount, lc.globalCount)
+	}
+}
+
+// bindClusterTexture binds one of the cluster textures to the sampler.
+func bindClusterTexture(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, name string, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(name)
+	if shaderTex >= 0 {
+		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+		gfx.BindTexture(graphics.TEXTURE_2D, tex)
+		gfx.Uniform1i(shaderTex, *texturesBound)
+		*texturesBound++
+	}
+}
+
+// createClusterTexture creates a texture for float data read with
+// texelFetch.
+func createClusterTexture(gfx graphics.GraphicsProvider) graphics.Texture {
+	tex := gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+	return tex
+}
+
+// isDirectionalLight returns true if the light has a direction, which the
+// shaders treat as a directional light.
+func isDirectionalLight(l *Light) bool {
+	return l.Direction[0] != 0.0 || l.Direction[1] != 0.0 || l.Direction[2] != 0.0
+}
@@ -15,6 +15,7 @@ package forward
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	mgl "github.com/go-gl/mathgl/mgl32"
@@ -122,6 +123,22 @@ type Light struct {
 	// the light does not cast shadows.
 	ShadowMap *ShadowMap
 
+	// Projector is the texture projected by the light to tint the light it
+	// casts. This member is nil when the light does not project a texture.
+	Projector *Projector
+
+	// IESProfile is the photometric profile that shapes the intensity of the
+	// light by direction. This member is nil when the light has no profile.
+	IESProfile *IESProfile
+
+	// IESRotation orients the IESProfile in world space. The identity points
+	// the profile's vertical angle of 0 degrees straight down.
+	IESRotation mgl.Quat
+
+	// Animation animates the strength and color of the light each frame.
+	// This member is nil when the light is not animated.
+	Animation *LightAnimation
+
 	// owner is the owning renderer
 	owner *ForwardRenderer
 }
@@ -199,22 +216,52 @@ type ForwardRenderer struct {
 	// a screen size change is detected.
 	OnScreenSizeChanged func(fr *ForwardRenderer, width int32, height int32)
 
+	// OnResolutionChanged is the function called by the renderer after its
+	// resolution has changed. The deferred renderer has the same hook.
+	OnResolutionChanged func(width int32, height int32)
+
 	// ActiveLights are the current lights that should be used while
 	// drawing Renderables.
 	ActiveLights [MaxForwardLights]*Light
 
+	// Exposure is the exposure compensation in stops applied to the color
+	// drawn by the basic shaders; each stop doubles the brightness. Materials
+	// can override it with Material.OverrideExposure.
+	Exposure float32
+
+	// Clusters are the light clusters bound for the clustered shader made
+	// by CreateClusteredShader. This member is nil when clustered lighting
+	// isn't used.
+	Clusters *LightClusters
+
+	// CullMask is the bitmask of the layers of Renderables the renderer
+	// draws, such as to leave out the editor gizmos in a render pass. The
+	// layers seen by the camera passed to the draw functions are applied
+	// as well.
+	CullMask uint32
+
 	width  int32
 	height int32
 
 	// lastFrameTime logs the last time the renderer started a frame
 	lastFrameTime time.Time
 
+	// animationTime is the number of seconds the light animations have run.
+	animationTime float32
+
+	// animationAdvanced is set when AdvanceAnimationTime was called during
+	// the frame so that EndRenderFrame doesn't advance the time again.
+	animationAdvanced bool
+
 	// shadowFBO is the framebuffer used to render shadows
 	shadowFBO graphics.Buffer
 
 	// currentShadowPassLight is the light currently enabled for shadow mapping
 	currentShadowPassLight *Light
 
+	// shadowMapping is true between StartShadowMapping and EndShadowMapping
+	shadowMapping bool
+
 	// gfx is the underlying graphics implementation for the renderer
 	gfx graphics.GraphicsProvider
 }
@@ -223,6 +270,7 @@ type ForwardRenderer struct {
 func NewForwardRenderer(g graphics.GraphicsProvider) *ForwardRenderer {
 	fr := new(ForwardRenderer)
 	fr.gfx = g
+	fr.CullMask = fizzle.LayerAll
 	fr.OnScreenSizeChanged = func(r *ForwardRenderer, width int32, height int32) {}
 	return fr
 }
@@ -245,6 +293,7 @@ func (fr *ForwardRenderer) NewShadowMap() *ShadowMap {
 // setting any default attributes.
 func (fr *ForwardRenderer) NewLight() *Light {
 	l := new(Light)
+	l.IESRotation = mgl.QuatIdent()
 	l.owner = fr
 	return l
 }
@@ -277,12 +326,22 @@ func (fr *ForwardRenderer) NewDirectionalLight(dir mgl.Vec3) *Light {
 }
 
 // ChangeResolution should be called when the underlying rendering
-// window changes size.
+// window changes size. Nothing happens if the size is the same as the
+// current one or if either dimension is zero, such as when the window
+// gets minimized. Use a renderer.ResizeDebouncer to only call this once
+// a window has finished being resized.
 func (fr *ForwardRenderer) ChangeResolution(width, height int32) {
+	if width <= 0 || height <= 0 || (width == fr.width && height == fr.height) {
+		return
+	}
+
 	fr.Init(width, height)
 	if fr.OnScreenSizeChanged != nil {
 		fr.OnScreenSizeChanged(fr, width, height)
 	}
+	if fr.OnResolutionChanged != nil {
+		fr.OnResolutionChanged(width, height)
+	}
 }
 
 // GetResolution returns the current dimensions of the renderer.
@@ -313,9 +372,32 @@ func (fr *ForwardRenderer) GetAspectRatio() float32 {
 	return float32(fr.width) / float32(fr.height)
 }
 
-// EndRenderFrame is the function called at end of the frame.
+// EndRenderFrame is the function called at end of the frame. It advances
+// the time of the light animations by the time since the last frame unless
+// AdvanceAnimationTime was called during the frame.
 func (fr *ForwardRenderer) EndRenderFrame() {
-	// nothing to do
+	currentFrameTime := time.Now()
+	if !fr.lastFrameTime.IsZero() && !fr.animationAdvanced {
+		fr.animationTime += float32(currentFrameTime.Sub(fr.lastFrameTime).Seconds())
+	}
+	fr.lastFrameTime = currentFrameTime
+	fr.animationAdvanced = false
+}
+
+// AdvanceAnimationTime advances the time of the light animations by dt
+// seconds. Call it once a frame from game loops that don't call
+// EndRenderFrame, or that want the lights to follow their own clock such
+// as a paused or slowed down game time; EndRenderFrame then leaves the
+// time alone for that frame.
+func (fr *ForwardRenderer) AdvanceAnimationTime(dt float32) {
+	fr.animationTime += dt
+	fr.animationAdvanced = true
+}
+
+// GetAnimationTime returns the number of seconds the light animations have
+// run, which is the time passed to LightAnimation.Evaluate.
+func (fr *ForwardRenderer) GetAnimationTime() float32 {
+	return fr.animationTime
 }
 
 // GetActiveLightCount counts the number of *Light set in
@@ -374,6 +456,7 @@ func (fr *ForwardRenderer) StartShadowMapping() {
 	fr.gfx.Enable(graphics.CULL_FACE)
 	fr.gfx.CullFace(graphics.FRONT)
 	fr.currentShadowPassLight = nil
+	fr.shadowMapping = true
 }
 
 // EndShadowMapping unbinds the shadow map framebuffer and lets the renderer
@@ -384,6 +467,7 @@ func (fr *ForwardRenderer) EndShadowMapping() {
 	fr.gfx.Disable(graphics.POLYGON_OFFSET_FILL)
 	fr.gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
 	fr.currentShadowPassLight = nil
+	fr.shadowMapping = false
 }
 
 // EnableShadowMappingLight enables the light to start casting shadows with draw functions
@@ -398,6 +482,38 @@ func (fr *ForwardRenderer) EnableShadowMappingLight(l *Light) {
 	fr.gfx.Viewport(0, 0, l.ShadowMap.TextureSize, l.ShadowMap.TextureSize)
 }
 
+// GetExposureScale returns the amount the color of the material gets scaled
+// by for the exposure compensation of the renderer or the material's own
+// exposure if it overrides the renderer's.
+func (fr *ForwardRenderer) GetExposureScale(m *fizzle.Material) float32 {
+	stops := fr.Exposure
+	if m != nil && m.OverrideExposure {
+		stops = m.Exposure
+	}
+	return float32(math.Exp2(float64(stops)))
+}
+
+// AdaptExposure moves the Exposure of the renderer toward the exposure that
+// maps the average luminance of the scene to the middle grey key, such as
+// 0.18, so that the image adjusts when moving between dark and bright areas.
+// The speed is how many stops the exposure can change per second and the
+// average luminance should be measured from the scene before exposure.
+func (fr *ForwardRenderer) AdaptExposure(averageLuminance, key, speed, dt float32) {
+	if averageLuminance <= 0.0 || key <= 0.0 {
+		return
+	}
+
+	target := float32(math.Log2(float64(key / averageLuminance)))
+	step := speed * dt
+	delta := target - fr.Exposure
+	if delta > step {
+		delta = step
+	} else if delta < -step {
+		delta = -step
+	}
+	fr.Exposure += delta
+}
+
 // do some special binding for the different Renderer types if necessary
 func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
 	gfx := fr.gfx
@@ -406,6 +522,13 @@ func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.R
 	if lightCount >= 1 {
 		for lightI := 0; lightI < int(lightCount); lightI++ {
 			light := fr.ActiveLights[lightI]
+			strength := light.Strength
+			diffuseColor := light.DiffuseColor
+			if light.Animation != nil {
+				intensity, color := light.Animation.Evaluate(fr.animationTime)
+				strength *= intensity
+				diffuseColor = mgl.Vec4{diffuseColor[0] * color[0], diffuseColor[1] * color[1], diffuseColor[2] * color[2], diffuseColor[3]}
+			}
 
 			shaderLightPosition := shader.GetUniformLocation(fmt.Sprintf("LIGHT_POSITION[%d]", lightI))
 			if shaderLightPosition >= 0 {
@@ -419,7 +542,7 @@ func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.R
 
 			shaderLightDiffuse := shader.GetUniformLocation(fmt.Sprintf("LIGHT_DIFFUSE[%d]", lightI))
 			if shaderLightDiffuse >= 0 {
-				gfx.Uniform4f(shaderLightDiffuse, light.DiffuseColor[0], light.DiffuseColor[1], light.DiffuseColor[2], light.DiffuseColor[3])
+				gfx.Uniform4f(shaderLightDiffuse, diffuseColor[0], diffuseColor[1], diffuseColor[2], diffuseColor[3])
 			}
 
 			shaderLightIntensity := shader.GetUniformLocation(fmt.Sprintf("LIGHT_DIFFUSE_INTENSITY[%d]", lightI))
@@ -454,7 +577,7 @@ func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.R
 
 			shaderLightStrength := shader.GetUniformLocation(fmt.Sprintf("LIGHT_STRENGTH[%d]", lightI))
 			if shaderLightStrength >= 0 {
-				gfx.Uniform1f(shaderLightStrength, light.Strength)
+				gfx.Uniform1f(shaderLightStrength, strength)
 			}
 
 			shaderShadowMaps := shader.GetUniformLocation(fmt.Sprintf("SHADOW_MAPS[%d]", lightI))
@@ -472,6 +595,65 @@ func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.R
 				*texturesBound++
 			}
 
+			shaderCookies := shader.GetUniformLocation(fmt.Sprintf("LIGHT_COOKIES[%d]", lightI))
+			if shaderCookies >= 0 {
+				// like the shadow maps, bind a 0 if the light has no projector
+				gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+				if light.Projector != nil {
+					gfx.BindTexture(graphics.TEXTURE_2D, light.Projector.Texture)
+				} else {
+					gfx.BindTexture(graphics.TEXTURE_2D, 0)
+				}
+				gfx.Uniform1i(shaderCookies, *texturesBound)
+				*texturesBound++
+			}
+
+			shaderCookieValid := shader.GetUniformLocation(fmt.Sprintf("LIGHT_COOKIE_VALID[%d]", lightI))
+			if shaderCookieValid >= 0 {
+				if light.Projector != nil {
+					gfx.Uniform1f(shaderCookieValid, 1.0)
+				} else {
+					gfx.Uniform1f(shaderCookieValid, 0.0)
+				}
+			}
+
+			if light.Projector != nil {
+				shaderCookieMatrix := shader.GetUniformLocation(fmt.Sprintf("LIGHT_COOKIE_MATRIX[%d]", lightI))
+				if shaderCookieMatrix >= 0 {
+					light.UpdateProjectorData()
+					gfx.UniformMatrix4fv(shaderCookieMatrix, 1, false, light.Projector.BiasedMatrix)
+				}
+			}
+
+			shaderIES := shader.GetUniformLocation(fmt.Sprintf("LIGHT_IES[%d]", lightI))
+			if shaderIES >= 0 {
+				// like the shadow maps, bind a 0 if the light has no profile
+				gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+				if light.IESProfile != nil {
+					gfx.BindTexture(graphics.TEXTURE_2D, light.IESProfile.Texture)
+				} else {
+					gfx.BindTexture(graphics.TEXTURE_2D, 0)
+				}
+				gfx.Uniform1i(shaderIES, *texturesBound)
+				*texturesBound++
+			}
+
+			shaderIESValid := shader.GetUniformLocation(fmt.Sprintf("LIGHT_IES_VALID[%d]", lightI))
+			if shaderIESValid >= 0 {
+				if light.IESProfile != nil {
+					gfx.Uniform1f(shaderIESValid, 1.0)
+				} else {
+					gfx.Uniform1f(shaderIESValid, 0.0)
+				}
+			}
+
+			if light.IESProfile != nil {
+				shaderIESMatrix := shader.GetUniformLocation(fmt.Sprintf("LIGHT_IES_MATRIX[%d]", lightI))
+				if shaderIESMatrix >= 0 {
+					gfx.UniformMatrix4fv(shaderIESMatrix, 1, false, light.GetIESMatrix())
+				}
+			}
+
 			if light.ShadowMap != nil {
 				shaderShadowMatrix := shader.GetUniformLocation(fmt.Sprintf("SHADOW_MATRIX[%d]", lightI))
 				if shaderShadowMatrix >= 0 {
@@ -498,12 +680,36 @@ func (fr *ForwardRenderer) chainedBinder(renderer renderer.Renderer, r *fizzle.R
 		}
 
 	} // lightcount
+
+	if fr.Clusters != nil {
+		fr.Clusters.bind(gfx, shader, texturesBound)
+	}
+
+	shaderExposure := shader.GetUniformLocation("EXPOSURE")
+	if shaderExposure >= 0 {
+		gfx.Uniform1f(shaderExposure, fr.GetExposureScale(r.Material))
+	}
+
+	shaderObjectID := shader.GetUniformLocation("OBJECT_ID")
+	if shaderObjectID >= 0 {
+		gfx.Uniform1i(shaderObjectID, int32(r.ObjectID))
+	}
+}
+
+// isInCullMask returns true if the Renderable is in the layers drawn by the
+// renderer and seen by the camera.
+func (fr *ForwardRenderer) isInCullMask(r *fizzle.Renderable, camera fizzle.Camera) bool {
+	mask := fr.CullMask
+	if camera != nil {
+		mask &= fizzle.GetCameraCullMask(camera)
+	}
+	return r.IsInLayers(mask)
 }
 
 // DrawRenderable draws a Renderable object with the supplied projection and view matrixes.
 func (fr *ForwardRenderer) DrawRenderable(r *fizzle.Renderable, binder renderer.RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
-	// only draw visible nodes
-	if !r.IsVisible {
+	// only draw visible nodes in the layers being drawn
+	if !r.IsVisible || !fr.isInCullMask(r, camera) {
 		return
 	}
 
@@ -521,15 +727,15 @@ func (fr *ForwardRenderer) DrawRenderable(r *fizzle.Renderable, binder renderer.
 	if binder != nil {
 		binders = append(binders, binder)
 	}
-	renderer.BindAndDraw(fr, r, r.Material.Shader, binders, perspective, view, camera, graphics.TRIANGLES)
+	fr.drawTriangles(r, r.Material.Shader, binders, perspective, view, camera)
 }
 
 // DrawRenderableWithShader draws a Renderable object with the supplied projection and view matrixes
 // and a different shader than what is set in the Renderable.
 func (fr *ForwardRenderer) DrawRenderableWithShader(r *fizzle.Renderable, shader *fizzle.RenderShader,
 	binder renderer.RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
-	// only draw visible nodes
-	if !r.IsVisible {
+	// only draw visible nodes in the layers being drawn
+	if !r.IsVisible || !fr.isInCullMask(r, camera) {
 		return
 	}
 
@@ -547,14 +753,47 @@ func (fr *ForwardRenderer) DrawRenderableWithShader(r *fizzle.Renderable, shader
 	if binder != nil {
 		binders = append(binders, binder)
 	}
+	fr.drawTriangles(r, shader, binders, perspective, view, camera)
+}
+
+// DrawRenderableInstanced draws instanceCount instances of a Renderable
+// object with one draw call using the supplied shader. The binder is
+// responsible for setting up the per-instance vertex attributes. Children
+// of the Renderable are not drawn.
+func (fr *ForwardRenderer) DrawRenderableInstanced(r *fizzle.Renderable, shader *fizzle.RenderShader, instanceCount int32,
+	binder renderer.RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	// only draw visible nodes in the layers being drawn
+	if !r.IsVisible || r.IsGroup || instanceCount <= 0 || !fr.isInCullMask(r, camera) {
+		return
+	}
+
+	binders := []renderer.RenderBinder{fr.chainedBinder}
+	if binder != nil {
+		binders = append(binders, binder)
+	}
+	renderer.BindAndDrawInstanced(fr, r, shader, binders, perspective, view, camera, instanceCount)
+}
+
+// drawTriangles binds and draws the Renderable, offsetting its depth by its
+// DepthBias outside of the shadow mapping pass, which has its own offset.
+func (fr *ForwardRenderer) drawTriangles(r *fizzle.Renderable, shader *fizzle.RenderShader, binders []renderer.RenderBinder,
+	perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	if r.DepthBias == 0.0 || fr.shadowMapping {
+		renderer.BindAndDraw(fr, r, shader, binders, perspective, view, camera, graphics.TRIANGLES)
+		return
+	}
+
+	fr.gfx.Enable(graphics.POLYGON_OFFSET_FILL)
+	fr.gfx.PolygonOffset(-r.DepthBias, -r.DepthBias)
 	renderer.BindAndDraw(fr, r, shader, binders, perspective, view, camera, graphics.TRIANGLES)
+	fr.gfx.Disable(graphics.POLYGON_OFFSET_FILL)
 }
 
 // DrawLines draws the Renderable using graphics.LINES mode instead of graphics.TRIANGLES.
 func (fr *ForwardRenderer) DrawLines(r *fizzle.Renderable, shader *fizzle.RenderShader, binder renderer.RenderBinder,
 	perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
-	// only draw visible nodes
-	if !r.IsVisible {
+	// only draw visible nodes in the layers being drawn
+	if !r.IsVisible || !fr.isInCullMask(r, camera) {
 		return
 	}
 
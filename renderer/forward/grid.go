@@ -0,0 +1,139 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// Grid is an infinite ground grid for editors. It is drawn with one quad
+// covering the screen and a shader that finds where each pixel's view ray
+// hits the ground, so the lines stay antialiased into the distance and fade
+// out instead of ending at the edge of a line mesh.
+type Grid struct {
+	// Renderable is the screen covering quad. It is in LayerGizmos so that
+	// render passes that leave out the gizmos skip the grid too.
+	Renderable *fizzle.Renderable
+
+	// Shader is the grid shader created with CreateGridShader.
+	Shader *fizzle.RenderShader
+
+	// Height is the Y coordinate of the ground plane.
+	Height float32
+
+	// CellSize is the size of the cells between the minor lines.
+	CellSize float32
+
+	// MajorEvery is the number of cells between the major lines.
+	MajorEvery float32
+
+	// FadeDistance is the distance from the camera at which the grid has
+	// faded out completely. It starts fading at half of the distance.
+	FadeDistance float32
+
+	// Color and MajorColor are the colors of the minor and major lines.
+	Color      mgl.Vec4
+	MajorColor mgl.Vec4
+
+	// AxisXColor and AxisZColor are the colors of the lines along the X
+	// and Z axes.
+	AxisXColor mgl.Vec4
+	AxisZColor mgl.Vec4
+
+	viewProj    mgl.Mat4
+	invViewProj mgl.Mat4
+}
+
+// NewGrid creates a new Grid with one unit cells and major lines every ten
+// cells, using a new grid shader.
+func NewGrid() (*Grid, error) {
+	shader, err := CreateGridShader()
+	if err != nil {
+		return nil, err
+	}
+
+	g := new(Grid)
+	g.Shader = shader
+	g.Renderable = fizzle.CreatePlaneXY(-1.0, -1.0, 1.0, 1.0)
+	g.Renderable.Layers = fizzle.LayerGizmos
+	g.CellSize = 1.0
+	g.MajorEvery = 10.0
+	g.FadeDistance = 100.0
+	g.Color = mgl.Vec4{0.5, 0.5, 0.5, 0.4}
+	g.MajorColor = mgl.Vec4{0.6, 0.6, 0.6, 0.7}
+	g.AxisXColor = mgl.Vec4{0.9, 0.2, 0.2, 1.0}
+	g.AxisZColor = mgl.Vec4{0.2, 0.3, 0.9, 1.0}
+	return g, nil
+}
+
+// Destroy releases the quad and the shader.
+func (g *Grid) Destroy() {
+	g.Renderable.Destroy()
+	g.Shader.Destroy()
+}
+
+// Draw draws the grid blended over what is on the screen, hidden by what is
+// in front of it in the depth buffer. It doesn't write to the depth buffer
+// so it can be drawn before or after the scene.
+func (g *Grid) Draw(fr *ForwardRenderer, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	g.viewProj = perspective.Mul4(view)
+	g.invViewProj = g.viewProj.Inv()
+
+	gfx := fr.GetGraphics()
+	gfx.Enable(graphics.BLEND)
+	gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+	gfx.DepthMask(false)
+	fr.DrawRenderableWithShader(g.Renderable, g.Shader, g.bindGrid, perspective, view, camera)
+	gfx.DepthMask(true)
+	gfx.Disable(graphics.BLEND)
+}
+
+// bindGrid is the RenderBinder that sets the grid uniforms.
+func (g *Grid) bindGrid(rend renderer.Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+	gfx := rend.GetGraphics()
+
+	shaderViewProj := shader.GetUniformLocation("GRID_VIEW_PROJ")
+	if shaderViewProj >= 0 {
+		gfx.UniformMatrix4fv(shaderViewProj, 1, false, g.viewProj)
+	}
+	shaderInvViewProj := shader.GetUniformLocation("GRID_INV_VIEW_PROJ")
+	if shaderInvViewProj >= 0 {
+		gfx.UniformMatrix4fv(shaderInvViewProj, 1, false, g.invViewProj)
+	}
+	shaderHeight := shader.GetUniformLocation("GRID_HEIGHT")
+	if shaderHeight >= 0 {
+		gfx.Uniform1f(shaderHeight, g.Height)
+	}
+	shaderCellSize := shader.GetUniformLocation("GRID_CELL_SIZE")
+	if shaderCellSize >= 0 {
+		gfx.Uniform1f(shaderCellSize, g.CellSize)
+	}
+	shaderMajorEvery := shader.GetUniformLocation("GRID_MAJOR_EVERY")
+	if shaderMajorEvery >= 0 {
+		gfx.Uniform1f(shaderMajorEvery, g.MajorEvery)
+	}
+	shaderFadeDistance := shader.GetUniformLocation("GRID_FADE_DISTANCE")
+	if shaderFadeDistance >= 0 {
+		gfx.Uniform1f(shaderFadeDistance, g.FadeDistance)
+	}
+	shaderColor := shader.GetUniformLocation("GRID_COLOR")
+	if shaderColor >= 0 {
+		gfx.Uniform4f(shaderColor, g.Color[0], g.Color[1], g.Color[2], g.Color[3])
+	}
+	shaderMajorColor := shader.GetUniformLocation("GRID_MAJOR_COLOR")
+	if shaderMajorColor >= 0 {
+		gfx.Uniform4f(shaderMajorColor, g.MajorColor[0], g.MajorColor[1], g.MajorColor[2], g.MajorColor[3])
+	}
+	shaderAxisXColor := shader.GetUniformLocation("GRID_AXIS_X_COLOR")
+	if shaderAxisXColor >= 0 {
+		gfx.Uniform4f(shaderAxisXColor, g.AxisXColor[0], g.AxisXColor[1], g.AxisXColor[2], g.AxisXColor[3])
+	}
+	shaderAxisZColor := shader.GetUniformLocation("GRID_AXIS_Z_COLOR")
+	if shaderAxisZColor >= 0 {
+		gfx.Uniform4f(shaderAxisZColor, g.AxisZColor[0], g.AxisZColor[1], g.AxisZColor[2], g.AxisZColor[3])
+	}
+}
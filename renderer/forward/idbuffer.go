@@ -0,0 +1,144 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// IDBuffer is an offscreen framebuffer that the main pass can be drawn into
+// to get the ObjectID of the Renderable at each pixel along with the color,
+// which allows pixel perfect picking in editors and ID based outlines in
+// post effects that sample the IDs texture with a usampler2D.
+//
+// The basic shaders write the ObjectID to the second color output as
+// frag_id; custom shaders need to do the same to show up in the buffer,
+// otherwise the IDs they leave behind are undefined. An ObjectID of 0 means
+// that nothing was drawn at the pixel.
+type IDBuffer struct {
+	// Framebuffer is the OpenGL framebuffer object.
+	Framebuffer graphics.Buffer
+
+	// Color is the color texture for the first color output.
+	Color graphics.Texture
+
+	// IDs is the unsigned integer texture for the second color output.
+	IDs graphics.Texture
+
+	// Depth is the depth texture attached to the framebuffer.
+	Depth graphics.Texture
+
+	// Width and Height are the size of the textures in pixels.
+	Width  int32
+	Height int32
+}
+
+// NewIDBuffer creates a new IDBuffer of the size specified.
+func NewIDBuffer(width, height int32) (*IDBuffer, error) {
+	gfx := fizzle.GetGraphics()
+	b := new(IDBuffer)
+	b.Width = width
+	b.Height = height
+
+	b.Color = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, b.Color)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, width, height, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, nil, 0)
+	setIDBufferTextureParameters(gfx, graphics.LINEAR)
+
+	// integer textures can't be filtered
+	b.IDs = gfx.GenTexture()
+	gfx.BindTexture(graphics.TEXTURE_2D, b.IDs)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.R32UI, width, height, 0, graphics.RED_INTEGER, graphics.UNSIGNED_INT, nil, 0)
+	setIDBufferTextureParameters(gfx, graphics.NEAREST)
+
+	b.Depth = gfx.GenTexture()
+	gfx.BindTexture(graphics.TEXTURE_2D, b.Depth)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.DEPTH_COMPONENT24, width, height, 0, graphics.DEPTH_COMPONENT, graphics.UNSIGNED_INT, nil, 0)
+	setIDBufferTextureParameters(gfx, graphics.NEAREST)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	b.Framebuffer = gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, b.Framebuffer)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, b.Color, 0)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT1, graphics.TEXTURE_2D, b.IDs, 0)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.TEXTURE_2D, b.Depth, 0)
+	gfx.DrawBuffers([]uint32{graphics.COLOR_ATTACHMENT0, graphics.COLOR_ATTACHMENT1})
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		b.Destroy()
+		return nil, fmt.Errorf("Failed to create the ID buffer framebuffer. Code 0x%x", status)
+	}
+
+	return b, nil
+}
+
+// setIDBufferTextureParameters sets the filtering and wrapping for the
+// texture bound to TEXTURE_2D.
+func setIDBufferTextureParameters(gfx graphics.GraphicsProvider, filter int32) {
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, filter)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, filter)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+}
+
+// Destroy deletes the framebuffer and textures from OpenGL.
+func (b *IDBuffer) Destroy() {
+	gfx := fizzle.GetGraphics()
+	gfx.DeleteFramebuffer(b.Framebuffer)
+	gfx.DeleteTexture(b.Color)
+	gfx.DeleteTexture(b.IDs)
+	gfx.DeleteTexture(b.Depth)
+}
+
+// Begin binds the framebuffer for drawing, sets the viewport to its size and
+// clears the color, the depth and the IDs to 0.
+func (b *IDBuffer) Begin() {
+	gfx := fizzle.GetGraphics()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, b.Framebuffer)
+	gfx.Viewport(0, 0, b.Width, b.Height)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+
+	// clearing integer buffers with Clear is undefined
+	gfx.ClearBufferuiv(graphics.COLOR, 1, []uint32{0, 0, 0, 0})
+}
+
+// End binds the default framebuffer again.
+func (b *IDBuffer) End() {
+	fizzle.GetGraphics().BindFramebuffer(graphics.FRAMEBUFFER, 0)
+}
+
+// BlitToScreen copies the color texture to the default framebuffer, scaled
+// to the width and height specified.
+func (b *IDBuffer) BlitToScreen(width, height int32) {
+	gfx := fizzle.GetGraphics()
+	gfx.BindFramebuffer(graphics.READ_FRAMEBUFFER, b.Framebuffer)
+	gfx.ReadBuffer(graphics.COLOR_ATTACHMENT0)
+	gfx.BindFramebuffer(graphics.DRAW_FRAMEBUFFER, 0)
+	gfx.BlitFramebuffer(0, 0, b.Width, b.Height, 0, 0, width, height, graphics.COLOR_BUFFER_BIT, graphics.LINEAR)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+}
+
+// ReadID returns the ObjectID drawn at the pixel, with x and y measured from
+// the top-left corner like window coordinates. 0 is returned for pixels
+// outside of the buffer or where nothing was drawn.
+func (b *IDBuffer) ReadID(x, y int32) uint32 {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return 0
+	}
+
+	gfx := fizzle.GetGraphics()
+	var id uint32
+	gfx.BindFramebuffer(graphics.READ_FRAMEBUFFER, b.Framebuffer)
+	gfx.ReadBuffer(graphics.COLOR_ATTACHMENT1)
+	gfx.ReadPixels(x, b.Height-1-y, 1, 1, graphics.RED_INTEGER, graphics.UNSIGNED_INT, unsafe.Pointer(&id))
+	gfx.ReadBuffer(graphics.COLOR_ATTACHMENT0)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	return id
+}
@@ -0,0 +1,307 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+const (
+	// IESTextureWidth is the number of vertical angles, from straight down
+	// to straight up, baked into the texture of an IESProfile.
+	IESTextureWidth = 128
+
+	// IESTextureHeight is the number of horizontal angles, around the
+	// light, baked into the texture of an IESProfile.
+	IESTextureHeight = 64
+
+	// iesPhotometricTypeC is the photometric type used by nearly all
+	// architectural fixtures and the only one supported.
+	iesPhotometricTypeC = 1
+)
+
+// IESProfile is an IES photometric profile that describes how the intensity
+// of a real light fixture varies by direction. The profile gets baked into a
+// texture with the intensity normalized to the brightest direction, which
+// the basic shaders use to scale the light; Light.Strength still sets the
+// overall brightness.
+//
+// In the light's space, a vertical angle of 0 degrees points down the -Y
+// axis and 180 degrees points up. A horizontal angle of 0 degrees points
+// along +X and 90 degrees along +Z. Light.IESRotation orients the profile.
+type IESProfile struct {
+	// VerticalAngles are the vertical angles of the measurements in degrees.
+	VerticalAngles []float32
+
+	// HorizontalAngles are the horizontal angles of the measurements in
+	// degrees.
+	HorizontalAngles []float32
+
+	// Candela are the measured intensities for each horizontal angle and
+	// then each vertical angle, with the multiplier of the file applied.
+	Candela [][]float32
+
+	// MaxCandela is the largest intensity in the profile.
+	MaxCandela float32
+
+	// Texture is the baked profile created by CreateTexture.
+	Texture graphics.Texture
+}
+
+// LoadIESProfile loads an IES photometric file, such as a manufacturer
+// supplied .ies file, and creates the texture for the profile.
+func LoadIESProfile(path string) (*IESProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the IES file %s.\n%v", path, err)
+	}
+	defer f.Close()
+
+	profile, err := ParseIESProfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the IES file %s.\n%v", path, err)
+	}
+
+	profile.CreateTexture()
+	return profile, nil
+}
+
+// ParseIESProfile parses IES LM-63 photometric data from the reader without
+// creating the texture. Only type C photometry is supported. Tilt data is
+// skipped.
+func ParseIESProfile(r io.Reader) (*IESProfile, error) {
+	scanner := bufio.NewScanner(r)
+
+	// skip the header and keywords until the TILT line
+	tilt := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "TILT=") {
+			tilt = strings.TrimPrefix(line, "TILT=")
+			break
+		}
+	}
+	if tilt == "" {
+		return nil, fmt.Errorf("Failed to find the TILT line of the IES data.")
+	}
+
+	// the rest of the file is numbers separated by spaces, commas or lines
+	var values []float32
+	for scanner.Scan() {
+		fields := strings.FieldsFunc(scanner.Text(), func(c rune) bool {
+			return c == ' ' || c == '\t' || c == ','
+		})
+		for _, field := range fields {
+			v, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse the IES value %q.\n%v", field, err)
+			}
+			values = append(values, float32(v))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read the IES data.\n%v", err)
+	}
+
+	next := 0
+	take := func(count int) ([]float32, error) {
+		if next+count > len(values) {
+			return nil, fmt.Errorf("Failed to parse the IES data because it ended early.")
+		}
+		taken := values[next : next+count]
+		next += count
+		return taken, nil
+	}
+
+	// skip the lamp to luminaire geometry and the tilt angle and factor pairs
+	if tilt == "INCLUDE" {
+		tiltHeader, err := take(2)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := take(int(tiltHeader[1]) * 2); err != nil {
+			return nil, err
+		}
+	}
+
+	header, err := take(13)
+	if err != nil {
+		return nil, err
+	}
+	candelaMultiplier := header[2]
+	verticalCount := int(header[3])
+	horizontalCount := int(header[4])
+	photometricType := int(header[5])
+	if photometricType != iesPhotometricTypeC {
+		return nil, fmt.Errorf("Failed to parse the IES data because photometric type %d is not supported.", photometricType)
+	}
+	if verticalCount < 1 || horizontalCount < 1 {
+		return nil, fmt.Errorf("Failed to parse the IES data because it has %d vertical and %d horizontal angles.", verticalCount, horizontalCount)
+	}
+
+	profile := new(IESProfile)
+	if profile.VerticalAngles, err = take(verticalCount); err != nil {
+		return nil, err
+	}
+	if profile.HorizontalAngles, err = take(horizontalCount); err != nil {
+		return nil, err
+	}
+	if !sort.IsSorted(float32Slice(profile.VerticalAngles)) || !sort.IsSorted(float32Slice(profile.HorizontalAngles)) {
+		return nil, fmt.Errorf("Failed to parse the IES data because the angles are not in increasing order.")
+	}
+
+	profile.Candela = make([][]float32, horizontalCount)
+	for h := range profile.Candela {
+		row, err := take(verticalCount)
+		if err != nil {
+			return nil, err
+		}
+		profile.Candela[h] = make([]float32, verticalCount)
+		for v, c := range row {
+			c *= candelaMultiplier
+			profile.Candela[h][v] = c
+			if c > profile.MaxCandela {
+				profile.MaxCandela = c
+			}
+		}
+	}
+
+	return profile, nil
+}
+
+// Sample returns the intensity of the profile in the direction specified by
+// the angles in degrees, normalized so that the brightest direction is 1.0.
+// The symmetry of profiles that only cover part of the horizontal angles is
+// taken into account.
+func (p *IESProfile) Sample(vertical, horizontal float32) float32 {
+	if p.MaxCandela <= 0.0 {
+		return 0.0
+	}
+
+	// directions outside of the measured vertical angles get no light
+	if vertical < p.VerticalAngles[0] || vertical > p.VerticalAngles[len(p.VerticalAngles)-1] {
+		return 0.0
+	}
+
+	lastH := p.HorizontalAngles[len(p.HorizontalAngles)-1]
+	horizontal = float32(mathMod(float64(horizontal), 360.0))
+	switch {
+	case len(p.HorizontalAngles) == 1 || lastH == 0.0:
+		// rotationally symmetric
+		horizontal = p.HorizontalAngles[0]
+	case lastH == 90.0:
+		// symmetric in each quadrant
+		horizontal = float32(mathMod(float64(horizontal), 180.0))
+		if horizontal > 90.0 {
+			horizontal = 180.0 - horizontal
+		}
+	case lastH == 180.0:
+		// symmetric across the 0 to 180 degree plane
+		if horizontal > 180.0 {
+			horizontal = 360.0 - horizontal
+		}
+	}
+
+	h0, h1, hf := findAngleSpan(p.HorizontalAngles, horizontal)
+	v0, v1, vf := findAngleSpan(p.VerticalAngles, vertical)
+	c0 := p.Candela[h0][v0] + (p.Candela[h0][v1]-p.Candela[h0][v0])*vf
+	c1 := p.Candela[h1][v0] + (p.Candela[h1][v1]-p.Candela[h1][v0])*vf
+	return (c0 + (c1-c0)*hf) / p.MaxCandela
+}
+
+// CreateTexture bakes the profile into a texture that is indexed by the
+// vertical angle on the S axis and the horizontal angle on the T axis.
+func (p *IESProfile) CreateTexture() {
+	data := make([]float32, IESTextureWidth*IESTextureHeight)
+	for y := 0; y < IESTextureHeight; y++ {
+		horizontal := 360.0 * float32(y) / float32(IESTextureHeight)
+		for x := 0; x < IESTextureWidth; x++ {
+			vertical := 180.0 * float32(x) / float32(IESTextureWidth-1)
+			data[y*IESTextureWidth+x] = p.Sample(vertical, horizontal)
+		}
+	}
+
+	gfx := fizzle.GetGraphics()
+	p.Texture = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, p.Texture)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.R32F, IESTextureWidth, IESTextureHeight, 0, graphics.RED, graphics.FLOAT, gfx.Ptr(data), len(data)*4)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.REPEAT)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+}
+
+// Destroy deletes the texture of the profile from OpenGL.
+func (p *IESProfile) Destroy() {
+	fizzle.GetGraphics().DeleteTexture(p.Texture)
+	p.Texture = 0
+}
+
+// findAngleSpan returns the indexes of the angles on either side of the
+// angle and how far it is between them. Angles outside of the list are
+// clamped to the ends.
+func findAngleSpan(angles []float32, angle float32) (int, int, float32) {
+	last := len(angles) - 1
+	if angle <= angles[0] {
+		return 0, 0, 0.0
+	}
+	if angle >= angles[last] {
+		return last, last, 0.0
+	}
+
+	i := sort.Search(len(angles), func(i int) bool { return angles[i] > angle }) - 1
+	span := angles[i+1] - angles[i]
+	if span <= 0.0 {
+		return i, i, 0.0
+	}
+	return i, i + 1, (angle - angles[i]) / span
+}
+
+// mathMod returns x modulo y in the range of [0, y).
+func mathMod(x, y float64) float64 {
+	m := x - y*float64(int64(x/y))
+	if m < 0.0 {
+		m += y
+	}
+	return m
+}
+
+// float32Slice implements sort.Interface to check the order of angles.
+type float32Slice []float32
+
+func (s float32Slice) Len() int {
+	return len(s)
+}
+
+func (s float32Slice) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s float32Slice) Less(i, j int) bool {
+	return s[i] < s[j]
+}
+
+// GetIESMatrix returns the matrix that rotates world space directions into
+// the space of the light's IESProfile. A zero IESRotation is treated as the
+// identity so that lights created without NewLight still work.
+func (l *Light) GetIESMatrix() mgl.Mat4 {
+	rot := l.IESRotation
+	if rot.W == 0.0 && rot.V.Len() == 0.0 {
+		return mgl.Ident4()
+	}
+	return rot.Normalize().Conjugate().Mat4()
+}
@@ -0,0 +1,234 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// LightFlicker is the style of random flicker a LightAnimation applies.
+type LightFlicker int
+
+const (
+	// LightFlickerNone doesn't flicker the light.
+	LightFlickerNone LightFlicker = iota
+
+	// LightFlickerTorch smoothly wavers the light like a torch or candle.
+	LightFlickerTorch
+
+	// LightFlickerFluorescent keeps the light steady with the occasional
+	// burst of stuttering like a failing fluorescent tube.
+	LightFlickerFluorescent
+)
+
+// LightIntensityKey is a key in the intensity curve of a LightAnimation.
+type LightIntensityKey struct {
+	// Time is the time of the key in seconds.
+	Time float32
+
+	// Intensity is the amount the light's strength is scaled by.
+	Intensity float32
+}
+
+// LightAnimation animates the strength and color of a Light. The forward
+// renderer advances its animation time each frame in EndRenderFrame, or
+// with AdvanceAnimationTime, and applies the animation while binding the
+// light, so the Light's own Strength and DiffuseColor are left untouched
+// and act as the base values.
+//
+// The curves loop over the time of their last key and are linearly
+// interpolated. Flicker and strobing are applied on top of the curves.
+type LightAnimation struct {
+	// IntensityKeys is the curve that scales the light's strength. It must
+	// be sorted by time.
+	IntensityKeys []LightIntensityKey
+
+	// ColorGradient is multiplied into the light's color, with the key
+	// positions being times in seconds. The alpha of the colors is ignored.
+	ColorGradient *fizzle.Gradient
+
+	// Flicker is the style of random flicker to apply.
+	Flicker LightFlicker
+
+	// FlickerAmount is how strong the flicker is in the range of [0, 1].
+	// For LightFlickerFluorescent it is how often the light stutters.
+	FlickerAmount float32
+
+	// FlickerSpeed scales how quickly the flicker changes.
+	FlickerSpeed float32
+
+	// StrobeRate is the number of times per second the light flashes. A
+	// value of 0 disables strobing.
+	StrobeRate float32
+
+	// StrobeDuty is the fraction of each strobe flash the light is on.
+	StrobeDuty float32
+
+	// Phase offsets the time of the animation in seconds so that lights
+	// sharing the same settings don't flicker in unison.
+	Phase float32
+
+	// Paused stops the animation at its current values.
+	Paused bool
+
+	evaluated bool
+	lastTime  float32
+	intensity float32
+	color     mgl.Vec3
+}
+
+// NewLightAnimation creates a new LightAnimation that leaves the light as
+// is until curves, flicker or strobing are set.
+func NewLightAnimation() *LightAnimation {
+	anim := new(LightAnimation)
+	anim.FlickerSpeed = 1.0
+	anim.StrobeDuty = 0.5
+	return anim
+}
+
+// NewTorchFlicker creates a new LightAnimation that wavers like a torch.
+func NewTorchFlicker(phase float32) *LightAnimation {
+	anim := NewLightAnimation()
+	anim.Flicker = LightFlickerTorch
+	anim.FlickerAmount = 0.35
+	anim.FlickerSpeed = 8.0
+	anim.Phase = phase
+	return anim
+}
+
+// NewCandleFlicker creates a new LightAnimation that gently wavers like
+// a candle.
+func NewCandleFlicker(phase float32) *LightAnimation {
+	anim := NewLightAnimation()
+	anim.Flicker = LightFlickerTorch
+	anim.FlickerAmount = 0.15
+	anim.FlickerSpeed = 5.0
+	anim.Phase = phase
+	return anim
+}
+
+// NewFluorescentFlicker creates a new LightAnimation that stutters like a
+// failing fluorescent tube.
+func NewFluorescentFlicker(phase float32) *LightAnimation {
+	anim := NewLightAnimation()
+	anim.Flicker = LightFlickerFluorescent
+	anim.FlickerAmount = 0.3
+	anim.FlickerSpeed = 1.5
+	anim.Phase = phase
+	return anim
+}
+
+// NewStrobe creates a new LightAnimation that flashes the light the number
+// of times per second specified, staying on for the duty fraction of each
+// flash.
+func NewStrobe(rate, duty float32) *LightAnimation {
+	anim := NewLightAnimation()
+	anim.StrobeRate = rate
+	anim.StrobeDuty = duty
+	return anim
+}
+
+// Evaluate returns the amount the light's strength is scaled by and the
+// color multiplied into its diffuse color at the time specified in seconds.
+// The result is cached so that evaluating it for each draw of a frame is
+// cheap.
+func (anim *LightAnimation) Evaluate(t float32) (float32, mgl.Vec3) {
+	if anim.evaluated && (anim.Paused || t == anim.lastTime) {
+		return anim.intensity, anim.color
+	}
+	anim.evaluated = true
+	anim.lastTime = t
+
+	t += anim.Phase
+	intensity := float32(1.0)
+	color := mgl.Vec3{1.0, 1.0, 1.0}
+
+	if len(anim.IntensityKeys) > 0 {
+		intensity *= anim.sampleIntensity(t)
+	}
+	if anim.ColorGradient != nil && len(anim.ColorGradient.Keys) > 0 {
+		color = anim.ColorGradient.Sample(loopCurveTime(t, anim.ColorGradient.GetLength())).Vec3()
+	}
+
+	ft := t * anim.FlickerSpeed
+	switch anim.Flicker {
+	case LightFlickerTorch:
+		// two octaves of noise so that the slow waver has some crackle
+		n := 0.65*valueNoise(ft) + 0.35*valueNoise(ft*2.7+17.0)
+		intensity *= 1.0 - anim.FlickerAmount*n
+	case LightFlickerFluorescent:
+		// the light only stutters while the slow noise is under the amount
+		if valueNoise(ft) < anim.FlickerAmount*0.5 {
+			if valueNoise(ft*40.0+31.0) < 0.5 {
+				intensity *= 0.1
+			}
+		}
+	}
+
+	if anim.StrobeRate > 0.0 {
+		cycle := t * anim.StrobeRate
+		if cycle-float32(math.Floor(float64(cycle))) >= anim.StrobeDuty {
+			intensity = 0.0
+		}
+	}
+
+	anim.intensity = intensity
+	anim.color = color
+	return intensity, color
+}
+
+// sampleIntensity interpolates the intensity curve at the time.
+func (anim *LightAnimation) sampleIntensity(t float32) float32 {
+	keys := anim.IntensityKeys
+	t = loopCurveTime(t, keys[len(keys)-1].Time)
+	if t <= keys[0].Time {
+		return keys[0].Intensity
+	}
+	for i := 1; i < len(keys); i++ {
+		if t <= keys[i].Time {
+			prev := keys[i-1]
+			f := curveFactor(prev.Time, keys[i].Time, t)
+			return prev.Intensity + (keys[i].Intensity-prev.Intensity)*f
+		}
+	}
+	return keys[len(keys)-1].Intensity
+}
+
+// loopCurveTime wraps the time into the length of a curve.
+func loopCurveTime(t, length float32) float32 {
+	if length <= 0.0 {
+		return 0.0
+	}
+	return float32(mathMod(float64(t), float64(length)))
+}
+
+// curveFactor returns how far t is between the times of two keys.
+func curveFactor(t0, t1, t float32) float32 {
+	if t1 <= t0 {
+		return 0.0
+	}
+	return (t - t0) / (t1 - t0)
+}
+
+// valueNoise returns smoothly interpolated 1D noise in the range of [0, 1].
+func valueNoise(x float32) float32 {
+	i := math.Floor(float64(x))
+	f := float32(float64(x) - i)
+	f = f * f * (3.0 - 2.0*f)
+	a := hashNoise(int64(i))
+	b := hashNoise(int64(i) + 1)
+	return a + (b-a)*f
+}
+
+// hashNoise returns a pseudo random value in the range of [0, 1] for the
+// integer.
+func hashNoise(n int64) float32 {
+	h := uint32(n) * 374761393
+	h = (h ^ (h >> 13)) * 1274126177
+	h ^= h >> 16
+	return float32(h) / float32(math.MaxUint32)
+}
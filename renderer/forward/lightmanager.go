@@ -0,0 +1,305 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// DefaultLightFadeTime is the default number of seconds it takes a light
+	// to fade in or out when it gains or loses a slot in ActiveLights.
+	DefaultLightFadeTime = 0.25
+
+	// DefaultLightInfluence is the default attenuation below which a point
+	// light is considered to no longer light anything. It determines the
+	// radius of a point light used for scoring and culling.
+	DefaultLightInfluence = 0.05
+
+	// DefaultLightHysteresis is the default multiplier on the score of
+	// lights that are already active, which keeps lights with similar
+	// scores from swapping slots every frame.
+	DefaultLightHysteresis = 1.2
+
+	// directionalLightScale is multiplied into the score of directional
+	// lights, which light everything, so that they stay active.
+	directionalLightScale = 1000000.0
+)
+
+// lightState is the per light state the LightManager keeps between frames.
+type lightState struct {
+	light    *Light
+	score    float32
+	fade     float32
+	selected bool
+}
+
+// LightManager holds all of the lights of a scene and picks the ones that
+// matter most to the camera each frame to fill the ActiveLights of a
+// ForwardRenderer. Lights are scored by their intensity and how much of the
+// screen their influence covers, which accounts for their distance to the
+// camera, and lights outside of the view frustum are skipped. Lights fade in
+// and out over FadeTime when they gain or lose a slot so that they don't pop.
+//
+// The lights are not modified; the renderer gets copies of them with the
+// Strength scaled by the fade, so that lights can be moved and changed as
+// normal.
+type LightManager struct {
+	// FadeTime is how many seconds it takes a light to fade in or out. A
+	// value of 0 disables fading.
+	FadeTime float32
+
+	// Influence is the attenuation below which a point light no longer
+	// lights anything.
+	Influence float32
+
+	// Hysteresis is multiplied into the score of lights that are active.
+	Hysteresis float32
+
+	states  []*lightState
+	slots   [MaxForwardLights]Light
+	ordered []*lightState
+	active  []*lightState
+	updated bool
+}
+
+// NewLightManager creates a new LightManager with no lights.
+func NewLightManager() *LightManager {
+	lm := new(LightManager)
+	lm.FadeTime = DefaultLightFadeTime
+	lm.Influence = DefaultLightInfluence
+	lm.Hysteresis = DefaultLightHysteresis
+	lm.states = []*lightState{}
+	return lm
+}
+
+// AddLight adds a light to the manager. Adding a light that is already
+// managed does nothing.
+func (lm *LightManager) AddLight(l *Light) {
+	if lm.getState(l) != nil {
+		return
+	}
+	lm.states = append(lm.states, &lightState{light: l})
+}
+
+// RemoveLight removes a light from the manager. It gets removed from the
+// renderer on the next Update.
+func (lm *LightManager) RemoveLight(l *Light) {
+	surviving := lm.states[:0]
+	for _, s := range lm.states {
+		if s.light != l {
+			surviving = append(surviving, s)
+		}
+	}
+	lm.states = surviving
+}
+
+// GetLights returns all of the managed lights.
+func (lm *LightManager) GetLights() []*Light {
+	lights := make([]*Light, len(lm.states))
+	for i, s := range lm.states {
+		lights[i] = s.light
+	}
+	return lights
+}
+
+// GetFade returns how far the light has faded in, from 0 to 1.
+func (lm *LightManager) GetFade(l *Light) float32 {
+	if s := lm.getState(l); s != nil {
+		return s.fade
+	}
+	return 0.0
+}
+
+// GetScore returns the score of the light for the camera position and
+// projection. Higher scores matter more and a score of 0 means the light
+// can't be seen. The frustum may be nil to skip visibility testing.
+func (lm *LightManager) GetScore(l *Light, eye mgl.Vec3, projection mgl.Mat4, frustum *fizzle.Frustum) float32 {
+	intensity := (l.DiffuseIntensity + l.AmbientIntensity + l.SpecularIntensity) * l.Strength
+	brightest := l.DiffuseColor[0]
+	if l.DiffuseColor[1] > brightest {
+		brightest = l.DiffuseColor[1]
+	}
+	if l.DiffuseColor[2] > brightest {
+		brightest = l.DiffuseColor[2]
+	}
+	intensity *= brightest
+	if intensity <= 0.0 {
+		return 0.0
+	}
+
+	// directional lights light everything
+	if l.Direction[0] != 0.0 || l.Direction[1] != 0.0 || l.Direction[2] != 0.0 {
+		return intensity * directionalLightScale
+	}
+
+	radius := lm.GetInfluenceRadius(l)
+	if radius <= 0.0 {
+		return 0.0
+	}
+	if frustum != nil && !frustum.ContainsSphere(l.Position, radius) {
+		return 0.0
+	}
+
+	// approximate the fraction of the screen the sphere of influence covers
+	// with the projection's vertical scale
+	coverage := float32(1.0)
+	distance := l.Position.Sub(eye).Len()
+	if distance > radius {
+		projected := radius / distance * projection[5]
+		coverage = projected * projected
+		if coverage > 1.0 {
+			coverage = 1.0
+		}
+	}
+
+	return intensity * coverage
+}
+
+// GetInfluenceRadius returns the distance at which the point light's
+// attenuation drops to the manager's Influence. Lights without any
+// distance attenuation return math.MaxFloat32.
+func (lm *LightManager) GetInfluenceRadius(l *Light) float32 {
+	return getInfluenceRadius(l, lm.Influence)
+}
+
+// getInfluenceRadius returns the distance at which the point light's
+// attenuation drops to the influence, or DefaultLightInfluence if the
+// influence isn't positive.
+func getInfluenceRadius(l *Light, influence float32) float32 {
+	// solve Strength / (1 + c + l*d + q*d*d) = Influence for d
+	if influence <= 0.0 {
+		influence = DefaultLightInfluence
+	}
+	a := float64(l.QuadraticAttenuation)
+	b := float64(l.LinearAttenuation)
+	c := 1.0 + float64(l.ConstAttenuation) - float64(l.Strength/influence)
+	if c >= 0.0 {
+		// too weak to reach the influence at any distance
+		return 0.0
+	}
+
+	if a > 0.0 {
+		return float32((-b + math.Sqrt(b*b-4.0*a*c)) / (2.0 * a))
+	} else if b > 0.0 {
+		return float32(-c / b)
+	}
+	return math.MaxFloat32
+}
+
+// Update scores the lights for the camera, fades them and fills the
+// ActiveLights of the renderer, with shadow casting lights packed first.
+// The frameDelta is the time in seconds since the last update.
+func (lm *LightManager) Update(fr *ForwardRenderer, camera fizzle.Camera, projection mgl.Mat4, frameDelta float32) {
+	var eye mgl.Vec3
+	var frustum *fizzle.Frustum
+	if camera != nil {
+		eye = camera.GetPosition()
+		f := fizzle.GetFrustum(camera, projection)
+		frustum = &f
+	}
+
+	for _, s := range lm.states {
+		s.score = lm.GetScore(s.light, eye, projection, frustum)
+		if s.selected {
+			s.score *= lm.Hysteresis
+		}
+	}
+
+	// pick the best lights for the slots
+	lm.ordered = append(lm.ordered[:0], lm.states...)
+	sort.Stable(lightStatesByScore(lm.ordered))
+	for i, s := range lm.ordered {
+		s.selected = i < MaxForwardLights && s.score > 0.0
+	}
+
+	// fade the lights and fill any spare slots with lights fading out;
+	// the first update doesn't fade the lights in from nothing
+	fadeStep := float32(1.0)
+	if lm.FadeTime > 0.0 && lm.updated {
+		fadeStep = frameDelta / lm.FadeTime
+	}
+	lm.updated = true
+	active := lm.active[:0]
+	for _, s := range lm.ordered {
+		if s.selected {
+			s.fade += fadeStep
+			if s.fade > 1.0 {
+				s.fade = 1.0
+			}
+			active = append(active, s)
+		}
+	}
+	for _, s := range lm.ordered {
+		if s.selected {
+			continue
+		}
+		s.fade -= fadeStep
+		if s.fade <= 0.0 || len(active) >= MaxForwardLights {
+			s.fade = 0.0
+			continue
+		}
+		active = append(active, s)
+	}
+
+	// the renderer expects the shadow casting lights first
+	sort.Stable(lightStatesByShadow(active))
+	lm.active = active
+
+	for i := range fr.ActiveLights {
+		if i >= len(active) {
+			fr.ActiveLights[i] = nil
+			continue
+		}
+		lm.slots[i] = *active[i].light
+		lm.slots[i].Strength *= active[i].fade
+		fr.ActiveLights[i] = &lm.slots[i]
+	}
+}
+
+// getState returns the state for the light or nil if it isn't managed.
+func (lm *LightManager) getState(l *Light) *lightState {
+	for _, s := range lm.states {
+		if s.light == l {
+			return s
+		}
+	}
+	return nil
+}
+
+// lightStatesByScore implements sort.Interface to sort light states from
+// the highest score to the lowest.
+type lightStatesByScore []*lightState
+
+func (s lightStatesByScore) Len() int {
+	return len(s)
+}
+
+func (s lightStatesByScore) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s lightStatesByScore) Less(i, j int) bool {
+	return s[i].score > s[j].score
+}
+
+// lightStatesByShadow implements sort.Interface to move the light states
+// of lights with shadow maps to the front.
+type lightStatesByShadow []*lightState
+
+func (s lightStatesByShadow) Len() int {
+	return len(s)
+}
+
+func (s lightStatesByShadow) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s lightStatesByShadow) Less(i, j int) bool {
+	return s[i].light.ShadowMap != nil && s[j].light.ShadowMap == nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// Projector projects a texture from the position of its owning light so
+// that the light it casts on surfaces is tinted by the texture, such as a
+// flashlight cookie, a stained glass window or a video projector. Surfaces
+// outside of the projector's frustum get none of the light's diffuse or
+// specular light.
+type Projector struct {
+	// Texture is the texture that gets projected. The projector does not
+	// own it, so it can be shared or be a render target or video frame.
+	Texture graphics.Texture
+
+	// Direction controls the direction the projector points in.
+	Direction mgl.Vec3
+
+	// Up defines the Up vector for the projection. Defaults to {0,1,0}
+	Up mgl.Vec3
+
+	// FieldOfView is the vertical field of view of the projection in radians.
+	FieldOfView float32
+
+	// AspectRatio is the ratio of width to height of the projection.
+	AspectRatio float32
+
+	// Near is the near distance for the projection
+	Near float32
+
+	// Far is the far distance for the projection
+	Far float32
+
+	// Projection is the projection transformation matrix for the projector.
+	// Updated with UpdateProjectorData().
+	Projection mgl.Mat4
+
+	// View is the view transformation matrix for the projector.
+	// Updated with UpdateProjectorData().
+	View mgl.Mat4
+
+	// BiasedMatrix is the view-projection matrix biased to map into texture
+	// space. Updated with UpdateProjectorData().
+	BiasedMatrix mgl.Mat4
+}
+
+// CreateProjector sets up a Projector for the light that projects the
+// texture in the direction specified with the field of view in radians.
+func (l *Light) CreateProjector(tex graphics.Texture, fov float32, near float32, far float32, dir mgl.Vec3) {
+	p := new(Projector)
+	p.Texture = tex
+	p.Direction = dir
+	p.Up = mgl.Vec3{0.0, 1.0, 0.0}
+	p.FieldOfView = fov
+	p.AspectRatio = 1.0
+	p.Near = near
+	p.Far = far
+	p.Projection = mgl.Ident4()
+	p.View = mgl.Ident4()
+	p.BiasedMatrix = mgl.Ident4()
+	l.Projector = p
+	l.UpdateProjectorData()
+}
+
+// UpdateProjectorData updates a projector's matrixes based on data from the
+// light. It gets called by the renderer while binding the lights.
+func (l *Light) UpdateProjectorData() {
+	if l.Projector == nil {
+		return
+	}
+
+	p := l.Projector
+	target := l.Position.Add(p.Direction)
+	p.Projection = mgl.Perspective(p.FieldOfView, p.AspectRatio, p.Near, p.Far)
+	p.View = mgl.LookAtV(l.Position, target, p.Up)
+	p.BiasedMatrix = shadowBiasMat.Mul4(p.Projection.Mul4(p.View))
+}
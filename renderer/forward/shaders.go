@@ -54,6 +54,59 @@ const (
     	return vec4(shadow,shadow,shadow,1.0);
     }`
 
+	calcCookieFactor = `vec3 CalcCookieFactor(int i, vec3 v_model) {
+    	if (LIGHT_COOKIE_VALID[i] == 0.0) {
+    		return vec3(1.0);
+    	}
+
+    	// surfaces behind or outside of the projector get no light from it
+    	vec4 coord = LIGHT_COOKIE_MATRIX[i] * vec4(v_model, 1.0);
+    	if (coord.w <= 0.0) {
+    		return vec3(0.0);
+    	}
+    	vec2 uv = coord.xy / coord.w;
+    	if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+    		return vec3(0.0);
+    	}
+
+    	// samplers can only be indexed by constants
+    	if (i == 0) {
+    		return texture(LIGHT_COOKIES[0], uv).rgb;
+    	} else if (i == 1) {
+    		return texture(LIGHT_COOKIES[1], uv).rgb;
+    	} else if (i == 2) {
+    		return texture(LIGHT_COOKIES[2], uv).rgb;
+    	}
+    	return texture(LIGHT_COOKIES[3], uv).rgb;
+    }`
+
+	calcIESFactor = `float CalcIESFactor(int i, vec3 light_to_surface) {
+    	if (LIGHT_IES_VALID[i] == 0.0) {
+    		return 1.0;
+    	}
+
+    	// the vertical angle is 0 at -Y in the profile's space and the
+    	// horizontal angle runs from +X toward +Z; both get remapped to the
+    	// centers of the texels the profile was baked into.
+    	vec3 d = normalize((LIGHT_IES_MATRIX[i] * vec4(light_to_surface, 0.0)).xyz);
+    	float vertical = acos(clamp(-d.y, -1.0, 1.0)) / 3.14159265;
+    	float horizontal = atan(d.z, d.x) / 6.28318531;
+    	if (horizontal < 0.0) {
+    		horizontal += 1.0;
+    	}
+    	vec2 uv = vec2(vertical * (127.0 / 128.0) + (0.5 / 128.0), horizontal + (0.5 / 64.0));
+
+    	// samplers can only be indexed by constants
+    	if (i == 0) {
+    		return texture(LIGHT_IES[0], uv).r;
+    	} else if (i == 1) {
+    		return texture(LIGHT_IES[1], uv).r;
+    	} else if (i == 2) {
+    		return texture(LIGHT_IES[2], uv).r;
+    	}
+    	return texture(LIGHT_IES[3], uv).r;
+    }`
+
 	calcADSLights = `vec3 CalcADSLights(vec3 v_model, vec3 n_model, vec3 color)
     {
     	vec3 scattered_light = vec3(0.0);
@@ -94,9 +147,10 @@ const (
     			specularF = pow(max(0.0, dot(s_to_camera, reflection)), MATERIAL_SHININESS);
     		}
 
+    		vec3 shaping = CalcCookieFactor(i, v_model) * CalcIESFactor(i, -incidence);
     		vec3 ambient = LIGHT_DIFFUSE[i].rgb * LIGHT_AMBIENT_INTENSITY[i] * attenuation;
-    		vec3 diffuse = LIGHT_DIFFUSE[i].rgb * LIGHT_DIFFUSE_INTENSITY[i] * diffuseF * attenuation;
-    		vec3 specular = LIGHT_DIFFUSE[i].rgb * LIGHT_SPECULAR_INTENSITY[i] * specularF * attenuation;
+    		vec3 diffuse = LIGHT_DIFFUSE[i].rgb * LIGHT_DIFFUSE_INTENSITY[i] * diffuseF * attenuation * shaping;
+    		vec3 specular = LIGHT_DIFFUSE[i].rgb * LIGHT_SPECULAR_INTENSITY[i] * specularF * attenuation * shaping;
 
     		scattered_light += ambient + diffuse;
     		reflected_light += specular;
@@ -105,6 +159,101 @@ const (
     	return min(color * scattered_light + reflected_light, vec3(1.0));
     }
     `
+
+	calcPBRLights = `const float PI = 3.14159265359;
+
+    float DistributionGGX(float NdotH, float roughness) {
+    	float a = roughness * roughness;
+    	float a2 = a * a;
+    	float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+    	return a2 / max(PI * d * d, 0.0000001);
+    }
+
+    float GeometrySmith(float NdotV, float NdotL, float roughness) {
+    	// Schlick-GGX with k remapped for direct lighting
+    	float r = roughness + 1.0;
+    	float k = (r * r) / 8.0;
+    	float gv = NdotV / (NdotV * (1.0 - k) + k);
+    	float gl = NdotL / (NdotL * (1.0 - k) + k);
+    	return gv * gl;
+    }
+
+    vec3 FresnelSchlick(float cosTheta, vec3 F0) {
+    	return F0 + (1.0 - F0) * pow(1.0 - cosTheta, 5.0);
+    }
+
+    vec3 FresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness) {
+    	return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(1.0 - cosTheta, 5.0);
+    }
+
+    // EnvBRDFApprox is an analytic fit of the BRDF lookup table used when
+    // the material doesn't have one.
+    vec2 EnvBRDFApprox(float NdotV, float roughness) {
+    	const vec4 c0 = vec4(-1.0, -0.0275, -0.572, 0.022);
+    	const vec4 c1 = vec4(1.0, 0.0425, 1.04, -0.04);
+    	vec4 r = roughness * c0 + c1;
+    	float a004 = min(r.x * r.x, exp2(-9.28 * NdotV)) * r.x + r.y;
+    	return vec2(-1.04, 1.04) * a004 + r.zw;
+    }
+
+    // CalcPBRLights returns the light reflected toward the camera by the
+    // active lights with the Cook-Torrance GGX BRDF and sets ambient to the
+    // ambient light of the lights. The light intensities are scaled by PI so
+    // that a white surface facing a light is as bright as with CalcADSLights.
+    // Light cookies and IES profiles are not used so that the shader stays
+    // within the 16 texture units OpenGL 3.3 guarantees.
+    vec3 CalcPBRLights(vec3 v_model, vec3 n_model, vec3 to_camera, vec3 albedo, float roughness, float metalness, vec3 F0, out vec3 ambient)
+    {
+    	vec3 direct = vec3(0.0);
+    	ambient = vec3(0.0);
+    	float NdotV = max(dot(n_model, to_camera), 0.0001);
+
+    	for (int i=0; i<MAX_LIGHTS; i++) {
+    		if (i >= LIGHT_COUNT) {
+    			break;
+    		}
+
+    		vec3 incidence;
+    		float attenuation = LIGHT_STRENGTH[i];
+    		vec3 light_direction = LIGHT_DIRECTION[i]; // in world space
+
+    		if (light_direction.x == 0.0 && light_direction.y == 0.0 && light_direction.z == 0.0) {
+    			// point light
+    			light_direction = LIGHT_POSITION[i] - v_model;
+    			float distance = length(light_direction);
+
+    			attenuation = LIGHT_STRENGTH[i] / (1.0 +
+    				(LIGHT_CONST_ATTENUATION[i] +
+    				 LIGHT_LINEAR_ATTENUATION[i] * distance +
+    				 LIGHT_QUADRATIC_ATTENUATION[i] * distance * distance));
+
+    			incidence = light_direction / distance;
+    		} else {
+    			// directional light
+    			incidence = -normalize(light_direction);
+    		}
+
+    		ambient += LIGHT_DIFFUSE[i].rgb * LIGHT_AMBIENT_INTENSITY[i] * attenuation;
+
+    		float NdotL = dot(n_model, incidence);
+    		if (NdotL <= 0.0) {
+    			continue;
+    		}
+
+    		vec3 radiance = LIGHT_DIFFUSE[i].rgb * LIGHT_DIFFUSE_INTENSITY[i] * attenuation * PI;
+
+    		vec3 h = normalize(incidence + to_camera);
+    		vec3 F = FresnelSchlick(max(dot(h, to_camera), 0.0), F0);
+    		float D = DistributionGGX(max(dot(n_model, h), 0.0), roughness);
+    		float G = GeometrySmith(NdotV, NdotL, roughness);
+    		vec3 specular = D * G * F / (4.0 * NdotV * NdotL + 0.0001);
+    		vec3 kD = (vec3(1.0) - F) * (1.0 - metalness);
+
+    		direct += (kD * albedo / PI + specular) * radiance * NdotL;
+    	}
+
+    	return direct;
+    }`
 	/*
 
 	    ____                  _
@@ -123,6 +272,8 @@ const (
     const int MAX_BONES=32;
 
     uniform mat4 MVP_MATRIX;
+    uniform vec4 MATERIAL_ATLAS_REGION;
+    uniform mat4 MATERIAL_UV_TRANSFORM;
     uniform mat4 M_MATRIX;
     uniform mat4 V_MATRIX;
     uniform mat4 MV_MATRIX;
@@ -151,7 +302,7 @@ const (
     	vs_position_view = vec3(MV_MATRIX * vertex4);
     	vs_camera_world = CAMERA_WORLD_POSITION;
     	vs_tangent = mat3(M_MATRIX) * VERTEX_TANGENT;
-    	vs_tex0_uv = VERTEX_UV_0;
+    	vs_tex0_uv = MATERIAL_ATLAS_REGION.xy + (MATERIAL_UV_TRANSFORM * vec4(VERTEX_UV_0, 0.0, 1.0)).xy * MATERIAL_ATLAS_REGION.zw;
 
     	/* handle the shadow coordinates unrolled since for loop indexing can be problematic */
     	vs_shadow_coord[0] = (SHADOW_MATRIX[0] * M_MATRIX) * vertex4;
@@ -176,7 +327,18 @@ const (
     uniform sampler2D MATERIAL_TEX_NORMALS; // norm
     uniform float MATERIAL_TEX_DIFFUSE_VALID;
     uniform float MATERIAL_TEX_NORMALS_VALID;
+    uniform vec4 MATERIAL_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_AO;
+    uniform float MATERIAL_TEX_EMISSIVE_VALID;
+    uniform float MATERIAL_TEX_AO_VALID;
     uniform sampler2DShadow SHADOW_MAPS[4];
+    uniform sampler2D LIGHT_COOKIES[4];
+    uniform mat4 LIGHT_COOKIE_MATRIX[4];
+    uniform float LIGHT_COOKIE_VALID[4];
+    uniform sampler2D LIGHT_IES[4];
+    uniform mat4 LIGHT_IES_MATRIX[4];
+    uniform float LIGHT_IES_VALID[4];
 
     uniform vec3 LIGHT_POSITION[MAX_LIGHTS];
     uniform vec4 LIGHT_DIFFUSE[MAX_LIGHTS];
@@ -190,6 +352,8 @@ const (
     uniform float LIGHT_STRENGTH[MAX_LIGHTS];
     uniform int LIGHT_COUNT;
     uniform int SHADOW_COUNT;
+    uniform float EXPOSURE;
+    uniform int OBJECT_ID;
 
     in vec3 vs_normal_model;
     in vec3 vs_position_model;
@@ -199,10 +363,15 @@ const (
     in vec3 vs_camera_world;
     in vec4 vs_shadow_coord[4];
 
-    out vec4 frag_color;
+    layout(location = 0) out vec4 frag_color;
+    layout(location = 1) out uint frag_id;
 
     ` + calcShadowFactor + `
 
+    ` + calcCookieFactor + `
+
+    ` + calcIESFactor + `
+
     ` + calcADSLights + `
 
     void main()
@@ -224,7 +393,302 @@ const (
     		normal = TBN * bump_normal;
     	}
 
-			frag_color = vec4(shadowFactor.rgb * CalcADSLights(vs_position_model, normalize(normal), color.rgb), 1.0);
+    	vec3 lit = shadowFactor.rgb * CalcADSLights(vs_position_model, normalize(normal), color.rgb);
+    	if (MATERIAL_TEX_AO_VALID > 0.0) {
+    		lit *= texture(MATERIAL_TEX_AO, vs_tex0_uv).r;
+    	}
+
+    	vec3 emissive = MATERIAL_EMISSIVE.rgb;
+    	if (MATERIAL_TEX_EMISSIVE_VALID > 0.0) {
+    		emissive = texture(MATERIAL_TEX_EMISSIVE, vs_tex0_uv).rgb;
+    	}
+
+    	frag_color = vec4((lit + emissive) * EXPOSURE, 1.0);
+    	frag_id = uint(OBJECT_ID);
+    }
+    `
+
+	/*
+
+	  _____    ____    _____
+	 |  __ \  |  _ \  |  __ \
+	 | |__) | | |_) | | |__) |
+	 |  ___/  |  _ <  |  _  /
+	 | |      | |_) | | | \ \
+	 |_|      |____/  |_|  \_\
+
+	*/
+
+	pbrShaderF = `#version 330
+    precision highp float;
+
+    const int MAX_LIGHTS=4;
+
+    // the prefiltered environment goes from a mirror at level 0 to fully
+    // rough at probe.RoughnessLevels-1
+    const float ENVIRONMENT_MAX_LOD = 4.0;
+
+    uniform mat4 V_MATRIX;
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_NORMALS;
+    uniform float MATERIAL_TEX_DIFFUSE_VALID;
+    uniform float MATERIAL_TEX_NORMALS_VALID;
+    uniform vec4 MATERIAL_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_EMISSIVE;
+    uniform float MATERIAL_TEX_EMISSIVE_VALID;
+    uniform float MATERIAL_ROUGHNESS;
+    uniform float MATERIAL_METALNESS;
+    uniform sampler2D MATERIAL_TEX_ORM;
+    uniform float MATERIAL_TEX_ORM_VALID;
+    uniform float MATERIAL_ORM_OCCLUSION;
+    uniform samplerCube MATERIAL_TEX_ENVIRONMENT;
+    uniform samplerCube MATERIAL_TEX_IRRADIANCE;
+    uniform sampler2D MATERIAL_TEX_BRDF_LUT;
+    uniform float MATERIAL_TEX_ENVIRONMENT_VALID;
+    uniform float MATERIAL_TEX_IRRADIANCE_VALID;
+    uniform float MATERIAL_TEX_BRDF_LUT_VALID;
+    uniform sampler2DShadow SHADOW_MAPS[4];
+
+    uniform vec3 LIGHT_POSITION[MAX_LIGHTS];
+    uniform vec4 LIGHT_DIFFUSE[MAX_LIGHTS];
+    uniform float LIGHT_DIFFUSE_INTENSITY[MAX_LIGHTS];
+    uniform float LIGHT_AMBIENT_INTENSITY[MAX_LIGHTS];
+    uniform vec3 LIGHT_DIRECTION[MAX_LIGHTS];
+    uniform float LIGHT_CONST_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_LINEAR_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_QUADRATIC_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_STRENGTH[MAX_LIGHTS];
+    uniform int LIGHT_COUNT;
+    uniform int SHADOW_COUNT;
+    uniform float EXPOSURE;
+    uniform int OBJECT_ID;
+
+    in vec3 vs_normal_model;
+    in vec3 vs_position_model;
+    in vec3 vs_position_view;
+    in vec3 vs_tangent;
+    in vec2 vs_tex0_uv;
+    in vec3 vs_camera_world;
+    in vec4 vs_shadow_coord[4];
+
+    layout(location = 0) out vec4 frag_color;
+    layout(location = 1) out uint frag_id;
+
+    ` + calcShadowFactor + `
+
+    ` + calcPBRLights + `
+
+    void main()
+    {
+    	vec4 color = MATERIAL_DIFFUSE;
+    	if (MATERIAL_TEX_DIFFUSE_VALID > 0.0) {
+    		color *= texture(MATERIAL_TEX_DIFFUSE, vs_tex0_uv);
+    	}
+
+    	vec3 normal = vs_normal_model;
+    	if (MATERIAL_TEX_NORMALS_VALID > 0.0) {
+    		vec3 T = normalize(vs_tangent - dot(vs_tangent, vs_normal_model) * vs_normal_model);
+    		vec3 BT = cross(T, vs_normal_model);
+    		vec3 bump_normal = texture(MATERIAL_TEX_NORMALS, vs_tex0_uv).rgb;
+    		bump_normal = 2.0 * bump_normal - vec3(1.0, 1.0, 1.0);
+    		mat3 TBN = mat3(T, BT, vs_normal_model);
+    		normal = TBN * bump_normal;
+    	}
+    	normal = normalize(normal);
+
+    	// the ORM texture scales the material values like the glTF factors
+    	float roughness = MATERIAL_ROUGHNESS;
+    	float metalness = MATERIAL_METALNESS;
+    	float occlusion = 1.0;
+    	if (MATERIAL_TEX_ORM_VALID > 0.0) {
+    		vec3 orm = texture(MATERIAL_TEX_ORM, vs_tex0_uv).rgb;
+    		roughness *= orm.g;
+    		metalness *= orm.b;
+    		if (MATERIAL_ORM_OCCLUSION > 0.0) {
+    			occlusion = orm.r;
+    		}
+    	}
+    	// highlights of perfectly smooth surfaces get too small to see
+    	roughness = clamp(roughness, 0.04, 1.0);
+    	metalness = clamp(metalness, 0.0, 1.0);
+
+    	vec3 albedo = color.rgb;
+    	vec3 F0 = mix(vec3(0.04), albedo, metalness);
+    	vec3 to_camera = normalize(vs_camera_world - vs_position_model);
+    	float NdotV = max(dot(normal, to_camera), 0.0001);
+
+    	vec3 ambient;
+    	vec3 direct = CalcPBRLights(vs_position_model, normal, to_camera, albedo, roughness, metalness, F0, ambient);
+    	direct *= CalcShadowFactor().rgb;
+
+    	// image based lighting when the material has the environment maps,
+    	// otherwise the ambient light of the lights stands in for them
+    	vec3 irradiance = ambient;
+    	if (MATERIAL_TEX_IRRADIANCE_VALID > 0.0) {
+    		irradiance = texture(MATERIAL_TEX_IRRADIANCE, normal).rgb;
+    	}
+    	vec3 prefiltered = ambient;
+    	if (MATERIAL_TEX_ENVIRONMENT_VALID > 0.0) {
+    		vec3 r = reflect(-to_camera, normal);
+    		prefiltered = textureLod(MATERIAL_TEX_ENVIRONMENT, r, roughness * ENVIRONMENT_MAX_LOD).rgb;
+    	}
+    	vec2 brdf = EnvBRDFApprox(NdotV, roughness);
+    	if (MATERIAL_TEX_BRDF_LUT_VALID > 0.0) {
+    		brdf = texture(MATERIAL_TEX_BRDF_LUT, vec2(NdotV, roughness)).rg;
+    	}
+
+    	vec3 F = FresnelSchlickRoughness(NdotV, F0, roughness);
+    	vec3 kD = (vec3(1.0) - F) * (1.0 - metalness);
+    	vec3 indirect = (kD * albedo * irradiance + prefiltered * (F0 * brdf.x + brdf.y)) * occlusion;
+
+    	vec3 emissive = MATERIAL_EMISSIVE.rgb;
+    	if (MATERIAL_TEX_EMISSIVE_VALID > 0.0) {
+    		emissive *= texture(MATERIAL_TEX_EMISSIVE, vs_tex0_uv).rgb;
+    	}
+
+    	frag_color = vec4((direct + indirect + emissive) * EXPOSURE, 1.0);
+    	frag_id = uint(OBJECT_ID);
+    }
+    `
+
+	/*
+
+	   _____   _                 _                            _
+	  / ____| | |               | |                          | |
+	 | |      | |  _   _   ___  | |_    ___   _ __    ___    __| |
+	 | |      | | | | | | / __| | __|  / _ \ | '__|  / _ \  / _` |
+	 | |____  | | | |_| | \__ \ | |_  |  __/ | |    |  __/ | (_| |
+	  \_____| |_|  \__,_| |___/  \__|  \___| |_|     \___|  \__,_|
+
+	*/
+
+	clusteredShaderF = `#version 330
+    precision highp float;
+
+    const int CLUSTER_INDEX_WIDTH = 1024;
+
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform float MATERIAL_SHININESS;
+    uniform sampler2D MATERIAL_TEX_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_NORMALS;
+    uniform float MATERIAL_TEX_DIFFUSE_VALID;
+    uniform float MATERIAL_TEX_NORMALS_VALID;
+    uniform vec4 MATERIAL_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_AO;
+    uniform float MATERIAL_TEX_EMISSIVE_VALID;
+    uniform float MATERIAL_TEX_AO_VALID;
+
+    uniform sampler2D CLUSTER_LIGHTS;
+    uniform sampler2D CLUSTER_GRID;
+    uniform sampler2D CLUSTER_INDICES;
+    uniform vec3 CLUSTER_TILES;
+    uniform vec2 CLUSTER_TILE_SIZE;
+    uniform vec2 CLUSTER_DEPTH;
+    uniform int CLUSTER_GLOBAL_COUNT;
+    uniform float EXPOSURE;
+    uniform int OBJECT_ID;
+
+    in vec3 vs_normal_model;
+    in vec3 vs_position_model;
+    in vec3 vs_position_view;
+    in vec3 vs_tangent;
+    in vec2 vs_tex0_uv;
+    in vec3 vs_camera_world;
+
+    layout(location = 0) out vec4 frag_color;
+    layout(location = 1) out uint frag_id;
+
+    /* each light is four texels: the position and diffuse intensity, the
+       color and strength, the attenuation and specular intensity and the
+       direction and ambient intensity */
+    void AddClusterLight(int i, vec3 v_model, vec3 n_model, inout vec3 scattered_light, inout vec3 reflected_light) {
+    	vec4 position = texelFetch(CLUSTER_LIGHTS, ivec2(0, i), 0);
+    	vec4 color = texelFetch(CLUSTER_LIGHTS, ivec2(1, i), 0);
+    	vec4 falloff = texelFetch(CLUSTER_LIGHTS, ivec2(2, i), 0);
+    	vec4 direction = texelFetch(CLUSTER_LIGHTS, ivec2(3, i), 0);
+
+    	vec3 incidence;
+    	float attenuation = color.a;
+    	if (direction.x == 0.0 && direction.y == 0.0 && direction.z == 0.0) {
+    		// point light
+    		vec3 light_direction = position.xyz - v_model;
+    		float distance = length(light_direction);
+    		attenuation = color.a / (1.0 + (falloff.x + falloff.y * distance + falloff.z * distance * distance));
+    		incidence = light_direction / distance;
+    	} else {
+    		// directional light
+    		incidence = -normalize(direction.xyz);
+    	}
+
+    	float specularF = 0.0;
+    	float diffuseF = max(0.0, dot(n_model, incidence));
+    	if (MATERIAL_SHININESS != 0.0 && diffuseF != 0.0) {
+    		vec3 reflection = reflect(-incidence, n_model);
+    		vec3 s_to_camera = normalize(vs_camera_world - v_model);
+    		specularF = pow(max(0.0, dot(s_to_camera, reflection)), MATERIAL_SHININESS);
+    	}
+
+    	scattered_light += color.rgb * (direction.w + position.w * diffuseF) * attenuation;
+    	reflected_light += color.rgb * falloff.w * specularF * attenuation;
+    }
+
+    vec3 CalcClusteredLights(vec3 v_model, vec3 n_model, vec3 color) {
+    	vec3 scattered_light = vec3(0.0);
+    	vec3 reflected_light = vec3(0.0);
+
+    	// directional lights are first and light every cluster
+    	for (int i = 0; i < CLUSTER_GLOBAL_COUNT; i++) {
+    		AddClusterLight(i, v_model, n_model, scattered_light, reflected_light);
+    	}
+
+    	// find the cluster from the screen tile and the exponential depth slice
+    	ivec2 tile = clamp(ivec2(gl_FragCoord.xy / CLUSTER_TILE_SIZE), ivec2(0), ivec2(CLUSTER_TILES.xy) - 1);
+    	float depth = max(-vs_position_view.z, CLUSTER_DEPTH.x);
+    	int slice = clamp(int(log(depth / CLUSTER_DEPTH.x) * CLUSTER_DEPTH.y), 0, int(CLUSTER_TILES.z) - 1);
+    	vec2 cluster = texelFetch(CLUSTER_GRID, ivec2(tile.y * int(CLUSTER_TILES.x) + tile.x, slice), 0).rg;
+
+    	int first = int(cluster.r);
+    	int count = int(cluster.g);
+    	for (int j = 0; j < count; j++) {
+    		int index = first + j;
+    		int light = int(texelFetch(CLUSTER_INDICES, ivec2(index % CLUSTER_INDEX_WIDTH, index / CLUSTER_INDEX_WIDTH), 0).r);
+    		AddClusterLight(light, v_model, n_model, scattered_light, reflected_light);
+    	}
+
+    	return min(color * scattered_light + reflected_light, vec3(1.0));
+    }
+
+    void main()
+    {
+    	vec4 color = MATERIAL_DIFFUSE;
+    	if (MATERIAL_TEX_DIFFUSE_VALID > 0.0) {
+    		color *= texture(MATERIAL_TEX_DIFFUSE, vs_tex0_uv);
+    	}
+
+    	vec3 normal = vs_normal_model;
+    	if (MATERIAL_TEX_NORMALS_VALID > 0.0) {
+    		vec3 T = normalize(vs_tangent - dot(vs_tangent, vs_normal_model) * vs_normal_model);
+    		vec3 BT = cross(T, vs_normal_model);
+    		vec3 bump_normal = texture(MATERIAL_TEX_NORMALS, vs_tex0_uv).rgb;
+    		bump_normal = 2.0 * bump_normal - vec3(1.0, 1.0, 1.0);
+    		mat3 TBN = mat3(T, BT, vs_normal_model);
+    		normal = TBN * bump_normal;
+    	}
+
+    	vec3 lit = CalcClusteredLights(vs_position_model, normalize(normal), color.rgb);
+    	if (MATERIAL_TEX_AO_VALID > 0.0) {
+    		lit *= texture(MATERIAL_TEX_AO, vs_tex0_uv).r;
+    	}
+
+    	vec3 emissive = MATERIAL_EMISSIVE.rgb;
+    	if (MATERIAL_TEX_EMISSIVE_VALID > 0.0) {
+    		emissive = texture(MATERIAL_TEX_EMISSIVE, vs_tex0_uv).rgb;
+    	}
+
+    	frag_color = vec4((lit + emissive) * EXPOSURE, 1.0);
+    	frag_id = uint(OBJECT_ID);
     }
     `
 
@@ -247,6 +711,8 @@ const (
     const int MAX_BONES=32;
 
     uniform mat4 MVP_MATRIX;
+    uniform vec4 MATERIAL_ATLAS_REGION;
+    uniform mat4 MATERIAL_UV_TRANSFORM;
     uniform mat4 M_MATRIX;
     uniform mat4 V_MATRIX;
     uniform mat4 MV_MATRIX;
@@ -289,7 +755,7 @@ const (
     	vs_position_view = vec3(MV_MATRIX * skinned.position);
     	vs_camera_world = CAMERA_WORLD_POSITION;
     	vs_tangent = mat3(M_MATRIX) * skinned.tangent;
-    	vs_tex0_uv = VERTEX_UV_0;
+    	vs_tex0_uv = MATERIAL_ATLAS_REGION.xy + (MATERIAL_UV_TRANSFORM * vec4(VERTEX_UV_0, 0.0, 1.0)).xy * MATERIAL_ATLAS_REGION.zw;
 
     	/* handle the shadow coordinates unrolled since for loop indexing can be problematic */
     	vs_shadow_coord[0] = (SHADOW_MATRIX[0] * M_MATRIX) * skinned.position;
@@ -314,7 +780,18 @@ const (
     uniform sampler2D MATERIAL_TEX_NORMALS;
     uniform float MATERIAL_TEX_DIFFUSE_VALID;
     uniform float MATERIAL_TEX_NORMALS_VALID;
+    uniform vec4 MATERIAL_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_EMISSIVE;
+    uniform sampler2D MATERIAL_TEX_AO;
+    uniform float MATERIAL_TEX_EMISSIVE_VALID;
+    uniform float MATERIAL_TEX_AO_VALID;
     uniform sampler2DShadow SHADOW_MAPS[4];
+    uniform sampler2D LIGHT_COOKIES[4];
+    uniform mat4 LIGHT_COOKIE_MATRIX[4];
+    uniform float LIGHT_COOKIE_VALID[4];
+    uniform sampler2D LIGHT_IES[4];
+    uniform mat4 LIGHT_IES_MATRIX[4];
+    uniform float LIGHT_IES_VALID[4];
 
     uniform vec3 LIGHT_POSITION[MAX_LIGHTS];
     uniform vec4 LIGHT_DIFFUSE[MAX_LIGHTS];
@@ -328,6 +805,8 @@ const (
     uniform float LIGHT_STRENGTH[MAX_LIGHTS];
     uniform int LIGHT_COUNT;
     uniform int SHADOW_COUNT;
+    uniform float EXPOSURE;
+    uniform int OBJECT_ID;
 
     in vec3 vs_normal_model;
     in vec3 vs_position_model;
@@ -337,10 +816,15 @@ const (
     in vec3 vs_camera_world;
     in vec4 vs_shadow_coord[4];
 
-    out vec4 frag_color;
+    layout(location = 0) out vec4 frag_color;
+    layout(location = 1) out uint frag_id;
 
     ` + calcShadowFactor + `
 
+    ` + calcCookieFactor + `
+
+    ` + calcIESFactor + `
+
     ` + calcADSLights + `
 
     void main()
@@ -362,7 +846,18 @@ const (
     		normal = TBN * bump_normal;
     	}
 
-    	frag_color = vec4(shadowFactor.rgb * CalcADSLights(vs_position_model, normalize(normal), color.rgb), 1.0);
+    	vec3 lit = shadowFactor.rgb * CalcADSLights(vs_position_model, normalize(normal), color.rgb);
+    	if (MATERIAL_TEX_AO_VALID > 0.0) {
+    		lit *= texture(MATERIAL_TEX_AO, vs_tex0_uv).r;
+    	}
+
+    	vec3 emissive = MATERIAL_EMISSIVE.rgb;
+    	if (MATERIAL_TEX_EMISSIVE_VALID > 0.0) {
+    		emissive = texture(MATERIAL_TEX_EMISSIVE, vs_tex0_uv).rgb;
+    	}
+
+    	frag_color = vec4((lit + emissive) * EXPOSURE, 1.0);
+    	frag_id = uint(OBJECT_ID);
     }
     `
 
@@ -461,6 +956,8 @@ const (
 			precision highp float;
 
 			uniform mat4 MVP_MATRIX;
+			uniform vec4 MATERIAL_ATLAS_REGION;
+			uniform mat4 MATERIAL_UV_TRANSFORM;
 
 			in vec3 VERTEX_POSITION;
 			in vec2 VERTEX_UV_0;
@@ -469,7 +966,7 @@ const (
 
 			void main(void) {
 				gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
-				vs_tex0_uv = VERTEX_UV_0;
+				vs_tex0_uv = MATERIAL_ATLAS_REGION.xy + (MATERIAL_UV_TRANSFORM * vec4(VERTEX_UV_0, 0.0, 1.0)).xy * MATERIAL_ATLAS_REGION.zw;
 			}
 			`
 
@@ -521,6 +1018,469 @@ const (
 	  frag_color = vec4(gl_FragCoord.z);
 	}
 	`
+
+	/*
+
+	   _         _             _        _                                                          _
+	  | |       (_)           | |      | |                                                        | |
+	  | |        _     __ _   | |__    | |_    _ __ ___      __ _    _ __     _ __      ___     __| |
+	  | |       | |   / _` |  | '_ \   | __|  | '_ ` _ \    / _` |  | '_ \   | '_ \    / _ \   / _` |
+	  | |____   | |  | (_| |  | | | |  | |_   | | | | | |  | (_| |  | |_) |  | |_) |  |  __/  | (_| |
+	  |______|  |_|   \__, |  |_| |_|   \__|  |_| |_| |_|   \__,_|  | .__/   | .__/    \___|   \__,_|
+	                   __/ |                                        | |      | |
+	                  |___/                                         |_|      |_|
+	*/
+
+	lightmappedShaderV = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+
+    in vec3 VERTEX_POSITION;
+    in vec2 VERTEX_UV_0;
+    in vec2 VERTEX_UV_1;
+
+    out vec2 vs_tex0_uv;
+    out vec2 vs_tex1_uv;
+
+    void main(void) {
+    	vs_tex0_uv = VERTEX_UV_0;
+    	vs_tex1_uv = VERTEX_UV_1;
+    	gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+    }
+    `
+
+	lightmappedShaderF = `#version 330
+    precision highp float;
+
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_DIFFUSE;
+    uniform float MATERIAL_TEX_DIFFUSE_VALID;
+    uniform sampler2D MATERIAL_TEX_LIGHTMAP;
+    uniform float MATERIAL_TEX_LIGHTMAP_VALID;
+
+    in vec2 vs_tex0_uv;
+    in vec2 vs_tex1_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	vec4 color = MATERIAL_DIFFUSE;
+    	if (MATERIAL_TEX_DIFFUSE_VALID > 0.0) {
+    		color *= texture(MATERIAL_TEX_DIFFUSE, vs_tex0_uv);
+    	}
+    	if (MATERIAL_TEX_LIGHTMAP_VALID > 0.0) {
+    		color.rgb *= texture(MATERIAL_TEX_LIGHTMAP, vs_tex1_uv).rgb;
+    	}
+    	frag_color = color;
+    }
+    `
+
+	/*
+
+	    _____            _
+	   |  __ \          | |
+	   | |  | |   ___   | |__    _   _    __ _
+	   | |  | |  / _ \  | '_ \  | | | |  / _` |
+	   | |__| | |  __/  | |_) | | |_| | | (_| |
+	   |_____/   \___|  |_.__/   \__,_|  \__, |
+	                                      __/ |
+	                                     |___/
+	*/
+
+	debugShaderV = `#version 330
+    precision highp float;
+
+    const int MAX_BONES=32;
+
+    uniform mat4 MVP_MATRIX;
+    uniform mat4 M_MATRIX;
+    uniform mat4 BONES[MAX_BONES];
+    uniform float HAS_BONES;
+    in vec3 VERTEX_POSITION;
+    in vec3 VERTEX_NORMAL;
+    in vec3 VERTEX_TANGENT;
+    in vec2 VERTEX_UV_0;
+    in vec4 VERTEX_BONE_IDS;
+    in vec4 VERTEX_BONE_WEIGHTS;
+
+    out vec3 vs_normal_model;
+    out vec3 vs_tangent;
+    out vec2 vs_tex0_uv;
+
+    ` + calcSkinnedData + `
+
+    void main()
+    {
+    	skinnedData skinned;
+    	if (HAS_BONES > 0.0) {
+    		skinned = calculateSkinnedData();
+    	} else {
+    		skinned.position = vec4(VERTEX_POSITION, 1.0);
+    		skinned.normal = VERTEX_NORMAL;
+    		skinned.tangent = VERTEX_TANGENT;
+    	}
+
+    	mat3 vs_normal_mat = transpose(inverse(mat3(M_MATRIX)));
+
+    	vs_normal_model = vs_normal_mat * skinned.normal;
+    	vs_tangent = mat3(M_MATRIX) * skinned.tangent;
+    	vs_tex0_uv = VERTEX_UV_0;
+
+    	gl_Position = MVP_MATRIX * skinned.position;
+    }
+    `
+
+	debugUnlitShaderF = `#version 330
+    precision highp float;
+
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_DIFFUSE;
+    uniform float MATERIAL_TEX_DIFFUSE_VALID;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	vec4 color = MATERIAL_DIFFUSE;
+    	if (MATERIAL_TEX_DIFFUSE_VALID > 0.0) {
+    		color *= texture(MATERIAL_TEX_DIFFUSE, vs_tex0_uv);
+    	}
+    	frag_color = color;
+    }
+    `
+
+	debugNormalsShaderF = `#version 330
+    precision highp float;
+
+    in vec3 vs_normal_model;
+    out vec4 frag_color;
+
+    void main (void) {
+    	/* map the world space normal from [-1,1] to [0,1] */
+    	frag_color = vec4(normalize(vs_normal_model) * 0.5 + 0.5, 1.0);
+    }
+    `
+
+	debugTangentsShaderF = `#version 330
+    precision highp float;
+
+    in vec3 vs_tangent;
+    out vec4 frag_color;
+
+    void main (void) {
+    	/* map the world space tangent from [-1,1] to [0,1] */
+    	frag_color = vec4(normalize(vs_tangent) * 0.5 + 0.5, 1.0);
+    }
+    `
+
+	debugUVCheckerShaderF = `#version 330
+    precision highp float;
+
+    const float CHECKER_COUNT = 8.0;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	/* alternate light and dark squares tinted by the uv so that
+    	   stretching, seams and flipped islands are easy to spot */
+    	vec2 cell = floor(vs_tex0_uv * CHECKER_COUNT);
+    	float checker = mod(cell.x + cell.y, 2.0);
+    	vec3 tint = vec3(fract(vs_tex0_uv), 0.5);
+    	frag_color = vec4(mix(tint * 0.35, tint, checker), 1.0);
+    }
+    `
+
+	debugOverdrawShaderF = `#version 330
+    precision highp float;
+
+    out vec4 frag_color;
+
+    void main (void) {
+    	/* meant to be drawn with additive blending and no depth test so
+    	   that the color builds up with every fragment drawn to a pixel */
+    	frag_color = vec4(0.1, 0.04, 0.02, 1.0);
+    }
+    `
+	// crowdShaderV is the vertex shader for crowds that skins the vertices
+	// with bone matrices fetched from a baked animation texture. Each row of
+	// the texture is one frame with four texels per bone for the columns of
+	// the matrix. INSTANCE_ANIMATION holds the first frame row of the clip,
+	// the number of frames in the clip and the current frame.
+	crowdShaderV = `#version 330
+    precision highp float;
+
+    const int MAX_LIGHTS=4;
+
+    uniform mat4 MVP_MATRIX;
+    uniform vec4 MATERIAL_ATLAS_REGION;
+    uniform mat4 MATERIAL_UV_TRANSFORM;
+    uniform mat4 M_MATRIX;
+    uniform mat4 V_MATRIX;
+    uniform vec3 CAMERA_WORLD_POSITION;
+    uniform mat4 SHADOW_MATRIX[MAX_LIGHTS];
+    uniform sampler2D CROWD_ANIMATION;
+    in vec3 VERTEX_POSITION;
+    in vec3 VERTEX_NORMAL;
+    in vec3 VERTEX_TANGENT;
+    in vec2 VERTEX_UV_0;
+    in vec4 VERTEX_BONE_IDS;
+    in vec4 VERTEX_BONE_WEIGHTS;
+    in mat4 INSTANCE_MODEL;
+    in vec4 INSTANCE_ANIMATION;
+
+    out vec3 vs_normal_model;
+    out vec3 vs_position_model;
+    out vec3 vs_position_view;
+    out vec3 vs_tangent;
+    out vec2 vs_tex0_uv;
+    out vec3 vs_camera_world;
+    out vec4 vs_shadow_coord[4];
+
+    mat4 fetchBone(int bone, int frame) {
+    	int x = bone * 4;
+    	return mat4(
+    		texelFetch(CROWD_ANIMATION, ivec2(x, frame), 0),
+    		texelFetch(CROWD_ANIMATION, ivec2(x+1, frame), 0),
+    		texelFetch(CROWD_ANIMATION, ivec2(x+2, frame), 0),
+    		texelFetch(CROWD_ANIMATION, ivec2(x+3, frame), 0));
+    }
+
+    mat4 crowdBone(float boneID) {
+    	int bone = int(boneID);
+    	int first = int(INSTANCE_ANIMATION.x);
+    	int count = max(int(INSTANCE_ANIMATION.y), 1);
+    	float frame = floor(INSTANCE_ANIMATION.z);
+    	float blend = INSTANCE_ANIMATION.z - frame;
+    	int frame0 = int(frame) % count;
+    	int frame1 = (frame0 + 1) % count;
+    	return fetchBone(bone, first + frame0) * (1.0 - blend) + fetchBone(bone, first + frame1) * blend;
+    }
+
+    void main()
+    {
+    	mat4 skin =  crowdBone(VERTEX_BONE_IDS.x) * VERTEX_BONE_WEIGHTS.x;
+    	skin += crowdBone(VERTEX_BONE_IDS.y) * VERTEX_BONE_WEIGHTS.y;
+    	skin += crowdBone(VERTEX_BONE_IDS.z) * VERTEX_BONE_WEIGHTS.z;
+    	skin += crowdBone(VERTEX_BONE_IDS.w) * VERTEX_BONE_WEIGHTS.w;
+
+    	vec4 position = skin * vec4(VERTEX_POSITION, 1.0);
+    	position.w = 1.0;
+    	vec3 normal = (skin * vec4(VERTEX_NORMAL, 0.0)).xyz;
+    	vec3 tangent = (skin * vec4(VERTEX_TANGENT, 0.0)).xyz;
+
+    	mat4 model = M_MATRIX * INSTANCE_MODEL;
+    	mat3 vs_normal_mat = transpose(inverse(mat3(model)));
+
+    	vs_normal_model = vs_normal_mat * normal;
+    	vs_position_model = vec3(model * position);
+    	vs_position_view = vec3(V_MATRIX * model * position);
+    	vs_camera_world = CAMERA_WORLD_POSITION;
+    	vs_tangent = mat3(model) * tangent;
+    	vs_tex0_uv = MATERIAL_ATLAS_REGION.xy + (MATERIAL_UV_TRANSFORM * vec4(VERTEX_UV_0, 0.0, 1.0)).xy * MATERIAL_ATLAS_REGION.zw;
+
+    	/* handle the shadow coordinates unrolled since for loop indexing can be problematic */
+    	vs_shadow_coord[0] = (SHADOW_MATRIX[0] * model) * position;
+    	vs_shadow_coord[1] = (SHADOW_MATRIX[1] * model) * position;
+    	vs_shadow_coord[2] = (SHADOW_MATRIX[2] * model) * position;
+    	vs_shadow_coord[3] = (SHADOW_MATRIX[3] * model) * position;
+
+    	gl_Position = MVP_MATRIX * INSTANCE_MODEL * position;
+    }
+    `
+	// gridShaderV is the vertex shader for the infinite ground grid. It is
+	// drawn with a quad covering the screen and unprojects each corner to
+	// the near and far planes so the fragment shader can find where the view
+	// ray hits the ground.
+	gridShaderV = `#version 330
+    precision highp float;
+
+    uniform mat4 GRID_INV_VIEW_PROJ;
+    in vec3 VERTEX_POSITION;
+
+    out vec3 vs_near;
+    out vec3 vs_far;
+
+    vec3 unproject(vec2 xy, float z) {
+    	vec4 p = GRID_INV_VIEW_PROJ * vec4(xy, z, 1.0);
+    	return p.xyz / p.w;
+    }
+
+    void main()
+    {
+    	vs_near = unproject(VERTEX_POSITION.xy, -1.0);
+    	vs_far = unproject(VERTEX_POSITION.xy, 1.0);
+    	gl_Position = vec4(VERTEX_POSITION.xy, 0.0, 1.0);
+    }
+    `
+
+	// gridShaderF is the fragment shader for the infinite ground grid. The
+	// lines are antialiased with screen space derivatives and fade out with
+	// distance from the camera and once the cells get smaller than a pixel.
+	gridShaderF = `#version 330
+    precision highp float;
+
+    uniform mat4 GRID_VIEW_PROJ;
+    uniform vec3 CAMERA_WORLD_POSITION;
+    uniform float GRID_HEIGHT;
+    uniform float GRID_CELL_SIZE;
+    uniform float GRID_MAJOR_EVERY;
+    uniform float GRID_FADE_DISTANCE;
+    uniform vec4 GRID_COLOR;
+    uniform vec4 GRID_MAJOR_COLOR;
+    uniform vec4 GRID_AXIS_X_COLOR;
+    uniform vec4 GRID_AXIS_Z_COLOR;
+
+    in vec3 vs_near;
+    in vec3 vs_far;
+
+    out vec4 frag_color;
+
+    /* returns the coverage of the lines of a grid with the cell size */
+    float gridLines(vec2 coord, float cell) {
+    	vec2 g = coord / cell;
+    	vec2 width = fwidth(g);
+    	vec2 lines = abs(fract(g - 0.5) - 0.5) / width;
+    	float coverage = 1.0 - min(min(lines.x, lines.y), 1.0);
+
+    	/* fade the lines out before the cells shrink under a pixel and alias */
+    	return coverage * (1.0 - smoothstep(0.25, 0.5, max(width.x, width.y)));
+    }
+
+    /* returns the coverage of a line along an axis at 0 */
+    float axisLine(float coord) {
+    	return 1.0 - min(abs(coord) / fwidth(coord), 1.0);
+    }
+
+    void main (void) {
+    	/* find where the view ray crosses the ground plane */
+    	float t = (GRID_HEIGHT - vs_near.y) / (vs_far.y - vs_near.y);
+    	if (t <= 0.0) {
+    		discard;
+    	}
+    	vec3 position = vs_near + t * (vs_far - vs_near);
+
+    	/* write the depth of the plane so the scene hides the grid */
+    	vec4 clip = GRID_VIEW_PROJ * vec4(position, 1.0);
+    	gl_FragDepth = clamp((clip.z / clip.w) * 0.5 + 0.5, 0.0, 1.0);
+
+    	vec2 coord = position.xz;
+    	float minor = gridLines(coord, GRID_CELL_SIZE);
+    	float major = gridLines(coord, GRID_CELL_SIZE * GRID_MAJOR_EVERY);
+    	vec4 color = vec4(GRID_COLOR.rgb, GRID_COLOR.a * minor);
+    	color = mix(color, GRID_MAJOR_COLOR, major);
+
+    	/* the X axis runs along z = 0 and the Z axis along x = 0 */
+    	color = mix(color, GRID_AXIS_X_COLOR, axisLine(coord.y));
+    	color = mix(color, GRID_AXIS_Z_COLOR, axisLine(coord.x));
+
+    	float distance = length(position.xz - CAMERA_WORLD_POSITION.xz);
+    	color.a *= 1.0 - smoothstep(GRID_FADE_DISTANCE * 0.5, GRID_FADE_DISTANCE, distance);
+    	if (color.a <= 0.0) {
+    		discard;
+    	}
+    	frag_color = color;
+    }
+    `
+
+	// skyShaderV is the vertex shader for the sky dome. Only the rotation
+	// of the view is in SKY_VIEW_PROJ and the dome is pushed out to just
+	// inside of the far plane so it is behind everything else.
+	skyShaderV = `#version 330
+    precision highp float;
+
+    uniform mat4 SKY_VIEW_PROJ;
+    in vec3 VERTEX_POSITION;
+
+    out vec3 vs_direction;
+
+    void main()
+    {
+    	vs_direction = VERTEX_POSITION;
+    	vec4 clip = SKY_VIEW_PROJ * vec4(VERTEX_POSITION, 1.0);
+    	gl_Position = vec4(clip.xy, clip.w * 0.99999, clip.w);
+    }
+    `
+
+	// skyShaderF is the fragment shader for the sky dome. It evaluates the
+	// Perez distribution of the Preetham sky model for the luminance and
+	// chromaticity of each direction, converts that to RGB and adds the sun
+	// disc, the ground below the horizon and the night color.
+	skyShaderF = `#version 330
+    precision highp float;
+
+    uniform vec3 SKY_SUN_DIRECTION;
+    uniform vec3 SKY_PEREZ_A;
+    uniform vec3 SKY_PEREZ_B;
+    uniform vec3 SKY_PEREZ_C;
+    uniform vec3 SKY_PEREZ_D;
+    uniform vec3 SKY_PEREZ_E;
+    uniform vec3 SKY_ZENITH;
+    uniform float SKY_INTENSITY;
+    uniform vec3 SKY_SUN_COLOR;
+    uniform float SKY_SUN_SIZE;
+    uniform vec3 SKY_GROUND_COLOR;
+    uniform vec3 SKY_NIGHT_COLOR;
+    uniform float EXPOSURE;
+
+    in vec3 vs_direction;
+    out vec4 frag_color;
+
+    vec3 perez(float cosTheta, float gamma, float cosGamma) {
+    	return (1.0 + SKY_PEREZ_A * exp(SKY_PEREZ_B / cosTheta)) *
+    		(1.0 + SKY_PEREZ_C * exp(SKY_PEREZ_D * gamma) + SKY_PEREZ_E * cosGamma * cosGamma);
+    }
+
+    void main()
+    {
+    	vec3 dir = normalize(vs_direction);
+    	float cosTheta = max(dir.y, 0.001);
+    	float cosGamma = clamp(dot(dir, SKY_SUN_DIRECTION), -1.0, 1.0);
+    	float gamma = acos(cosGamma);
+
+    	/* luminance and chromaticity to CIE XYZ and then to linear sRGB */
+    	vec3 Yxy = SKY_ZENITH * perez(cosTheta, gamma, cosGamma);
+    	vec3 XYZ = vec3(Yxy.y / Yxy.z * Yxy.x, Yxy.x, (1.0 - Yxy.y - Yxy.z) / Yxy.z * Yxy.x);
+    	mat3 xyzToRGB = mat3(
+    		3.2406, -0.9689, 0.0557,
+    		-1.5372, 1.8758, -0.2040,
+    		-0.4986, 0.0415, 1.0570);
+    	vec3 sky = max(xyzToRGB * XYZ, vec3(0.0)) * SKY_INTENSITY;
+
+    	float disc = smoothstep(cos(SKY_SUN_SIZE * 1.2), cos(SKY_SUN_SIZE), cosGamma);
+    	sky += SKY_SUN_COLOR * disc;
+
+    	/* blend into the ground just below the horizon */
+    	vec3 color = mix(SKY_GROUND_COLOR, sky, smoothstep(-0.02, 0.0, dir.y));
+    	color += SKY_NIGHT_COLOR;
+    	frag_color = vec4(color * EXPOSURE, 1.0);
+    }
+    `
+
+	// skyboxShaderF is the fragment shader for the skybox. It is used with
+	// skyShaderV and blends between the day and night cube maps.
+	skyboxShaderF = `#version 330
+    precision highp float;
+
+    uniform samplerCube SKYBOX_DAY;
+    uniform samplerCube SKYBOX_NIGHT;
+    uniform float SKYBOX_BLEND;
+    uniform float SKYBOX_INTENSITY;
+    uniform float EXPOSURE;
+
+    in vec3 vs_direction;
+
+    out vec4 frag_color;
+
+    void main()
+    {
+    	vec3 dir = normalize(vs_direction);
+    	vec3 day = texture(SKYBOX_DAY, dir).rgb;
+    	vec3 night = texture(SKYBOX_NIGHT, dir).rgb;
+    	vec3 color = mix(night, day, SKYBOX_BLEND) * SKYBOX_INTENSITY;
+    	frag_color = vec4(color * EXPOSURE, 1.0);
+    }
+    `
 )
 
 // CreateBasicShader creates a new shader object using the built
@@ -529,12 +1489,60 @@ func CreateBasicShader() (*fizzle.RenderShader, error) {
 	return fizzle.LoadShaderProgram(basicShaderV, basicShaderF, nil)
 }
 
+// CreateClusteredShader creates a new shader object using the built in
+// basic shader code lit by the LightClusters of the renderer instead of
+// the ActiveLights, for scenes with many lights.
+func CreateClusteredShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(basicShaderV, clusteredShaderF, nil)
+}
+
 // CreateBasicSkinnedShader creates a new shader object using the built
 // in basic shader code with GPU skinning for bones.
 func CreateBasicSkinnedShader() (*fizzle.RenderShader, error) {
 	return fizzle.LoadShaderProgram(basicSkinnedShaderV, basicSkinnedShaderF, nil)
 }
 
+// CreatePBRShader creates a new shader object using the built in physically
+// based shader code. It lights the material with the metallic-roughness
+// model using Roughness and Metalness scaled by ORMTex, and EmissiveColor
+// scaled by EmissiveTex, and uses the environment maps of the material for
+// image based lighting if they are set. The specular intensity, cookies and
+// IES profiles of the lights are not used.
+func CreatePBRShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(basicShaderV, pbrShaderF, nil)
+}
+
+// CreatePBRSkinnedShader creates a new shader object using the built in
+// physically based shader code with GPU skinning for bones.
+func CreatePBRSkinnedShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(basicSkinnedShaderV, pbrShaderF, nil)
+}
+
+// CreateCrowdShader creates a new shader object using the built in basic
+// skinned shader code with bones read from a baked animation texture for
+// each instance. It is meant to be used by the crowd package.
+func CreateCrowdShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(crowdShaderV, basicSkinnedShaderF, nil)
+}
+
+// CreateGridShader creates a new shader object using the built in infinite
+// ground grid shader code. It is meant to be used by Grid.
+func CreateGridShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(gridShaderV, gridShaderF, nil)
+}
+
+// CreateSkyShader creates a new shader object using the built in
+// procedural sky shader code. It is meant to be used by Sky.
+func CreateSkyShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(skyShaderV, skyShaderF, nil)
+}
+
+// CreateSkyboxShader creates a new shader object using the built in
+// skybox shader code. It is meant to be used by Skybox.
+func CreateSkyboxShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(skyShaderV, skyboxShaderF, nil)
+}
+
 // CreateColorShader creates a new shader object using the built
 // in flat color shader code that uses Material.DiffuseColor.
 func CreateColorShader() (*fizzle.RenderShader, error) {
@@ -560,3 +1568,46 @@ func CreateShadowmapGeneratorShader() (*fizzle.RenderShader, error) {
 func CreateDiffuseUnlitShader() (*fizzle.RenderShader, error) {
 	return fizzle.LoadShaderProgram(diffuseUnlitShaderV, diffuseUnlitShaderF, nil)
 }
+
+// CreateLightmappedShader creates a new shader object that draws the
+// diffuse color and texture lit only by the baked lightmap texture, which
+// is sampled with the second set of texture coordinates.
+func CreateLightmappedShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(lightmappedShaderV, lightmappedShaderF, nil)
+}
+
+// CreateDebugUnlitShader creates a new shader object that draws the
+// diffuse color and texture without any lighting. It supports skinned
+// meshes if bones are present.
+func CreateDebugUnlitShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(debugShaderV, debugUnlitShaderF, nil)
+}
+
+// CreateDebugNormalsShader creates a new shader object that draws the
+// world space vertex normals as colors. It supports skinned meshes if
+// bones are present.
+func CreateDebugNormalsShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(debugShaderV, debugNormalsShaderF, nil)
+}
+
+// CreateDebugTangentsShader creates a new shader object that draws the
+// world space vertex tangents as colors. It supports skinned meshes if
+// bones are present.
+func CreateDebugTangentsShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(debugShaderV, debugTangentsShaderF, nil)
+}
+
+// CreateDebugUVCheckerShader creates a new shader object that draws a
+// checker pattern using the first set of texture coordinates. It supports
+// skinned meshes if bones are present.
+func CreateDebugUVCheckerShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(debugShaderV, debugUVCheckerShaderF, nil)
+}
+
+// CreateDebugOverdrawShader creates a new shader object that draws a dim
+// constant color. When drawn with additive blending and the depth test
+// disabled, brighter areas show where pixels are drawn many times.
+// It supports skinned meshes if bones are present.
+func CreateDebugOverdrawShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(debugShaderV, debugOverdrawShaderF, nil)
+}
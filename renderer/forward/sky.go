@@ -0,0 +1,301 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// Sky is a procedural sky dome lit by the sun with the Preetham analytic
+// model of atmospheric scattering. The dome is drawn around the camera at
+// the far plane, so it should be drawn after the opaque geometry like a
+// skybox. When the Light is set, the sky points it away from the sun and
+// gives it the color of the sunlight that makes it through the atmosphere,
+// so the scene is lit to match the sky.
+type Sky struct {
+	// Renderable is the dome drawn around the camera.
+	Renderable *fizzle.Renderable
+
+	// Shader is the sky shader created with CreateSkyShader.
+	Shader *fizzle.RenderShader
+
+	// SunDirection is the direction toward the sun in world space. It
+	// can be set from a time of day with SetTimeOfDay.
+	SunDirection mgl.Vec3
+
+	// Turbidity is how hazy the atmosphere is, from about 2 for a very
+	// clear sky to 10 for a hazy one.
+	Turbidity float32
+
+	// Intensity scales the brightness of the sky.
+	Intensity float32
+
+	// SunSize is the angular radius of the sun disc in radians.
+	SunSize float32
+
+	// SunIntensity is the brightness of the sun disc.
+	SunIntensity float32
+
+	// GroundColor is the color below the horizon at full daylight.
+	GroundColor mgl.Vec3
+
+	// NightColor is added to the whole dome so that the sky isn't black
+	// after the sun sets.
+	NightColor mgl.Vec3
+
+	// Latitude, in degrees, and DayOfYear are used by SetTimeOfDay to
+	// find where the sun is.
+	Latitude  float32
+	DayOfYear int
+
+	// Light is the directional light driven by the sun, or nil to leave
+	// the lights alone. Its direction, diffuse color and diffuse intensity
+	// are set each time the sky is updated.
+	Light *Light
+
+	// LightIntensity is the diffuse intensity given to the Light when the
+	// sun is overhead in a clear sky.
+	LightIntensity float32
+
+	viewProj  mgl.Mat4
+	perez     [5]mgl.Vec3
+	zenith    mgl.Vec3
+	daylight  float32
+	sunColor  mgl.Vec3
+	sunRadius float32
+}
+
+// NewSky creates a new Sky with a clear atmosphere and the sun high in the
+// sky, using a new sky shader.
+func NewSky() (*Sky, error) {
+	shader, err := CreateSkyShader()
+	if err != nil {
+		return nil, err
+	}
+
+	sky := new(Sky)
+	sky.Shader = shader
+	sky.Renderable = fizzle.CreateSphere(1.0, 16, 32)
+	sky.Renderable.RenderOrder = fizzle.RenderOrderSkybox
+	sky.SunDirection = mgl.Vec3{0.3, 0.8, -0.5}.Normalize()
+	sky.Turbidity = 2.5
+	sky.Intensity = 0.1
+	sky.SunSize = 0.01
+	sky.SunIntensity = 20.0
+	sky.GroundColor = mgl.Vec3{0.1, 0.09, 0.08}
+	sky.NightColor = mgl.Vec3{0.002, 0.003, 0.006}
+	sky.Latitude = 40.0
+	sky.DayOfYear = 172
+	sky.LightIntensity = 0.9
+	sky.Update()
+	return sky, nil
+}
+
+// Destroy releases the dome and the shader.
+func (sky *Sky) Destroy() {
+	sky.Renderable.Destroy()
+	sky.Shader.Destroy()
+}
+
+// SetTimeOfDay moves the sun to where it is at the hour of the day, in the
+// range of [0, 24), for the Latitude and DayOfYear of the sky.
+func (sky *Sky) SetTimeOfDay(hours float32) {
+	sky.SunDirection = GetSunDirection(hours, sky.Latitude, sky.DayOfYear)
+}
+
+// GetSunDirection returns the direction toward the sun at the hour of the
+// day for the latitude, in degrees, and the day of the year. North is down
+// the -Z axis, east is down the +X axis and +Y is up.
+func GetSunDirection(hours, latitude float32, dayOfYear int) mgl.Vec3 {
+	lat := float64(mgl.DegToRad(latitude))
+	declination := -23.44 * math.Pi / 180.0 * math.Cos(2.0*math.Pi/365.0*float64(dayOfYear+10))
+	hourAngle := float64(hours-12.0) * 15.0 * math.Pi / 180.0
+
+	up := math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	east := -math.Cos(declination) * math.Sin(hourAngle)
+	north := math.Cos(lat)*math.Sin(declination) - math.Sin(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	return mgl.Vec3{float32(east), float32(up), float32(-north)}.Normalize()
+}
+
+// GetDaylight returns how much daylight there is as of the last update,
+// going from 0 at night to 1 once the sun is a little above the horizon.
+func (sky *Sky) GetDaylight() float32 {
+	return sky.daylight
+}
+
+// GetSunColor returns the color of the sunlight that makes it through the
+// atmosphere as of the last update, which reddens and fades out as the sun
+// sets.
+func (sky *Sky) GetSunColor() mgl.Vec3 {
+	return sky.sunColor
+}
+
+// Update recalculates the scattering for the sun direction and turbidity
+// and then updates the Light if it is set. Draw calls this.
+func (sky *Sky) Update() {
+	sunDir := sky.SunDirection.Normalize()
+	turbidity := sky.Turbidity
+	elevation := sunDir[1]
+	sky.daylight = smoothStep32(-0.1, 0.05, elevation)
+
+	// the model only holds up with the sun above the horizon, so hold the
+	// scattering there and let the daylight fade it out
+	thetaS := math.Acos(float64(mgl.Clamp(elevation, 0.0, 1.0)))
+	if thetaS > math.Pi/2.0-0.02 {
+		thetaS = math.Pi/2.0 - 0.02
+	}
+	sky.perez = getPerezCoefficients(turbidity)
+	sky.zenith = getZenith(thetaS, turbidity)
+
+	// fold in the distribution at the zenith so the shader only scales it
+	for i := 0; i < 3; i++ {
+		p := sky.perez
+		f0 := (1.0 + p[0][i]*float32(math.Exp(float64(p[1][i])))) *
+			(1.0 + p[2][i]*float32(math.Exp(float64(p[3][i])*thetaS)) + p[4][i]*float32(math.Pow(math.Cos(thetaS), 2.0)))
+		if f0 != 0.0 {
+			sky.zenith[i] /= f0
+		}
+	}
+
+	sky.sunColor = getSunTransmittance(elevation, turbidity).Mul(smoothStep32(-0.02, 0.02, elevation))
+	sky.sunRadius = sky.SunSize
+
+	if sky.Light != nil {
+		dir := sunDir.Mul(-1.0)
+		sky.Light.Direction = dir
+		if sky.Light.ShadowMap != nil {
+			sky.Light.ShadowMap.Direction = dir
+		}
+		sky.Light.DiffuseColor = mgl.Vec4{sky.sunColor[0], sky.sunColor[1], sky.sunColor[2], 1.0}
+		sky.Light.DiffuseIntensity = sky.LightIntensity * sky.daylight
+	}
+}
+
+// Draw updates the sky and draws the dome around the camera behind
+// everything already in the depth buffer without writing to it. Face
+// culling is enabled afterwards.
+func (sky *Sky) Draw(fr *ForwardRenderer, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	sky.Update()
+
+	// only the rotation of the view is used so the dome stays around the camera
+	rotation := view.Mat3().Mat4()
+	sky.viewProj = perspective.Mul4(rotation)
+
+	gfx := fr.GetGraphics()
+	gfx.Disable(graphics.CULL_FACE)
+	gfx.DepthMask(false)
+	fr.DrawRenderableWithShader(sky.Renderable, sky.Shader, sky.bindSky, perspective, view, camera)
+	gfx.DepthMask(true)
+	gfx.Enable(graphics.CULL_FACE)
+}
+
+// bindSky is the RenderBinder that sets the sky uniforms.
+func (sky *Sky) bindSky(rend renderer.Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+	gfx := rend.GetGraphics()
+
+	shaderViewProj := shader.GetUniformLocation("SKY_VIEW_PROJ")
+	if shaderViewProj >= 0 {
+		gfx.UniformMatrix4fv(shaderViewProj, 1, false, sky.viewProj)
+	}
+	shaderSunDir := shader.GetUniformLocation("SKY_SUN_DIRECTION")
+	if shaderSunDir >= 0 {
+		sunDir := sky.SunDirection.Normalize()
+		gfx.Uniform3f(shaderSunDir, sunDir[0], sunDir[1], sunDir[2])
+	}
+	perezNames := [5]string{"SKY_PEREZ_A", "SKY_PEREZ_B", "SKY_PEREZ_C", "SKY_PEREZ_D", "SKY_PEREZ_E"}
+	for i, name := range perezNames {
+		shaderPerez := shader.GetUniformLocation(name)
+		if shaderPerez >= 0 {
+			gfx.Uniform3f(shaderPerez, sky.perez[i][0], sky.perez[i][1], sky.perez[i][2])
+		}
+	}
+	shaderZenith := shader.GetUniformLocation("SKY_ZENITH")
+	if shaderZenith >= 0 {
+		gfx.Uniform3f(shaderZenith, sky.zenith[0], sky.zenith[1], sky.zenith[2])
+	}
+	shaderIntensity := shader.GetUniformLocation("SKY_INTENSITY")
+	if shaderIntensity >= 0 {
+		gfx.Uniform1f(shaderIntensity, sky.Intensity*sky.daylight)
+	}
+	shaderSunColor := shader.GetUniformLocation("SKY_SUN_COLOR")
+	if shaderSunColor >= 0 {
+		c := sky.sunColor.Mul(sky.SunIntensity)
+		gfx.Uniform3f(shaderSunColor, c[0], c[1], c[2])
+	}
+	shaderSunSize := shader.GetUniformLocation("SKY_SUN_SIZE")
+	if shaderSunSize >= 0 {
+		gfx.Uniform1f(shaderSunSize, sky.sunRadius)
+	}
+	shaderGroundColor := shader.GetUniformLocation("SKY_GROUND_COLOR")
+	if shaderGroundColor >= 0 {
+		c := sky.GroundColor.Mul(sky.daylight)
+		gfx.Uniform3f(shaderGroundColor, c[0], c[1], c[2])
+	}
+	shaderNightColor := shader.GetUniformLocation("SKY_NIGHT_COLOR")
+	if shaderNightColor >= 0 {
+		gfx.Uniform3f(shaderNightColor, sky.NightColor[0], sky.NightColor[1], sky.NightColor[2])
+	}
+}
+
+// getPerezCoefficients returns the A through E coefficients of the Perez
+// sky distribution for the luminance and the x and y chromaticities.
+func getPerezCoefficients(t float32) [5]mgl.Vec3 {
+	return [5]mgl.Vec3{
+		{0.1787*t - 1.4630, -0.0193*t - 0.2592, -0.0167*t - 0.2608},
+		{-0.3554*t + 0.4275, -0.0665*t + 0.0008, -0.0950*t + 0.0092},
+		{-0.0227*t + 5.3251, -0.0004*t + 0.2125, -0.0079*t + 0.2102},
+		{0.1206*t - 2.5771, -0.0641*t - 0.8989, -0.0441*t - 1.6537},
+		{-0.0670*t + 0.3703, -0.0033*t + 0.0452, -0.0109*t + 0.0529},
+	}
+}
+
+// getZenith returns the luminance and x and y chromaticities of the sky at
+// the zenith for the angle of the sun from the zenith.
+func getZenith(thetaS float64, turbidity float32) mgl.Vec3 {
+	t := float64(turbidity)
+	chi := (4.0/9.0 - t/120.0) * (math.Pi - 2.0*thetaS)
+	luminance := (4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192
+
+	th, th2, th3 := thetaS, thetaS*thetaS, thetaS*thetaS*thetaS
+	x := t*t*(0.00166*th3-0.00375*th2+0.00209*th) +
+		t*(-0.02903*th3+0.06377*th2-0.03202*th+0.00394) +
+		(0.11693*th3 - 0.21196*th2 + 0.06052*th + 0.25886)
+	y := t*t*(0.00275*th3-0.00610*th2+0.00317*th) +
+		t*(-0.04214*th3+0.08970*th2-0.04153*th+0.00516) +
+		(0.15346*th3 - 0.26756*th2 + 0.06670*th + 0.26688)
+	return mgl.Vec3{float32(luminance), float32(x), float32(y)}
+}
+
+// getSunTransmittance returns the fraction of red, green and blue sunlight
+// that makes it through the Rayleigh and aerosol scattering of the
+// atmosphere for the elevation of the sun, as the sine of its angle above
+// the horizon.
+func getSunTransmittance(elevation, turbidity float32) mgl.Vec3 {
+	// the optical mass of the air the light goes through, growing toward
+	// the horizon where the sun shines through the most atmosphere
+	zenithDeg := 90.0 - float64(mgl.RadToDeg(float32(math.Asin(float64(mgl.Clamp(elevation, 0.0, 1.0))))))
+	mass := 1.0 / (math.Cos(zenithDeg*math.Pi/180.0) + 0.15*math.Pow(93.885-zenithDeg, -1.253))
+
+	// wavelengths in micrometers for red, green and blue
+	wavelengths := [3]float64{0.68, 0.55, 0.44}
+	beta := 0.04608*float64(turbidity) - 0.04586
+	var result mgl.Vec3
+	for i, l := range wavelengths {
+		rayleigh := 0.008735 * math.Pow(l, -4.08)
+		aerosol := beta * math.Pow(l, -1.3)
+		result[i] = float32(math.Exp(-mass * (rayleigh + aerosol)))
+	}
+	return result
+}
+
+// smoothStep32 is the GLSL smoothstep function.
+func smoothStep32(edge0, edge1, x float32) float32 {
+	t := mgl.Clamp((x-edge0)/(edge1-edge0), 0.0, 1.0)
+	return t * t * (3.0 - 2.0*t)
+}
@@ -0,0 +1,122 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// Skybox draws a cube map around the camera, blending between a day and a
+// night cube map so that a TimeOfDay can fade from one to the other. Like
+// the Sky, it should be drawn after the opaque geometry.
+type Skybox struct {
+	// Renderable is the cube drawn around the camera.
+	Renderable *fizzle.Renderable
+
+	// Shader is the skybox shader created with CreateSkyboxShader.
+	Shader *fizzle.RenderShader
+
+	// DayTex and NightTex are the cube maps, such as ones loaded with
+	// TextureManager.LoadCubeMap. NightTex can be 0 to only use DayTex.
+	DayTex   graphics.Texture
+	NightTex graphics.Texture
+
+	// Blend is how much of DayTex is shown, from 0 for only NightTex to 1
+	// for only DayTex.
+	Blend float32
+
+	// Intensity scales the brightness of the cube maps.
+	Intensity float32
+
+	viewProj mgl.Mat4
+}
+
+// NewSkybox creates a new Skybox showing the day cube map, using a new
+// skybox shader. The cube maps are not destroyed with the skybox.
+func NewSkybox(dayTex, nightTex graphics.Texture) (*Skybox, error) {
+	shader, err := CreateSkyboxShader()
+	if err != nil {
+		return nil, err
+	}
+
+	skybox := new(Skybox)
+	skybox.Shader = shader
+	skybox.Renderable = fizzle.CreateCube(-1, -1, -1, 1, 1, 1)
+	skybox.Renderable.RenderOrder = fizzle.RenderOrderSkybox
+	skybox.DayTex = dayTex
+	skybox.NightTex = nightTex
+	skybox.Blend = 1.0
+	skybox.Intensity = 1.0
+	return skybox, nil
+}
+
+// Destroy releases the cube and the shader.
+func (skybox *Skybox) Destroy() {
+	skybox.Renderable.Destroy()
+	skybox.Shader.Destroy()
+}
+
+// Draw draws the cube maps around the camera behind everything already in
+// the depth buffer without writing to it. Face culling is enabled
+// afterwards.
+func (skybox *Skybox) Draw(fr *ForwardRenderer, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	// only the rotation of the view is used so the cube stays around the camera
+	rotation := view.Mat3().Mat4()
+	skybox.viewProj = perspective.Mul4(rotation)
+
+	gfx := fr.GetGraphics()
+	gfx.Disable(graphics.CULL_FACE)
+	gfx.DepthMask(false)
+	fr.DrawRenderableWithShader(skybox.Renderable, skybox.Shader, skybox.bindSkybox, perspective, view, camera)
+	gfx.DepthMask(true)
+	gfx.Enable(graphics.CULL_FACE)
+}
+
+// bindSkybox is the RenderBinder that sets the skybox uniforms.
+func (skybox *Skybox) bindSkybox(rend renderer.Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+	gfx := rend.GetGraphics()
+
+	shaderViewProj := shader.GetUniformLocation("SKY_VIEW_PROJ")
+	if shaderViewProj >= 0 {
+		gfx.UniformMatrix4fv(shaderViewProj, 1, false, skybox.viewProj)
+	}
+
+	// without a night cube map the day one is faded out instead
+	nightTex := skybox.NightTex
+	if nightTex == 0 {
+		nightTex = skybox.DayTex
+	}
+	bindSkyboxCubeMap(gfx, shader, "SKYBOX_DAY", skybox.DayTex, texturesBound)
+	bindSkyboxCubeMap(gfx, shader, "SKYBOX_NIGHT", nightTex, texturesBound)
+
+	shaderBlend := shader.GetUniformLocation("SKYBOX_BLEND")
+	if shaderBlend >= 0 {
+		gfx.Uniform1f(shaderBlend, mgl.Clamp(skybox.Blend, 0.0, 1.0))
+	}
+	shaderIntensity := shader.GetUniformLocation("SKYBOX_INTENSITY")
+	if shaderIntensity >= 0 {
+		intensity := skybox.Intensity
+		if skybox.NightTex == 0 {
+			intensity *= mgl.Clamp(skybox.Blend, 0.0, 1.0)
+		}
+		gfx.Uniform1f(shaderIntensity, intensity)
+	}
+}
+
+// bindSkyboxCubeMap binds the cube map to the next texture unit if the
+// shader has the uniform for it.
+func bindSkyboxCubeMap(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(uniformName)
+	if shaderTex < 0 {
+		return
+	}
+
+	gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, tex)
+	gfx.Uniform1i(shaderTex, *texturesBound)
+	*texturesBound++
+}
@@ -0,0 +1,240 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package forward
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// TimeOfDay runs a day and night cycle. Each Update advances the clock and
+// then moves the sun and moon lights across the sky, warms the sunlight
+// toward the horizon, blends the ambient light between day and night,
+// moves the sun of the Sky and blends the Skybox from night to day. OnDawn
+// and OnDusk are called as the sun rises and sets so that gameplay can
+// react to it.
+//
+// When a Sky is used with a TimeOfDay, leave Sky.Light unset and use
+// SunLight instead so that only one of them drives the light.
+type TimeOfDay struct {
+	// Hours is the time of day in the range of [0, 24).
+	Hours float32
+
+	// DayLength is the number of seconds a full day takes. A value of 0
+	// stops the clock.
+	DayLength float32
+
+	// Paused stops the clock without changing DayLength.
+	Paused bool
+
+	// Latitude, in degrees, and DayOfYear place the sun with
+	// GetSunDirection. DayOfYear advances when the clock passes midnight.
+	Latitude  float32
+	DayOfYear int
+
+	// SunLight is the directional light moved with the sun, or nil.
+	SunLight *Light
+
+	// MoonLight is the directional light moved with the moon, or nil. The
+	// moon is kept opposite of the sun.
+	MoonLight *Light
+
+	// Sky has its sun moved along with the clock if it is set.
+	Sky *Sky
+
+	// Skybox has its Blend set to the daylight if it is set, fading from
+	// its night cube map to its day cube map as the sun rises.
+	Skybox *Skybox
+
+	// NoonTemperature and HorizonTemperature are the color temperatures in
+	// Kelvin of the sunlight when the sun is high and when it is at the
+	// horizon; the color is blended between them as the sun moves.
+	NoonTemperature    float32
+	HorizonTemperature float32
+
+	// MoonTemperature is the color temperature in Kelvin of the moonlight.
+	MoonTemperature float32
+
+	// SunIntensity and MoonIntensity are the diffuse intensities of the
+	// lights when they are fully up.
+	SunIntensity  float32
+	MoonIntensity float32
+
+	// DayAmbient and NightAmbient are the ambient intensities given to the
+	// lights at day and at night.
+	DayAmbient   float32
+	NightAmbient float32
+
+	// SunGradient, if set, is sampled at Hours for the color of the
+	// sunlight instead of using the color temperatures. The alpha of the
+	// colors is ignored.
+	SunGradient *fizzle.Gradient
+
+	// AmbientGradient, if set, is sampled at Hours for the ambient
+	// intensity instead of blending DayAmbient and NightAmbient. The
+	// brightest of the red, green and blue channels is used so that gray
+	// keys can be used.
+	AmbientGradient *fizzle.Gradient
+
+	// OnDawn is called when the sun rises above the horizon and OnDusk is
+	// called when it sets below it.
+	OnDawn func(tod *TimeOfDay)
+	OnDusk func(tod *TimeOfDay)
+
+	sunDirection mgl.Vec3
+	daylight     float32
+	isDay        bool
+	updated      bool
+}
+
+// NewTimeOfDay creates a new TimeOfDay starting at the hour specified with
+// a day that takes dayLength seconds.
+func NewTimeOfDay(hours, dayLength float32) *TimeOfDay {
+	tod := new(TimeOfDay)
+	tod.Hours = hours
+	tod.DayLength = dayLength
+	tod.Latitude = 40.0
+	tod.DayOfYear = 172
+	tod.NoonTemperature = 6000.0
+	tod.HorizonTemperature = 2500.0
+	tod.MoonTemperature = 4100.0
+	tod.SunIntensity = 0.9
+	tod.MoonIntensity = 0.1
+	tod.DayAmbient = 0.3
+	tod.NightAmbient = 0.05
+	return tod
+}
+
+// GetSunDirection returns the direction toward the sun as of the last
+// update.
+func (tod *TimeOfDay) GetSunDirection() mgl.Vec3 {
+	return tod.sunDirection
+}
+
+// GetDaylight returns how much daylight there is as of the last update,
+// going from 0 at night to 1 once the sun is a little above the horizon.
+// Skybox uses it to blend between its day and night cube maps.
+func (tod *TimeOfDay) GetDaylight() float32 {
+	return tod.daylight
+}
+
+// IsDay returns true if the sun was above the horizon at the last update.
+func (tod *TimeOfDay) IsDay() bool {
+	return tod.isDay
+}
+
+// Update advances the clock by frameDelta seconds and then updates the
+// lights and the sky for the new time.
+func (tod *TimeOfDay) Update(frameDelta float32) {
+	if !tod.Paused && tod.DayLength > 0.0 {
+		tod.Hours += frameDelta / tod.DayLength * 24.0
+		for tod.Hours >= 24.0 {
+			tod.Hours -= 24.0
+			tod.DayOfYear = tod.DayOfYear%365 + 1
+		}
+		for tod.Hours < 0.0 {
+			tod.Hours += 24.0
+		}
+	}
+
+	tod.sunDirection = GetSunDirection(tod.Hours, tod.Latitude, tod.DayOfYear)
+	elevation := tod.sunDirection[1]
+	tod.daylight = smoothStep32(-0.1, 0.05, elevation)
+
+	if tod.Sky != nil {
+		tod.Sky.SunDirection = tod.sunDirection
+	}
+	if tod.Skybox != nil {
+		tod.Skybox.Blend = tod.daylight
+	}
+
+	ambient := tod.NightAmbient + (tod.DayAmbient-tod.NightAmbient)*tod.daylight
+	if tod.AmbientGradient != nil && len(tod.AmbientGradient.Keys) > 0 {
+		c := tod.AmbientGradient.Sample(tod.Hours)
+		ambient = c[0]
+		if c[1] > ambient {
+			ambient = c[1]
+		}
+		if c[2] > ambient {
+			ambient = c[2]
+		}
+	}
+	if tod.SunLight != nil {
+		var color mgl.Vec3
+		if tod.SunGradient != nil && len(tod.SunGradient.Keys) > 0 {
+			color = tod.SunGradient.Sample(tod.Hours).Vec3()
+		} else {
+			t := smoothStep32(0.0, 0.5, elevation)
+			kelvin := tod.HorizonTemperature + (tod.NoonTemperature-tod.HorizonTemperature)*t
+			color = ColorTemperature(kelvin)
+		}
+		tod.SunLight.Direction = tod.sunDirection.Mul(-1.0)
+		if tod.SunLight.ShadowMap != nil {
+			tod.SunLight.ShadowMap.Direction = tod.SunLight.Direction
+		}
+		tod.SunLight.DiffuseColor = mgl.Vec4{color[0], color[1], color[2], 1.0}
+		tod.SunLight.DiffuseIntensity = tod.SunIntensity * smoothStep32(-0.02, 0.05, elevation)
+		tod.SunLight.AmbientIntensity = ambient
+	}
+	if tod.MoonLight != nil {
+		color := ColorTemperature(tod.MoonTemperature)
+		tod.MoonLight.Direction = tod.sunDirection
+		if tod.MoonLight.ShadowMap != nil {
+			tod.MoonLight.ShadowMap.Direction = tod.MoonLight.Direction
+		}
+		tod.MoonLight.DiffuseColor = mgl.Vec4{color[0], color[1], color[2], 1.0}
+		tod.MoonLight.DiffuseIntensity = tod.MoonIntensity * smoothStep32(-0.02, 0.05, -elevation)
+		if tod.SunLight == nil {
+			tod.MoonLight.AmbientIntensity = ambient
+		} else {
+			tod.MoonLight.AmbientIntensity = 0.0
+		}
+	}
+
+	// the first update only finds out if it is day or night
+	isDay := elevation > 0.0
+	if tod.updated && isDay != tod.isDay {
+		tod.isDay = isDay
+		if isDay && tod.OnDawn != nil {
+			tod.OnDawn(tod)
+		} else if !isDay && tod.OnDusk != nil {
+			tod.OnDusk(tod)
+		}
+	}
+	tod.isDay = isDay
+	tod.updated = true
+}
+
+// ColorTemperature returns the approximate color of a black body at the
+// temperature in Kelvin, normalized so that the brightest channel is 1.
+// Useful temperatures range from about 1000 for candle light to 12000 for
+// a blue sky.
+func ColorTemperature(kelvin float32) mgl.Vec3 {
+	t := float64(mgl.Clamp(kelvin, 1000.0, 40000.0)) / 100.0
+
+	var r, g, b float64
+	if t <= 66.0 {
+		r = 255.0
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60.0, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60.0, -0.0755148492)
+	}
+
+	if t >= 66.0 {
+		b = 255.0
+	} else if t <= 19.0 {
+		b = 0.0
+	} else {
+		b = 138.5177312231*math.Log(t-10.0) - 305.0447927307
+	}
+
+	return mgl.Vec3{
+		mgl.Clamp(float32(r/255.0), 0.0, 1.0),
+		mgl.Clamp(float32(g/255.0), 0.0, 1.0),
+		mgl.Clamp(float32(b/255.0), 0.0, 1.0),
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package renderer
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// ParticleSystem is a particle system that can be drawn by
+// DrawParticleSystem, such as a particles.System. The particles package
+// imports this one, so it is referred to with an interface.
+type ParticleSystem interface {
+	// GetLocation returns the world location used to sort the particle
+	// system with the transparent Renderables of a RenderQueue.
+	GetLocation() mgl.Vec3
+
+	// DrawExposed draws the particles with their colors scaled by exposure.
+	DrawExposed(projection mgl.Mat4, view mgl.Mat4, exposure float32)
+}
+
+// ExposureScaler is implemented by renderers that scale the color of what
+// they draw for exposure compensation, like the forward renderer.
+type ExposureScaler interface {
+	// GetExposureScale returns the amount the color of the material gets
+	// scaled by, using the renderer's exposure if the material is nil.
+	GetExposureScale(m *fizzle.Material) float32
+}
+
+// DrawParticleSystem draws the particle system so that it composites with
+// the scene drawn by the renderer. The particles are depth tested against
+// the scene without writing to the depth buffer, so they don't cut into each
+// other or the transparent objects drawn after them, and their colors are
+// scaled by the renderer's exposure so that tonemapping treats them like the
+// rest of the scene.
+func DrawParticleSystem(renderer Renderer, ps ParticleSystem, perspective mgl.Mat4, view mgl.Mat4) {
+	exposure := float32(1.0)
+	if scaler, okay := renderer.(ExposureScaler); okay {
+		exposure = scaler.GetExposureScale(nil)
+	}
+
+	gfx := renderer.GetGraphics()
+	gfx.Enable(graphics.DEPTH_TEST)
+	gfx.DepthMask(false)
+	ps.DrawExposed(perspective, view, exposure)
+	gfx.DepthMask(true)
+}
@@ -0,0 +1,141 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package renderer
+
+import (
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+// queueItem is a Renderable waiting to be drawn by a RenderQueue.
+type queueItem struct {
+	renderable *fizzle.Renderable
+	shader     *fizzle.RenderShader
+	binder     RenderBinder
+	particles  ParticleSystem
+	order      int32
+	key        float32
+}
+
+// RenderQueue collects Renderables for a frame and draws them sorted by
+// their RenderOrder and then by their distance to the camera, so that the
+// drawing order doesn't depend on the order they were added in. Renderables
+// before RenderOrderTransparent are drawn front to back to cut down on
+// overdraw and the rest are drawn back to front so blending works. The
+// SortBias of a Renderable is added to its sort key so it can be pushed
+// later in its group. Particle systems are sorted with the transparent
+// Renderables by their location.
+//
+// Children are drawn along with their parent, so only the Renderables at
+// the top of a hierarchy should be added.
+type RenderQueue struct {
+	items []queueItem
+}
+
+// NewRenderQueue creates a new empty RenderQueue.
+func NewRenderQueue() *RenderQueue {
+	q := new(RenderQueue)
+	q.items = []queueItem{}
+	return q
+}
+
+// Add puts the Renderable in the queue to be drawn with its own shader.
+func (q *RenderQueue) Add(r *fizzle.Renderable, binder RenderBinder) {
+	q.items = append(q.items, queueItem{renderable: r, binder: binder})
+}
+
+// AddWithShader puts the Renderable in the queue to be drawn with the
+// shader specified instead of the one in its material.
+func (q *RenderQueue) AddWithShader(r *fizzle.Renderable, shader *fizzle.RenderShader, binder RenderBinder) {
+	q.items = append(q.items, queueItem{renderable: r, shader: shader, binder: binder})
+}
+
+// AddParticleSystem puts the particle system in the queue to be drawn with
+// DrawParticleSystem along with the transparent Renderables.
+func (q *RenderQueue) AddParticleSystem(ps ParticleSystem) {
+	q.items = append(q.items, queueItem{particles: ps})
+}
+
+// Len returns the number of Renderables in the queue.
+func (q *RenderQueue) Len() int {
+	return len(q.items)
+}
+
+// Clear removes all of the Renderables from the queue.
+func (q *RenderQueue) Clear() {
+	for i := range q.items {
+		q.items[i] = queueItem{}
+	}
+	q.items = q.items[:0]
+}
+
+// Sort orders the queue for a camera at the position specified.
+func (q *RenderQueue) Sort(cameraPosition mgl.Vec3) {
+	for i := range q.items {
+		item := &q.items[i]
+		var location mgl.Vec3
+		var bias float32
+		if item.particles != nil {
+			item.order = fizzle.RenderOrderTransparent
+			location = item.particles.GetLocation()
+		} else {
+			item.order = item.renderable.RenderOrder
+			location = item.renderable.GetTransformMat4().Col(3).Vec3()
+			bias = item.renderable.SortBias
+		}
+
+		distance := location.Sub(cameraPosition).Len()
+		if item.order >= fizzle.RenderOrderTransparent {
+			distance = -distance
+		}
+		item.key = distance + bias
+	}
+	sort.Stable(queueItemsByOrder(q.items))
+}
+
+// Draw sorts the queue for the camera, draws everything in it with the
+// renderer and then clears it. If the camera is nil, the position is taken
+// from the view matrix.
+func (q *RenderQueue) Draw(renderer Renderer, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) {
+	var cameraPosition mgl.Vec3
+	if camera != nil {
+		cameraPosition = camera.GetPosition()
+	} else {
+		cameraPosition = view.Inv().Col(3).Vec3()
+	}
+	q.Sort(cameraPosition)
+
+	for _, item := range q.items {
+		if item.particles != nil {
+			DrawParticleSystem(renderer, item.particles, perspective, view)
+		} else if item.shader != nil {
+			renderer.DrawRenderableWithShader(item.renderable, item.shader, item.binder, perspective, view, camera)
+		} else {
+			renderer.DrawRenderable(item.renderable, item.binder, perspective, view, camera)
+		}
+	}
+	q.Clear()
+}
+
+// queueItemsByOrder implements sort.Interface to sort queue items by their
+// RenderOrder and then by their sort key.
+type queueItemsByOrder []queueItem
+
+func (s queueItemsByOrder) Len() int {
+	return len(s)
+}
+
+func (s queueItemsByOrder) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s queueItemsByOrder) Less(i, j int) bool {
+	orderI, orderJ := s[i].order, s[j].order
+	if orderI != orderJ {
+		return orderI < orderJ
+	}
+	return s[i].key < s[j].key
+}
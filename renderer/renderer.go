@@ -76,7 +76,43 @@ type RenderBinder func(renderer Renderer, r *fizzle.Renderable, shader *fizzle.R
 // renderer implementations.
 func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader,
 	binders []RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera, mode uint32) {
+	gfx := bindRenderable(renderer, r, shader, binders, perspective, view, camera)
+
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	stats.DrawCalls++
+	if mode != graphics.LINES {
+		stats.Triangles += int(r.FaceCount)
+		gfx.DrawElements(graphics.Enum(mode), int32(r.FaceCount*3), graphics.UNSIGNED_INT, gfx.PtrOffset(0))
+	} else {
+		gfx.DrawElements(graphics.Enum(mode), int32(r.FaceCount*2), graphics.UNSIGNED_INT, gfx.PtrOffset(0))
+	}
+	gfx.BindVertexArray(0)
+}
+
+// BindAndDrawInstanced binds the shader variables like BindAndDraw and then
+// draws instanceCount instances of the Renderable's triangles with one draw
+// call. The per-instance vertex attributes need to be set up by one of the
+// binders with VertexAttribDivisor.
+func BindAndDrawInstanced(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader,
+	binders []RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera, instanceCount int32) {
+	gfx := bindRenderable(renderer, r, shader, binders, perspective, view, camera)
+
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	stats.DrawCalls++
+	stats.Triangles += int(r.FaceCount) * int(instanceCount)
+	gfx.DrawElementsInstanced(graphics.TRIANGLES, int32(r.FaceCount*3), graphics.UNSIGNED_INT, gfx.PtrOffset(0), instanceCount)
+	gfx.BindVertexArray(0)
+}
+
+// bindRenderable uses the shader program, binds the vertex array of the
+// Renderable and sets all of the shader variables that are found before
+// calling the binders. The graphics provider of the renderer is returned.
+func bindRenderable(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderShader,
+	binders []RenderBinder, perspective mgl.Mat4, view mgl.Mat4, camera fizzle.Camera) graphics.GraphicsProvider {
 	gfx := renderer.GetGraphics()
+	if rec, ok := gfx.(*CommandRecorder); ok {
+		rec.setDrawSource(shader, r)
+	}
 	gfx.UseProgram(shader.Prog)
 	gfx.BindVertexArray(r.Core.Vao)
 
@@ -120,57 +156,67 @@ func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderS
 		gfx.Uniform1f(shaderShiny, r.Material.Shininess)
 	}
 
-	shaderMatTexDiff := shader.GetUniformLocation("MATERIAL_TEX_DIFFUSE")
-	if shaderMatTexDiff >= 0 && r.Material != nil {
-		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(texturesBound)))
-		gfx.BindTexture(graphics.TEXTURE_2D, r.Material.DiffuseTex)
-		gfx.Uniform1i(shaderMatTexDiff, texturesBound)
-		texturesBound++
+	shaderEmissive := shader.GetUniformLocation("MATERIAL_EMISSIVE")
+	if shaderEmissive >= 0 && r.Material != nil {
+		gfx.Uniform4f(shaderEmissive, r.Material.EmissiveColor[0], r.Material.EmissiveColor[1], r.Material.EmissiveColor[2], r.Material.EmissiveColor[3])
+	}
 
-		shaderMatTexDiffValid := shader.GetUniformLocation("MATERIAL_TEX_DIFFUSE_VALID")
-		if shaderMatTexDiffValid >= 0 {
-			if r.Material.DiffuseTex > 0 {
-				gfx.Uniform1f(shaderMatTexDiffValid, 1.0)
-			} else {
-				gfx.Uniform1f(shaderMatTexDiffValid, 0.0)
-			}
-		}
+	shaderRoughness := shader.GetUniformLocation("MATERIAL_ROUGHNESS")
+	if shaderRoughness >= 0 && r.Material != nil {
+		gfx.Uniform1f(shaderRoughness, r.Material.Roughness)
 	}
 
-	shaderMatTexNorms := shader.GetUniformLocation("MATERIAL_TEX_NORMALS")
-	if shaderMatTexNorms >= 0 && r.Material != nil {
-		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(texturesBound)))
-		gfx.BindTexture(graphics.TEXTURE_2D, r.Material.NormalsTex)
-		gfx.Uniform1i(shaderMatTexNorms, texturesBound)
-		texturesBound++
+	shaderMetalness := shader.GetUniformLocation("MATERIAL_METALNESS")
+	if shaderMetalness >= 0 && r.Material != nil {
+		gfx.Uniform1f(shaderMetalness, r.Material.Metalness)
+	}
 
-		shaderMatTexNormsValid := shader.GetUniformLocation("MATERIAL_TEX_NORMALS_VALID")
-		if shaderMatTexNormsValid >= 0 {
-			if r.Material.NormalsTex > 0 {
-				gfx.Uniform1f(shaderMatTexNormsValid, 1.0)
-			} else {
-				gfx.Uniform1f(shaderMatTexNormsValid, 0.0)
-			}
+	shaderORMOcclusion := shader.GetUniformLocation("MATERIAL_ORM_OCCLUSION")
+	if shaderORMOcclusion >= 0 && r.Material != nil {
+		if r.Material.ORMOcclusion {
+			gfx.Uniform1f(shaderORMOcclusion, 1.0)
+		} else {
+			gfx.Uniform1f(shaderORMOcclusion, 0.0)
 		}
 	}
 
-	shaderMatTexSpec := shader.GetUniformLocation("MATERIAL_TEX_SPECULAR")
-	if shaderMatTexSpec >= 0 && r.Material != nil {
-		gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(texturesBound)))
-		gfx.BindTexture(graphics.TEXTURE_2D, r.Material.SpecularTex)
-		gfx.Uniform1i(shaderMatTexSpec, texturesBound)
-		texturesBound++
+	shaderUVTransform := shader.GetUniformLocation("MATERIAL_UV_TRANSFORM")
+	if shaderUVTransform >= 0 {
+		if r.Material != nil {
+			uvTransform := r.Material.GetUVTransform()
+			gfx.UniformMatrix4fv(shaderUVTransform, 1, false, uvTransform)
+		} else {
+			identity := mgl.Ident4()
+			gfx.UniformMatrix4fv(shaderUVTransform, 1, false, identity)
+		}
+	}
 
-		shaderMatTexSpecValid := shader.GetUniformLocation("MATERIAL_TEX_SPECULAR_VALID")
-		if shaderMatTexSpecValid >= 0 {
-			if r.Material.SpecularTex > 0 {
-				gfx.Uniform1f(shaderMatTexSpecValid, 1.0)
-			} else {
-				gfx.Uniform1f(shaderMatTexSpecValid, 0.0)
-			}
+	// the atlas region is passed as the uv offset and scale
+	shaderAtlasRegion := shader.GetUniformLocation("MATERIAL_ATLAS_REGION")
+	if shaderAtlasRegion >= 0 {
+		if r.Material != nil && r.Material.AtlasRegion != nil {
+			uv := r.Material.AtlasRegion.UV
+			gfx.Uniform4f(shaderAtlasRegion, uv[0], uv[1], uv[2]-uv[0], uv[3]-uv[1])
+		} else {
+			gfx.Uniform4f(shaderAtlasRegion, 0.0, 0.0, 1.0, 1.0)
 		}
 	}
 
+	if r.Material != nil {
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_DIFFUSE", "MATERIAL_TEX_DIFFUSE_VALID", r.Material.DiffuseTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_NORMALS", "MATERIAL_TEX_NORMALS_VALID", r.Material.NormalsTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_SPECULAR", "MATERIAL_TEX_SPECULAR_VALID", r.Material.SpecularTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_EMISSIVE", "MATERIAL_TEX_EMISSIVE_VALID", r.Material.EmissiveTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_AO", "MATERIAL_TEX_AO_VALID", r.Material.AOTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_ROUGHNESS", "MATERIAL_TEX_ROUGHNESS_VALID", r.Material.RoughnessTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_METALNESS", "MATERIAL_TEX_METALNESS_VALID", r.Material.MetalnessTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_ORM", "MATERIAL_TEX_ORM_VALID", r.Material.ORMTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_LIGHTMAP", "MATERIAL_TEX_LIGHTMAP_VALID", r.Material.LightmapTex, &texturesBound)
+		bindMaterialCubeMap(gfx, shader, "MATERIAL_TEX_ENVIRONMENT", "MATERIAL_TEX_ENVIRONMENT_VALID", r.Material.EnvironmentTex, &texturesBound)
+		bindMaterialCubeMap(gfx, shader, "MATERIAL_TEX_IRRADIANCE", "MATERIAL_TEX_IRRADIANCE_VALID", r.Material.IrradianceTex, &texturesBound)
+		bindMaterialTexture(gfx, shader, "MATERIAL_TEX_BRDF_LUT", "MATERIAL_TEX_BRDF_LUT_VALID", r.Material.BRDFLutTex, &texturesBound)
+	}
+
 	for texI := 0; texI < fizzle.MaxCustomTextures; texI++ {
 		shaderTex := shader.GetUniformLocation(shaderTexUniformNames[texI])
 		if shaderTex >= 0 {
@@ -190,9 +236,14 @@ func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderS
 		}
 	}
 
+	// cores skinned on the CPU already have the pose in their vertices
+	// so the shader must not apply the bones again
+	r.Core.UpdateCPUSkinning()
+	gpuSkinned := r.Core.Skeleton != nil && len(r.Core.Skeleton.Bones) > 0 && r.Core.CPUSkin == nil
+
 	shaderHasBones := shader.GetUniformLocation("HAS_BONES")
 	if shaderHasBones >= 0 {
-		if r.Core.Skeleton != nil && len(r.Core.Skeleton.Bones) > 0 {
+		if gpuSkinned {
 			gfx.Uniform1f(shaderHasBones, 1.0)
 		} else {
 			gfx.Uniform1f(shaderHasBones, 0.0)
@@ -200,7 +251,7 @@ func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderS
 		}
 	}
 	shaderBones := shader.GetUniformLocation("BONES")
-	if shaderBones >= 0 && r.Core.Skeleton != nil && len(r.Core.Skeleton.Bones) > 0 {
+	if shaderBones >= 0 && gpuSkinned {
 		gfx.UniformMatrix4fv(shaderBones, int32(len(r.Core.Skeleton.Bones)), false, r.Core.Skeleton.PoseTransforms)
 	}
 
@@ -226,6 +277,13 @@ func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderS
 		gfx.VertexAttribPointer(uint32(shaderVertUv), 2, graphics.FLOAT, false, r.Core.VBOStride, gfx.PtrOffset(r.Core.UvVBOOffset))
 	}
 
+	shaderVertUv2 := shader.GetAttribLocation("VERTEX_UV_1")
+	if shaderVertUv2 >= 0 && r.Core.Uv2VBO > 0 {
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.Uv2VBO)
+		gfx.EnableVertexAttribArray(uint32(shaderVertUv2))
+		gfx.VertexAttribPointer(uint32(shaderVertUv2), 2, graphics.FLOAT, false, r.Core.VBOStride, gfx.PtrOffset(r.Core.Uv2VBOOffset))
+	}
+
 	shaderNormal := shader.GetAttribLocation("VERTEX_NORMAL")
 	if shaderNormal >= 0 {
 		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.NormsVBO)
@@ -265,11 +323,52 @@ func BindAndDraw(renderer Renderer, r *fizzle.Renderable, shader *fizzle.RenderS
 		}
 	}
 
-	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
-	if mode != graphics.LINES {
-		gfx.DrawElements(graphics.Enum(mode), int32(r.FaceCount*3), graphics.UNSIGNED_INT, gfx.PtrOffset(0))
-	} else {
-		gfx.DrawElements(graphics.Enum(mode), int32(r.FaceCount*2), graphics.UNSIGNED_INT, gfx.PtrOffset(0))
+	return gfx
+}
+
+// bindMaterialTexture binds a material texture to the next texture unit if
+// the shader has the uniform for it and sets the uniform flagging if the
+// texture is valid.
+func bindMaterialTexture(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, validName string, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(uniformName)
+	if shaderTex < 0 {
+		return
+	}
+
+	gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.Uniform1i(shaderTex, *texturesBound)
+	*texturesBound++
+
+	shaderTexValid := shader.GetUniformLocation(validName)
+	if shaderTexValid >= 0 {
+		if tex > 0 {
+			gfx.Uniform1f(shaderTexValid, 1.0)
+		} else {
+			gfx.Uniform1f(shaderTexValid, 0.0)
+		}
+	}
+}
+
+// bindMaterialCubeMap binds a material cube map texture to the next texture
+// unit in the same way bindMaterialTexture does for 2D textures.
+func bindMaterialCubeMap(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, validName string, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(uniformName)
+	if shaderTex < 0 {
+		return
+	}
+
+	gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+	gfx.BindTexture(graphics.TEXTURE_CUBE_MAP, tex)
+	gfx.Uniform1i(shaderTex, *texturesBound)
+	*texturesBound++
+
+	shaderTexValid := shader.GetUniformLocation(validName)
+	if shaderTexValid >= 0 {
+		if tex > 0 {
+			gfx.Uniform1f(shaderTexValid, 1.0)
+		} else {
+			gfx.Uniform1f(shaderTexValid, 0.0)
+		}
 	}
-	gfx.BindVertexArray(0)
 }
@@ -0,0 +1,65 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package renderer
+
+import (
+	"time"
+)
+
+// DefaultResizeDelay is how long the window size has to stay the same before
+// a ResizeDebouncer reports it.
+const DefaultResizeDelay = 200 * time.Millisecond
+
+// ResizeDebouncer collects window resize events, which come in for every
+// step of a window being dragged to a new size, and only reports the new
+// size once it has stopped changing. This keeps renderers from recreating
+// their framebuffers over and over during the drag.
+//
+// Call Resize from the window's size callback and call Update once per frame,
+// passing the size it reports to ChangeResolution.
+type ResizeDebouncer struct {
+	// Delay is how long the size has to stay the same before Update reports it.
+	Delay time.Duration
+
+	width      int32
+	height     int32
+	lastResize time.Time
+	pending    bool
+}
+
+// NewResizeDebouncer creates a new resize debouncer using DefaultResizeDelay.
+func NewResizeDebouncer() *ResizeDebouncer {
+	d := new(ResizeDebouncer)
+	d.Delay = DefaultResizeDelay
+	return d
+}
+
+// Resize records the latest size of the window. Sizes that are the same as
+// the last one recorded do not restart the delay, so it is safe to call this
+// every frame with a polled window size.
+func (d *ResizeDebouncer) Resize(width, height int32) {
+	if width == d.width && height == d.height {
+		return
+	}
+
+	d.width = width
+	d.height = height
+	d.lastResize = time.Now()
+	d.pending = true
+}
+
+// Update returns the new size and true once the size has stayed the same
+// for Delay. Sizes with a zero dimension, such as when the window gets
+// minimized, are never reported.
+func (d *ResizeDebouncer) Update() (int32, int32, bool) {
+	if !d.pending || time.Since(d.lastResize) < d.Delay {
+		return 0, 0, false
+	}
+
+	d.pending = false
+	if d.width <= 0 || d.height <= 0 {
+		return 0, 0, false
+	}
+	return d.width, d.height, true
+}
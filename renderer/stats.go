@@ -0,0 +1,28 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package renderer
+
+// FrameStats counts the work the renderers submit to the GPU.
+type FrameStats struct {
+	// DrawCalls is the number of draw calls made by BindAndDraw.
+	DrawCalls int
+
+	// Triangles is the number of triangles drawn; lines are not counted.
+	Triangles int
+}
+
+// stats accumulates the counters for BindAndDraw until they get reset.
+var stats FrameStats
+
+// GetFrameStats returns the counters accumulated since the last call to
+// ResetFrameStats.
+func GetFrameStats() FrameStats {
+	return stats
+}
+
+// ResetFrameStats clears the counters, which is usually done at the start
+// of every frame.
+func ResetFrameStats() {
+	stats = FrameStats{}
+}
@@ -0,0 +1,236 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ResourceManager owns the shaders, textures and renderable cores of an
+// application under names so that they can be shared by name and all get
+// destroyed together at shutdown with a single call to Destroy.
+//
+// Like the TextureManager, resources are reference counted with Retain and
+// Release. Anything that still has references when the manager is destroyed
+// gets reported as a leak since some part of the application thought it was
+// still using it.
+type ResourceManager struct {
+	// Textures is the TextureManager that stores the textures. Textures can
+	// be loaded with it directly and are destroyed along with the rest of
+	// the resources.
+	Textures *TextureManager
+
+	// shaders are the shaders stored by name.
+	shaders map[string]*RenderShader
+
+	// shaderRefs is the number of users of each shader by name.
+	shaderRefs map[string]int
+
+	// cores are the renderable cores stored by name.
+	cores map[string]*RenderableCore
+
+	// coreRefs is the number of users of each renderable core by name.
+	coreRefs map[string]int
+}
+
+// NewResourceManager creates a new ResourceManager object with empty storage
+// and a new TextureManager.
+func NewResourceManager() *ResourceManager {
+	rm := new(ResourceManager)
+	rm.Textures = NewTextureManager()
+	rm.shaders = make(map[string]*RenderShader)
+	rm.shaderRefs = make(map[string]int)
+	rm.cores = make(map[string]*RenderableCore)
+	rm.coreRefs = make(map[string]int)
+	return rm
+}
+
+// Destroy deletes all of the stored shaders, textures and renderable cores
+// from OpenGL and resets the storage. Any resources that still have
+// references are logged as leaks and their descriptions are returned.
+// Renderable cores that were already destroyed elsewhere are skipped.
+func (rm *ResourceManager) Destroy() []string {
+	leaks := rm.GetLeaks()
+	for _, leak := range leaks {
+		Logf(LogWarning, "resources", "Destroying a resource that is still in use: %s.", leak)
+	}
+
+	for _, shader := range rm.shaders {
+		shader.Destroy()
+	}
+	for _, core := range rm.cores {
+		if !core.IsDestroyed {
+			core.DestroyCore()
+		}
+	}
+	rm.Textures.Destroy()
+
+	rm.shaders = make(map[string]*RenderShader)
+	rm.shaderRefs = make(map[string]int)
+	rm.cores = make(map[string]*RenderableCore)
+	rm.coreRefs = make(map[string]int)
+	return leaks
+}
+
+// GetLeaks returns a sorted description of every stored resource that still
+// has references. Calling it just before Destroy shows which resources were
+// never released.
+func (rm *ResourceManager) GetLeaks() []string {
+	var leaks []string
+	for name, count := range rm.shaderRefs {
+		if count > 0 {
+			leaks = append(leaks, fmt.Sprintf("shader %s (%d references)", name, count))
+		}
+	}
+	for name := range rm.Textures.storage {
+		if count := rm.Textures.GetRefCount(name); count > 0 {
+			leaks = append(leaks, fmt.Sprintf("texture %s (%d references)", name, count))
+		}
+	}
+	for name, count := range rm.coreRefs {
+		if count > 0 {
+			leaks = append(leaks, fmt.Sprintf("renderable core %s (%d references)", name, count))
+		}
+	}
+	sort.Strings(leaks)
+	return leaks
+}
+
+// GetResourceCount returns the number of stored shaders, textures and
+// renderable cores.
+func (rm *ResourceManager) GetResourceCount() (int, int, int) {
+	return len(rm.shaders), len(rm.Textures.storage), len(rm.cores)
+}
+
+// AddShader stores a shader that was created elsewhere under the name so
+// that the manager destroys it. A different shader already stored under
+// the name gets destroyed, but its references are kept.
+func (rm *ResourceManager) AddShader(name string, shader *RenderShader) *RenderShader {
+	oldShader, okay := rm.shaders[name]
+	if okay && oldShader != shader {
+		oldShader.Destroy()
+	}
+
+	rm.shaders[name] = shader
+	if _, okay := rm.shaderRefs[name]; !okay {
+		rm.shaderRefs[name] = 0
+	}
+	return shader
+}
+
+// LoadShader compiles and links the shader source with LoadShaderProgram
+// and stores the shader under the name.
+func (rm *ResourceManager) LoadShader(name string, vertShader, fragShader string, prelink PreLinkBinder) (*RenderShader, error) {
+	shader, err := LoadShaderProgram(vertShader, fragShader, prelink)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the shader %s.\n%v", name, err)
+	}
+	return rm.AddShader(name, shader), nil
+}
+
+// LoadShaderFromFiles compiles and links the shader files with
+// LoadShaderProgramFromFiles and stores the shader under the name.
+func (rm *ResourceManager) LoadShaderFromFiles(name string, baseFilename string, prelink PreLinkBinder) (*RenderShader, error) {
+	shader, err := LoadShaderProgramFromFiles(baseFilename, prelink)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the shader %s.\n%v", name, err)
+	}
+	return rm.AddShader(name, shader), nil
+}
+
+// GetShader returns the shader stored under the name and a bool indicating
+// if it was found.
+func (rm *ResourceManager) GetShader(name string) (*RenderShader, bool) {
+	shader, okay := rm.shaders[name]
+	return shader, okay
+}
+
+// RetainShader adds a reference to the shader stored under the name and
+// returns it and a bool indicating if it was found.
+func (rm *ResourceManager) RetainShader(name string) (*RenderShader, bool) {
+	shader, okay := rm.shaders[name]
+	if okay {
+		rm.shaderRefs[name]++
+	}
+	return shader, okay
+}
+
+// ReleaseShader removes a reference to the shader stored under the name.
+// The shader stays loaded until DestroyShader or Destroy is called.
+func (rm *ResourceManager) ReleaseShader(name string) {
+	if rm.shaderRefs[name] > 0 {
+		rm.shaderRefs[name]--
+	}
+}
+
+// DestroyShader deletes the shader stored under the name from OpenGL
+// regardless of how many references it has.
+func (rm *ResourceManager) DestroyShader(name string) {
+	shader, okay := rm.shaders[name]
+	if !okay {
+		return
+	}
+
+	shader.Destroy()
+	delete(rm.shaders, name)
+	delete(rm.shaderRefs, name)
+}
+
+// AddCore stores a renderable core under the name so that the manager
+// destroys it. Renderables made with Clone share the core of the original,
+// so it only needs to be added once. A different core already stored under
+// the name gets destroyed, but its references are kept.
+func (rm *ResourceManager) AddCore(name string, core *RenderableCore) *RenderableCore {
+	oldCore, okay := rm.cores[name]
+	if okay && oldCore != core && !oldCore.IsDestroyed {
+		oldCore.DestroyCore()
+	}
+
+	rm.cores[name] = core
+	if _, okay := rm.coreRefs[name]; !okay {
+		rm.coreRefs[name] = 0
+	}
+	return core
+}
+
+// GetCore returns the renderable core stored under the name and a bool
+// indicating if it was found.
+func (rm *ResourceManager) GetCore(name string) (*RenderableCore, bool) {
+	core, okay := rm.cores[name]
+	return core, okay
+}
+
+// RetainCore adds a reference to the renderable core stored under the name
+// and returns it and a bool indicating if it was found.
+func (rm *ResourceManager) RetainCore(name string) (*RenderableCore, bool) {
+	core, okay := rm.cores[name]
+	if okay {
+		rm.coreRefs[name]++
+	}
+	return core, okay
+}
+
+// ReleaseCore removes a reference to the renderable core stored under the
+// name. The core stays loaded until DestroyCore or Destroy is called.
+func (rm *ResourceManager) ReleaseCore(name string) {
+	if rm.coreRefs[name] > 0 {
+		rm.coreRefs[name]--
+	}
+}
+
+// DestroyCore deletes the renderable core stored under the name from OpenGL
+// regardless of how many references it has.
+func (rm *ResourceManager) DestroyCore(name string) {
+	core, okay := rm.cores[name]
+	if !okay {
+		return
+	}
+
+	if !core.IsDestroyed {
+		core.DestroyCore()
+	}
+	delete(rm.cores, name)
+	delete(rm.coreRefs, name)
+}
@@ -0,0 +1,162 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/gombz"
+)
+
+// AnimationRetargeter maps the animations of a source skeleton onto a
+// target skeleton with different proportions so that one set of animations
+// can drive many character meshes. The bones are matched by name, or by the
+// BoneMap for skeletons that name them differently.
+//
+// Bone rotations are carried over as the change from the source bone's rest
+// pose applied to the target bone's rest pose, so the skeletons should have
+// bones that point the same way. The target bones keep their own lengths
+// and only the RootBone gets moved, by the source's movement scaled to the
+// target's size.
+type AnimationRetargeter struct {
+	// Source is the skeleton the animations were made for.
+	Source *Skeleton
+
+	// Target is the skeleton the animations get retargeted to.
+	Target *Skeleton
+
+	// BoneMap maps the names of target bones to the names of the source
+	// bones that drive them. Target bones that aren't in the map are driven
+	// by the source bone with the same name, if there is one.
+	BoneMap map[string]string
+
+	// RootBone is the name of the target bone, such as the hips, whose
+	// position is animated. It's also used to work out how much bigger the
+	// target is than the source.
+	RootBone string
+}
+
+// NewAnimationRetargeter creates a new retargeter from the source skeleton
+// to the target skeleton that moves the root bone.
+func NewAnimationRetargeter(source, target *Skeleton, rootBone string) *AnimationRetargeter {
+	rt := new(AnimationRetargeter)
+	rt.Source = source
+	rt.Target = target
+	rt.BoneMap = make(map[string]string)
+	rt.RootBone = rootBone
+	return rt
+}
+
+// MapBone sets the source bone that drives the target bone.
+func (rt *AnimationRetargeter) MapBone(targetBone, sourceBone string) {
+	rt.BoneMap[targetBone] = sourceBone
+}
+
+// getSourceBoneIndex returns the index of the source bone that drives the
+// target bone or -1 if there isn't one.
+func (rt *AnimationRetargeter) getSourceBoneIndex(targetBone string) int {
+	if sourceBone, okay := rt.BoneMap[targetBone]; okay {
+		return rt.Source.GetBoneIndex(sourceBone)
+	}
+	return rt.Source.GetBoneIndex(targetBone)
+}
+
+// getScale returns how much bigger the target is than the source based on
+// how far the root bones are from the origin in their rest poses.
+func (rt *AnimationRetargeter) getScale() float32 {
+	ti := rt.Target.GetBoneIndex(rt.RootBone)
+	si := rt.getSourceBoneIndex(rt.RootBone)
+	if ti < 0 || si < 0 {
+		return 1.0
+	}
+
+	targetLen := rt.Target.Bones[ti].Offset.Inv().Col(3).Vec3().Len()
+	sourceLen := rt.Source.Bones[si].Offset.Inv().Col(3).Vec3().Len()
+	if targetLen < ikEpsilon || sourceLen < ikEpsilon {
+		return 1.0
+	}
+	return targetLen / sourceLen
+}
+
+// Retarget creates a new animation for the target skeleton from an
+// animation of the source skeleton. The new animation can be added to the
+// target's Animations. Target bones that aren't driven by a source bone
+// with a channel in the animation keep their rest pose.
+func (rt *AnimationRetargeter) Retarget(animation *gombz.Animation) *gombz.Animation {
+	retargeted := new(gombz.Animation)
+	*retargeted = *animation
+	retargeted.Channels = nil
+
+	scale := rt.getScale()
+	for _, targetBone := range rt.Target.Bones {
+		si := rt.getSourceBoneIndex(targetBone.Name)
+		if si < 0 {
+			continue
+		}
+		sourceBone := rt.Source.Bones[si]
+		var source *gombz.AnimationChannel
+		for ci := range animation.Channels {
+			if animation.Channels[ci].BoneId == sourceBone.Id {
+				source = &animation.Channels[ci]
+				break
+			}
+		}
+		if source == nil {
+			continue
+		}
+
+		sourceRest := BonePoseFromMat4(sourceBone.Transform)
+		targetRest := BonePoseFromMat4(targetBone.Transform)
+
+		var channel gombz.AnimationChannel
+		channel.BoneId = targetBone.Id
+
+		// the rotation is the change from the source rest pose applied to
+		// the target rest pose
+		correction := targetRest.Rotation.Mul(sourceRest.Rotation.Inverse())
+		channel.RotationKeys = make([]gombz.AnimationQuatKey, len(source.RotationKeys))
+		for ki, key := range source.RotationKeys {
+			channel.RotationKeys[ki].Time = key.Time
+			channel.RotationKeys[ki].Key = correction.Mul(key.Key).Normalize()
+		}
+
+		// only the root bone moves and the rest keep their own lengths
+		if targetBone.Name == rt.RootBone {
+			channel.PositionKeys = make([]gombz.AnimationVec3Key, len(source.PositionKeys))
+			for ki, key := range source.PositionKeys {
+				moved := key.Key.Sub(sourceRest.Position).Mul(scale)
+				channel.PositionKeys[ki].Time = key.Time
+				channel.PositionKeys[ki].Key = targetRest.Position.Add(moved)
+			}
+		} else {
+			channel.PositionKeys = []gombz.AnimationVec3Key{{Time: 0.0, Key: targetRest.Position}}
+		}
+		channel.ScaleKeys = []gombz.AnimationVec3Key{{Time: 0.0, Key: targetRest.Scale}}
+
+		retargeted.Channels = append(retargeted.Channels, channel)
+	}
+
+	return retargeted
+}
+
+// BonePoseFromMat4 breaks a bone transform matrix down into a position,
+// rotation and scale. The matrix shouldn't be skewed.
+func BonePoseFromMat4(m mgl.Mat4) BonePose {
+	var pose BonePose
+	pose.Position = m.Col(3).Vec3()
+	pose.Scale = mgl.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+
+	// take the scale out of the matrix to leave the rotation
+	var rotation mgl.Mat4
+	for c := 0; c < 3; c++ {
+		col := m.Col(c)
+		if pose.Scale[c] > 0.0 {
+			col = col.Mul(1.0 / pose.Scale[c])
+		}
+		rotation.SetCol(c, col)
+	}
+	rotation.SetCol(3, mgl.Vec4{0, 0, 0, 1})
+	pose.Rotation = mgl.Mat4ToQuat(rotation).Normalize()
+	pose.Animated = true
+	return pose
+}
@@ -0,0 +1,122 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// GetRootMotion returns how far the root bone moved and turned in the space
+// of the mesh during the last Update when RootMotion is enabled.
+func (a *Animator) GetRootMotion() (mgl.Vec3, mgl.Quat) {
+	return a.rootMotionMove, a.rootMotionTurn
+}
+
+// ApplyRootMotion moves and turns the renderable by the root motion of the
+// last Update, taking its scale and local rotation into account.
+func (a *Animator) ApplyRootMotion(r *Renderable) {
+	move := mgl.Vec3{
+		a.rootMotionMove[0] * r.Scale[0],
+		a.rootMotionMove[1] * r.Scale[1],
+		a.rootMotionMove[2] * r.Scale[2],
+	}
+	r.Location = r.Location.Add(r.LocalRotation.Rotate(move))
+	r.LocalRotation = r.LocalRotation.Mul(a.rootMotionTurn).Normalize()
+}
+
+// getRootMotionBone returns the index of the bone root motion is taken from.
+func (a *Animator) getRootMotionBone() int {
+	if a.RootMotionBone != "" {
+		return a.Skeleton.GetBoneIndex(a.RootMotionBone)
+	}
+	for bi, bone := range a.Skeleton.Bones {
+		if bone.Parent < 0 {
+			return bi
+		}
+	}
+	return -1
+}
+
+// updateRootMotion works out the root motion of the playing animations
+// since the last update, blending the motion of the previous state while
+// blending between states.
+func (a *Animator) updateRootMotion(lastTime, lastPreviousTime float32) {
+	a.rootMotionMove = mgl.Vec3{}
+	a.rootMotionTurn = mgl.QuatIdent()
+	boneIndex := a.getRootMotionBone()
+	if boneIndex < 0 {
+		return
+	}
+
+	a.rootMotionMove, a.rootMotionTurn = a.getPlaybackRootMotion(&a.current, boneIndex, lastTime)
+	if a.blending {
+		move, turn := a.getPlaybackRootMotion(&a.previous, boneIndex, lastPreviousTime)
+		factor := a.blendTime / a.blendDuration
+		a.rootMotionMove = move.Add(a.rootMotionMove.Sub(move).Mul(factor))
+		a.rootMotionTurn = mgl.QuatSlerp(turn, a.rootMotionTurn, factor)
+	}
+}
+
+// getPlaybackRootMotion returns the movement and turning of the root bone
+// in the space of the mesh for a playback from lastTime to its current time.
+func (a *Animator) getPlaybackRootMotion(p *animatorPlayback, boneIndex int, lastTime float32) (mgl.Vec3, mgl.Quat) {
+	sample := func(time float32) BonePose {
+		return a.Skeleton.SampleBonePose(p.animation, boneIndex, p.ticksAt(time))
+	}
+
+	from := sample(lastTime)
+	to := sample(p.time)
+	if !from.Animated {
+		return mgl.Vec3{}, mgl.QuatIdent()
+	}
+
+	var move mgl.Vec3
+	var turn mgl.Quat
+	if p.state.Loop && p.time < lastTime {
+		// the animation looped, so add the motion to the end and from the start
+		start := sample(0.0)
+		end := sample(p.length)
+		move = end.Position.Sub(from.Position).Add(to.Position.Sub(start.Position))
+		turn = to.Rotation.Mul(start.Rotation.Inverse()).Mul(end.Rotation.Mul(from.Rotation.Inverse()))
+	} else {
+		move = to.Position.Sub(from.Position)
+		turn = to.Rotation.Mul(from.Rotation.Inverse())
+	}
+
+	// bring the motion into the space of the mesh
+	transform := p.animation.Transform
+	move = a.maskRootMotion(mgl.TransformNormal(move, transform))
+	if !a.RootMotionRotation {
+		return move, mgl.QuatIdent()
+	}
+	rotation := mgl.Mat4ToQuat(transform)
+	turn = rotation.Mul(turn).Mul(rotation.Inverse()).Normalize()
+	return move, turn
+}
+
+// maskRootMotion keeps only the movement along the RootMotionAxes.
+func (a *Animator) maskRootMotion(move mgl.Vec3) mgl.Vec3 {
+	return mgl.Vec3{
+		move[0] * a.RootMotionAxes[0],
+		move[1] * a.RootMotionAxes[1],
+		move[2] * a.RootMotionAxes[2],
+	}
+}
+
+// removeRootMotion takes the root motion out of a sampled pose so that the
+// root bone stays where it was at the start of the animation.
+func (a *Animator) removeRootMotion(p *animatorPlayback, pose []BonePose) {
+	boneIndex := a.getRootMotionBone()
+	if boneIndex < 0 || !pose[boneIndex].Animated {
+		return
+	}
+
+	start := a.Skeleton.SampleBonePose(p.animation, boneIndex, 0.0)
+	transform := p.animation.Transform
+	offset := a.maskRootMotion(mgl.TransformNormal(pose[boneIndex].Position.Sub(start.Position), transform))
+	pose[boneIndex].Position = pose[boneIndex].Position.Sub(mgl.TransformNormal(offset, transform.Inv()))
+	if a.RootMotionRotation {
+		pose[boneIndex].Rotation = start.Rotation
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package scene
+
+import (
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/particles"
+	"github.com/tbogdala/fizzle/renderer/forward"
+)
+
+// RenderableEntity is an Entity that has a Renderable to draw. The
+// TransformSystem places the Renderable at the entity's location and
+// orientation and the RenderSystem draws it.
+type RenderableEntity interface {
+	Entity
+	GetRenderable() *fizzle.Renderable
+}
+
+// LightEntity is an Entity that lights the scene. The TransformSystem
+// moves the light to the entity's location and the RenderSystem makes it
+// active in the renderer.
+type LightEntity interface {
+	Entity
+	GetLight() *forward.Light
+}
+
+// ParticleEntity is an Entity with a particle system. The TransformSystem
+// moves the particle system's origin to the entity's location, the
+// ParticleUpdateSystem updates it and the RenderSystem draws it.
+type ParticleEntity interface {
+	Entity
+	GetParticleSystem() *particles.System
+}
+
+// VisibleEntity is a BasicEntity that draws a Renderable.
+type VisibleEntity struct {
+	*BasicEntity
+
+	Renderable *fizzle.Renderable
+}
+
+// NewVisibleEntity creates a new VisibleEntity for the Renderable.
+func NewVisibleEntity(r *fizzle.Renderable) *VisibleEntity {
+	e := new(VisibleEntity)
+	e.BasicEntity = NewBasicEntity()
+	e.Renderable = r
+	return e
+}
+
+// GetRenderable returns the renderable for the entity.
+func (e *VisibleEntity) GetRenderable() *fizzle.Renderable {
+	return e.Renderable
+}
+
+// LightSourceEntity is a BasicEntity that holds a forward renderer Light.
+type LightSourceEntity struct {
+	*BasicEntity
+
+	Light *forward.Light
+}
+
+// NewLightSourceEntity creates a new LightSourceEntity for the light.
+func NewLightSourceEntity(l *forward.Light) *LightSourceEntity {
+	e := new(LightSourceEntity)
+	e.BasicEntity = NewBasicEntity()
+	e.Light = l
+	e.SetLocation(l.Position)
+	return e
+}
+
+// GetLight returns the light for the entity.
+func (e *LightSourceEntity) GetLight() *forward.Light {
+	return e.Light
+}
+
+// ParticleEffectEntity is a BasicEntity that holds a particle system.
+type ParticleEffectEntity struct {
+	*BasicEntity
+
+	ParticleSystem *particles.System
+}
+
+// NewParticleEffectEntity creates a new ParticleEffectEntity for the
+// particle system.
+func NewParticleEffectEntity(ps *particles.System) *ParticleEffectEntity {
+	e := new(ParticleEffectEntity)
+	e.BasicEntity = NewBasicEntity()
+	e.ParticleSystem = ps
+	e.SetLocation(ps.Origin)
+	return e
+}
+
+// GetParticleSystem returns the particle system for the entity.
+func (e *ParticleEffectEntity) GetParticleSystem() *particles.System {
+	return e.ParticleSystem
+}
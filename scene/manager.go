@@ -5,6 +5,8 @@ package scene
 
 import (
 	"sort"
+
+	"github.com/tbogdala/fizzle"
 )
 
 // Manager is an interface for scene Manager objects that contain systems and
@@ -51,6 +53,20 @@ type BasicSceneManager struct {
 
 	// nextID is the next ID number to return on request.
 	nextID uint64
+
+	// FixedTimestep makes Update call FixedUpdate on the systems that
+	// implement FixedUpdateSystem in steps of this many seconds, carrying
+	// the remaining time over to the next frame. A value of 0 disables the
+	// fixed updates.
+	FixedTimestep float32
+
+	// MaxFixedSteps limits the number of fixed steps taken in one frame so
+	// that a slow frame doesn't cause more and more steps to be needed. Time
+	// beyond the limit is dropped.
+	MaxFixedSteps int
+
+	// timestep runs the fixed steps with the settings above.
+	timestep fizzle.FixedTimestep
 }
 
 // NewBasicSceneManager creates a new BasicSceneManager manager object
@@ -60,6 +76,7 @@ func NewBasicSceneManager() *BasicSceneManager {
 	sm.entities = make(map[uint64]Entity)
 	sm.systems = make(map[string]System)
 	sm.sortedSystems = []System{}
+	sm.MaxFixedSteps = 8
 	return sm
 }
 
@@ -104,12 +121,25 @@ func (sm *BasicSceneManager) RemoveEntity(oldEntity Entity) {
 	})
 }
 
-// AddSystem adds a new system to the scene manager.
+// AddSystem adds a new system to the scene manager, replacing any system
+// with the same name, and calls its OnAddEntity() for the entities already
+// in the scene.
 func (sm *BasicSceneManager) AddSystem(newSystem System) {
 	if newSystem != nil {
+		if existing, found := sm.systems[newSystem.GetName()]; found {
+			sm.removeSorted(existing)
+		}
 		sm.systems[newSystem.GetName()] = newSystem
 		sm.sortedSystems = append(sm.sortedSystems, newSystem)
-		sort.Sort(SystemsByPriority(sm.sortedSystems))
+
+		// a stable sort keeps systems with the same priority in the order
+		// they were added
+		sort.Stable(SystemsByPriority(sm.sortedSystems))
+
+		// let the new system know about the entities already in the scene
+		for _, e := range sm.entities {
+			newSystem.OnAddEntity(e)
+		}
 	}
 }
 
@@ -117,15 +147,20 @@ func (sm *BasicSceneManager) AddSystem(newSystem System) {
 func (sm *BasicSceneManager) RemoveSystem(oldSystem System) {
 	if oldSystem != nil {
 		delete(sm.systems, oldSystem.GetName())
+		sm.removeSorted(oldSystem)
+	}
+}
 
-		// instead of filtering out the system being removed, we'll create
-		// a new slice instead. The amount of systems in are typically few in number.
-		sm.sortedSystems = make([]System, len(sm.systems))
-		for _, s := range sm.systems {
-			sm.sortedSystems = append(sm.sortedSystems, s)
+// removeSorted removes the system from the sorted slice, keeping the
+// order of the remaining systems.
+func (sm *BasicSceneManager) removeSorted(oldSystem System) {
+	surviving := sm.sortedSystems[:0]
+	for _, s := range sm.sortedSystems {
+		if s != oldSystem {
+			surviving = append(surviving, s)
 		}
-		sort.Sort(SystemsByPriority(sm.sortedSystems))
 	}
+	sm.sortedSystems = surviving
 }
 
 // GetSystemByName returns a System, if found, that matches the name supplied.
@@ -137,14 +172,42 @@ func (sm *BasicSceneManager) GetSystemByName(name string) System {
 	return s
 }
 
-// Update should be called each frame to update the scene manager.
+// Update should be called each frame to update the scene manager. If
+// FixedTimestep is set, the fixed steps are run first and then Update is
+// called on all systems in order of priority.
 func (sm *BasicSceneManager) Update(frameDelta float32) {
+	if sm.FixedTimestep > 0.0 {
+		sm.timestep.Step = sm.FixedTimestep
+		sm.timestep.MaxUpdatesPerFrame = sm.MaxFixedSteps
+		sm.timestep.Advance(frameDelta, sm.fixedUpdate)
+	}
+
 	// call Update on all systems
 	sm.mapSystems(func(s System) {
 		s.Update(frameDelta)
 	})
 }
 
+// GetFixedStepAlpha returns how far, from 0 to 1, the time carried over
+// is into the next fixed step. Systems can use it to interpolate between
+// the last two fixed steps when drawing.
+func (sm *BasicSceneManager) GetFixedStepAlpha() float32 {
+	if sm.FixedTimestep <= 0.0 {
+		return 0.0
+	}
+	return sm.timestep.GetAlpha()
+}
+
+// fixedUpdate calls FixedUpdate on all of the systems that implement
+// FixedUpdateSystem in order of priority.
+func (sm *BasicSceneManager) fixedUpdate(step float32) {
+	sm.mapSystems(func(s System) {
+		if fs, okay := s.(FixedUpdateSystem); okay {
+			fs.FixedUpdate(step)
+		}
+	})
+}
+
 // MapEntities takes a function that accepts a uint64 ID value and
 // an Entity interface and will get called for each entity in the
 // scene in an undefined order.
@@ -0,0 +1,261 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package scene
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	_ "image/jpeg" // register the JPEG decoder for height maps
+	_ "image/png"  // register the PNG decoder for height maps
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/fizzle/particles"
+	"github.com/tbogdala/fizzle/renderer/forward"
+	"github.com/tbogdala/fizzle/terrain"
+)
+
+// Runtime holds the live objects created from a Scene. The slices line up
+// with the descriptions in the scene so that Runtime.Instances[i] was
+// created from Scene.Instances[i].
+type Runtime struct {
+	// Scene is the scene the objects were created from.
+	Scene *Scene
+
+	Cameras         []fizzle.Camera
+	Instances       []*fizzle.Renderable
+	Terrains        []*terrain.Terrain
+	ParticleSystems []*particles.System
+}
+
+// Instantiate loads the components, terrain and particle effects that the
+// scene references and creates the cameras and renderables for it.
+// Components get loaded through the component manager, stored under their
+// cleaned full path, and terrain textures through the texture manager. The
+// particle emitters still need their shader set by the caller. Lights are
+// applied separately with ApplyForwardLights since they depend on the
+// renderer.
+func (s *Scene) Instantiate(cm *component.Manager, tm *fizzle.TextureManager) (*Runtime, error) {
+	rt := new(Runtime)
+	rt.Scene = s
+
+	for _, cd := range s.Cameras {
+		rt.Cameras = append(rt.Cameras, cd.CreateCamera())
+	}
+
+	for _, inst := range s.Instances {
+		// store the components by their full path so that files with the
+		// same name in different directories don't get mixed up
+		compPath := filepath.Clean(s.GetFullPath(inst.Component))
+		comp, err := cm.LoadComponentFromFile(compPath, compPath)
+		if err != nil {
+			rt.Destroy()
+			return nil, fmt.Errorf("Failed to load the component for instance %s. %v", inst.Name, err)
+		}
+
+		r := cm.GetRenderableInstance(comp)
+		r.Location = inst.Location
+		r.LocalRotation = inst.Rotation
+		r.Scale = inst.Scale
+		rt.Instances = append(rt.Instances, r)
+	}
+
+	for _, td := range s.Terrains {
+		t, err := s.createTerrain(td, tm)
+		if err != nil {
+			rt.Destroy()
+			return nil, err
+		}
+		rt.Terrains = append(rt.Terrains, t)
+	}
+
+	for _, pd := range s.ParticleSystems {
+		ps := particles.NewSystem(fizzle.GetGraphics())
+		ps.Origin = pd.Origin
+		rt.ParticleSystems = append(rt.ParticleSystems, ps)
+		for _, emitterFile := range pd.Emitters {
+			_, err := ps.LoadEmitter(s.GetFullPath(emitterFile))
+			if err != nil {
+				rt.Destroy()
+				return nil, fmt.Errorf("Failed to load the particle emitter for system %s. %v", pd.Name, err)
+			}
+		}
+	}
+
+	return rt, nil
+}
+
+// createTerrain builds the terrain from the height map and sets up the
+// splat-map material if there is one.
+func (s *Scene) createTerrain(td *TerrainDesc, tm *fizzle.TextureManager) (*terrain.Terrain, error) {
+	imgFile, err := os.Open(s.GetFullPath(td.HeightMap))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the height map for terrain %s. %v", td.Name, err)
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the height map for terrain %s. %v", td.Name, err)
+	}
+
+	t, err := terrain.NewTerrainFromImage(img, td.CellSize, td.HeightScale, td.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	t.Renderable.Location = td.Location
+
+	if td.SplatMap == "" {
+		return t, nil
+	}
+	if len(td.Layers) > 4 {
+		t.Destroy()
+		return nil, fmt.Errorf("Failed to create terrain %s; only 4 layers are supported but %d were specified.", td.Name, len(td.Layers))
+	}
+
+	t.Renderable.Material.Shader, err = terrain.CreateSplatShader()
+	if err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	for i, texFile := range append([]string{td.SplatMap}, td.Layers...) {
+		texPath := s.GetFullPath(texFile)
+		tex, err := tm.LoadTexture(texPath, texPath)
+		if err != nil {
+			t.Destroy()
+			return nil, fmt.Errorf("Failed to load the texture %s for terrain %s. %v", texFile, td.Name, err)
+		}
+		t.Renderable.Material.CustomTex[i] = tex
+	}
+
+	return t, nil
+}
+
+// Destroy releases the terrain and particle systems of the runtime. The
+// component instances are owned by the component manager.
+func (rt *Runtime) Destroy() {
+	for _, t := range rt.Terrains {
+		t.Destroy()
+	}
+	for _, ps := range rt.ParticleSystems {
+		for len(ps.Emitters) > 0 {
+			ps.RemoveEmitter(ps.Emitters[0])
+		}
+	}
+	rt.Terrains = nil
+	rt.ParticleSystems = nil
+}
+
+// GetActiveCamera returns the camera to draw the scene with or nil if the
+// scene has no cameras.
+func (rt *Runtime) GetActiveCamera() fizzle.Camera {
+	if rt.Scene.ActiveCamera < 0 || rt.Scene.ActiveCamera >= len(rt.Cameras) {
+		return nil
+	}
+	return rt.Cameras[rt.Scene.ActiveCamera]
+}
+
+// GetActivePerspective returns the projection matrix for the active camera.
+func (rt *Runtime) GetActivePerspective(aspectRatio float32) mgl.Mat4 {
+	if rt.Scene.ActiveCamera < 0 || rt.Scene.ActiveCamera >= len(rt.Scene.Cameras) {
+		return mgl.Ident4()
+	}
+	return rt.Scene.Cameras[rt.Scene.ActiveCamera].GetPerspective(aspectRatio)
+}
+
+// UpdateScene copies the transforms of the live objects back into the
+// scene descriptions so that changes made at runtime, such as in an
+// editor, get saved.
+func (rt *Runtime) UpdateScene() {
+	for i, r := range rt.Instances {
+		inst := rt.Scene.Instances[i]
+		inst.Location = r.Location
+		inst.Rotation = r.LocalRotation
+		inst.Scale = r.Scale
+	}
+	for i, t := range rt.Terrains {
+		rt.Scene.Terrains[i].Location = t.Renderable.Location
+	}
+	for i, ps := range rt.ParticleSystems {
+		rt.Scene.ParticleSystems[i].Origin = ps.Origin
+	}
+	for i, cam := range rt.Cameras {
+		if ypc, okay := cam.(*fizzle.YawPitchCamera); okay {
+			cd := rt.Scene.Cameras[i]
+			cd.Position = ypc.GetPosition()
+			cd.Yaw = ypc.GetYaw()
+			cd.Pitch = ypc.GetPitch()
+		}
+	}
+}
+
+// ApplyForwardLights creates forward renderer lights for the scene's
+// lights and makes them active, replacing the renderer's active lights.
+// Lights past forward.MaxForwardLights are ignored. The deferred renderer
+// draws lights itself each frame, so for it call DrawDirectionalLight
+// with the directional lights of the scene instead.
+func (s *Scene) ApplyForwardLights(fr *forward.ForwardRenderer) []*forward.Light {
+	var lights []*forward.Light
+	for i := range fr.ActiveLights {
+		fr.ActiveLights[i] = nil
+	}
+
+	for i, ld := range s.Lights {
+		if i >= forward.MaxForwardLights {
+			break
+		}
+
+		l := fr.NewLight()
+		l.Position = ld.Position
+		l.Direction = ld.Direction
+		l.DiffuseColor = ld.DiffuseColor
+		l.DiffuseIntensity = ld.DiffuseIntensity
+		l.SpecularIntensity = ld.SpecularIntensity
+		l.AmbientIntensity = ld.AmbientIntensity
+		l.ConstAttenuation = ld.ConstAttenuation
+		l.LinearAttenuation = ld.LinearAttenuation
+		l.QuadraticAttenuation = ld.QuadraticAttenuation
+		l.Strength = ld.Strength
+		if ld.ShadowMapSize > 0 {
+			l.CreateShadowMap(ld.ShadowMapSize, ld.ShadowNear, ld.ShadowFar, ld.Direction)
+		}
+
+		fr.ActiveLights[i] = l
+		lights = append(lights, l)
+	}
+
+	return lights
+}
+
+// CreateCamera creates the camera described. Unknown types create a
+// yaw-pitch camera.
+func (cd *CameraDesc) CreateCamera() fizzle.Camera {
+	if cd.Type == CameraTypeOrbit {
+		return fizzle.NewOrbitCamera(cd.Target, cd.VerticalAngle, cd.Distance, cd.Rotation)
+	}
+
+	cam := fizzle.NewYawPitchCamera(cd.Position)
+	cam.SetYawAndPitch(cd.Yaw, cd.Pitch)
+	return cam
+}
+
+// GetPerspective returns the perspective projection for the camera.
+// Defaults are used for a field of view or clip distances of 0.
+func (cd *CameraDesc) GetPerspective(aspectRatio float32) mgl.Mat4 {
+	fov, near, far := cd.FieldOfView, cd.Near, cd.Far
+	if fov <= 0.0 {
+		fov = mgl.DegToRad(60.0)
+	}
+	if near <= 0.0 {
+		near = 0.1
+	}
+	if far <= 0.0 {
+		far = 1000.0
+	}
+	return mgl.Perspective(fov, aspectRatio, near, far)
+}
@@ -0,0 +1,276 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package scene
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Scene describes everything needed to draw a frame: the cameras, lights,
+// component instances, terrain and particle systems. It can be saved as
+// JSON or as a compact binary file and turned into live objects with
+// Instantiate. File paths in the scene are relative to the scene file.
+type Scene struct {
+	// Name is the user friendly name of the scene.
+	Name string
+
+	// Cameras are the cameras of the scene and ActiveCamera is the index
+	// of the one to draw with.
+	Cameras      []*CameraDesc
+	ActiveCamera int
+
+	// Lights are the lights of the scene.
+	Lights []*LightDesc
+
+	// Instances are the component instances placed in the scene.
+	Instances []*InstanceDesc
+
+	// Terrains are the height map terrains of the scene.
+	Terrains []*TerrainDesc
+
+	// ParticleSystems are the particle systems of the scene.
+	ParticleSystems []*ParticleSystemDesc
+
+	// Properties is a map for client code's custom properties for the scene.
+	Properties map[string]string
+
+	// dirPath is the directory the scene was loaded from or saved to and is
+	// used to find the files the scene references.
+	dirPath string
+}
+
+const (
+	// CameraTypeYawPitch is for fizzle.YawPitchCamera cameras.
+	CameraTypeYawPitch = "yawpitch"
+
+	// CameraTypeOrbit is for fizzle.OrbitCamera cameras.
+	CameraTypeOrbit = "orbit"
+)
+
+// CameraDesc describes a camera in the scene.
+type CameraDesc struct {
+	// Name is the user identifier for the camera.
+	Name string
+
+	// Type is the type of camera, such as CameraTypeYawPitch.
+	Type string
+
+	// Position is the eye position of yaw-pitch cameras.
+	Position mgl.Vec3
+
+	// Yaw and Pitch are the rotations in radians of yaw-pitch cameras.
+	Yaw   float32
+	Pitch float32
+
+	// Target is the point orbit cameras look at.
+	Target mgl.Vec3
+
+	// VerticalAngle, Distance and Rotation place orbit cameras around the
+	// target; see fizzle.NewOrbitCamera.
+	VerticalAngle float32
+	Distance      float32
+	Rotation      float32
+
+	// FieldOfView is the vertical field of view in radians and Near and
+	// Far are the clipping distances for the perspective projection.
+	FieldOfView float32
+	Near        float32
+	Far         float32
+}
+
+const (
+	// LightTypePoint is for point lights.
+	LightTypePoint = "point"
+
+	// LightTypeDirectional is for directional lights.
+	LightTypeDirectional = "directional"
+)
+
+// LightDesc describes a light in the scene. The fields mirror the ones in
+// the forward renderer's Light.
+type LightDesc struct {
+	// Name is the user identifier for the light.
+	Name string
+
+	// Type is the type of light, such as LightTypePoint.
+	Type string
+
+	Position             mgl.Vec3
+	Direction            mgl.Vec3
+	DiffuseColor         mgl.Vec4
+	DiffuseIntensity     float32
+	SpecularIntensity    float32
+	AmbientIntensity     float32
+	ConstAttenuation     float32
+	LinearAttenuation    float32
+	QuadraticAttenuation float32
+	Strength             float32
+
+	// ShadowMapSize is the size of the shadow map texture. Lights with a
+	// size of 0 don't cast shadows.
+	ShadowMapSize int32
+
+	// ShadowNear and ShadowFar are the distances for the shadow map
+	// projection.
+	ShadowNear float32
+	ShadowFar  float32
+}
+
+// InstanceDesc describes an instance of a component placed in the scene.
+type InstanceDesc struct {
+	// Name is the user identifier for the instance.
+	Name string
+
+	// Component is the component file to create the instance from.
+	Component string
+
+	// Location, Rotation and Scale are the transform of the instance.
+	Location mgl.Vec3
+	Rotation mgl.Quat
+	Scale    mgl.Vec3
+}
+
+// TerrainDesc describes a height map terrain in the scene.
+type TerrainDesc struct {
+	// Name is the user identifier for the terrain.
+	Name string
+
+	// HeightMap is the image file with the heights of the terrain.
+	HeightMap string
+
+	// CellSize, HeightScale and ChunkSize are passed to
+	// terrain.NewTerrainFromImage.
+	CellSize    float32
+	HeightScale float32
+	ChunkSize   int
+
+	// Location is where the terrain is placed in the world.
+	Location mgl.Vec3
+
+	// SplatMap is the splat map texture and Layers are the up to four
+	// layer textures it blends between. If SplatMap is empty the terrain
+	// gets no material textures or shader.
+	SplatMap string
+	Layers   []string
+}
+
+// ParticleSystemDesc describes a particle system in the scene.
+type ParticleSystemDesc struct {
+	// Name is the user identifier for the particle system.
+	Name string
+
+	// Origin is the location of the particle system.
+	Origin mgl.Vec3
+
+	// Emitters are the emitter files written by particles.Emitter.Save.
+	Emitters []string
+}
+
+// NewScene creates a new empty scene.
+func NewScene(name string) *Scene {
+	s := new(Scene)
+	s.Name = name
+	s.Properties = make(map[string]string)
+	return s
+}
+
+// NewInstanceDesc creates a new instance description for the component
+// file with an identity transform.
+func NewInstanceDesc(name string, componentFile string) *InstanceDesc {
+	inst := new(InstanceDesc)
+	inst.Name = name
+	inst.Component = componentFile
+	inst.Rotation = mgl.QuatIdent()
+	inst.Scale = mgl.Vec3{1.0, 1.0, 1.0}
+	return inst
+}
+
+// GetFullPath returns the path for a file referenced by the scene.
+func (s *Scene) GetFullPath(relPath string) string {
+	if filepath.IsAbs(relPath) {
+		return relPath
+	}
+	return filepath.Join(s.dirPath, relPath)
+}
+
+// SetDirPath sets the directory that file paths in the scene are relative
+// to. Loading and saving a scene sets it to the directory of the file.
+func (s *Scene) SetDirPath(dirPath string) {
+	s.dirPath = dirPath
+}
+
+// SaveJSON writes the scene to a JSON file.
+func (s *Scene) SaveJSON(filePath string) error {
+	jsonBytes, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the scene to JSON. %v", err)
+	}
+
+	err = ioutil.WriteFile(filePath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the scene file %s. %v", filePath, err)
+	}
+
+	s.dirPath = filepath.Dir(filePath)
+	return nil
+}
+
+// SaveBinary writes the scene to a binary file, which is smaller and
+// faster to load than JSON but not meant to be edited by hand.
+func (s *Scene) SaveBinary(filePath string) error {
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(s)
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the scene to binary. %v", err)
+	}
+
+	err = ioutil.WriteFile(filePath, buffer.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the scene file %s. %v", filePath, err)
+	}
+
+	s.dirPath = filepath.Dir(filePath)
+	return nil
+}
+
+// LoadSceneJSON reads a scene from a JSON file written by SaveJSON.
+func LoadSceneJSON(filePath string) (*Scene, error) {
+	jsonBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the scene file %s. %v", filePath, err)
+	}
+
+	s := NewScene("")
+	err = json.Unmarshal(jsonBytes, s)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the JSON for the scene. %v", err)
+	}
+
+	s.dirPath = filepath.Dir(filePath)
+	return s, nil
+}
+
+// LoadSceneBinary reads a scene from a binary file written by SaveBinary.
+func LoadSceneBinary(filePath string) (*Scene, error) {
+	binBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the scene file %s. %v", filePath, err)
+	}
+
+	s := NewScene("")
+	err = gob.NewDecoder(bytes.NewReader(binBytes)).Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the binary scene. %v", err)
+	}
+
+	s.dirPath = filepath.Dir(filePath)
+	return s, nil
+}
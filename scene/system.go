@@ -27,6 +27,17 @@ type System interface {
 	GetName() string
 }
 
+// FixedUpdateSystem is a System that also gets updated in steps of a fixed
+// amount of time when the scene Manager has a fixed timestep set, which
+// keeps physics and gameplay simulation the same regardless of frame rate.
+type FixedUpdateSystem interface {
+	System
+
+	// FixedUpdate advances the system by one step of step seconds. It is
+	// called zero or more times per frame before the Update calls.
+	FixedUpdate(step float32)
+}
+
 // SystemsByPriority is a type alias that will implement sort.Interface to sort
 // the slice of Systems by priority.
 type SystemsByPriority []System
@@ -0,0 +1,373 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package scene
+
+import (
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/fizzle/renderer"
+	"github.com/tbogdala/fizzle/renderer/forward"
+)
+
+const (
+	// TransformSystemPriority is the priority of the TransformSystem, which
+	// should run after gameplay systems have moved entities.
+	TransformSystemPriority = 80.0
+
+	// ParticleUpdateSystemPriority is the priority of the ParticleUpdateSystem.
+	ParticleUpdateSystemPriority = 90.0
+
+	// RenderSystemPriority is the priority of the RenderSystem, which runs
+	// last so that it draws the updated scene.
+	RenderSystemPriority = 100.0
+
+	// TransformSystemName is the name of the TransformSystem.
+	TransformSystemName = "TransformSystem"
+
+	// ParticleUpdateSystemName is the name of the ParticleUpdateSystem.
+	ParticleUpdateSystemName = "ParticleUpdateSystem"
+
+	// RenderSystemName is the name of the RenderSystem.
+	RenderSystemName = "RenderSystem"
+)
+
+// entityList is a list of entities that systems use to track the entities
+// they care about.
+type entityList []Entity
+
+// remove returns the list without the entity.
+func (el entityList) remove(oldEntity Entity) entityList {
+	surviving := el[:0]
+	for _, e := range el {
+		if e.GetID() != oldEntity.GetID() {
+			surviving = append(surviving, e)
+		}
+	}
+	return surviving
+}
+
+// TransformSystem copies the location and orientation of entities to their
+// Renderables, lights and particle systems each frame.
+type TransformSystem struct {
+	entities entityList
+}
+
+// NewTransformSystem creates a new TransformSystem.
+func NewTransformSystem() *TransformSystem {
+	s := new(TransformSystem)
+	s.entities = entityList{}
+	return s
+}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (s *TransformSystem) GetRequestedPriority() float32 {
+	return TransformSystemPriority
+}
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (s *TransformSystem) GetName() string {
+	return TransformSystemName
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (s *TransformSystem) OnAddEntity(newEntity Entity) {
+	switch newEntity.(type) {
+	case RenderableEntity, LightEntity, ParticleEntity:
+		s.entities = append(s.entities, newEntity)
+	}
+}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (s *TransformSystem) OnRemoveEntity(oldEntity Entity) {
+	s.entities = s.entities.remove(oldEntity)
+}
+
+// Update copies the entity transforms.
+func (s *TransformSystem) Update(frameDelta float32) {
+	for _, e := range s.entities {
+		if re, okay := e.(RenderableEntity); okay {
+			if r := re.GetRenderable(); r != nil {
+				r.Location = e.GetLocation()
+				r.LocalRotation = e.GetOrientation()
+			}
+		}
+		if le, okay := e.(LightEntity); okay {
+			if l := le.GetLight(); l != nil {
+				l.Position = e.GetLocation()
+			}
+		}
+		if pe, okay := e.(ParticleEntity); okay {
+			if ps := pe.GetParticleSystem(); ps != nil {
+				ps.Origin = e.GetLocation()
+			}
+		}
+	}
+}
+
+// ParticleUpdateSystem updates the particle systems of entities.
+type ParticleUpdateSystem struct {
+	entities entityList
+}
+
+// NewParticleUpdateSystem creates a new ParticleUpdateSystem.
+func NewParticleUpdateSystem() *ParticleUpdateSystem {
+	s := new(ParticleUpdateSystem)
+	s.entities = entityList{}
+	return s
+}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (s *ParticleUpdateSystem) GetRequestedPriority() float32 {
+	return ParticleUpdateSystemPriority
+}
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (s *ParticleUpdateSystem) GetName() string {
+	return ParticleUpdateSystemName
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (s *ParticleUpdateSystem) OnAddEntity(newEntity Entity) {
+	if _, okay := newEntity.(ParticleEntity); okay {
+		s.entities = append(s.entities, newEntity)
+	}
+}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (s *ParticleUpdateSystem) OnRemoveEntity(oldEntity Entity) {
+	s.entities = s.entities.remove(oldEntity)
+}
+
+// Update advances the particle systems by the frame delta.
+func (s *ParticleUpdateSystem) Update(frameDelta float32) {
+	for _, e := range s.entities {
+		if ps := e.(ParticleEntity).GetParticleSystem(); ps != nil {
+			ps.Update(float64(frameDelta))
+		}
+	}
+}
+
+// RenderSystem draws the renderables and particle systems of entities with
+// a renderer. With a forward renderer it also fills the active lights with
+// the light entities closest to the camera. Renderables are drawn through a
+// renderer.RenderQueue so that their RenderOrder and SortBias are respected.
+type RenderSystem struct {
+	// Renderer is the renderer to draw with.
+	Renderer renderer.Renderer
+
+	// Camera is the camera to draw from. The identity view matrix is used
+	// if it is nil.
+	Camera fizzle.Camera
+
+	// FieldOfView is the vertical field of view in radians and Near and Far
+	// are the clipping distances used to build the perspective projection.
+	FieldOfView float32
+	Near        float32
+	Far         float32
+
+	// Octree, if set, is used to only draw the renderables inside the view
+	// frustum. The renderables of entities get added to it and updated
+	// every frame by the RenderSystem.
+	Octree *fizzle.Octree
+
+	// LightManager, if set, picks and fades the lights of light entities
+	// for a forward renderer instead of using the closest lights. The
+	// lights of entities get added to it by the RenderSystem, so it should
+	// be set before entities are added.
+	LightManager *forward.LightManager
+
+	// BeforeDraw is called before the entities get drawn and can be used to
+	// clear the screen.
+	BeforeDraw func(rs *RenderSystem)
+
+	// AfterDraw is called after the entities get drawn and can be used to
+	// swap the window buffers.
+	AfterDraw func(rs *RenderSystem)
+
+	renderables entityList
+	lights      entityList
+	particles   entityList
+	visible     []*fizzle.Renderable
+	queue       *renderer.RenderQueue
+}
+
+// NewRenderSystem creates a new RenderSystem that draws with the renderer.
+func NewRenderSystem(r renderer.Renderer) *RenderSystem {
+	rs := new(RenderSystem)
+	rs.Renderer = r
+	rs.FieldOfView = mgl.DegToRad(60.0)
+	rs.Near = 0.1
+	rs.Far = 100.0
+	rs.BeforeDraw = func(rs *RenderSystem) {}
+	rs.AfterDraw = func(rs *RenderSystem) {}
+	rs.renderables = entityList{}
+	rs.lights = entityList{}
+	rs.particles = entityList{}
+	rs.queue = renderer.NewRenderQueue()
+	return rs
+}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (rs *RenderSystem) GetRequestedPriority() float32 {
+	return RenderSystemPriority
+}
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (rs *RenderSystem) GetName() string {
+	return RenderSystemName
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (rs *RenderSystem) OnAddEntity(newEntity Entity) {
+	if re, okay := newEntity.(RenderableEntity); okay {
+		rs.renderables = append(rs.renderables, newEntity)
+		if r := re.GetRenderable(); r != nil && rs.Octree != nil {
+			rs.Octree.Add(r)
+		}
+	}
+	if le, okay := newEntity.(LightEntity); okay {
+		rs.lights = append(rs.lights, newEntity)
+		if l := le.GetLight(); l != nil && rs.LightManager != nil {
+			rs.LightManager.AddLight(l)
+		}
+	}
+	if _, okay := newEntity.(ParticleEntity); okay {
+		rs.particles = append(rs.particles, newEntity)
+	}
+}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (rs *RenderSystem) OnRemoveEntity(oldEntity Entity) {
+	if re, okay := oldEntity.(RenderableEntity); okay && rs.Octree != nil {
+		if r := re.GetRenderable(); r != nil {
+			rs.Octree.Remove(r)
+		}
+	}
+	if le, okay := oldEntity.(LightEntity); okay && rs.LightManager != nil {
+		if l := le.GetLight(); l != nil {
+			rs.LightManager.RemoveLight(l)
+		}
+	}
+	rs.renderables = rs.renderables.remove(oldEntity)
+	rs.lights = rs.lights.remove(oldEntity)
+	rs.particles = rs.particles.remove(oldEntity)
+}
+
+// GetPerspective returns the projection matrix for the renderer's
+// current resolution.
+func (rs *RenderSystem) GetPerspective() mgl.Mat4 {
+	width, height := rs.Renderer.GetResolution()
+	if height == 0 {
+		height = 1
+	}
+	return mgl.Perspective(rs.FieldOfView, float32(width)/float32(height), rs.Near, rs.Far)
+}
+
+// Update draws the entities.
+func (rs *RenderSystem) Update(frameDelta float32) {
+	rs.BeforeDraw(rs)
+
+	projection := rs.GetPerspective()
+	view := mgl.Ident4()
+	if rs.Camera != nil {
+		view = rs.Camera.GetViewMatrix()
+	}
+
+	if fr, okay := rs.Renderer.(*forward.ForwardRenderer); okay {
+		if rs.LightManager != nil {
+			rs.LightManager.Update(fr, rs.Camera, projection, frameDelta)
+		} else {
+			rs.assignLights(fr)
+		}
+	}
+
+	if rs.Octree != nil {
+		for _, e := range rs.renderables {
+			if r := e.(RenderableEntity).GetRenderable(); r != nil {
+				rs.Octree.Update(r)
+			}
+		}
+		frustum := fizzle.NewFrustum(projection, view)
+		rs.visible = rs.Octree.QueryFrustum(&frustum, rs.visible[:0])
+		for _, r := range rs.visible {
+			rs.queue.Add(r, nil)
+		}
+	} else {
+		for _, e := range rs.renderables {
+			if r := e.(RenderableEntity).GetRenderable(); r != nil {
+				rs.queue.Add(r, nil)
+			}
+		}
+	}
+
+	// particles get sorted with the transparent renderables
+	for _, e := range rs.particles {
+		if ps := e.(ParticleEntity).GetParticleSystem(); ps != nil {
+			rs.queue.AddParticleSystem(ps)
+		}
+	}
+
+	// the queue sorts the renderables by their render order and distance
+	rs.queue.Draw(rs.Renderer, projection, view, rs.Camera)
+
+	rs.Renderer.EndRenderFrame()
+	rs.AfterDraw(rs)
+}
+
+// assignLights makes the lights closest to the camera active in the
+// forward renderer.
+func (rs *RenderSystem) assignLights(fr *forward.ForwardRenderer) {
+	lights := make([]*forward.Light, 0, len(rs.lights))
+	for _, e := range rs.lights {
+		if l := e.(LightEntity).GetLight(); l != nil {
+			lights = append(lights, l)
+		}
+	}
+
+	if len(lights) > forward.MaxForwardLights && rs.Camera != nil {
+		sort.Stable(lightsByDistance{lights, rs.Camera.GetPosition()})
+	}
+
+	for i := range fr.ActiveLights {
+		if i < len(lights) {
+			fr.ActiveLights[i] = lights[i]
+		} else {
+			fr.ActiveLights[i] = nil
+		}
+	}
+}
+
+// lightsByDistance implements sort.Interface to sort lights by their
+// distance to the eye.
+type lightsByDistance struct {
+	lights []*forward.Light
+	eye    mgl.Vec3
+}
+
+func (s lightsByDistance) Len() int {
+	return len(s.lights)
+}
+
+func (s lightsByDistance) Swap(i, j int) {
+	s.lights[i], s.lights[j] = s.lights[j], s.lights[i]
+}
+
+func (s lightsByDistance) Less(i, j int) bool {
+	return s.lights[i].Position.Sub(s.eye).LenSqr() < s.lights[j].Position.Sub(s.eye).LenSqr()
+}
@@ -9,7 +9,6 @@ import (
 	"io/ioutil"
 
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
-	"github.com/tbogdala/groggy"
 )
 
 // RenderShader is an OpenGL shader that is used for easier access
@@ -51,6 +50,20 @@ func (rs *RenderShader) GetUniformLocation(name string) int32 {
 	return ul
 }
 
+// GetUniformName returns the name of the uniform at the location if it has
+// been looked up with GetUniformLocation, otherwise an empty string.
+func (rs *RenderShader) GetUniformName(location int32) string {
+	if location < 0 {
+		return ""
+	}
+	for name, ul := range rs.uniCache {
+		if ul == location {
+			return name
+		}
+	}
+	return ""
+}
+
 // AssertUniformsExist attempts to get uniforms for the names passed in and returns
 // an error value if a name doesn't exist.
 func (rs *RenderShader) AssertUniformsExist(names ...string) error {
@@ -123,7 +136,7 @@ func LoadShaderProgramFromFiles(baseFilename string, prelink PreLinkBinder) (*Re
 	}
 	fsBuffer := bytes.NewBuffer(fsBytes)
 
-	groggy.Logsf("DEBUG", "Compiling shader: %s.", baseFilename)
+	Logf(LogDebug, "shader", "Compiling shader: %s.", baseFilename)
 	return LoadShaderProgram(vsBuffer.String(), fsBuffer.String(), prelink)
 }
 
@@ -4,9 +4,10 @@
 package fizzle
 
 import (
+	"math"
+
 	mgl "github.com/go-gl/mathgl/mgl32"
 	"github.com/tbogdala/gombz"
-	"github.com/tbogdala/groggy"
 )
 
 // Skeleton contains data for Bones and all of the matrix transforms
@@ -36,6 +37,28 @@ type Skeleton struct {
 	// They are local to the skeleton since it depends on the last calculated
 	// animation.
 	globalTransforms []mgl.Mat4
+
+	// pose is the pose sampled by Animate.
+	pose []BonePose
+
+	// clipCaches are the bone channel caches for each animation used so far.
+	clipCaches map[*gombz.Animation][]boneChannelCache
+
+	// rootTransform is the root transform of the last animation applied,
+	// which takes the global transforms into the space of the mesh.
+	rootTransform mgl.Mat4
+
+	// BoneBounds are the bind pose bounds, in mesh space, of the vertices
+	// each bone moves. Bones that don't move any vertices have an empty
+	// rectangle with Bottom above Top. This will not be modified by the
+	// Skeleton methods and can be shared between many instances of Skeleton.
+	BoneBounds []Rectangle3D
+
+	// posedBounds is the bounding rectangle of the mesh in the current pose.
+	posedBounds Rectangle3D
+
+	// hasPosedBounds is true if posedBounds has been calculated.
+	hasPosedBounds bool
 }
 
 // NewSkeleton creates a new Skeleton that shares a bones slice.
@@ -50,15 +73,37 @@ func NewSkeleton(bones []gombz.Bone, animations []gombz.Animation) *Skeleton {
 	skel.localTransforms = make([]mgl.Mat4, boneCount)
 	skel.globalTransforms = make([]mgl.Mat4, boneCount)
 	skel.PoseTransforms = make([]mgl.Mat4, boneCount)
+	skel.pose = make([]BonePose, boneCount)
+	skel.clipCaches = make(map[*gombz.Animation][]boneChannelCache)
 
 	// setup the transforms with idenity matrixes
 	for i := range skel.PoseTransforms {
 		skel.PoseTransforms[i] = mgl.Ident4()
 	}
+	skel.rootTransform = mgl.Ident4()
+
+	// start the bones out in the bind pose so they can be found before
+	// the first animation
+	for bi, bone := range bones {
+		skel.localTransforms[bi] = bone.Transform
+	}
+	skel.updateGlobalTransforms()
 
 	return skel
 }
 
+// BonePose is the position, rotation and scale of a bone relative to its
+// parent at one point in an animation.
+type BonePose struct {
+	Position mgl.Vec3
+	Rotation mgl.Quat
+	Scale    mgl.Vec3
+
+	// Animated is false for bones without a channel in the animation, which
+	// keep the bone's own transform.
+	Animated bool
+}
+
 // Animate interpolates the animation at the given time then calculates
 // the bone transformation matrixes.
 func (skel *Skeleton) Animate(animation *gombz.Animation, time float32) {
@@ -67,23 +112,177 @@ func (skel *Skeleton) Animate(animation *gombz.Animation, time float32) {
 		return
 	}
 
-	skel.updateLocalTransforms(animation, time)
+	skel.SamplePose(animation, time, skel.pose)
+	skel.ApplyPose(skel.pose, animation.Transform)
+}
+
+// GetAnimation returns the animation with the name or nil if the skeleton
+// doesn't have it.
+func (skel *Skeleton) GetAnimation(name string) *gombz.Animation {
+	for i := range skel.Animations {
+		if skel.Animations[i].Name == name {
+			return &skel.Animations[i]
+		}
+	}
+	return nil
+}
+
+// GetBoneIndex returns the index of the bone with the name or -1 if the
+// skeleton doesn't have it.
+func (skel *Skeleton) GetBoneIndex(name string) int {
+	for bi, bone := range skel.Bones {
+		if bone.Name == name {
+			return bi
+		}
+	}
+	return -1
+}
+
+// GetBoneTransform returns the transform of the named bone in the space of
+// the mesh for the pose of the last Animate or ApplyPose call and true if
+// the skeleton has the bone.
+func (skel *Skeleton) GetBoneTransform(name string) (mgl.Mat4, bool) {
+	bi := skel.GetBoneIndex(name)
+	if bi < 0 {
+		return mgl.Ident4(), false
+	}
+	return skel.rootTransform.Mul4(skel.globalTransforms[bi]), true
+}
+
+// GetBoneWorldTransform returns the world space transform of the named
+// bone for the pose of the last Animate or ApplyPose call when the mesh is
+// placed by the model matrix, such as from Renderable.GetTransformMat4.
+// True is returned if the skeleton has the bone.
+func (skel *Skeleton) GetBoneWorldTransform(name string, model mgl.Mat4) (mgl.Mat4, bool) {
+	boneTransform, okay := skel.GetBoneTransform(name)
+	return model.Mul4(boneTransform), okay
+}
+
+// GetAnimationLength returns the length of the animation in seconds.
+func GetAnimationLength(animation *gombz.Animation) float32 {
+	if animation.TicksPerSecond <= 0.0 {
+		return animation.Duration
+	}
+	return animation.Duration / animation.TicksPerSecond
+}
+
+// SamplePose interpolates the animation at the given time in ticks into
+// the pose slice, which needs an entry for each bone of the skeleton.
+func (skel *Skeleton) SamplePose(animation *gombz.Animation, time float32, pose []BonePose) {
+	for bi := range skel.Bones {
+		pose[bi] = skel.SampleBonePose(animation, bi, time)
+	}
+}
+
+// SampleBonePose interpolates the animation at the given time in ticks for
+// one bone.
+func (skel *Skeleton) SampleBonePose(animation *gombz.Animation, boneIndex int, time float32) BonePose {
+	var pose BonePose
+	cache := &skel.getClipCache(animation)[boneIndex]
+	if cache.channel < 0 {
+		return pose
+	}
+
+	channel := &animation.Channels[cache.channel]
+	pose.Scale = interpolateKeyVec3(channel.ScaleKeys, time, &cache.scaleKey)
+	pose.Position = interpolateKeyVec3(channel.PositionKeys, time, &cache.posKey)
+	pose.Rotation = interpolateKeyQuat(channel.RotationKeys, time, &cache.rotKey)
+	pose.Animated = true
+	return pose
+}
+
+// BlendPoses blends from pose a to pose b by the factor in the range of
+// [0, 1] and writes the result into out, which can be the same slice as
+// a or b. A bone that's only animated in one of the poses takes that pose.
+func BlendPoses(a, b []BonePose, factor float32, out []BonePose) {
+	for i := range out {
+		switch {
+		case a[i].Animated && b[i].Animated:
+			out[i].Position = a[i].Position.Add(b[i].Position.Sub(a[i].Position).Mul(factor))
+			out[i].Scale = a[i].Scale.Add(b[i].Scale.Sub(a[i].Scale).Mul(factor))
+			out[i].Rotation = mgl.QuatSlerp(a[i].Rotation, b[i].Rotation, factor)
+			out[i].Animated = true
+		case a[i].Animated:
+			out[i] = a[i]
+		default:
+			out[i] = b[i]
+		}
+	}
+}
+
+// ApplyPose calculates the bone transformation matrixes for a pose, such as
+// one made with SamplePose and BlendPoses. The transform is the root
+// transform of the animation the pose came from.
+func (skel *Skeleton) ApplyPose(pose []BonePose, transform mgl.Mat4) {
+	for bi, bone := range skel.Bones {
+		if pose[bi].Animated {
+			skel.localTransforms[bi] = pose[bi].Mat4()
+		} else {
+			skel.localTransforms[bi] = bone.Transform
+		}
+	}
 	skel.updateGlobalTransforms()
-	skel.updatePoseTransforms(animation)
+	skel.updatePoseTransforms(transform)
+}
+
+// Mat4 returns the transform matrix for the bone pose.
+func (bp *BonePose) Mat4() mgl.Mat4 {
+	rotMat := bp.Rotation.Mat4()
+	posMat := mgl.Translate3D(bp.Position[0], bp.Position[1], bp.Position[2])
+	scaleMat := mgl.Scale3D(bp.Scale[0], bp.Scale[1], bp.Scale[2])
+	return posMat.Mul4(rotMat).Mul4(scaleMat)
 }
 
-// getAnimationChannel returns the Channel for a given bone id or nil on error.
-func getAnimationChannel(animation *gombz.Animation, boneId int32) *gombz.AnimationChannel {
-	for _, c := range animation.Channels {
-		if c.BoneId == boneId {
-			return &c
+// boneChannelCache is the channel of an animation that moves a bone along
+// with the last keyframe indexes used for it, so that playing forward
+// doesn't search the keys from the start every time.
+type boneChannelCache struct {
+	channel  int
+	scaleKey int
+	posKey   int
+	rotKey   int
+}
+
+// getClipCache returns the per bone channel cache of the animation for the
+// skeleton, building it the first time the animation is used.
+func (skel *Skeleton) getClipCache(animation *gombz.Animation) []boneChannelCache {
+	cache, okay := skel.clipCaches[animation]
+	if okay {
+		return cache
+	}
+
+	cache = make([]boneChannelCache, len(skel.Bones))
+	for bi, bone := range skel.Bones {
+		cache[bi].channel = -1
+		for ci := range animation.Channels {
+			if animation.Channels[ci].BoneId == bone.Id {
+				cache[bi].channel = ci
+				break
+			}
 		}
 	}
+	skel.clipCaches[animation] = cache
+	return cache
+}
 
-	return nil
+// findKeyIndex returns the index of the key at or before the time, where
+// the keyTime function returns the time of a key. The search starts at the
+// hint if it's not past the time, which is the case when playing forward.
+// -1 is returned if the time is past the last key.
+func findKeyIndex(keyCount int, keyTime func(int) float32, time float32, hint int) int {
+	start := 0
+	if hint > 0 && hint < keyCount-1 && keyTime(hint) <= time {
+		start = hint
+	}
+	for i := start; i < keyCount-1; i++ {
+		if time < keyTime(i+1) {
+			return i
+		}
+	}
+	return -1
 }
 
-func interpolateKeyVec3(keys []gombz.AnimationVec3Key, time float32) mgl.Vec3 {
+func interpolateKeyVec3(keys []gombz.AnimationVec3Key, time float32, hint *int) mgl.Vec3 {
 	// if there's only one key, just return it
 	if len(keys) == 1 {
 		return keys[0].Key
@@ -92,21 +291,15 @@ func interpolateKeyVec3(keys []gombz.AnimationVec3Key, time float32) mgl.Vec3 {
 	// Note: at this point, there should be more than one key so we should
 	// be able to interpolate between two of them.
 
-	// find the first key index that has a Time greater than the current animation time ...
-	keyForTime := -1
-	for aniKeyIndex := 0; aniKeyIndex < len(keys)-1; aniKeyIndex++ {
-		// check to see if the current time
-		if time < keys[aniKeyIndex+1].Time {
-			keyForTime = aniKeyIndex
-			break
-		}
-	}
+	// find the last key index that has a Time before the current animation time ...
+	keyForTime := findKeyIndex(len(keys), func(i int) float32 { return keys[i].Time }, time, *hint)
 
 	// if we didn't find a key with a Time greater then the animation time has
 	// overflowed what is defined in the channel -- just return the last key
 	if keyForTime == -1 {
 		return keys[len(keys)-1].Key
 	}
+	*hint = keyForTime
 
 	// get the data to interpolate
 	keyTime := keys[keyForTime].Time
@@ -129,7 +322,7 @@ func interpolateKeyVec3(keys []gombz.AnimationVec3Key, time float32) mgl.Vec3 {
 	return interpVec
 }
 
-func interpolateKeyQuat(keys []gombz.AnimationQuatKey, time float32) mgl.Quat {
+func interpolateKeyQuat(keys []gombz.AnimationQuatKey, time float32, hint *int) mgl.Quat {
 	// if there's only one key, just return it
 	if len(keys) == 1 {
 		return keys[0].Key
@@ -138,21 +331,15 @@ func interpolateKeyQuat(keys []gombz.AnimationQuatKey, time float32) mgl.Quat {
 	// Note: at this point, there should be more than one key so we should
 	// be able to interpolate between two of them.
 
-	// find the first key index that has a Time greater than the current animation time ...
-	keyForTime := -1
-	for aniKeyIndex := 0; aniKeyIndex < len(keys)-1; aniKeyIndex++ {
-		// check to see if the current time
-		if time < keys[aniKeyIndex+1].Time {
-			keyForTime = aniKeyIndex
-			break
-		}
-	}
+	// find the last key index that has a Time before the current animation time ...
+	keyForTime := findKeyIndex(len(keys), func(i int) float32 { return keys[i].Time }, time, *hint)
 
 	// if we didn't find a key with a Time greater then the animation time has
 	// overflowed what is defined in the channel -- just return the last key
 	if keyForTime == -1 {
 		return keys[len(keys)-1].Key
 	}
+	*hint = keyForTime
 
 	// get the data to interpolate
 	keyTime := keys[keyForTime].Time
@@ -167,36 +354,6 @@ func interpolateKeyQuat(keys []gombz.AnimationQuatKey, time float32) mgl.Quat {
 	return mgl.QuatSlerp(key, nextKey, factor)
 }
 
-// updateLocalTransforms updates the localTransforms slice for each bone.
-func (skel *Skeleton) updateLocalTransforms(animation *gombz.Animation, time float32) {
-	for bi, bone := range skel.Bones {
-		// get the correct channel
-		channel := getAnimationChannel(animation, bone.Id)
-		if channel == nil {
-			groggy.Logsf("DEBUG", "updateLocalTransforms couldn't find a channel for bone %s", bone.Name)
-			continue
-		}
-
-		// if there's no channel for the bone, then it doesn't get animated
-		if channel == nil {
-			skel.localTransforms[bi] = bone.Transform
-		} else {
-			// we have the channel so interpolate the scale, position and rotation keys
-			scale := interpolateKeyVec3(channel.ScaleKeys, time)
-			position := interpolateKeyVec3(channel.PositionKeys, time)
-			rotation := interpolateKeyQuat(channel.RotationKeys, time)
-
-			// now build up the local transform matrix for the bone
-			rotMat := rotation.Mat4()
-			posMat := mgl.Translate3D(position[0], position[1], position[2])
-			scaleMat := mgl.Scale3D(scale[0], scale[1], scale[2])
-			//skel.localTransforms[bi] = rotMat.Mul4(posMat).Mul4(scaleMat)
-			skel.localTransforms[bi] = posMat.Mul4(rotMat).Mul4(scaleMat)
-		}
-
-	}
-}
-
 func (skel *Skeleton) updateGlobalTransforms() {
 	for bi, bone := range skel.Bones {
 		iter := &bone
@@ -206,32 +363,109 @@ func (skel *Skeleton) updateGlobalTransforms() {
 
 		// loop while there's a parent id
 		for iter.Parent >= 0 {
-			//groggy.Logsf("DEBUG", "\t\titer == %s ; iter.Parent == %s", iter.Name, skel.Bones[iter.Parent].Name)
+			//Logf(LogDebug, "skeleton", "\t\titer == %s ; iter.Parent == %s", iter.Name, skel.Bones[iter.Parent].Name)
 			skel.globalTransforms[bi] = skel.localTransforms[iter.Parent].Mul4(skel.globalTransforms[bi])
 			iter = &skel.Bones[iter.Parent]
 		}
 	}
 }
 
-func (skel *Skeleton) buildPoseTransforms(animation *gombz.Animation, b *gombz.Bone) {
-	skel.PoseTransforms[b.Id] = animation.Transform.Mul4(skel.globalTransforms[b.Id].Mul4(b.Offset))
+func (skel *Skeleton) buildPoseTransforms(transform mgl.Mat4, b *gombz.Bone) {
+	skel.PoseTransforms[b.Id] = transform.Mul4(skel.globalTransforms[b.Id].Mul4(b.Offset))
 
 	// loop through all of the bones to find child bones of this one
 	for _, possibleChild := range skel.Bones {
 		// does the child's parent match this bone's id (and the parent isn't the child itself)
 		if possibleChild.Parent == b.Id && possibleChild.Parent != possibleChild.Id {
 			// recurse down the bone textureIndex
-			//groggy.Logsf("DEBUG", "\tbuildPoseTransforms recursing. Bone=%s, Child=%s", b.Name, possibleChild.Name)
-			skel.buildPoseTransforms(animation, &possibleChild)
+			//Logf(LogDebug, "skeleton", "\tbuildPoseTransforms recursing. Bone=%s, Child=%s", b.Name, possibleChild.Name)
+			skel.buildPoseTransforms(transform, &possibleChild)
 		}
 	}
 }
 
-func (skel *Skeleton) updatePoseTransforms(animation *gombz.Animation) {
+func (skel *Skeleton) updatePoseTransforms(transform mgl.Mat4) {
+	skel.rootTransform = transform
+
 	// loop through all of the root level bones
 	for _, bone := range skel.Bones {
 		if bone.Parent == -1 {
-			skel.buildPoseTransforms(animation, &bone)
+			skel.buildPoseTransforms(transform, &bone)
+		}
+	}
+	skel.UpdatePosedBounds()
+}
+
+// CalculateBoneBounds returns the bind pose bounds of the vertices each
+// bone moves with a weight above zero, to be used as the Skeleton's
+// BoneBounds.
+func CalculateBoneBounds(srcMesh *gombz.Mesh) []Rectangle3D {
+	bounds := make([]Rectangle3D, srcMesh.BoneCount)
+	for bi := range bounds {
+		bounds[bi].Bottom = mgl.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+		bounds[bi].Top = mgl.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	}
+
+	for vi, v := range srcMesh.Vertices {
+		if vi >= len(srcMesh.VertexWeightIds) || vi >= len(srcMesh.VertexWeights) {
+			break
+		}
+		for wi := 0; wi < 4; wi++ {
+			bi := int(srcMesh.VertexWeightIds[vi][wi])
+			if srcMesh.VertexWeights[vi][wi] <= 0.0 || bi < 0 || bi >= len(bounds) {
+				continue
+			}
+			expandRect(&bounds[bi], mgl.Vec3{v[0], v[1], v[2]})
+		}
+	}
+
+	return bounds
+}
+
+// UpdatePosedBounds recalculates the bounding rectangle of the mesh in the
+// current pose by moving the corners of each bone's BoneBounds with its
+// pose transform. This gets called whenever the pose transforms change.
+func (skel *Skeleton) UpdatePosedBounds() {
+	skel.hasPosedBounds = false
+	for bi, bounds := range skel.BoneBounds {
+		if bi >= len(skel.PoseTransforms) || bounds.Bottom[0] > bounds.Top[0] {
+			continue
+		}
+
+		poseTransform := skel.PoseTransforms[bi]
+		for corner := 0; corner < 8; corner++ {
+			p := bounds.Bottom
+			for axis := uint(0); axis < 3; axis++ {
+				if corner&(1<<axis) != 0 {
+					p[axis] = bounds.Top[axis]
+				}
+			}
+
+			posed := poseTransform.Mul4x1(p.Vec4(1.0)).Vec3()
+			if !skel.hasPosedBounds {
+				skel.posedBounds = Rectangle3D{posed, posed}
+				skel.hasPosedBounds = true
+			} else {
+				expandRect(&skel.posedBounds, posed)
+			}
+		}
+	}
+}
+
+// GetPosedBounds returns the bounding rectangle of the mesh in the current
+// pose. False is returned if there are no BoneBounds to calculate it from.
+func (skel *Skeleton) GetPosedBounds() (Rectangle3D, bool) {
+	return skel.posedBounds, skel.hasPosedBounds
+}
+
+// expandRect grows the rectangle to include the point.
+func expandRect(rect *Rectangle3D, p mgl.Vec3) {
+	for i := 0; i < 3; i++ {
+		if p[i] < rect.Bottom[i] {
+			rect.Bottom[i] = p[i]
+		}
+		if p[i] > rect.Top[i] {
+			rect.Top[i] = p[i]
 		}
 	}
 }
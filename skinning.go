@@ -0,0 +1,145 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+var (
+	// forceCPUSkinning makes new skinned meshes use CPU skinning even if
+	// the graphics provider can skin them on the GPU.
+	forceCPUSkinning bool
+)
+
+// SetForceCPUSkinning sets whether skinned meshes created afterwards are
+// always skinned on the CPU, even when the graphics provider supports GPU
+// skinning. This can be faster on GPUs with slow vertex shaders.
+func SetForceCPUSkinning(force bool) {
+	forceCPUSkinning = force
+}
+
+// UseCPUSkinning returns true if new skinned meshes will be skinned on the
+// CPU, either because it was forced with SetForceCPUSkinning or because
+// the graphics provider doesn't support GPU skinning.
+func UseCPUSkinning() bool {
+	if forceCPUSkinning {
+		return true
+	}
+	return gfx != nil && !gfx.GetCapabilities().GPUSkinning
+}
+
+// CPUSkin keeps the bind pose of a skinned mesh so that the skinned
+// vertices can be calculated on the CPU and uploaded into the dynamic
+// position, normal and tangent VBOs of a RenderableCore.
+type CPUSkin struct {
+	// Positions, Normals and Tangents are the bind pose vertex data with
+	// three floats per vertex. Normals and Tangents may be empty.
+	Positions []float32
+	Normals   []float32
+	Tangents  []float32
+
+	// BoneIds and Weights are the four bones affecting each vertex and how
+	// strongly they do.
+	BoneIds []float32
+	Weights []float32
+
+	// skinned is the scratch buffer the skinned data is written to before
+	// being uploaded.
+	skinned []float32
+
+	// lastPose is the pose the current VBO contents were skinned with.
+	lastPose []mgl.Mat4
+}
+
+// newCPUSkin creates a new CPUSkin that keeps the bind pose slices.
+func newCPUSkin(positions, normals, tangents, boneIds, weights []float32) *CPUSkin {
+	skin := new(CPUSkin)
+	skin.Positions = positions
+	skin.Normals = normals
+	skin.Tangents = tangents
+	skin.BoneIds = boneIds
+	skin.Weights = weights
+	skin.skinned = make([]float32, len(positions))
+	return skin
+}
+
+// UpdateCPUSkinning skins the vertices of the core with the current pose
+// of its skeleton and uploads them if the pose changed since the last
+// update. It does nothing for cores that are not skinned on the CPU and
+// gets called by the renderers before drawing.
+func (r *RenderableCore) UpdateCPUSkinning() {
+	skin := r.CPUSkin
+	if skin == nil || r.Skeleton == nil {
+		return
+	}
+
+	pose := r.Skeleton.PoseTransforms
+	if skin.poseMatches(pose) {
+		return
+	}
+	skin.lastPose = append(skin.lastPose[:0], pose...)
+
+	const floatSize = 4
+	skin.skinVectors(skin.Positions, pose, 1.0)
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.VertVBO)
+	gfx.BufferSubData(graphics.ARRAY_BUFFER, 0, floatSize*len(skin.skinned), gfx.Ptr(&skin.skinned[0]))
+
+	if len(skin.Normals) > 0 {
+		skin.skinVectors(skin.Normals, pose, 0.0)
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.NormsVBO)
+		gfx.BufferSubData(graphics.ARRAY_BUFFER, 0, floatSize*len(skin.skinned), gfx.Ptr(&skin.skinned[0]))
+	}
+
+	if len(skin.Tangents) > 0 {
+		skin.skinVectors(skin.Tangents, pose, 0.0)
+		gfx.BindBuffer(graphics.ARRAY_BUFFER, r.TangentsVBO)
+		gfx.BufferSubData(graphics.ARRAY_BUFFER, 0, floatSize*len(skin.skinned), gfx.Ptr(&skin.skinned[0]))
+	}
+
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, 0)
+}
+
+// poseMatches returns true if the pose is the same one the VBOs were last
+// skinned with. Comparing the matrices catches every change to the pose,
+// including ones made by writing to PoseTransforms directly.
+func (skin *CPUSkin) poseMatches(pose []mgl.Mat4) bool {
+	if len(skin.lastPose) != len(pose) {
+		return false
+	}
+	for i := range pose {
+		if pose[i] != skin.lastPose[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// skinVectors transforms the bind pose vectors by the blended bone matrices
+// of each vertex into the skinned slice. The w component is 1 for
+// positions and 0 for directions.
+func (skin *CPUSkin) skinVectors(src []float32, pose []mgl.Mat4, w float32) {
+	vertCount := len(src) / 3
+	for v := 0; v < vertCount; v++ {
+		var m mgl.Mat4
+		for i := 0; i < 4; i++ {
+			weight := skin.Weights[v*4+i]
+			if weight == 0.0 {
+				continue
+			}
+			bone := int(skin.BoneIds[v*4+i])
+			if bone < 0 || bone >= len(pose) {
+				continue
+			}
+			m = m.Add(pose[bone].Mul(weight))
+		}
+
+		o := v * 3
+		result := m.Mul4x1(mgl.Vec4{src[o], src[o+1], src[o+2], w})
+		skin.skinned[o] = result[0]
+		skin.skinned[o+1] = result[1]
+		skin.skinned[o+2] = result[2]
+	}
+}
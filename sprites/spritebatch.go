@@ -0,0 +1,275 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package sprites draws batches of textured 2D quads, such as HUD elements or
+the sprites of a 2D game, with as few draw calls as possible. It is meant to
+be used along with a fizzle.OrthoCamera.
+
+*/
+package sprites
+
+import (
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+var (
+	// VertShader330 is the GLSL vertex shader program for the sprite batch.
+	VertShader330 = `#version 330
+  uniform mat4 MVP;
+  in vec3 POSITION;
+  in vec2 UV;
+  in vec4 COLOR;
+
+  out vec2 vs_uv;
+  out vec4 vs_color;
+
+  void main()
+  {
+    vs_uv = UV;
+    vs_color = COLOR;
+    gl_Position = MVP * vec4(POSITION, 1.0);
+  }`
+
+	// FragShader330 is the GLSL fragment shader program for the sprite batch.
+	FragShader330 = `#version 330
+  uniform sampler2D TEX;
+  in vec2 vs_uv;
+  in vec4 vs_color;
+
+  out vec4 frag_color;
+
+  void main()
+  {
+    frag_color = vs_color * texture(TEX, vs_uv);
+  }`
+)
+
+const (
+	floatSize = 4
+
+	// vertexFloats is the number of floats per sprite vertex:
+	// position (3), uv (2), color (4).
+	vertexFloats = 3 + 2 + 4
+	vertexStride = floatSize * vertexFloats
+)
+
+// Sprite is a textured quad to draw with a SpriteBatch.
+type Sprite struct {
+	Texture graphics.Texture
+
+	// Location is where the Origin of the sprite gets placed.
+	Location mgl.Vec2
+
+	// Size is the width and height of the sprite.
+	Size mgl.Vec2
+
+	// Origin is the point the sprite is placed and rotated around, with
+	// {0, 0} being the bottom-left and {1, 1} the top-right.
+	Origin mgl.Vec2
+
+	// Rotation is the counter-clockwise rotation in radians.
+	Rotation float32
+
+	// UV is the region of the texture to draw as {u0, v0, u1, v1} with
+	// u0, v0 at the bottom-left of the sprite.
+	UV mgl.Vec4
+
+	// Tint is multiplied with the texture color.
+	Tint mgl.Vec4
+
+	// Layer controls the drawing order; sprites on higher layers are drawn
+	// on top of the ones on lower layers.
+	Layer int
+}
+
+// NewSprite creates a sprite that draws the whole texture untinted at a
+// location and size.
+func NewSprite(tex graphics.Texture, x, y, w, h float32) Sprite {
+	var s Sprite
+	s.Texture = tex
+	s.Location = mgl.Vec2{x, y}
+	s.Size = mgl.Vec2{w, h}
+	s.UV = mgl.Vec4{0, 0, 1, 1}
+	s.Tint = mgl.Vec4{1, 1, 1, 1}
+	return s
+}
+
+// AtlasUV returns the UV region of a frame in a texture atlas divided into
+// a grid of columns by rows. Frames go left to right and then top to bottom.
+func AtlasUV(columns, rows, frame int) mgl.Vec4 {
+	if columns <= 0 || rows <= 0 {
+		return mgl.Vec4{0, 0, 1, 1}
+	}
+
+	col := frame % columns
+	row := (frame / columns) % rows
+	w := 1.0 / float32(columns)
+	h := 1.0 / float32(rows)
+	u0 := float32(col) * w
+	v1 := 1.0 - float32(row)*h
+	return mgl.Vec4{u0, v1 - h, u0 + w, v1}
+}
+
+// SpriteBatch collects sprites between Begin and End and then draws them
+// sorted by layer, using one draw call for each run of sprites on the same
+// layer that share a texture.
+type SpriteBatch struct {
+	// Shader is the program used to draw the sprites and should be created
+	// with VertShader330 and FragShader330.
+	Shader graphics.Program
+
+	gfx     graphics.GraphicsProvider
+	vao     uint32
+	vbo     graphics.Buffer
+	sprites []Sprite
+	buffer  []float32
+}
+
+// NewSpriteBatch creates a new sprite batch.
+func NewSpriteBatch(gfx graphics.GraphicsProvider) *SpriteBatch {
+	sb := new(SpriteBatch)
+	sb.gfx = gfx
+	sb.vao = gfx.GenVertexArray()
+	sb.vbo = gfx.GenBuffer()
+	return sb
+}
+
+// Destroy releases the graphics objects for the sprite batch.
+func (sb *SpriteBatch) Destroy() {
+	sb.gfx.DeleteVertexArray(sb.vao)
+	sb.gfx.DeleteBuffer(sb.vbo)
+}
+
+// Begin clears out the sprites from the last batch.
+func (sb *SpriteBatch) Begin() {
+	sb.sprites = sb.sprites[:0]
+}
+
+// Draw adds a sprite to the batch to be drawn when End is called.
+func (sb *SpriteBatch) Draw(s Sprite) {
+	sb.sprites = append(sb.sprites, s)
+}
+
+// spritesByLayer sorts sprites by layer and then by texture.
+type spritesByLayer []Sprite
+
+func (s spritesByLayer) Len() int {
+	return len(s)
+}
+
+func (s spritesByLayer) Less(i, j int) bool {
+	if s[i].Layer != s[j].Layer {
+		return s[i].Layer < s[j].Layer
+	}
+	return s[i].Texture < s[j].Texture
+}
+
+func (s spritesByLayer) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// End draws all of the sprites added since Begin with alpha blending.
+func (sb *SpriteBatch) End(projection mgl.Mat4, view mgl.Mat4) {
+	if len(sb.sprites) == 0 {
+		return
+	}
+
+	// keep the order sprites were added in for each layer and texture
+	sort.Stable(spritesByLayer(sb.sprites))
+
+	// build the vertices for all of the sprites at once
+	buffer := sb.buffer[:0]
+	for i := range sb.sprites {
+		buffer = appendSprite(buffer, &sb.sprites[i])
+	}
+	sb.buffer = buffer
+
+	gfx := sb.gfx
+	gfx.BindVertexArray(sb.vao)
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, sb.vbo)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(buffer), gfx.Ptr(&buffer[0]), graphics.STREAM_DRAW)
+
+	gfx.UseProgram(sb.Shader)
+	gfx.Enable(graphics.BLEND)
+	gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+
+	mvpMatrix := gfx.GetUniformLocation(sb.Shader, "MVP")
+	if mvpMatrix >= 0 {
+		gfx.UniformMatrix4fv(mvpMatrix, 1, false, projection.Mul4(view))
+	}
+
+	const posOffset = 0
+	const uvOffset = floatSize * 3
+	const colorOffset = floatSize * 5
+
+	shaderPosition := gfx.GetAttribLocation(sb.Shader, "POSITION")
+	if shaderPosition >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderPosition))
+		gfx.VertexAttribPointer(uint32(shaderPosition), 3, graphics.FLOAT, false, vertexStride, gfx.PtrOffset(posOffset))
+	}
+
+	shaderUV := gfx.GetAttribLocation(sb.Shader, "UV")
+	if shaderUV >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderUV))
+		gfx.VertexAttribPointer(uint32(shaderUV), 2, graphics.FLOAT, false, vertexStride, gfx.PtrOffset(uvOffset))
+	}
+
+	shaderColor := gfx.GetAttribLocation(sb.Shader, "COLOR")
+	if shaderColor >= 0 {
+		gfx.EnableVertexAttribArray(uint32(shaderColor))
+		gfx.VertexAttribPointer(uint32(shaderColor), 4, graphics.FLOAT, false, vertexStride, gfx.PtrOffset(colorOffset))
+	}
+
+	shaderTex0 := gfx.GetUniformLocation(sb.Shader, "TEX")
+	if shaderTex0 >= 0 {
+		gfx.ActiveTexture(graphics.TEXTURE0)
+		gfx.Uniform1i(shaderTex0, 0)
+	}
+
+	// draw each run of sprites that share a layer and texture
+	const verticesPerSprite = 6
+	start := 0
+	for start < len(sb.sprites) {
+		end := start + 1
+		for end < len(sb.sprites) && sb.sprites[end].Texture == sb.sprites[start].Texture && sb.sprites[end].Layer == sb.sprites[start].Layer {
+			end++
+		}
+
+		gfx.BindTexture(graphics.TEXTURE_2D, sb.sprites[start].Texture)
+		gfx.DrawArrays(graphics.TRIANGLES, int32(start*verticesPerSprite), int32((end-start)*verticesPerSprite))
+		start = end
+	}
+
+	gfx.BindVertexArray(0)
+}
+
+// appendSprite adds the two triangles for a sprite to the buffer.
+func appendSprite(buffer []float32, s *Sprite) []float32 {
+	sin := float32(math.Sin(float64(s.Rotation)))
+	cos := float32(math.Cos(float64(s.Rotation)))
+
+	// corners of the quad in the order of bottom-left, bottom-right,
+	// top-right, top-left with the matching uvs
+	corners := [4]mgl.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	uvs := [4]mgl.Vec2{{s.UV[0], s.UV[1]}, {s.UV[2], s.UV[1]}, {s.UV[2], s.UV[3]}, {s.UV[0], s.UV[3]}}
+
+	var points [4]mgl.Vec2
+	for i, c := range corners {
+		x := (c[0] - s.Origin[0]) * s.Size[0]
+		y := (c[1] - s.Origin[1]) * s.Size[1]
+		points[i] = mgl.Vec2{x*cos - y*sin + s.Location[0], x*sin + y*cos + s.Location[1]}
+	}
+
+	for _, i := range [6]int{0, 1, 2, 0, 2, 3} {
+		buffer = append(buffer, points[i][0], points[i][1], 0.0)
+		buffer = append(buffer, uvs[i][0], uvs[i][1])
+		buffer = append(buffer, s.Tint[0], s.Tint[1], s.Tint[2], s.Tint[3])
+	}
+	return buffer
+}
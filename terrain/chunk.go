@@ -0,0 +1,173 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package terrain
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// Chunk is a square piece of the terrain with its own vertex buffer and an
+// index buffer for each level of detail.
+type Chunk struct {
+	// Renderable draws the chunk and is a child of the terrain's Renderable.
+	Renderable *fizzle.Renderable
+
+	// X and Z are the coordinates of the chunk's first height sample.
+	X int
+	Z int
+
+	// LOD is the current level of detail where 0 is the full detail and
+	// each level after that uses every other height sample of the last.
+	LOD int
+
+	lodElements []graphics.Buffer
+	lodFaces    []uint32
+}
+
+// newChunk creates the chunk of the terrain starting at the height sample.
+func newChunk(t *Terrain, x0, z0 int) *Chunk {
+	const floatSize = 4
+	const uintSize = 4
+
+	gfx := fizzle.GetGraphics()
+	size := t.ChunkSize
+
+	c := new(Chunk)
+	c.X = x0
+	c.Z = z0
+	c.Renderable = fizzle.NewRenderable()
+	c.Renderable.Location = mgl.Vec3{float32(x0) * t.CellSize, 0.0, float32(z0) * t.CellSize}
+	c.Renderable.Material = t.Renderable.Material
+	c.Renderable.BoundingRect = t.getBoundingRect(x0, z0, x0+size, z0+size)
+
+	// the grid vertices come first and then a row of skirt vertices for
+	// each edge, in the order that getSkirtCell walks them
+	vnutBuffer := make([]float32, 0, ((size+1)*(size+1)+4*(size+1))*11)
+	addVertex := func(x, z int, drop float32) {
+		h := t.getHeight(x0+x, z0+z)
+		n := t.getNormal(x0+x, z0+z)
+		tangent := mgl.Vec3{2.0 * t.CellSize, t.getHeight(x0+x+1, z0+z) - t.getHeight(x0+x-1, z0+z), 0.0}.Normalize()
+		u := float32(x0+x) / float32(t.Width-1)
+		v := float32(z0+z) / float32(t.Depth-1)
+		vnutBuffer = append(vnutBuffer, float32(x)*t.CellSize, h-drop, float32(z)*t.CellSize)
+		vnutBuffer = append(vnutBuffer, n[0], n[1], n[2])
+		vnutBuffer = append(vnutBuffer, u, v)
+		vnutBuffer = append(vnutBuffer, tangent[0], tangent[1], tangent[2])
+	}
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			addVertex(x, z, 0.0)
+		}
+	}
+	for edge := 0; edge < 4; edge++ {
+		for i := 0; i <= size; i++ {
+			x, z := getSkirtCell(edge, i, size)
+			addVertex(x, z, t.SkirtDepth)
+		}
+	}
+
+	r := c.Renderable
+	r.Core.VertVBO = gfx.GenBuffer()
+	r.Core.UvVBO = r.Core.VertVBO
+	r.Core.NormsVBO = r.Core.VertVBO
+	r.Core.TangentsVBO = r.Core.VertVBO
+	r.Core.VertVBOOffset = 0
+	r.Core.NormsVBOOffset = floatSize * 3
+	r.Core.UvVBOOffset = floatSize * 6
+	r.Core.TangentsVBOOffset = floatSize * 8
+	r.Core.VBOStride = floatSize * (3 + 3 + 2 + 3) // vert / normal / uv / tangent
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vnutBuffer), gfx.Ptr(&vnutBuffer[0]), graphics.STATIC_DRAW)
+
+	// build the index buffers for each level of detail down to a single quad
+	for step := 1; step <= size; step *= 2 {
+		indexes := createLODIndexes(size, step)
+		elements := gfx.GenBuffer()
+		gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, elements)
+		gfx.BufferData(graphics.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gfx.Ptr(&indexes[0]), graphics.STATIC_DRAW)
+		c.lodElements = append(c.lodElements, elements)
+		c.lodFaces = append(c.lodFaces, uint32(len(indexes)/3))
+	}
+	c.SetLOD(0)
+
+	return c
+}
+
+// SetLOD changes the level of detail that the chunk gets drawn with. The
+// level is clamped to the ones the chunk has.
+func (c *Chunk) SetLOD(lod int) {
+	lod = clampInt(lod, len(c.lodElements)-1)
+	c.LOD = lod
+	c.Renderable.Core.ElementsVBO = c.lodElements[lod]
+	c.Renderable.FaceCount = c.lodFaces[lod]
+}
+
+// GetLODCount returns the number of levels of detail the chunk has.
+func (c *Chunk) GetLODCount() int {
+	return len(c.lodElements)
+}
+
+// destroy releases the index buffers of every level of detail and the rest
+// of the chunk's OpenGL objects.
+func (c *Chunk) destroy() {
+	gfx := fizzle.GetGraphics()
+	for i, elements := range c.lodElements {
+		if i != c.LOD {
+			gfx.DeleteBuffer(elements)
+		}
+	}
+	c.Renderable.Destroy()
+}
+
+// getSkirtCell returns the grid coordinates of the i-th vertex along an
+// edge of the chunk. The edges are walked counter-clockwise when looking
+// down on the terrain so that the skirt faces point outwards.
+func getSkirtCell(edge, i, size int) (int, int) {
+	switch edge {
+	case 0:
+		return i, 0
+	case 1:
+		return size, i
+	case 2:
+		return size - i, size
+	default:
+		return 0, size - i
+	}
+}
+
+// createLODIndexes returns the triangle indexes for a chunk that uses every
+// step-th height sample, including the skirts along the edges.
+func createLODIndexes(size, step int) []uint32 {
+	row := size + 1
+	gridIndex := func(x, z int) uint32 {
+		return uint32(z*row + x)
+	}
+
+	quads := size / step
+	indexes := make([]uint32, 0, (quads*quads+4*quads)*6)
+	for z := 0; z < size; z += step {
+		for x := 0; x < size; x += step {
+			i00 := gridIndex(x, z)
+			i10 := gridIndex(x+step, z)
+			i01 := gridIndex(x, z+step)
+			i11 := gridIndex(x+step, z+step)
+			indexes = append(indexes, i00, i01, i10, i10, i01, i11)
+		}
+	}
+
+	skirtBase := row * row
+	for edge := 0; edge < 4; edge++ {
+		for i := 0; i < size; i += step {
+			p0 := gridIndex(getSkirtCell(edge, i, size))
+			p1 := gridIndex(getSkirtCell(edge, i+step, size))
+			s0 := uint32(skirtBase + edge*row + i)
+			s1 := uint32(skirtBase + edge*row + i + step)
+			indexes = append(indexes, p0, p1, s0, p1, s1, s0)
+		}
+	}
+
+	return indexes
+}
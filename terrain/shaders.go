@@ -0,0 +1,139 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package terrain
+
+import (
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// SplatShaderV330 is the vertex shader for splat-map texturing. The splat
+	// map covers the whole terrain once while the layer textures get
+	// tiled by the material's UV transform.
+	SplatShaderV330 = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+    uniform mat4 M_MATRIX;
+    uniform mat4 MATERIAL_UV_TRANSFORM;
+    in vec3 VERTEX_POSITION;
+    in vec3 VERTEX_NORMAL;
+    in vec2 VERTEX_UV_0;
+
+    out vec3 vs_normal_model;
+    out vec3 vs_position_model;
+    out vec2 vs_splat_uv;
+    out vec2 vs_layer_uv;
+
+    void main()
+    {
+    	vec4 vertex4 = vec4(VERTEX_POSITION, 1.0);
+    	vs_normal_model = mat3(M_MATRIX) * VERTEX_NORMAL;
+    	vs_position_model = vec3(M_MATRIX * vertex4);
+    	vs_splat_uv = VERTEX_UV_0;
+    	vs_layer_uv = (MATERIAL_UV_TRANSFORM * vec4(VERTEX_UV_0, 0.0, 1.0)).xy;
+    	gl_Position = MVP_MATRIX * vertex4;
+    }
+    `
+
+	// SplatShaderF330 is the fragment shader for splat-map texturing. The
+	// splat map is in MATERIAL_TEX_0 (CustomTex[0]) and its red, green, blue
+	// and alpha channels weight the layer textures in MATERIAL_TEX_1 through
+	// MATERIAL_TEX_4. It is lit by the lights of the forward renderer.
+	SplatShaderF330 = `#version 330
+    precision highp float;
+
+    const int MAX_LIGHTS=4;
+
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform sampler2D MATERIAL_TEX_0;
+    uniform sampler2D MATERIAL_TEX_1;
+    uniform sampler2D MATERIAL_TEX_2;
+    uniform sampler2D MATERIAL_TEX_3;
+    uniform sampler2D MATERIAL_TEX_4;
+    uniform float MATERIAL_TEX_0_VALID;
+    uniform float MATERIAL_TEX_1_VALID;
+    uniform float MATERIAL_TEX_2_VALID;
+    uniform float MATERIAL_TEX_3_VALID;
+    uniform float MATERIAL_TEX_4_VALID;
+
+    uniform vec3 LIGHT_POSITION[MAX_LIGHTS];
+    uniform vec4 LIGHT_DIFFUSE[MAX_LIGHTS];
+    uniform float LIGHT_DIFFUSE_INTENSITY[MAX_LIGHTS];
+    uniform float LIGHT_AMBIENT_INTENSITY[MAX_LIGHTS];
+    uniform vec3 LIGHT_DIRECTION[MAX_LIGHTS];
+    uniform float LIGHT_CONST_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_LINEAR_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_QUADRATIC_ATTENUATION[MAX_LIGHTS];
+    uniform float LIGHT_STRENGTH[MAX_LIGHTS];
+    uniform int LIGHT_COUNT;
+
+    in vec3 vs_normal_model;
+    in vec3 vs_position_model;
+    in vec2 vs_splat_uv;
+    in vec2 vs_layer_uv;
+
+    out vec4 frag_color;
+
+    vec3 CalcLights(vec3 v_model, vec3 n_model, vec3 color)
+    {
+    	vec3 scattered_light = vec3(0.0);
+    	for (int i=0; i<MAX_LIGHTS; i++) {
+    		if (i >= LIGHT_COUNT) {
+    			break;
+    		}
+
+    		vec3 incidence;
+    		float attenuation = LIGHT_STRENGTH[i];
+    		vec3 light_direction = LIGHT_DIRECTION[i];
+    		if (light_direction.x == 0.0 && light_direction.y == 0.0 && light_direction.z == 0.0) {
+    			// point light
+    			light_direction = LIGHT_POSITION[i] - v_model;
+    			float distance = length(light_direction);
+    			attenuation = LIGHT_STRENGTH[i] / (1.0 +
+    				(LIGHT_CONST_ATTENUATION[i] +
+    				 LIGHT_LINEAR_ATTENUATION[i] * distance +
+    				 LIGHT_QUADRATIC_ATTENUATION[i] * distance * distance));
+    			incidence = light_direction / distance;
+    		} else {
+    			// directional light
+    			incidence = -normalize(light_direction);
+    		}
+
+    		float diffuseF = max(0.0, dot(n_model, incidence));
+    		vec3 ambient = LIGHT_DIFFUSE[i].rgb * LIGHT_AMBIENT_INTENSITY[i] * attenuation;
+    		vec3 diffuse = LIGHT_DIFFUSE[i].rgb * LIGHT_DIFFUSE_INTENSITY[i] * diffuseF * attenuation;
+    		scattered_light += ambient + diffuse;
+    	}
+    	return min(color * scattered_light, vec3(1.0));
+    }
+
+    void main()
+    {
+    	vec4 weights = vec4(1.0, 0.0, 0.0, 0.0);
+    	if (MATERIAL_TEX_0_VALID > 0.0) {
+    		weights = texture(MATERIAL_TEX_0, vs_splat_uv);
+    	}
+    	float total = weights.r + weights.g + weights.b + weights.a;
+    	if (total > 0.0) {
+    		weights /= total;
+    	}
+
+    	vec3 color = vec3(0.0);
+    	color += weights.r * mix(vec3(1.0), texture(MATERIAL_TEX_1, vs_layer_uv).rgb, MATERIAL_TEX_1_VALID);
+    	color += weights.g * mix(vec3(1.0), texture(MATERIAL_TEX_2, vs_layer_uv).rgb, MATERIAL_TEX_2_VALID);
+    	color += weights.b * mix(vec3(1.0), texture(MATERIAL_TEX_3, vs_layer_uv).rgb, MATERIAL_TEX_3_VALID);
+    	color += weights.a * mix(vec3(1.0), texture(MATERIAL_TEX_4, vs_layer_uv).rgb, MATERIAL_TEX_4_VALID);
+    	color *= MATERIAL_DIFFUSE.rgb;
+
+    	frag_color = vec4(CalcLights(vs_position_model, normalize(vs_normal_model), color), 1.0);
+    }
+    `
+)
+
+// CreateSplatShader creates the shader that blends up to four layer
+// textures across the terrain by the weights in a splat map.
+func CreateSplatShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(SplatShaderV330, SplatShaderF330, nil)
+}
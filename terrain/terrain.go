@@ -0,0 +1,304 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package terrain builds renderable terrain from height maps.
+
+The height map gets split into square chunks that are each a child
+Renderable of the terrain's group Renderable so that they get culled
+and drawn like any other object. Every chunk has index buffers for a few
+levels of detail that skip more and more of the height samples
+(geo-mipmapping) and Update picks a level for each chunk based on the
+distance to the camera. Chunks are surrounded by skirts hanging down from
+their edges which hide the cracks between neighbors at different levels.
+
+A simple example:
+
+	heightImg, _ := os.Open("heightmap.png")
+	img, _, _ := image.Decode(heightImg)
+	t, err := terrain.NewTerrainFromImage(img, 1.0, 64.0, 32)
+	...
+	t.Renderable.Material.Shader, _ = terrain.CreateSplatShader()
+	t.Renderable.Material.CustomTex[0] = splatTex
+	t.Renderable.Material.CustomTex[1] = grassTex
+	...
+	// every frame
+	t.Update(camera.GetPosition())
+	renderer.DrawRenderable(t.Renderable, nil, perspective, view, camera)
+
+	// gameplay can ask for the ground height
+	playerPos[1] = t.GetHeightAt(playerPos[0], playerPos[2])
+
+*/
+package terrain
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// DefaultLODDistance is the default distance from the camera where
+	// chunks start to drop to lower levels of detail.
+	DefaultLODDistance = 64.0
+
+	// DefaultSkirtDepth is the default distance that the chunk skirts hang
+	// below the edges of the chunks.
+	DefaultSkirtDepth = 2.0
+)
+
+// Terrain is a height map split into chunks that can be drawn with varying
+// levels of detail.
+type Terrain struct {
+	// Renderable is the group that has the chunks as its children. Its
+	// Location places the terrain in the world and its Material is shared
+	// by all of the chunks. The terrain should not be rotated or scaled
+	// because GetHeightAt only accounts for the Location; use CellSize and
+	// HeightScale to size it instead.
+	Renderable *fizzle.Renderable
+
+	// Chunks are the pieces of the terrain in rows along the Z axis.
+	Chunks []*Chunk
+
+	// Width and Depth are the number of height samples along the X and Z axes.
+	Width int
+	Depth int
+
+	// Heights are the height samples in rows along the Z axis, already
+	// multiplied by HeightScale.
+	Heights []float32
+
+	// Normals are the normals at each height sample.
+	Normals []mgl.Vec3
+
+	// CellSize is the distance between height samples on the X and Z axes.
+	CellSize float32
+
+	// HeightScale is the height of a sample of 1.0.
+	HeightScale float32
+
+	// ChunkSize is the number of cells along each side of a chunk.
+	ChunkSize int
+
+	// LODDistance is the distance from the camera that the chunks are drawn
+	// at full detail. Each doubling of the distance after that drops one
+	// level of detail.
+	LODDistance float32
+
+	// SkirtDepth is how far the skirts hang below the chunk edges. It
+	// should be more than the biggest height difference that a crack can
+	// show.
+	SkirtDepth float32
+}
+
+// NewTerrain creates a terrain from a grid of width by depth height samples
+// in rows along the Z axis. The heights get multiplied by heightScale and
+// the samples are spaced cellSize apart. chunkSize must be a power of two
+// and both width-1 and depth-1 have to be multiples of it, so a 257x257
+// height map works with chunks of 32 cells.
+func NewTerrain(heights []float32, width, depth int, cellSize, heightScale float32, chunkSize int) (*Terrain, error) {
+	if chunkSize < 1 || chunkSize&(chunkSize-1) != 0 {
+		return nil, fmt.Errorf("Failed to create the terrain. The chunk size of %d is not a power of two.", chunkSize)
+	}
+	if width < 2 || depth < 2 || (width-1)%chunkSize != 0 || (depth-1)%chunkSize != 0 {
+		return nil, fmt.Errorf("Failed to create the terrain. A %dx%d height map can't be split into chunks of %d cells.", width, depth, chunkSize)
+	}
+	if len(heights) != width*depth {
+		return nil, fmt.Errorf("Failed to create the terrain. Expected %d height samples but got %d.", width*depth, len(heights))
+	}
+
+	t := new(Terrain)
+	t.Width = width
+	t.Depth = depth
+	t.CellSize = cellSize
+	t.HeightScale = heightScale
+	t.ChunkSize = chunkSize
+	t.LODDistance = DefaultLODDistance
+	t.SkirtDepth = DefaultSkirtDepth
+	t.Heights = make([]float32, len(heights))
+	for i, h := range heights {
+		t.Heights[i] = h * heightScale
+	}
+	t.generateNormals()
+
+	t.Renderable = fizzle.NewRenderable()
+	t.Renderable.IsGroup = true
+	t.Renderable.Material = fizzle.NewMaterial()
+	t.Renderable.BoundingRect = t.getBoundingRect(0, 0, width-1, depth-1)
+
+	for cz := 0; cz < (depth-1)/chunkSize; cz++ {
+		for cx := 0; cx < (width-1)/chunkSize; cx++ {
+			c := newChunk(t, cx*chunkSize, cz*chunkSize)
+			t.Chunks = append(t.Chunks, c)
+			t.Renderable.AddChild(c.Renderable)
+		}
+	}
+
+	return t, nil
+}
+
+// NewTerrainFromImage creates a terrain using the brightness of the image's
+// pixels as the heights, with black at 0 and white at heightScale. The top
+// row of the image is at Z = 0 and the left column is at X = 0. See
+// NewTerrain for the size requirements.
+func NewTerrainFromImage(img image.Image, cellSize, heightScale float32, chunkSize int) (*Terrain, error) {
+	b := img.Bounds()
+	heights := make([]float32, b.Dx()*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			gray := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray16)
+			heights[y*b.Dx()+x] = float32(gray.Y) / 65535.0
+		}
+	}
+	return NewTerrain(heights, b.Dx(), b.Dy(), cellSize, heightScale, chunkSize)
+}
+
+// Destroy releases the OpenGL objects of all of the chunks.
+func (t *Terrain) Destroy() {
+	for _, c := range t.Chunks {
+		c.destroy()
+	}
+}
+
+// Update picks the level of detail for each chunk from the distance between
+// the camera and the closest point of the chunk's bounding box.
+func (t *Terrain) Update(cameraPos mgl.Vec3) {
+	for _, c := range t.Chunks {
+		min := c.Renderable.BoundingRect.Bottom.Add(c.Renderable.Location).Add(t.Renderable.Location)
+		max := c.Renderable.BoundingRect.Top.Add(c.Renderable.Location).Add(t.Renderable.Location)
+		var dist2 float32
+		for i := 0; i < 3; i++ {
+			if cameraPos[i] < min[i] {
+				dist2 += (min[i] - cameraPos[i]) * (min[i] - cameraPos[i])
+			} else if cameraPos[i] > max[i] {
+				dist2 += (cameraPos[i] - max[i]) * (cameraPos[i] - max[i])
+			}
+		}
+		dist := float32(math.Sqrt(float64(dist2)))
+
+		lod := 0
+		lodDist := t.LODDistance
+		for lod < len(c.lodElements)-1 && dist > lodDist {
+			lod++
+			lodDist *= 2.0
+		}
+		c.SetLOD(lod)
+	}
+}
+
+// GetHeightAt returns the height of the terrain surface in world space at
+// the world space X and Z coordinates. The height follows the triangles
+// drawn at the full level of detail. Points off of the terrain get the
+// height of the closest edge.
+func (t *Terrain) GetHeightAt(x, z float32) float32 {
+	cx, cz, fx, fz := t.getCell(x, z)
+	h00 := t.getHeight(cx, cz)
+	h10 := t.getHeight(cx+1, cz)
+	h01 := t.getHeight(cx, cz+1)
+	h11 := t.getHeight(cx+1, cz+1)
+
+	// cells are split along the diagonal from (1,0) to (0,1)
+	var h float32
+	if fx+fz <= 1.0 {
+		h = h00 + (h10-h00)*fx + (h01-h00)*fz
+	} else {
+		h = h11 + (h01-h11)*(1.0-fx) + (h10-h11)*(1.0-fz)
+	}
+	return h + t.Renderable.Location[1]
+}
+
+// GetNormalAt returns the surface normal of the terrain at the world space
+// X and Z coordinates, blended between the normals of the height samples.
+func (t *Terrain) GetNormalAt(x, z float32) mgl.Vec3 {
+	cx, cz, fx, fz := t.getCell(x, z)
+	n0 := t.getNormal(cx, cz).Mul(1.0 - fx).Add(t.getNormal(cx+1, cz).Mul(fx))
+	n1 := t.getNormal(cx, cz+1).Mul(1.0 - fx).Add(t.getNormal(cx+1, cz+1).Mul(fx))
+	return n0.Mul(1.0 - fz).Add(n1.Mul(fz)).Normalize()
+}
+
+// getCell returns the cell that the world space coordinates are over and
+// how far into the cell they are in the range of [0, 1].
+func (t *Terrain) getCell(x, z float32) (int, int, float32, float32) {
+	lx := (x - t.Renderable.Location[0]) / t.CellSize
+	lz := (z - t.Renderable.Location[2]) / t.CellSize
+	lx = mgl.Clamp(lx, 0.0, float32(t.Width-1))
+	lz = mgl.Clamp(lz, 0.0, float32(t.Depth-1))
+
+	cx := int(lx)
+	cz := int(lz)
+	if cx >= t.Width-1 {
+		cx = t.Width - 2
+	}
+	if cz >= t.Depth-1 {
+		cz = t.Depth - 2
+	}
+	return cx, cz, lx - float32(cx), lz - float32(cz)
+}
+
+// getHeight returns the scaled height sample with the coordinates clamped
+// to the height map.
+func (t *Terrain) getHeight(x, z int) float32 {
+	x = clampInt(x, t.Width-1)
+	z = clampInt(z, t.Depth-1)
+	return t.Heights[z*t.Width+x]
+}
+
+// getNormal returns the normal of the height sample with the coordinates
+// clamped to the height map.
+func (t *Terrain) getNormal(x, z int) mgl.Vec3 {
+	x = clampInt(x, t.Width-1)
+	z = clampInt(z, t.Depth-1)
+	return t.Normals[z*t.Width+x]
+}
+
+// generateNormals calculates the normal of each height sample from the
+// slope between its neighbors.
+func (t *Terrain) generateNormals() {
+	t.Normals = make([]mgl.Vec3, len(t.Heights))
+	for z := 0; z < t.Depth; z++ {
+		for x := 0; x < t.Width; x++ {
+			dx := (t.getHeight(x+1, z) - t.getHeight(x-1, z)) / (2.0 * t.CellSize)
+			dz := (t.getHeight(x, z+1) - t.getHeight(x, z-1)) / (2.0 * t.CellSize)
+			t.Normals[z*t.Width+x] = mgl.Vec3{-dx, 1.0, -dz}.Normalize()
+		}
+	}
+}
+
+// getBoundingRect returns the bounding box of the height samples in the
+// range relative to the first sample.
+func (t *Terrain) getBoundingRect(x0, z0, x1, z1 int) (rect fizzle.Rectangle3D) {
+	minH := float32(math.MaxFloat32)
+	maxH := float32(-math.MaxFloat32)
+	for z := z0; z <= z1; z++ {
+		for x := x0; x <= x1; x++ {
+			h := t.getHeight(x, z)
+			if h < minH {
+				minH = h
+			}
+			if h > maxH {
+				maxH = h
+			}
+		}
+	}
+
+	rect.Bottom = mgl.Vec3{0.0, minH - t.SkirtDepth, 0.0}
+	rect.Top = mgl.Vec3{float32(x1-x0) * t.CellSize, maxH, float32(z1-z0) * t.CellSize}
+	return rect
+}
+
+// clampInt clamps v to the range of [0, max].
+func clampInt(v, max int) int {
+	if v > max {
+		return max
+	}
+	if v < 0 {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,264 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package text draws text with signed distance field fonts.
+
+Glyphs of a TrueType font get baked into a texture atlas as signed
+distance fields, which stay crisp when the text is scaled up, rotated or
+viewed at an angle. A Text is a Renderable of quads for a string that can
+be drawn in the world with the regular perspective and view matrices or
+on the screen with GetScreenProjection and an identity view.
+
+A simple example:
+
+	font, err := text.LoadFont("assets/fonts/Roboto.ttf", 32, text.DefaultRunes)
+	...
+	shader, err := text.CreateSDFShader()
+	label := text.NewText(font, "Hello world", 24.0)
+	label.Renderable.Material.Shader = shader
+	label.Renderable.Location = mgl.Vec3{10, 10, 0}
+
+	// blending needs to be enabled for the soft edges
+	gfx.Enable(graphics.BLEND)
+	gfx.BlendFunc(graphics.SRC_ALPHA, graphics.ONE_MINUS_SRC_ALPHA)
+	renderer.DrawRenderable(label.Renderable, nil, text.GetScreenProjection(width, height), mgl.Ident4(), camera)
+
+*/
+package text
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/golang/freetype/truetype"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultRunes are the printable ASCII characters.
+	DefaultRunes = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+	// DefaultSpread is the distance in pixels of the atlas that the signed
+	// distance fields reach outside of the glyphs.
+	DefaultSpread = 4
+
+	// sdfOversample is how many times larger the glyphs are rendered before
+	// creating the distance fields.
+	sdfOversample = 4
+
+	// maxAtlasSize is the largest texture the glyphs get packed into.
+	maxAtlasSize = 4096
+)
+
+// Glyph is the placement of a character in the font atlas.
+type Glyph struct {
+	// Advance is how far the pen moves after the glyph in pixels of the
+	// font size.
+	Advance float32
+
+	// Min and Max are the corners of the glyph's quad relative to the pen
+	// on the baseline with Y going up, in pixels of the font size. They
+	// include the spread of the distance field.
+	Min mgl.Vec2
+	Max mgl.Vec2
+
+	// UV is the area of the atlas for the glyph as (u0, v0, u1, v1) where
+	// v0 is the top of the glyph.
+	UV mgl.Vec4
+}
+
+// Font is a TrueType font baked into a signed distance field atlas.
+type Font struct {
+	// Texture is the atlas with the distance fields of the glyphs.
+	Texture graphics.Texture
+
+	// AtlasSize is the width and height of the atlas texture.
+	AtlasSize int32
+
+	// PixelSize is the size the glyphs were baked at. Text sizes are
+	// relative to it.
+	PixelSize int
+
+	// Spread is the reach of the distance fields in pixels of the atlas.
+	Spread int
+
+	// Ascent, Descent and LineHeight are the metrics of the font in pixels
+	// of the font size.
+	Ascent     float32
+	Descent    float32
+	LineHeight float32
+
+	// Glyphs are the baked characters of the font.
+	Glyphs map[rune]*Glyph
+
+	face font.Face
+}
+
+// bakedGlyph is the distance field of a glyph waiting to be packed.
+type bakedGlyph struct {
+	glyph  *Glyph
+	sdf    []uint8
+	width  int
+	height int
+}
+
+// LoadFont loads a TrueType font file and bakes the runes into a new font
+// atlas at the pixel size given.
+func LoadFont(filePath string, pixelSize int, runes string) (*Font, error) {
+	ttfBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the font file %s: %v", filePath, err)
+	}
+	return NewFont(ttfBytes, pixelSize, runes)
+}
+
+// NewFont bakes the runes of the TrueType font data into a new font atlas
+// at the pixel size given. Runes the font doesn't have are skipped.
+func NewFont(ttfBytes []byte, pixelSize int, runes string) (*Font, error) {
+	ttf, err := truetype.Parse(ttfBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the font: %v", err)
+	}
+
+	f := new(Font)
+	f.PixelSize = pixelSize
+	f.Spread = DefaultSpread
+	f.Glyphs = make(map[rune]*Glyph)
+	f.face = truetype.NewFace(ttf, &truetype.Options{Size: float64(pixelSize), DPI: 72, Hinting: font.HintingNone})
+	metrics := f.face.Metrics()
+	f.Ascent = fixedToFloat(metrics.Ascent)
+	f.Descent = fixedToFloat(metrics.Descent)
+	f.LineHeight = fixedToFloat(metrics.Height)
+
+	// render the glyphs larger so that the distance fields are accurate
+	bigFace := truetype.NewFace(ttf, &truetype.Options{Size: float64(pixelSize * sdfOversample), DPI: 72, Hinting: font.HintingNone})
+	var baked []*bakedGlyph
+	for _, r := range runes {
+		if _, done := f.Glyphs[r]; done || ttf.Index(r) == 0 {
+			continue
+		}
+		b, okay := f.bakeGlyph(bigFace, r)
+		if !okay {
+			continue
+		}
+		f.Glyphs[r] = b.glyph
+		if b.sdf != nil {
+			baked = append(baked, b)
+		}
+	}
+
+	// find the smallest atlas that fits everything
+	var atlasSize int
+	for atlasSize = 128; atlasSize <= maxAtlasSize; atlasSize *= 2 {
+		if packGlyphs(baked, atlasSize) {
+			break
+		}
+	}
+	if atlasSize > maxAtlasSize {
+		return nil, fmt.Errorf("Failed to fit the glyphs into a %dx%d atlas.", maxAtlasSize, maxAtlasSize)
+	}
+
+	// copy the distance fields into the atlas and set the UVs
+	atlas := make([]byte, atlasSize*atlasSize*4)
+	for _, b := range baked {
+		x0 := int(b.glyph.UV[0])
+		y0 := int(b.glyph.UV[1])
+		for y := 0; y < b.height; y++ {
+			for x := 0; x < b.width; x++ {
+				v := b.sdf[y*b.width+x]
+				i := ((y0+y)*atlasSize + x0 + x) * 4
+				atlas[i], atlas[i+1], atlas[i+2], atlas[i+3] = v, v, v, v
+			}
+		}
+		size := float32(atlasSize)
+		b.glyph.UV = mgl.Vec4{float32(x0) / size, float32(y0) / size, float32(x0+b.width) / size, float32(y0+b.height) / size}
+	}
+
+	f.AtlasSize = int32(atlasSize)
+	f.Texture = fizzle.LoadRGBAToTextureExt(atlas, f.AtlasSize, graphics.LINEAR, graphics.LINEAR, graphics.CLAMP_TO_EDGE, graphics.CLAMP_TO_EDGE)
+	return f, nil
+}
+
+// Destroy releases the atlas texture of the font.
+func (f *Font) Destroy() {
+	fizzle.GetGraphics().DeleteTexture(f.Texture)
+}
+
+// GetKerning returns the adjustment to the advance between two runes in
+// pixels of the font size.
+func (f *Font) GetKerning(r0, r1 rune) float32 {
+	return fixedToFloat(f.face.Kern(r0, r1))
+}
+
+// bakeGlyph renders the rune with the oversampled face and creates its
+// distance field. Glyphs without any pixels, such as a space, only get
+// their advance set.
+func (f *Font) bakeGlyph(bigFace font.Face, r rune) (*bakedGlyph, bool) {
+	dr, mask, maskp, advance, okay := bigFace.Glyph(fixed.Point26_6{}, r)
+	if !okay {
+		return nil, false
+	}
+
+	b := new(bakedGlyph)
+	b.glyph = new(Glyph)
+	b.glyph.Advance = fixedToFloat(advance) / sdfOversample
+	if dr.Empty() {
+		return b, true
+	}
+
+	// pad the glyph so the distance field has room to fall off
+	pad := f.Spread * sdfOversample
+	width := dr.Dx() + pad*2
+	height := dr.Dy() + pad*2
+	inside := make([]bool, width*height)
+	for y := 0; y < dr.Dy(); y++ {
+		for x := 0; x < dr.Dx(); x++ {
+			_, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA()
+			inside[(y+pad)*width+x+pad] = a >= 0x8000
+		}
+	}
+	b.sdf, b.width, b.height = createSDF(inside, width, height, sdfOversample, f.Spread)
+
+	// flip the glyph bounds so that Y goes up from the baseline
+	left := float32(dr.Min.X-pad) / sdfOversample
+	top := float32(-(dr.Min.Y - pad)) / sdfOversample
+	b.glyph.Min = mgl.Vec2{left, top - float32(b.height)}
+	b.glyph.Max = mgl.Vec2{left + float32(b.width), top}
+	return b, true
+}
+
+// packGlyphs places the glyphs in rows of an atlas of the size given,
+// storing the pixel position in the first two UV components. False is
+// returned if they don't fit.
+func packGlyphs(baked []*bakedGlyph, atlasSize int) bool {
+	const gap = 1
+	x, y, rowHeight := gap, gap, 0
+	for _, b := range baked {
+		if x+b.width+gap > atlasSize {
+			x = gap
+			y += rowHeight + gap
+			rowHeight = 0
+		}
+		if b.width+gap*2 > atlasSize || y+b.height+gap > atlasSize {
+			return false
+		}
+		b.glyph.UV[0] = float32(x)
+		b.glyph.UV[1] = float32(y)
+		x += b.width + gap
+		if b.height > rowHeight {
+			rowHeight = b.height
+		}
+	}
+	return true
+}
+
+// fixedToFloat converts a 26.6 fixed point number to a float.
+func fixedToFloat(v fixed.Int26_6) float32 {
+	return float32(v) / 64.0
+}
@@ -0,0 +1,114 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package text
+
+import (
+	"math"
+)
+
+// edtInfinity is the squared distance used for pixels with nothing to
+// measure to.
+const edtInfinity = 1e20
+
+// createSDF turns the coverage of a glyph rendered at scale times the final
+// size into a signed distance field at the final size. Every output pixel
+// stores 0.5 on the outline going up to 1.0 at spread pixels inside the
+// glyph and down to 0.0 at spread pixels outside of it.
+func createSDF(inside []bool, width, height, scale, spread int) ([]uint8, int, int) {
+	toInside := make([]float64, width*height)
+	toOutside := make([]float64, width*height)
+	for i, in := range inside {
+		if in {
+			toInside[i] = 0.0
+			toOutside[i] = edtInfinity
+		} else {
+			toInside[i] = edtInfinity
+			toOutside[i] = 0.0
+		}
+	}
+	distanceTransform(toInside, width, height)
+	distanceTransform(toOutside, width, height)
+
+	outWidth := (width + scale - 1) / scale
+	outHeight := (height + scale - 1) / scale
+	sdf := make([]uint8, outWidth*outHeight)
+	for oy := 0; oy < outHeight; oy++ {
+		for ox := 0; ox < outWidth; ox++ {
+			x := ox*scale + scale/2
+			y := oy*scale + scale/2
+			if x >= width {
+				x = width - 1
+			}
+			if y >= height {
+				y = height - 1
+			}
+			i := y*width + x
+
+			// positive outside of the glyph, in output pixels
+			dist := (math.Sqrt(toInside[i]) - math.Sqrt(toOutside[i])) / float64(scale)
+			v := 0.5 - dist/float64(2*spread)
+			sdf[oy*outWidth+ox] = uint8(math.Max(0.0, math.Min(1.0, v))*255.0 + 0.5)
+		}
+	}
+	return sdf, outWidth, outHeight
+}
+
+// distanceTransform replaces the grid of zeros and edtInfinity values with
+// the squared distance of each pixel to the closest zero using the two pass
+// algorithm from Felzenszwalb and Huttenlocher's "Distance Transforms of
+// Sampled Functions".
+func distanceTransform(grid []float64, width, height int) {
+	size := width
+	if height > size {
+		size = height
+	}
+	f := make([]float64, size)
+	d := make([]float64, size)
+	v := make([]int, size)
+	z := make([]float64, size+1)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			f[y] = grid[y*width+x]
+		}
+		distanceTransform1D(f[:height], d, v, z)
+		for y := 0; y < height; y++ {
+			grid[y*width+x] = d[y]
+		}
+	}
+	for y := 0; y < height; y++ {
+		copy(f, grid[y*width:(y+1)*width])
+		distanceTransform1D(f[:width], d, v, z)
+		copy(grid[y*width:(y+1)*width], d[:width])
+	}
+}
+
+// distanceTransform1D computes the squared distance transform of f into d
+// using the lower envelope of parabolas. v and z are scratch space.
+func distanceTransform1D(f []float64, d []float64, v []int, z []float64) {
+	n := len(f)
+	k := 0
+	v[0] = 0
+	z[0] = -edtInfinity
+	z[1] = edtInfinity
+	for q := 1; q < n; q++ {
+		s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		for s <= z[k] {
+			k--
+			s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = edtInfinity
+	}
+
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package text
+
+import (
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// SDFShaderV330 is the vertex shader for signed distance field text.
+	SDFShaderV330 = `#version 330
+    precision highp float;
+
+    uniform mat4 MVP_MATRIX;
+
+    in vec3 VERTEX_POSITION;
+    in vec2 VERTEX_UV_0;
+
+    out vec2 vs_tex0_uv;
+
+    void main(void) {
+    	gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+    	vs_tex0_uv = VERTEX_UV_0;
+    }
+    `
+
+	// SDFShaderF330 is the fragment shader for signed distance field text.
+	// The distance field is in MATERIAL_TEX_0 and the edge gets smoothed over
+	// about a pixel on screen no matter how large the text is drawn. If the
+	// material's emissive color has any alpha it is used as an outline.
+	SDFShaderF330 = `#version 330
+    precision highp float;
+
+    uniform sampler2D MATERIAL_TEX_0;
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform vec4 MATERIAL_EMISSIVE;
+
+    in vec2 vs_tex0_uv;
+    out vec4 frag_color;
+
+    void main (void) {
+    	float dist = texture(MATERIAL_TEX_0, vs_tex0_uv).r;
+    	float width = max(fwidth(dist) * 0.75, 0.001);
+    	float alpha = smoothstep(0.5 - width, 0.5 + width, dist);
+
+    	vec4 color = vec4(MATERIAL_DIFFUSE.rgb, MATERIAL_DIFFUSE.a * alpha);
+    	if (MATERIAL_EMISSIVE.a > 0.0) {
+    		float outline = smoothstep(0.35 - width, 0.35 + width, dist);
+    		color.rgb = mix(MATERIAL_EMISSIVE.rgb, MATERIAL_DIFFUSE.rgb, alpha);
+    		color.a = max(color.a, MATERIAL_EMISSIVE.a * outline);
+    	}
+
+    	if (color.a < 0.01) {
+    		discard;
+    	}
+    	frag_color = color;
+    }
+    `
+)
+
+// CreateSDFShader creates the shader that draws Text.
+func CreateSDFShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(SDFShaderV330, SDFShaderF330, nil)
+}
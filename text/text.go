@@ -0,0 +1,141 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package text
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// Text is a string drawn as a Renderable of glyph quads on the XY plane
+// with the pen starting at the origin on the baseline of the first line.
+type Text struct {
+	// Renderable draws the text. Its Material has the font atlas as
+	// CustomTex[0] and its DiffuseColor is the color of the text.
+	Renderable *fizzle.Renderable
+
+	// Font is the font the glyphs come from.
+	Font *Font
+
+	// Size is the height of the font in world units, or pixels for text
+	// drawn with GetScreenProjection.
+	Size float32
+
+	// Width and Height are the size of the text's lines in the same units.
+	Width  float32
+	Height float32
+
+	str string
+}
+
+// NewText creates a new Text for the string in the font at the size given.
+func NewText(f *Font, str string, size float32) *Text {
+	t := new(Text)
+	t.Font = f
+	t.Size = size
+	t.Renderable = fizzle.NewRenderable()
+	t.Renderable.Material = fizzle.NewMaterial()
+	t.Renderable.Material.CustomTex[0] = f.Texture
+
+	gfx := fizzle.GetGraphics()
+	t.Renderable.Core.VertVBO = gfx.GenBuffer()
+	t.Renderable.Core.UvVBO = t.Renderable.Core.VertVBO
+	t.Renderable.Core.ElementsVBO = gfx.GenBuffer()
+	t.SetString(str)
+	return t
+}
+
+// GetString returns the string the Text draws.
+func (t *Text) GetString() string {
+	return t.str
+}
+
+// SetString changes the string the Text draws and rebuilds its quads.
+// Newlines start a new line below the last one.
+func (t *Text) SetString(str string) {
+	const floatSize = 4
+	const uintSize = 4
+
+	t.str = str
+	scale := t.Size / float32(t.Font.PixelSize)
+
+	verts := make([]float32, 0, len(str)*4*5)
+	indexes := make([]uint32, 0, len(str)*6)
+	var penX, penY float32
+	var prev rune = -1
+	lines := 1
+	t.Width = 0.0
+	for _, r := range str {
+		if r == '\n' {
+			penX = 0.0
+			penY -= t.Font.LineHeight * scale
+			prev = -1
+			lines++
+			continue
+		}
+		glyph, okay := t.Font.Glyphs[r]
+		if !okay {
+			prev = -1
+			continue
+		}
+		if prev >= 0 {
+			penX += t.Font.GetKerning(prev, r) * scale
+		}
+		prev = r
+
+		if glyph.Max[0] > glyph.Min[0] {
+			x0 := penX + glyph.Min[0]*scale
+			y0 := penY + glyph.Min[1]*scale
+			x1 := penX + glyph.Max[0]*scale
+			y1 := penY + glyph.Max[1]*scale
+			uv := glyph.UV
+
+			// vert / uv with the top of the glyph at v0 in the atlas
+			base := uint32(len(verts) / 5)
+			verts = append(verts,
+				x0, y0, 0.0, uv[0], uv[3],
+				x1, y0, 0.0, uv[2], uv[3],
+				x0, y1, 0.0, uv[0], uv[1],
+				x1, y1, 0.0, uv[2], uv[1],
+			)
+			indexes = append(indexes, base, base+1, base+2, base+1, base+3, base+2)
+		}
+
+		penX += glyph.Advance * scale
+		if penX > t.Width {
+			t.Width = penX
+		}
+	}
+	t.Height = t.Font.Ascent*scale + float32(lines-1)*t.Font.LineHeight*scale + t.Font.Descent*scale
+
+	r := t.Renderable
+	r.FaceCount = uint32(len(indexes) / 3)
+	r.BoundingRect.Bottom = mgl.Vec3{0.0, t.Font.Ascent*scale - t.Height, 0.0}
+	r.BoundingRect.Top = mgl.Vec3{t.Width, t.Font.Ascent * scale, 0.0}
+	if len(indexes) == 0 {
+		return
+	}
+
+	gfx := fizzle.GetGraphics()
+	r.Core.VertVBOOffset = 0
+	r.Core.UvVBOOffset = floatSize * 3
+	r.Core.VBOStride = floatSize * (3 + 2) // vert / uv
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(verts), gfx.Ptr(&verts[0]), graphics.STATIC_DRAW)
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	gfx.BufferData(graphics.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gfx.Ptr(&indexes[0]), graphics.STATIC_DRAW)
+}
+
+// Destroy releases the OpenGL objects of the text but not the font.
+func (t *Text) Destroy() {
+	t.Renderable.Destroy()
+}
+
+// GetScreenProjection returns an orthographic projection for drawing text
+// in screen space, in pixels with the origin at the bottom left of the
+// screen. Use it with an identity view matrix.
+func GetScreenProjection(width, height int32) mgl.Mat4 {
+	return mgl.Ortho(0.0, float32(width), 0.0, float32(height), -1.0, 1.0)
+}
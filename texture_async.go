@@ -0,0 +1,138 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"image"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// asyncTexture is a texture decoded off of the OpenGL thread that is
+// waiting to be uploaded.
+type asyncTexture struct {
+	key       string
+	tex       graphics.Texture
+	img       *image.NRGBA
+	container *TextureContainer
+	hdr       *HDRImage
+	err       error
+
+	// reload is true for textures being reloaded by hot reloading, which
+	// are not counted as pending.
+	reload bool
+}
+
+// LoadTextureAsync returns a placeholder texture right away and decodes the
+// image file on another goroutine. The image replaces the placeholder in
+// the same OpenGL texture object when UpdateAsync is called after it has
+// been decoded, so the returned texture can be assigned to materials
+// immediately. This must be called on the OpenGL thread.
+func (tm *TextureManager) LoadTextureAsync(keyToUse string, path string) graphics.Texture {
+	tex := tm.createPlaceholderTexture()
+	tm.store(keyToUse, tex, 2*2*4)
+	tm.watchFile(keyToUse, path)
+	tm.asyncPending++
+	tm.decodeAsync(&asyncTexture{key: keyToUse, tex: tex}, path)
+	return tex
+}
+
+// decodeAsync decodes the image file on another goroutine and queues the
+// result for UpdateAsync to upload.
+func (tm *TextureManager) decodeAsync(result *asyncTexture, path string) {
+	go func() {
+		tm.asyncWorkers <- true
+		if IsTextureContainerFile(path) {
+			result.container, result.err = LoadTextureContainer(path)
+		} else if IsHDRFile(path) {
+			result.hdr, result.err = LoadHDRFile(path)
+		} else {
+			result.img, result.err = loadFile(path)
+		}
+		<-tm.asyncWorkers
+
+		tm.asyncMutex.Lock()
+		tm.asyncResults = append(tm.asyncResults, result)
+		tm.asyncMutex.Unlock()
+	}()
+}
+
+// UpdateAsync uploads the textures that have finished decoding since the
+// last call, up to AsyncUploadsPerFrame of them. With hot reloading
+// enabled it also checks for changed texture files and uploads the ones
+// that were reloaded. It should be called once per frame on the OpenGL
+// thread and returns the number of textures still being loaded.
+func (tm *TextureManager) UpdateAsync() int {
+	tm.checkHotReload()
+
+	tm.asyncMutex.Lock()
+	ready := tm.asyncResults
+	if tm.AsyncUploadsPerFrame > 0 && len(ready) > tm.AsyncUploadsPerFrame {
+		ready = ready[:tm.AsyncUploadsPerFrame]
+	}
+	tm.asyncResults = append([]*asyncTexture(nil), tm.asyncResults[len(ready):]...)
+	tm.asyncMutex.Unlock()
+
+	for _, result := range ready {
+		if result.reload {
+			tm.finishHotReload(result)
+			continue
+		}
+		tm.asyncPending--
+
+		// skip textures that were unloaded or replaced while decoding
+		if glTexture, okay := tm.storage[result.key]; !okay || glTexture != result.tex {
+			continue
+		}
+
+		if result.err == nil {
+			if result.container != nil {
+				uploadTextureContainer(result.tex, result.container)
+				tm.byteSizes[result.key] = result.container.DataSize()
+			} else if result.hdr != nil {
+				uploadHDR(result.tex, result.hdr)
+				tm.byteSizes[result.key] = result.hdr.ByteSize()
+			} else {
+				uploadNRGBA(result.tex, result.img)
+				tm.byteSizes[result.key] = len(result.img.Pix)
+			}
+		}
+
+		if tm.OnAsyncLoaded != nil {
+			tm.OnAsyncLoaded(result.key, result.err)
+		}
+	}
+
+	return tm.asyncPending
+}
+
+// GetPendingAsync returns the number of textures from LoadTextureAsync that
+// have not been uploaded by UpdateAsync yet.
+func (tm *TextureManager) GetPendingAsync() int {
+	return tm.asyncPending
+}
+
+// createPlaceholderTexture creates a small checker texture with the
+// placeholder colors.
+func (tm *TextureManager) createPlaceholderTexture() graphics.Texture {
+	a, b := tm.PlaceholderColors[0], tm.PlaceholderColors[1]
+	pixels := make([]byte, 0, 2*2*4)
+	pixels = append(pixels, a[:]...)
+	pixels = append(pixels, b[:]...)
+	pixels = append(pixels, b[:]...)
+	pixels = append(pixels, a[:]...)
+	return LoadRGBAToTextureExt(pixels, 2, graphics.NEAREST, graphics.NEAREST, graphics.REPEAT, graphics.REPEAT)
+}
+
+// uploadNRGBA replaces the image of an OpenGL texture with the image.
+func uploadNRGBA(tex graphics.Texture, img *image.NRGBA) {
+	w := int32(img.Bounds().Dx())
+	h := int32(img.Bounds().Dy())
+
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, w, h, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(img.Pix), len(img.Pix))
+}
@@ -0,0 +1,422 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+var (
+	ktxIdentifier  = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ktx2Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ddsMagic       = []byte{'D', 'D', 'S', ' '}
+)
+
+// TextureLevel is one mipmap level of a texture in a TextureContainer.
+type TextureLevel struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+// TextureContainer is a 2D texture read from a KTX, KTX2 or DDS file, which
+// can hold compressed data and a pre-generated mip chain so that nothing
+// has to be decoded or generated at runtime.
+//
+// NOTE: the images are not flipped like PNG files are when loaded, so they
+// should be exported with the bottom row of the image first.
+type TextureContainer struct {
+	// InternalFormat is the OpenGL internal format of the texture data.
+	InternalFormat graphics.Enum
+
+	// Format and Type are the OpenGL pixel format and type for
+	// uncompressed textures.
+	Format graphics.Enum
+	Type   graphics.Enum
+
+	// Compressed is true if the data is block compressed and should be
+	// uploaded with CompressedTexImage2D.
+	Compressed bool
+
+	// Levels are the mipmap levels starting with the full size image.
+	Levels []TextureLevel
+}
+
+// DataSize returns the number of bytes of texture data in all of the levels.
+func (tc *TextureContainer) DataSize() int {
+	size := 0
+	for _, level := range tc.Levels {
+		size += len(level.Data)
+	}
+	return size
+}
+
+// IsTextureContainerFile returns true if the file extension is one of the
+// texture container types that LoadTextureContainer can read.
+func IsTextureContainerFile(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ktx", ".ktx2", ".dds":
+		return true
+	}
+	return false
+}
+
+// LoadTextureContainer reads a KTX, KTX2 or DDS file.
+func LoadTextureContainer(filePath string) (*TextureContainer, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the texture file: %v\n", err)
+	}
+
+	tc, err := ParseTextureContainer(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the texture file %s. %v", filePath, err)
+	}
+	return tc, nil
+}
+
+// ParseTextureContainer reads a KTX, KTX2 or DDS file from a byte slice,
+// detecting the type of container by the identifier at the start of the data.
+func ParseTextureContainer(data []byte) (*TextureContainer, error) {
+	switch {
+	case bytes.HasPrefix(data, ktxIdentifier):
+		return ParseKTX(data)
+	case bytes.HasPrefix(data, ktx2Identifier):
+		return ParseKTX2(data)
+	case bytes.HasPrefix(data, ddsMagic):
+		return ParseDDS(data)
+	}
+	return nil, fmt.Errorf("Unknown texture container type.")
+}
+
+// LoadTextureContainerToTexture uploads all of the mipmap levels of the
+// texture container to a new OpenGL texture.
+func LoadTextureContainerToTexture(tc *TextureContainer) graphics.Texture {
+	tex := gfx.GenTexture()
+	uploadTextureContainer(tex, tc)
+	return tex
+}
+
+// uploadTextureContainer replaces the images of an OpenGL texture with all of
+// the mipmap levels of the texture container.
+func uploadTextureContainer(tex graphics.Texture, tc *TextureContainer) {
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	if len(tc.Levels) > 1 {
+		gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR_MIPMAP_LINEAR)
+	} else {
+		gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	}
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.REPEAT)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.REPEAT)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAX_LEVEL, int32(len(tc.Levels)-1))
+
+	for i, level := range tc.Levels {
+		if tc.Compressed {
+			gfx.CompressedTexImage2D(graphics.TEXTURE_2D, int32(i), tc.InternalFormat, level.Width, level.Height, 0, int32(len(level.Data)), gfx.Ptr(level.Data))
+		} else {
+			gfx.TexImage2D(graphics.TEXTURE_2D, int32(i), int32(tc.InternalFormat), level.Width, level.Height, 0, tc.Format, tc.Type, gfx.Ptr(level.Data), len(level.Data))
+		}
+	}
+}
+
+// LoadTextureContainerFileToTexture reads a KTX, KTX2 or DDS file into a
+// new OpenGL texture.
+func LoadTextureContainerFileToTexture(filePath string) (graphics.Texture, error) {
+	tc, err := LoadTextureContainer(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return LoadTextureContainerToTexture(tc), nil
+}
+
+// ParseKTX reads a KTX 1.1 file. Only 2D textures are supported; arrays,
+// cube maps and 3D textures will return an error.
+func ParseKTX(data []byte) (*TextureContainer, error) {
+	const headerSize = 64
+	if len(data) < headerSize || !bytes.HasPrefix(data, ktxIdentifier) {
+		return nil, fmt.Errorf("The data is not a KTX file.")
+	}
+
+	// the endianness field is written in the byte order of the file
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(data[12:]) != 0x04030201 {
+		order = binary.BigEndian
+	}
+
+	var header [13]uint32
+	for i := range header {
+		header[i] = order.Uint32(data[16+i*4:])
+	}
+	glType, glFormat, glInternalFormat := header[0], header[2], header[3]
+	width, height, depth := header[5], header[6], header[7]
+	arrayElements, faces, levels := header[8], header[9], header[10]
+	keyValueBytes := header[11]
+
+	if depth > 0 || arrayElements > 0 || faces != 1 || height == 0 {
+		return nil, fmt.Errorf("Only 2D KTX textures are supported.")
+	}
+	if levels == 0 {
+		levels = 1
+	}
+
+	tc := new(TextureContainer)
+	tc.InternalFormat = graphics.Enum(glInternalFormat)
+	tc.Format = graphics.Enum(glFormat)
+	tc.Type = graphics.Enum(glType)
+	tc.Compressed = glType == 0
+
+	offset := headerSize + int(keyValueBytes)
+	for i := uint32(0); i < levels; i++ {
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("The KTX file is missing mipmap level %d.", i)
+		}
+		imageSize := int(order.Uint32(data[offset:]))
+		offset += 4
+		if offset+imageSize > len(data) {
+			return nil, fmt.Errorf("The KTX file is missing data for mipmap level %d.", i)
+		}
+
+		tc.Levels = append(tc.Levels, TextureLevel{
+			Width:  mipSize(width, i),
+			Height: mipSize(height, i),
+			Data:   data[offset : offset+imageSize],
+		})
+
+		// each level is padded out to 4 bytes
+		offset += (imageSize + 3) &^ 3
+	}
+
+	return tc, nil
+}
+
+// containerFormat is the OpenGL equivalent of a format in a texture container.
+type containerFormat struct {
+	internalFormat graphics.Enum
+	format         graphics.Enum
+	compressed     bool
+}
+
+// ktx2Formats maps the supported Vulkan formats to OpenGL formats.
+var ktx2Formats = map[uint32]containerFormat{
+	23:  {graphics.RGB8, graphics.RGB, false},                     // VK_FORMAT_R8G8B8_UNORM
+	29:  {graphics.SRGB8, graphics.RGB, false},                    // VK_FORMAT_R8G8B8_SRGB
+	37:  {graphics.RGBA8, graphics.RGBA, false},                   // VK_FORMAT_R8G8B8A8_UNORM
+	43:  {graphics.SRGB8_ALPHA8, graphics.RGBA, false},            // VK_FORMAT_R8G8B8A8_SRGB
+	131: {graphics.COMPRESSED_RGB_S3TC_DXT1_EXT, 0, true},         // VK_FORMAT_BC1_RGB_UNORM_BLOCK
+	133: {graphics.COMPRESSED_RGBA_S3TC_DXT1_EXT, 0, true},        // VK_FORMAT_BC1_RGBA_UNORM_BLOCK
+	135: {graphics.COMPRESSED_RGBA_S3TC_DXT3_EXT, 0, true},        // VK_FORMAT_BC2_UNORM_BLOCK
+	137: {graphics.COMPRESSED_RGBA_S3TC_DXT5_EXT, 0, true},        // VK_FORMAT_BC3_UNORM_BLOCK
+	139: {graphics.COMPRESSED_RED_RGTC1, 0, true},                 // VK_FORMAT_BC4_UNORM_BLOCK
+	141: {graphics.COMPRESSED_RG_RGTC2, 0, true},                  // VK_FORMAT_BC5_UNORM_BLOCK
+	145: {graphics.COMPRESSED_RGBA_BPTC_UNORM_ARB, 0, true},       // VK_FORMAT_BC7_UNORM_BLOCK
+	146: {graphics.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB, 0, true}, // VK_FORMAT_BC7_SRGB_BLOCK
+	147: {graphics.COMPRESSED_RGB8_ETC2, 0, true},                 // VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK
+	151: {graphics.COMPRESSED_RGBA8_ETC2_EAC, 0, true},            // VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK
+	152: {graphics.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC, 0, true},     // VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK
+	157: {graphics.COMPRESSED_RGBA_ASTC_4x4_KHR, 0, true},         // VK_FORMAT_ASTC_4x4_UNORM_BLOCK
+}
+
+// ParseKTX2 reads a KTX 2.0 file. Only 2D textures without supercompression
+// in the formats listed in ktx2Formats are supported.
+func ParseKTX2(data []byte) (*TextureContainer, error) {
+	const headerSize = 80
+	if len(data) < headerSize || !bytes.HasPrefix(data, ktx2Identifier) {
+		return nil, fmt.Errorf("The data is not a KTX2 file.")
+	}
+
+	le := binary.LittleEndian
+	vkFormat := le.Uint32(data[12:])
+	width, height, depth := le.Uint32(data[20:]), le.Uint32(data[24:]), le.Uint32(data[28:])
+	layers, faces, levels := le.Uint32(data[32:]), le.Uint32(data[36:]), le.Uint32(data[40:])
+	supercompression := le.Uint32(data[44:])
+
+	if depth > 0 || layers > 0 || faces != 1 || height == 0 {
+		return nil, fmt.Errorf("Only 2D KTX2 textures are supported.")
+	}
+	if supercompression != 0 {
+		return nil, fmt.Errorf("Supercompressed KTX2 textures are not supported.")
+	}
+	format, okay := ktx2Formats[vkFormat]
+	if !okay {
+		return nil, fmt.Errorf("The KTX2 format %d is not supported.", vkFormat)
+	}
+	if levels == 0 {
+		levels = 1
+	}
+
+	tc := new(TextureContainer)
+	tc.InternalFormat = format.internalFormat
+	tc.Format = format.format
+	tc.Type = graphics.UNSIGNED_BYTE
+	tc.Compressed = format.compressed
+
+	// the level index follows the header with 24 bytes per level
+	if headerSize+int(levels)*24 > len(data) {
+		return nil, fmt.Errorf("The KTX2 file is missing the level index.")
+	}
+	for i := uint32(0); i < levels; i++ {
+		entry := data[headerSize+int(i)*24:]
+		offset, length := le.Uint64(entry), le.Uint64(entry[8:])
+		if offset+length > uint64(len(data)) {
+			return nil, fmt.Errorf("The KTX2 file is missing data for mipmap level %d.", i)
+		}
+
+		tc.Levels = append(tc.Levels, TextureLevel{
+			Width:  mipSize(width, i),
+			Height: mipSize(height, i),
+			Data:   data[offset : offset+length],
+		})
+	}
+
+	return tc, nil
+}
+
+const (
+	ddsHeaderSize      = 124
+	ddsHeaderDX10Size  = 20
+	ddsFlagMipMapCount = 0x20000
+	ddsPixelFourCC     = 0x4
+	ddsPixelRGB        = 0x40
+	ddsCaps2CubeMap    = 0x200
+	ddsCaps2Volume     = 0x200000
+)
+
+// ddsFourCCFormats maps the supported DDS FourCC codes to OpenGL formats.
+var ddsFourCCFormats = map[string]graphics.Enum{
+	"DXT1": graphics.COMPRESSED_RGBA_S3TC_DXT1_EXT,
+	"DXT3": graphics.COMPRESSED_RGBA_S3TC_DXT3_EXT,
+	"DXT5": graphics.COMPRESSED_RGBA_S3TC_DXT5_EXT,
+	"ATI1": graphics.COMPRESSED_RED_RGTC1,
+	"BC4U": graphics.COMPRESSED_RED_RGTC1,
+	"ATI2": graphics.COMPRESSED_RG_RGTC2,
+	"BC5U": graphics.COMPRESSED_RG_RGTC2,
+}
+
+// ddsDXGIFormats maps the supported DXGI formats of the DX10 header extension
+// to OpenGL formats.
+var ddsDXGIFormats = map[uint32]containerFormat{
+	28: {graphics.RGBA8, graphics.RGBA, false},                   // DXGI_FORMAT_R8G8B8A8_UNORM
+	29: {graphics.SRGB8_ALPHA8, graphics.RGBA, false},            // DXGI_FORMAT_R8G8B8A8_UNORM_SRGB
+	71: {graphics.COMPRESSED_RGBA_S3TC_DXT1_EXT, 0, true},        // DXGI_FORMAT_BC1_UNORM
+	74: {graphics.COMPRESSED_RGBA_S3TC_DXT3_EXT, 0, true},        // DXGI_FORMAT_BC2_UNORM
+	77: {graphics.COMPRESSED_RGBA_S3TC_DXT5_EXT, 0, true},        // DXGI_FORMAT_BC3_UNORM
+	80: {graphics.COMPRESSED_RED_RGTC1, 0, true},                 // DXGI_FORMAT_BC4_UNORM
+	83: {graphics.COMPRESSED_RG_RGTC2, 0, true},                  // DXGI_FORMAT_BC5_UNORM
+	98: {graphics.COMPRESSED_RGBA_BPTC_UNORM_ARB, 0, true},       // DXGI_FORMAT_BC7_UNORM
+	99: {graphics.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB, 0, true}, // DXGI_FORMAT_BC7_UNORM_SRGB
+}
+
+// ParseDDS reads a DDS file. Only 2D textures that are block compressed or
+// 32-bit RGBA/BGRA are supported.
+func ParseDDS(data []byte) (*TextureContainer, error) {
+	if len(data) < 4+ddsHeaderSize || !bytes.HasPrefix(data, ddsMagic) {
+		return nil, fmt.Errorf("The data is not a DDS file.")
+	}
+
+	le := binary.LittleEndian
+	header := data[4:]
+	flags := le.Uint32(header[4:])
+	height, width := le.Uint32(header[8:]), le.Uint32(header[12:])
+	levels := uint32(1)
+	if flags&ddsFlagMipMapCount != 0 && le.Uint32(header[24:]) > 0 {
+		levels = le.Uint32(header[24:])
+	}
+	pixelFlags := le.Uint32(header[76:])
+	fourCC := string(header[80:84])
+	bitCount := le.Uint32(header[84:])
+	redMask := le.Uint32(header[88:])
+	caps2 := le.Uint32(header[108:])
+
+	if caps2&(ddsCaps2CubeMap|ddsCaps2Volume) != 0 {
+		return nil, fmt.Errorf("Only 2D DDS textures are supported.")
+	}
+
+	tc := new(TextureContainer)
+	tc.Type = graphics.UNSIGNED_BYTE
+	offset := 4 + ddsHeaderSize
+
+	switch {
+	case pixelFlags&ddsPixelFourCC != 0 && fourCC == "DX10":
+		if len(data) < offset+ddsHeaderDX10Size {
+			return nil, fmt.Errorf("The DDS file is missing the DX10 header.")
+		}
+		dxgiFormat := le.Uint32(data[offset:])
+		format, okay := ddsDXGIFormats[dxgiFormat]
+		if !okay {
+			return nil, fmt.Errorf("The DDS DXGI format %d is not supported.", dxgiFormat)
+		}
+		if le.Uint32(data[offset+12:]) > 1 {
+			return nil, fmt.Errorf("DDS texture arrays are not supported.")
+		}
+		tc.InternalFormat = format.internalFormat
+		tc.Format = format.format
+		tc.Compressed = format.compressed
+		offset += ddsHeaderDX10Size
+	case pixelFlags&ddsPixelFourCC != 0:
+		format, okay := ddsFourCCFormats[fourCC]
+		if !okay {
+			return nil, fmt.Errorf("The DDS FourCC format %q is not supported.", fourCC)
+		}
+		tc.InternalFormat = format
+		tc.Compressed = true
+	case pixelFlags&ddsPixelRGB != 0 && bitCount == 32:
+		tc.InternalFormat = graphics.RGBA8
+		if redMask == 0x00ff0000 {
+			tc.Format = graphics.BGRA
+		} else {
+			tc.Format = graphics.RGBA
+		}
+	default:
+		return nil, fmt.Errorf("The DDS pixel format is not supported.")
+	}
+
+	for i := uint32(0); i < levels; i++ {
+		w, h := mipSize(width, i), mipSize(height, i)
+		size := textureLevelSize(tc.InternalFormat, tc.Compressed, w, h)
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("The DDS file is missing data for mipmap level %d.", i)
+		}
+
+		tc.Levels = append(tc.Levels, TextureLevel{Width: w, Height: h, Data: data[offset : offset+size]})
+		offset += size
+	}
+
+	return tc, nil
+}
+
+// mipSize returns the size of a texture dimension at a mipmap level.
+func mipSize(size uint32, level uint32) int32 {
+	s := int32(size >> level)
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+// textureLevelSize returns the number of bytes in a mipmap level of a
+// DDS texture, which doesn't store the size of the levels.
+func textureLevelSize(internalFormat graphics.Enum, compressed bool, width, height int32) int {
+	if !compressed {
+		return int(width * height * 4)
+	}
+
+	// 4x4 blocks are either 8 or 16 bytes
+	blockBytes := 16
+	switch internalFormat {
+	case graphics.COMPRESSED_RGB_S3TC_DXT1_EXT, graphics.COMPRESSED_RGBA_S3TC_DXT1_EXT, graphics.COMPRESSED_RED_RGTC1:
+		blockBytes = 8
+	}
+	blocksWide := int((width + 3) / 4)
+	blocksHigh := int((height + 3) / 4)
+	return blocksWide * blocksHigh * blockBytes
+}
@@ -0,0 +1,117 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"image"
+	"os"
+	"time"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// DefaultHotReloadInterval is the default time between checks for changed
+// texture files with hot reloading enabled.
+const DefaultHotReloadInterval = time.Second
+
+// EnableHotReload starts watching the files of the 2D textures loaded with
+// LoadTexture and LoadTextureAsync. When UpdateAsync finds that a file has
+// changed, at most once every interval, the image is decoded again and
+// uploaded into the same OpenGL texture object so that materials using it
+// show the changes without being updated. This is meant for development
+// so that texture edits can be seen in a running application.
+func (tm *TextureManager) EnableHotReload(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHotReloadInterval
+	}
+	tm.hotReloadInterval = interval
+	tm.hotReloadChecked = time.Now()
+
+	// start from the current state of the files so that only later
+	// changes cause a reload
+	for key, path := range tm.filePaths {
+		if info, err := os.Stat(path); err == nil {
+			tm.modTimes[key] = info.ModTime()
+		}
+	}
+}
+
+// DisableHotReload stops watching the texture files for changes.
+func (tm *TextureManager) DisableHotReload() {
+	tm.hotReloadInterval = 0
+}
+
+// IsHotReloadEnabled returns true if the texture files are being watched
+// for changes.
+func (tm *TextureManager) IsHotReloadEnabled() bool {
+	return tm.hotReloadInterval > 0
+}
+
+// watchFile remembers the file a texture was loaded from so that it can be
+// reloaded when it changes.
+func (tm *TextureManager) watchFile(keyToUse string, path string) {
+	tm.filePaths[keyToUse] = path
+	if info, err := os.Stat(path); err == nil {
+		tm.modTimes[keyToUse] = info.ModTime()
+	}
+}
+
+// checkHotReload starts decoding the texture files that changed since they
+// were last loaded if hot reloading is enabled and the interval has passed.
+func (tm *TextureManager) checkHotReload() {
+	if tm.hotReloadInterval <= 0 || time.Since(tm.hotReloadChecked) < tm.hotReloadInterval {
+		return
+	}
+	tm.hotReloadChecked = time.Now()
+
+	for key, path := range tm.filePaths {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(tm.modTimes[key]) {
+			continue
+		}
+		tm.modTimes[key] = info.ModTime()
+		tm.decodeAsync(&asyncTexture{key: key, tex: tm.storage[key], reload: true}, path)
+	}
+}
+
+// finishHotReload uploads a reloaded texture into its existing OpenGL
+// texture object.
+func (tm *TextureManager) finishHotReload(result *asyncTexture) {
+	// skip textures that were unloaded or replaced while decoding
+	if glTexture, okay := tm.storage[result.key]; !okay || glTexture != result.tex {
+		return
+	}
+
+	if result.err == nil {
+		if result.container != nil {
+			uploadTextureContainer(result.tex, result.container)
+			tm.byteSizes[result.key] = result.container.DataSize()
+		} else if result.hdr != nil {
+			uploadHDR(result.tex, result.hdr)
+			gfx.BindTexture(graphics.TEXTURE_2D, 0)
+			tm.byteSizes[result.key] = result.hdr.ByteSize()
+		} else {
+			reloadNRGBA(result.tex, result.img)
+			tm.byteSizes[result.key] = len(result.img.Pix)
+		}
+	}
+
+	if tm.OnHotReloaded != nil {
+		tm.OnHotReloaded(result.key, result.err)
+	}
+}
+
+// reloadNRGBA replaces the image of an OpenGL texture with the image while
+// keeping its filtering and wrapping parameters. The mipmaps get generated
+// again in case the texture uses them.
+func reloadNRGBA(tex graphics.Texture, img *image.NRGBA) {
+	w := int32(img.Bounds().Dx())
+	h := int32(img.Bounds().Dy())
+
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, w, h, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(img.Pix), len(img.Pix))
+	gfx.GenerateMipmap(graphics.TEXTURE_2D)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+}
@@ -4,30 +4,106 @@
 package fizzle
 
 import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+
 	graphics "github.com/tbogdala/fizzle/graphicsprovider"
 )
 
 // TextureManager provides an easy way to load textures to OpenGL and
 // to access the textures by name elsewhere.
+//
+// Textures are reference counted with Retain and Release so that the ones
+// no longer in use can be deleted with UnloadUnused. Loading or registering
+// a texture under a new key counts as one reference for the caller, which
+// should call Release once it's done with the texture. Loading a texture
+// again under a key already in use replaces the texture but keeps its
+// reference count.
 type TextureManager struct {
+	// PlaceholderColors are the two colors of the checker pattern used for
+	// textures still being loaded by LoadTextureAsync. Use the same color
+	// twice for a solid color.
+	PlaceholderColors [2][4]uint8
+
+	// AsyncUploadsPerFrame is the most textures UpdateAsync uploads at
+	// once to spread out the cost over frames. A value of 0 uploads all
+	// of the finished textures.
+	AsyncUploadsPerFrame int
+
+	// OnAsyncLoaded is called by UpdateAsync when a texture loaded with
+	// LoadTextureAsync is ready or has failed to load. If it fails, the
+	// placeholder texture is kept.
+	OnAsyncLoaded func(keyToUse string, err error)
+
+	// OnHotReloaded is called by UpdateAsync when a texture file changed
+	// while hot reloading is enabled and the texture was reloaded or failed
+	// to reload. If it fails, the old image is kept.
+	OnHotReloaded func(keyToUse string, err error)
+
 	// storage keeps references to the OpenGL texture objects referenced by name.
 	storage map[string]graphics.Texture
+
+	// refCounts is the number of users of each texture by name.
+	refCounts map[string]int
+
+	// byteSizes is the approximate amount of memory used by each texture by name.
+	byteSizes map[string]int
+
+	// unowned are the names of registered textures that the manager
+	// should not delete from OpenGL.
+	unowned map[string]bool
+
+	// asyncWorkers limits the number of textures being decoded at once.
+	asyncWorkers chan bool
+
+	// asyncMutex guards asyncResults, which are written by the goroutines
+	// decoding textures and read on the OpenGL thread.
+	asyncMutex   sync.Mutex
+	asyncResults []*asyncTexture
+	asyncPending int
+
+	// filePaths are the files 2D textures were loaded from by name, which
+	// get watched for changes when hot reloading is enabled.
+	filePaths map[string]string
+
+	// hotReloadInterval is how often the files are checked for changes with
+	// hot reloading enabled; 0 means it's disabled.
+	hotReloadInterval time.Duration
+	hotReloadChecked  time.Time
+	modTimes          map[string]time.Time
 }
 
 // NewTextureManager creates a new TextureManager object with empty storage.
 func NewTextureManager() *TextureManager {
 	tm := new(TextureManager)
 	tm.storage = make(map[string]graphics.Texture)
+	tm.refCounts = make(map[string]int)
+	tm.byteSizes = make(map[string]int)
+	tm.unowned = make(map[string]bool)
+	tm.filePaths = make(map[string]string)
+	tm.modTimes = make(map[string]time.Time)
+	tm.asyncWorkers = make(chan bool, runtime.NumCPU())
+	tm.PlaceholderColors = [2][4]uint8{{128, 128, 128, 255}, {192, 192, 192, 255}}
 	return tm
 }
 
 // Destroy deletes all of the stored textures from OpenGL
 // and resets the storage map.
 func (tm *TextureManager) Destroy() {
-	for _, t := range tm.storage {
-		gfx.DeleteTexture(t)
+	for key, t := range tm.storage {
+		if !tm.unowned[key] {
+			gfx.DeleteTexture(t)
+		}
 	}
 	tm.storage = make(map[string]graphics.Texture)
+	tm.refCounts = make(map[string]int)
+	tm.byteSizes = make(map[string]int)
+	tm.unowned = make(map[string]bool)
+	tm.filePaths = make(map[string]string)
+	tm.modTimes = make(map[string]time.Time)
 }
 
 // GetTexture attempts to access the texture by name in storage and returns
@@ -42,12 +118,186 @@ func (tm *TextureManager) GetTexture(keyToUse string) (graphics.Texture, bool) {
 // stores the object in the storage map under the specified keyToUse.
 func (tm *TextureManager) LoadTexture(keyToUse string, path string) (graphics.Texture, error) {
 	// load the file into a GL texture
-	glTexture, err := LoadImageToTexture(path)
+	glTexture, byteSize, err := loadImageToTexture(path)
 	if err != nil {
 		return glTexture, err
 	}
 
 	// store it for later
-	tm.storage[keyToUse] = glTexture
+	tm.store(keyToUse, glTexture, byteSize)
+	tm.watchFile(keyToUse, path)
+	return glTexture, nil
+}
+
+// LoadTextureFromImage loads an image that was decoded elsewhere, such as
+// one embedded in a model file, into OpenGL and then stores the object in
+// the storage map under the specified keyToUse.
+func (tm *TextureManager) LoadTextureFromImage(keyToUse string, img image.Image) (graphics.Texture, error) {
+	rgbaFlipped, err := loadDecodedPNG(img)
+	if err != nil {
+		return 0, err
+	}
+
+	glTexture := gfx.GenTexture()
+	uploadNRGBA(glTexture, rgbaFlipped)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.REPEAT)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.REPEAT)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	// store it for later
+	tm.store(keyToUse, glTexture, len(rgbaFlipped.Pix))
+	return glTexture, nil
+}
+
+// LoadCubeMap loads a cube map texture into OpenGL and then stores the object
+// in the storage map under the specified keyToUse. Either six face image paths
+// in the order of +X, -X, +Y, -Y, +Z, -Z or a single path to a cross or
+// equirectangular image can be passed. The texture gets bound to
+// TEXTURE_CUBE_MAP with mipmaps when used.
+func (tm *TextureManager) LoadCubeMap(keyToUse string, paths ...string) (graphics.Texture, error) {
+	var faces [CubeMapFaceCount]*image.NRGBA
+	var err error
+
+	switch len(paths) {
+	case 1:
+		faces, err = loadCubeMapFacesFromFile(paths[0])
+	case CubeMapFaceCount:
+		var facePaths [CubeMapFaceCount]string
+		copy(facePaths[:], paths)
+		faces, err = loadCubeMapFaceFiles(facePaths)
+	default:
+		return 0, fmt.Errorf("Failed to load the cube map %s because %d paths were given instead of 1 or 6.", keyToUse, len(paths))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	glTexture, err := LoadImagesToCubeMap(faces)
+	if err != nil {
+		return glTexture, err
+	}
+
+	// six faces with a third more for the mipmaps
+	byteSize := len(faces[0].Pix) * CubeMapFaceCount * 4 / 3
+
+	// store it for later
+	tm.store(keyToUse, glTexture, byteSize)
 	return glTexture, nil
 }
+
+// RegisterTexture stores a texture that was created elsewhere, such as a
+// render target, video frame or procedurally generated texture, under the
+// specified keyToUse so that it can be used like a texture loaded from a
+// file. If owned is true, the manager deletes the texture from OpenGL when
+// it gets unloaded or replaced; otherwise it only forgets about it and
+// UnloadUnused leaves it alone. The byteSize is used for the memory usage.
+//
+// Registering a new texture under a key already in use replaces it, which
+// can be used to swap in a new video frame or a resized render target.
+func (tm *TextureManager) RegisterTexture(keyToUse string, glTexture graphics.Texture, byteSize int, owned bool) {
+	tm.store(keyToUse, glTexture, byteSize)
+	if owned {
+		delete(tm.unowned, keyToUse)
+	} else {
+		tm.unowned[keyToUse] = true
+	}
+}
+
+// store puts the texture in storage under a key, keeping the reference
+// count of any texture already stored under the key and deleting the old
+// texture if it's owned by the manager. A new key starts with one reference
+// for the caller.
+func (tm *TextureManager) store(keyToUse string, glTexture graphics.Texture, byteSize int) {
+	oldTexture, okay := tm.storage[keyToUse]
+	if okay && oldTexture != glTexture && !tm.unowned[keyToUse] {
+		gfx.DeleteTexture(oldTexture)
+	}
+	delete(tm.unowned, keyToUse)
+	delete(tm.filePaths, keyToUse)
+	delete(tm.modTimes, keyToUse)
+
+	tm.storage[keyToUse] = glTexture
+	tm.byteSizes[keyToUse] = byteSize
+	if _, okay := tm.refCounts[keyToUse]; !okay {
+		tm.refCounts[keyToUse] = 1
+	}
+}
+
+// Retain adds a reference to the texture stored under the key and returns
+// the OpenGL object and a bool indicating if the texture was found in storage.
+func (tm *TextureManager) Retain(keyToUse string) (graphics.Texture, bool) {
+	glTexture, okay := tm.storage[keyToUse]
+	if okay {
+		tm.refCounts[keyToUse]++
+	}
+	return glTexture, okay
+}
+
+// Release removes a reference to the texture stored under the key. The
+// texture stays loaded until UnloadUnused or Unload is called.
+func (tm *TextureManager) Release(keyToUse string) {
+	if tm.refCounts[keyToUse] > 0 {
+		tm.refCounts[keyToUse]--
+	}
+}
+
+// GetRefCount returns the number of references to the texture stored
+// under the key.
+func (tm *TextureManager) GetRefCount(keyToUse string) int {
+	return tm.refCounts[keyToUse]
+}
+
+// Unload deletes the texture stored under the key from OpenGL regardless
+// of how many references it has. Registered textures that are not owned
+// by the manager are removed from storage without being deleted.
+func (tm *TextureManager) Unload(keyToUse string) {
+	glTexture, okay := tm.storage[keyToUse]
+	if !okay {
+		return
+	}
+
+	if !tm.unowned[keyToUse] {
+		gfx.DeleteTexture(glTexture)
+	}
+	delete(tm.storage, keyToUse)
+	delete(tm.refCounts, keyToUse)
+	delete(tm.byteSizes, keyToUse)
+	delete(tm.unowned, keyToUse)
+	delete(tm.filePaths, keyToUse)
+	delete(tm.modTimes, keyToUse)
+}
+
+// UnloadUnused deletes all of the textures that have no references from
+// OpenGL and returns the number of textures and bytes that were freed.
+func (tm *TextureManager) UnloadUnused() (int, int) {
+	texturesFreed := 0
+	bytesFreed := 0
+	for key := range tm.storage {
+		if tm.refCounts[key] > 0 || tm.unowned[key] {
+			continue
+		}
+
+		bytesFreed += tm.byteSizes[key]
+		texturesFreed++
+		tm.Unload(key)
+	}
+
+	return texturesFreed, bytesFreed
+}
+
+// GetTextureMemory returns the approximate number of bytes used by the
+// texture stored under the key. Mipmaps generated after a 2D texture is
+// loaded are not included and add about a third more.
+func (tm *TextureManager) GetTextureMemory(keyToUse string) int {
+	return tm.byteSizes[keyToUse]
+}
+
+// GetMemoryUsage returns the approximate number of bytes used by all of the
+// stored textures.
+func (tm *TextureManager) GetMemoryUsage() int {
+	total := 0
+	for _, byteSize := range tm.byteSizes {
+		total += byteSize
+	}
+	return total
+}
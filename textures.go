@@ -128,7 +128,32 @@ func LoadRGBToTextureExt(rgb []byte, imageSize, magFilter, minFilter, wrapS, wra
 }
 
 // LoadImageToTexture loads an image from a file into an OpenGL texture.
+// KTX, KTX2 and DDS files are loaded with their stored mipmap levels, .hdr
+// files are loaded as RGB16F textures with LoadHDRToTexture and any other
+// file is decoded as a PNG.
 func LoadImageToTexture(filePath string) (graphics.Texture, error) {
+	tex, _, err := loadImageToTexture(filePath)
+	return tex, err
+}
+
+// loadImageToTexture loads an image from a file into an OpenGL texture and
+// also returns the number of bytes of texture data uploaded.
+func loadImageToTexture(filePath string) (graphics.Texture, int, error) {
+	if IsTextureContainerFile(filePath) {
+		tc, err := LoadTextureContainer(filePath)
+		if err != nil {
+			return 0, 0, err
+		}
+		return LoadTextureContainerToTexture(tc), tc.DataSize(), nil
+	}
+	if IsHDRFile(filePath) {
+		img, err := LoadHDRFile(filePath)
+		if err != nil {
+			return 0, 0, err
+		}
+		return LoadHDRToTexture(img), img.ByteSize(), nil
+	}
+
 	tex := gfx.GenTexture()
 	gfx.ActiveTexture(graphics.TEXTURE0)
 	gfx.BindTexture(graphics.TEXTURE_2D, tex)
@@ -139,14 +164,14 @@ func LoadImageToTexture(filePath string) (graphics.Texture, error) {
 
 	rgbaFlipped, err := loadFile(filePath)
 	if err != nil {
-		return tex, err
+		return tex, 0, err
 	}
 
 	imageSizeW := int32(rgbaFlipped.Bounds().Max.X)
 	imageSizeH := int32(rgbaFlipped.Bounds().Max.Y)
 
 	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, imageSizeW, imageSizeH, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(rgbaFlipped.Pix), len(rgbaFlipped.Pix))
-	return tex, nil
+	return tex, len(rgbaFlipped.Pix), nil
 }
 
 // LoadPNGToTexture loads a byte slice as a PNG image and buffers it into
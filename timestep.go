@@ -0,0 +1,201 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// DefaultMaxUpdatesPerFrame is the most fixed updates a FixedTimestep
+	// runs for one frame when created with NewFixedTimestep.
+	DefaultMaxUpdatesPerFrame = 8
+)
+
+// FixedTimestepUpdate is the prototype for the function called by a
+// FixedTimestep for each fixed update with the length of the step in
+// seconds.
+type FixedTimestepUpdate func(step float32)
+
+// FixedTimestep runs a simulation at a fixed rate independent of the frame
+// rate. The frame time is added to an accumulator and the update function
+// gets called once for every whole step in it, so physics and animation
+// give the same results on fast and slow machines. The time left over is
+// returned as an interpolation alpha between the last two updates to
+// smooth out rendering, such as with TransformInterpolator.
+type FixedTimestep struct {
+	// Step is the length of each fixed update in seconds.
+	Step float32
+
+	// MaxUpdatesPerFrame is the most updates run by a call to Advance. If
+	// the simulation falls further behind than this, the extra time is
+	// dropped instead of running ever more updates each frame. A value of
+	// 0 doesn't limit the updates.
+	MaxUpdatesPerFrame int
+
+	// accumulator is the simulation time that hasn't been updated yet.
+	accumulator float32
+
+	// updateCount is the total number of updates run.
+	updateCount uint64
+}
+
+// NewFixedTimestep creates a new FixedTimestep that updates the number of
+// times per second specified.
+func NewFixedTimestep(updatesPerSecond float32) *FixedTimestep {
+	ft := new(FixedTimestep)
+	ft.Step = 1.0 / updatesPerSecond
+	ft.MaxUpdatesPerFrame = DefaultMaxUpdatesPerFrame
+	return ft
+}
+
+// Advance adds the frame time in seconds to the accumulator and calls
+// update for each whole step that fits in it. It returns the
+// interpolation alpha in the range of [0, 1) which is how far the render
+// time is between the previous and the latest update.
+func (ft *FixedTimestep) Advance(frameDelta float32, update FixedTimestepUpdate) float32 {
+	if ft.Step <= 0.0 {
+		return 0.0
+	}
+
+	ft.accumulator += frameDelta
+	updates := 0
+	for ft.accumulator >= ft.Step {
+		if ft.MaxUpdatesPerFrame > 0 && updates >= ft.MaxUpdatesPerFrame {
+			// drop the time that can't be caught up on
+			ft.accumulator = 0.0
+			break
+		}
+
+		update(ft.Step)
+		ft.accumulator -= ft.Step
+		ft.updateCount++
+		updates++
+	}
+
+	return ft.GetAlpha()
+}
+
+// GetAlpha returns the interpolation alpha for the time in the accumulator.
+func (ft *FixedTimestep) GetAlpha() float32 {
+	if ft.Step <= 0.0 {
+		return 0.0
+	}
+	return ft.accumulator / ft.Step
+}
+
+// GetUpdateCount returns the total number of fixed updates run.
+func (ft *FixedTimestep) GetUpdateCount() uint64 {
+	return ft.updateCount
+}
+
+// GetSimulationTime returns the total time simulated in seconds.
+func (ft *FixedTimestep) GetSimulationTime() float64 {
+	return float64(ft.updateCount) * float64(ft.Step)
+}
+
+// Reset clears the accumulator and the update count.
+func (ft *FixedTimestep) Reset() {
+	ft.accumulator = 0.0
+	ft.updateCount = 0
+}
+
+// TransformState is the transform of a Renderable at a point in time.
+type TransformState struct {
+	Location      mgl.Vec3
+	Scale         mgl.Vec3
+	Rotation      mgl.Quat
+	LocalRotation mgl.Quat
+}
+
+// GetTransformState returns the current transform of the Renderable.
+func (r *Renderable) GetTransformState() TransformState {
+	return TransformState{
+		Location:      r.Location,
+		Scale:         r.Scale,
+		Rotation:      r.Rotation,
+		LocalRotation: r.LocalRotation,
+	}
+}
+
+// SetTransformState sets the transform of the Renderable.
+func (r *Renderable) SetTransformState(s TransformState) {
+	r.Location = s.Location
+	r.Scale = s.Scale
+	r.Rotation = s.Rotation
+	r.LocalRotation = s.LocalRotation
+}
+
+// InterpolateTransforms interpolates from transform a to transform b by
+// alpha in the range of [0, 1]. Locations and scales are interpolated
+// linearly and rotations take the shortest path.
+func InterpolateTransforms(a, b TransformState, alpha float32) TransformState {
+	var s TransformState
+	s.Location = a.Location.Add(b.Location.Sub(a.Location).Mul(alpha))
+	s.Scale = a.Scale.Add(b.Scale.Sub(a.Scale).Mul(alpha))
+	s.Rotation = slerpShortest(a.Rotation, b.Rotation, alpha)
+	s.LocalRotation = slerpShortest(a.LocalRotation, b.LocalRotation, alpha)
+	return s
+}
+
+// slerpShortest spherically interpolates between the quaternions, flipping
+// the second one if needed so that the rotation takes the shortest path.
+func slerpShortest(q1, q2 mgl.Quat, amount float32) mgl.Quat {
+	if q1.Dot(q2) < 0.0 {
+		q2 = q2.Scale(-1.0)
+	}
+	return mgl.QuatSlerp(q1, q2, amount)
+}
+
+// TransformInterpolator smooths the motion of a Renderable that is moved
+// by a FixedTimestep. The simulation changes Current during the fixed
+// updates and Apply sets the Renderable to the interpolation between
+// Previous and Current for drawing.
+//
+// A typical frame looks like:
+//
+//	alpha := timestep.Advance(dt, func(step float32) {
+//		interp.Step()
+//		interp.Current.Location = interp.Current.Location.Add(velocity.Mul(step))
+//	})
+//	interp.Apply(alpha)
+type TransformInterpolator struct {
+	// Target is the Renderable that gets the interpolated transform.
+	Target *Renderable
+
+	// Previous is the transform after the update before the latest one.
+	Previous TransformState
+
+	// Current is the transform after the latest update.
+	Current TransformState
+}
+
+// NewTransformInterpolator creates a new TransformInterpolator for the
+// Renderable starting at its current transform.
+func NewTransformInterpolator(target *Renderable) *TransformInterpolator {
+	ti := new(TransformInterpolator)
+	ti.Target = target
+	ti.Teleport(target.GetTransformState())
+	return ti
+}
+
+// Step saves Current as Previous and should be called at the start of each
+// fixed update before Current is changed.
+func (ti *TransformInterpolator) Step() {
+	ti.Previous = ti.Current
+}
+
+// Teleport sets both Previous and Current to the transform so that the
+// Renderable jumps there without interpolating across the distance.
+func (ti *TransformInterpolator) Teleport(s TransformState) {
+	ti.Previous = s
+	ti.Current = s
+}
+
+// Apply sets the transform of the Target to the interpolation between
+// Previous and Current by alpha, which is usually the value returned by
+// FixedTimestep.Advance.
+func (ti *TransformInterpolator) Apply(alpha float32) {
+	ti.Target.SetTransformState(InterpolateTransforms(ti.Previous, ti.Current, alpha))
+}
@@ -0,0 +1,226 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VideoSource provides the frames of a video for a VideoTexture. Frames are
+// read on a goroutine other than the OpenGL thread.
+type VideoSource interface {
+	// GetSize returns the width and height of the frames.
+	GetSize() (int32, int32)
+
+	// GetFrameRate returns the number of frames per second.
+	GetFrameRate() float64
+
+	// NextFrame returns the next frame with the first row at the top of the
+	// image, or io.EOF when there are no more frames.
+	NextFrame() (*image.NRGBA, error)
+
+	// Rewind starts the video over from the first frame.
+	Rewind() error
+
+	// Close releases the resources used by the source.
+	Close() error
+}
+
+// ImageSequenceSource is a VideoSource that plays the PNG files in a
+// directory in the order of their file names.
+type ImageSequenceSource struct {
+	files     []string
+	next      int
+	width     int32
+	height    int32
+	frameRate float64
+}
+
+// NewImageSequenceSource creates a video source from the PNG files in the
+// directory played at the frame rate. All of the images should be the same
+// size as the first one.
+func NewImageSequenceSource(dirPath string, frameRate float64) (*ImageSequenceSource, error) {
+	files, err := filepath.Glob(filepath.Join(dirPath, "*.png"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the image sequence files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("Failed to find any PNG files in %s for the image sequence.", dirPath)
+	}
+	sort.Strings(files)
+
+	first, err := loadFileUnflipped(files[0])
+	if err != nil {
+		return nil, err
+	}
+
+	src := new(ImageSequenceSource)
+	src.files = files
+	src.width = int32(first.Bounds().Dx())
+	src.height = int32(first.Bounds().Dy())
+	src.frameRate = frameRate
+	return src, nil
+}
+
+// GetSize returns the width and height of the frames.
+func (src *ImageSequenceSource) GetSize() (int32, int32) {
+	return src.width, src.height
+}
+
+// GetFrameRate returns the number of frames per second.
+func (src *ImageSequenceSource) GetFrameRate() float64 {
+	return src.frameRate
+}
+
+// NextFrame loads the next image file of the sequence.
+func (src *ImageSequenceSource) NextFrame() (*image.NRGBA, error) {
+	if src.next >= len(src.files) {
+		return nil, io.EOF
+	}
+
+	frame, err := loadFileUnflipped(src.files[src.next])
+	if err != nil {
+		return nil, err
+	}
+	if int32(frame.Bounds().Dx()) != src.width || int32(frame.Bounds().Dy()) != src.height {
+		return nil, fmt.Errorf("Failed to load the image sequence frame %s because it's not %dx%d.", src.files[src.next], src.width, src.height)
+	}
+
+	src.next++
+	return frame, nil
+}
+
+// Rewind starts the sequence over from the first image file.
+func (src *ImageSequenceSource) Rewind() error {
+	src.next = 0
+	return nil
+}
+
+// Close does nothing for image sequences.
+func (src *ImageSequenceSource) Close() error {
+	return nil
+}
+
+// FFmpegSource is a VideoSource that decodes a video file with the ffmpeg
+// command, which has to be installed and in the path along with ffprobe.
+type FFmpegSource struct {
+	filePath  string
+	width     int32
+	height    int32
+	frameRate float64
+
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	reader *bufio.Reader
+}
+
+// NewFFmpegSource creates a video source that decodes the video file with
+// ffmpeg. The size and frame rate are read with ffprobe.
+func NewFFmpegSource(filePath string) (*FFmpegSource, error) {
+	probe, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width,height,r_frame_rate", "-of", "csv=p=0", filePath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to probe the video file %s: %v", filePath, err)
+	}
+
+	// the output looks like: 1280,720,30000/1001
+	fields := strings.Split(strings.TrimSpace(string(probe)), ",")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("Failed to read the size and frame rate of the video file %s.", filePath)
+	}
+	width, errW := strconv.Atoi(fields[0])
+	height, errH := strconv.Atoi(fields[1])
+	if errW != nil || errH != nil {
+		return nil, fmt.Errorf("Failed to read the size of the video file %s.", filePath)
+	}
+
+	frameRate := 30.0
+	rate := strings.Split(fields[2], "/")
+	if num, err := strconv.ParseFloat(rate[0], 64); err == nil {
+		frameRate = num
+		if len(rate) == 2 {
+			if den, err := strconv.ParseFloat(rate[1], 64); err == nil && den > 0.0 {
+				frameRate = num / den
+			}
+		}
+	}
+
+	src := new(FFmpegSource)
+	src.filePath = filePath
+	src.width = int32(width)
+	src.height = int32(height)
+	src.frameRate = frameRate
+	err = src.start()
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// start runs ffmpeg to decode the video to raw RGBA frames.
+func (src *FFmpegSource) start() error {
+	src.cmd = exec.Command("ffmpeg", "-v", "error", "-i", src.filePath,
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-")
+	stdout, err := src.cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Failed to decode the video file %s: %v", src.filePath, err)
+	}
+	err = src.cmd.Start()
+	if err != nil {
+		return fmt.Errorf("Failed to start ffmpeg to decode the video file %s: %v", src.filePath, err)
+	}
+
+	src.stdout = stdout
+	src.reader = bufio.NewReaderSize(stdout, int(src.width*src.height*4))
+	return nil
+}
+
+// GetSize returns the width and height of the frames.
+func (src *FFmpegSource) GetSize() (int32, int32) {
+	return src.width, src.height
+}
+
+// GetFrameRate returns the number of frames per second.
+func (src *FFmpegSource) GetFrameRate() float64 {
+	return src.frameRate
+}
+
+// NextFrame reads the next decoded frame from ffmpeg.
+func (src *FFmpegSource) NextFrame() (*image.NRGBA, error) {
+	frame := image.NewNRGBA(image.Rect(0, 0, int(src.width), int(src.height)))
+	_, err := io.ReadFull(src.reader, frame.Pix)
+	if err == io.ErrUnexpectedEOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// Rewind restarts ffmpeg to decode the video from the beginning.
+func (src *FFmpegSource) Rewind() error {
+	src.Close()
+	return src.start()
+}
+
+// Close stops ffmpeg.
+func (src *FFmpegSource) Close() error {
+	if src.cmd == nil || src.cmd.Process == nil {
+		return nil
+	}
+	src.stdout.Close()
+	src.cmd.Process.Kill()
+	src.cmd.Wait()
+	src.cmd = nil
+	return nil
+}
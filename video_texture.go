@@ -0,0 +1,218 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package fizzle
+
+import (
+	"image"
+	"io"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+)
+
+// videoFrameBuffer is the number of decoded frames that can wait to be
+// uploaded so that decoding stays ahead of playback.
+const videoFrameBuffer = 3
+
+// VideoTexture plays a VideoSource into an OpenGL texture that can be used
+// by materials like any other texture, such as for in-game screens or menu
+// backgrounds. Frames are decoded on another goroutine and streamed to the
+// texture through pixel buffer objects, which needs OpenGL 3 or OpenGL ES 3.
+// The texture can be put in a TextureManager with RegisterTexture so that
+// components can refer to it by name.
+type VideoTexture struct {
+	// Texture is the OpenGL texture object showing the current frame.
+	Texture graphics.Texture
+
+	// Width and Height are the size of the video frames.
+	Width  int32
+	Height int32
+
+	// OnFinished is called by Update when a video that doesn't loop has
+	// played its last frame.
+	OnFinished func()
+
+	source    VideoSource
+	loop      bool
+	playing   bool
+	finished  bool
+	frameTime float64
+	elapsed   float64
+
+	frames  chan *image.NRGBA
+	stop    chan bool
+	pbos    [2]graphics.Buffer
+	pboNext int
+	staging []byte
+}
+
+// NewVideoTexture creates a texture the size of the video frames and
+// starts decoding the source. If loop is true the video starts over when
+// it reaches the end. Call Play to start the playback and Update each frame
+// on the OpenGL thread.
+func NewVideoTexture(source VideoSource, loop bool) *VideoTexture {
+	vt := new(VideoTexture)
+	vt.Width, vt.Height = source.GetSize()
+	vt.source = source
+	vt.loop = loop
+	vt.frameTime = 1.0 / 30.0
+	if frameRate := source.GetFrameRate(); frameRate > 0.0 {
+		vt.frameTime = 1.0 / frameRate
+	}
+	vt.frames = make(chan *image.NRGBA, videoFrameBuffer)
+	vt.stop = make(chan bool)
+	vt.staging = make([]byte, vt.Width*vt.Height*4)
+
+	// start out with a black texture until the first frame is ready
+	vt.Texture = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, vt.Texture)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, vt.Width, vt.Height, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.Ptr(vt.staging), len(vt.staging))
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	// two pixel buffers are used in turn so that a frame can be copied into
+	// one while the previous one is still being transferred to the texture
+	for i := range vt.pbos {
+		vt.pbos[i] = gfx.GenBuffer()
+		gfx.BindBuffer(graphics.PIXEL_UNPACK_BUFFER, vt.pbos[i])
+		gfx.BufferData(graphics.PIXEL_UNPACK_BUFFER, len(vt.staging), nil, graphics.STREAM_DRAW)
+	}
+	gfx.BindBuffer(graphics.PIXEL_UNPACK_BUFFER, 0)
+
+	go vt.decode()
+	return vt
+}
+
+// decode reads frames from the source until it runs out or Destroy is called.
+func (vt *VideoTexture) decode() {
+	defer close(vt.frames)
+	for {
+		frame, err := vt.source.NextFrame()
+		if err == io.EOF && vt.loop {
+			err = vt.source.Rewind()
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				Logf(LogError, "video", "VideoTexture failed to decode a frame: %v", err)
+			}
+			return
+		}
+
+		select {
+		case vt.frames <- frame:
+		case <-vt.stop:
+			return
+		}
+	}
+}
+
+// Play starts or resumes the playback.
+func (vt *VideoTexture) Play() {
+	vt.playing = true
+}
+
+// Pause stops the playback on the current frame.
+func (vt *VideoTexture) Pause() {
+	vt.playing = false
+}
+
+// IsPlaying returns true if the video is playing.
+func (vt *VideoTexture) IsPlaying() bool {
+	return vt.playing
+}
+
+// IsFinished returns true if a video that doesn't loop has played its
+// last frame.
+func (vt *VideoTexture) IsFinished() bool {
+	return vt.finished
+}
+
+// Update advances the playback by the time since the last update in
+// seconds and uploads a new frame to the texture if one is due. Frames are
+// skipped if decoding falls behind. True is returned if the texture changed.
+func (vt *VideoTexture) Update(frameDelta float64) bool {
+	if !vt.playing || vt.finished {
+		return false
+	}
+
+	vt.elapsed += frameDelta
+	var frame *image.NRGBA
+	for vt.elapsed >= vt.frameTime {
+		select {
+		case next, okay := <-vt.frames:
+			if !okay {
+				vt.finished = true
+				vt.playing = false
+				if vt.OnFinished != nil {
+					vt.OnFinished()
+				}
+				return vt.uploadIfAny(frame)
+			}
+			frame = next
+			vt.elapsed -= vt.frameTime
+		default:
+			// the decoder is behind so show what there is and catch up later
+			return vt.uploadIfAny(frame)
+		}
+	}
+
+	return vt.uploadIfAny(frame)
+}
+
+// uploadIfAny uploads the frame if it's not nil and returns true if it was.
+func (vt *VideoTexture) uploadIfAny(frame *image.NRGBA) bool {
+	if frame == nil {
+		return false
+	}
+	vt.uploadFrame(frame)
+	return true
+}
+
+// uploadFrame streams a frame to the texture through the next pixel buffer,
+// flipping it so that the first row is at the bottom of the texture.
+func (vt *VideoTexture) uploadFrame(frame *image.NRGBA) {
+	rowSize := int(vt.Width * 4)
+	for y := 0; y < int(vt.Height); y++ {
+		src := frame.Pix[y*frame.Stride : y*frame.Stride+rowSize]
+		dst := (int(vt.Height) - y - 1) * rowSize
+		copy(vt.staging[dst:dst+rowSize], src)
+	}
+
+	pbo := vt.pbos[vt.pboNext]
+	vt.pboNext = (vt.pboNext + 1) % len(vt.pbos)
+
+	// orphan the old buffer storage so the driver doesn't have to wait
+	// for a transfer still using it
+	gfx.BindBuffer(graphics.PIXEL_UNPACK_BUFFER, pbo)
+	gfx.BufferData(graphics.PIXEL_UNPACK_BUFFER, len(vt.staging), nil, graphics.STREAM_DRAW)
+	gfx.BufferSubData(graphics.PIXEL_UNPACK_BUFFER, 0, len(vt.staging), gfx.Ptr(vt.staging))
+
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, vt.Texture)
+	gfx.TexSubImage2D(graphics.TEXTURE_2D, 0, 0, 0, vt.Width, vt.Height, graphics.RGBA, graphics.UNSIGNED_BYTE, gfx.PtrOffset(0))
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+	gfx.BindBuffer(graphics.PIXEL_UNPACK_BUFFER, 0)
+}
+
+// Destroy stops decoding, closes the source and deletes the texture and
+// buffers from OpenGL.
+func (vt *VideoTexture) Destroy() {
+	close(vt.stop)
+
+	// drain the frames so the decoder isn't left blocked before closing
+	for range vt.frames {
+	}
+	vt.source.Close()
+
+	gfx.DeleteTexture(vt.Texture)
+	for _, pbo := range vt.pbos {
+		gfx.DeleteBuffer(pbo)
+	}
+}
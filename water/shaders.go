@@ -0,0 +1,164 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package water
+
+import (
+	"github.com/tbogdala/fizzle"
+)
+
+const (
+	// WaterShaderV330 is the vertex shader for the water surface. It moves
+	// the vertices by the sum of the sine waves and calculates the normal
+	// of the displaced surface.
+	WaterShaderV330 = `#version 330
+    precision highp float;
+
+    const int MAX_WAVES=4;
+
+    uniform mat4 MVP_MATRIX;
+    uniform mat4 M_MATRIX;
+    uniform vec3 CAMERA_WORLD_POSITION;
+    uniform vec4 WATER_WAVES[MAX_WAVES]; // direction.xy, amplitude, wave number
+    uniform float WATER_WAVE_PHASES[MAX_WAVES];
+    uniform int WATER_WAVE_COUNT;
+    in vec3 VERTEX_POSITION;
+
+    out vec3 vs_position_model;
+    out vec3 vs_normal_model;
+    out vec3 vs_camera_world;
+    out vec4 vs_clip_position;
+
+    void main()
+    {
+    	vec3 world = vec3(M_MATRIX * vec4(VERTEX_POSITION, 1.0));
+    	float height = 0.0;
+    	vec2 slope = vec2(0.0);
+    	for (int i=0; i<MAX_WAVES; i++) {
+    		if (i >= WATER_WAVE_COUNT) {
+    			break;
+    		}
+    		vec4 wave = WATER_WAVES[i];
+    		float theta = wave.w * dot(wave.xy, world.xz) - WATER_WAVE_PHASES[i];
+    		height += wave.z * sin(theta);
+    		slope += wave.xy * (wave.z * wave.w * cos(theta));
+    	}
+
+    	vec4 vertex4 = vec4(VERTEX_POSITION.x, VERTEX_POSITION.y + height, VERTEX_POSITION.z, 1.0);
+    	vs_position_model = vec3(M_MATRIX * vertex4);
+    	vs_normal_model = normalize(vec3(-slope.x, 1.0, -slope.y));
+    	vs_camera_world = CAMERA_WORLD_POSITION;
+    	vs_clip_position = MVP_MATRIX * vertex4;
+    	gl_Position = vs_clip_position;
+    }
+    `
+
+	// WaterShaderF330 is the fragment shader for the water surface. The
+	// detail normal map is MATERIAL_TEX_NORMALS and an optional foam
+	// texture is MATERIAL_TEX_0 (CustomTex[0]). The first light of the
+	// forward renderer adds a specular highlight.
+	WaterShaderF330 = `#version 330
+    precision highp float;
+
+    uniform vec4 MATERIAL_DIFFUSE;
+    uniform float MATERIAL_SHININESS;
+    uniform sampler2D MATERIAL_TEX_NORMALS;
+    uniform float MATERIAL_TEX_NORMALS_VALID;
+    uniform sampler2D MATERIAL_TEX_0;
+    uniform float MATERIAL_TEX_0_VALID;
+
+    uniform sampler2D WATER_REFLECTION;
+    uniform sampler2D WATER_REFRACTION;
+    uniform sampler2D WATER_REFRACTION_DEPTH;
+    uniform float WATER_NORMAL_SCALE;
+    uniform vec2 WATER_NORMAL_OFFSET;
+    uniform float WATER_DISTORTION;
+    uniform float WATER_FRESNEL_BIAS;
+    uniform float WATER_DEEP_DEPTH;
+    uniform float WATER_FOAM_DISTANCE;
+    uniform vec4 WATER_FOAM_COLOR;
+    uniform vec2 WATER_NEAR_FAR;
+    uniform mat4 WATER_REFRACTION_INV_PROJECTION;
+
+    uniform vec3 LIGHT_DIRECTION[4];
+    uniform vec4 LIGHT_DIFFUSE[4];
+    uniform float LIGHT_SPECULAR_INTENSITY[4];
+    uniform int LIGHT_COUNT;
+
+    in vec3 vs_position_model;
+    in vec3 vs_normal_model;
+    in vec3 vs_camera_world;
+    in vec4 vs_clip_position;
+
+    out vec4 frag_color;
+
+    float LinearizeDepth(float depth)
+    {
+    	float z = depth * 2.0 - 1.0;
+    	return 2.0 * WATER_NEAR_FAR.x * WATER_NEAR_FAR.y / (WATER_NEAR_FAR.y + WATER_NEAR_FAR.x - z * (WATER_NEAR_FAR.y - WATER_NEAR_FAR.x));
+    }
+
+    // RefractionDepth returns the view space distance to the ground in the
+    // refraction target, which was drawn with an oblique projection.
+    float RefractionDepth(vec2 uv)
+    {
+    	float depth = texture(WATER_REFRACTION_DEPTH, uv).r;
+    	vec4 view = WATER_REFRACTION_INV_PROJECTION * vec4(vec3(uv, depth) * 2.0 - 1.0, 1.0);
+    	return -view.z / view.w;
+    }
+
+    void main()
+    {
+    	// blend two layers of the normal map scrolling in different directions
+    	vec2 normal_uv = vs_position_model.xz / WATER_NORMAL_SCALE;
+    	vec2 detail = vec2(0.0);
+    	if (MATERIAL_TEX_NORMALS_VALID > 0.0) {
+    		vec3 n0 = texture(MATERIAL_TEX_NORMALS, normal_uv + WATER_NORMAL_OFFSET).rgb * 2.0 - 1.0;
+    		vec3 n1 = texture(MATERIAL_TEX_NORMALS, normal_uv * 1.7 - WATER_NORMAL_OFFSET * 0.5).rgb * 2.0 - 1.0;
+    		detail = (n0.xy + n1.xy) * 0.5;
+    	}
+    	vec3 normal = normalize(vs_normal_model + vec3(detail.x, 0.0, detail.y));
+
+    	vec2 screen_uv = (vs_clip_position.xy / vs_clip_position.w) * 0.5 + 0.5;
+    	vec2 distorted_uv = clamp(screen_uv + detail * WATER_DISTORTION, 0.001, 0.999);
+
+    	// the depth of the water is the distance from the surface to the
+    	// ground behind it in the refraction target
+    	float ground_depth = RefractionDepth(screen_uv);
+    	float surface_depth = LinearizeDepth(gl_FragCoord.z);
+    	float water_depth = max(ground_depth - surface_depth, 0.0);
+
+    	vec3 refraction = texture(WATER_REFRACTION, distorted_uv).rgb;
+    	refraction = mix(refraction, MATERIAL_DIFFUSE.rgb, clamp(water_depth / WATER_DEEP_DEPTH, 0.0, 1.0));
+    	vec3 reflection = texture(WATER_REFLECTION, distorted_uv).rgb;
+
+    	vec3 to_camera = normalize(vs_camera_world - vs_position_model);
+    	float cos_theta = max(dot(to_camera, normal), 0.0);
+    	float fresnel = WATER_FRESNEL_BIAS + (1.0 - WATER_FRESNEL_BIAS) * pow(1.0 - cos_theta, 5.0);
+    	vec3 color = mix(refraction, reflection, fresnel);
+
+    	if (LIGHT_COUNT > 0 && MATERIAL_SHININESS > 0.0) {
+    		vec3 incidence = -normalize(LIGHT_DIRECTION[0]);
+    		vec3 reflected = reflect(-incidence, normal);
+    		float specularF = pow(max(dot(to_camera, reflected), 0.0), MATERIAL_SHININESS);
+    		color += LIGHT_DIFFUSE[0].rgb * LIGHT_SPECULAR_INTENSITY[0] * specularF;
+    	}
+
+    	if (WATER_FOAM_DISTANCE > 0.0) {
+    		float foam = 1.0 - clamp(water_depth / WATER_FOAM_DISTANCE, 0.0, 1.0);
+    		if (MATERIAL_TEX_0_VALID > 0.0) {
+    			foam *= texture(MATERIAL_TEX_0, normal_uv * 2.0 + WATER_NORMAL_OFFSET).r;
+    		}
+    		color = mix(color, WATER_FOAM_COLOR.rgb, foam * WATER_FOAM_COLOR.a);
+    	}
+
+    	frag_color = vec4(min(color, vec3(1.0)), 1.0);
+    }
+    `
+)
+
+// CreateWaterShader creates the shader that draws the water surface. It
+// needs the water's Binder to be passed when drawing.
+func CreateWaterShader() (*fizzle.RenderShader, error) {
+	return fizzle.LoadShaderProgram(WaterShaderV330, WaterShaderF330, nil)
+}
@@ -0,0 +1,178 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package water
+
+import (
+	"fmt"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+// DrawSceneFunc draws everything that should show up in the water, except
+// for the water itself, with the projection and view matrices given.
+type DrawSceneFunc func(projection mgl.Mat4, view mgl.Mat4)
+
+// RenderTarget is a framebuffer with a color texture and a depth texture
+// that the scene can be drawn into.
+type RenderTarget struct {
+	Framebuffer graphics.Buffer
+	Color       graphics.Texture
+	Depth       graphics.Texture
+	Width       int32
+	Height      int32
+}
+
+// NewRenderTarget creates a new render target of the size given.
+func NewRenderTarget(width, height int32) (*RenderTarget, error) {
+	gfx := fizzle.GetGraphics()
+	rt := new(RenderTarget)
+	rt.Width = width
+	rt.Height = height
+
+	rt.Color = gfx.GenTexture()
+	gfx.ActiveTexture(graphics.TEXTURE0)
+	gfx.BindTexture(graphics.TEXTURE_2D, rt.Color)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA, width, height, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, nil, 0)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+
+	rt.Depth = gfx.GenTexture()
+	gfx.BindTexture(graphics.TEXTURE_2D, rt.Depth)
+	gfx.TexImage2D(graphics.TEXTURE_2D, 0, graphics.DEPTH_COMPONENT24, width, height, 0, graphics.DEPTH_COMPONENT, graphics.UNSIGNED_INT, nil, 0)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.NEAREST)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	rt.Framebuffer = gfx.GenFramebuffer()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, rt.Framebuffer)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, rt.Color, 0)
+	gfx.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.DEPTH_ATTACHMENT, graphics.TEXTURE_2D, rt.Depth, 0)
+	status := gfx.CheckFramebufferStatus(graphics.FRAMEBUFFER)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	if status != graphics.FRAMEBUFFER_COMPLETE {
+		rt.Destroy()
+		return nil, fmt.Errorf("Failed to create the water render target. Code 0x%x", status)
+	}
+
+	return rt, nil
+}
+
+// Destroy releases the framebuffer and textures of the render target.
+func (rt *RenderTarget) Destroy() {
+	gfx := fizzle.GetGraphics()
+	gfx.DeleteFramebuffer(rt.Framebuffer)
+	gfx.DeleteTexture(rt.Color)
+	gfx.DeleteTexture(rt.Depth)
+}
+
+// begin binds the render target, sets the viewport to its size and clears it.
+func (rt *RenderTarget) begin(gfx graphics.GraphicsProvider) {
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, rt.Framebuffer)
+	gfx.Viewport(0, 0, rt.Width, rt.Height)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+}
+
+// end binds the default framebuffer and sets the viewport back to the
+// renderer's resolution.
+func (rt *RenderTarget) end(r renderer.Renderer) {
+	gfx := r.GetGraphics()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	width, height := r.GetResolution()
+	gfx.Viewport(0, 0, width, height)
+}
+
+// RenderReflection draws the scene into the Reflection target as it looks
+// mirrored in the water. The view is flipped under the water and the near
+// plane of the projection is moved onto the water so that nothing below
+// it shows up in the reflection. Mirroring the scene flips the winding of
+// the triangles, so front faces are culled while drawing.
+func (w *Water) RenderReflection(r renderer.Renderer, projection mgl.Mat4, view mgl.Mat4, draw DrawSceneFunc) {
+	gfx := r.GetGraphics()
+	height := w.GetHeight()
+
+	// reflect across the plane y = height
+	mirror := mgl.Translate3D(0.0, height, 0.0).Mul4(mgl.Scale3D(1.0, -1.0, 1.0)).Mul4(mgl.Translate3D(0.0, -height, 0.0))
+	mirrorView := view.Mul4(mirror)
+	clipPlane := mgl.Vec4{0.0, 1.0, 0.0, -height + w.ClipBias}
+	mirrorProjection := getObliqueProjection(projection, mirrorView, clipPlane)
+
+	w.Reflection.begin(gfx)
+	gfx.CullFace(graphics.FRONT)
+	draw(mirrorProjection, mirrorView)
+	gfx.CullFace(graphics.BACK)
+	w.Reflection.end(r)
+}
+
+// RenderRefraction draws the scene below the water into the Refraction
+// target along with its depth, which the water shader uses to tint deep
+// water and to place the shoreline foam.
+func (w *Water) RenderRefraction(r renderer.Renderer, projection mgl.Mat4, view mgl.Mat4, draw DrawSceneFunc) {
+	gfx := r.GetGraphics()
+	height := w.GetHeight()
+
+	// the water shader linearizes the depth of its own surface with the near
+	// and far planes of the projection
+	w.nearFar = mgl.Vec2{projection[14] / (projection[10] - 1.0), projection[14] / (projection[10] + 1.0)}
+
+	clipPlane := mgl.Vec4{0.0, -1.0, 0.0, height + w.ClipBias}
+	refractProjection := getObliqueProjection(projection, view, clipPlane)
+
+	// the oblique near plane changes the depth values in a way that the near
+	// and far planes alone can't undo, so the shader gets the inverse of the
+	// actual projection for the depth of the refraction
+	w.refractionInvProjection = refractProjection.Inv()
+
+	w.Refraction.begin(gfx)
+	draw(refractProjection, view)
+	w.Refraction.end(r)
+}
+
+// getObliqueProjection returns the projection matrix with the near plane
+// replaced by the world space clip plane, which keeps the side of the
+// plane its normal points to. This clips the scene to the plane without
+// needing clip distances in every shader. See Eric Lengyel's "Oblique View
+// Frustum Depth Projection and Clipping".
+func getObliqueProjection(projection mgl.Mat4, view mgl.Mat4, plane mgl.Vec4) mgl.Mat4 {
+	// planes transform by the inverse transpose of the view matrix
+	c := view.Inv().Transpose().Mul4x1(plane)
+	if c[3] >= 0.0 {
+		// the camera is on the clipped side of the plane so moving the near
+		// plane would flip the frustum
+		return projection
+	}
+
+	q := mgl.Vec4{
+		(sign(c[0]) + projection[8]) / projection[0],
+		(sign(c[1]) + projection[9]) / projection[5],
+		-1.0,
+		(1.0 + projection[10]) / projection[14],
+	}
+	c = c.Mul(2.0 / c.Dot(q))
+
+	oblique := projection
+	oblique[2] = c[0]
+	oblique[6] = c[1]
+	oblique[10] = c[2] + 1.0
+	oblique[14] = c[3]
+	return oblique
+}
+
+// sign returns -1, 0 or 1 for the sign of v.
+func sign(v float32) float32 {
+	switch {
+	case v > 0.0:
+		return 1.0
+	case v < 0.0:
+		return -1.0
+	default:
+		return 0.0
+	}
+}
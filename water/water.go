@@ -0,0 +1,346 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package water renders animated water surfaces.
+
+The water is a flat grid that gets displaced by a few sine waves in the
+vertex shader and detailed with a scrolling normal map. The scene above the
+water is rendered into a reflection target from a camera mirrored below
+the surface and the scene below it is rendered into a refraction target.
+The water shader blends the two with a Fresnel term, tints deep water,
+and adds foam along the shore where the depth of the refraction target
+shows the ground is close to the surface.
+
+A simple example:
+
+	w, err := water.NewWater(200.0, 200.0, 64, 0.0, 512, 512)
+	...
+	w.Renderable.Material.Shader, _ = water.CreateWaterShader()
+	w.Renderable.Material.NormalsTex = waterNormalsTex
+
+	// every frame, before drawing the scene
+	w.Update(frameDelta)
+	w.RenderReflection(renderer, perspective, view, drawScene)
+	w.RenderRefraction(renderer, perspective, view, drawScene)
+
+	// then draw the scene and the water last
+	drawScene(perspective, view)
+	renderer.DrawRenderable(w.Renderable, w.Binder, perspective, view, camera)
+
+*/
+package water
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/renderer"
+)
+
+const (
+	// MaxWaves is the number of waves the water shader sums up.
+	MaxWaves = 4
+)
+
+// Wave is a sine wave moving across the water.
+type Wave struct {
+	// Direction is the direction on the XZ plane that the wave travels.
+	Direction mgl.Vec2
+
+	// Amplitude is the height of the wave crests above the surface.
+	Amplitude float32
+
+	// Wavelength is the distance between the wave crests.
+	Wavelength float32
+
+	// Speed is how fast the crests move in units per second.
+	Speed float32
+}
+
+// Water is a water surface along with the render targets it reflects and
+// refracts the scene from.
+type Water struct {
+	// Renderable is the surface of the water. Its Y location is the height
+	// of the water; it should not be rotated since the reflection and the
+	// refraction assume the water is level. The Material's NormalsTex is the
+	// detail normal map, DiffuseColor is the color of deep water and
+	// CustomTex[0] can be set to a foam texture.
+	Renderable *fizzle.Renderable
+
+	// Reflection holds the scene mirrored above the water and Refraction
+	// holds the scene below the water along with its depth.
+	Reflection *RenderTarget
+	Refraction *RenderTarget
+
+	// Waves are the sine waves that move the surface. Only the first
+	// MaxWaves are used.
+	Waves []Wave
+
+	// NormalScale is the size in world units that the normal map covers.
+	NormalScale float32
+
+	// NormalSpeed is the velocity the normal map scrolls at. A second layer
+	// of the normal map scrolls the other way at half the speed.
+	NormalSpeed mgl.Vec2
+
+	// Distortion is how far the normal map shifts the reflection and the
+	// refraction in screen space.
+	Distortion float32
+
+	// FresnelBias is the amount of reflection when looking straight down
+	// at the water.
+	FresnelBias float32
+
+	// DeepDepth is the depth at which the water is fully the diffuse color
+	// of the material.
+	DeepDepth float32
+
+	// FoamDistance is the depth of the water where the shoreline foam
+	// starts to fade in and FoamColor is the color of the foam.
+	FoamDistance float32
+	FoamColor    mgl.Vec4
+
+	// ClipBias moves the clip planes of the reflection and refraction
+	// passes a little to hide the seams at the edge of the water.
+	ClipBias float32
+
+	time    float32
+	nearFar mgl.Vec2
+
+	// refractionInvProjection is the inverse of the oblique projection the
+	// refraction was drawn with, which is needed to turn its depth back
+	// into a distance since the oblique near plane skews the depth values.
+	refractionInvProjection mgl.Mat4
+}
+
+// NewWater creates a water surface width by depth in size centered on the
+// origin at the height given. The surface is a grid with subdivisions
+// cells across each side so that the waves have vertices to move. The
+// render targets are created with the target size which is usually the
+// size of the window or half of it.
+func NewWater(width, depth float32, subdivisions int, height float32, targetWidth, targetHeight int32) (*Water, error) {
+	var err error
+	w := new(Water)
+	w.Reflection, err = NewRenderTarget(targetWidth, targetHeight)
+	if err != nil {
+		return nil, err
+	}
+	w.Refraction, err = NewRenderTarget(targetWidth, targetHeight)
+	if err != nil {
+		w.Reflection.Destroy()
+		return nil, err
+	}
+
+	w.Renderable = createGrid(width, depth, subdivisions)
+	w.Renderable.Location = mgl.Vec3{0.0, height, 0.0}
+	w.Renderable.Material = fizzle.NewMaterial()
+	w.Renderable.Material.DiffuseColor = mgl.Vec4{0.05, 0.2, 0.25, 1.0}
+	w.Renderable.Material.Shininess = 64.0
+
+	w.Waves = []Wave{
+		Wave{Direction: mgl.Vec2{1.0, 0.0}, Amplitude: 0.1, Wavelength: 8.0, Speed: 1.5},
+		Wave{Direction: mgl.Vec2{0.6, 0.8}, Amplitude: 0.05, Wavelength: 3.0, Speed: 1.0},
+	}
+	w.NormalScale = 8.0
+	w.NormalSpeed = mgl.Vec2{0.03, 0.02}
+	w.Distortion = 0.02
+	w.FresnelBias = 0.02
+	w.DeepDepth = 6.0
+	w.FoamDistance = 0.5
+	w.FoamColor = mgl.Vec4{1.0, 1.0, 1.0, 1.0}
+	w.ClipBias = 0.05
+	w.nearFar = mgl.Vec2{0.1, 1000.0}
+	w.refractionInvProjection = mgl.Perspective(mgl.DegToRad(60.0), 1.0, 0.1, 1000.0).Inv()
+	return w, nil
+}
+
+// Destroy releases the OpenGL objects of the water surface and its render
+// targets.
+func (w *Water) Destroy() {
+	w.Renderable.Destroy()
+	w.Reflection.Destroy()
+	w.Refraction.Destroy()
+}
+
+// Update advances the animation of the waves by frameDelta seconds.
+func (w *Water) Update(frameDelta float32) {
+	w.time += frameDelta
+}
+
+// GetHeight returns the height of the still water surface.
+func (w *Water) GetHeight() float32 {
+	return w.Renderable.Location[1]
+}
+
+// GetWaveHeightAt returns the height of the water surface at the world
+// space X and Z coordinates with the waves applied, matching the shader.
+func (w *Water) GetWaveHeightAt(x, z float32) float32 {
+	h := w.GetHeight()
+	for i, wave := range w.Waves {
+		if i >= MaxWaves {
+			break
+		}
+		if wave.Wavelength <= 0.0 || wave.Direction.Len() == 0.0 {
+			continue
+		}
+		dir := wave.Direction.Normalize()
+		k := 2.0 * math.Pi / float64(wave.Wavelength)
+		phase := k * (float64(dir.Dot(mgl.Vec2{x, z})) - float64(wave.Speed*w.time))
+		h += wave.Amplitude * float32(math.Sin(phase))
+	}
+	return h
+}
+
+// Binder is a renderer.RenderBinder that sets the water uniforms and binds
+// the render target textures. Pass it when drawing the Renderable.
+func (w *Water) Binder(r renderer.Renderer, renderable *fizzle.Renderable, shader *fizzle.RenderShader, texturesBound *int32) {
+	gfx := r.GetGraphics()
+
+	bindTexture(gfx, shader, "WATER_REFLECTION", w.Reflection.Color, texturesBound)
+	bindTexture(gfx, shader, "WATER_REFRACTION", w.Refraction.Color, texturesBound)
+	bindTexture(gfx, shader, "WATER_REFRACTION_DEPTH", w.Refraction.Depth, texturesBound)
+
+	// the wave phases are sent instead of the speeds so that the time
+	// doesn't lose precision on the GPU as it grows
+	waves := make([]float32, 0, MaxWaves*4)
+	phases := make([]float32, 0, MaxWaves)
+	for i, wave := range w.Waves {
+		if i >= MaxWaves {
+			break
+		}
+		if wave.Wavelength <= 0.0 || wave.Direction.Len() == 0.0 {
+			continue
+		}
+		dir := wave.Direction.Normalize()
+		k := 2.0 * math.Pi / float64(wave.Wavelength)
+		waves = append(waves, dir[0], dir[1], wave.Amplitude, float32(k))
+		phases = append(phases, float32(math.Mod(k*float64(wave.Speed*w.time), 2.0*math.Pi)))
+	}
+
+	shaderWaveCount := shader.GetUniformLocation("WATER_WAVE_COUNT")
+	if shaderWaveCount >= 0 {
+		gfx.Uniform1i(shaderWaveCount, int32(len(phases)))
+	}
+	shaderWaves := shader.GetUniformLocation("WATER_WAVES")
+	if shaderWaves >= 0 && len(waves) > 0 {
+		gfx.Uniform4fv(shaderWaves, waves)
+	}
+	shaderPhases := shader.GetUniformLocation("WATER_WAVE_PHASES")
+	if shaderPhases >= 0 && len(phases) > 0 {
+		gfx.Uniform1fv(shaderPhases, phases)
+	}
+
+	shaderNormalScale := shader.GetUniformLocation("WATER_NORMAL_SCALE")
+	if shaderNormalScale >= 0 {
+		gfx.Uniform1f(shaderNormalScale, w.NormalScale)
+	}
+	shaderNormalOffset := shader.GetUniformLocation("WATER_NORMAL_OFFSET")
+	if shaderNormalOffset >= 0 {
+		offset := w.NormalSpeed.Mul(w.time)
+		gfx.Uniform2f(shaderNormalOffset, float32(math.Mod(float64(offset[0]), 1.0)), float32(math.Mod(float64(offset[1]), 1.0)))
+	}
+	shaderDistortion := shader.GetUniformLocation("WATER_DISTORTION")
+	if shaderDistortion >= 0 {
+		gfx.Uniform1f(shaderDistortion, w.Distortion)
+	}
+	shaderFresnelBias := shader.GetUniformLocation("WATER_FRESNEL_BIAS")
+	if shaderFresnelBias >= 0 {
+		gfx.Uniform1f(shaderFresnelBias, w.FresnelBias)
+	}
+	shaderDeepDepth := shader.GetUniformLocation("WATER_DEEP_DEPTH")
+	if shaderDeepDepth >= 0 {
+		gfx.Uniform1f(shaderDeepDepth, w.DeepDepth)
+	}
+	shaderFoamDistance := shader.GetUniformLocation("WATER_FOAM_DISTANCE")
+	if shaderFoamDistance >= 0 {
+		gfx.Uniform1f(shaderFoamDistance, w.FoamDistance)
+	}
+	shaderFoamColor := shader.GetUniformLocation("WATER_FOAM_COLOR")
+	if shaderFoamColor >= 0 {
+		gfx.Uniform4f(shaderFoamColor, w.FoamColor[0], w.FoamColor[1], w.FoamColor[2], w.FoamColor[3])
+	}
+	shaderNearFar := shader.GetUniformLocation("WATER_NEAR_FAR")
+	if shaderNearFar >= 0 {
+		gfx.Uniform2f(shaderNearFar, w.nearFar[0], w.nearFar[1])
+	}
+	shaderRefractionInvProjection := shader.GetUniformLocation("WATER_REFRACTION_INV_PROJECTION")
+	if shaderRefractionInvProjection >= 0 {
+		gfx.UniformMatrix4fv(shaderRefractionInvProjection, 1, false, w.refractionInvProjection)
+	}
+}
+
+// bindTexture binds the texture to the next texture unit if the shader has
+// the uniform for it.
+func bindTexture(gfx graphics.GraphicsProvider, shader *fizzle.RenderShader, uniformName string, tex graphics.Texture, texturesBound *int32) {
+	shaderTex := shader.GetUniformLocation(uniformName)
+	if shaderTex < 0 {
+		return
+	}
+
+	gfx.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + uint32(*texturesBound)))
+	gfx.BindTexture(graphics.TEXTURE_2D, tex)
+	gfx.Uniform1i(shaderTex, *texturesBound)
+	*texturesBound++
+}
+
+// createGrid creates a flat grid on the XZ plane centered on the origin.
+func createGrid(width, depth float32, subdivisions int) *fizzle.Renderable {
+	const floatSize = 4
+	const uintSize = 4
+
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+	gfx := fizzle.GetGraphics()
+
+	r := fizzle.NewRenderable()
+	r.FaceCount = uint32(subdivisions * subdivisions * 2)
+	r.BoundingRect.Bottom = mgl.Vec3{-width * 0.5, 0.0, -depth * 0.5}
+	r.BoundingRect.Top = mgl.Vec3{width * 0.5, 0.0, depth * 0.5}
+
+	row := subdivisions + 1
+	vnutBuffer := make([]float32, 0, row*row*11)
+	for z := 0; z < row; z++ {
+		for x := 0; x < row; x++ {
+			u := float32(x) / float32(subdivisions)
+			v := float32(z) / float32(subdivisions)
+			vnutBuffer = append(vnutBuffer, (u-0.5)*width, 0.0, (v-0.5)*depth) // vert
+			vnutBuffer = append(vnutBuffer, 0.0, 1.0, 0.0)                     // normal
+			vnutBuffer = append(vnutBuffer, u, v)                              // uv
+			vnutBuffer = append(vnutBuffer, 1.0, 0.0, 0.0)                     // tangent
+		}
+	}
+
+	indexes := make([]uint32, 0, subdivisions*subdivisions*6)
+	for z := 0; z < subdivisions; z++ {
+		for x := 0; x < subdivisions; x++ {
+			i00 := uint32(z*row + x)
+			i10 := i00 + 1
+			i01 := i00 + uint32(row)
+			i11 := i01 + 1
+			indexes = append(indexes, i00, i01, i10, i10, i01, i11)
+		}
+	}
+
+	r.Core.VertVBO = gfx.GenBuffer()
+	r.Core.UvVBO = r.Core.VertVBO
+	r.Core.NormsVBO = r.Core.VertVBO
+	r.Core.TangentsVBO = r.Core.VertVBO
+	r.Core.VertVBOOffset = 0
+	r.Core.NormsVBOOffset = floatSize * 3
+	r.Core.UvVBOOffset = floatSize * 6
+	r.Core.TangentsVBOOffset = floatSize * 8
+	r.Core.VBOStride = floatSize * (3 + 3 + 2 + 3) // vert / normal / uv / tangent
+	gfx.BindBuffer(graphics.ARRAY_BUFFER, r.Core.VertVBO)
+	gfx.BufferData(graphics.ARRAY_BUFFER, floatSize*len(vnutBuffer), gfx.Ptr(&vnutBuffer[0]), graphics.STATIC_DRAW)
+
+	r.Core.ElementsVBO = gfx.GenBuffer()
+	gfx.BindBuffer(graphics.ELEMENT_ARRAY_BUFFER, r.Core.ElementsVBO)
+	gfx.BufferData(graphics.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gfx.Ptr(&indexes[0]), graphics.STATIC_DRAW)
+
+	return r
+}
@@ -0,0 +1,126 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package weather
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/particles"
+)
+
+// precipitationDefinition is the serialized form of a Precipitation. The
+// emitters are stored in the particle emitter JSON format.
+type precipitationDefinition struct {
+	Emitter json.RawMessage
+	Splash  json.RawMessage `json:",omitempty"`
+
+	SplashCount   uint
+	CameraOffset  mgl.Vec3
+	Wind          mgl.Vec3
+	Turbulence    float32
+	WindCoupling  float32
+	DropletAmount float32
+}
+
+// Save writes the settings of the precipitation and its emitters to a
+// JSON file.
+func (p *Precipitation) Save(filepath string) error {
+	var def precipitationDefinition
+	def.SplashCount = p.SplashCount
+	def.CameraOffset = p.CameraOffset
+	def.Wind = p.Wind
+	def.Turbulence = p.Turbulence
+	def.WindCoupling = p.WindCoupling
+	def.DropletAmount = p.DropletAmount
+
+	var err error
+	def.Emitter, err = p.Emitter.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if p.Splash != nil {
+		def.Splash, err = p.Splash.MarshalJSON()
+		if err != nil {
+			return err
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(&def, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the precipitation to JSON. %v", err)
+	}
+
+	err = ioutil.WriteFile(filepath, jsonBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write the precipitation file %s. %v", filepath, err)
+	}
+
+	return nil
+}
+
+// LoadPrecipitation creates a new Precipitation from a JSON file written by
+// Save and loads the textures of the emitters. The shader, created with
+// particles.VertShader330 and particles.FragShader330, is used for both
+// emitters.
+func LoadPrecipitation(gfx graphics.GraphicsProvider, filepath string, shader graphics.Program) (*Precipitation, error) {
+	jsonBytes, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the precipitation file %s. %v", filepath, err)
+	}
+
+	var def precipitationDefinition
+	err = json.Unmarshal(jsonBytes, &def)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the JSON for the precipitation. %v", err)
+	}
+
+	p := newPrecipitation(gfx)
+	p.SplashCount = def.SplashCount
+	p.CameraOffset = def.CameraOffset
+	p.Wind = def.Wind
+	p.Turbulence = def.Turbulence
+	p.WindCoupling = def.WindCoupling
+	p.DropletAmount = def.DropletAmount
+
+	err = loadEmitter(p.Emitter, def.Emitter, shader)
+	if err != nil {
+		p.Destroy()
+		return nil, err
+	}
+
+	if len(def.Splash) > 0 {
+		p.Splash = p.newSplashEmitter()
+		err = loadEmitter(p.Splash, def.Splash, shader)
+		if err != nil {
+			p.Destroy()
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// loadEmitter decodes the emitter from JSON, loads its texture if it has
+// one and sets the shader.
+func loadEmitter(e *particles.Emitter, jsonBytes []byte, shader graphics.Program) error {
+	err := e.UnmarshalJSON(jsonBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(e.Properties.TextureFilepath) > 0 {
+		err = e.LoadTexture()
+		if err != nil {
+			return err
+		}
+	}
+
+	e.Shader = shader
+	return nil
+}
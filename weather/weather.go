@@ -0,0 +1,210 @@
+// Copyright 2016, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+/*
+
+Package weather adds rain and snow that follow the camera around.
+
+Precipitation owns a particle System with an emitter for the falling rain or
+snow that is kept above the camera, an optional splash emitter that spawns
+particles where the drops hit the ground or a collider, and a wind force
+field that pushes the particles around. It can also drive a ScreenDroplets
+post effect so that rain runs down the lens when the camera looks up.
+
+A simple example:
+
+	rain := weather.NewRain(gfx, particleShader.Prog, rainTex)
+	rain.Emitter.Colliders = buildingColliders
+	rain.Droplets, _ = postfx.NewScreenDroplets()
+	stack.Add(rain.Droplets)
+
+	// every frame
+	rain.Wind = mgl.Vec3{4.0, 0.0, 1.0}
+	rain.Update(frameDelta, camera)
+	queue.AddParticleSystem(rain.System)
+
+Presets can be saved to and loaded from JSON files with the emitters stored
+in the same format that particles.Emitter.Save uses.
+
+*/
+package weather
+
+import (
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	fizzle "github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	"github.com/tbogdala/fizzle/particles"
+	"github.com/tbogdala/fizzle/postfx"
+)
+
+// Precipitation is rain or snow falling around the camera.
+type Precipitation struct {
+	// System contains the emitters and should be drawn with the scene.
+	System *particles.System
+
+	// Emitter spawns the falling particles. It is simulated in world space
+	// so the particles don't move with the camera once they have spawned.
+	Emitter *particles.Emitter
+
+	// Splash is the emitter that SplashCount particles are spawned on
+	// wherever the particles of Emitter collide with something, or nil.
+	Splash      *particles.Emitter
+	SplashCount uint
+
+	// CameraOffset is where the emitter is kept relative to the camera.
+	CameraOffset mgl.Vec3
+
+	// Wind is the direction and strength of the wind and Turbulence is the
+	// strength of the gusts added to it.
+	Wind       mgl.Vec3
+	Turbulence float32
+
+	// WindCoupling is how strongly the wind pushes the particles around,
+	// which is low for heavy rain drops and high for snow flakes.
+	WindCoupling float32
+
+	// Droplets is the screen effect driven by the precipitation, or nil.
+	Droplets *postfx.ScreenDroplets
+
+	// DropletAmount is the Amount given to Droplets when the camera looks
+	// straight up; it falls off as the camera looks down.
+	DropletAmount float32
+
+	windField *particles.WindField
+}
+
+// newPrecipitation creates a new Precipitation with an emitter that has
+// no properties set yet.
+func newPrecipitation(gfx graphics.GraphicsProvider) *Precipitation {
+	p := new(Precipitation)
+	p.System = particles.NewSystem(gfx)
+	p.windField = particles.NewWindField(mgl.Vec3{}, 0.0, 0.5)
+	p.System.ForceFields = append(p.System.ForceFields, p.windField)
+	p.WindCoupling = 1.0
+
+	p.Emitter = p.System.NewEmitter(nil)
+	p.Emitter.OnCollision = p.onCollision
+	return p
+}
+
+// onCollision spawns splashes where the falling particles hit something.
+func (p *Precipitation) onCollision(e *particles.Emitter, location, normal mgl.Vec3) {
+	if p.Splash != nil && p.SplashCount > 0 {
+		p.Splash.EmitAt(location, p.SplashCount)
+	}
+}
+
+// newSplashEmitter creates the splash emitter in the system with gravity
+// pulling the splashes back down.
+func (p *Precipitation) newSplashEmitter() *particles.Emitter {
+	splash := p.System.NewEmitter(nil)
+	splash.ForceFields = append(splash.ForceFields, particles.NewWindField(mgl.Vec3{0.0, -9.8, 0.0}, 0.0, 0.0))
+	return splash
+}
+
+// NewRain creates falling rain with splashes on the ground plane at a
+// height of 0. Add colliders to Emitter for the rain to splash on other
+// surfaces. The shader should be created with particles.VertShader330 and
+// particles.FragShader330 and is used for both emitters, and the texture
+// is used for both the drops and the splashes.
+func NewRain(gfx graphics.GraphicsProvider, shader graphics.Program, texture graphics.Texture) *Precipitation {
+	p := newPrecipitation(gfx)
+	p.CameraOffset = mgl.Vec3{0.0, 12.0, 0.0}
+	p.WindCoupling = 0.3
+	p.Turbulence = 0.5
+	p.DropletAmount = 0.4
+
+	props := &p.Emitter.Properties
+	props.MaxParticles = 4000
+	props.SpawnRate = 2000
+	props.Velocity = mgl.Vec3{0, -1, 0}
+	props.Speed = 14.0
+	props.TTL = 1.5
+	props.Color = mgl.Vec4{0.7, 0.75, 0.8, 0.5}
+	props.Size = 4.0
+	props.SimulationSpace = particles.SimulationWorld
+	props.CollisionMode = particles.CollisionKill
+	props.GroundCollision = true
+	p.Emitter.Spawner = particles.NewCubeSpawner(p.Emitter, mgl.Vec3{-20, -1, -20}, mgl.Vec3{20, 1, 20})
+	p.Emitter.Shader = shader
+	p.Emitter.Texture = texture
+
+	p.Splash = p.newSplashEmitter()
+	p.SplashCount = 2
+	props = &p.Splash.Properties
+	props.MaxParticles = 2000
+	props.Speed = 1.5
+	props.TTL = 0.25
+	props.Color = mgl.Vec4{0.8, 0.85, 0.9, 0.6}
+	props.Size = 3.0
+	props.SimulationSpace = particles.SimulationWorld
+	props.ColorOverLife = fizzle.NewGradient(mgl.Vec4{1, 1, 1, 1}, mgl.Vec4{1, 1, 1, 0})
+	p.Splash.Spawner = particles.NewSphereSpawner(p.Splash, 0.05, true)
+	p.Splash.Shader = shader
+	p.Splash.Texture = texture
+
+	return p
+}
+
+// NewSnow creates slowly falling snow that drifts with the wind and melts
+// away on the ground plane at a height of 0. The shader should be created
+// with particles.VertShader330 and particles.FragShader330.
+func NewSnow(gfx graphics.GraphicsProvider, shader graphics.Program, texture graphics.Texture) *Precipitation {
+	p := newPrecipitation(gfx)
+	p.CameraOffset = mgl.Vec3{0.0, 8.0, 0.0}
+	p.WindCoupling = 1.0
+	p.Turbulence = 1.0
+
+	props := &p.Emitter.Properties
+	props.MaxParticles = 6000
+	props.SpawnRate = 500
+	props.Velocity = mgl.Vec3{0, -1, 0}
+	props.Speed = 1.0
+	props.TTL = 10.0
+	props.Color = mgl.Vec4{1.0, 1.0, 1.0, 0.9}
+	props.Size = 6.0
+	props.SimulationSpace = particles.SimulationWorld
+	props.CollisionMode = particles.CollisionKill
+	props.GroundCollision = true
+	props.Prewarm = true
+	p.Emitter.Spawner = particles.NewCubeSpawner(p.Emitter, mgl.Vec3{-15, -1, -15}, mgl.Vec3{15, 1, 15})
+	p.Emitter.Shader = shader
+	p.Emitter.Texture = texture
+
+	return p
+}
+
+// Destroy removes the emitters from the system, which releases their
+// graphics objects. Textures and shaders are left alone.
+func (p *Precipitation) Destroy() {
+	for len(p.System.Emitters) > 0 {
+		p.System.RemoveEmitter(p.System.Emitters[0])
+	}
+	p.Emitter = nil
+	p.Splash = nil
+}
+
+// Update moves the emitter over the camera, applies the wind, updates the
+// particle system and sets the amount of screen droplets for the direction
+// the camera is looking in.
+func (p *Precipitation) Update(frameDelta float64, camera fizzle.Camera) {
+	p.System.Origin = camera.GetPosition().Add(p.CameraOffset)
+	p.windField.Force = p.Wind.Mul(p.WindCoupling)
+	p.windField.Turbulence = p.Turbulence * p.WindCoupling
+	p.System.Update(frameDelta)
+
+	if p.Droplets != nil {
+		// the camera looks down the negative Z axis of the view
+		forward := camera.GetViewMatrix().Row(2).Vec3().Mul(-1.0)
+		amount := p.DropletAmount * mgl.Clamp(0.5+forward[1], 0.0, 1.0)
+		if !p.System.IsEmitting {
+			amount = 0.0
+		}
+
+		// ease toward the amount so drops don't appear all at once
+		blend := mgl.Clamp(float32(frameDelta), 0.0, 1.0)
+		p.Droplets.Amount += (amount - p.Droplets.Amount) * blend
+		p.Droplets.Update(float32(frameDelta))
+	}
+}